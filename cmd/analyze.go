@@ -6,26 +6,40 @@ import (
 	"fmt"
 	"img-cli/pkg/errors"
 	"img-cli/pkg/logger"
+	"img-cli/pkg/output"
+	"img-cli/pkg/vocab"
 	"img-cli/pkg/workflow"
+	"img-cli/pkg/workspace"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
 
 var (
 	analyzeNoCache bool
+	analyzeRefresh bool
 	analyzeType    string
+	analyzeOutput  string
 )
 
 // analyzeCmd represents the analyze command
 var analyzeCmd = &cobra.Command{
 	Use:   "analyze <image-path>",
-	Short: "Analyze an image for outfit, visual style, or art style",
-	Long: `Analyze an image to extract detailed information about outfits,
-visual/photographic styles, or artistic styles.
-
-The analysis results are cached by default to improve performance.`,
+	Short: "Analyze an image and print or save the component JSON",
+	Long: `Analyze an image with the same analyzers the workflows use and print
+the raw JSON for each component: outfit, visual_style, art_style, hair_style,
+hair_color, makeup, expression, accessories, pose and background.
+
+The analysis results are cached by default, so analyzing references up front
+pre-warms the cache for later runs. --refresh analyzes again and overwrites
+the cached result; --no-cache neither reads nor writes the cache.
+
+Examples:
+  img-cli analyze outfits/suit.png --type outfit
+  img-cli analyze styles/night.png --type visual_style -o analyses/
+  img-cli analyze subjects/jaimee.png --type all --refresh`,
 	Args: cobra.ExactArgs(1),
 	RunE: runAnalyze,
 }
@@ -34,16 +48,24 @@ func init() {
 	rootCmd.AddCommand(analyzeCmd)
 
 	analyzeCmd.Flags().BoolVar(&analyzeNoCache, "no-cache", false, "Disable cache for this analysis")
-	analyzeCmd.Flags().StringVarP(&analyzeType, "type", "t", "", "Type of analysis: outfit, visual_style, art_style (default: all)")
+	analyzeCmd.Flags().BoolVar(&analyzeRefresh, "refresh", false, "Analyze again and overwrite the cached result")
+	analyzeCmd.Flags().StringVarP(&analyzeType, "type", "t", "all", "Type of analysis: "+strings.Join(workflow.AnalysisTypes(), ", ")+" or all")
+	analyzeCmd.Flags().StringVarP(&analyzeOutput, "output", "o", "", "Save each analysis as <image>.<type>.json in this directory")
 }
 
 func runAnalyze(cmd *cobra.Command, args []string) error {
-	imagePath := args[0]
+	imagePath := workspace.Resolve(args[0])
 
 	// Validate input
 	if _, err := os.Stat(imagePath); os.IsNotExist(err) {
 		return errors.ErrFileNotFound(imagePath)
 	}
+	if analyzeType == "" {
+		analyzeType = "all"
+	}
+	if analyzeType != "all" && !workflow.IsAnalysisType(analyzeType) {
+		return errors.ErrInvalidInput("type", fmt.Sprintf("unknown analysis type %q (use %s or all)", analyzeType, strings.Join(workflow.AnalysisTypes(), ", ")))
+	}
 
 	orchestrator := workflow.NewOrchestrator(apiKey)
 
@@ -51,44 +73,91 @@ func runAnalyze(cmd *cobra.Command, args []string) error {
 		orchestrator.SetCacheEnabled(false)
 		defer orchestrator.SetCacheEnabled(true)
 	}
+	orchestrator.SetCacheRefresh(analyzeRefresh)
 
 	logger.Info("Starting analysis",
 		"image", filepath.Base(imagePath),
 		"type", analyzeType)
 
 	// Perform analysis
-	if analyzeType == "" {
-		// Analyze all types
-		results, err := orchestrator.AnalyzeAll(imagePath)
+	types := []string{analyzeType}
+	results := make(map[string]json.RawMessage)
+	if analyzeType == "all" {
+		all, err := orchestrator.AnalyzeAll(imagePath)
 		if err != nil {
 			return errors.Wrap(err, errors.AnalysisError, "failed to analyze image")
 		}
-
-		// Print results
-		for typ, result := range results {
-			fmt.Printf("\n=== %s Analysis ===\n", typ)
-			printJSON(result)
-		}
+		types, results = workflow.AnalysisTypes(), all
 	} else {
-		// Analyze specific type
 		result, err := orchestrator.AnalyzeImage(analyzeType, imagePath)
 		if err != nil {
 			return errors.Wrapf(err, errors.AnalysisError, "failed to analyze %s", analyzeType)
 		}
+		results[analyzeType] = result
+	}
+
+	output.Set("analyses", results)
+	output.Count("analyses", len(results))
 
-		fmt.Printf("\n=== %s Analysis ===\n", analyzeType)
-		printJSON(result)
+	// Print results in a stable order
+	for _, typ := range types {
+		output.Printf("\n=== %s Analysis ===\n", typ)
+		printJSON(results[typ])
+		printVocabulary(typ, results[typ])
+	}
+
+	if analyzeOutput != "" {
+		if err := saveAnalyses(analyzeOutput, imagePath, types, results); err != nil {
+			return err
+		}
 	}
 
 	logger.Info("Analysis completed successfully")
 	return nil
 }
 
+// saveAnalyses writes each analysis to <dir>/<image>.<type>.json
+func saveAnalyses(dir, imagePath string, types []string, results map[string]json.RawMessage) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.Wrapf(err, errors.FileError, "failed to create %s", dir)
+	}
+	stem := strings.TrimSuffix(filepath.Base(imagePath), filepath.Ext(imagePath))
+	for _, typ := range types {
+		var formatted bytes.Buffer
+		if err := json.Indent(&formatted, results[typ], "", "  "); err != nil {
+			formatted.Reset()
+			formatted.Write(results[typ])
+		}
+		formatted.WriteByte('\n')
+		path := filepath.Join(dir, stem+"."+typ+".json")
+		if err := os.WriteFile(path, formatted.Bytes(), 0644); err != nil {
+			return errors.Wrapf(err, errors.FileError, "failed to save %s", path)
+		}
+		output.Printf("💾 Saved %s analysis to %s\n", typ, path)
+		output.AddFiles(path)
+	}
+	return nil
+}
+
+// printVocabulary shows an analysis mapped onto the controlled vocabulary
+func printVocabulary(analyzerType string, data json.RawMessage) {
+	terms := vocab.ForAnalysis(analyzerType, data, "")
+	if terms == nil {
+		return
+	}
+	normalized, err := json.Marshal(terms)
+	if err != nil {
+		return
+	}
+	output.Printf("\n=== %s Vocabulary ===\n", analyzerType)
+	printJSON(normalized)
+}
+
 func printJSON(data json.RawMessage) {
 	var formatted bytes.Buffer
 	if err := json.Indent(&formatted, data, "", "  "); err != nil {
-		fmt.Println(string(data))
+		output.Println(string(data))
 	} else {
-		fmt.Println(formatted.String())
+		output.Println(formatted.String())
 	}
 }
\ No newline at end of file
@@ -2,9 +2,16 @@ package cmd
 
 import (
 	"fmt"
+	"img-cli/pkg/cache"
+	"img-cli/pkg/config"
 	"img-cli/pkg/errors"
 	"img-cli/pkg/logger"
+	"img-cli/pkg/output"
 	"img-cli/pkg/workflow"
+	"img-cli/pkg/workspace"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -20,13 +27,196 @@ Available actions:
   clear              - Clear all cache entries
   clear-outfit       - Clear outfit analysis cache
   clear-visual_style - Clear visual style cache
-  clear-art_style    - Clear art style cache`,
+  clear-art_style    - Clear art style cache
+  migrate            - Move JSON cache files into the configured backend
+                       (IMG_CLI_CACHE_BACKEND=sqlite)
+
+Subcommands:
+  warm <dir>           - Analyze every image in a directory ahead of a run
+  show <type> <file>   - Print the cached analysis of one image
+  evict <type> <file>  - Remove the cached analysis of one image`,
 	Args: cobra.ExactArgs(1),
 	RunE: runCache,
 }
 
+var (
+	warmType    string
+	warmWorkers int
+	warmRefresh bool
+)
+
+// cacheWarmCmd pre-warms the analysis cache for a directory of references
+var cacheWarmCmd = &cobra.Command{
+	Use:   "warm <dir>",
+	Short: "Analyze every image in a directory into the cache",
+	Long: `Run an analyzer on every image in a directory (including subfolders) and
+store the results in the per-type cache, so the first big batch run finds
+them there. Images that are already cached are skipped unless --refresh is set.
+
+Images are analyzed concurrently; the analyze rate limit and concurrency
+(IMG_CLI_ANALYZE_RPS, IMG_CLI_ANALYZE_CONCURRENCY) still apply.
+
+Examples:
+  img-cli cache warm outfits/ --type outfit
+  img-cli cache warm styles/ --type visual_style --workers 8
+  img-cli cache warm hair-style/ --type hair_style --refresh`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCacheWarm,
+}
+
+// cacheShowCmd prints one cached analysis
+var cacheShowCmd = &cobra.Command{
+	Use:   "show <type> <file>",
+	Short: "Print the cached analysis of one image",
+	Long: `Print the cached analysis of one image with the cache file it lives in.
+Given a path, it shows the entry for that image's content; given a name
+that isn't on disk, it shows every entry cached under that name.
+
+Examples:
+  img-cli cache show outfit outfits/suit.png
+  img-cli cache show visual_style night.png`,
+	Args: cobra.ExactArgs(2),
+	Annotations: map[string]string{
+		annotationNoAPIKey: "true",
+	},
+	RunE: runCacheShow,
+}
+
+// cacheEvictCmd removes one cached analysis
+var cacheEvictCmd = &cobra.Command{
+	Use:   "evict <type> <file>",
+	Short: "Remove the cached analysis of one image",
+	Long: `Remove the cached analysis of one image, so the next run analyzes it
+again, without clearing the rest of the cache. Given a name that isn't on
+disk, every entry cached under that name is removed.
+
+Examples:
+  img-cli cache evict outfit outfits/suit.png`,
+	Args: cobra.ExactArgs(2),
+	Annotations: map[string]string{
+		annotationNoAPIKey: "true",
+	},
+	RunE: runCacheEvict,
+}
+
 func init() {
 	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheWarmCmd)
+	cacheCmd.AddCommand(cacheShowCmd)
+	cacheCmd.AddCommand(cacheEvictCmd)
+
+	cacheWarmCmd.Flags().StringVarP(&warmType, "type", "t", "", "Type of analysis: "+strings.Join(workflow.AnalysisTypes(), ", ")+" (required)")
+	cacheWarmCmd.Flags().IntVar(&warmWorkers, "workers", config.DefaultLimitsConfig().AnalyzeConcurrency, "Analyze up to N images at once")
+	cacheWarmCmd.Flags().BoolVar(&warmRefresh, "refresh", false, "Analyze cached images again and overwrite their entries")
+	cacheWarmCmd.MarkFlagRequired("type")
+}
+
+// analysisCache returns the cache holding analyses of the given type
+func analysisCache(analysisType string) (*cache.Cache, error) {
+	if !workflow.IsAnalysisType(analysisType) {
+		return nil, errors.ErrInvalidInput("type", fmt.Sprintf("unknown analysis type %q (use %s)", analysisType, strings.Join(workflow.AnalysisTypes(), ", ")))
+	}
+	return cache.NewCacheForType(analysisType, 0), nil
+}
+
+func runCacheShow(cmd *cobra.Command, args []string) error {
+	analysisType, file := args[0], workspace.Resolve(args[1])
+	c, err := analysisCache(analysisType)
+	if err != nil {
+		return err
+	}
+
+	entries := c.Entries(analysisType, file)
+	if len(entries) == 0 {
+		return errors.Newf(errors.CacheError, "no cached %s analysis for %s", analysisType, filepath.Base(file)).
+			WithContext("file", file)
+	}
+
+	output.Set("entries", entries)
+	for i, entry := range entries {
+		if i > 0 {
+			output.Println()
+		}
+		output.Printf("Cache file: %s\n", c.Location(entry.Key))
+		if entry.FilePath != "" {
+			output.Printf("Image:      %s\n", entry.FilePath)
+		}
+		output.Printf("Cached:     %s\n\n", entry.Timestamp.Local().Format("2006-01-02 15:04:05"))
+		printJSON(entry.Data)
+	}
+	return nil
+}
+
+func runCacheEvict(cmd *cobra.Command, args []string) error {
+	analysisType, file := args[0], workspace.Resolve(args[1])
+	c, err := analysisCache(analysisType)
+	if err != nil {
+		return err
+	}
+
+	evicted, err := c.Evict(analysisType, file)
+	if err != nil {
+		return errors.Wrap(err, errors.CacheError, "failed to evict cache entry")
+	}
+	output.Count("evicted", evicted)
+	if evicted == 0 {
+		output.Printf("No cached %s analysis for %s\n", analysisType, filepath.Base(file))
+		return nil
+	}
+	if evicted == 1 {
+		output.Printf("✓ Evicted the cached %s analysis for %s\n", analysisType, filepath.Base(file))
+	} else {
+		output.Printf("✓ Evicted %d cached %s analyses for %s\n", evicted, analysisType, filepath.Base(file))
+	}
+	logger.Info("Cache entries evicted", "type", analysisType, "file", filepath.Base(file), "entries", evicted)
+	return nil
+}
+
+func runCacheWarm(cmd *cobra.Command, args []string) error {
+	dir := workspace.Resolve(args[0])
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return errors.ErrInvalidInput("dir", fmt.Sprintf("not a directory: %s", args[0]))
+	}
+	if _, err := analysisCache(warmType); err != nil {
+		return err
+	}
+	if warmWorkers < 1 {
+		return errors.ErrInvalidInput("workers", "must be at least 1")
+	}
+
+	orchestrator := workflow.NewOrchestrator(apiKey)
+	orchestrator.SetCacheRefresh(warmRefresh)
+
+	output.Progress.Printf("🔥 Warming the %s cache from %s\n", warmType, dir)
+	logger.Info("Warming cache", "type", warmType, "dir", dir, "workers", warmWorkers, "refresh", warmRefresh)
+	result, err := orchestrator.WarmCache(dir, warmType, warmWorkers)
+	if err != nil {
+		return errors.Wrap(err, errors.CacheError, "failed to warm cache")
+	}
+
+	output.Count("images", result.Images)
+	output.Count("analyzed", result.Analyzed)
+	output.Count("cached", result.Cached)
+	output.Count("failed", len(result.Failures))
+	if len(result.Failures) > 0 {
+		output.Set("failures", result.Failures)
+	}
+	output.Printf("\n✓ %d image(s): %d analyzed, %d already cached", result.Images, result.Analyzed, result.Cached)
+	if len(result.Failures) > 0 {
+		output.Printf(", %d failed", len(result.Failures))
+	}
+	output.Println()
+	logger.Info("Cache warmed",
+		"type", warmType,
+		"images", result.Images,
+		"analyzed", result.Analyzed,
+		"cached", result.Cached,
+		"failed", len(result.Failures))
+
+	if result.Analyzed == 0 && len(result.Failures) > 0 {
+		return errors.Newf(errors.AnalysisError, "all %d analyses failed", len(result.Failures))
+	}
+	return nil
 }
 
 func runCache(cmd *cobra.Command, args []string) error {
@@ -53,17 +243,20 @@ func runCache(cmd *cobra.Command, args []string) error {
 			}
 		}
 
-		fmt.Println("Cache Statistics (All Locations):")
-		fmt.Printf("  Total entries: %d\n", totalEntries)
-		fmt.Printf("  Total size: %.2f MB\n", float64(totalSize)/1024/1024)
-		fmt.Println("\nCache locations:")
-		fmt.Println("  Outfit cache: outfits/cache")
-		fmt.Println("  Style caches: styles/cache")
+		output.Count("entries", totalEntries)
+		output.Set("size_bytes", totalSize)
+		output.Set("by_type", entriesByType)
+		output.Println("Cache Statistics (All Locations):")
+		output.Printf("  Total entries: %d\n", totalEntries)
+		output.Printf("  Total size: %.2f MB\n", float64(totalSize)/1024/1024)
+		output.Println("\nCache locations:")
+		output.Printf("  Outfit cache: %s\n", workspace.AssetCacheDir("outfits"))
+		output.Printf("  Style caches: %s\n", workspace.AssetCacheDir("styles"))
 
 		if len(entriesByType) > 0 {
-			fmt.Println("\nEntries by type:")
+			output.Println("\nEntries by type:")
 			for typ, count := range entriesByType {
-				fmt.Printf("    %s: %d\n", typ, count)
+				output.Printf("    %s: %d\n", typ, count)
 			}
 		}
 
@@ -71,6 +264,28 @@ func runCache(cmd *cobra.Command, args []string) error {
 			"entries", totalEntries,
 			"size_mb", float64(totalSize)/1024/1024)
 
+	case "migrate":
+		// Move file entries into the configured store
+		if config.CacheBackend() == config.CacheBackendFile {
+			output.Println("The cache backend is file (IMG_CLI_CACHE_BACKEND); nothing to migrate")
+			return nil
+		}
+		moved := 0
+		for _, cacheType := range append([]string{"subject_check"}, workflow.AnalysisTypes()...) {
+			c := cache.NewCacheForType(cacheType, 0)
+			if c.Backend() != config.CacheBackend() {
+				return errors.Newf(errors.ConfigError, "the %s cache backend is unavailable (see the warning above)", config.CacheBackend())
+			}
+			n, err := c.ImportFiles()
+			moved += n
+			if err != nil {
+				return errors.Wrapf(err, errors.CacheError, "failed to migrate the %s cache", cacheType)
+			}
+		}
+		output.Count("migrated", moved)
+		output.Printf("✓ Moved %d cache file(s) into the %s cache\n", moved, config.CacheBackend())
+		logger.Info("Cache migrated", "backend", config.CacheBackend(), "entries", moved)
+
 	case "clear":
 		// Clear all caches
 		for _, cacheType := range []string{"outfit", "visual_style", "art_style"} {
@@ -79,7 +294,7 @@ func runCache(cmd *cobra.Command, args []string) error {
 				logger.Warn("Failed to clear cache", "type", cacheType, "error", err)
 			}
 		}
-		fmt.Println("✓ All caches cleared successfully")
+		output.Println("✓ All caches cleared successfully")
 		logger.Info("All caches cleared")
 
 	case "clear-outfit":
@@ -87,7 +302,7 @@ func runCache(cmd *cobra.Command, args []string) error {
 		if err := cache.ClearType("outfit"); err != nil {
 			return errors.Wrap(err, errors.CacheError, "failed to clear outfit cache")
 		}
-		fmt.Println("✓ Outfit cache cleared successfully (outfits/cache)")
+		output.Printf("✓ Outfit cache cleared successfully (%s)\n", workspace.AssetCacheDir("outfits"))
 		logger.Info("Outfit cache cleared")
 
 	case "clear-visual_style":
@@ -95,7 +310,7 @@ func runCache(cmd *cobra.Command, args []string) error {
 		if err := cache.ClearType("visual_style"); err != nil {
 			return errors.Wrap(err, errors.CacheError, "failed to clear visual style cache")
 		}
-		fmt.Println("✓ Visual style cache cleared successfully (styles/cache)")
+		output.Printf("✓ Visual style cache cleared successfully (%s)\n", workspace.AssetCacheDir("styles"))
 		logger.Info("Visual style cache cleared")
 
 	case "clear-art_style":
@@ -103,7 +318,7 @@ func runCache(cmd *cobra.Command, args []string) error {
 		if err := cache.ClearType("art_style"); err != nil {
 			return errors.Wrap(err, errors.CacheError, "failed to clear art style cache")
 		}
-		fmt.Println("✓ Art style cache cleared successfully (styles/cache)")
+		output.Printf("✓ Art style cache cleared successfully (%s)\n", workspace.AssetCacheDir("styles"))
 		logger.Info("Art style cache cleared")
 
 	default:
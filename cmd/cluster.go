@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"fmt"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/logger"
+	"img-cli/pkg/output"
+	"img-cli/pkg/workflow"
+	"img-cli/pkg/workspace"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	clusterThreshold int
+	clusterReport    string
+)
+
+// clusterCmd groups a run's outputs into distinct looks
+var clusterCmd = &cobra.Command{
+	Use:   "cluster <output-dir>",
+	Short: "Group a run's outputs into distinct looks",
+	Long: `Group generated images into clusters of near-duplicates using perceptual
+hashes, and report how many distinct looks a run achieved.
+
+Outputs are also grouped by the combination they were generated from (read
+from each image's .json sidecar). A combination whose variations all landed in
+the same look is marked as saturated: generating more variations of it is
+unlikely to produce anything new.
+
+This command runs locally and does not call the Gemini API.
+
+Examples:
+  # Cluster one run and write clusters.json into it
+  img-cli cluster output/2024-01-15/143022
+
+  # Stricter matching (fewer differing bits count as the same look)
+  img-cli cluster output/2024-01-15 --threshold 6`,
+	Args: cobra.ExactArgs(1),
+	Annotations: map[string]string{
+		annotationNoAPIKey: "true",
+	},
+	RunE: runCluster,
+}
+
+func init() {
+	rootCmd.AddCommand(clusterCmd)
+
+	clusterCmd.Flags().IntVar(&clusterThreshold, "threshold", workflow.DefaultClusterThreshold, "Maximum hash distance in bits (0-64) for two images to count as the same look")
+	clusterCmd.Flags().StringVar(&clusterReport, "report", "", "Where to write the JSON report (default: <output-dir>/clusters.json)")
+}
+
+func runCluster(cmd *cobra.Command, args []string) error {
+	dir := workspace.Resolve(args[0])
+
+	info, err := os.Stat(dir)
+	if err != nil || !info.IsDir() {
+		return errors.ErrInvalidInput("output-dir", fmt.Sprintf("not a directory: %s", dir))
+	}
+	if clusterThreshold < 0 || clusterThreshold > 64 {
+		return errors.Newf(errors.ValidationError, "--threshold must be between 0 and 64, got %d", clusterThreshold)
+	}
+
+	report, err := workflow.BuildClusterReport(dir, clusterThreshold)
+	if err != nil {
+		return errors.Wrap(err, errors.FileError, "failed to cluster outputs")
+	}
+	output.Count("images", report.Images)
+	output.Count("looks", len(report.Looks))
+	if report.Images == 0 {
+		output.Printf("No images found in %s\n", dir)
+		return nil
+	}
+
+	output.Printf("🔍 %d images, %d distinct looks\n\n", report.Images, len(report.Looks))
+	for _, look := range report.Looks {
+		output.Printf("Look %d (%d images)\n", look.ID, len(look.Images))
+		for _, path := range look.Images {
+			output.Printf("   %s\n", relativeTo(dir, path))
+		}
+	}
+
+	output.Println("\nBy combination:")
+	saturated := 0
+	for _, combo := range report.Combinations {
+		note := ""
+		if combo.Saturated {
+			note = "  ← saturated, more variations unlikely to help"
+			saturated++
+		}
+		output.Printf("   %d variation(s) → %d look(s): %s%s\n", len(combo.Images), len(combo.Looks), combo.Recipe, note)
+	}
+	if saturated > 0 {
+		output.Printf("\n%d of %d combinations produced only one look\n", saturated, len(report.Combinations))
+	}
+	if len(report.Skipped) > 0 {
+		output.Printf("\nSkipped %d image(s) that could not be decoded\n", len(report.Skipped))
+	}
+
+	reportPath := clusterReport
+	if reportPath == "" {
+		reportPath = filepath.Join(dir, "clusters.json")
+	}
+	if err := report.Save(reportPath); err != nil {
+		return errors.Wrapf(err, errors.FileError, "failed to write report")
+	}
+	output.Printf("\nReport saved to: %s\n", reportPath)
+	output.AddFiles(reportPath)
+	output.Set("clusters", report)
+	logger.Info("Clustered outputs", "dir", dir, "images", report.Images, "looks", len(report.Looks))
+
+	return nil
+}
+
+// relativeTo shortens a path for display when it's inside dir
+func relativeTo(dir, path string) string {
+	if rel, err := filepath.Rel(dir, path); err == nil {
+		return rel
+	}
+	return path
+}
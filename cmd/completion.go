@@ -0,0 +1,295 @@
+package cmd
+
+import (
+	"fmt"
+	"img-cli/pkg/analyzer"
+	"img-cli/pkg/config"
+	"img-cli/pkg/library"
+	"img-cli/pkg/output"
+	"img-cli/pkg/prompts"
+	"img-cli/pkg/workflow"
+	"img-cli/pkg/workspace"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// assetChoice is a value a component flag takes, with what it stands for
+type assetChoice struct {
+	value       string
+	description string
+}
+
+// subjectChoices lists the registered subjects, then the subject images not
+// registered, by name
+func subjectChoices() []assetChoice {
+	var choices []assetChoice
+	seen := make(map[string]bool)
+	for _, registry := range workspace.SubjectRegistries() {
+		for _, subject := range registry.Subjects {
+			var description string
+			if len(subject.Aliases) > 0 {
+				description = "aka " + strings.Join(subject.Aliases, ", ")
+			}
+			choices = append(choices, assetChoice{subject.Name, description})
+			seen[strings.ToLower(subject.Name)] = true
+		}
+	}
+	for _, path := range workspace.Assets("subject") {
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		if !seen[strings.ToLower(name)] {
+			choices = append(choices, assetChoice{value: name})
+			seen[strings.ToLower(name)] = true
+		}
+	}
+	return choices
+}
+
+// assetChoices lists the reference images of a component kind by asset name
+// with their library tags, then its subfolders and tag selectors, which stand
+// for several references
+func assetChoices(kind string) []assetChoice {
+	if kind == "over-outfit" { // Shares outfits/
+		kind = "outfit"
+	}
+	catalog, err := library.Open(kind)
+	if err != nil {
+		return nil
+	}
+	items := catalog.Items()
+
+	names := make(map[string]int)
+	for _, item := range items {
+		names[item.Name]++
+	}
+	var choices, folders []assetChoice
+	tags := make(map[string]int)
+	folderImages := make(map[string]int)
+	for _, item := range items {
+		value := item.Name
+		if names[item.Name] > 1 {
+			value = item.Path // The name alone is ambiguous
+		}
+		choices = append(choices, assetChoice{value, strings.Join(item.Tags, ", ")})
+		for _, tag := range item.Tags {
+			tags[tag]++
+		}
+		folderImages[filepath.Dir(item.Path)]++
+	}
+
+	// Subfolders of the asset folder group references, like outfits/batch/
+	top := map[string]bool{
+		filepath.Clean(workspace.Path(workspace.AssetDirs[kind])):        true,
+		filepath.Clean(workspace.ProjectPath(workspace.AssetDirs[kind])): true,
+	}
+	for dir, count := range folderImages {
+		if !top[filepath.Clean(dir)] {
+			folders = append(folders, assetChoice{dir, fmt.Sprintf("folder, %d images", count)})
+		}
+	}
+	sort.Slice(folders, func(i, j int) bool { return folders[i].value < folders[j].value })
+	choices = append(choices, folders...)
+
+	var tagNames []string
+	for tag := range tags {
+		tagNames = append(tagNames, tag)
+	}
+	sort.Strings(tagNames)
+	for _, tag := range tagNames {
+		choices = append(choices, assetChoice{library.SelectorPrefix + tag, fmt.Sprintf("tag, %d images", tags[tag])})
+	}
+	return choices
+}
+
+// assetFlagKinds maps flags taking a reference whose name isn't its asset
+// kind (see workspace.AssetDirs) to the kind
+var assetFlagKinds = map[string]string{
+	"test":       "subject",
+	"outfit-ref": "outfit",
+	"style-ref":  "style",
+	"art-style":  "style",
+}
+
+// flagValues lists the fixed values of flags, by flag name
+var flagValues = map[string]func() []string{
+	"provider": func() []string { return []string{config.ProviderGemini, config.ProviderOpenAI, config.ProviderSD} },
+	"outfit-check": func() []string {
+		return []string{workflow.OutfitCheckWarn, workflow.OutfitCheckFill, workflow.OutfitCheckOff}
+	},
+	"organize-by":   func() []string { return workflow.OrganizeByValues },
+	"upscaler":      func() []string { return []string{workflow.UpscalerRegenerate, workflow.UpscalerResample} },
+	"ambient":       workflow.AmbientPresets,
+	"project":       projectNames,
+	"output-format": func() []string { return output.Formats },
+	"kind":          library.Kinds,
+}
+
+// registerCompletions adds dynamic shell completion to every command: flags
+// and arguments naming assets complete from the project's folders, others
+// from their fixed values. Cobra's completion command generates the shell
+// scripts. Call it once every command and flag is defined.
+func registerCompletions(root *cobra.Command) {
+	// Flags whose values depend on the command
+	analysisTypes := func() []string { return workflow.AnalysisTypes() }
+	commandFlags := map[*cobra.Command]map[string]func() []string{
+		analyzeCmd:   {"type": func() []string { return append(workflow.AnalysisTypes(), "all") }},
+		cacheWarmCmd: {"type": analysisTypes},
+		generateCmd:  {"type": func() []string { return []string{"outfit", "style_transfer", "art_style"} }},
+	}
+	args := map[*cobra.Command]cobra.CompletionFunc{
+		outfitSwapCmd:      firstArg(completeAsset("outfit")),
+		generateModularCmd: firstArg(completeAsset("subject")),
+		styleLUTCmd:        firstArg(completeAsset("style")),
+		promptsShowCmd:     firstArg(completeValues(prompts.Names)),
+		promptsExportCmd:   completeValues(prompts.Names),
+		projectSwitchCmd:   firstArg(completeValues(func() []string { return append(projectNames(), "none") })),
+		libraryListCmd:     firstArg(completeValues(library.Kinds)),
+		libraryThumbsCmd:   firstArg(completeValues(library.Kinds)),
+		libraryTagCmd:      completeLibraryTag,
+		cacheShowCmd:       firstArg(completeValues(analysisTypes)),
+		cacheEvictCmd:      firstArg(completeValues(analysisTypes)),
+	}
+
+	var walk func(cmd *cobra.Command)
+	walk = func(cmd *cobra.Command) {
+		if complete, ok := args[cmd]; ok && cmd.ValidArgsFunction == nil {
+			cmd.ValidArgsFunction = complete
+		}
+		register := func(flag string, complete cobra.CompletionFunc) {
+			if _, exists := cmd.GetFlagCompletionFunc(flag); !exists {
+				cmd.RegisterFlagCompletionFunc(flag, complete)
+			}
+		}
+		cmd.LocalFlags().VisitAll(func(f *pflag.Flag) {
+			switch {
+			case commandFlags[cmd][f.Name] != nil:
+				register(f.Name, completeValues(commandFlags[cmd][f.Name]))
+			case f.Name == "preset":
+				register(f.Name, cobra.FixedCompletions([]string{"json"}, cobra.ShellCompDirectiveFilterFileExt))
+			case flagValues[f.Name] != nil:
+				register(f.Name, completeValues(flagValues[f.Name]))
+			case assetFlagKinds[f.Name] != "":
+				register(f.Name, completeAsset(assetFlagKinds[f.Name]))
+			case workspace.AssetDirs[f.Name] != "":
+				register(f.Name, completeAsset(f.Name))
+			default:
+				if _, component, ok := analyzer.LookupComponent(f.Name); ok && component.TextOnly {
+					keywords := component.Keywords
+					register(f.Name, completeValues(func() []string { return keywords })) // Otherwise a description
+				}
+			}
+		})
+		for _, child := range cmd.Commands() {
+			walk(child)
+		}
+	}
+	walk(root)
+}
+
+// isCompletionCmd reports whether cmd generates completion scripts or
+// answers a shell's completion request; they need no API key
+func isCompletionCmd(cmd *cobra.Command) bool {
+	switch cmd.Name() {
+	case cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+		return true
+	}
+	for ; cmd != nil; cmd = cmd.Parent() {
+		if cmd.Name() == "completion" && cmd.Parent() != nil && !cmd.Parent().HasParent() {
+			return true
+		}
+	}
+	return false
+}
+
+// completeAsset completes a component value with the names, folders and tag
+// selectors of its references (see assetChoices), or with file paths once
+// the value looks like one
+func completeAsset(kind string) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if strings.ContainsAny(toComplete, "/\\") || strings.HasPrefix(toComplete, ".") {
+			return nil, cobra.ShellCompDirectiveDefault
+		}
+		var choices []assetChoice
+		if _, component, ok := analyzer.LookupComponent(kind); ok {
+			for _, keyword := range component.Keywords {
+				choices = append(choices, assetChoice{keyword, "keyword"})
+			}
+		}
+		if kind == "subject" {
+			choices = append(choices, subjectChoices()...)
+		} else {
+			choices = append(choices, assetChoices(kind)...)
+		}
+		var completions []cobra.Completion
+		for _, c := range choices {
+			if hasPrefixFold(c.value, toComplete) {
+				completions = append(completions, cobra.CompletionWithDesc(c.value, c.description))
+			}
+		}
+		if len(completions) == 0 {
+			return nil, cobra.ShellCompDirectiveDefault // Maybe a path outside the asset folders
+		}
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeValues completes from a list of fixed values
+func completeValues(values func() []string) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		var completions []cobra.Completion
+		for _, value := range values() {
+			if hasPrefixFold(value, toComplete) {
+				completions = append(completions, value)
+			}
+		}
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// firstArg completes the first argument with complete and leaves the others
+// to file completion
+func firstArg(complete cobra.CompletionFunc) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveDefault
+		}
+		return complete(cmd, args, toComplete)
+	}
+}
+
+// completeLibraryTag completes "library tag <kind> <name>" with the kinds,
+// then the references of the kind
+func completeLibraryTag(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	switch len(args) {
+	case 0:
+		return completeValues(library.Kinds)(cmd, args, toComplete)
+	case 1:
+		catalog, err := library.Open(args[0])
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		var names []string
+		for _, item := range catalog.Items() {
+			names = append(names, item.Name)
+		}
+		return completeValues(func() []string { return names })(cmd, args, toComplete)
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp // Tags are free text
+}
+
+// projectNames lists the projects by name
+func projectNames() []string {
+	projects, _ := workspace.Projects()
+	names := make([]string, len(projects))
+	for i, project := range projects {
+		names[i] = project.Name
+	}
+	return names
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
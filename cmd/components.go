@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"img-cli/pkg/analyzer"
+	"img-cli/pkg/errors"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// componentFlags holds the flag values of the components added with
+// analyzer.Register for one command, by analyzer type
+type componentFlags map[string]*string
+
+// addComponentFlags defines a flag for every registered component; accepts
+// says what the flag takes, e.g. "reference image or description"
+func addComponentFlags(cmd *cobra.Command, accepts string) componentFlags {
+	values := make(componentFlags)
+	for _, name := range analyzer.Components() {
+		_, component, _ := analyzer.LookupComponent(name)
+		help := strings.ToUpper(component.Help[:1]) + component.Help[1:]
+		if !component.TextOnly {
+			help += ": " + accepts
+		}
+		for _, keyword := range component.Keywords {
+			help += ", " + keyword
+		}
+		if component.Default != "" {
+			help += fmt.Sprintf(" (default %s)", component.Default)
+		}
+		values[name] = cmd.Flags().String(component.Flag, "", help)
+	}
+	return values
+}
+
+// assetFlags returns the flags for resolveAssetFlags and expandTagSelectors
+func (c componentFlags) assetFlags() []assetFlag {
+	var flags []assetFlag
+	for _, name := range analyzer.Components() {
+		if value, ok := c[name]; ok {
+			_, component, _ := analyzer.LookupComponent(name)
+			flags = append(flags, assetFlag{component.Flag, value})
+		}
+	}
+	return flags
+}
+
+// preserveBody applies --preserve-body to the body component: the subject's
+// build is locked by default, --preserve-body=false leaves it to the model,
+// and neither can be combined with --body-ref
+func (c componentFlags) preserveBody(cmd *cobra.Command, preserve bool) error {
+	ref, ok := c["body"]
+	if !ok || !cmd.Flags().Changed("preserve-body") {
+		return nil
+	}
+	if *ref != "" && *ref != "preserve" && *ref != "any" {
+		return errors.ErrInvalidInput("preserve-body", "can't be combined with --body-ref, which replaces the subject's build")
+	}
+	if preserve {
+		*ref = "preserve"
+	} else {
+		*ref = "any"
+	}
+	return nil
+}
+
+// refs returns the flags that are set by analyzer type, nil when none is
+func (c componentFlags) refs() map[string]string {
+	var refs map[string]string
+	for name, value := range c {
+		if *value == "" {
+			continue
+		}
+		if refs == nil {
+			refs = make(map[string]string)
+		}
+		refs[name] = *value
+	}
+	return refs
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"img-cli/pkg/dataset"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/output"
+	"img-cli/pkg/workflow"
+	"img-cli/pkg/workspace"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportFormat  string
+	exportOutput  string
+	exportClassBy string
+	exportTrigger string
+	exportFlagged bool
+)
+
+// exportCmd packages outputs into a training dataset
+var exportCmd = &cobra.Command{
+	Use:   "export <images or output dirs...>",
+	Short: "Export outputs as an image + caption training dataset",
+	Long: `Package generated images with captions into a dataset layout for LoRA or
+fine-tuning experiments.
+
+Captions are built from the component descriptions recorded in each image's
+.json sidecar (outfit, hair, makeup, expression, accessories, pose,
+background, style). Images without a sidecar, and images flagged by automated
+checks, are skipped.
+
+Formats:
+  jsonl    images/<name> plus metadata.jsonl with one {"image", "caption"} per line
+  folders  <class>/<name> with a <name>.txt caption next to each image,
+           where the class comes from --class-by
+
+This command runs locally and does not call the Gemini API.
+
+Examples:
+  # JSONL dataset from one run
+  img-cli export output/2024-01-15/143022 -o datasets/suits
+
+  # Folder per outfit with a trigger word for LoRA training
+  img-cli export output/2024-01-15 --format folders --class-by outfit --trigger "ohwx person" -o datasets/outfits`,
+	Args: cobra.MinimumNArgs(1),
+	Annotations: map[string]string{
+		annotationNoAPIKey: "true",
+	},
+	RunE: runExport,
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+
+	exportCmd.Flags().StringVar(&exportFormat, "format", dataset.FormatJSONL, "Dataset layout: jsonl or folders")
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "dataset", "Output directory")
+	exportCmd.Flags().StringVar(&exportClassBy, "class-by", "subject", "Component used as the class folder for --format folders (subject, outfit, style, ...)")
+	exportCmd.Flags().StringVar(&exportTrigger, "trigger", "", "Token prepended to every caption")
+	exportCmd.Flags().BoolVar(&exportFlagged, "include-flagged", false, "Also export images flagged for review")
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	switch exportFormat {
+	case dataset.FormatJSONL, dataset.FormatFolders:
+	default:
+		return errors.ErrInvalidInput("format", "must be jsonl or folders, got "+exportFormat)
+	}
+	if exportFormat == dataset.FormatFolders && !validClassBy(exportClassBy) {
+		return errors.ErrInvalidInput("class-by", "unknown component "+exportClassBy)
+	}
+
+	inputs := make([]string, len(args))
+	for i, arg := range args {
+		inputs[i] = workspace.Resolve(arg)
+	}
+
+	result, err := dataset.Export(inputs, dataset.Options{
+		Format:         exportFormat,
+		OutputDir:      exportOutput,
+		ClassBy:        exportClassBy,
+		Trigger:        exportTrigger,
+		IncludeFlagged: exportFlagged,
+	})
+	if err != nil {
+		return errors.Wrap(err, errors.FileError, "export failed")
+	}
+
+	for _, skipped := range result.Skipped {
+		output.Printf("  Skipped %s\n", skipped)
+	}
+	output.Printf("✓ Exported %d image(s) to %s (%s)\n", result.Exported, exportOutput, exportFormat)
+	output.AddFiles(exportOutput)
+	output.Count("exported", result.Exported)
+	output.Count("skipped", len(result.Skipped))
+	if len(result.Skipped) > 0 {
+		output.Set("skipped", result.Skipped)
+	}
+	return nil
+}
+
+// validClassBy checks --class-by against the recorded component names
+func validClassBy(name string) bool {
+	for _, component := range workflow.RecipeComponents() {
+		if name == component {
+			return true
+		}
+	}
+	return false
+}
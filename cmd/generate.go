@@ -1,11 +1,12 @@
 package cmd
 
 import (
-	"fmt"
 	"img-cli/pkg/errors"
 	"img-cli/pkg/generator"
 	"img-cli/pkg/logger"
+	"img-cli/pkg/output"
 	"img-cli/pkg/workflow"
+	"img-cli/pkg/workspace"
 	"os"
 	"path/filepath"
 	"strings"
@@ -22,6 +23,11 @@ var (
 	outputDir        string
 	temperature      float64
 	debugPrompt      bool
+	generateDryRun   bool
+	generateAspect   string
+	generateRes      int
+	generateStrength float64
+	generateNameTmpl string
 )
 
 // generateCmd represents the generate command
@@ -33,7 +39,8 @@ var generateCmd = &cobra.Command{
 Examples:
   img-cli generate portrait.jpg "business suit" --type outfit
   img-cli generate portrait.jpg --type outfit --outfit-ref outfits/suit.png
-  img-cli generate image.jpg "dramatic lighting" --type style_transfer`,
+  img-cli generate image.jpg "dramatic lighting" --type style_transfer
+  img-cli generate image.jpg "film noir" --type style_transfer --strength 0.3`,
 	Args: cobra.RangeArgs(1, 2),
 	RunE: runGenerate,
 }
@@ -48,6 +55,11 @@ func init() {
 	generateCmd.Flags().StringVarP(&outputDir, "output", "o", "", "Output directory (default: output/YYYY-MM-DD/HHMMSS)")
 	generateCmd.Flags().Float64Var(&temperature, "temperature", 0.7, "Generation temperature (0.0-1.0)")
 	generateCmd.Flags().BoolVar(&debugPrompt, "debug-prompt", false, "Show the generation prompt")
+	generateCmd.Flags().StringVar(&generateAspect, "aspect", "", "Aspect ratio of generated images: 9:16 (default), 1:1, 16:9, 4:5 or 3:2")
+	generateCmd.Flags().IntVar(&generateRes, "resolution", 0, "Long side of saved images in pixels; outputs are cropped and resized to match (0 = as generated)")
+	generateCmd.Flags().Float64Var(&generateStrength, "strength", 0, "How much the source image may change, 0-1: 0.2 is a subtle restyling, 1 a complete transformation; Stable Diffusion uses it as the denoising strength (0 = left to the model)")
+	generateCmd.Flags().StringVar(&generateNameTmpl, "name-template", "", "Output file name template relative to the output folder, e.g. \"{subject}/{outfit}-{date}\"; \"/\" makes subfolders (placeholders: subject, outfit, seed, date, time, timestamp)")
+	generateCmd.Flags().BoolVar(&generateDryRun, "dry-run", false, "Build the generation prompt and write it to a .prompt.txt file without generating the image")
 }
 
 func runGenerate(cmd *cobra.Command, args []string) error {
@@ -57,6 +69,17 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 		prompt = strings.Join(args[1:], " ")
 	}
 
+	var err error
+	if imagePath, err = workspace.ResolveAssetPath("subject", imagePath); err != nil {
+		return err
+	}
+	if outfitRef, err = workspace.ResolveAssetPath("outfit", outfitRef); err != nil {
+		return err
+	}
+	if styleRef, err = workspace.ResolveAssetPath("style", styleRef); err != nil {
+		return err
+	}
+
 	// Validate input
 	if _, err := os.Stat(imagePath); os.IsNotExist(err) {
 		return errors.ErrFileNotFound(imagePath)
@@ -74,15 +97,25 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	if err := workflow.ValidateFormat(generateAspect, generateRes); err != nil {
+		return err
+	}
+	if err := workflow.ValidateStrength(generateStrength); err != nil {
+		return err
+	}
+	if err := generator.ValidateNameTemplate(generateNameTmpl); err != nil {
+		return err
+	}
+
 	// Set default output directory if not specified
 	if outputDir == "" {
 		now := time.Now()
-		outputDir = filepath.Join("output",
+		outputDir = filepath.Join(workspace.OutputPath(),
 			now.Format("2006-01-02"),
 			now.Format("150405"))
 	}
 
-	orchestrator := workflow.NewOrchestrator(apiKey)
+	orchestrator := workflow.NewOrchestrator(apiKey, workflow.WithDryRun(generateDryRun))
 
 	logger.Info("Starting generation",
 		"type", generateType,
@@ -98,15 +131,25 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 		StyleReference:  styleRef,
 		Temperature:     temperature,
 		DebugPrompt:     debugPrompt,
+		Aspect:          generateAspect,
+		Resolution:      generateRes,
+		Strength:        generateStrength,
+		NameTemplate:    generateNameTmpl,
 	}
 
 	result, err := orchestrator.GenerateImage(generateType, params)
+	if orchestrator.Planned(err, outputDir, generateType+" "+filepath.Base(imagePath)) {
+		printDryRunSummary(orchestrator.DryRunPrompts())
+		return nil
+	}
 	if err != nil {
 		return errors.Wrap(err, errors.GenerationError, "failed to generate image")
 	}
 
-	fmt.Printf("✓ %s\n", result.Message)
-	fmt.Printf("Saved to: %s\n", result.OutputPath)
+	output.Printf("✓ %s\n", result.Message)
+	output.Printf("Saved to: %s\n", result.OutputPath)
+	output.AddFiles(result.OutputPath)
+	output.Count("images", 1)
 
 	logger.Info("Generation completed successfully",
 		"output", result.OutputPath)
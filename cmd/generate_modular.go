@@ -1,10 +1,17 @@
 package cmd
 
 import (
+	stderrors "errors"
 	"fmt"
+	"img-cli/pkg/config"
+	"img-cli/pkg/cost"
 	"img-cli/pkg/errors"
+	"img-cli/pkg/generator"
 	"img-cli/pkg/logger"
+	"img-cli/pkg/notify"
+	"img-cli/pkg/output"
 	"img-cli/pkg/workflow"
+	"img-cli/pkg/workspace"
 	"os"
 	"path/filepath"
 	"strings"
@@ -22,13 +29,54 @@ var (
 	modMakeupRef      string
 	modExpressionRef  string
 	modAccessoriesRef string
+	modPoseRef        string
+	modBackgroundRef  string
+	modExtraRefs      componentFlags // Components added with analyzer.Register
+	modPreserveBody   bool
 
 	// Target options
 	modSubjects      string
 	modVariations    int
 	modSendOriginal  bool
 	modNoConfirm     bool
+	modDryRun        bool
+	modProgress      string
 	modDebug         bool
+	modLUT           string
+	modFaceLock      bool
+	modVerifyColor   bool
+	modConsistency   bool
+	modSign          bool
+	modRefineAlt     bool
+	modColorTol      float64
+	modEnhance       bool
+	modStyleBlend    bool
+	modOutfitCheck   string
+	modImplausible   bool
+	modAmbient       string
+	modAvoid         string
+	modAspect        string
+	modResolution    int
+	modSeed          int64
+	modTemperature   float64
+	modTopK          int
+	modTopP          float64
+	modStrength      float64
+	modNameTemplate  string
+	modSkipExisting  bool
+	modPerson        string
+	modFor           []string
+	modUpscale       string
+	modUpscaler      string
+	modReview        bool
+	modJudge         bool
+	modBestOf        int
+	modValidateID    bool
+	modMinIdentity   float64
+	modIDRetries     int
+	modMaxAccess     int
+	modReport        string
+	modNotify        string
 )
 
 // generateModularCmd represents the new modular generation command
@@ -60,6 +108,18 @@ Examples:
     --hair-style "professional bun" \
     --expression "confident"
 
+  # Pose from one image, lighting and framing from another
+  img-cli generate-modular subjects/person.png \
+    --outfit outfits/suit.png \
+    --pose poses/hand-on-hip.png \
+    --style styles/street.png
+
+  # Environment independent of the style's framing and color grading
+  img-cli generate-modular subjects/person.png \
+    --outfit outfits/suit.png \
+    --background "rooftop bar overlooking a harbor" \
+    --style styles/night.png
+
   # Layered outfits (jacket from first outfit worn over complete second outfit)
   img-cli generate-modular subjects/person.png \
     --outfit outfits/punk-jacket.png \
@@ -67,6 +127,23 @@ Examples:
     --style styles/winter.png
   # Result: dress + only the jacket from punk-jacket outfit
 
+  # Lighting study: keep everything but the light fixed
+  img-cli generate-modular subjects/person.png \
+    --outfit outfits/suit.png \
+    --style styles/street.png \
+    --ambient sweep:golden-hour,noon,overcast,night-neon
+
+  # Group photo: dress the person on the left, give the one on the right a suit
+  img-cli generate-modular subjects/friends.png \
+    --person left --outfit outfits/dress.png \
+    --for right:outfit=outfits/suit.png --for "right:hair-color=silver" \
+    --style styles/night.png
+
+  # Weighted components: the outfit must win, the makeup is only a hint
+  img-cli generate-modular subjects/person.png \
+    --outfit outfits/coat.png:1.5 \
+    --makeup makeup/bold.png:0.5
+
 Component Input Types:
   - Subject: Image file only (required)
   - Style: Image file only
@@ -75,7 +152,12 @@ Component Input Types:
 Component Independence:
   - Each component is analyzed and applied independently
   - Unspecified components use the subject's natural appearance
-  - Components don't influence each other (e.g., outfit won't affect hair)`,
+  - Components don't influence each other (e.g., outfit won't affect hair)
+
+Component Weights:
+  - Any component value takes a ":weight" suffix from above 0 up to 3 (1 = normal)
+  - 1.5 and above: CRITICAL, wins when components conflict; above 1: high priority
+  - Below 1: lower priority; 0.5 and below: a subtle suggestion only`,
 	Args: cobra.ExactArgs(1),
 	RunE: runGenerateModular,
 }
@@ -86,28 +168,165 @@ func init() {
 	// Component flags
 	generateModularCmd.Flags().StringVar(&modOutfitRef, "outfit", "", "Outfit reference image")
 	generateModularCmd.Flags().StringVar(&modOverOutfitRef, "over-outfit", "", "Complete base outfit; main outfit's outer layer (jacket/coat) will be worn over this")
-	generateModularCmd.Flags().StringVar(&modStyleRef, "style", "", "Photo style reference image")
+	generateModularCmd.Flags().StringVar(&modStyleRef, "style", "", "Photo style reference image or description, e.g. \"1990s film noir, heavy grain, low-key lighting\"")
 	generateModularCmd.Flags().StringVar(&modHairStyleRef, "hair-style", "", "Hair style reference image")
 	generateModularCmd.Flags().StringVar(&modHairColorRef, "hair-color", "", "Hair color reference image")
 	generateModularCmd.Flags().StringVar(&modMakeupRef, "makeup", "", "Makeup reference image")
 	generateModularCmd.Flags().StringVar(&modExpressionRef, "expression", "", "Expression reference image")
 	generateModularCmd.Flags().StringVar(&modAccessoriesRef, "accessories", "", "Accessories reference image")
+	generateModularCmd.Flags().StringVar(&modBackgroundRef, "background", "", "Background/environment reference image (replaces the style's background)")
+	generateModularCmd.Flags().StringVar(&modPoseRef, "pose", "", "Body pose reference image (replaces the style's pose)")
+	modExtraRefs = addComponentFlags(generateModularCmd, "reference image or description")
+	generateModularCmd.Flags().BoolVar(&modPreserveBody, "preserve-body", true, "Keep the subject's own build and proportions across variations; false leaves the body to the model (can't be combined with --body-ref)")
 
 	// Generation options
 	generateModularCmd.Flags().IntVarP(&modVariations, "variations", "v", 1, "Number of variations to generate")
 	generateModularCmd.Flags().BoolVar(&modSendOriginal, "send-original", false, "Include reference images in API requests")
 	generateModularCmd.Flags().BoolVar(&modNoConfirm, "no-confirm", false, "Skip cost confirmation")
+	generateModularCmd.Flags().StringVar(&modProgress, "progress", "bar", "Progress output: bar (a bar line per image with timing, ETA and cost) or json (one JSON event per line)")
+	generateModularCmd.Flags().BoolVar(&modSkipExisting, "skip-existing", false, "Skip variations whose subject, components, variation number and prompt template match an image already under the output root (add one outfit and rerun without regenerating the rest)")
+	generateModularCmd.Flags().BoolVar(&modDryRun, "dry-run", false, "Build every generation prompt and write it to a .prompt.txt file with the image count and cost, without generating anything")
 	generateModularCmd.Flags().BoolVar(&modDebug, "debug", false, "Show debug information including prompts")
+	generateModularCmd.Flags().StringVar(&modLUT, "lut", "", "Apply a .cube LUT to each generated image (see 'style lut')")
+	generateModularCmd.Flags().BoolVar(&modFaceLock, "face-lock", false, "Paste the subject's face from its photo back over each generated image, blended and color-matched (one extra analysis per image)")
+	generateModularCmd.Flags().StringVar(&modOutfitCheck, "outfit-check", workflow.OutfitCheckWarn, "Missing bottoms/footwear in full-body styles: warn, fill (add neutral defaults) or off")
+	generateModularCmd.Flags().BoolVar(&modImplausible, "allow-implausible", false, "Generate even when garments clash with the style's scene (shearling coat on a beach)")
+	generateModularCmd.Flags().StringVar(&modAmbient, "ambient", "", "Generate the look under several lightings, e.g. sweep:golden-hour,noon,overcast,night-neon")
+	generateModularCmd.Flags().StringVar(&modAvoid, "avoid", "", "Comma-separated elements that must not appear, e.g. \"hats, sunglasses, visible logos\"")
+	generateModularCmd.Flags().StringVar(&modAspect, "aspect", "", "Aspect ratio of generated images: 9:16 (default), 1:1, 16:9, 4:5 or 3:2")
+	generateModularCmd.Flags().IntVar(&modResolution, "resolution", 0, "Long side of saved images in pixels; outputs are cropped and resized to match (0 = as generated)")
+	generateModularCmd.Flags().Int64Var(&modSeed, "seed", 0, "Generation seed, recorded in file names and the manifest; the same prompt and seed reproduce an image where the backend supports it, variation i uses seed+i (0 = random)")
+	generateModularCmd.Flags().Float64Var(&modTemperature, "temperature", 0, "Generation temperature, 0-2; lower sticks closer to the prompt (default: generation.temperature in the config, else the model's)")
+	generateModularCmd.Flags().IntVar(&modTopK, "top-k", 0, "Generation top-k sampling (default: generation.top_k in the config, else the model's)")
+	generateModularCmd.Flags().Float64Var(&modTopP, "top-p", 0, "Generation top-p sampling, 0-1 (default: generation.top_p in the config, else the model's)")
+	generateModularCmd.Flags().Float64Var(&modStrength, "strength", 0, "How much the subject photo may change, 0-1: 0.2 is a subtle restyling, 1 a complete transformation; Stable Diffusion uses it as the denoising strength (0 = left to the model)")
+	generateModularCmd.Flags().StringVar(&modNameTemplate, "name-template", "", "Output file name template relative to the output folder, e.g. \"{subject}/{outfit}-{style}-v{variation:2}\"; \"/\" makes subfolders, {name:N} zero-pads numbers (placeholders: subject, outfit, style, tag, hair-style, hair-color, makeup, expression, accessories, pose, background, variation, seed, date, time, timestamp)")
+	generateModularCmd.Flags().StringVar(&modPerson, "person", "", "Apply the components to one person of a group photo: their number from the left (1, 2, ...), left, center, right, or words from their description (\"red scarf\")")
+	generateModularCmd.Flags().StringArrayVar(&modFor, "for", nil, "Give one person of a group photo their own component, as person:component=value, e.g. right:outfit=outfits/suit.png (repeatable; components: outfit, hair-style, hair-color, makeup, expression, accessories)")
+	generateModularCmd.Flags().StringVar(&modUpscale, "upscale", "", "Also write an _upscaled copy of each image: 2x or 4x (print-ready outputs)")
+	generateModularCmd.Flags().StringVar(&modUpscaler, "upscaler", workflow.UpscalerRegenerate, "Upscaler for --upscale: regenerate (through the image model, billed like an image) or resample (local, free, adds no detail)")
+	generateModularCmd.Flags().IntVar(&modMaxAccess, "max-accessories", 0, "Keep only the N most important accessories in the prompt (0 = no limit)")
+	generateModularCmd.Flags().BoolVar(&modStyleBlend, "style-blend", false, "Blend the comma-separated --style references (images or folders) into one style, e.g. --style noir.png,pastel.png --style-blend (one extra cheap API call)")
+	generateModularCmd.Flags().BoolVar(&modEnhance, "enhance-text", false, "Expand text hair/makeup/expression descriptions into detailed ones (one extra cheap API call each)")
+	generateModularCmd.Flags().BoolVar(&modSign, "sign", false, "Embed signed C2PA content credentials in each image (certificate from IMG_CLI_C2PA_CERT/IMG_CLI_C2PA_KEY)")
+	generateModularCmd.Flags().BoolVar(&modRefineAlt, "refine-alt-text", false, "Polish each image's alt text with a text request (~1 cheap call per image)")
+	generateModularCmd.Flags().BoolVar(&modConsistency, "consistency", false, "Score identity and outfit color consistency across each combination's variations (one extra cheap API call per combination)")
+	generateModularCmd.Flags().BoolVar(&modReview, "review", false, "Label each image with the failure taxonomy (identity drift, wrong garment color, framing ignored, extra accessories); one extra cheap API call per image")
+	generateModularCmd.Flags().BoolVar(&modJudge, "judge", false, "Score each image for outfit fidelity, style fidelity and artifacts, and write ranking.txt best first (one extra cheap API call per image)")
+	generateModularCmd.Flags().IntVar(&modBestOf, "best-of", 1, "Generate N candidates per image and keep the best-scoring one (by --judge and/or --validate-identity; judged when neither is set); the others move to rejected/ and are billed like images")
+	generateModularCmd.Flags().StringVar(&modNotify, "notify", "", "POST a JSON run summary (counts, cost, output folder) to this Slack/Discord-compatible webhook when the run finishes or fails (default: IMG_CLI_NOTIFY_URL)")
+	generateModularCmd.Flags().StringVar(&modReport, "report", "", "Write a shareable gallery of the output folder: html (index.html with thumbnails, full-size links, references, prompts and generation times)")
+	generateModularCmd.Flags().BoolVar(&modValidateID, "validate-identity", false, "Score each image's face against the subject and record it in the manifest (one extra cheap API call per image)")
+	generateModularCmd.Flags().Float64Var(&modMinIdentity, "min-identity-score", 0, "Regenerate images whose identity score is below this (0-1, e.g. 0.7); implies --validate-identity, retries are billed like images")
+	generateModularCmd.Flags().IntVar(&modIDRetries, "identity-retries", workflow.DefaultIdentityRetries, "Regenerations allowed per image below --min-identity-score; the best attempt is kept")
+	generateModularCmd.Flags().BoolVar(&modVerifyColor, "verify-color", false, "Flag outputs whose colors don't match the style reference")
+	generateModularCmd.Flags().Float64Var(&modColorTol, "color-tolerance", config.DefaultVerifyConfig().ColorTolerance, "Maximum color distance (0-1) for --verify-color")
 }
 
-func runGenerateModular(cmd *cobra.Command, args []string) error {
-	subjectPath := args[0]
+func runGenerateModular(cmd *cobra.Command, args []string) (err error) {
+	notifier := newRunNotifier("generate-modular", modNotify)
+	defer func() { notifier.send(err) }()
+
+	subjectPath, err := workspace.ResolveAssetPath("subject", args[0])
+	if err != nil {
+		return err
+	}
+	flags := append([]assetFlag{
+		{"outfit", &modOutfitRef},
+		{"over-outfit", &modOverOutfitRef},
+		styleFlag(&modStyleRef, modStyleBlend),
+		{"hair-style", &modHairStyleRef},
+		{"hair-color", &modHairColorRef},
+		{"makeup", &modMakeupRef},
+		{"expression", &modExpressionRef},
+		{"accessories", &modAccessoriesRef},
+		{"pose", &modPoseRef},
+		{"background", &modBackgroundRef},
+	}, modExtraRefs.assetFlags()...)
+	weights := splitWeights(flags...)
+	if err := resolveAssetFlags(flags...); err != nil {
+		return err
+	}
+	if err := modExtraRefs.preserveBody(cmd, modPreserveBody); err != nil {
+		return err
+	}
+	modLUT = workspace.Resolve(modLUT)
 
 	// Validate subject exists
 	if !fileExists(subjectPath) {
 		return errors.ErrInvalidInput("subject", fmt.Sprintf("file not found: %s", subjectPath))
 	}
 
+	if err := validateLUTFlag(modLUT); err != nil {
+		return err
+	}
+	if err := validateOutfitCheckFlag(modOutfitCheck); err != nil {
+		return err
+	}
+	if err := validateMaxAccessoriesFlag(modMaxAccess); err != nil {
+		return err
+	}
+	if err := workflow.ValidateFormat(modAspect, modResolution); err != nil {
+		return err
+	}
+	upscale, err := workflow.ParseUpscale(modUpscale)
+	if err != nil {
+		return err
+	}
+	if err := workflow.ValidateUpscaler(modUpscaler); err != nil {
+		return err
+	}
+	if err := workflow.ValidateIdentityFlags(modMinIdentity, modIDRetries); err != nil {
+		return err
+	}
+	if err := workflow.ValidateBestOf(modBestOf); err != nil {
+		return err
+	}
+	if err := workflow.ValidateSeed(modSeed); err != nil {
+		return err
+	}
+	sampling, err := workflow.GenerationSampling(modTemperature, modTopK, modTopP)
+	if err != nil {
+		return err
+	}
+	if err := workflow.ValidateStrength(modStrength); err != nil {
+		return err
+	}
+	if err := generator.ValidateNameTemplate(modNameTemplate); err != nil {
+		return err
+	}
+	if err := workflow.ValidateReportFormat(modReport); err != nil {
+		return err
+	}
+	if err := notify.Validate(modNotify); err != nil {
+		return err
+	}
+	signOpts, err := signerOptions(modSign)
+	if err != nil {
+		return err
+	}
+	progressOpt, err := progressOption(modProgress)
+	if err != nil {
+		return err
+	}
+	ambients, err := workflow.ParseAmbient(modAmbient)
+	if err != nil {
+		return err
+	}
+	people, err := workflow.ParsePersonAssignments(modFor)
+	if err != nil {
+		return err
+	}
+	for i := range people {
+		for _, kind := range workflow.PersonComponentKinds {
+			if ref := people[i].Ref(kind); *ref != "" {
+				if err := resolveAssetFlags(assetFlag{kind, ref}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
 	// Log what components are being used
 	logger.Info("Starting modular generation",
 		"subject", filepath.Base(subjectPath),
@@ -115,83 +334,187 @@ func runGenerateModular(cmd *cobra.Command, args []string) error {
 
 	// Create workflow configuration
 	config := workflow.ModularConfig{
-		SubjectPath:    subjectPath,
-		OutfitRef:      modOutfitRef,
-		OverOutfitRef:  modOverOutfitRef,
-		StyleRef:       modStyleRef,
-		HairStyleRef:   modHairStyleRef,
-		HairColorRef:   modHairColorRef,
-		MakeupRef:      modMakeupRef,
-		ExpressionRef:  modExpressionRef,
-		AccessoriesRef: modAccessoriesRef,
-		Variations:     modVariations,
-		SendOriginal:   modSendOriginal,
-		Debug:          modDebug,
+		SubjectPath:      subjectPath,
+		OutfitRef:        modOutfitRef,
+		OverOutfitRef:    modOverOutfitRef,
+		StyleRef:         modStyleRef,
+		HairStyleRef:     modHairStyleRef,
+		HairColorRef:     modHairColorRef,
+		MakeupRef:        modMakeupRef,
+		ExpressionRef:    modExpressionRef,
+		AccessoriesRef:   modAccessoriesRef,
+		PoseRef:          modPoseRef,
+		BackgroundRef:    modBackgroundRef,
+		Extra:            modExtraRefs.refs(),
+		Weights:          weights,
+		Variations:       modVariations,
+		SendOriginal:     modSendOriginal,
+		Debug:            modDebug,
+		EnhanceText:      modEnhance,
+		StyleBlend:       modStyleBlend,
+		OutfitCheck:      modOutfitCheck,
+		MaxAccessories:   modMaxAccess,
+		AllowImplausible: modImplausible,
+		Avoid:            workflow.ParseAvoid(modAvoid),
+		Aspect:           modAspect,
+		Resolution:       modResolution,
+		Seed:             modSeed,
+		Sampling:         sampling,
+		Strength:         modStrength,
+		NameTemplate:     modNameTemplate,
+		SkipExisting:     modSkipExisting,
+		Person:           modPerson,
+		People:           people,
+		Post:             workflow.PostOptions{LUTPath: modLUT, Upscale: upscale, Upscaler: modUpscaler, FaceLock: modFaceLock},
+		Verify: workflow.VerifyOptions{
+			ColorCheck:       modVerifyColor,
+			Consistency:      modConsistency,
+			ColorTolerance:   modColorTol,
+			Review:           modReview,
+			Judge:            modJudge,
+			BestOf:           modBestOf,
+			Identity:         modValidateID,
+			MinIdentityScore: modMinIdentity,
+			IdentityRetries:  modIDRetries,
+		},
 	}
 
 	// Calculate cost
-	totalImages := modVariations
-	estimatedCost := float64(totalImages) * 0.04
+	generations := modVariations * max(1, len(ambients))
+	totalImages := generations + config.Post.ExtraImages(generations) + config.Verify.ExtraImages(generations)
+
+	// Each ambient run scores the consistency of its own variations
+	scoredRuns := 0
+	if modVariations > 1 {
+		scoredRuns = max(1, len(ambients))
+	}
+	calls := workflow.ExtraCalls(generations, modRefineAlt) + config.Verify.ExtraCalls(generations, scoredRuns)
 
 	// Always show cost breakdown
-	fmt.Printf("\n📊 Generation Cost Analysis:\n")
-	fmt.Printf("   Images to generate: %d\n", totalImages)
-	fmt.Printf("   Cost breakdown: %d images × $0.04 = $%.2f\n", totalImages, estimatedCost)
+	cost.PrintEstimate("Generation Cost Analysis", totalImages)
+	cost.PrintCalls(calls)
 
 	// Show which components will be applied
-	fmt.Println("\n🎨 Components to apply:")
+	output.Progress.Println("\n🎨 Components to apply:")
 	if modOutfitRef != "" {
-		fmt.Printf("   ✓ Outfit: %s\n", filepath.Base(modOutfitRef))
+		output.Progress.Printf("   ✓ Outfit: %s\n", filepath.Base(modOutfitRef))
 	}
 	if modOverOutfitRef != "" {
-		fmt.Printf("   ✓ Over-outfit: %s\n", filepath.Base(modOverOutfitRef))
+		output.Progress.Printf("   ✓ Over-outfit: %s\n", filepath.Base(modOverOutfitRef))
 	}
 	if modStyleRef != "" {
-		fmt.Printf("   ✓ Style: %s\n", filepath.Base(modStyleRef))
+		output.Progress.Printf("   ✓ Style: %s\n", filepath.Base(modStyleRef))
 	}
 	if modHairStyleRef != "" {
-		fmt.Printf("   ✓ Hair Style: %s\n", filepath.Base(modHairStyleRef))
+		output.Progress.Printf("   ✓ Hair Style: %s\n", filepath.Base(modHairStyleRef))
 	}
 	if modHairColorRef != "" {
-		fmt.Printf("   ✓ Hair Color: %s\n", filepath.Base(modHairColorRef))
+		output.Progress.Printf("   ✓ Hair Color: %s\n", filepath.Base(modHairColorRef))
 	}
 	if modMakeupRef != "" {
-		fmt.Printf("   ✓ Makeup: %s\n", filepath.Base(modMakeupRef))
+		output.Progress.Printf("   ✓ Makeup: %s\n", filepath.Base(modMakeupRef))
 	}
 	if modExpressionRef != "" {
-		fmt.Printf("   ✓ Expression: %s\n", filepath.Base(modExpressionRef))
+		output.Progress.Printf("   ✓ Expression: %s\n", filepath.Base(modExpressionRef))
 	}
 	if modAccessoriesRef != "" {
-		fmt.Printf("   ✓ Accessories: %s\n", filepath.Base(modAccessoriesRef))
+		output.Progress.Printf("   ✓ Accessories: %s\n", filepath.Base(modAccessoriesRef))
+	}
+	if modPoseRef != "" {
+		output.Progress.Printf("   ✓ Pose: %s\n", filepath.Base(modPoseRef))
+	}
+	if modBackgroundRef != "" {
+		output.Progress.Printf("   ✓ Background: %s\n", filepath.Base(modBackgroundRef))
+	}
+	for _, flag := range modExtraRefs.assetFlags() {
+		if *flag.value != "" {
+			output.Progress.Printf("   ✓ %s: %s\n", strings.ToUpper(flag.kind[:1])+flag.kind[1:], filepath.Base(*flag.value))
+		}
+	}
+	if modPerson != "" {
+		output.Progress.Printf("   ✓ Person: %s\n", modPerson)
+	}
+	for _, person := range people {
+		for _, kind := range workflow.PersonComponentKinds {
+			if ref := person.Ref(kind); *ref != "" {
+				output.Progress.Printf("   ✓ For %s: %s %s\n", person.Person, kind, filepath.Base(*ref))
+			}
+		}
+	}
+	if len(ambients) > 0 {
+		output.Progress.Printf("   ✓ Ambients: %s\n", strings.Join(ambients, ", "))
+	}
+	if len(config.Avoid) > 0 {
+		output.Progress.Printf("   ✓ Avoiding: %s\n", strings.Join(config.Avoid, ", "))
+	}
+	if modAspect != "" || modResolution > 0 {
+		output.Progress.Printf("   ✓ Format: %s\n", workflow.FormatLabel(modAspect, modResolution))
+	}
+	if upscale > 0 {
+		output.Progress.Printf("   ✓ Upscale: %dx (%s)\n", upscale, modUpscaler)
+	}
+	if modBestOf > 1 {
+		output.Progress.Printf("   ✓ Best of: %d candidates per image\n", modBestOf)
+	}
+	if modMinIdentity > 0 {
+		output.Progress.Printf("   ✓ Min identity score: %.2f (up to %d retries per image)\n", modMinIdentity, modIDRetries)
 	}
 
-	// Only ask for confirmation if cost exceeds $5 (unless --no-confirm is used)
-	if !modNoConfirm && estimatedCost > 5.00 {
-		fmt.Printf("\n⚠️  This will cost more than $5 ($%.2f)\n", estimatedCost)
-		fmt.Print("   Proceed? (y/N): ")
-		var response string
-		fmt.Scanln(&response)
-		if strings.ToLower(response) != "y" {
-			fmt.Println("❌ Generation cancelled by user")
+	// Refuse runs over the budget cap and confirm expensive ones
+	if err := cost.Check(totalImages, cost.CheckOptions{Calls: calls, SkipConfirm: modNoConfirm, DryRun: modDryRun}); err != nil {
+		if stderrors.Is(err, cost.ErrCancelled) {
 			return nil
 		}
+		return err
 	}
 
 	// Create orchestrator and run workflow
-	orchestrator := workflow.NewOrchestrator(apiKey)
+	orchestrator := workflow.NewOrchestrator(apiKey, append(signOpts, workflow.WithAltTextRefinement(modRefineAlt), workflow.WithDryRun(modDryRun), progressOpt)...)
 
-	// Run the modular workflow
-	results, err := orchestrator.RunModularWorkflow(config)
+	// Run the modular workflow, once per ambient for a sweep
+	if !modDryRun {
+		notifier.start(config.OutputDir)
+	}
+	var results []string
+	if len(ambients) > 0 {
+		results, err = orchestrator.RunAmbientSweep(config, ambients)
+	} else {
+		results, err = orchestrator.RunModularWorkflow(config)
+	}
+	notifier.recordImages(results, orchestrator.ReviewFlags)
+	reportImages(results, orchestrator.ReviewFlags)
 	if err != nil {
 		return errors.Wrap(err, errors.WorkflowError, "modular generation failed")
 	}
+	if modDryRun {
+		printDryRunSummary(orchestrator.DryRunPrompts())
+		printThroughput(orchestrator.Throughput())
+		return nil
+	}
 
 	// Display results
-	fmt.Printf("\n✅ Generation completed successfully!\n")
-	fmt.Printf("   Generated %d images\n", len(results))
+	output.Printf("\n✅ Generation completed successfully!\n")
+	output.Printf("   Generated %d images\n", len(results))
 
 	if len(results) > 0 {
-		fmt.Printf("   Output directory: %s\n", filepath.Dir(results[0]))
+		output.Printf("   Output directory: %s\n", generator.OutputRoot(results[0], modNameTemplate))
+	}
+
+	for _, path := range results {
+		if flags := orchestrator.ReviewFlags(path); len(flags) > 0 {
+			output.Printf("   ⚠️  Flagged for review: %s (%s)\n", filepath.Base(path), strings.Join(flags, ", "))
+		}
+	}
+	printConsistencySummary(orchestrator.ConsistencyScores())
+	printThroughput(orchestrator.Throughput())
+
+	if modReport == "html" && len(results) > 0 {
+		if galleryPath, err := workflow.WriteGallery(filepath.Dir(results[0])); err != nil {
+			logger.Warn("Gallery not written", "error", err)
+		} else {
+			output.Printf("🌐 Gallery: %s\n", galleryPath)
+			output.AddFiles(galleryPath)
+		}
 	}
 
 	return nil
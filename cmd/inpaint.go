@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	stderrors "errors"
+	"fmt"
+	"img-cli/pkg/cost"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/output"
+	"img-cli/pkg/workflow"
+	"img-cli/pkg/workspace"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	inpaintMask        string
+	inpaintRegion      string
+	inpaintEdit        string
+	inpaintVariations  int
+	inpaintSeed        int64
+	inpaintStrength    float64
+	inpaintTemperature float64
+	inpaintTopK        int
+	inpaintTopP        float64
+	inpaintAvoid       string
+	inpaintOutput      string
+	inpaintDebug       bool
+	inpaintNoConfirm   bool
+)
+
+var inpaintCmd = &cobra.Command{
+	Use:   "inpaint <image>",
+	Short: "Edit only one region of an image, keeping the rest pixel-identical",
+	Long: `Change one region of an image, such as the jacket, and keep everything else
+exactly as it is.
+
+The region comes from a mask image (--mask: white is the region to edit, black
+is kept; masks with transparency, where the region is transparent, work too)
+or is found by name (--region jacket). Found regions are boxes around what the
+vision model sees, padded a little, and are saved as <image>_mask.png in the
+output folder to check or refine and reuse with --mask.
+
+--edit says what the region becomes: a description, or an outfit reference
+image whose analysis is used as the description.
+
+The model gets the mask with the image (Stable Diffusion and OpenAI as a
+native inpainting mask), and its result is pasted back through the mask with
+a soft inner edge, so every pixel outside the region is identical to the
+original.
+
+Examples:
+  img-cli inpaint output/2024-01-15/143022/suit_kat.png --region jacket --edit "black leather biker jacket"
+  img-cli inpaint kat --mask masks/kat-top.png --edit outfits/shearling-black.png -v 3
+  img-cli inpaint kat --region "hair" --edit "short platinum blonde pixie cut" --seed 1234`,
+	Args: cobra.ExactArgs(1),
+	RunE: runInpaint,
+}
+
+func init() {
+	rootCmd.AddCommand(inpaintCmd)
+
+	inpaintCmd.Flags().StringVar(&inpaintMask, "mask", "", "Mask image: white marks the region to edit, black what to keep")
+	inpaintCmd.Flags().StringVar(&inpaintRegion, "region", "", "Part of the image to find and edit instead of a mask, e.g. jacket, hair, shoes")
+	inpaintCmd.Flags().StringVar(&inpaintEdit, "edit", "", "What the region becomes: a description or an outfit reference image")
+	inpaintCmd.Flags().IntVarP(&inpaintVariations, "variations", "v", 1, "Number of variations to generate")
+	inpaintCmd.Flags().Int64Var(&inpaintSeed, "seed", 0, "Generation seed, recorded in file names and the manifest; variation i uses seed+i (0 = random)")
+	inpaintCmd.Flags().Float64Var(&inpaintStrength, "strength", 0, "How much the region may change, 0-1; Stable Diffusion uses it as the denoising strength (0 = left to the model)")
+	inpaintCmd.Flags().Float64Var(&inpaintTemperature, "temperature", 0, "Generation temperature, 0-2 (default: generation.temperature in the config, else 0.4)")
+	inpaintCmd.Flags().IntVar(&inpaintTopK, "top-k", 0, "Generation top-k sampling (default: generation.top_k in the config, else 32)")
+	inpaintCmd.Flags().Float64Var(&inpaintTopP, "top-p", 0, "Generation top-p sampling, 0-1 (default: generation.top_p in the config, else 0.9)")
+	inpaintCmd.Flags().StringVar(&inpaintAvoid, "avoid", "", "Comma-separated elements that must not appear, e.g. \"visible logos, zippers\"")
+	inpaintCmd.Flags().StringVarP(&inpaintOutput, "output", "o", "", "Output directory (default: output/YYYY-MM-DD/HHMMSS)")
+	inpaintCmd.Flags().BoolVar(&inpaintDebug, "debug", false, "Show the inpainting prompt")
+	inpaintCmd.Flags().BoolVar(&inpaintNoConfirm, "no-confirm", false, "Skip cost confirmation")
+}
+
+func runInpaint(cmd *cobra.Command, args []string) error {
+	imagePath, err := workspace.ResolveAssetPath("subject", args[0])
+	if err != nil {
+		return err
+	}
+	if !fileExists(imagePath) {
+		return errors.ErrInvalidInput("image", fmt.Sprintf("file not found: %s", imagePath))
+	}
+	if err := resolveAssetFlags(assetFlag{"outfit", &inpaintEdit}); err != nil {
+		return err
+	}
+	inpaintMask = workspace.Resolve(inpaintMask)
+	if inpaintMask != "" && !fileExists(inpaintMask) {
+		return errors.ErrInvalidInput("mask", fmt.Sprintf("file not found: %s", inpaintMask))
+	}
+
+	if err := workflow.ValidateSeed(inpaintSeed); err != nil {
+		return err
+	}
+	if err := workflow.ValidateStrength(inpaintStrength); err != nil {
+		return err
+	}
+	sampling, err := workflow.GenerationSampling(inpaintTemperature, inpaintTopK, inpaintTopP)
+	if err != nil {
+		return err
+	}
+	options := workflow.InpaintOptions{
+		ImagePath:  imagePath,
+		MaskPath:   inpaintMask,
+		Region:     inpaintRegion,
+		Edit:       inpaintEdit,
+		Variations: inpaintVariations,
+		Seed:       inpaintSeed,
+		Strength:   inpaintStrength,
+		Sampling:   sampling,
+		Avoid:      workflow.ParseAvoid(inpaintAvoid),
+		OutputDir:  inpaintOutput,
+		Debug:      inpaintDebug,
+	}
+	if err := workflow.ValidateInpaintOptions(options); err != nil {
+		return err
+	}
+
+	cost.PrintEstimate("Inpaint Cost Analysis", inpaintVariations)
+	output.Progress.Printf("\n🖌️  Editing %s\n", filepath.Base(imagePath))
+	if inpaintMask != "" {
+		output.Progress.Printf("   ✓ Mask: %s\n", filepath.Base(inpaintMask))
+	} else {
+		output.Progress.Printf("   ✓ Region: %s\n", inpaintRegion)
+	}
+	output.Progress.Printf("   ✓ Edit: %s\n", filepath.Base(inpaintEdit))
+	if err := cost.Check(inpaintVariations, cost.CheckOptions{SkipConfirm: inpaintNoConfirm}); err != nil {
+		if stderrors.Is(err, cost.ErrCancelled) {
+			return nil
+		}
+		return err
+	}
+
+	orchestrator := workflow.NewOrchestrator(apiKey)
+	results, err := orchestrator.RunInpaint(options)
+	if err != nil {
+		return errors.Wrap(err, errors.WorkflowError, "inpainting failed")
+	}
+	if len(results) == 0 {
+		return errors.New(errors.GenerationError, "no image was generated")
+	}
+
+	output.AddFiles(results...)
+	output.Count("images", len(results))
+	output.Printf("\n✅ Inpainting completed: %d image(s)\n", len(results))
+	output.Printf("   Output directory: %s\n", filepath.Dir(results[0]))
+	printThroughput(orchestrator.Throughput())
+	return nil
+}
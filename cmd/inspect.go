@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	stderrors "errors"
+	"fmt"
+	"img-cli/pkg/c2pa"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/metadata"
+	"img-cli/pkg/output"
+	"img-cli/pkg/watermark"
+	"img-cli/pkg/workflow"
+	"img-cli/pkg/workspace"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// inspectCmd prints the provenance embedded in a generated image
+var inspectCmd = &cobra.Command{
+	Use:   "inspect <image>",
+	Short: "Show the provenance embedded in a generated image",
+	Long: `Show the provenance img-cli embeds in every generated PNG and JPEG: the
+workflow, provider and model, when it was made, the SHA-256 of the prompt, and
+the file name and SHA-256 of each input (text inputs by the hash of their text).
+It also reports the invisible AI-generated watermark of images made with
+--watermark, which survives when the metadata is stripped.
+
+The record is stored as XMP in the image file, with the workflow, tool and date
+also as EXIF tags, so it survives when the image is copied away from its .json
+sidecar. When the sidecar is still next to the image, inspect checks that the
+prompt matches the embedded hash.
+
+Examples:
+  img-cli inspect output/2024-01-15/143022/suit_dramatic_kat_20240115_143028.png
+  img-cli inspect ~/Downloads/final-look.jpg`,
+	Args: cobra.ExactArgs(1),
+	Annotations: map[string]string{
+		annotationNoAPIKey: "true",
+	},
+	RunE: runInspect,
+}
+
+func init() {
+	rootCmd.AddCommand(inspectCmd)
+}
+
+func runInspect(cmd *cobra.Command, args []string) error {
+	imagePath := workspace.Resolve(args[0])
+	if !fileExists(imagePath) {
+		return errors.ErrInvalidInput("image", fmt.Sprintf("file not found: %s", imagePath))
+	}
+
+	info, err := metadata.Read(imagePath)
+	if err != nil && !stderrors.Is(err, metadata.ErrNoMetadata) {
+		return errors.Wrapf(err, errors.FileError, "failed to read metadata from %s", filepath.Base(imagePath))
+	}
+	mark, marked, err := watermark.DetectFile(imagePath)
+	if err != nil {
+		return errors.Wrapf(err, errors.FileError, "failed to read %s", filepath.Base(imagePath))
+	}
+	if info == nil && !marked {
+		return errors.Newf(errors.FileError, "%s has no img-cli metadata or watermark (made by another tool, or before metadata was embedded)", filepath.Base(imagePath))
+	}
+
+	if marked {
+		output.Set("watermark", mark)
+	}
+	if info != nil {
+		output.Set("metadata", info)
+	}
+	output.Set("c2pa", c2pa.HasManifest(imagePath))
+
+	output.Printf("🔎 %s\n", filepath.Base(imagePath))
+	if marked {
+		output.Printf("   Watermark: %s\n", mark)
+	}
+	if info == nil {
+		output.Println("   No embedded metadata (stripped, or the image was re-saved)")
+		return nil
+	}
+	output.Printf("   Workflow: %s\n", info.Workflow)
+	if info.Model != "" {
+		output.Printf("   Model: %s (%s)\n", info.Model, info.Provider)
+	}
+	if !info.Created.IsZero() {
+		output.Printf("   Created: %s\n", info.Created.Local().Format(time.DateTime))
+	}
+	if info.PromptSHA256 != "" {
+		output.Printf("   Prompt SHA-256: %s\n", info.PromptSHA256)
+	}
+	if len(info.Inputs) > 0 {
+		output.Println("   Inputs:")
+		for _, input := range info.Inputs {
+			source := "text"
+			if input.File != "" {
+				source = input.File
+			}
+			if input.SHA256 != "" {
+				source += fmt.Sprintf(" (sha256 %s)", input.SHA256)
+			}
+			output.Printf("     %s: %s\n", input.Role, source)
+		}
+	}
+	if c2pa.HasManifest(imagePath) {
+		output.Println("   Content credentials: signed (C2PA)")
+	}
+
+	if sidecar, err := workflow.ReadSidecar(imagePath); err == nil {
+		name := filepath.Base(workflow.SidecarPath(imagePath))
+		switch {
+		case sidecar.Prompt == "" || info.PromptSHA256 == "":
+			output.Printf("   Sidecar: %s\n", name)
+		case workflow.PromptSHA256(sidecar.Prompt) == info.PromptSHA256:
+			output.Printf("   Sidecar: %s (prompt matches)\n", name)
+		default:
+			output.Printf("   Sidecar: %s (⚠️  its prompt differs from the embedded hash)\n", name)
+		}
+	}
+	return nil
+}
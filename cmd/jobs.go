@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"img-cli/pkg/jobs"
+	"img-cli/pkg/output"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// jobsCmd groups commands that inspect the jobs of the serve command
+var jobsCmd = &cobra.Command{
+	Use:   "jobs",
+	Short: "List, inspect and cancel server jobs",
+	Long: `List, inspect and cancel the jobs of "img-cli serve".
+
+Jobs are saved under .img-cli/jobs in the project, so these commands work
+while the server runs in another terminal, and after it has stopped.
+
+Available subcommands:
+  list   - List every job with its status
+  status - Show a job's request, images and failures
+  cancel - Cancel a queued job, or stop a running one before its next combination`,
+	Annotations: map[string]string{
+		annotationNoAPIKey: "true",
+	},
+}
+
+var jobsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List server jobs",
+	Args:  cobra.NoArgs,
+	Annotations: map[string]string{
+		annotationNoAPIKey: "true",
+	},
+	RunE: runJobsList,
+}
+
+var jobsStatusCmd = &cobra.Command{
+	Use:   "status <id>",
+	Short: "Show a server job",
+	Args:  cobra.ExactArgs(1),
+	Annotations: map[string]string{
+		annotationNoAPIKey: "true",
+	},
+	RunE: runJobsStatus,
+}
+
+var jobsCancelCmd = &cobra.Command{
+	Use:   "cancel <id>",
+	Short: "Cancel a server job",
+	Long: `Cancel a server job. A queued job never runs; a running job finishes the
+combination it is generating and stops, keeping the images it made.
+
+Examples:
+  img-cli jobs cancel 3`,
+	Args: cobra.ExactArgs(1),
+	Annotations: map[string]string{
+		annotationNoAPIKey: "true",
+	},
+	RunE: runJobsCancel,
+}
+
+func init() {
+	rootCmd.AddCommand(jobsCmd)
+	jobsCmd.AddCommand(jobsListCmd, jobsStatusCmd, jobsCancelCmd)
+}
+
+func runJobsList(cmd *cobra.Command, args []string) error {
+	store, err := jobs.Open(jobs.DefaultDir())
+	if err != nil {
+		return err
+	}
+	all, err := store.List()
+	if err != nil {
+		return err
+	}
+	output.Set("jobs", all)
+	if len(all) == 0 {
+		output.Println("No jobs yet. Submit one to: img-cli serve")
+		return nil
+	}
+
+	for _, job := range all {
+		status := job.Status
+		if job.CancelRequested && !job.Done() {
+			status += " (stopping)"
+		}
+		output.Printf("%-5s %-12s %-20s %-16s %3d image(s)", job.ID, job.Kind, status, job.Created.Format("2006-01-02 15:04"), len(job.Images))
+		if len(job.Failures) > 0 {
+			output.Printf(", %d failed", len(job.Failures))
+		}
+		output.Println()
+	}
+	return nil
+}
+
+func runJobsStatus(cmd *cobra.Command, args []string) error {
+	store, err := jobs.Open(jobs.DefaultDir())
+	if err != nil {
+		return err
+	}
+	job, err := store.Get(args[0])
+	if err != nil {
+		return err
+	}
+
+	output.Set("job", job)
+	output.Printf("📋 Job %s (%s)\n", job.ID, job.Kind)
+	output.Printf("  Status:   %s", job.Status)
+	if job.CancelRequested && !job.Done() {
+		output.Print(", stopping after the current combination")
+	}
+	output.Println()
+	output.Printf("  Created:  %s\n", job.Created.Format(time.DateTime))
+	if job.Started != nil {
+		output.Printf("  Started:  %s", job.Started.Format(time.DateTime))
+		if job.Attempts > 1 {
+			output.Printf(" (attempt %d)", job.Attempts)
+		}
+		output.Println()
+	}
+	if job.Finished != nil {
+		output.Printf("  Finished: %s", job.Finished.Format(time.DateTime))
+		if job.Started != nil {
+			output.Printf(" after %s", job.Finished.Sub(*job.Started).Round(time.Second))
+		}
+		output.Println()
+	}
+	output.Printf("  Output:   %s\n", job.OutputDir)
+	request := &bytes.Buffer{}
+	if err := json.Compact(request, job.Request); err == nil {
+		output.Printf("  Request:  %s\n", request)
+	}
+
+	if len(job.Images) > 0 {
+		output.Printf("\n🖼️  Images (%d):\n", len(job.Images))
+		for _, image := range job.Images {
+			output.Printf("  %s", image.Path)
+			if len(image.Flags) > 0 {
+				output.Printf("  [%s]", strings.Join(image.Flags, ", "))
+			}
+			output.Println()
+		}
+	}
+	if len(job.Failures) > 0 {
+		output.Printf("\n⚠️  Failed generations (%d):\n", len(job.Failures))
+		for _, failure := range job.Failures {
+			output.Printf("  %s: %s\n", failure.Combination, failure.Error)
+		}
+	}
+	if job.Error != nil {
+		output.Printf("\n❌ %s: %s\n", job.Error.Type, job.Error.Message)
+	}
+	return nil
+}
+
+func runJobsCancel(cmd *cobra.Command, args []string) error {
+	store, err := jobs.Open(jobs.DefaultDir())
+	if err != nil {
+		return err
+	}
+	job, err := store.Cancel(args[0])
+	if err != nil {
+		return err
+	}
+	output.Set("job", job)
+	if job.Status == jobs.StatusCancelled {
+		output.Printf("✓ Cancelled job %s\n", job.ID)
+	} else {
+		output.Printf("✓ Job %s will stop after the combination it is generating\n", job.ID)
+	}
+	return nil
+}
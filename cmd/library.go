@@ -0,0 +1,239 @@
+package cmd
+
+import (
+	"img-cli/pkg/library"
+	"img-cli/pkg/logger"
+	"img-cli/pkg/output"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	libraryRemove   bool
+	libraryFindTags []string
+	libraryFindKind []string
+)
+
+// libraryCmd groups commands that catalog the reference folders
+var libraryCmd = &cobra.Command{
+	Use:   "library",
+	Short: "Catalog, tag and search reference images",
+	Long: `Catalog the reference images of outfits/, styles/, hair-style/, makeup/
+and the other component folders, so they can be found by tag instead of by
+browsing folders of opaque filenames.
+
+Tags and thumbnails are kept in a library/ folder inside each component's
+analysis cache, keyed by asset name, and survive "cache clear". Kinds: ` + strings.Join(library.Kinds(), ", ") + `.
+
+Available subcommands:
+  list   - List the reference images of one or every kind with their tags
+  tag    - Add or remove tags on a reference image
+  find   - Find reference images by tag and name
+  thumbs - Generate thumbnails of the reference images`,
+	Annotations: map[string]string{
+		annotationNoAPIKey: "true",
+	},
+}
+
+var libraryListCmd = &cobra.Command{
+	Use:   "list [kind]",
+	Short: "List reference images with their tags",
+	Args:  cobra.MaximumNArgs(1),
+	Annotations: map[string]string{
+		annotationNoAPIKey: "true",
+	},
+	RunE: runLibraryList,
+}
+
+var libraryTagCmd = &cobra.Command{
+	Use:   "tag <kind> <name> <tag>...",
+	Short: "Add or remove tags on a reference image",
+	Long: `Add tags to a reference image, or remove them with --remove. Tags are
+lowercased; spaces are allowed when quoted.
+
+Examples:
+  img-cli library tag outfit shearling-black leather winter outerwear
+  img-cli library tag style night "low key"
+  img-cli library tag outfit shearling-black winter --remove`,
+	Args: cobra.MinimumNArgs(3),
+	Annotations: map[string]string{
+		annotationNoAPIKey: "true",
+	},
+	RunE: runLibraryTag,
+}
+
+var libraryFindCmd = &cobra.Command{
+	Use:   "find [text]",
+	Short: "Find reference images by tag and name",
+	Long: `Find reference images that have every --tag given and, with a text
+argument, whose name or a tag contains it.
+
+Examples:
+  img-cli library find --tag leather --tag winter
+  img-cli library find --kind style --tag "low key"
+  img-cli library find shearling`,
+	Args: cobra.MaximumNArgs(1),
+	Annotations: map[string]string{
+		annotationNoAPIKey: "true",
+	},
+	RunE: runLibraryFind,
+}
+
+var libraryThumbsCmd = &cobra.Command{
+	Use:   "thumbs [kind]",
+	Short: "Generate thumbnails of reference images",
+	Long: `Generate JPEG thumbnails of the reference images of one or every kind.
+Thumbnails that are newer than their image are kept.`,
+	Args: cobra.MaximumNArgs(1),
+	Annotations: map[string]string{
+		annotationNoAPIKey: "true",
+	},
+	RunE: runLibraryThumbs,
+}
+
+func init() {
+	rootCmd.AddCommand(libraryCmd)
+	libraryCmd.AddCommand(libraryListCmd, libraryTagCmd, libraryFindCmd, libraryThumbsCmd)
+
+	libraryTagCmd.Flags().BoolVar(&libraryRemove, "remove", false, "Remove the tags instead of adding them")
+	libraryFindCmd.Flags().StringArrayVar(&libraryFindTags, "tag", nil, "Tag the images must have (repeatable)")
+	libraryFindCmd.Flags().StringSliceVar(&libraryFindKind, "kind", nil, "Kinds to search (default: all)")
+}
+
+// libraryKinds returns the kind given as the only argument, or every kind
+func libraryKinds(args []string) ([]string, error) {
+	if len(args) == 0 {
+		return library.Kinds(), nil
+	}
+	if err := library.ValidateKind(args[0]); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+func runLibraryList(cmd *cobra.Command, args []string) error {
+	kinds, err := libraryKinds(args)
+	if err != nil {
+		return err
+	}
+	items, err := library.Search(library.Query{Kinds: kinds})
+	if err != nil {
+		return err
+	}
+	output.Set("items", items)
+	output.Count("items", len(items))
+	if len(items) == 0 {
+		output.Println("No reference images found")
+		return nil
+	}
+	printLibraryItems(items)
+	return nil
+}
+
+func runLibraryTag(cmd *cobra.Command, args []string) error {
+	catalog, err := library.Open(args[0])
+	if err != nil {
+		return err
+	}
+	item, err := catalog.Find(args[1])
+	if err != nil {
+		return err
+	}
+	if libraryRemove {
+		catalog.Untag(item.Name, args[2:])
+	} else {
+		catalog.Tag(item.Name, args[2:])
+	}
+	if err := catalog.Save(); err != nil {
+		return err
+	}
+	item, _ = catalog.Find(item.Name)
+	output.Set("item", item)
+	if len(item.Tags) == 0 {
+		output.Printf("✓ %s %s has no tags\n", item.Kind, item.Name)
+	} else {
+		output.Printf("✓ %s %s: %s\n", item.Kind, item.Name, strings.Join(item.Tags, ", "))
+	}
+	return nil
+}
+
+func runLibraryFind(cmd *cobra.Command, args []string) error {
+	for _, kind := range libraryFindKind {
+		if err := library.ValidateKind(kind); err != nil {
+			return err
+		}
+	}
+	query := library.Query{Kinds: libraryFindKind, Tags: libraryFindTags}
+	if len(args) == 1 {
+		query.Text = args[0]
+	}
+	items, err := library.Search(query)
+	if err != nil {
+		return err
+	}
+	output.Set("items", items)
+	output.Count("items", len(items))
+	if len(items) == 0 {
+		output.Println("No matching reference images")
+		return nil
+	}
+	printLibraryItems(items)
+	output.Printf("\n🔎 %d match(es)\n", len(items))
+	return nil
+}
+
+func runLibraryThumbs(cmd *cobra.Command, args []string) error {
+	kinds, err := libraryKinds(args)
+	if err != nil {
+		return err
+	}
+	items, err := library.Search(library.Query{Kinds: kinds})
+	if err != nil {
+		return err
+	}
+	made, kept, failed := 0, 0, 0
+	for _, item := range items {
+		_, generated, err := library.Thumbnail(item)
+		switch {
+		case err != nil:
+			failed++
+			logger.Warn("Failed to make thumbnail", "image", item.Path, "error", err)
+		case generated:
+			made++
+		default:
+			kept++
+		}
+	}
+	output.Count("generated", made)
+	output.Count("up_to_date", kept)
+	output.Count("failed", failed)
+	output.Printf("🖼️  Thumbnails: %d generated, %d up to date", made, kept)
+	if failed > 0 {
+		output.Printf(", %d failed", failed)
+	}
+	output.Println()
+	return nil
+}
+
+// printLibraryItems prints items grouped under a heading per kind
+func printLibraryItems(items []library.Item) {
+	kind := ""
+	for _, item := range items {
+		if item.Kind != kind {
+			if kind != "" {
+				output.Println()
+			}
+			kind = item.Kind
+			output.Printf("📚 %s\n", kind)
+		}
+		output.Printf("  %-24s %s", item.Name, item.Path)
+		if len(item.Tags) > 0 {
+			output.Printf("  [%s]", strings.Join(item.Tags, ", "))
+		}
+		output.Println()
+		if item.Thumbnail != "" {
+			output.Printf("  %-24s thumbnail: %s\n", "", item.Thumbnail)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"img-cli/pkg/config"
+	"img-cli/pkg/cost"
+	"img-cli/pkg/logger"
+	"img-cli/pkg/notify"
+	"img-cli/pkg/workflow"
+	"path/filepath"
+	"time"
+)
+
+// runNotifier reports a workflow to the --notify webhook (or
+// IMG_CLI_NOTIFY_URL) when it finishes or fails. Nothing is sent for
+// failures before start, such as bad flags, or for cancelled and dry runs.
+type runNotifier struct {
+	url     string
+	started bool
+	summary notify.Summary
+}
+
+func newRunNotifier(command, url string) *runNotifier {
+	if url == "" {
+		url = config.DefaultNotifyConfig().URL
+	}
+	return &runNotifier{url: url, summary: notify.Summary{Command: command}}
+}
+
+// start marks the beginning of the workflow
+func (n *runNotifier) start(outputDir string) {
+	n.started = true
+	n.summary.Started = time.Now()
+	n.summary.OutputDir = outputDir
+}
+
+// cancel drops the notification, e.g. when the cost confirmation is declined
+func (n *runNotifier) cancel() {
+	n.started = false
+}
+
+// record counts the images, flags and failures of a workflow result
+func (n *runNotifier) record(result *workflow.WorkflowResult) {
+	for _, step := range result.Steps {
+		if step.Type != "generation" || step.OutputPath == "" {
+			continue
+		}
+		n.summary.Images++
+		if len(step.Flags) > 0 {
+			n.summary.Flagged++
+		}
+	}
+	n.summary.Failed = len(result.Failures)
+	for _, failure := range result.Failures {
+		n.summary.Failures = append(n.summary.Failures, failure.Combination+": "+failure.Error)
+	}
+}
+
+// recordImages counts the images of workflows that return only their paths
+func (n *runNotifier) recordImages(paths []string, flags func(string) []string) {
+	n.summary.Images = len(paths)
+	for _, path := range paths {
+		if len(flags(path)) > 0 {
+			n.summary.Flagged++
+		}
+	}
+	if len(paths) > 0 {
+		n.summary.OutputDir = filepath.Dir(paths[0])
+	}
+}
+
+// send posts the summary with the command's outcome. Delivery problems are
+// logged and never fail the command.
+func (n *runNotifier) send(err error) {
+	if n.url == "" || !n.started {
+		return
+	}
+	n.summary.Status = notify.StatusCompleted
+	if err != nil {
+		n.summary.Status = notify.StatusFailed
+		n.summary.Error = err.Error()
+	}
+	n.summary.Cost = cost.Of(n.summary.Images)
+	n.summary.Duration = time.Since(n.summary.Started).Round(time.Second).String()
+
+	if err := notify.Send(n.url, n.summary); err != nil {
+		logger.Warn("Run notification not sent", "error", err)
+		return
+	}
+	logger.Info("Run notification sent", "status", n.summary.Status, "images", n.summary.Images)
+}
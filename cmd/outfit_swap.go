@@ -1,10 +1,19 @@
 package cmd
 
 import (
+	stderrors "errors"
 	"fmt"
+	"img-cli/pkg/config"
+	"img-cli/pkg/cost"
 	"img-cli/pkg/errors"
+	"img-cli/pkg/generator"
+	"img-cli/pkg/gemini"
+	"img-cli/pkg/library"
 	"img-cli/pkg/logger"
+	"img-cli/pkg/notify"
+	"img-cli/pkg/output"
 	"img-cli/pkg/workflow"
+	"img-cli/pkg/workspace"
 	"io"
 	"os"
 	"path/filepath"
@@ -28,14 +37,63 @@ var (
 	outfitExpression  string
 	outfitAccessories string
 	outfitOverOutfit  string
+	outfitPose        string
+	outfitBackground  string
+	outfitExtraRefs   componentFlags // Components added with analyzer.Register
+	outfitPreserve    bool
+	outfitLUT         string
+	outfitFaceLock    bool
+	outfitVerifyColor bool
+	outfitConsistency bool
+	outfitHTMLReport  bool
+	outfitSheet       bool
+	outfitReport      string
+	outfitNotify      string
+	outfitSign        bool
+	outfitRefineAlt   bool
+	outfitChain       []string
+	outfitArtStyle    string
+	outfitColorTol    float64
+	outfitEnhance     bool
+	outfitStyleBlend  bool
+	outfitMaxDuration time.Duration
+	outfitResume      string
+	outfitSkipExist   bool
+	outfitParallel    int
+	outfitDryRun      bool
+	outfitProgress    string
+	outfitCheck       string
+	outfitImplausible bool
+	outfitPick        bool
+	outfitSample      int
+	outfitSampleSeed  int64
+	outfitPairwise    bool
+	outfitAmbient     string
+	outfitAvoid       string
+	outfitAspect      string
+	outfitResolution  int
+	outfitSeed        int64
+	outfitTemperature float64
+	outfitTopK        int
+	outfitTopP        float64
+	outfitStrength    float64
+	outfitNameTmpl    string
+	outfitOrganizeBy  string
+	outfitUpscale     string
+	outfitUpscaler    string
+	outfitReview      bool
+	outfitJudge       bool
+	outfitBestOf      int
+	outfitValidateID  bool
+	outfitMinIdentity float64
+	outfitIDRetries   int
+	outfitMaxAccess   int
+	outfitNoPreflight bool
 )
 
-// Default values for common parameters
-const (
-	defaultOutfit = "./outfits/shearling-black.png"
-	defaultStyle  = "./styles/plain-white.png"
-	defaultSubject = "jaimee"
-)
+// Default subject for -t with no value; the outfit, style and subject list
+// defaults come from config.DefaultInputsConfig
+const defaultSubject = "jaimee"
 
 // outfitSwapCmd represents the simplified outfit-swap command
 var outfitSwapCmd = &cobra.Command{
@@ -69,6 +127,12 @@ Examples:
     --makeup ./makeup/natural.png \
     -t "jaimee kat"
 
+  # Weighted components: the outfit must win, the makeup is only a hint
+  img-cli outfit-swap ./outfits/coat.png:1.5 --makeup ./makeup/bold.png:0.5
+
+  # Every outfit tagged formal under every style tagged night (see "img-cli library")
+  img-cli outfit-swap tag:formal -s tag:night -t kat
+
   # Layered outfits (jacket from first outfit worn over complete second outfit)
   img-cli outfit-swap ./outfits/punk-jacket.png \
     --over-outfit ./outfits/dress.png \
@@ -76,6 +140,9 @@ Examples:
     -t sarah
   # Result: dress + only the jacket from punk-jacket outfit
 
+  # Lighting study: same look under four ambients
+  img-cli outfit-swap ./outfits/suit.png -t kat --ambient sweep:golden-hour,noon,overcast,night-neon
+
 Default values:
   Outfit:  ./outfits/shearling-black.png
   Style:   ./styles/plain-white.png
@@ -89,7 +156,7 @@ func init() {
 	rootCmd.AddCommand(outfitSwapCmd)
 
 	// Shortcuts and full flags
-	outfitSwapCmd.Flags().StringVarP(&outfitStyleRef, "style", "s", "", "Style reference image (default: ./styles/plain-white.png)")
+	outfitSwapCmd.Flags().StringVarP(&outfitStyleRef, "style", "s", "", "Style reference image, directory or description (default: ./styles/plain-white.png)")
 	outfitSwapCmd.Flags().StringVarP(&outfitTestSubjects, "test", "t", "", "Test subjects from subjects/ directory (omit flag for all subjects, use -t alone for jaimee)")
 	outfitSwapCmd.Flags().IntVarP(&outfitVariations, "variations", "v", 1, "Number of variations per combination")
 
@@ -101,26 +168,93 @@ func init() {
 	outfitSwapCmd.Flags().StringVarP(&outfitAccessories, "accessories", "a", "", "Accessories reference image or directory")
 	outfitSwapCmd.Flags().StringVar(&outfitAccessories, "accessory", "", "Accessories reference image or directory (alias for --accessories)")
 	outfitSwapCmd.Flags().MarkHidden("accessory") // Hide from help to avoid clutter, but still works
+	outfitSwapCmd.Flags().StringVar(&outfitPose, "pose", "", "Body pose reference image or directory (replaces the style's pose)")
+	outfitSwapCmd.Flags().StringVar(&outfitBackground, "background", "", "Background/environment reference image or directory (replaces the style's background)")
+	outfitExtraRefs = addComponentFlags(outfitSwapCmd, "reference image, directory or description")
+	outfitSwapCmd.Flags().BoolVar(&outfitPreserve, "preserve-body", true, "Keep each subject's own build and proportions across variations; false leaves the body to the model (can't be combined with --body-ref)")
 	outfitSwapCmd.Flags().StringVar(&outfitOverOutfit, "over-outfit", "", "Complete base outfit; main outfit's outer layer (jacket/coat) will be worn over this")
 
 	// Additional options
 	outfitSwapCmd.Flags().BoolVar(&outfitSendOriginal, "send-original", false, "Include reference images in API requests")
 	outfitSwapCmd.Flags().BoolVar(&outfitNoConfirm, "no-confirm", false, "Skip cost confirmation prompts")
 	outfitSwapCmd.Flags().BoolVar(&outfitDebugPrompt, "debug", false, "Show debug information including prompts")
+	outfitSwapCmd.Flags().StringVar(&outfitLUT, "lut", "", "Apply a .cube LUT to each generated image (see 'style lut')")
+	outfitSwapCmd.Flags().BoolVar(&outfitFaceLock, "face-lock", false, "Paste the subject's face from its photo back over each generated image, blended and color-matched (one extra analysis per image)")
+	outfitSwapCmd.Flags().DurationVar(&outfitMaxDuration, "max-duration", 0, "Stop starting new combinations after this long, e.g. 2h (remaining work is saved to run_state.json)")
+	outfitSwapCmd.Flags().StringVar(&outfitProgress, "progress", "bar", "Progress output: bar (a bar line per image with timing, ETA and cost) or json (one JSON event per line)")
+	outfitSwapCmd.Flags().BoolVar(&outfitDryRun, "dry-run", false, "Build every generation prompt and write it to a .prompt.txt file with the image count and cost, without generating anything")
+	outfitSwapCmd.Flags().IntVar(&outfitParallel, "parallel", 1, "Generate up to N images at once (in-flight requests are still capped by IMG_CLI_GENERATE_CONCURRENCY)")
+	outfitSwapCmd.Flags().StringVar(&outfitResume, "resume", "", "Continue an interrupted run in this output folder, skipping combinations that already have their images (rerun with the same inputs)")
+	outfitSwapCmd.Flags().StringVar(&outfitCheck, "outfit-check", workflow.OutfitCheckWarn, "Missing bottoms/footwear in full-body styles: warn, fill (add neutral defaults) or off")
+	outfitSwapCmd.Flags().BoolVar(&outfitImplausible, "allow-implausible", false, "Generate even when garments clash with the style's scene (shearling coat on a beach)")
+	outfitSwapCmd.Flags().BoolVar(&outfitPick, "pick", false, "List the planned combinations first and prompt for commands that toggle rows and set variations per row, reprinting the cost after each")
+	outfitSwapCmd.Flags().IntVar(&outfitSample, "sample", 0, "Generate only N combinations drawn at random from the full matrix, to explore a large component space cheaply")
+	outfitSwapCmd.Flags().Int64Var(&outfitSampleSeed, "sample-seed", 0, "Seed for --sample; the same seed and inputs draw the same combinations (default: random, printed)")
+	outfitSwapCmd.Flags().BoolVar(&outfitPairwise, "pairwise", false, "Generate only enough combinations to cover every pair of component values (outfit x style, style x hair, ...) instead of the full matrix")
+	outfitSwapCmd.Flags().StringVar(&outfitAmbient, "ambient", "", "Generate each combination under several lightings, e.g. sweep:golden-hour,noon,overcast,night-neon")
+	outfitSwapCmd.Flags().StringVar(&outfitAspect, "aspect", "", "Aspect ratio of generated images: 9:16 (default), 1:1, 16:9, 4:5 or 3:2")
+	outfitSwapCmd.Flags().IntVar(&outfitResolution, "resolution", 0, "Long side of saved images in pixels; outputs are cropped and resized to match (0 = as generated)")
+	outfitSwapCmd.Flags().Int64Var(&outfitSeed, "seed", 0, "Generation seed, recorded in file names and the manifest; the same prompt and seed reproduce an image where the backend supports it, variation i of each combination uses seed+i (0 = random)")
+	outfitSwapCmd.Flags().Float64Var(&outfitTemperature, "temperature", 0, "Generation temperature, 0-2; lower sticks closer to the prompt (default: generation.temperature in the config, else the model's)")
+	outfitSwapCmd.Flags().IntVar(&outfitTopK, "top-k", 0, "Generation top-k sampling (default: generation.top_k in the config, else the model's)")
+	outfitSwapCmd.Flags().Float64Var(&outfitTopP, "top-p", 0, "Generation top-p sampling, 0-1 (default: generation.top_p in the config, else the model's)")
+	outfitSwapCmd.Flags().Float64Var(&outfitStrength, "strength", 0, "How much the subject photo may change, 0-1: 0.2 is a subtle restyling, 1 a complete transformation; Stable Diffusion uses it as the denoising strength (0 = left to the model)")
+	outfitSwapCmd.Flags().StringVar(&outfitNameTmpl, "name-template", "", "Output file name template relative to the output folder, e.g. \"{subject}/{outfit}-{style}-v{variation:2}\"; \"/\" makes subfolders, {name:N} zero-pads numbers (placeholders: subject, outfit, style, tag, variation, seed, date, time, timestamp)")
+	outfitSwapCmd.Flags().StringVar(&outfitOrganizeBy, "organize-by", "", "Put images in subfolders of the output folder named after their subject, outfit or style")
+	outfitSwapCmd.Flags().StringVar(&outfitUpscale, "upscale", "", "Also write an _upscaled copy of each image: 2x or 4x (print-ready outputs)")
+	outfitSwapCmd.Flags().StringVar(&outfitUpscaler, "upscaler", workflow.UpscalerRegenerate, "Upscaler for --upscale: regenerate (through the image model, billed like an image) or resample (local, free, adds no detail)")
+	outfitSwapCmd.Flags().StringVar(&outfitAvoid, "avoid", "", "Comma-separated elements that must not appear, e.g. \"hats, sunglasses, visible logos\"")
+	outfitSwapCmd.Flags().BoolVar(&outfitSkipExist, "skip-existing", false, "Skip variations whose subject, components, variation number and prompt template match an image already under the output root (add one outfit and rerun without regenerating the rest)")
+	outfitSwapCmd.Flags().BoolVar(&outfitNoPreflight, "skip-preflight", false, "Skip checking subject photos (face visible, single person, resolution) before the run")
+	outfitSwapCmd.Flags().IntVar(&outfitMaxAccess, "max-accessories", 0, "Keep only the N most important accessories in the prompt (0 = no limit)")
+	outfitSwapCmd.Flags().BoolVar(&outfitStyleBlend, "style-blend", false, "Blend the comma-separated --style references (images or folders) into one style instead of generating each, e.g. --style noir.png,pastel.png --style-blend (one extra cheap API call)")
+	outfitSwapCmd.Flags().BoolVar(&outfitEnhance, "enhance-text", false, "Expand text hair/makeup/expression descriptions into detailed ones (one extra cheap API call each)")
+	outfitSwapCmd.Flags().StringSliceVar(&outfitChain, "chain", nil, "Extra outputs from the same analyses: art_style (illustrated copy of each image), style_guide (one sheet per run)")
+	outfitSwapCmd.Flags().StringVar(&outfitArtStyle, "art-style", "", "Art style reference image for --chain")
+	outfitSwapCmd.Flags().BoolVar(&outfitHTMLReport, "html-report", false, "Write a self-contained report.html (stats, thumbnails, costs, failures) to the output folder")
+	outfitSwapCmd.Flags().BoolVar(&outfitSheet, "contact-sheet", false, "Write contact_sheet.png to the output folder: all images in a labeled grid, a row per subject and a column per outfit/style")
+	outfitSwapCmd.Flags().StringVar(&outfitNotify, "notify", "", "POST a JSON run summary (counts, cost, output folder, failures) to this Slack/Discord-compatible webhook when the run finishes or fails (default: IMG_CLI_NOTIFY_URL)")
+	outfitSwapCmd.Flags().StringVar(&outfitReport, "report", "", "Write a shareable gallery of the output folder: html (index.html with thumbnails, full-size links, references, prompts and generation times)")
+	outfitSwapCmd.Flags().BoolVar(&outfitSign, "sign", false, "Embed signed C2PA content credentials in each image (certificate from IMG_CLI_C2PA_CERT/IMG_CLI_C2PA_KEY)")
+	outfitSwapCmd.Flags().BoolVar(&outfitRefineAlt, "refine-alt-text", false, "Polish each image's alt text with a text request (~1 cheap call per image)")
+	outfitSwapCmd.Flags().BoolVar(&outfitConsistency, "consistency", false, "Score identity and outfit color consistency across each combination's variations (one extra cheap API call per combination)")
+	outfitSwapCmd.Flags().BoolVar(&outfitReview, "review", false, "Label each image with the failure taxonomy (identity drift, wrong garment color, framing ignored, extra accessories); one extra cheap API call per image")
+	outfitSwapCmd.Flags().BoolVar(&outfitJudge, "judge", false, "Score each image for outfit fidelity, style fidelity and artifacts, and write ranking.txt best first (one extra cheap API call per image)")
+	outfitSwapCmd.Flags().IntVar(&outfitBestOf, "best-of", 1, "Generate N candidates per image and keep the best-scoring one (by --judge and/or --validate-identity; judged when neither is set); the others move to rejected/ and are billed like images")
+	outfitSwapCmd.Flags().BoolVar(&outfitValidateID, "validate-identity", false, "Score each image's face against the subject and record it in the manifest (one extra cheap API call per image)")
+	outfitSwapCmd.Flags().Float64Var(&outfitMinIdentity, "min-identity-score", 0, "Regenerate images whose identity score is below this (0-1, e.g. 0.7); implies --validate-identity, retries are billed like images")
+	outfitSwapCmd.Flags().IntVar(&outfitIDRetries, "identity-retries", workflow.DefaultIdentityRetries, "Regenerations allowed per image below --min-identity-score; the best attempt is kept")
+	outfitSwapCmd.Flags().BoolVar(&outfitVerifyColor, "verify-color", false, "Flag outputs whose colors don't match the style reference")
+	outfitSwapCmd.Flags().Float64Var(&outfitColorTol, "color-tolerance", config.DefaultVerifyConfig().ColorTolerance, "Maximum color distance (0-1) for --verify-color")
 }
 
-func runOutfitSwap(cmd *cobra.Command, args []string) error {
+func runOutfitSwap(cmd *cobra.Command, args []string) (err error) {
+	notifier := newRunNotifier("outfit-swap", outfitNotify)
+	defer func() { notifier.send(err) }()
+
 	// Debug: log all arguments received
 	if len(args) > 1 {
 		logger.Debug("Received multiple arguments", "count", len(args), "args", args)
 	}
 
 	// Determine outfit source
+	inputs := config.DefaultInputsConfig()
 	var outfitPath string
+	var outfitWeight float64
 	if len(args) > 0 {
-		outfitPath = args[0]
+		var outfit string
+		outfit, outfitWeight = workflow.SplitWeight(args[0])
+		outfit, err := library.Expand("outfit", outfit)
+		if err != nil {
+			return err
+		}
+		resolved, err := workspace.ResolveAssetPath("outfit", outfit)
+		if err != nil {
+			return err
+		}
+		outfitPath = resolved
 	} else {
-		outfitPath = defaultOutfit
+		outfitPath = workspace.Resolve(inputs.Outfit)
 		logger.Info("Using default outfit", "path", outfitPath)
 	}
 
@@ -129,7 +263,7 @@ func runOutfitSwap(cmd *cobra.Command, args []string) error {
 		// Try without extension if it's not a directory
 		if !strings.Contains(outfitPath, ".") {
 			for _, ext := range []string{".png", ".jpg", ".jpeg"} {
-				tryPath := outfitPath + ext
+				tryPath := workspace.Resolve(outfitPath + ext)
 				if _, err := os.Stat(tryPath); err == nil {
 					outfitPath = tryPath
 					break
@@ -143,23 +277,129 @@ func runOutfitSwap(cmd *cobra.Command, args []string) error {
 	}
 
 	// Move external images to outfits folder if needed
-	outfitPath, err := moveToOutfitsIfExternal(outfitPath)
+	outfitPath, err = moveToOutfitsIfExternal(outfitPath)
 	if err != nil {
 		return errors.Wrapf(err, errors.FileError, "failed to move outfit to outfits folder")
 	}
 
+	outfitLUT = workspace.Resolve(outfitLUT)
+	if err := validateLUTFlag(outfitLUT); err != nil {
+		return err
+	}
+	if err := validateOutfitCheckFlag(outfitCheck); err != nil {
+		return err
+	}
+	if err := validateMaxAccessoriesFlag(outfitMaxAccess); err != nil {
+		return err
+	}
+	if err := workflow.ValidateFormat(outfitAspect, outfitResolution); err != nil {
+		return err
+	}
+	upscale, err := workflow.ParseUpscale(outfitUpscale)
+	if err != nil {
+		return err
+	}
+	if err := workflow.ValidateUpscaler(outfitUpscaler); err != nil {
+		return err
+	}
+	if err := workflow.ValidateIdentityFlags(outfitMinIdentity, outfitIDRetries); err != nil {
+		return err
+	}
+	if err := workflow.ValidateBestOf(outfitBestOf); err != nil {
+		return err
+	}
+	if err := workflow.ValidateSeed(outfitSeed); err != nil {
+		return err
+	}
+	sampling, err := workflow.GenerationSampling(outfitTemperature, outfitTopK, outfitTopP)
+	if err != nil {
+		return err
+	}
+	if err := workflow.ValidateStrength(outfitStrength); err != nil {
+		return err
+	}
+	if err := generator.ValidateNameTemplate(outfitNameTmpl); err != nil {
+		return err
+	}
+	if err := workflow.ValidateOrganizeBy(outfitOrganizeBy); err != nil {
+		return err
+	}
+	if err := workflow.ValidateReportFormat(outfitReport); err != nil {
+		return err
+	}
+	if err := notify.Validate(outfitNotify); err != nil {
+		return err
+	}
+
 	// Set default style if not specified
 	if outfitStyleRef == "" {
-		outfitStyleRef = defaultStyle
+		outfitStyleRef = workspace.Resolve(inputs.Style)
 		logger.Info("Using default style", "path", outfitStyleRef)
 	}
+	flags := append([]assetFlag{
+		{"style", &outfitStyleRef},
+		{"hair-style", &outfitHairStyle},
+		{"hair-color", &outfitHairColor},
+		{"makeup", &outfitMakeup},
+		{"expression", &outfitExpression},
+		{"accessories", &outfitAccessories},
+		{"over-outfit", &outfitOverOutfit},
+		{"pose", &outfitPose},
+		{"background", &outfitBackground},
+	}, outfitExtraRefs.assetFlags()...)
+	weights := splitWeights(flags...)
+	if outfitWeight != 0 {
+		if weights == nil {
+			weights = make(map[string]float64)
+		}
+		weights["outfit"] = outfitWeight
+	}
+	if err := expandTagSelectors(flags...); err != nil {
+		return err
+	}
+	if err := resolveAssetFlags(append([]assetFlag{
+		styleFlag(&outfitStyleRef, outfitStyleBlend),
+		{"hair-style", &outfitHairStyle},
+		{"hair-color", &outfitHairColor},
+		{"makeup", &outfitMakeup},
+		{"expression", &outfitExpression},
+		{"accessories", &outfitAccessories},
+		{"over-outfit", &outfitOverOutfit},
+		{"pose", &outfitPose},
+		{"background", &outfitBackground},
+	}, outfitExtraRefs.assetFlags()...)...); err != nil {
+		return err
+	}
+	// The --art-style reference of --chain is always an image
+	if outfitArtStyle, err = workspace.ResolveAssetPath("style", outfitArtStyle); err != nil {
+		return err
+	}
+	if err := outfitExtraRefs.preserveBody(cmd, outfitPreserve); err != nil {
+		return err
+	}
+	chain := workflow.ChainOptions{Steps: outfitChain, ArtStyleRef: outfitArtStyle}
+	if err := chain.Validate(); err != nil {
+		return err
+	}
+	ambients, err := workflow.ParseAmbient(outfitAmbient)
+	if err != nil {
+		return err
+	}
+	signOpts, err := signerOptions(outfitSign)
+	if err != nil {
+		return err
+	}
+	progressOpt, err := progressOption(outfitProgress)
+	if err != nil {
+		return err
+	}
 
 	// Handle test subjects
 	var targetImages []string
-	subjectsDir := "subjects"
+	subjectsDir := workspace.AssetDir("subjects")
 
 	// Check if test flag was provided
-	if !cmd.Flags().Changed("test") {
+	if !cmd.Flags().Changed("test") && len(inputs.Subjects) == 0 {
 		// No -t flag provided at all: use ALL subjects
 		logger.Info("No test subjects specified, using all subjects")
 		files, err := os.ReadDir(subjectsDir)
@@ -181,7 +421,11 @@ func runOutfitSwap(cmd *cobra.Command, args []string) error {
 		}
 	} else {
 		// -t flag was provided
-		if outfitTestSubjects == "" {
+		if outfitTestSubjects == "" && len(inputs.Subjects) > 0 {
+			// No -t value: use the configured default subjects
+			outfitTestSubjects = strings.Join(inputs.Subjects, " ")
+			logger.Info("Using default subjects", "names", outfitTestSubjects)
+		} else if outfitTestSubjects == "" {
 			// -t provided with no value: use default "jaimee"
 			outfitTestSubjects = defaultSubject
 			logger.Info("Using default subject", "name", defaultSubject)
@@ -204,7 +448,12 @@ func runOutfitSwap(cmd *cobra.Command, args []string) error {
 					}
 				}
 				if !found {
-					return errors.ErrFileNotFound(subjectPath)
+					// Fall back to the subject library (subfolders, case, typo suggestions)
+					resolved, err := workspace.ResolveAssetPath("subject", subject)
+					if err != nil {
+						return err
+					}
+					subjectPath = resolved
 				}
 			}
 
@@ -216,7 +465,25 @@ func runOutfitSwap(cmd *cobra.Command, args []string) error {
 	now := time.Now()
 	dateFolder := now.Format("2006-01-02")
 	timestampFolder := now.Format("150405")
-	outputDir := filepath.Join("output", dateFolder, timestampFolder)
+	outputDir := filepath.Join(workspace.OutputPath(), dateFolder, timestampFolder)
+	if outfitResume != "" {
+		if info, err := os.Stat(outfitResume); err != nil || !info.IsDir() {
+			return errors.ErrInvalidInput("resume", fmt.Sprintf("output folder %s not found", outfitResume))
+		}
+		outputDir = outfitResume
+	}
+	if err := workflow.ValidateSample(outfitSample, outfitPairwise); err != nil {
+		return err
+	}
+	if outfitSample > 0 && !cmd.Flags().Changed("sample-seed") {
+		outfitSampleSeed = workflow.NewSampleSeed()
+	}
+	if outfitParallel < 1 {
+		return errors.ErrInvalidInput("parallel", "must be at least 1")
+	}
+	if limit := config.DefaultLimitsConfig().GenerateConcurrency; outfitParallel > limit {
+		output.Progress.Printf("ℹ️  --parallel %d: at most %d generation requests run at once (raise IMG_CLI_GENERATE_CONCURRENCY)\n", outfitParallel, limit)
+	}
 
 	// Create workflow options
 	options := workflow.WorkflowOptions{
@@ -226,18 +493,58 @@ func runOutfitSwap(cmd *cobra.Command, args []string) error {
 		Variations:      outfitVariations,
 		SendOriginal:    outfitSendOriginal,
 		SkipCostConfirm: outfitNoConfirm,
+		SkipPreflight:   outfitNoPreflight,
 		DebugPrompt:     outfitDebugPrompt,
 		// Modular components
-		HairStyleRef:   outfitHairStyle,
-		HairColorRef:   outfitHairColor,
-		MakeupRef:      outfitMakeup,
-		ExpressionRef:  outfitExpression,
-		AccessoriesRef: outfitAccessories,
-		OverOutfitRef:  outfitOverOutfit,
+		HairStyleRef:     outfitHairStyle,
+		HairColorRef:     outfitHairColor,
+		MakeupRef:        outfitMakeup,
+		ExpressionRef:    outfitExpression,
+		AccessoriesRef:   outfitAccessories,
+		OverOutfitRef:    outfitOverOutfit,
+		PoseRef:          outfitPose,
+		BackgroundRef:    outfitBackground,
+		Extra:            outfitExtraRefs.refs(),
+		Weights:          weights,
+		EnhanceText:      outfitEnhance,
+		StyleBlend:       outfitStyleBlend,
+		MaxDuration:      outfitMaxDuration,
+		OutfitCheck:      outfitCheck,
+		MaxAccessories:   outfitMaxAccess,
+		AllowImplausible: outfitImplausible,
+		Pick:             outfitPick,
+		Sample:           outfitSample,
+		SampleSeed:       outfitSampleSeed,
+		Pairwise:         outfitPairwise,
+		Resume:           outfitResume != "",
+		SkipExisting:     outfitSkipExist,
+		Parallel:         outfitParallel,
+		Ambient:          ambients,
+		Avoid:            workflow.ParseAvoid(outfitAvoid),
+		Aspect:           outfitAspect,
+		Resolution:       outfitResolution,
+		Seed:             outfitSeed,
+		Sampling:         sampling,
+		Strength:         outfitStrength,
+		NameTemplate:     outfitNameTmpl,
+		OrganizeBy:       outfitOrganizeBy,
+		Post:             workflow.PostOptions{LUTPath: outfitLUT, Upscale: upscale, Upscaler: outfitUpscaler, FaceLock: outfitFaceLock},
+		Chain:            chain,
+		Verify: workflow.VerifyOptions{
+			ColorCheck:       outfitVerifyColor,
+			Consistency:      outfitConsistency,
+			ColorTolerance:   outfitColorTol,
+			Review:           outfitReview,
+			Judge:            outfitJudge,
+			BestOf:           outfitBestOf,
+			Identity:         outfitValidateID,
+			MinIdentityScore: outfitMinIdentity,
+			IdentityRetries:  outfitIDRetries,
+		},
 	}
 
 	// Initialize orchestrator
-	orchestrator := workflow.NewOrchestrator(apiKey)
+	orchestrator := workflow.NewOrchestrator(apiKey, append(signOpts, workflow.WithAltTextRefinement(outfitRefineAlt), workflow.WithDryRun(outfitDryRun), progressOpt)...)
 
 	// Log the operation
 	logger.Info("Starting outfit-swap",
@@ -247,21 +554,45 @@ func runOutfitSwap(cmd *cobra.Command, args []string) error {
 		"variations", outfitVariations)
 
 	// Run the workflow
+	if !outfitDryRun {
+		notifier.start(outputDir)
+	}
 	result, err := orchestrator.RunWorkflow("outfit-swap", outfitPath, options)
+	if stderrors.Is(err, cost.ErrCancelled) {
+		notifier.cancel()
+		return nil
+	}
+	if result != nil {
+		notifier.record(result)
+		reportWorkflow(result)
+	}
 	if err != nil {
 		return errors.Wrapf(err, errors.WorkflowError, "outfit-swap failed")
 	}
+	if outfitDryRun {
+		printDryRunSummary(orchestrator.DryRunPrompts())
+		printThroughput(orchestrator.Throughput())
+		return nil
+	}
 
 	// Display results
-	fmt.Printf("\n✓ Outfit swap completed successfully\n")
-	fmt.Printf("Duration: %s\n", result.EndTime.Sub(result.StartTime))
+	output.Printf("\n✓ Outfit swap completed successfully\n")
+	output.Printf("Duration: %s\n", result.EndTime.Sub(result.StartTime))
 
 	// Count actual generated images (only "combined" type steps)
 	generatedCount := 0
+	chainedCount := 0
+	flaggedCount := 0
 	for _, step := range result.Steps {
 		if step.Type == "generation" && step.Name == "combined" {
 			generatedCount++
 		}
+		if step.Name == workflow.ChainArtStyle || step.Name == workflow.ChainStyleGuide {
+			chainedCount++
+		}
+		if step.Type == "generation" && len(step.Flags) > 0 {
+			flaggedCount++
+		}
 	}
 
 	// Build the summary based on what was actually done
@@ -291,7 +622,49 @@ func runOutfitSwap(cmd *cobra.Command, args []string) error {
 		summary = fmt.Sprintf("Created %d images", generatedCount)
 	}
 
-	fmt.Println(summary)
+	output.Println(summary)
+	if chainedCount > 0 {
+		output.Printf("🔗 Plus %d chained output(s) (%s)\n", chainedCount, strings.Join(outfitChain, ", "))
+	}
+	if result.Stopped {
+		output.Printf("⏱️  Stopped early: %d combinations remaining (see %s in %s; continue with --resume %s)\n",
+			result.Remaining, workflow.RunStateFile, outputDir, outputDir)
+	}
+	if flaggedCount > 0 {
+		output.Printf("⚠️  %d images flagged for review\n", flaggedCount)
+	}
+	if len(result.Failures) > 0 {
+		output.Printf("❌ %d generation(s) failed\n", len(result.Failures))
+	}
+	printConsistencySummary(result.Consistency)
+	printFailureSummary(result.Taxonomy)
+	printThroughput(orchestrator.Throughput())
+
+	if outfitHTMLReport {
+		reportPath := filepath.Join(outputDir, workflow.ReportFileName)
+		if err := workflow.WriteHTMLReport(reportPath, result); err != nil {
+			return errors.Wrap(err, errors.FileError, "failed to write HTML report")
+		}
+		output.Printf("📄 Report: %s\n", reportPath)
+		output.AddFiles(reportPath)
+	}
+	if outfitSheet {
+		sheetPath := filepath.Join(outputDir, workflow.ContactSheetFileName)
+		if err := workflow.WriteContactSheet(sheetPath, result); err != nil {
+			logger.Warn("Contact sheet not written", "error", err)
+		} else {
+			output.Printf("🖼️  Contact sheet: %s\n", sheetPath)
+			output.AddFiles(sheetPath)
+		}
+	}
+	if outfitReport == "html" {
+		if galleryPath, err := workflow.WriteGallery(outputDir); err != nil {
+			logger.Warn("Gallery not written", "error", err)
+		} else {
+			output.Printf("🌐 Gallery: %s\n", galleryPath)
+			output.AddFiles(galleryPath)
+		}
+	}
 
 	logger.Info("Outfit swap completed",
 		"duration", result.EndTime.Sub(result.StartTime),
@@ -302,14 +675,19 @@ func runOutfitSwap(cmd *cobra.Command, args []string) error {
 
 // moveToOutfitsIfExternal moves an image to the outfits folder if it's from an external location
 func moveToOutfitsIfExternal(imagePath string) (string, error) {
+	// Read-only asset folders are never written to; use the image where it is
+	if workspace.ReadOnlyAssets() {
+		return imagePath, nil
+	}
+
 	// Clean and convert to absolute path for comparison
 	absPath, err := filepath.Abs(imagePath)
 	if err != nil {
 		return imagePath, err
 	}
 
-	// Get the absolute path of the outfits directory
-	outfitsDir, err := filepath.Abs("outfits")
+	// Get the absolute path of the outfits directory (the active project's, if any)
+	outfitsDir, err := filepath.Abs(workspace.ProjectPath("outfits"))
 	if err != nil {
 		return imagePath, err
 	}
@@ -319,12 +697,15 @@ func moveToOutfitsIfExternal(imagePath string) (string, error) {
 		return imagePath, err
 	}
 
-	// Check if the image is already in the outfits folder or a subfolder
-	relPath, err := filepath.Rel(outfitsDir, absPath)
-	if err == nil && !strings.HasPrefix(relPath, "..") {
-		// Image is already in outfits folder or subfolder
-		logger.Debug("Image already in outfits folder", "path", imagePath)
-		return imagePath, nil
+	// Check if the image is already in the outfits folder, the shared one or a subfolder
+	sharedDir, _ := filepath.Abs(workspace.Path("outfits"))
+	for _, dir := range []string{outfitsDir, sharedDir} {
+		relPath, err := filepath.Rel(dir, absPath)
+		if err == nil && !strings.HasPrefix(relPath, "..") {
+			// Image is already in outfits folder or subfolder
+			logger.Debug("Image already in outfits folder", "path", imagePath)
+			return imagePath, nil
+		}
 	}
 
 	// Check if file is a directory (batch processing case)
@@ -376,10 +757,80 @@ func moveToOutfitsIfExternal(imagePath string) (string, error) {
 		"to", destPath)
 
 	// Return the new path relative to current directory
-	relPath, err = filepath.Rel(".", destPath)
+	relPath, err := filepath.Rel(".", destPath)
 	if err != nil {
 		// If relative path fails, just use the destination path
 		return destPath, nil
 	}
 	return relPath, nil
-}
\ No newline at end of file
+}
+
+// printConsistencySummary reports the aggregate variation consistency of a run
+func printConsistencySummary(scores []workflow.ConsistencyScore) {
+	if len(scores) == 0 {
+		return
+	}
+	identity, color, unstable := workflow.AverageConsistency(scores)
+	identityText := "n/a"
+	if identity >= 0 {
+		identityText = fmt.Sprintf("%.2f", identity)
+	}
+	output.Printf("📏 Consistency across %d combination(s): identity %s, outfit color %.2f\n", len(scores), identityText, color)
+	if unstable == 0 {
+		return
+	}
+	output.Printf("⚠️  %d unstable combination(s) may need prompt reinforcement or more variations:\n", unstable)
+	for _, score := range scores {
+		if score.Unstable {
+			output.Printf("   - %s\n", score.Combination)
+		}
+	}
+}
+
+// printFailureSummary reports the failure labels of a run and their likely culprits
+func printFailureSummary(summary *workflow.FailureSummary) {
+	if summary == nil {
+		return
+	}
+	var counts []string
+	for _, label := range summary.Labels {
+		counts = append(counts, fmt.Sprintf("%s %d", label.Label, label.Count))
+	}
+	output.Printf("🏷️  Failure labels: %s\n", strings.Join(counts, ", "))
+	for _, suggestion := range summary.Suggestions {
+		output.Printf("   - %s\n", suggestion)
+	}
+}
+
+// progressOption validates a --progress format and reports batch progress in it with the command's text
+func progressOption(format string) (workflow.Option, error) {
+	reporter, err := workflow.NewProgressReporter(format, output.Writer())
+	if err != nil {
+		return nil, errors.ErrInvalidInput("progress", err.Error())
+	}
+	return workflow.WithProgress(reporter), nil
+}
+
+// printDryRunSummary reports the prompts a dry run planned instead of generating
+func printDryRunSummary(prompts []string) {
+	output.AddFiles(prompts...)
+	output.Count("planned", len(prompts))
+	output.Printf("\n📝 Dry run: %d generation prompt(s) planned (%s if generated), no generation requests sent\n",
+		len(prompts), cost.Format(cost.Of(len(prompts))))
+	if len(prompts) > 0 {
+		output.Printf("   Prompts written to: %s\n", filepath.Dir(prompts[0]))
+	}
+}
+
+// printThroughput reports the request rate achieved for each kind of API call
+func printThroughput(stats []gemini.Throughput) {
+	for _, t := range stats {
+		line := fmt.Sprintf("📶 %s: %d request(s) in %s, %.2f req/s effective", t.Operation, t.Requests, t.Elapsed.Round(time.Second), t.EffectiveRPS)
+		if t.Throttled > 0 {
+			line += fmt.Sprintf(", %d throttled", t.Throttled)
+		}
+		output.Printf("%s (rate settled at %.2f/s)\n", line, t.FinalRPS)
+		logger.Debug("API throughput", "operation", t.Operation, "requests", t.Requests, "throttled", t.Throttled,
+			"elapsed", t.Elapsed, "effective_rps", t.EffectiveRPS, "final_rps", t.FinalRPS)
+	}
+}
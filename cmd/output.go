@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"img-cli/pkg/cache"
+	"img-cli/pkg/cost"
+	"img-cli/pkg/output"
+	"img-cli/pkg/workflow"
+)
+
+// reportTotals adds what the command spent and its cache use to the
+// --output-format json result
+func reportTotals() {
+	session := cost.Session()
+	if session.EstimatedImages > 0 || session.Images > 0 {
+		output.SetCost(output.Cost{
+			EstimatedImages: session.EstimatedImages,
+			EstimatedUSD:    cost.Of(session.EstimatedImages),
+			Images:          session.Images,
+			SpentUSD:        session.Spent,
+			PerImageUSD:     cost.PerImage(),
+		})
+	}
+	if session.Cancelled {
+		output.Cancel()
+	}
+	if counters := cache.Activity(); counters.Hits+counters.Misses+counters.Writes > 0 {
+		output.SetCache(output.CacheStats(counters))
+	}
+}
+
+// reportWorkflow adds the images, counts and failures of a workflow result to
+// the command's --output-format json result
+func reportWorkflow(result *workflow.WorkflowResult) {
+	var images, chained, flagged []string
+	for _, step := range result.Steps {
+		if step.Type != "generation" || step.OutputPath == "" {
+			continue
+		}
+		if step.Name == workflow.ChainArtStyle || step.Name == workflow.ChainStyleGuide {
+			chained = append(chained, step.OutputPath)
+		} else {
+			images = append(images, step.OutputPath)
+		}
+		if len(step.Flags) > 0 {
+			flagged = append(flagged, step.OutputPath)
+		}
+	}
+	output.AddFiles(images...)
+	output.AddFiles(chained...)
+	output.Count("images", len(images))
+	output.Count("chained", len(chained))
+	output.Count("flagged", len(flagged))
+	output.Count("failed", len(result.Failures))
+	output.Count("subjects", result.SubjectCount)
+	output.Count("outfits", result.OutfitCount)
+	output.Count("styles", result.StyleCount)
+	output.Count("variations", result.VariationCount)
+	if result.Stopped {
+		output.Count("remaining", result.Remaining)
+	}
+	if len(flagged) > 0 {
+		output.Set("flagged", flagged)
+	}
+	if len(result.Failures) > 0 {
+		output.Set("failures", result.Failures)
+	}
+	if len(result.Consistency) > 0 {
+		output.Set("consistency", result.Consistency)
+	}
+}
+
+// reportImages adds the images of a workflow that returns only their paths
+// to the command's --output-format json result, with the review flags of each
+func reportImages(paths []string, flags func(string) []string) {
+	output.AddFiles(paths...)
+	output.Count("images", len(paths))
+	review := make(map[string][]string)
+	for _, path := range paths {
+		if f := flags(path); len(f) > 0 {
+			review[path] = f
+		}
+	}
+	output.Count("flagged", len(review))
+	if len(review) > 0 {
+		output.Set("flagged", review)
+	}
+}
@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"fmt"
+	"img-cli/pkg/output"
+	"img-cli/pkg/usage"
+	"img-cli/pkg/workspace"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	projectDescription string
+	projectBudget      float64
+)
+
+// projectCmd groups commands that manage client projects
+var projectCmd = &cobra.Command{
+	Use:   "project",
+	Short: "Manage client projects",
+	Long: `Manage client projects.
+
+A project keeps one client's work apart from everyone else's: its outputs and
+analysis caches live under projects/<name>/, and reference folders there
+(outfits/, styles/, subjects/, ...) are searched before the shared ones at the
+project root. A project can have a budget; runs that would take it over the
+budget are refused. Its spend is the total of its calls in the usage ledger
+(see "img-cli usage").
+
+Select a project per command with --project (or IMG_CLI_PROJECT), or make one
+the default with "project switch".
+
+Available subcommands:
+  list   - List projects with their spend and budget
+  create - Create a project
+  switch - Set the default project ("none" to go back to the shared tree)`,
+	Annotations: map[string]string{
+		annotationNoAPIKey: "true",
+	},
+}
+
+var projectListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List projects",
+	Args:  cobra.NoArgs,
+	Annotations: map[string]string{
+		annotationNoAPIKey: "true",
+	},
+	RunE: runProjectList,
+}
+
+var projectCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a project",
+	Long: `Create a client project under projects/<name>/.
+
+Examples:
+  img-cli project create acme --budget 200 --description "Acme spring catalog"
+  img-cli outfit-swap suit -t kat --project acme`,
+	Args: cobra.ExactArgs(1),
+	Annotations: map[string]string{
+		annotationNoAPIKey: "true",
+	},
+	RunE: runProjectCreate,
+}
+
+var projectSwitchCmd = &cobra.Command{
+	Use:   "switch <name|none>",
+	Short: "Set the default project",
+	Args:  cobra.ExactArgs(1),
+	Annotations: map[string]string{
+		annotationNoAPIKey: "true",
+	},
+	RunE: runProjectSwitch,
+}
+
+func init() {
+	rootCmd.AddCommand(projectCmd)
+	projectCmd.AddCommand(projectListCmd, projectCreateCmd, projectSwitchCmd)
+
+	projectCreateCmd.Flags().StringVar(&projectDescription, "description", "", "Short description of the project")
+	projectCreateCmd.Flags().Float64Var(&projectBudget, "budget", 0, "Total spend allowed in USD (0 = no cap)")
+}
+
+func runProjectList(cmd *cobra.Command, args []string) error {
+	projects, err := workspace.Projects()
+	if err != nil {
+		return err
+	}
+	if len(projects) == 0 {
+		output.Println("No projects yet. Create one with: img-cli project create <name>")
+		return nil
+	}
+
+	current := ""
+	if project := workspace.ActiveProject(); project != nil {
+		current = project.Name
+	}
+	type projectResult struct {
+		workspace.Project
+		SpentUSD float64 `json:"spent_usd"`
+		Active   bool    `json:"active"`
+	}
+	spent := usage.SpentByProject()
+	var results []projectResult
+	for _, project := range projects {
+		results = append(results, projectResult{project, spent[project.Name], project.Name == current})
+	}
+	output.Set("projects", results)
+
+	for _, project := range projects {
+		marker := " "
+		if project.Name == current {
+			marker = "*"
+		}
+		budget := "no budget"
+		if project.BudgetUSD > 0 {
+			budget = fmt.Sprintf("$%.2f budget", project.BudgetUSD)
+		}
+		output.Printf("%s %-20s $%.2f spent, %s", marker, project.Name, spent[project.Name], budget)
+		if project.Description != "" {
+			output.Printf("  %s", project.Description)
+		}
+		output.Println()
+	}
+	return nil
+}
+
+func runProjectCreate(cmd *cobra.Command, args []string) error {
+	project, err := workspace.CreateProject(args[0], projectDescription, projectBudget)
+	if err != nil {
+		return err
+	}
+	output.Set("project", project)
+	output.Printf("✓ Created project %s in %s\n", project.Name, workspace.Path(workspace.ProjectsDir, project.Name))
+	output.Printf("  Use it with --project %s or: img-cli project switch %s\n", project.Name, project.Name)
+	return nil
+}
+
+func runProjectSwitch(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	if strings.EqualFold(name, "none") {
+		name = ""
+	}
+	if err := workspace.SwitchProject(name); err != nil {
+		return err
+	}
+	output.Set("project", name)
+	if name == "" {
+		output.Println("✓ No default project; using the shared tree")
+	} else {
+		output.Printf("✓ Default project is now %s\n", name)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"fmt"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/output"
+	"img-cli/pkg/prompts"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var promptsExportForce bool
+
+// promptsCmd groups commands that manage prompt templates
+var promptsCmd = &cobra.Command{
+	Use:   "prompts",
+	Short: "Manage prompt templates",
+	Long: `Manage the templates that generation and analysis prompts are built from.
+
+Every prompt is a Go text/template compiled into the binary. A file named
+<template>.tmpl in the prompts folder (prompts/ in the project root, or
+IMG_CLI_PROMPTS_DIR) replaces the built-in one on the next run, no rebuild
+needed. A replacement that fails to parse or run fails the step that uses it,
+naming the file, rather than silently sending a different prompt.
+
+Available subcommands:
+  list   - List the templates and which ones are replaced
+  show   - Print the template in use
+  export - Copy built-in templates into the prompts folder to edit them`,
+	Annotations: map[string]string{
+		annotationNoAPIKey: "true",
+	},
+}
+
+var promptsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List prompt templates",
+	Args:  cobra.NoArgs,
+	Annotations: map[string]string{
+		annotationNoAPIKey: "true",
+	},
+	RunE: runPromptsList,
+}
+
+var promptsShowCmd = &cobra.Command{
+	Use:   "show <template>",
+	Short: "Print the template in use",
+	Args:  cobra.ExactArgs(1),
+	Annotations: map[string]string{
+		annotationNoAPIKey: "true",
+	},
+	RunE: runPromptsShow,
+}
+
+var promptsExportCmd = &cobra.Command{
+	Use:   "export [template...]",
+	Short: "Copy built-in templates into the prompts folder",
+	Long: `Copy built-in templates into the prompts folder, where edits replace them.
+
+Without arguments every template is exported. Existing files are kept unless
+--force is given.
+
+Examples:
+  img-cli prompts export modular
+  img-cli prompts export --force`,
+	Annotations: map[string]string{
+		annotationNoAPIKey: "true",
+	},
+	RunE: runPromptsExport,
+}
+
+func init() {
+	rootCmd.AddCommand(promptsCmd)
+	promptsCmd.AddCommand(promptsListCmd, promptsShowCmd, promptsExportCmd)
+
+	promptsExportCmd.Flags().BoolVar(&promptsExportForce, "force", false, "Overwrite templates already in the prompts folder")
+}
+
+func runPromptsList(cmd *cobra.Command, args []string) error {
+	output.Printf("Prompt templates (replacements in %s):\n", prompts.Dir())
+	overrides := make(map[string]string) // Template name to replacement file, "" = built-in
+	for _, name := range prompts.Names() {
+		overrides[name] = prompts.Override(name)
+		if path := overrides[name]; path != "" {
+			output.Printf("  %-28s replaced by %s\n", name, path)
+		} else {
+			output.Printf("  %-28s built-in\n", name)
+		}
+	}
+	output.Set("templates", overrides)
+	return nil
+}
+
+func runPromptsShow(cmd *cobra.Command, args []string) error {
+	name := strings.TrimSuffix(args[0], prompts.Ext)
+	if path := prompts.Override(name); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return errors.Wrapf(err, errors.FileError, "failed to read %s", path)
+		}
+		output.Set("path", path)
+		output.Set("source", string(data))
+		output.Printf("# %s\n%s", path, data)
+		return nil
+	}
+	source, ok := prompts.Default(name)
+	if !ok {
+		return errors.ErrInvalidInput("template", fmt.Sprintf("unknown template %q (see 'prompts list')", name))
+	}
+	output.Set("source", source)
+	output.Printf("# built-in\n%s", source)
+	return nil
+}
+
+func runPromptsExport(cmd *cobra.Command, args []string) error {
+	names := prompts.Names()
+	if len(args) > 0 {
+		names = nil
+		for _, arg := range args {
+			name := strings.TrimSuffix(arg, prompts.Ext)
+			if _, ok := prompts.Default(name); !ok {
+				return errors.ErrInvalidInput("template", fmt.Sprintf("unknown template %q (see 'prompts list')", name))
+			}
+			names = append(names, name)
+		}
+	}
+
+	dir := prompts.Dir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.Wrapf(err, errors.FileError, "failed to create %s", dir)
+	}
+	exported := 0
+	for _, name := range names {
+		path := filepath.Join(dir, name+prompts.Ext)
+		if _, err := os.Stat(path); err == nil && !promptsExportForce {
+			output.Printf("  - %s exists, kept (use --force to overwrite)\n", path)
+			continue
+		}
+		source, _ := prompts.Default(name)
+		if err := os.WriteFile(path, []byte(source), 0644); err != nil {
+			return errors.Wrapf(err, errors.FileError, "failed to write %s", path)
+		}
+		output.Printf("  ✓ %s\n", path)
+		output.AddFiles(path)
+		exported++
+	}
+	output.Printf("Exported %d template(s) to %s\n", exported, dir)
+	return nil
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	stderrors "errors"
+	"fmt"
+	"img-cli/pkg/cost"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/logger"
+	"img-cli/pkg/output"
+	"img-cli/pkg/workflow"
+	"img-cli/pkg/workspace"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	regenSet        []string
+	regenVariations int
+	regenDebug      bool
+	regenOutputDir  string
+	regenSign       bool
+	regenRefineAlt  bool
+	regenSeed       int64
+)
+
+// regenCmd regenerates a previous output from its recorded recipe
+var regenCmd = &cobra.Command{
+	Use:   "regen <image-or-metadata.json>",
+	Short: "Regenerate a previous image with some components changed",
+	Long: `Regenerate a previous output from the recipe recorded in its .json sidecar,
+optionally replacing components with --set.
+
+Every generated image has a sidecar listing the subject, each component input
+and the generation settings. regen loads that recipe, applies the overrides and
+runs a modular generation, so "same everything, tweak one component" is a
+single command.
+
+Components for --set: subject, outfit, over-outfit, style, hair-style,
+hair-color, makeup, expression, accessories, pose, background. Values are
+image paths or text descriptions, as with generate-modular; "none" removes a
+component.
+
+Examples:
+  # Same image, different hair color, two variations
+  img-cli regen output/2024-01-15/143022/suit_dramatic_kat_20240115_143028.json \
+    --set hair-color="copper red" --variations 2
+
+  # Pass the image instead of the sidecar, swap the style and drop the makeup
+  img-cli regen output/2024-01-15/143022/suit_dramatic_kat_20240115_143028.png \
+    --set style=styles/night.png --set makeup=none`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRegen,
+}
+
+func init() {
+	rootCmd.AddCommand(regenCmd)
+
+	regenCmd.Flags().StringArrayVar(&regenSet, "set", nil, "Replace a component: component=value (repeatable)")
+	regenCmd.Flags().IntVarP(&regenVariations, "variations", "v", 1, "Number of variations to generate")
+	regenCmd.Flags().BoolVar(&regenDebug, "debug", false, "Show debug information including prompts")
+	regenCmd.Flags().StringVarP(&regenOutputDir, "output", "o", "", "Output directory (default: new timestamped folder)")
+	regenCmd.Flags().BoolVar(&regenSign, "sign", false, "Embed signed C2PA content credentials in each image")
+	regenCmd.Flags().Int64Var(&regenSeed, "seed", 0, "Generation seed (default: the image's own seed, so the first variation reproduces it; 0 = random)")
+	regenCmd.Flags().BoolVar(&regenRefineAlt, "refine-alt-text", false, "Polish each image's alt text with a text request")
+}
+
+func runRegen(cmd *cobra.Command, args []string) error {
+	sidecar, err := workflow.ReadSidecar(workspace.Resolve(args[0]))
+	if err != nil {
+		return errors.Wrapf(err, errors.FileError, "failed to read metadata for %s", args[0])
+	}
+
+	config, err := sidecar.Recipe()
+	if err != nil {
+		return err
+	}
+
+	for _, change := range sidecar.ChangedInputs() {
+		output.Printf("⚠️  %s\n", change)
+	}
+
+	for _, override := range regenSet {
+		component, value, ok := strings.Cut(override, "=")
+		if !ok {
+			return errors.ErrInvalidInput("set", fmt.Sprintf("expected component=value, got %q", override))
+		}
+		if err := workflow.ApplyOverride(&config, strings.TrimSpace(component), strings.TrimSpace(value)); err != nil {
+			return err
+		}
+	}
+
+	if !fileExists(config.SubjectPath) {
+		return errors.ErrInvalidInput("subject", fmt.Sprintf("file not found: %s", config.SubjectPath))
+	}
+	if err := validateLUTFlag(config.Post.LUTPath); err != nil {
+		return err
+	}
+	if regenVariations < 1 {
+		return errors.ErrInvalidInput("variations", "must be at least 1")
+	}
+	if cmd.Flags().Changed("seed") {
+		if err := workflow.ValidateSeed(regenSeed); err != nil {
+			return err
+		}
+		config.Seed = regenSeed
+	}
+	signOpts, err := signerOptions(regenSign)
+	if err != nil {
+		return err
+	}
+
+	config.Variations = regenVariations
+	config.Debug = regenDebug
+	config.OutputDir = regenOutputDir
+
+	output.Progress.Printf("♻️  Regenerating %s\n", sidecar.Image)
+	inputs := workflow.RecipeInputs(config)
+	for _, name := range workflow.RecipeComponents() {
+		if value, ok := inputs[name]; ok {
+			output.Progress.Printf("   %-12s %s\n", name+":", value)
+		}
+	}
+	if config.Seed != 0 {
+		output.Progress.Printf("   %-12s %d\n", "seed:", config.Seed)
+	}
+	images := config.Variations + config.Post.ExtraImages(config.Variations)
+	calls := workflow.ExtraCalls(config.Variations, regenRefineAlt)
+	output.Progress.Printf("   Images to generate: %d (%s)\n", images, cost.Format(cost.Of(images)))
+	cost.PrintCalls(calls)
+	output.Progress.Println()
+	if err := cost.Check(images, cost.CheckOptions{Calls: calls}); err != nil {
+		if stderrors.Is(err, cost.ErrCancelled) {
+			return nil
+		}
+		return err
+	}
+
+	logger.Info("Starting regeneration",
+		"source", sidecar.Image,
+		"overrides", len(regenSet),
+		"variations", config.Variations)
+
+	orchestrator := workflow.NewOrchestrator(apiKey, append(signOpts, workflow.WithAltTextRefinement(regenRefineAlt))...)
+	results, err := orchestrator.RunModularWorkflow(config)
+	if err != nil {
+		return errors.Wrap(err, errors.WorkflowError, "regeneration failed")
+	}
+
+	reportImages(results, orchestrator.ReviewFlags)
+	output.Printf("\n✅ Regenerated %d image(s)\n", len(results))
+	if len(results) > 0 {
+		output.Printf("   Output directory: %s\n", filepath.Dir(results[0]))
+	}
+	for _, path := range results {
+		if flags := orchestrator.ReviewFlags(path); len(flags) > 0 {
+			output.Printf("   ⚠️  Flagged for review: %s (%s)\n", filepath.Base(path), strings.Join(flags, ", "))
+		}
+	}
+
+	return nil
+}
@@ -4,7 +4,15 @@ package cmd
 
 import (
 	"fmt"
+	"img-cli/pkg/config"
+	"img-cli/pkg/cost"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/gemini"
 	"img-cli/pkg/logger"
+	"img-cli/pkg/output"
+	"img-cli/pkg/usage"
+	"img-cli/pkg/watermark"
+	"img-cli/pkg/workspace"
 	"os"
 
 	"github.com/joho/godotenv"
@@ -15,10 +23,33 @@ var (
 	// Global flags
 	logLevel   string
 	jsonLog    bool
+	logFile    string
+	quiet      bool
+	verbose    bool
 	configFile string
 	apiKey     string
+	errorsJSON bool
+	project    string
+	readOnly   bool
+	provider   string
+	maxBudget  float64
+	watermarks bool
+	outputFmt  string
+
+	// Active run for commands that call the API (holds the project lock)
+	currentRun *workspace.Run
+
+	// Closes the --log-file
+	closeLog func() error
 )
 
+// annotationNoAPIKey marks commands that run locally and don't need GEMINI_API_KEY
+const annotationNoAPIKey = "img-cli/no-api-key"
+
+// annotationLockPerRun marks long-running commands that take the project lock
+// around each run they start instead of for their whole lifetime
+const annotationLockPerRun = "img-cli/lock-per-run"
+
 // rootCmd represents the base command
 var rootCmd = &cobra.Command{
 	Use:   "img-cli",
@@ -38,20 +69,45 @@ Examples:
 Additional Commands:
   analyze - Analyze images for outfit, visual style, or art style
   generate - Generate images with specific transformations
-  cache - Manage analysis cache`,
+  cache - Manage analysis cache
+  style - Tools for style references (LUT extraction)`,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		// Set up logging
-		level := logger.ParseLevel(logLevel)
-		log := logger.NewLogger(level, jsonLog)
-		logger.SetDefault(log)
+		// Keep stdout for the result object with --output-format json
+		if err := output.SetFormat(outputFmt); err != nil {
+			return err
+		}
+		output.Start(cmd.CommandPath())
+
+		// Set up the console and logging: warnings on the console (errors
+		// with --quiet, info with --verbose, or what --log-level asks for),
+		// everything from --log-level up in the --log-file
+		if err := setupConsole(cmd); err != nil {
+			return err
+		}
 
 		// Load environment variables
-		if configFile != "" {
-			if err := godotenv.Load(configFile); err != nil {
-				logger.Warnf("Failed to load config file %s: %v", configFile, err)
+		if err := loadConfig(); err != nil {
+			return err
+		}
+
+		// Select the client project: flag, then environment, then `project switch`
+		if project == "" {
+			project = os.Getenv("IMG_CLI_PROJECT")
+		}
+		if project == "" {
+			project = workspace.DefaultProject()
+		}
+		workspace.SetReadOnlyAssets(readOnly)
+		watermark.SetEnabled(watermarks)
+		if maxBudget < 0 {
+			return errors.ErrInvalidInput("max-budget", "must not be negative")
+		}
+		cost.SetMaxBudget(maxBudget)
+		if err := workspace.UseProject(project); err != nil {
+			if cmd.Parent() != projectCmd {
+				return err
 			}
-		} else {
-			godotenv.Load() // Try to load .env file
+			logger.Warn("Ignoring the selected project", "project", project, "error", err)
 		}
 
 		// Get API key from flag or environment
@@ -59,9 +115,39 @@ Additional Commands:
 			apiKey = os.Getenv("GEMINI_API_KEY")
 		}
 
-		if apiKey == "" {
-			return fmt.Errorf("GEMINI_API_KEY is required. Set via --api-key flag or GEMINI_API_KEY environment variable")
+		if cmd.Annotations[annotationNoAPIKey] == "true" || isCompletionCmd(cmd) {
+			return nil
+		}
+
+		// Select the image model API: flag, then IMG_CLI_PROVIDER
+		if provider == "" {
+			provider = gemini.ProviderConfig().Name
 		}
+		if err := gemini.SetDefaultProvider(provider); err != nil {
+			return err
+		}
+		providerCfg := gemini.ProviderConfig()
+		if apiKey == "" && providerCfg.Uses(config.ProviderGemini) {
+			return errors.New(errors.ConfigError, "GEMINI_API_KEY is required. Set via --api-key flag or GEMINI_API_KEY environment variable")
+		}
+		if providerCfg.OpenAIKey == "" && providerCfg.Uses(config.ProviderOpenAI) {
+			return errors.New(errors.ConfigError, "OPENAI_API_KEY is required for the openai provider")
+		}
+		if providerCfg.Name == config.ProviderSD && !config.DefaultLocalVisionConfig().Enabled() && providerCfg.Fallback == "" {
+			logger.Warn("The sd provider only generates images; set IMG_CLI_LOCAL_VISION_URL or IMG_CLI_FALLBACK_PROVIDER for analysis")
+		}
+
+		if cmd.Annotations[annotationLockPerRun] == "true" {
+			usage.SetWorkflow(cmd.CommandPath())
+			return nil
+		}
+
+		// Isolate this invocation from other runs in the same project
+		run, err := workspace.Start(workspace.Root(), cmd.CommandPath())
+		if err != nil {
+			return err
+		}
+		currentRun = run
 
 		return nil
 	},
@@ -69,15 +155,145 @@ Additional Commands:
 
 // Execute runs the root command
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		logger.Error("Command execution failed", "error", err)
-		os.Exit(1)
+	// Checked before parsing so even flag errors are reported as JSON
+	errorsAsJSON := hasErrorsJSONFlag(os.Args[1:])
+	if hasJSONOutputFlag(os.Args[1:]) {
+		output.SetFormat(output.FormatJSON)
+	}
+	if errorsAsJSON || output.JSON() {
+		rootCmd.SilenceErrors = true
+		rootCmd.SilenceUsage = true
+	}
+
+	registerCompletions(rootCmd)
+	err := rootCmd.Execute()
+	if currentRun != nil {
+		currentRun.Finish(err == nil)
+	}
+	reportTotals()
+	output.Finish(err)
+	if err != nil {
+		switch {
+		case errorsAsJSON:
+			if data, jerr := errors.MarshalReport(err); jerr == nil {
+				fmt.Fprintln(os.Stderr, string(data))
+			}
+		case output.JSON():
+			// Reported in the result object
+		default:
+			logger.Error("Command execution failed", "error", err)
+		}
+	}
+	if closeLog != nil {
+		closeLog()
+	}
+	if err != nil {
+		os.Exit(errors.ExitCode(err))
+	}
+}
+
+// setupConsole applies --quiet, --verbose, --log-level, --log-file and
+// --json-log
+func setupConsole(cmd *cobra.Command) error {
+	level := logger.ParseLevel(logLevel)
+	console := logger.WarnLevel
+	switch {
+	case cmd.Flags().Changed("log-level"):
+		console = level
+	case quiet:
+		console = logger.ErrorLevel
+	case verbose:
+		console = logger.InfoLevel
+	}
+	switch {
+	case quiet:
+		output.SetVerbosity(output.Quiet)
+	case verbose:
+		output.SetVerbosity(output.Verbose)
+	}
+
+	if closeLog != nil { // Set up again, e.g. for the command the wizard runs
+		closeLog()
+	}
+	var err error
+	closeLog, err = logger.Setup(logger.Options{Level: level, Console: console, JSON: jsonLog, File: logFile})
+	if err != nil {
+		return errors.Wrapf(err, errors.FileError, "failed to open --log-file %s", logFile)
+	}
+	return nil
+}
+
+// loadConfig fills in settings not already in the environment: from
+// --config (a .env, YAML or TOML file), or else from the project .env, the
+// project .img-cli.yaml and then ~/.img-cli.yaml or ~/.img-cli.toml
+func loadConfig() error {
+	if configFile != "" && !config.IsConfigFile(configFile) {
+		if err := godotenv.Load(configFile); err != nil {
+			logger.Warnf("Failed to load config file %s: %v", configFile, err)
+		}
+		return nil
+	}
+
+	files := []string{configFile}
+	if configFile == "" {
+		godotenv.Load(workspace.Path(".env")) // Try to load the project .env file
+		files = config.DefaultConfigFiles(workspace.Root())
+	}
+	for _, file := range files {
+		unknown, err := config.LoadFile(file)
+		if os.IsNotExist(err) && configFile == "" {
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, errors.ConfigError, "failed to load config file %s", file)
+		}
+		for _, key := range unknown {
+			logger.Warn("Ignoring unknown config key", "file", file, "key", key)
+		}
+	}
+	return nil
+}
+
+// hasErrorsJSONFlag reports whether --errors-json was passed
+func hasErrorsJSONFlag(args []string) bool {
+	for _, arg := range args {
+		if arg == "--" {
+			return false
+		}
+		if arg == "--errors-json" || arg == "--errors-json=true" {
+			return true
+		}
+	}
+	return false
+}
+
+// hasJSONOutputFlag reports whether --output-format json was passed
+func hasJSONOutputFlag(args []string) bool {
+	for i, arg := range args {
+		if arg == "--" {
+			return false
+		}
+		if arg == "--output-format="+output.FormatJSON || arg == "--output-format" && i+1 < len(args) && args[i+1] == output.FormatJSON {
+			return true
+		}
 	}
+	return false
 }
 
 func init() {
-	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "INFO", "Log level (DEBUG, INFO, WARN, ERROR)")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "INFO", "Log level (DEBUG, INFO, WARN, ERROR) of the --log-file; also shown on the console when given")
 	rootCmd.PersistentFlags().BoolVar(&jsonLog, "json-log", false, "Output logs in JSON format")
-	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default: .env)")
+	rootCmd.PersistentFlags().StringVar(&logFile, "log-file", "", "Append structured logs to this file")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Show only results, warnings and errors")
+	rootCmd.PersistentFlags().BoolVar(&verbose, "verbose", false, "Also show the details of each step and info logs")
+	rootCmd.MarkFlagsMutuallyExclusive("quiet", "verbose")
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path: .env, .yaml or .toml (default: .env, .img-cli.yaml, ~/.img-cli.yaml)")
 	rootCmd.PersistentFlags().StringVar(&apiKey, "api-key", "", "Gemini API key")
+	rootCmd.PersistentFlags().StringVar(&project, "project", "", "Client project whose outputs, caches and budget to use (default: IMG_CLI_PROJECT or the \"project switch\" default)")
+	rootCmd.PersistentFlags().StringVar(&provider, "provider", "", "Image model API: gemini, openai or sd (default: IMG_CLI_PROVIDER or gemini)")
+	rootCmd.PersistentFlags().Float64Var(&maxBudget, "max-budget", 0, "Hard cap in dollars on what a run may spend; runs estimated above it are refused (default: IMG_CLI_MAX_COST)")
+	rootCmd.PersistentFlags().BoolVar(&readOnly, "readonly-assets", false, "Never write caches or copied references into the asset folders (also IMG_CLI_READONLY_ASSETS=true)")
+	rootCmd.PersistentFlags().BoolVar(&watermarks, "watermark", false, "Mark every generated PNG as AI-generated with an invisible watermark (also watermark: true in the config)")
+	rootCmd.PersistentFlags().StringVar(&outputFmt, "output-format", output.FormatText, "Result format: text, or json for one machine-readable result object on stdout (progress moves to stderr)")
+	rootCmd.PersistentFlags().BoolVar(&errorsJSON, "errors-json", false, "Print failures as JSON on stderr with a stable type and exit code")
 }
\ No newline at end of file
@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"context"
+	stderrors "errors"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/imgcli"
+	"img-cli/pkg/jobs"
+	"img-cli/pkg/logger"
+	"img-cli/pkg/output"
+	"img-cli/pkg/server"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	servePort  int
+	serveHost  string
+	serveToken string
+)
+
+// serveCmd runs the HTTP API server
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the analysis and generation pipeline over HTTP",
+	Long: `Run an HTTP server that exposes the same pipeline as the CLI, so a web UI
+or other services can drive it without shelling out.
+
+Endpoints (JSON in and out):
+  GET  /health                     liveness check and number of pending jobs
+  POST /uploads                    an image as the body; answers {"image": ".img-cli/uploads/<hash>.png"}
+  POST /analyze                    {"type": "outfit", "image": "outfits/suit.png"}
+  POST /jobs/modular               a recipe: {"subject": "kat", "outfit": "suit", "hair_color": "copper red", "variations": 2}
+  POST /jobs/outfit-swap           {"outfit": "suit", "style": "night", "subjects": ["kat", "jaimee"]}
+  GET  /jobs                       every job, oldest first
+  GET  /jobs/{id}                  status (queued, running, completed, failed, cancelled), images and failures
+  POST /jobs/{id}/cancel           cancel a queued job, or stop a running one before its next combination
+  GET  /jobs/{id}/images/{name}    download a generated image
+
+Images are given as asset names, text descriptions or paths inside the
+workspace. The server never reads files outside the workspace or fetches URLs
+for a client: post images from elsewhere to /uploads and use the path it
+answers. Jobs run one at a time in a folder of their own under output/, each
+holding the project lock while it runs, so other img-cli commands can use the
+project between jobs. Cost confirmation is never asked; --max-budget and
+IMG_CLI_MAX_COST still refuse jobs over the cap.
+
+Jobs are saved under .img-cli/jobs, so queued jobs survive a restart and a job
+that was running when the server stopped runs again, keeping the images it
+already made. Use "img-cli jobs" to list, inspect and cancel them from another
+terminal.
+
+With --token (or IMG_CLI_SERVE_TOKEN), every endpoint but /health needs an
+"Authorization: Bearer <token>" header. The server listens on localhost by
+default and refuses other --host addresses without a token.
+
+Examples:
+  img-cli serve --port 8080
+  curl -X POST localhost:8080/jobs/modular -d '{"subject": "kat", "outfit": "suit"}'
+  curl localhost:8080/jobs/1
+  IMG_CLI_SERVE_TOKEN=s3cret img-cli serve --host 0.0.0.0
+  curl -H "Authorization: Bearer s3cret" --data-binary @suit.png host:8080/uploads`,
+	Annotations: map[string]string{
+		annotationLockPerRun: "true",
+	},
+	RunE: runServe,
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().IntVar(&servePort, "port", 8080, "Port to listen on")
+	serveCmd.Flags().StringVar(&serveHost, "host", "127.0.0.1", "Address to listen on (0.0.0.0 for all interfaces, needs --token)")
+	serveCmd.Flags().StringVar(&serveToken, "token", os.Getenv("IMG_CLI_SERVE_TOKEN"), "Bearer token clients must send (env IMG_CLI_SERVE_TOKEN)")
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	if servePort < 1 || servePort > 65535 {
+		return errors.ErrInvalidInput("port", "must be between 1 and 65535")
+	}
+	if serveToken == "" && !loopbackHost(serveHost) {
+		return errors.ErrInvalidInput("host", "listening on "+serveHost+" needs --token or IMG_CLI_SERVE_TOKEN")
+	}
+
+	// Each job takes the project lock while it runs, not the clients
+	analyzer, err := imgcli.NewClient(imgcli.Config{APIKey: apiKey})
+	if err != nil {
+		return err
+	}
+	runner, err := imgcli.NewClient(imgcli.Config{APIKey: apiKey})
+	if err != nil {
+		return err
+	}
+
+	store, err := jobs.Open(jobs.DefaultDir())
+	if err != nil {
+		return err
+	}
+	recovered, err := store.Recover()
+	if err != nil {
+		return err
+	}
+	if queued, err := store.Queued(); err == nil && queued > 0 {
+		output.Printf("♻️  Resuming %d queued job(s)", queued)
+		interrupted := 0
+		for _, job := range recovered {
+			if job.Status == jobs.StatusQueued {
+				interrupted++
+			}
+		}
+		if interrupted > 0 {
+			output.Printf(", %d interrupted by the last shutdown", interrupted)
+		}
+		output.Println()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	srv := server.New(analyzer, runner, store, server.Options{Token: serveToken})
+	go srv.Work(ctx)
+
+	addr := net.JoinHostPort(serveHost, strconv.Itoa(servePort))
+	httpServer := &http.Server{
+		Addr:              addr,
+		Handler:           srv.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return errors.Wrapf(err, errors.ConfigError, "failed to listen on %s", addr)
+	}
+
+	output.Printf("🌐 Serving on http://%s (Ctrl+C to stop)\n", listener.Addr())
+	logger.Info("Server started", "address", listener.Addr().String())
+
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		httpServer.Shutdown(shutdown)
+	}()
+
+	if err := httpServer.Serve(listener); err != nil && !stderrors.Is(err, http.ErrServerClosed) {
+		return errors.Wrap(err, errors.InternalError, "server failed")
+	}
+	if pending := srv.Pending(); pending > 0 {
+		logger.Warn("Server stopped with unfinished jobs", "pending", pending)
+		output.Printf("\n⏸️  %d unfinished job(s) will resume on the next start\n", pending)
+	}
+	output.Println("\n👋 Server stopped")
+	return nil
+}
+
+// loopbackHost reports whether a --host address only accepts local connections
+func loopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
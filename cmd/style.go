@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"img-cli/pkg/errors"
+	"img-cli/pkg/imaging"
+	"img-cli/pkg/logger"
+	"img-cli/pkg/output"
+	"img-cli/pkg/workspace"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	lutOutput   string
+	lutSize     int
+	lutStrength float64
+	lutApply    []string
+)
+
+// styleCmd groups local tools that work on style reference images
+var styleCmd = &cobra.Command{
+	Use:   "style",
+	Short: "Tools for style reference images",
+	Long: `Tools for working with style reference images.
+
+Available subcommands:
+  lut - Extract a color grading LUT (.cube) from a style reference`,
+}
+
+// styleLUTCmd extracts a .cube LUT from a style reference image
+var styleLUTCmd = &cobra.Command{
+	Use:   "lut <style-image>",
+	Short: "Extract a color grading LUT from a style reference",
+	Long: `Extract the color grade of a style reference image as a 3D LUT in .cube format.
+
+The LUT captures per-channel tone curves (tints, split toning, channel contrast)
+and can be applied after generation with --lut, giving consistent color across
+a batch regardless of how closely the model followed the style. The .cube file
+also works in most photo and video editors.
+
+This command runs locally and does not call the Gemini API.
+
+Examples:
+  # Write styles/night.cube next to the reference
+  img-cli style lut ./styles/night.png
+
+  # Softer grade, custom output path
+  img-cli style lut ./styles/night.png --strength 0.6 -o ./luts/night-soft.cube
+
+  # Preview the grade on existing images (writes *_graded copies)
+  img-cli style lut ./styles/night.png --apply output/2024-01-15/143022/*.png
+
+  # Apply during generation
+  img-cli outfit-swap ./outfits/suit.png -s ./styles/night.png --lut ./styles/night.cube`,
+	Args: cobra.ExactArgs(1),
+	Annotations: map[string]string{
+		annotationNoAPIKey: "true",
+	},
+	RunE: runStyleLUT,
+}
+
+func init() {
+	rootCmd.AddCommand(styleCmd)
+	styleCmd.AddCommand(styleLUTCmd)
+
+	styleLUTCmd.Flags().StringVarP(&lutOutput, "output", "o", "", "Output .cube path (default: <style>.cube next to the reference)")
+	styleLUTCmd.Flags().IntVar(&lutSize, "size", imaging.DefaultLUTSize, "LUT grid size")
+	styleLUTCmd.Flags().Float64Var(&lutStrength, "strength", 1.0, "Grade strength from 0 (no change) to 1 (full)")
+	styleLUTCmd.Flags().StringSliceVar(&lutApply, "apply", nil, "Images to grade with the extracted LUT (writes <name>_graded copies)")
+}
+
+func runStyleLUT(cmd *cobra.Command, args []string) error {
+	stylePath, err := workspace.ResolveAssetPath("style", args[0])
+	if err != nil {
+		return err
+	}
+
+	if lutSize < 2 || lutSize > 256 {
+		return errors.Newf(errors.ValidationError, "--size must be between 2 and 256, got %d", lutSize)
+	}
+	if lutStrength < 0 || lutStrength > 1 {
+		return errors.Newf(errors.ValidationError, "--strength must be between 0 and 1, got %.2f", lutStrength)
+	}
+
+	img, err := imaging.Load(stylePath)
+	if err != nil {
+		return errors.Wrapf(err, errors.FileError, "failed to load style image")
+	}
+
+	stem := strings.TrimSuffix(filepath.Base(stylePath), filepath.Ext(stylePath))
+	if lutOutput == "" {
+		lutOutput = filepath.Join(filepath.Dir(stylePath), stem+".cube")
+	}
+
+	lut := imaging.ExtractLUT(img, lutSize, lutStrength)
+	lut.Title = stem
+	if err := lut.SaveCube(lutOutput); err != nil {
+		return errors.Wrapf(err, errors.FileError, "failed to write LUT")
+	}
+
+	output.Printf("✓ LUT extracted from %s\n", filepath.Base(stylePath))
+	output.Printf("  Saved to: %s (%d³ grid)\n", lutOutput, lutSize)
+	output.AddFiles(lutOutput)
+	logger.Info("LUT extracted", "style", stylePath, "output", lutOutput, "size", lutSize, "strength", lutStrength)
+
+	for _, target := range lutApply {
+		src, err := imaging.Load(target)
+		if err != nil {
+			output.Printf("  Warning: skipping %s: %v\n", target, err)
+			continue
+		}
+
+		ext := filepath.Ext(target)
+		gradedPath := strings.TrimSuffix(target, ext) + "_graded" + ext
+		if err := imaging.Save(gradedPath, lut.Apply(src)); err != nil {
+			output.Printf("  Warning: failed to save %s: %v\n", gradedPath, err)
+			continue
+		}
+		output.Printf("  Graded: %s\n", gradedPath)
+		output.AddFiles(gradedPath)
+	}
+
+	return nil
+}
@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"fmt"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/output"
+	"img-cli/pkg/subjects"
+	"img-cli/pkg/workspace"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	subjectAliases   []string
+	subjectHairColor string
+	subjectKeep      []string
+	subjectNotes     string
+)
+
+// subjectsCmd groups commands that manage the subject registry
+var subjectsCmd = &cobra.Command{
+	Use:   "subjects",
+	Short: "Manage registered subjects",
+	Long: `Manage the subject registry.
+
+Registered subjects have a name, aliases and metadata on top of their reference
+image, kept in subjects/subjects.json (in the active project's subjects folder
+when a project is selected). Their names and aliases work wherever a subject is
+given, e.g. "-t jaimee", from any directory of the project. Their metadata is
+used when generating:
+  --hair-color  hair color used when a generation sets none (generate-modular)
+  --keep        traits every generation must leave unchanged (all workflows)
+  --notes       free text, shown by "subjects list"
+
+Available subcommands:
+  add  - Register a subject
+  list - List registered subjects`,
+	Annotations: map[string]string{
+		annotationNoAPIKey: "true",
+	},
+}
+
+var subjectsAddCmd = &cobra.Command{
+	Use:   "add <name> <image>",
+	Short: "Register a subject",
+	Long: `Register a subject under a name. The image can be a path, a URL or an
+existing subject file; images outside the subjects folder are copied into it.
+
+Examples:
+  img-cli subjects add jaimee ~/shoots/jaimee-headshot.jpg --alias jb --alias jaimee-b
+  img-cli subjects add kat subjects/kat.png --hair-color "copper red" --keep freckles --keep "nose ring"`,
+	Args: cobra.ExactArgs(2),
+	Annotations: map[string]string{
+		annotationNoAPIKey: "true",
+	},
+	RunE: runSubjectsAdd,
+}
+
+var subjectsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List registered subjects",
+	Args:  cobra.NoArgs,
+	Annotations: map[string]string{
+		annotationNoAPIKey: "true",
+	},
+	RunE: runSubjectsList,
+}
+
+func init() {
+	rootCmd.AddCommand(subjectsCmd)
+	subjectsCmd.AddCommand(subjectsAddCmd, subjectsListCmd)
+
+	subjectsAddCmd.Flags().StringSliceVar(&subjectAliases, "alias", nil, "Other name for the subject (repeatable or comma-separated)")
+	subjectsAddCmd.Flags().StringVar(&subjectHairColor, "hair-color", "", "Preferred hair color, used when a generation sets none")
+	subjectsAddCmd.Flags().StringArrayVar(&subjectKeep, "keep", nil, "Trait generations must not change, e.g. freckles (repeatable)")
+	subjectsAddCmd.Flags().StringVar(&subjectNotes, "notes", "", "Notes about the subject")
+}
+
+func runSubjectsAdd(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	if strings.ContainsAny(name, "/\\") {
+		return errors.ErrInvalidInput("name", "must not contain path separators")
+	}
+	if workspace.ReadOnlyAssets() {
+		return errors.New(errors.ValidationError, "the asset folders are read-only (--readonly-assets); subjects can't be added")
+	}
+
+	imagePath, err := workspace.ResolveAssetPath("subject", args[1])
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(imagePath); err != nil || info.IsDir() {
+		return errors.ErrInvalidInput("image", fmt.Sprintf("file not found: %s", args[1]))
+	}
+
+	registry, err := workspace.SubjectRegistry()
+	if err != nil {
+		return err
+	}
+	subject := subjects.Subject{
+		Name:      name,
+		Aliases:   subjectAliases,
+		HairColor: subjectHairColor,
+		Keep:      subjectKeep,
+		Notes:     subjectNotes,
+	}
+	if err := registry.Add(subject); err != nil {
+		return err
+	}
+
+	dir := workspace.ProjectPath(workspace.AssetDirs["subject"])
+	image, err := subjectImageIn(dir, imagePath, name)
+	if err != nil {
+		return err
+	}
+	registry.Find(name).Image = image
+	if err := registry.Save(); err != nil {
+		return err
+	}
+
+	output.Set("subject", registry.Find(name))
+	output.Printf("✓ Registered subject %s (%s)\n", name, filepath.Join(dir, image))
+	if len(subjectAliases) > 0 {
+		output.Printf("  Aliases: %s\n", strings.Join(subjectAliases, ", "))
+	}
+	return nil
+}
+
+// subjectImageIn returns the path of a subject image relative to the subjects
+// folder dir, copying it in as <name><ext> when it lives elsewhere
+func subjectImageIn(dir, imagePath, name string) (string, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	absImage, err := filepath.Abs(imagePath)
+	if err != nil {
+		return "", err
+	}
+	if rel, err := filepath.Rel(absDir, absImage); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel, nil
+	}
+
+	image := name + strings.ToLower(filepath.Ext(imagePath))
+	dest := filepath.Join(dir, image)
+	if _, err := os.Stat(dest); err == nil {
+		return "", errors.ErrInvalidInput("name", fmt.Sprintf("%s already exists; pick another name or register that file", dest))
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", errors.Wrapf(err, errors.FileError, "failed to create %s", dir)
+	}
+	src, err := os.Open(imagePath)
+	if err != nil {
+		return "", errors.Wrapf(err, errors.FileError, "failed to read %s", imagePath)
+	}
+	defer src.Close()
+	out, err := os.Create(dest)
+	if err != nil {
+		return "", errors.Wrapf(err, errors.FileError, "failed to create %s", dest)
+	}
+	if _, err := io.Copy(out, src); err != nil {
+		out.Close()
+		os.Remove(dest)
+		return "", errors.Wrapf(err, errors.FileError, "failed to copy %s", imagePath)
+	}
+	if err := out.Close(); err != nil {
+		return "", errors.Wrapf(err, errors.FileError, "failed to write %s", dest)
+	}
+	output.Progress.Printf("📁 Copied %s to %s\n", imagePath, dest)
+	return image, nil
+}
+
+func runSubjectsList(cmd *cobra.Command, args []string) error {
+	found := false
+	var listed []subjects.Subject
+	for _, registry := range workspace.SubjectRegistries() {
+		for i := range registry.Subjects {
+			subject := &registry.Subjects[i]
+			found = true
+			listed = append(listed, *subject)
+			output.Printf("%-16s %s", subject.Name, registry.ImagePath(subject))
+			if len(subject.Aliases) > 0 {
+				output.Printf("  (aka %s)", strings.Join(subject.Aliases, ", "))
+			}
+			output.Println()
+			if subject.HairColor != "" {
+				output.Printf("  Hair color: %s\n", subject.HairColor)
+			}
+			if len(subject.Keep) > 0 {
+				output.Printf("  Keep:       %s\n", strings.Join(subject.Keep, ", "))
+			}
+			if subject.Notes != "" {
+				output.Printf("  Notes:      %s\n", subject.Notes)
+			}
+		}
+	}
+	output.Set("subjects", listed)
+	if !found {
+		output.Println("No registered subjects. Add one with: img-cli subjects add <name> <image>")
+	}
+	return nil
+}
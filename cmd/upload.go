@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"img-cli/pkg/errors"
+	"img-cli/pkg/output"
+	"img-cli/pkg/remote"
+	"img-cli/pkg/workspace"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var uploadTo string
+
+// uploadCmd copies outputs to remote storage
+var uploadCmd = &cobra.Command{
+	Use:   "upload <files or output dirs...> --to <s3://bucket/prefix or URL>",
+	Short: "Upload outputs to S3 or a URL",
+	Long: `Copy generated images, sidecars and manifests to remote storage.
+
+Each file goes under the --to prefix with its path relative to the directory
+it was found in; files given directly go straight under the prefix. When a
+single file is uploaded and --to doesn't end in /, --to is the object itself,
+which is how presigned S3 PUT URLs are used.
+
+Uploads are recorded in the blob store (.img-cli/blobs), so a file that is
+already uploaded and unchanged on the server is skipped. S3 files larger than
+one chunk (IMG_CLI_DOWNLOAD_CHUNK_MB, at least 5 MB) go up as a multipart
+upload; when it is interrupted, the next upload continues with the next part.
+S3 locations use the same AWS_* variables as S3 inputs.
+
+Examples:
+  img-cli upload output/2024-01-15/143022 --to s3://lookbook-renders/fw24/
+  img-cli upload output/kat_suit_1.png --to "https://bucket.s3.amazonaws.com/kat.png?X-Amz-Signature=..."`,
+	Args: cobra.MinimumNArgs(1),
+	Annotations: map[string]string{
+		annotationNoAPIKey: "true",
+	},
+	RunE: runUpload,
+}
+
+func init() {
+	rootCmd.AddCommand(uploadCmd)
+
+	uploadCmd.Flags().StringVar(&uploadTo, "to", "", "S3 location (s3://bucket/prefix) or URL to upload to")
+	uploadCmd.MarkFlagRequired("to")
+}
+
+// uploadFile is a local file and the path it gets under the --to prefix
+type uploadFile struct {
+	path string
+	name string
+}
+
+func runUpload(cmd *cobra.Command, args []string) error {
+	if !remote.IsRemote(uploadTo) {
+		return errors.ErrInvalidInput("to", "want an s3://bucket/prefix location or http(s):// URL, got "+uploadTo)
+	}
+
+	var files []uploadFile
+	for _, arg := range args {
+		found, err := uploadFiles(workspace.Resolve(arg))
+		if err != nil {
+			return err
+		}
+		files = append(files, found...)
+	}
+	if len(files) == 0 {
+		return errors.ErrInvalidInput("files", "nothing to upload")
+	}
+
+	uploaded, unchanged := 0, 0
+	for _, file := range files {
+		location := uploadTo
+		if len(files) > 1 || strings.HasSuffix(uploadTo, "/") {
+			var err error
+			if location, err = uploadLocation(uploadTo, file.name); err != nil {
+				return err
+			}
+		}
+		sent, err := workspace.UploadRemote(file.path, location)
+		if err != nil {
+			return err
+		}
+		if sent {
+			uploaded++
+			output.Progress.Printf("  ↑ %s\n", file.name)
+		} else {
+			unchanged++
+			output.Progress.Printf("  = %s (unchanged)\n", file.name)
+		}
+	}
+
+	output.Printf("✓ Uploaded %d file(s) to %s, %d unchanged\n", uploaded, remoteDisplay(uploadTo), unchanged)
+	output.Count("uploaded", uploaded)
+	output.Count("unchanged", unchanged)
+	return nil
+}
+
+// uploadFiles lists a file, or the files of a directory with their paths
+// relative to it
+func uploadFiles(path string) ([]uploadFile, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, errors.FileError, "cannot upload %s", path)
+	}
+	if !info.IsDir() {
+		return []uploadFile{{path: path, name: filepath.Base(path)}}, nil
+	}
+
+	var files []uploadFile
+	err = filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(path, file)
+		if err != nil {
+			return err
+		}
+		files = append(files, uploadFile{path: file, name: filepath.ToSlash(rel)})
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, errors.FileError, "failed to list %s", path)
+	}
+	return files, nil
+}
+
+// uploadLocation puts a file name under a remote prefix
+func uploadLocation(prefix, name string) (string, error) {
+	u, err := url.Parse(prefix)
+	if err != nil {
+		return "", errors.ErrInvalidInput("to", err.Error())
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + name
+	u.RawPath = ""
+	return u.String(), nil
+}
+
+// remoteDisplay drops the query string, which carries the credentials of
+// presigned URLs
+func remoteDisplay(location string) string {
+	u, err := url.Parse(location)
+	if err != nil {
+		return location
+	}
+	u.RawQuery = ""
+	return u.String()
+}
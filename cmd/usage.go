@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/output"
+	"img-cli/pkg/usage"
+	"img-cli/pkg/workspace"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	usageMonthly  bool
+	usageSince    string
+	usageWorkflow string
+)
+
+// usageCmd summarizes the API usage ledger
+var usageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Show API calls and their cost by day, month and workflow",
+	Long: `Show what the API calls of this workspace used and cost.
+
+Every analysis and generation request is appended to .img-cli/usage.jsonl
+with its time, type, provider, the workflow that made it, its token and image
+counts and its estimated cost: images at IMG_CLI_COST_PER_IMAGE, analyses at
+IMG_CLI_COST_PER_MTOK_IN / IMG_CLI_COST_PER_MTOK_OUT per million tokens, and
+nothing for the local sd and vision servers. This command totals the ledger
+per day (or per month) and per workflow. With a project selected, only that
+project's calls are counted.
+
+Examples:
+  img-cli usage                        # Last 30 days, per day
+  img-cli usage --monthly              # Last 12 months, per month
+  img-cli usage --since 2026-10-01 --workflow outfit-swap`,
+	Args: cobra.NoArgs,
+	Annotations: map[string]string{
+		annotationNoAPIKey: "true",
+	},
+	RunE: runUsage,
+}
+
+func init() {
+	rootCmd.AddCommand(usageCmd)
+
+	usageCmd.Flags().BoolVar(&usageMonthly, "monthly", false, "Total per month instead of per day")
+	usageCmd.Flags().StringVar(&usageSince, "since", "", "First day to count, YYYY-MM-DD (default: 30 days ago, or 12 months ago with --monthly)")
+	usageCmd.Flags().StringVar(&usageWorkflow, "workflow", "", "Only count calls of this workflow, e.g. outfit-swap or \"cache warm\"")
+}
+
+func runUsage(cmd *cobra.Command, args []string) error {
+	since, err := usageStart()
+	if err != nil {
+		return err
+	}
+	all, err := usage.Load(since)
+	if err != nil {
+		return errors.Wrap(err, errors.FileError, "failed to read the usage ledger")
+	}
+	project := workspace.ActiveProject()
+	var entries []usage.Entry
+	for _, entry := range all {
+		if project != nil && entry.Project != project.Name {
+			continue
+		}
+		if usageWorkflow != "" && usage.Workflow(entry) != usageWorkflow {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	period, periodLabel, key := "daily", "Day", usage.Day
+	if usageMonthly {
+		period, periodLabel, key = "monthly", "Month", usage.Month
+	}
+	periods := usage.Summarize(entries, key)
+	workflows := usage.Summarize(entries, usage.Workflow)
+	sort.SliceStable(workflows, func(i, j int) bool { return workflows[i].CostUSD > workflows[j].CostUSD })
+	total := usage.Summary{Key: "total"}
+	for _, entry := range entries {
+		total.Add(entry)
+	}
+
+	output.Set("since", since.Format(time.DateOnly))
+	output.Set("periods", periods)
+	output.Set("workflows", workflows)
+	output.Set("total", total)
+	output.Count("calls", len(entries))
+
+	scope := ""
+	if project != nil {
+		scope = " in project " + project.Name
+	}
+	if usageWorkflow != "" {
+		scope += " for " + usageWorkflow
+	}
+	if len(entries) == 0 {
+		output.Printf("No API calls recorded since %s%s\n", since.Format(time.DateOnly), scope)
+		return nil
+	}
+
+	output.Printf("📈 API usage since %s%s (%s)\n\n", since.Format(time.DateOnly), scope, period)
+	printUsageTable(periodLabel, periods, total)
+	if usageWorkflow == "" {
+		output.Println("\n🧩 By workflow")
+		printUsageTable("Workflow", workflows, total)
+	}
+	output.Printf("\nLedger: %s\n", usage.Path())
+	return nil
+}
+
+// usageStart returns the first moment --since counts, by default 30 days
+// ago, or the start of the month 11 months ago with --monthly
+func usageStart() (time.Time, error) {
+	if usageSince != "" {
+		since, err := time.ParseInLocation(time.DateOnly, usageSince, time.Local)
+		if err != nil {
+			return time.Time{}, errors.ErrInvalidInput("since", fmt.Sprintf("want a date like 2026-10-01, got %q", usageSince))
+		}
+		return since, nil
+	}
+	now := time.Now()
+	if usageMonthly {
+		return time.Date(now.Year(), now.Month()-11, 1, 0, 0, 0, 0, time.Local), nil
+	}
+	return time.Date(now.Year(), now.Month(), now.Day()-29, 0, 0, 0, 0, time.Local), nil
+}
+
+// printUsageTable prints one row per summary and a total row
+func printUsageTable(label string, rows []usage.Summary, total usage.Summary) {
+	output.Printf("  %-20s %8s %11s %12s %12s %7s %9s\n", label, "Analyses", "Generations", "Tokens in", "Tokens out", "Images", "Cost")
+	for _, row := range rows {
+		printUsageRow(row.Key, row)
+	}
+	printUsageRow("Total", total)
+}
+
+func printUsageRow(key string, row usage.Summary) {
+	output.Printf("  %-20s %8d %11d %12d %12d %7d %9s\n",
+		key, row.Analyses, row.Generations, row.InputTokens, row.OutputTokens, row.Images, fmt.Sprintf("$%.4f", row.CostUSD))
+}
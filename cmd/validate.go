@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"img-cli/pkg/analyzer"
+	"img-cli/pkg/c2pa"
+	"img-cli/pkg/config"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/imaging"
+	"img-cli/pkg/library"
+	"img-cli/pkg/workflow"
+	"img-cli/pkg/workspace"
+	"strings"
+)
+
+// validateLUTFlag checks that a --lut file parses before any API calls are made
+func validateLUTFlag(path string) error {
+	if path == "" {
+		return nil
+	}
+	if _, err := imaging.LoadCube(path); err != nil {
+		return errors.Wrapf(err, errors.ValidationError, "invalid LUT file %s", path)
+	}
+	return nil
+}
+
+// signerOptions loads the --sign certificate before any API calls are made
+func signerOptions(sign bool) ([]workflow.Option, error) {
+	if !sign {
+		return nil, nil
+	}
+	signer, err := c2pa.LoadSigner(config.DefaultSigningConfig())
+	if err != nil {
+		return nil, err
+	}
+	return []workflow.Option{workflow.WithSigner(signer)}, nil
+}
+
+// validateOutfitCheckFlag checks the --outfit-check mode
+func validateOutfitCheckFlag(mode string) error {
+	switch mode {
+	case workflow.OutfitCheckWarn, workflow.OutfitCheckFill, workflow.OutfitCheckOff:
+		return nil
+	}
+	return errors.ErrInvalidInput("outfit-check", "must be warn, fill or off, got "+mode)
+}
+
+// validateMaxAccessoriesFlag checks the --max-accessories limit
+func validateMaxAccessoriesFlag(n int) error {
+	if n < 0 {
+		return errors.ErrInvalidInput("max-accessories", "must be 0 (no limit) or a positive number")
+	}
+	return nil
+}
+
+// assetFlag is a component flag whose value may be a path, an asset name or text
+type assetFlag struct {
+	kind  string
+	value *string
+}
+
+// expandTagSelectors replaces tag selectors ("tag:formal") in component flags
+// with folders of the matching reference images
+func expandTagSelectors(flags ...assetFlag) error {
+	for _, flag := range flags {
+		expanded, err := library.Expand(flag.kind, *flag.value)
+		if err != nil {
+			return err
+		}
+		*flag.value = expanded
+	}
+	return nil
+}
+
+// styleFlag is the --style asset flag; with --style-blend its value lists
+// several references
+func styleFlag(value *string, blend bool) assetFlag {
+	if blend {
+		return assetFlag{"style-blend", value}
+	}
+	return assetFlag{"style", value}
+}
+
+// splitWeights strips ":weight" suffixes (see workflow.SplitWeight) from
+// component flags and returns the weights by component name, nil when none
+// has one
+func splitWeights(flags ...assetFlag) map[string]float64 {
+	var weights map[string]float64
+	for _, flag := range flags {
+		value, weight := workflow.SplitWeight(*flag.value)
+		if weight == 0 {
+			continue
+		}
+		*flag.value = value
+		if weights == nil {
+			weights = make(map[string]float64)
+		}
+		weights[weightKey(flag.kind)] = weight
+	}
+	return weights
+}
+
+// weightKey names the component of a flag the way the prompt and sidecar do:
+// the analyzer type of a registered component, "hair_style" for --hair-style
+func weightKey(kind string) string {
+	if name, _, ok := analyzer.LookupComponent(kind); ok {
+		return name
+	}
+	if kind == "style-blend" {
+		return "style"
+	}
+	return strings.ReplaceAll(kind, "-", "_")
+}
+
+// resolveAssetFlags replaces asset names ("shearling-black") in component flags
+// with the matching files from the project's asset directories
+func resolveAssetFlags(flags ...assetFlag) error {
+	for _, flag := range flags {
+		if flag.kind == "style-blend" {
+			resolved, err := resolveStyleBlend(*flag.value)
+			if err != nil {
+				return err
+			}
+			*flag.value = resolved
+			continue
+		}
+		resolved, err := workspace.ResolveAsset(flag.kind, *flag.value)
+		if err != nil {
+			return err
+		}
+		*flag.value = resolved
+	}
+	return nil
+}
+
+// resolveStyleBlend resolves each comma-separated reference of a
+// --style-blend value; blended references are images or folders, never
+// descriptions
+func resolveStyleBlend(value string) (string, error) {
+	parts := strings.Split(value, ",")
+	for i, part := range parts {
+		resolved, err := workspace.ResolveAssetPath("style", strings.TrimSpace(part))
+		if err != nil {
+			return "", err
+		}
+		parts[i] = resolved
+	}
+	return strings.Join(parts, ","), nil
+}
@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	stderrors "errors"
+	"img-cli/pkg/config"
+	"img-cli/pkg/cost"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/output"
+	"img-cli/pkg/workflow"
+	"img-cli/pkg/workspace"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	videoOutfitRef      string
+	videoOverOutfitRef  string
+	videoStyleRef       string
+	videoHairStyleRef   string
+	videoHairColorRef   string
+	videoMakeupRef      string
+	videoExpressionRef  string
+	videoAccessoriesRef string
+	videoBackgroundRef  string
+
+	videoFPS        float64
+	videoMaxFrames  int
+	videoFramesOnly bool
+	videoSeed       int64
+	videoStrength   float64
+	videoOutput     string
+	videoNoConfirm  bool
+	videoAvoid      string
+)
+
+var videoCmd = &cobra.Command{
+	Use:   "video <video>",
+	Short: "Apply a modular look to every frame of a short video",
+	Long: `Extract the frames of a short video, generate each one with the same
+components, prompt and seed, and reassemble them into a video.
+
+Frames are sampled at --fps (default 8) up to --max-frames (default 120), so a
+15 second clip costs 120 images at most. Generated frames are cached by frame
+content and recipe: rerunning after an interruption, or with another clip that
+shares frames, only generates the frames not seen before. Identical frames in
+one clip are generated once.
+
+The output folder gets the image sequence in frames/ (frame_00001.png, ...)
+and <video>_swapped.mp4, unless --frames-only. Extracting and assembling needs
+ffmpeg on the PATH, or IMG_CLI_FFMPEG.
+
+Examples:
+  img-cli video clips/walk.mp4 --outfit outfits/suit.png --style styles/night.png
+  img-cli video clips/turn.mov --outfit "red leather jacket" --fps 12 --max-frames 48 --seed 1234
+  img-cli video clips/walk.mp4 --hair-color "platinum blonde" --frames-only`,
+	Args: cobra.ExactArgs(1),
+	RunE: runVideo,
+}
+
+func init() {
+	rootCmd.AddCommand(videoCmd)
+
+	videoCmd.Flags().StringVar(&videoOutfitRef, "outfit", "", "Outfit reference image or description")
+	videoCmd.Flags().StringVar(&videoOverOutfitRef, "over-outfit", "", "Complete base outfit; the main outfit's outer layer is worn over it")
+	videoCmd.Flags().StringVar(&videoStyleRef, "style", "", "Photo style reference image")
+	videoCmd.Flags().StringVar(&videoHairStyleRef, "hair-style", "", "Hair style reference image or description")
+	videoCmd.Flags().StringVar(&videoHairColorRef, "hair-color", "", "Hair color reference image or description")
+	videoCmd.Flags().StringVar(&videoMakeupRef, "makeup", "", "Makeup reference image or description")
+	videoCmd.Flags().StringVar(&videoExpressionRef, "expression", "", "Expression reference image or description")
+	videoCmd.Flags().StringVar(&videoAccessoriesRef, "accessories", "", "Accessories reference image or description")
+	videoCmd.Flags().StringVar(&videoBackgroundRef, "background", "", "Background/environment reference image or description")
+
+	defaults := config.DefaultVideoConfig()
+	videoCmd.Flags().Float64Var(&videoFPS, "fps", defaults.FPS, "Frames extracted per second of video, also the frame rate of the output")
+	videoCmd.Flags().IntVar(&videoMaxFrames, "max-frames", defaults.MaxFrames, "Most frames extracted (0 = every frame at --fps)")
+	videoCmd.Flags().BoolVar(&videoFramesOnly, "frames-only", false, "Write the image sequence without assembling a video")
+	videoCmd.Flags().Int64Var(&videoSeed, "seed", 0, "Generation seed used for every frame (default: random, printed; cached frames are reused whatever the seed)")
+	videoCmd.Flags().Float64Var(&videoStrength, "strength", 0, "How much each frame may change, 0-1: 0.2 is a subtle restyling, 1 a complete transformation (0 = left to the model)")
+	videoCmd.Flags().StringVar(&videoAvoid, "avoid", "", "Comma-separated elements that must not appear, e.g. \"hats, visible logos\"")
+	videoCmd.Flags().StringVarP(&videoOutput, "output", "o", "", "Output directory (default: output/YYYY-MM-DD/HHMMSS)")
+	videoCmd.Flags().BoolVar(&videoNoConfirm, "no-confirm", false, "Skip cost confirmation")
+}
+
+func runVideo(cmd *cobra.Command, args []string) error {
+	if err := resolveAssetFlags(
+		assetFlag{"outfit", &videoOutfitRef},
+		assetFlag{"over-outfit", &videoOverOutfitRef},
+		assetFlag{"style", &videoStyleRef},
+		assetFlag{"hair-style", &videoHairStyleRef},
+		assetFlag{"hair-color", &videoHairColorRef},
+		assetFlag{"makeup", &videoMakeupRef},
+		assetFlag{"expression", &videoExpressionRef},
+		assetFlag{"accessories", &videoAccessoriesRef},
+		assetFlag{"background", &videoBackgroundRef},
+	); err != nil {
+		return err
+	}
+
+	options := workflow.VideoOptions{
+		Path:       workspace.Resolve(args[0]),
+		FPS:        videoFPS,
+		MaxFrames:  videoMaxFrames,
+		FramesOnly: videoFramesOnly,
+	}
+	if err := workflow.ValidateVideoOptions(options); err != nil {
+		return err
+	}
+	if err := workflow.ValidateSeed(videoSeed); err != nil {
+		return err
+	}
+	if err := workflow.ValidateStrength(videoStrength); err != nil {
+		return err
+	}
+	sampling, err := workflow.GenerationSampling(0, 0, 0)
+	if err != nil {
+		return err
+	}
+
+	recipe := workflow.ModularConfig{
+		OutfitRef:      videoOutfitRef,
+		OverOutfitRef:  videoOverOutfitRef,
+		StyleRef:       videoStyleRef,
+		HairStyleRef:   videoHairStyleRef,
+		HairColorRef:   videoHairColorRef,
+		MakeupRef:      videoMakeupRef,
+		ExpressionRef:  videoExpressionRef,
+		AccessoriesRef: videoAccessoriesRef,
+		BackgroundRef:  videoBackgroundRef,
+		OutfitCheck:    workflow.OutfitCheckWarn,
+		Avoid:          workflow.ParseAvoid(videoAvoid),
+		Seed:           videoSeed,
+		Sampling:       sampling,
+		Strength:       videoStrength,
+		OutputDir:      videoOutput,
+	}
+
+	output.Progress.Printf("🎞️  Extracting frames from %s at %g fps...\n", filepath.Base(options.Path), options.FPS)
+	plan, err := workflow.PlanVideo(recipe, options)
+	if err != nil {
+		return err
+	}
+	toGenerate := plan.Uncached()
+	output.Progress.Printf("   %d frame(s), %d to generate, %d cached or repeated\n", len(plan.Frames), toGenerate, len(plan.Frames)-toGenerate)
+	if videoSeed == 0 {
+		output.Progress.Printf("🎲 Seed %d for every frame (generate the same frames again with --seed %d)\n", plan.Config.Seed, plan.Config.Seed)
+	}
+
+	cost.PrintEstimate("Video Cost Analysis", toGenerate)
+	if err := cost.Check(toGenerate, cost.CheckOptions{SkipConfirm: videoNoConfirm}); err != nil {
+		if stderrors.Is(err, cost.ErrCancelled) {
+			return nil
+		}
+		return err
+	}
+
+	orchestrator := workflow.NewOrchestrator(apiKey)
+	result, err := orchestrator.RunVideo(plan)
+	if err != nil {
+		return errors.Wrap(err, errors.WorkflowError, "video generation failed")
+	}
+
+	output.AddFiles(result.Video)
+	output.AddFiles(result.Frames...)
+	output.Count("frames", len(result.Frames))
+	output.Count("generated", result.Generated)
+	output.Count("cached", result.Cached)
+	output.Count("failed", result.Failed)
+	if result.Video != "" {
+		output.Set("video", result.Video)
+	}
+	output.Printf("\n✅ Video completed: %d frame(s) generated, %d from cache", result.Generated, result.Cached)
+	if result.Failed > 0 {
+		output.Printf(", %d failed and kept as extracted", result.Failed)
+	}
+	output.Println()
+	if len(result.Frames) > 0 {
+		output.Printf("   Frames: %s\n", filepath.Dir(result.Frames[0]))
+	}
+	if result.Video != "" {
+		output.Printf("   Video:  %s\n", result.Video)
+	}
+	printThroughput(orchestrator.Throughput())
+	return nil
+}
@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/imgcli"
+	"img-cli/pkg/library"
+	"img-cli/pkg/logger"
+	"img-cli/pkg/output"
+	"img-cli/pkg/server"
+	"img-cli/pkg/watch"
+	"img-cli/pkg/workspace"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	watchPreset   string
+	watchInterval time.Duration
+)
+
+// watchCmd runs an outfit-swap preset on every subject image dropped into a folder
+var watchCmd = &cobra.Command{
+	Use:   "watch <dir>",
+	Short: "Run an outfit-swap preset on images dropped into a folder",
+	Long: `Watch a folder for new subject images and run an outfit-swap preset on each
+one as it arrives, a drop-box pipeline for photographers.
+
+Every image that appears in the folder is used as the subject of one
+outfit-swap run, in a folder of its own under output/. Processed inputs are
+moved to done/ inside the watched folder; inputs whose run fails, or makes no
+image, are moved to failed/ so they are not retried forever. Images already in
+the folder when the command starts are processed too. Files are picked up once
+their size stops changing, so slow copies are never read half-written.
+
+The preset is a JSON file with the keys of the serve command's
+POST /jobs/outfit-swap body, without subjects:
+
+  {"outfit": "suit", "style": "night", "variations": 2, "hair_color": "copper red"}
+
+Without --preset, the outfit and style defaults of outfit-swap are used.
+Each run holds the project lock while it runs, so other img-cli commands can
+use the project between arrivals; images that arrive while another command
+holds the lock wait in the folder until it is released. Cost confirmation is never asked; --max-budget and IMG_CLI_MAX_COST still
+refuse runs over the cap. Ctrl+C stops after the image being processed.
+
+Examples:
+  img-cli watch dropbox/ --preset presets/catalog.json
+  img-cli watch incoming/ --interval 10s`,
+	Args: cobra.ExactArgs(1),
+	Annotations: map[string]string{
+		annotationLockPerRun: "true",
+	},
+	RunE: runWatch,
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+
+	watchCmd.Flags().StringVar(&watchPreset, "preset", "", "JSON file with the outfit-swap settings to run (see above)")
+	watchCmd.Flags().DurationVar(&watchInterval, "interval", 2*time.Second, "How often to look for new images")
+}
+
+func runWatch(cmd *cobra.Command, args []string) error {
+	if watchInterval < 100*time.Millisecond {
+		return errors.ErrInvalidInput("interval", "must be at least 100ms")
+	}
+	watcher, err := watch.New(args[0])
+	if err != nil {
+		return err
+	}
+	preset, err := loadWatchPreset(watchPreset)
+	if err != nil {
+		return err
+	}
+	// Fail now rather than on every arrival when the outfit can't be found
+	if library.IsSelector(preset.Outfit) {
+		if _, err := library.Select("outfit", preset.Outfit); err != nil {
+			return err
+		}
+	} else if outfitPath, err := workspace.ResolveAssetPath("outfit", preset.Outfit); err != nil {
+		return err
+	} else if _, err := os.Stat(outfitPath); err != nil {
+		return errors.ErrInvalidInput("outfit", fmt.Sprintf("outfit %s not found", preset.Outfit))
+	}
+
+	// Each run takes the project lock, not the client
+	client, err := imgcli.NewClient(imgcli.Config{APIKey: apiKey})
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// A second Ctrl+C stops right away
+	context.AfterFunc(ctx, stop)
+
+	output.Printf("👀 Watching %s (Ctrl+C to stop)\n", args[0])
+	output.Printf("   Outfit: %s, style: %s, %d variation(s)\n", preset.Outfit, preset.Style, preset.Variations)
+	logger.Info("Watch started", "dir", args[0], "outfit", preset.Outfit, "style", preset.Style)
+
+	processed, failed := 0, 0
+	waiting := false
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	for {
+		ready, err := watcher.Ready()
+		if err != nil {
+			logger.Warn("Failed to scan watched folder", "dir", args[0], "error", err)
+		}
+		for _, path := range ready {
+			if ctx.Err() != nil {
+				break
+			}
+			run, err := workspace.Start(workspace.Root(), cmd.CommandPath())
+			if err != nil {
+				// The image stays in the folder for the next poll
+				if !waiting {
+					output.Progress.Printf("⏳ Waiting for the project lock: %v\n", err)
+					waiting = true
+				}
+				break
+			}
+			waiting = false
+			ok := processWatchedImage(client, watcher, preset, path)
+			run.Finish(ok)
+			if ok {
+				processed++
+			} else {
+				failed++
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			output.Printf("\n👋 Stopped watching: %d processed, %d failed\n", processed, failed)
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// loadWatchPreset reads the preset file, or the outfit-swap defaults when
+// path is empty
+func loadWatchPreset(path string) (server.OutfitSwapRequest, error) {
+	var preset server.OutfitSwapRequest
+	if path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return preset, errors.Wrapf(err, errors.FileError, "failed to read preset %s", path)
+		}
+		defer file.Close()
+		decoder := json.NewDecoder(file)
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&preset); err != nil {
+			return preset, errors.ErrInvalidInput("preset", fmt.Sprintf("%s: %v", path, err))
+		}
+		if len(preset.Subjects) > 0 {
+			return preset, errors.ErrInvalidInput("preset", "subjects come from the watched folder; remove them from the preset")
+		}
+	}
+	preset.ApplyDefaults()
+	return preset, nil
+}
+
+// processWatchedImage runs the preset on one arrival and moves it to done/ or
+// failed/. It reports whether the run made images.
+func processWatchedImage(client *imgcli.Client, watcher *watch.Watcher, preset server.OutfitSwapRequest, path string) bool {
+	name := filepath.Base(path)
+	output.Printf("\n📥 New image: %s\n", name)
+
+	now := time.Now()
+	outputDir := filepath.Join(workspace.OutputPath(), now.Format("2006-01-02"),
+		now.Format("150405")+"-"+strings.TrimSuffix(name, filepath.Ext(name)))
+	options := preset.Options(outputDir)
+	options.Subjects = []string{path}
+
+	images := 0
+	result, err := client.OutfitSwap(preset.Outfit, options)
+	if result != nil {
+		images = len(result.Images)
+		output.AddFiles(result.Images...)
+	}
+	if err == nil && images == 0 {
+		err = errors.New(errors.WorkflowError, "no image was generated")
+	}
+
+	folder := watch.DoneDir
+	if err != nil {
+		folder = watch.FailedDir
+		output.Printf("❌ %s: %v\n", name, err)
+		logger.Warn("Watched image failed", "image", path, "error", err)
+	} else {
+		output.Printf("✅ %s: %d image(s) in %s\n", name, images, outputDir)
+		logger.Info("Watched image processed", "image", path, "images", images, "output", outputDir)
+	}
+	if _, moveErr := watcher.Move(path, folder); moveErr != nil {
+		logger.Warn("Failed to move watched image", "image", path, "error", moveErr)
+	}
+	return err == nil
+}
@@ -0,0 +1,249 @@
+package cmd
+
+import (
+	"fmt"
+	"img-cli/pkg/analyzer"
+	"img-cli/pkg/cost"
+	"img-cli/pkg/library"
+	"img-cli/pkg/output"
+	"img-cli/pkg/prompt"
+	"img-cli/pkg/workspace"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// wizardCmd builds an outfit-swap run by asking for each part of it
+var wizardCmd = &cobra.Command{
+	Use:   "wizard",
+	Short: "Build an outfit-swap run interactively",
+	Long: `Walk through an outfit-swap run one question at a time: subjects, outfit,
+style, optional components and variations. Each question lists what the asset
+folders hold; answer with numbers or part of a name ("shrlng" finds
+"shearling-black"), and tag selectors or folders run several references at
+once. Questions that take descriptions also accept any text.
+
+The wizard then shows the number of images and their cost, and either runs
+the workflow or prints the equivalent command line to reuse in scripts.
+
+Example:
+  img-cli wizard`,
+	Args: cobra.NoArgs,
+	Annotations: map[string]string{
+		annotationNoAPIKey: "true", // Checked before running the built command
+	},
+	RunE: runWizard,
+}
+
+func init() {
+	rootCmd.AddCommand(wizardCmd)
+}
+
+// wizardComponent is an optional outfit-swap component the wizard offers
+type wizardComponent struct {
+	flag     string
+	help     string
+	keywords []string
+	textOnly bool
+}
+
+// wizardComponents lists the optional components: the built-in ones, then
+// the registered ones (see analyzer.Register)
+func wizardComponents() []wizardComponent {
+	components := []wizardComponent{
+		{flag: "hair-style", help: "Hair style (cut and shape)"},
+		{flag: "hair-color", help: "Hair color"},
+		{flag: "makeup", help: "Makeup"},
+		{flag: "expression", help: "Facial expression"},
+		{flag: "accessories", help: "Accessories"},
+		{flag: "over-outfit", help: "Base outfit worn under the outfit's outer layer"},
+		{flag: "pose", help: "Body pose"},
+		{flag: "background", help: "Background / environment"},
+	}
+	for _, name := range analyzer.Components() {
+		_, component, _ := analyzer.LookupComponent(name)
+		components = append(components, wizardComponent{
+			flag:     component.Flag,
+			help:     component.Help,
+			keywords: component.Keywords,
+			textOnly: component.TextOnly,
+		})
+	}
+	return components
+}
+
+func runWizard(cmd *cobra.Command, args []string) error {
+	d := prompt.NewDialog()
+	d.Printf("🧙 Build an outfit-swap run (Ctrl-D quits at any question)\n")
+
+	run, images, err := askWizardRun(d)
+	if err == io.EOF {
+		d.Printf("\nCancelled\n")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	cost.PrintEstimate("Run preview", images)
+	d.Printf("\n   %s\n", commandLine(run))
+
+	next, err := d.Choose("What next?", []prompt.Option{
+		{Label: "run it now", Value: "run"},
+		{Label: "print the command line only", Value: "print"},
+		{Label: "quit", Value: "quit"},
+	}, prompt.ChooseOptions{Default: "run"})
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	switch next[0] {
+	case "print":
+		output.Println(commandLine(run))
+		return nil
+	case "quit":
+		return nil
+	}
+
+	// Run it as if typed: outfit-swap's own checks, API key and run lock apply
+	if err := outfitSwapCmd.ParseFlags(run[1:]); err != nil {
+		return err
+	}
+	positional := outfitSwapCmd.Flags().Args()
+	if err := rootCmd.PersistentPreRunE(outfitSwapCmd, positional); err != nil {
+		return err
+	}
+	return runOutfitSwap(outfitSwapCmd, positional)
+}
+
+// askWizardRun asks for each part of the run and returns its outfit-swap
+// arguments and the number of images it generates
+func askWizardRun(d *prompt.Dialog) ([]string, int, error) {
+	subjectNames, err := d.Choose("Subjects", subjectOptions(), prompt.ChooseOptions{Multi: true})
+	if err != nil {
+		return nil, 0, err
+	}
+	outfit, err := d.Choose("Outfit (a tag or folder runs every outfit in it)", assetOptions("outfit"), prompt.ChooseOptions{})
+	if err != nil {
+		return nil, 0, err
+	}
+	style, err := d.Choose("Style", assetOptions("style"), prompt.ChooseOptions{Optional: true, AllowText: true})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	run := []string{"outfit-swap", outfit[0], "-t", strings.Join(subjectNames, " ")}
+	combinations := len(subjectNames) * referenceCount("outfit", outfit[0])
+	if len(style) > 0 {
+		run = append(run, "--style", style[0])
+		combinations *= referenceCount("style", style[0])
+	}
+
+	components := wizardComponents()
+	var options []prompt.Option
+	for _, c := range components {
+		options = append(options, prompt.Option{Label: fmt.Sprintf("%-12s %s", c.flag, c.help), Value: c.flag})
+	}
+	chosen, err := d.Choose("Optional components", options, prompt.ChooseOptions{Multi: true, Optional: true})
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, flag := range chosen {
+		var component wizardComponent
+		for _, c := range components {
+			if c.flag == flag {
+				component = c
+			}
+		}
+		var choices []prompt.Option
+		for _, keyword := range component.keywords {
+			choices = append(choices, prompt.Option{Label: keyword})
+		}
+		if !component.textOnly {
+			choices = append(choices, assetOptions(flag)...)
+		}
+		value, err := d.Choose(upperFirst(component.help), choices, prompt.ChooseOptions{AllowText: true})
+		if err != nil {
+			return nil, 0, err
+		}
+		run = append(run, "--"+flag, value[0])
+		combinations *= referenceCount(flag, value[0])
+	}
+
+	variations, err := d.AskInt("\nVariations per combination", 1, 1)
+	if err != nil {
+		return nil, 0, err
+	}
+	if variations != 1 {
+		run = append(run, "-v", strconv.Itoa(variations))
+	}
+	return run, combinations * variations, nil
+}
+
+// subjectOptions offers the subjects (see subjectChoices)
+func subjectOptions() []prompt.Option {
+	return choiceOptions(subjectChoices())
+}
+
+// assetOptions offers the references of a component kind (see assetChoices)
+func assetOptions(kind string) []prompt.Option {
+	return choiceOptions(assetChoices(kind))
+}
+
+func choiceOptions(choices []assetChoice) []prompt.Option {
+	options := make([]prompt.Option, len(choices))
+	for i, c := range choices {
+		options[i] = prompt.Option{Label: c.value, Value: c.value}
+		if c.description != "" {
+			options[i].Label += "  (" + c.description + ")"
+		}
+	}
+	return options
+}
+
+// referenceCount is how many references a component value stands for: the
+// matches of a tag selector, the images of a folder, otherwise one
+func referenceCount(kind, value string) int {
+	if library.IsSelector(value) {
+		if items, err := library.Select(kind, value); err == nil {
+			return len(items)
+		}
+		return 1
+	}
+	if info, err := os.Stat(workspace.Resolve(value)); err == nil && info.IsDir() {
+		return max(len(workspace.ListImages(workspace.Resolve(value))), 1)
+	}
+	return 1
+}
+
+// commandLine renders arguments as a shell command
+func commandLine(args []string) string {
+	quoted := []string{"img-cli"}
+	for _, arg := range args {
+		quoted = append(quoted, shellQuote(arg))
+	}
+	return strings.Join(quoted, " ")
+}
+
+// shellQuote quotes an argument for POSIX shells when it needs it
+func shellQuote(arg string) string {
+	if arg != "" && strings.IndexFunc(arg, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:=,@+%", r))
+	}) < 0 {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// upperFirst capitalizes the first letter of a component description
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
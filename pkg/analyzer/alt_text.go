@@ -0,0 +1,58 @@
+package analyzer
+
+import (
+	"fmt"
+	"img-cli/pkg/gemini"
+	"strings"
+)
+
+// AltTextWriter polishes drafted alt text into a natural sentence with a cheap
+// text-only request. The draft is built from the recipe, so no image is sent.
+type AltTextWriter struct {
+	client *gemini.Client
+}
+
+func NewAltTextWriter(client *gemini.Client) *AltTextWriter {
+	return &AltTextWriter{client: client}
+}
+
+// Refine rewrites draft alt text using the longer caption for detail. The
+// result is a single sentence of at most maxLength characters.
+func (w *AltTextWriter) Refine(draft, caption string, maxLength int) (string, error) {
+	prompt := fmt.Sprintf(`Write alt text for a generated fashion photo. There is no image; it is described below.
+
+Draft alt text: %s
+
+Full description: %s
+
+Rules:
+- One plain sentence of at most %d characters describing what a viewer sees
+- Keep the garments, their colors and the setting from the draft; add detail from the description only if it fits
+- Do not start with "Image of", "Photo of" or "Picture of"
+- No generation instructions, camera jargon, hashtags or quotes
+
+Return only the alt text.`, draft, caption, maxLength)
+
+	request := gemini.Request{
+		Contents: []gemini.Content{
+			{
+				Parts: []interface{}{
+					gemini.TextPart{Text: prompt},
+				},
+			},
+		},
+		GenerationConfig: gemini.AnalyzerConfig,
+	}
+
+	resp, err := w.client.SendRequest(request)
+	if err != nil {
+		return "", fmt.Errorf("error sending request: %w", err)
+	}
+
+	text := strings.Join(strings.Fields(gemini.ExtractTextFromResponse(resp)), " ")
+	text = strings.Trim(text, "\"'` ")
+	if text == "" {
+		return "", fmt.Errorf("empty alt text response")
+	}
+	return text, nil
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"img-cli/pkg/gemini"
+	"img-cli/pkg/logger"
 	"strings"
 )
 
@@ -80,11 +81,11 @@ Return ONLY the JSON object, no additional text.`,
 				},
 			},
 		},
-		GenerationConfig: &gemini.GenerationConfig{
+		GenerationConfig: samplingFor(a.Type, &gemini.GenerationConfig{
 			Temperature: 0.3,
 			TopK:        20,
 			TopP:        0.8,
-		},
+		}),
 	}
 
 	resp, err := a.client.SendRequest(request)
@@ -129,7 +130,7 @@ func (a *ArtStyleAnalyzer) AnalyzeMultiple(imagePaths []string) (json.RawMessage
 	for _, path := range imagePaths {
 		style, err := a.Analyze(path)
 		if err != nil {
-			fmt.Printf("Warning: Failed to analyze %s: %v\n", path, err)
+			logger.Warn("Failed to analyze art style reference", "image", path, "error", err)
 			continue
 		}
 		styles = append(styles, style)
@@ -217,10 +218,10 @@ Return ONLY the JSON object.`, len(styles), strings.Join(styleStrings, "\n\n"))
 				},
 			},
 		},
-		GenerationConfig: &gemini.GenerationConfig{
+		GenerationConfig: samplingFor(a.Type, &gemini.GenerationConfig{
 			Temperature: 0.4,
 			TopK:        30,
 			TopP:        0.85,
-		},
+		}),
 	}
 }
\ No newline at end of file
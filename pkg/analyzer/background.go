@@ -0,0 +1,39 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/gemini"
+	"img-cli/pkg/prompts"
+)
+
+type BackgroundAnalyzer struct {
+	BaseAnalyzer
+	client *gemini.Client
+}
+
+func NewBackgroundAnalyzer(client *gemini.Client) *BackgroundAnalyzer {
+	return &BackgroundAnalyzer{
+		BaseAnalyzer: BaseAnalyzer{Type: "background"},
+		client:       client,
+	}
+}
+
+func (b *BackgroundAnalyzer) Analyze(imagePath string) (json.RawMessage, error) {
+	prompt, err := prompts.Render("analyze_background", nil)
+	if err != nil {
+		return nil, err
+	}
+	request, err := BuildImageAnalysisRequest(imagePath, prompt, samplingFor(b.Type, gemini.AnalyzerConfig))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := b.client.SendRequest(*request)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %w", err)
+	}
+
+	textResp := gemini.ExtractTextFromResponse(resp)
+	return CleanAndValidateJSONResponse(textResp)
+}
@@ -0,0 +1,51 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/gemini"
+	"img-cli/pkg/prompts"
+)
+
+func init() {
+	Register("body", func(c *gemini.Client) Analyzer { return NewBodyAnalyzer(c) }, Component{
+		Flag:     "body-ref",
+		Help:     "Body build to give the subject instead of their own",
+		Dir:      "bodies",
+		Prompt:   "analyze_body",
+		Keywords: []string{"preserve", "any"},
+		Default:  "preserve",
+		Section:  "body",
+	})
+}
+
+type BodyAnalyzer struct {
+	BaseAnalyzer
+	client *gemini.Client
+}
+
+func NewBodyAnalyzer(client *gemini.Client) *BodyAnalyzer {
+	return &BodyAnalyzer{
+		BaseAnalyzer: BaseAnalyzer{Type: "body"},
+		client:       client,
+	}
+}
+
+func (t *BodyAnalyzer) Analyze(imagePath string) (json.RawMessage, error) {
+	prompt, err := prompts.Render("analyze_body", nil)
+	if err != nil {
+		return nil, err
+	}
+	request, err := BuildImageAnalysisRequest(imagePath, prompt, samplingFor(t.Type, gemini.AnalyzerConfig))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.client.SendRequest(*request)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %w", err)
+	}
+
+	textResp := gemini.ExtractTextFromResponse(resp)
+	return CleanAndValidateJSONResponse(textResp)
+}
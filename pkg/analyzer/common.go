@@ -3,6 +3,7 @@ package analyzer
 import (
 	"encoding/json"
 	"fmt"
+	"img-cli/pkg/config"
 	"img-cli/pkg/gemini"
 	"strings"
 )
@@ -34,6 +35,12 @@ func CleanAndValidateJSONResponse(textResp string) (json.RawMessage, error) {
 	return json.RawMessage(cleaned), nil
 }
 
+// samplingFor applies the configured sampling of an analyzer type (see
+// config.AnalysisSampling) to the analyzer's own parameters
+func samplingFor(analysisType string, base *gemini.GenerationConfig) *gemini.GenerationConfig {
+	return base.WithSampling(config.AnalysisSampling(analysisType))
+}
+
 // BuildImageAnalysisRequest creates a standard Gemini request for image analysis
 func BuildImageAnalysisRequest(imagePath string, prompt string, config *gemini.GenerationConfig) (*gemini.Request, error) {
 	imageData, mimeType, err := gemini.LoadImageAsBase64(imagePath)
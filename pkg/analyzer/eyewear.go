@@ -0,0 +1,50 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/gemini"
+	"img-cli/pkg/prompts"
+)
+
+func init() {
+	Register("eyewear", func(c *gemini.Client) Analyzer { return NewEyewearAnalyzer(c) }, Component{
+		Help:     "Eyewear",
+		Dir:      "eyewear",
+		Prompt:   "analyze_eyewear",
+		Keywords: []string{"keep", "none"},
+		Default:  "keep",
+		Section:  "eyewear",
+	})
+}
+
+type EyewearAnalyzer struct {
+	BaseAnalyzer
+	client *gemini.Client
+}
+
+func NewEyewearAnalyzer(client *gemini.Client) *EyewearAnalyzer {
+	return &EyewearAnalyzer{
+		BaseAnalyzer: BaseAnalyzer{Type: "eyewear"},
+		client:       client,
+	}
+}
+
+func (t *EyewearAnalyzer) Analyze(imagePath string) (json.RawMessage, error) {
+	prompt, err := prompts.Render("analyze_eyewear", nil)
+	if err != nil {
+		return nil, err
+	}
+	request, err := BuildImageAnalysisRequest(imagePath, prompt, samplingFor(t.Type, gemini.AnalyzerConfig))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.client.SendRequest(*request)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %w", err)
+	}
+
+	textResp := gemini.ExtractTextFromResponse(resp)
+	return CleanAndValidateJSONResponse(textResp)
+}
@@ -0,0 +1,50 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/gemini"
+	"img-cli/pkg/prompts"
+)
+
+func init() {
+	Register("footwear", func(c *gemini.Client) Analyzer { return NewFootwearAnalyzer(c) }, Component{
+		Flag:    "shoes",
+		Help:    "Footwear",
+		Heading: "FOOTWEAR",
+		Instruction: "The subject wears these shoes instead of any footwear from the outfit description. " +
+			"Match the shoe type, material, color and heel height exactly. Keep the framing from the style: if the feet are out of frame, do not change the framing to show them.",
+		Prompt: "analyze_footwear",
+	})
+}
+
+type FootwearAnalyzer struct {
+	BaseAnalyzer
+	client *gemini.Client
+}
+
+func NewFootwearAnalyzer(client *gemini.Client) *FootwearAnalyzer {
+	return &FootwearAnalyzer{
+		BaseAnalyzer: BaseAnalyzer{Type: "footwear"},
+		client:       client,
+	}
+}
+
+func (t *FootwearAnalyzer) Analyze(imagePath string) (json.RawMessage, error) {
+	prompt, err := prompts.Render("analyze_footwear", nil)
+	if err != nil {
+		return nil, err
+	}
+	request, err := BuildImageAnalysisRequest(imagePath, prompt, samplingFor(t.Type, gemini.AnalyzerConfig))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.client.SendRequest(*request)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %w", err)
+	}
+
+	textResp := gemini.ExtractTextFromResponse(resp)
+	return CleanAndValidateJSONResponse(textResp)
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"img-cli/pkg/gemini"
+	"img-cli/pkg/prompts"
 )
 
 type HairStyleAnalyzer struct {
@@ -19,27 +20,11 @@ func NewHairStyleAnalyzer(client *gemini.Client) *HairStyleAnalyzer {
 }
 
 func (h *HairStyleAnalyzer) Analyze(imagePath string) (json.RawMessage, error) {
-	prompt := `Analyze ONLY the hairstyle structure and styling in this image. COMPLETELY IGNORE hair color - focus exclusively on the cut, shape, and styling. Return a JSON object with the following structure:
-{
-  "style": "detailed hairstyle name and description (e.g., 'sleek low bun with face-framing tendrils', 'tousled beach waves', 'slicked-back pompadour')",
-  "length": "specific length description (e.g., 'shoulder-length', 'pixie cut', 'waist-length', 'chin-length bob')",
-  "texture": "hair texture and treatment (e.g., 'straightened smooth', 'natural waves', 'tight curls', 'crimped')",
-  "volume": "volume and body description (e.g., 'voluminous with teased crown', 'sleek and flat', 'full-bodied')",
-  "layers": "layering and cut details (e.g., 'long layers', 'blunt cut', 'feathered', 'graduated bob')",
-  "parting": "part style if visible (e.g., 'deep side part', 'center part', 'zigzag part', 'no visible part')",
-  "styling_technique": "how the hair is styled (e.g., 'blow-dried smooth', 'air-dried natural', 'heat-styled curls', 'braided', 'twisted')",
-  "front_styling": "how front/bangs are styled (e.g., 'side-swept bangs', 'curtain bangs', 'pulled back', 'face-framing layers')",
-  "accessories": "hair accessories only if they affect the style (e.g., 'held with pearl clips', 'secured with elastic', 'decorated with flowers')",
-  "overall": "comprehensive description of the complete hairstyle focusing on cut, shape, and styling techniques"
-}
-
-IMPORTANT:
-- Focus ONLY on hairstyle structure, NOT color
-- Describe the cut, shape, and styling method
-- Do not mention hair color at all
-- Include styling techniques and how the hair is arranged`
-
-	request, err := BuildImageAnalysisRequest(imagePath, prompt, gemini.AnalyzerConfig)
+	prompt, err := prompts.Render("analyze_hair_style", nil)
+	if err != nil {
+		return nil, err
+	}
+	request, err := BuildImageAnalysisRequest(imagePath, prompt, samplingFor(h.Type, gemini.AnalyzerConfig))
 	if err != nil {
 		return nil, err
 	}
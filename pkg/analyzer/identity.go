@@ -0,0 +1,87 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/gemini"
+)
+
+// MaxIdentityImages caps how many images are sent in one identity comparison
+const MaxIdentityImages = 6
+
+// IdentityPair is the identity similarity of two images in a comparison (1-based numbers)
+type IdentityPair struct {
+	A     int     `json:"a"`
+	B     int     `json:"b"`
+	Score float64 `json:"score"`
+}
+
+// IdentityComparison is the result of comparing the person across several images
+type IdentityComparison struct {
+	Pairs []IdentityPair `json:"pairs"`
+}
+
+// IdentityComparer rates whether several images show the same person
+type IdentityComparer struct {
+	client *gemini.Client
+}
+
+func NewIdentityComparer(client *gemini.Client) *IdentityComparer {
+	return &IdentityComparer{client: client}
+}
+
+// identityPrompt asks for pairwise identity scores across the attached images
+const identityPrompt = `The %d images above are numbered 1 to %d in the order given. They are meant to show the SAME person.
+For every pair of images, rate how certain you are that they show the same individual, judging only facial identity (face shape, eyes, nose, mouth, bone structure). Ignore clothing, hair styling, makeup, expression, lighting and pose.
+
+Return a JSON object with the following structure:
+{
+  "pairs": [
+    {"a": 1, "b": 2, "score": a number from 0.0 (clearly different people) to 1.0 (certainly the same person)}
+  ]
+}
+
+Include every pair exactly once with a < b. Return ONLY the JSON object.`
+
+// Compare scores every pair of the given images. At most MaxIdentityImages are compared.
+func (c *IdentityComparer) Compare(imagePaths []string) (*IdentityComparison, error) {
+	if len(imagePaths) > MaxIdentityImages {
+		imagePaths = imagePaths[:MaxIdentityImages]
+	}
+	if len(imagePaths) < 2 {
+		return nil, fmt.Errorf("need at least 2 images to compare, got %d", len(imagePaths))
+	}
+
+	var parts []interface{}
+	for _, path := range imagePaths {
+		data, mimeType, err := gemini.LoadImageAsBase64(path)
+		if err != nil {
+			return nil, fmt.Errorf("error loading image: %w", err)
+		}
+		parts = append(parts, gemini.BlobPart{
+			InlineData: gemini.InlineData{MimeType: mimeType, Data: data},
+		})
+	}
+	parts = append(parts, gemini.TextPart{Text: fmt.Sprintf(identityPrompt, len(imagePaths), len(imagePaths))})
+
+	request := gemini.Request{
+		Contents:         []gemini.Content{{Parts: parts}},
+		GenerationConfig: gemini.AnalyzerConfig,
+	}
+
+	resp, err := c.client.SendRequest(request)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %w", err)
+	}
+
+	data, err := CleanAndValidateJSONResponse(gemini.ExtractTextFromResponse(resp))
+	if err != nil {
+		return nil, err
+	}
+
+	var comparison IdentityComparison
+	if err := json.Unmarshal(data, &comparison); err != nil {
+		return nil, fmt.Errorf("error parsing identity comparison: %w", err)
+	}
+	return &comparison, nil
+}
@@ -5,6 +5,25 @@ import (
 	"fmt"
 )
 
+// PromptVersions records the prompt revision of each analyzer type. Bump an
+// entry whenever its prompt or output fields change, so provenance records can
+// tell which revision described a component.
+var PromptVersions = map[string]int{
+	"outfit":        1,
+	"visual_style":  1,
+	"art_style":     1,
+	"hair_style":    1,
+	"hair_color":    1,
+	"makeup":        1,
+	"expression":    1,
+	"accessories":   2,
+	"pose":          1,
+	"background":    1,
+	"subject_check": 1,
+	"people":        1,
+	"face":          1,
+}
+
 type Analyzer interface {
 	Analyze(imagePath string) (json.RawMessage, error)
 	GetType() string
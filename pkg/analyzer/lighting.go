@@ -0,0 +1,50 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/gemini"
+	"img-cli/pkg/prompts"
+)
+
+func init() {
+	Register("lighting", func(c *gemini.Client) Analyzer { return NewLightingAnalyzer(c) }, Component{
+		Help:    "Lighting setup (replaces the style's lighting)",
+		Heading: "LIGHTING (LIGHT ONLY)",
+		Instruction: "Light the subject and the scene with exactly this setup instead of the lighting of the style reference: the same direction, quality, color temperature and contrast. " +
+			"Framing, camera angle, pose, composition and grain still come from the other sections; only the light changes.",
+		Prompt:   "analyze_lighting",
+		Replaces: []string{"lighting"},
+	})
+}
+
+type LightingAnalyzer struct {
+	BaseAnalyzer
+	client *gemini.Client
+}
+
+func NewLightingAnalyzer(client *gemini.Client) *LightingAnalyzer {
+	return &LightingAnalyzer{
+		BaseAnalyzer: BaseAnalyzer{Type: "lighting"},
+		client:       client,
+	}
+}
+
+func (t *LightingAnalyzer) Analyze(imagePath string) (json.RawMessage, error) {
+	prompt, err := prompts.Render("analyze_lighting", nil)
+	if err != nil {
+		return nil, err
+	}
+	request, err := BuildImageAnalysisRequest(imagePath, prompt, samplingFor(t.Type, gemini.AnalyzerConfig))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.client.SendRequest(*request)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %w", err)
+	}
+
+	textResp := gemini.ExtractTextFromResponse(resp)
+	return CleanAndValidateJSONResponse(textResp)
+}
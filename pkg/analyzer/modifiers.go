@@ -0,0 +1,22 @@
+package analyzer
+
+// Age and era are text-only components: they change how old the subject looks
+// and when the photo seems to be taken, not what is in the reference images.
+func init() {
+	Register("age", nil, Component{
+		Help:    `Age of the subject as a description, e.g. "as a teenager" or "aged 60"`,
+		Heading: "AGE (SIGNS OF AGE ONLY - SAME PERSON)",
+		Instruction: "Show the subject at this age by changing only the natural signs of age: skin texture, lines and wrinkles, hair greying or thinning, and the softening or firming of the face that comes with age. " +
+			"This is the SAME PERSON at a different age, not a relative or a look-alike: keep their bone structure, face shape, eye color and shape, nose, mouth, skin tone and any distinctive marks recognizably their own. " +
+			"Do not change the framing, camera angle, pose or lighting for the age; those still come from the other sections.",
+		TextOnly: true,
+	})
+	Register("era", nil, Component{
+		Help:    `Era the photo is set in as a description, e.g. "1970s" or "Victorian"`,
+		Heading: "ERA (PERIOD LOOK ONLY)",
+		Instruction: "Make the image look like it was taken in this era: period-typical photographic rendering (film stock, color rendition, grain, print or lens character) and period grooming for anything the other sections leave open. " +
+			"Outfit, hair, makeup and other components given above are kept exactly as described, even if they are not typical of the era. " +
+			"The framing, camera angle, pose and composition still come from the photographic style, and the subject remains the EXACT SAME PERSON with identical facial features.",
+		TextOnly: true,
+	})
+}
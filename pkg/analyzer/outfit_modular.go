@@ -4,7 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"img-cli/pkg/gemini"
-	"strings"
+	"img-cli/pkg/prompts"
 )
 
 type ModularOutfitAnalyzer struct {
@@ -37,75 +37,11 @@ func (o *ModularOutfitAnalyzer) Analyze(imagePath string) (json.RawMessage, erro
 		return nil, fmt.Errorf("error loading image: %w", err)
 	}
 
-	// Build the prompt based on what should be excluded
-	var promptParts []string
-
-	promptParts = append(promptParts, "Analyze the outfit in this image with extreme precision and detail. You are analyzing for fashion designers who need comprehensive information.")
-
-	if o.excludeHair {
-		promptParts = append(promptParts, "IMPORTANT: DO NOT include any hair information in your analysis.")
-	}
-	if o.excludeMakeup {
-		promptParts = append(promptParts, "IMPORTANT: DO NOT include any makeup information in your analysis.")
-	}
-	if o.excludeAccessories {
-		promptParts = append(promptParts, "IMPORTANT: DO NOT include any accessories (jewelry, bags, belts, watches, etc.) in your analysis.")
-	}
-
-	promptParts = append(promptParts, `Return a JSON object with the following structure:
-{
-  "clothing": [extremely detailed list of each clothing item with comprehensive descriptions like "fitted charcoal gray merino wool blazer with notch lapels, two-button closure, functional buttonholes, ticket pocket, and subtle pick-stitching along the edges"],
-  "style": "clothing style ONLY - fashion genre, formality level, and garment styling techniques. DO NOT include environmental descriptions, lighting, or background elements",
-  "colors": [ONLY colors of the actual CLOTHING - use fashion terminology like "midnight navy", "winter white", "camel beige", "oxblood red". DO NOT include lighting colors, background colors, or environmental colors],`)
-
-	// Only include accessories field if not excluded
-	if !o.excludeAccessories {
-		promptParts = append(promptParts, `  "accessories": [exhaustive list with detailed descriptions of watches, jewelry, belts, bags, scarves, hats, etc. but NOT glasses, weapons, or weapon-related items],`)
-	}
-
-	promptParts = append(promptParts, `  "overall": "thorough outfit analysis covering garment interaction, proportions, styling choices, layering techniques, fabric interplay, and overall aesthetic impact"`)
-
-	// Only include hair field if not excluded
-	if !o.excludeHair {
-		promptParts = append(promptParts, `,
-  "hair": {
-    "color": "precise hair color description (e.g., 'ash blonde with platinum highlights', 'jet black', 'chestnut brown with caramel balayage')",
-    "style": "detailed hairstyle name and description (e.g., 'sleek low bun with face-framing tendrils', 'tousled beach waves', 'slicked-back pompadour')",
-    "length": "specific length description (e.g., 'shoulder-length', 'pixie cut', 'waist-length', 'chin-length bob')",
-    "texture": "hair texture analysis (e.g., 'fine and straight', 'thick and wavy', 'coily', 'kinky')",
-    "details": "any additional hair styling details"
-  }`)
-	}
-
-	promptParts = append(promptParts, `
-}`)
-
-	// Add exclusion reminders
-	if o.excludeHair || o.excludeMakeup || o.excludeAccessories {
-		promptParts = append(promptParts, "\nREMINDER:")
-		if o.excludeHair {
-			promptParts = append(promptParts, "- Do NOT include hair information")
-		}
-		if o.excludeMakeup {
-			promptParts = append(promptParts, "- Do NOT analyze or mention makeup")
-		}
-		if o.excludeAccessories {
-			promptParts = append(promptParts, "- Do NOT include accessories")
-		}
+	fullPrompt, err := o.prompt()
+	if err != nil {
+		return nil, err
 	}
 
-	promptParts = append(promptParts, `
-
-CRITICAL REQUIREMENTS:
-- Focus on actual clothing construction, materials, and styling
-- Use professional fashion terminology
-- Be extremely specific about garment details
-- Describe materials accurately (use "leather" not "faux leather", "fur" not "faux fur")
-- Never include glasses in accessories
-- Never describe environmental elements or lighting as part of the outfit`)
-
-	fullPrompt := strings.Join(promptParts, "\n")
-
 	request := gemini.Request{
 		Contents: []gemini.Content{
 			{
@@ -122,11 +58,11 @@ CRITICAL REQUIREMENTS:
 				},
 			},
 		},
-		GenerationConfig: &gemini.GenerationConfig{
+		GenerationConfig: samplingFor(o.Type, &gemini.GenerationConfig{
 			Temperature: 0.1,
 			TopP:        0.95,
 			TopK:        20,
-		},
+		}),
 	}
 
 	resp, err := o.client.SendRequest(request)
@@ -136,4 +72,11 @@ CRITICAL REQUIREMENTS:
 
 	textResp := gemini.ExtractTextFromResponse(resp)
 	return CleanAndValidateJSONResponse(textResp)
-}
\ No newline at end of file
+}
+
+// prompt renders the outfit analysis prompt, leaving out what other components supply
+func (o *ModularOutfitAnalyzer) prompt() (string, error) {
+	return prompts.Render("analyze_outfit_modular", struct {
+		ExcludeHair, ExcludeMakeup, ExcludeAccessories bool
+	}{o.excludeHair, o.excludeMakeup, o.excludeAccessories})
+}
@@ -0,0 +1,108 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/gemini"
+	"strings"
+)
+
+// ReviewExpectations is what a generated image was asked to show
+type ReviewExpectations struct {
+	Garments    []string // e.g. "navy coat", "white shirt"
+	Framing     string   // e.g. "full body shot"
+	Accessories string   // Requested accessories; empty means none were requested
+}
+
+// GarmentCheck compares one requested garment with what the image shows
+type GarmentCheck struct {
+	Garment  string `json:"garment"`
+	Observed string `json:"observed_color"`
+	Match    bool   `json:"color_match"`
+}
+
+// OutputReview is the result of reviewing a generated image against its recipe
+type OutputReview struct {
+	Identity         float64        `json:"identity"` // 0-1 same-person score against the subject photo
+	Garments         []GarmentCheck `json:"garments"`
+	FramingMatch     bool           `json:"framing_match"`
+	ObservedFraming  string         `json:"observed_framing"`
+	ExtraAccessories []string       `json:"extra_accessories"`
+}
+
+// OutputReviewer checks a generated image against its subject photo and the
+// recipe it was generated from, with one request per image
+type OutputReviewer struct {
+	client *gemini.Client
+}
+
+func NewOutputReviewer(client *gemini.Client) *OutputReviewer {
+	return &OutputReviewer{client: client}
+}
+
+const outputReviewPrompt = `Image 1 is a reference photo of a person. Image 2 was generated to show the same person with the requested look below.
+
+Requested garments:
+%s
+Requested framing: %s
+Requested accessories: %s
+
+Review image 2 and return a JSON object with the following structure:
+{
+  "identity": a number from 0.0 (clearly a different person) to 1.0 (certainly the same person as image 1), judging only facial identity and ignoring styling, makeup, lighting and pose,
+  "garments": [{"garment": "requested garment as written above", "observed_color": "color of that garment in image 2, or 'not visible'", "color_match": true if the color is the requested one or the garment is legitimately out of frame}],
+  "framing_match": true if image 2 uses the requested framing,
+  "observed_framing": "the framing image 2 actually uses",
+  "extra_accessories": ["accessories worn in image 2 that were not requested (jewelry, bags, hats, glasses, watches, belts); empty array if none"]
+}
+
+Return ONLY the JSON object.`
+
+// Review compares a generated image with the subject photo and expectations
+func (r *OutputReviewer) Review(outputPath, subjectPath string, expect ReviewExpectations) (*OutputReview, error) {
+	var parts []interface{}
+	for _, path := range []string{subjectPath, outputPath} {
+		data, mimeType, err := gemini.LoadImageAsBase64(path)
+		if err != nil {
+			return nil, fmt.Errorf("error loading image: %w", err)
+		}
+		parts = append(parts, gemini.BlobPart{
+			InlineData: gemini.InlineData{MimeType: mimeType, Data: data},
+		})
+	}
+
+	garments := "- (none specified)\n"
+	if len(expect.Garments) > 0 {
+		garments = "- " + strings.Join(expect.Garments, "\n- ") + "\n"
+	}
+	framing := expect.Framing
+	if framing == "" {
+		framing = "(not specified, any framing is fine)"
+	}
+	accessories := expect.Accessories
+	if accessories == "" {
+		accessories = "none"
+	}
+	parts = append(parts, gemini.TextPart{Text: fmt.Sprintf(outputReviewPrompt, garments, framing, accessories)})
+
+	request := gemini.Request{
+		Contents:         []gemini.Content{{Parts: parts}},
+		GenerationConfig: gemini.AnalyzerConfig,
+	}
+
+	resp, err := r.client.SendRequest(request)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %w", err)
+	}
+
+	data, err := CleanAndValidateJSONResponse(gemini.ExtractTextFromResponse(resp))
+	if err != nil {
+		return nil, err
+	}
+
+	var review OutputReview
+	if err := json.Unmarshal(data, &review); err != nil {
+		return nil, fmt.Errorf("error parsing output review: %w", err)
+	}
+	return &review, nil
+}
@@ -0,0 +1,52 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/gemini"
+	"img-cli/pkg/prompts"
+)
+
+func init() {
+	Register("palette", func(c *gemini.Client) Analyzer { return NewPaletteAnalyzer(c) }, Component{
+		Help:    "Outfit color palette (recolors the garments, keeps their structure)",
+		Dir:     "palettes",
+		Heading: "COLOR PALETTE (OUTFIT COLORS ONLY)",
+		Instruction: "Recolor the outfit with this palette; it replaces the colors given in the OUTFIT section. " +
+			"Keep every garment's cut, construction, fabric, texture, pattern layout, trims and hardware exactly as described - only the colors change. " +
+			"If the palette names garments, recolor those garments only; otherwise put the dominant colors on the largest garments and the accent colors on trims and smaller pieces. " +
+			"Do not recolor the subject's skin, hair, eyes or makeup, the background or the lighting.",
+		Prompt: "analyze_palette",
+	})
+}
+
+type PaletteAnalyzer struct {
+	BaseAnalyzer
+	client *gemini.Client
+}
+
+func NewPaletteAnalyzer(client *gemini.Client) *PaletteAnalyzer {
+	return &PaletteAnalyzer{
+		BaseAnalyzer: BaseAnalyzer{Type: "palette"},
+		client:       client,
+	}
+}
+
+func (t *PaletteAnalyzer) Analyze(imagePath string) (json.RawMessage, error) {
+	prompt, err := prompts.Render("analyze_palette", nil)
+	if err != nil {
+		return nil, err
+	}
+	request, err := BuildImageAnalysisRequest(imagePath, prompt, samplingFor(t.Type, gemini.AnalyzerConfig))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.client.SendRequest(*request)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %w", err)
+	}
+
+	textResp := gemini.ExtractTextFromResponse(resp)
+	return CleanAndValidateJSONResponse(textResp)
+}
@@ -0,0 +1,51 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/gemini"
+	"img-cli/pkg/prompts"
+)
+
+// People is the result of detecting the people in a group photo
+type People struct {
+	People []Person `json:"people"` // Left to right
+}
+
+// Person is one person found in a photo
+type Person struct {
+	Position    string `json:"position"`    // left, center or right
+	Description string `json:"description"` // Enough to tell them apart, e.g. "tall man in a grey suit"
+}
+
+// PeopleAnalyzer finds the people in a photo so one of them can be picked out
+type PeopleAnalyzer struct {
+	BaseAnalyzer
+	client *gemini.Client
+}
+
+func NewPeopleAnalyzer(client *gemini.Client) *PeopleAnalyzer {
+	return &PeopleAnalyzer{
+		BaseAnalyzer: BaseAnalyzer{Type: "people"},
+		client:       client,
+	}
+}
+
+func (p *PeopleAnalyzer) Analyze(imagePath string) (json.RawMessage, error) {
+	prompt, err := prompts.Render("analyze_people", nil)
+	if err != nil {
+		return nil, err
+	}
+	request, err := BuildImageAnalysisRequest(imagePath, prompt, samplingFor(p.Type, gemini.AnalyzerConfig))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.client.SendRequest(*request)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %w", err)
+	}
+
+	textResp := gemini.ExtractTextFromResponse(resp)
+	return CleanAndValidateJSONResponse(textResp)
+}
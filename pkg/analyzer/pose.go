@@ -0,0 +1,39 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/gemini"
+	"img-cli/pkg/prompts"
+)
+
+type PoseAnalyzer struct {
+	BaseAnalyzer
+	client *gemini.Client
+}
+
+func NewPoseAnalyzer(client *gemini.Client) *PoseAnalyzer {
+	return &PoseAnalyzer{
+		BaseAnalyzer: BaseAnalyzer{Type: "pose"},
+		client:       client,
+	}
+}
+
+func (p *PoseAnalyzer) Analyze(imagePath string) (json.RawMessage, error) {
+	prompt, err := prompts.Render("analyze_pose", nil)
+	if err != nil {
+		return nil, err
+	}
+	request, err := BuildImageAnalysisRequest(imagePath, prompt, samplingFor(p.Type, gemini.AnalyzerConfig))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.client.SendRequest(*request)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %w", err)
+	}
+
+	textResp := gemini.ExtractTextFromResponse(resp)
+	return CleanAndValidateJSONResponse(textResp)
+}
@@ -0,0 +1,51 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/gemini"
+	"img-cli/pkg/prompts"
+)
+
+func init() {
+	Register("prop", func(c *gemini.Client) Analyzer { return NewPropAnalyzer(c) }, Component{
+		Help:    "Object the subject holds (kept apart from accessories)",
+		Dir:     "props",
+		Heading: "HELD PROP (IN THE HANDS - NOT WORN)",
+		Instruction: "The subject holds this object naturally in their hands, at a size that is realistic next to their body. " +
+			"It is a prop, not an accessory: do not turn it into jewelry, a bag strap or any other worn item, and do not add other held objects. " +
+			"Adjust only the hands and arms as far as needed to hold it; framing, camera angle and the subject's identity stay as described in the other sections.",
+		Prompt: "analyze_prop",
+	})
+}
+
+type PropAnalyzer struct {
+	BaseAnalyzer
+	client *gemini.Client
+}
+
+func NewPropAnalyzer(client *gemini.Client) *PropAnalyzer {
+	return &PropAnalyzer{
+		BaseAnalyzer: BaseAnalyzer{Type: "prop"},
+		client:       client,
+	}
+}
+
+func (t *PropAnalyzer) Analyze(imagePath string) (json.RawMessage, error) {
+	prompt, err := prompts.Render("analyze_prop", nil)
+	if err != nil {
+		return nil, err
+	}
+	request, err := BuildImageAnalysisRequest(imagePath, prompt, samplingFor(t.Type, gemini.AnalyzerConfig))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.client.SendRequest(*request)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %w", err)
+	}
+
+	textResp := gemini.ExtractTextFromResponse(resp)
+	return CleanAndValidateJSONResponse(textResp)
+}
@@ -0,0 +1,71 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/gemini"
+	"img-cli/pkg/prompts"
+)
+
+// RegionScale is the range of Region box coordinates: 0 is the top or left
+// edge of the image and RegionScale the bottom or right edge
+const RegionScale = 1000
+
+// Region is where something named with --region appears in a photo
+type Region struct {
+	Found bool     `json:"found"`
+	Boxes [][4]int `json:"boxes"` // [ymin, xmin, ymax, xmax] in 0-RegionScale; a pair of shoes has two
+}
+
+// RegionAnalyzer locates a named part of a photo ("jacket", "hair") for the
+// masks of the inpaint command. The region is part of the question, so its
+// answers aren't cached like the component analyses.
+type RegionAnalyzer struct {
+	BaseAnalyzer
+	client *gemini.Client
+	region string
+}
+
+func NewRegionAnalyzer(client *gemini.Client, region string) *RegionAnalyzer {
+	return &RegionAnalyzer{
+		BaseAnalyzer: BaseAnalyzer{Type: "region"},
+		client:       client,
+		region:       region,
+	}
+}
+
+// NewFaceAnalyzer returns a RegionAnalyzer that finds faces for --face-lock.
+// It asks the same question of every image, so unlike other regions its
+// answers for subject photos can be cached.
+func NewFaceAnalyzer(client *gemini.Client) *RegionAnalyzer {
+	return &RegionAnalyzer{
+		BaseAnalyzer: BaseAnalyzer{Type: "face"},
+		client:       client,
+		region:       "face",
+	}
+}
+
+// regionPromptData is what the analyze_region template sees
+type regionPromptData struct {
+	Region string
+	Scale  int
+}
+
+func (r *RegionAnalyzer) Analyze(imagePath string) (json.RawMessage, error) {
+	prompt, err := prompts.Render("analyze_region", regionPromptData{Region: r.region, Scale: RegionScale})
+	if err != nil {
+		return nil, err
+	}
+	request, err := BuildImageAnalysisRequest(imagePath, prompt, samplingFor(r.Type, gemini.AnalyzerConfig))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := r.client.SendRequest(*request)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %w", err)
+	}
+
+	textResp := gemini.ExtractTextFromResponse(resp)
+	return CleanAndValidateJSONResponse(textResp)
+}
@@ -0,0 +1,189 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"img-cli/pkg/cache"
+	"img-cli/pkg/gemini"
+	"img-cli/pkg/workspace"
+	"strings"
+	"sync"
+)
+
+// Factory builds an analyzer that sends its requests through client
+type Factory func(client *gemini.Client) Analyzer
+
+// Component makes a registered analyzer a modular component: it gets a flag
+// on generate-modular and outfit-swap, an asset folder with an analysis
+// cache, text descriptions expanded like the built-in components, and its own
+// section in the generation prompt.
+type Component struct {
+	Flag        string // CLI flag and asset kind ("" = the type with "-" for "_")
+	Help        string // What the component controls, for flag help, e.g. "Tattoos and body art"
+	Dir         string // Folder of its references and their cached analyses ("" = the flag)
+	Heading     string // Heading of its prompt section ("" = the type in capitals)
+	Instruction string // How the model should apply the description, after it in the prompt
+	Prompt      string // Analysis prompt template text descriptions are expanded with ("" = used as-is)
+	Version     int    // Prompt revision recorded in provenance (see PromptVersions; 0 = 1)
+
+	// Keywords are values taken as-is rather than as a reference name or a
+	// description, e.g. "none"; Default is the value used when the component
+	// isn't given ("" = left out of the prompt)
+	Keywords []string
+	Default  string
+
+	// Section is the prompt template its section is rendered with, given the
+	// description or keyword ("" = the heading, description and instruction)
+	Section string
+
+	// Replaces lists the fields of the style analysis the component supplies;
+	// they are left out of the style description when it is given
+	Replaces []string
+
+	// TextOnly components take a description but no reference image, and
+	// have no analyzer: register them with a nil factory
+	TextOnly bool
+
+	// Describe turns an analysis into the prompt description (nil = every
+	// field as "Field: value", in the order of the analysis)
+	Describe func(data json.RawMessage) string
+}
+
+// registration is one analyzer type added with Register
+type registration struct {
+	name      string
+	factory   Factory
+	component *Component
+}
+
+// registry holds the analyzer types orchestrators construct, in registration
+// order. The built-in types come first, in the order "analyze --type all"
+// runs them; package variables are set before any init function registers
+// more.
+var (
+	registryMu sync.RWMutex
+	registry   = []registration{
+		{name: "outfit", factory: func(c *gemini.Client) Analyzer { return NewOutfitAnalyzer(c) }},
+		{name: "visual_style", factory: func(c *gemini.Client) Analyzer { return NewVisualStyleAnalyzer(c) }},
+		{name: "art_style", factory: func(c *gemini.Client) Analyzer { return NewArtStyleAnalyzer(c) }},
+		{name: "hair_style", factory: func(c *gemini.Client) Analyzer { return NewHairStyleAnalyzer(c) }},
+		{name: "hair_color", factory: func(c *gemini.Client) Analyzer { return NewHairColorAnalyzer(c) }},
+		{name: "makeup", factory: func(c *gemini.Client) Analyzer { return NewMakeupAnalyzer(c) }},
+		{name: "expression", factory: func(c *gemini.Client) Analyzer { return NewExpressionAnalyzer(c) }},
+		{name: "accessories", factory: func(c *gemini.Client) Analyzer { return NewAccessoriesAnalyzer(c) }},
+		{name: "pose", factory: func(c *gemini.Client) Analyzer { return NewPoseAnalyzer(c) }},
+		{name: "background", factory: func(c *gemini.Client) Analyzer { return NewBackgroundAnalyzer(c) }},
+	}
+)
+
+// Register adds an analyzer type, replacing one registered under the same
+// name. Orchestrators construct it with factory the first time it is needed
+// and give it its own cache. Passing a Component also makes it a modular
+// component (see Component); register those from an init function so the
+// commands see them when they define their flags.
+func Register(name string, factory Factory, component ...Component) {
+	entry := registration{name: name, factory: factory}
+	if len(component) > 0 {
+		c := withComponentDefaults(name, component[0])
+		entry.component = &c
+		if !c.TextOnly {
+			cache.RegisterDir(name, c.Dir)
+			workspace.RegisterAssetKind(c.Flag, c.Dir)
+		}
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if entry.component != nil && !entry.component.TextOnly {
+		PromptVersions[name] = entry.component.Version
+	}
+	for i, existing := range registry {
+		if existing.name == name {
+			registry[i] = entry
+			return
+		}
+	}
+	registry = append(registry, entry)
+}
+
+// withComponentDefaults fills in the fields a component left empty
+func withComponentDefaults(name string, c Component) Component {
+	if c.Flag == "" {
+		c.Flag = strings.ReplaceAll(name, "_", "-")
+	}
+	if c.Help == "" {
+		c.Help = strings.ReplaceAll(name, "_", " ")
+	}
+	if c.Dir == "" {
+		c.Dir = c.Flag
+	}
+	if c.Heading == "" {
+		c.Heading = strings.ToUpper(strings.ReplaceAll(name, "_", " "))
+	}
+	if c.Version == 0 {
+		c.Version = 1
+	}
+	return c
+}
+
+// Keyword returns the keyword a value names, matched regardless of case
+func (c Component) Keyword(value string) (string, bool) {
+	for _, keyword := range c.Keywords {
+		if strings.EqualFold(strings.TrimSpace(value), keyword) {
+			return keyword, true
+		}
+	}
+	return "", false
+}
+
+// Registered lists the registered analyzer types in registration order,
+// leaving out text-only components
+func Registered() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	var names []string
+	for _, entry := range registry {
+		if entry.factory != nil {
+			names = append(names, entry.name)
+		}
+	}
+	return names
+}
+
+// New constructs a registered analyzer type
+func New(name string, client *gemini.Client) (Analyzer, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	for _, entry := range registry {
+		if entry.name == name && entry.factory != nil {
+			return entry.factory(client), true
+		}
+	}
+	return nil, false
+}
+
+// Components lists the registered analyzer types that are modular
+// components, in registration order
+func Components() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	var names []string
+	for _, entry := range registry {
+		if entry.component != nil {
+			names = append(names, entry.name)
+		}
+	}
+	return names
+}
+
+// LookupComponent returns a registered component by analyzer type or flag,
+// with its defaults filled in, and its analyzer type
+func LookupComponent(name string) (string, Component, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	for _, entry := range registry {
+		if entry.component != nil && (entry.name == name || entry.component.Flag == name) {
+			return entry.name, *entry.component, true
+		}
+	}
+	return "", Component{}, false
+}
@@ -0,0 +1,68 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/gemini"
+	"img-cli/pkg/prompts"
+)
+
+// StyleBlendVersion identifies the blending step. Bump it whenever the way
+// analyses are blended changes, so cached blends are regenerated; edits to
+// blend_visual_style are picked up by StyleBlendPromptVersion.
+const StyleBlendVersion = 1
+
+// StyleBlendPromptVersion identifies the blending prompt for caching,
+// including an override of blend_visual_style in prompts/
+func StyleBlendPromptVersion() string {
+	return fmt.Sprintf("%d:%s", StyleBlendVersion, prompts.Version("blend_visual_style"))
+}
+
+// StyleBlender synthesizes one visual style from the analyses of several
+// style references (--style-blend), like ArtStyleAnalyzer.AnalyzeMultiple
+// does for art styles. The result has the fields of a visual style analysis,
+// so it is used exactly like the analysis of a single reference.
+type StyleBlender struct {
+	client *gemini.Client
+}
+
+func NewStyleBlender(client *gemini.Client) *StyleBlender {
+	return &StyleBlender{client: client}
+}
+
+// Blend merges visual style analyses into one, with a text-only request
+func (b *StyleBlender) Blend(styles []json.RawMessage) (json.RawMessage, error) {
+	if len(styles) == 0 {
+		return nil, fmt.Errorf("no styles to blend")
+	}
+	if len(styles) == 1 {
+		return styles[0], nil
+	}
+
+	analyses := make([]string, len(styles))
+	for i, style := range styles {
+		analyses[i] = string(style)
+	}
+	prompt, err := prompts.Render("blend_visual_style", analyses)
+	if err != nil {
+		return nil, err
+	}
+	request := gemini.Request{
+		Contents: []gemini.Content{
+			{
+				Parts: []interface{}{
+					gemini.TextPart{Text: prompt},
+				},
+			},
+		},
+		GenerationConfig: samplingFor("visual_style", gemini.AnalyzerConfig),
+	}
+
+	resp, err := b.client.SendRequest(request)
+	if err != nil {
+		return nil, fmt.Errorf("error blending styles: %w", err)
+	}
+
+	textResp := gemini.ExtractTextFromResponse(resp)
+	return CleanAndValidateJSONResponse(textResp)
+}
@@ -0,0 +1,48 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/gemini"
+	"img-cli/pkg/prompts"
+)
+
+// SubjectCheck is the result of a subject photo pre-flight check
+type SubjectCheck struct {
+	PersonCount   int      `json:"person_count"`
+	FaceVisible   bool     `json:"face_visible"`
+	FaceOcclusion string   `json:"face_occlusion"` // none, partial or heavy
+	Issues        []string `json:"issues"`
+}
+
+// SubjectCheckAnalyzer checks whether a photo is usable as a generation subject
+type SubjectCheckAnalyzer struct {
+	BaseAnalyzer
+	client *gemini.Client
+}
+
+func NewSubjectCheckAnalyzer(client *gemini.Client) *SubjectCheckAnalyzer {
+	return &SubjectCheckAnalyzer{
+		BaseAnalyzer: BaseAnalyzer{Type: "subject_check"},
+		client:       client,
+	}
+}
+
+func (s *SubjectCheckAnalyzer) Analyze(imagePath string) (json.RawMessage, error) {
+	prompt, err := prompts.Render("analyze_subject_check", nil)
+	if err != nil {
+		return nil, err
+	}
+	request, err := BuildImageAnalysisRequest(imagePath, prompt, samplingFor(s.Type, gemini.AnalyzerConfig))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.SendRequest(*request)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %w", err)
+	}
+
+	textResp := gemini.ExtractTextFromResponse(resp)
+	return CleanAndValidateJSONResponse(textResp)
+}
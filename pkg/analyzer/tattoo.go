@@ -0,0 +1,50 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/gemini"
+	"img-cli/pkg/prompts"
+)
+
+func init() {
+	Register("tattoo", func(c *gemini.Client) Analyzer { return NewTattooAnalyzer(c) }, Component{
+		Help:    "Tattoos and body art",
+		Dir:     "tattoos",
+		Heading: "TATTOOS / BODY ART (SKIN ONLY)",
+		Instruction: "Apply these tattoos to the subject's skin at the placement described, following the curve of the body and partly hidden where clothing covers that area. " +
+			"They are ink on the skin only: do NOT change the subject's face, facial features, skin tone, body shape or identity, and do not add any tattoos that are not described.",
+		Prompt: "analyze_tattoo",
+	})
+}
+
+type TattooAnalyzer struct {
+	BaseAnalyzer
+	client *gemini.Client
+}
+
+func NewTattooAnalyzer(client *gemini.Client) *TattooAnalyzer {
+	return &TattooAnalyzer{
+		BaseAnalyzer: BaseAnalyzer{Type: "tattoo"},
+		client:       client,
+	}
+}
+
+func (t *TattooAnalyzer) Analyze(imagePath string) (json.RawMessage, error) {
+	prompt, err := prompts.Render("analyze_tattoo", nil)
+	if err != nil {
+		return nil, err
+	}
+	request, err := BuildImageAnalysisRequest(imagePath, prompt, samplingFor(t.Type, gemini.AnalyzerConfig))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.client.SendRequest(*request)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %w", err)
+	}
+
+	textResp := gemini.ExtractTextFromResponse(resp)
+	return CleanAndValidateJSONResponse(textResp)
+}
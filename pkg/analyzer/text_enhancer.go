@@ -0,0 +1,101 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/gemini"
+	"img-cli/pkg/prompts"
+)
+
+// TextEnhancerVersion identifies the expansion prompt. Bump it whenever the
+// wrapper below changes, so cached expansions are regenerated; edits to the
+// analysis templates it reuses are picked up by EnhancerPromptVersion.
+const TextEnhancerVersion = 1
+
+// textEnhancerPrompts maps component types to the analysis prompt template
+// whose JSON structure a text description is expanded into
+var textEnhancerPrompts = map[string]string{
+	"hair_style": "analyze_hair_style",
+	"hair_color": "analyze_hair_color",
+	"makeup":     "analyze_makeup",
+	"expression": "analyze_expression",
+	"pose":       "analyze_pose",
+	"background": "analyze_background",
+}
+
+// TextEnhancer expands short free-text component descriptions ("smoky eye") into
+// the same structured fields the image analyzers produce, using a text-only request.
+// This gives text inputs the same level of detail in the generation prompt as
+// image references.
+type TextEnhancer struct {
+	client *gemini.Client
+}
+
+func NewTextEnhancer(client *gemini.Client) *TextEnhancer {
+	return &TextEnhancer{client: client}
+}
+
+// Supports reports whether a component type can be expanded
+func (t *TextEnhancer) Supports(componentType string) bool {
+	return enhancerPrompt(componentType) != ""
+}
+
+// enhancerPrompt returns the analysis prompt template of a built-in or
+// registered component type (see Register), or "" when it has none
+func enhancerPrompt(componentType string) string {
+	if template, ok := textEnhancerPrompts[componentType]; ok {
+		return template
+	}
+	if name, component, ok := LookupComponent(componentType); ok && name == componentType {
+		return component.Prompt
+	}
+	return ""
+}
+
+// EnhancerPromptVersion identifies the expansion prompt of a component type
+// for caching: the wrapper's TextEnhancerVersion and the version of the
+// analysis template, which may be overridden in prompts/
+func EnhancerPromptVersion(componentType string) string {
+	return fmt.Sprintf("%d:%s", TextEnhancerVersion, prompts.Version(enhancerPrompt(componentType)))
+}
+
+// Enhance expands a text description into the analyzer JSON for componentType
+func (t *TextEnhancer) Enhance(componentType, text string) (json.RawMessage, error) {
+	template := enhancerPrompt(componentType)
+	if template == "" {
+		return nil, fmt.Errorf("text enhancement not supported for %s", componentType)
+	}
+	instructions, err := prompts.Render(template, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	prompt := fmt.Sprintf(`There is no image for this task. Instead, the desired look was described in words:
+
+"%s"
+
+Imagine a photo that shows exactly this and answer the instructions below as if analyzing that photo.
+Stay faithful to every detail in the description. Fill in anything it leaves open with specific,
+plausible choices that fit the described look, and never contradict it.
+
+%s`, text, instructions)
+
+	request := gemini.Request{
+		Contents: []gemini.Content{
+			{
+				Parts: []interface{}{
+					gemini.TextPart{Text: prompt},
+				},
+			},
+		},
+		GenerationConfig: samplingFor(componentType, gemini.AnalyzerConfig),
+	}
+
+	resp, err := t.client.SendRequest(request)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %w", err)
+	}
+
+	textResp := gemini.ExtractTextFromResponse(resp)
+	return CleanAndValidateJSONResponse(textResp)
+}
@@ -0,0 +1,104 @@
+package c2pa
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// A small CBOR (RFC 8949) encoder covering the types used by C2PA claims,
+// assertions and COSE signatures. Maps keep their insertion order so the
+// encoding is deterministic.
+
+const (
+	majorUint   = 0
+	majorNegInt = 1
+	majorBytes  = 2
+	majorText   = 3
+	majorArray  = 4
+	majorMap    = 5
+	majorTag    = 6
+	majorSimple = 7
+)
+
+// cborMap is an ordered CBOR map
+type cborMap []cborPair
+
+type cborPair struct {
+	Key   any
+	Value any
+}
+
+// cborTag is a tagged CBOR value
+type cborTag struct {
+	Tag   uint64
+	Value any
+}
+
+func cborEncode(v any) []byte {
+	var buf []byte
+	return appendCBOR(buf, v)
+}
+
+func appendHead(buf []byte, major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return append(buf, major<<5|byte(n))
+	case n <= 0xff:
+		return append(buf, major<<5|24, byte(n))
+	case n <= 0xffff:
+		return binary.BigEndian.AppendUint16(append(buf, major<<5|25), uint16(n))
+	case n <= 0xffffffff:
+		return binary.BigEndian.AppendUint32(append(buf, major<<5|26), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(buf, major<<5|27), n)
+	}
+}
+
+func appendCBOR(buf []byte, v any) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(buf, majorSimple<<5|22)
+	case bool:
+		if v {
+			return append(buf, majorSimple<<5|21)
+		}
+		return append(buf, majorSimple<<5|20)
+	case int:
+		return appendInt(buf, int64(v))
+	case int64:
+		return appendInt(buf, v)
+	case uint64:
+		return appendHead(buf, majorUint, v)
+	case string:
+		return append(appendHead(buf, majorText, uint64(len(v))), v...)
+	case []byte:
+		return append(appendHead(buf, majorBytes, uint64(len(v))), v...)
+	case time.Time:
+		return appendCBOR(buf, v.UTC().Format(time.RFC3339))
+	case []any:
+		buf = appendHead(buf, majorArray, uint64(len(v)))
+		for _, item := range v {
+			buf = appendCBOR(buf, item)
+		}
+		return buf
+	case cborMap:
+		buf = appendHead(buf, majorMap, uint64(len(v)))
+		for _, pair := range v {
+			buf = appendCBOR(buf, pair.Key)
+			buf = appendCBOR(buf, pair.Value)
+		}
+		return buf
+	case cborTag:
+		return appendCBOR(appendHead(buf, majorTag, v.Tag), v.Value)
+	default:
+		panic(fmt.Sprintf("c2pa: unsupported CBOR type %T", v))
+	}
+}
+
+func appendInt(buf []byte, n int64) []byte {
+	if n < 0 {
+		return appendHead(buf, majorNegInt, uint64(-1-n))
+	}
+	return appendHead(buf, majorUint, uint64(n))
+}
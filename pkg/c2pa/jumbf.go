@@ -0,0 +1,52 @@
+package c2pa
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+)
+
+// JUMBF (ISO/IEC 19566-5) boxes. A superbox ("jumb") starts with a description
+// box ("jumd") giving its content type UUID and label, followed by its children.
+
+// C2PA content types are the four-character code followed by this suffix
+const c2paUUIDSuffix = "00110010800000aa00389b71"
+
+var (
+	typeManifestStore  = contentType("c2pa")
+	typeManifest       = contentType("c2ma")
+	typeAssertionStore = contentType("c2as")
+	typeClaim          = contentType("c2cl")
+	typeSignature      = contentType("c2cs")
+	typeCBOR           = contentType("cbor")
+)
+
+func contentType(fourCC string) []byte {
+	suffix, _ := hex.DecodeString(c2paUUIDSuffix)
+	return append([]byte(fourCC), suffix...)
+}
+
+// box encodes a box with a 32-bit length
+func box(boxType string, payload []byte) []byte {
+	buf := binary.BigEndian.AppendUint32(nil, uint32(8+len(payload)))
+	buf = append(buf, boxType...)
+	return append(buf, payload...)
+}
+
+// superboxPayload is a superbox without its own header: the description box
+// followed by the children. Assertion hashes are computed over this.
+func superboxPayload(uuid []byte, label string, children ...[]byte) []byte {
+	desc := append([]byte{}, uuid...)
+	desc = append(desc, 0x03) // requestable, label present
+	desc = append(desc, label...)
+	desc = append(desc, 0)
+
+	payload := box("jumd", desc)
+	for _, child := range children {
+		payload = append(payload, child...)
+	}
+	return payload
+}
+
+func superbox(uuid []byte, label string, children ...[]byte) []byte {
+	return box("jumb", superboxPayload(uuid, label, children...))
+}
@@ -0,0 +1,136 @@
+package c2pa
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+)
+
+// chunkType is the PNG chunk holding a C2PA manifest store
+const chunkType = "caBX"
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// SignPNG embeds signed content credentials in a PNG file, replacing any it
+// already carries. The manifest goes right after the IHDR chunk and is
+// excluded from the file hash it signs.
+func SignPNG(path string, info Info, s *Signer) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	data, err = stripManifest(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+
+	// Signature plus IHDR (length, type, 13 data bytes, CRC)
+	insertAt := len(pngSignature) + 12 + 13
+	assetHash := sha256.Sum256(data)
+
+	// The exclusion length is recorded inside the manifest, so rebuild until
+	// the chunk size stops changing
+	var store []byte
+	length := 0
+	for i := 0; i < 5; i++ {
+		store, err = s.manifestStore(info, "image/png", assetHash[:], exclusion{insertAt, length})
+		if err != nil {
+			return err
+		}
+		if 12+len(store) == length {
+			break
+		}
+		length = 12 + len(store)
+	}
+	if 12+len(store) != length {
+		return fmt.Errorf("%s: manifest size did not settle", filepath.Base(path))
+	}
+
+	var out bytes.Buffer
+	out.Write(data[:insertAt])
+	out.Write(pngChunk(chunkType, store))
+	out.Write(data[insertAt:])
+	return writeFile(path, out.Bytes())
+}
+
+// HasManifest reports whether a PNG file carries a C2PA manifest store
+func HasManifest(path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	found := false
+	walkChunks(data, func(typ string, start, end int) {
+		found = found || typ == chunkType
+	})
+	return found
+}
+
+// stripManifest checks the PNG layout and removes existing manifest chunks
+func stripManifest(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, fmt.Errorf("not a PNG file")
+	}
+	if len(data) < len(pngSignature)+25 || string(data[12:16]) != "IHDR" {
+		return nil, fmt.Errorf("PNG does not start with an IHDR chunk")
+	}
+
+	var out bytes.Buffer
+	out.Write(pngSignature)
+	ok := walkChunks(data, func(typ string, start, end int) {
+		if typ != chunkType {
+			out.Write(data[start:end])
+		}
+	})
+	if !ok {
+		return nil, fmt.Errorf("truncated PNG chunk")
+	}
+	return out.Bytes(), nil
+}
+
+// walkChunks calls fn with the byte range of every chunk; it reports false
+// if a chunk runs past the end of the file
+func walkChunks(data []byte, fn func(typ string, start, end int)) bool {
+	pos := len(pngSignature)
+	for pos+12 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		end := pos + 12 + length
+		if length < 0 || end > len(data) {
+			return false
+		}
+		fn(string(data[pos+4:pos+8]), pos, end)
+		pos = end
+	}
+	return pos == len(data)
+}
+
+func pngChunk(typ string, payload []byte) []byte {
+	buf := binary.BigEndian.AppendUint32(nil, uint32(len(payload)))
+	buf = append(buf, typ...)
+	buf = append(buf, payload...)
+	return binary.BigEndian.AppendUint32(buf, crc32.ChecksumIEEE(buf[4:]))
+}
+
+// writeFile replaces a file through a temporary file in the same directory
+func writeFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if info, err := os.Stat(path); err == nil {
+		os.Chmod(tmp.Name(), info.Mode())
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,354 @@
+package c2pa
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"encoding/pem"
+	"image"
+	"image/png"
+	"img-cli/pkg/config"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// A signed PNG carries a COSE signature that verifies against the embedded
+// certificate, and a data hash that matches the file outside the manifest
+func TestSignPNG(t *testing.T) {
+	path := testPNG(t)
+	if err := SignPNG(path, Info{Title: "kat.png", Workflow: "modular", Model: "image", Created: time.Now()}, testSigner(t)); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := png.Decode(bytes.NewReader(data)); err != nil {
+		t.Fatalf("signed file is not a valid PNG: %v", err)
+	}
+
+	start, end, store := manifestChunk(t, data)
+
+	// Hard binding: exclusion covers the caBX chunk, the rest hashes to the claim
+	hashData := cborDecode(t, jumbfContent(t, store, labelDataHash))
+	exclusions := cborField(t, hashData, "exclusions").([]any)
+	if len(exclusions) != 1 {
+		t.Fatalf("got %d exclusions, want 1", len(exclusions))
+	}
+	exclStart := int(cborField(t, exclusions[0], "start").(uint64))
+	exclLength := int(cborField(t, exclusions[0], "length").(uint64))
+	if exclStart != start || exclLength != end-start {
+		t.Errorf("exclusion %d+%d, want the caBX chunk at %d+%d", exclStart, exclLength, start, end-start)
+	}
+	outside := append(append([]byte{}, data[:exclStart]...), data[exclStart+exclLength:]...)
+	if got, want := sha256.Sum256(outside), cborField(t, hashData, "hash").([]byte); !bytes.Equal(got[:], want) {
+		t.Errorf("bytes outside the exclusion hash to %x, c2pa.hash.data has %x", got, want)
+	}
+
+	// COSE_Sign1 over the claim, signed by the certificate in the protected header
+	claim := jumbfContent(t, store, "c2pa.claim")
+	tagged, ok := cborDecode(t, jumbfContent(t, store, "c2pa.signature")).(cborTag)
+	if !ok || tagged.Tag != 18 {
+		t.Fatalf("signature is not a tagged COSE_Sign1: %#v", tagged)
+	}
+	sign1 := tagged.Value.([]any)
+	protected, signature := sign1[0].([]byte), sign1[3].([]byte)
+	header := cborDecode(t, protected)
+	if alg := cborField(t, header, uint64(1)); alg != int64(algES256) {
+		t.Errorf("alg = %v, want ES256", alg)
+	}
+	cert, err := x509.ParseCertificate(cborField(t, header, uint64(33)).([]byte))
+	if err != nil {
+		t.Fatalf("x5chain: %v", err)
+	}
+	toBeSigned := cborEncode([]any{"Signature1", protected, []byte{}, claim})
+	digest := sha256.Sum256(toBeSigned)
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:])
+	if len(signature) != 64 || !ecdsa.Verify(cert.PublicKey.(*ecdsa.PublicKey), digest[:], r, s) {
+		t.Error("COSE signature does not verify against the embedded certificate")
+	}
+
+	// The claim references the data hash assertion by the hash of its box
+	refs := cborField(t, cborDecode(t, claim), "assertions").([]any)
+	assertion := sha256.Sum256(jumbfPayload(t, store, labelDataHash))
+	if got := cborField(t, refs[0], "hash").([]byte); !bytes.Equal(got, assertion[:]) {
+		t.Errorf("claim hashes the data hash assertion as %x, want %x", got, assertion)
+	}
+}
+
+// Signing an already signed image replaces its manifest instead of adding one
+func TestSignPNGReplacesManifest(t *testing.T) {
+	path := testPNG(t)
+	original, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := testSigner(t)
+	for _, title := range []string{"first", "second"} {
+		if err := SignPNG(path, Info{Title: title, Created: time.Now()}, signer); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunks := 0
+	walkChunks(data, func(typ string, start, end int) {
+		if typ == chunkType {
+			chunks++
+		}
+	})
+	if chunks != 1 {
+		t.Fatalf("re-signed file has %d %s chunks, want 1", chunks, chunkType)
+	}
+	_, _, store := manifestChunk(t, data)
+	if title := cborField(t, cborDecode(t, jumbfContent(t, store, "c2pa.claim")), "dc:title"); title != "second" {
+		t.Errorf("manifest title = %v, want the second signing", title)
+	}
+	stripped, err := stripManifest(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(stripped, original) {
+		t.Error("removing the manifest does not give back the original PNG")
+	}
+}
+
+// testSigner loads a throwaway self-signed P-256 certificate
+func testSigner(t *testing.T) *Signer {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "img-cli test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	cfg := &config.SigningConfig{
+		CertPath:  filepath.Join(dir, "cert.pem"),
+		KeyPath:   filepath.Join(dir, "key.pem"),
+		Generator: "img-cli test",
+	}
+	if err := os.WriteFile(cfg.CertPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(cfg.KeyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	signer, err := LoadSigner(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+func testPNG(t *testing.T) string {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 16, 16))); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "kat.png")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// manifestChunk returns the byte range of the caBX chunk and its payload
+func manifestChunk(t *testing.T, data []byte) (start, end int, store []byte) {
+	t.Helper()
+	start = -1
+	walkChunks(data, func(typ string, s, e int) {
+		if typ == chunkType && start < 0 {
+			start, end = s, e
+		}
+	})
+	if start < 0 {
+		t.Fatalf("no %s chunk", chunkType)
+	}
+	return start, end, data[start+8 : end-4]
+}
+
+// jumbfPayload finds the superbox with the given label and returns its
+// payload, the description box followed by the children
+func jumbfPayload(t *testing.T, data []byte, label string) []byte {
+	t.Helper()
+	if payload := findSuperbox(data, label); payload != nil {
+		return payload
+	}
+	t.Fatalf("no JUMBF box labeled %s", label)
+	return nil
+}
+
+// jumbfContent returns the content of the first child of a labeled superbox
+func jumbfContent(t *testing.T, data []byte, label string) []byte {
+	t.Helper()
+	children := readBoxes(jumbfPayload(t, data, label))
+	if len(children) < 2 {
+		t.Fatalf("JUMBF box %s has no content", label)
+	}
+	return children[1].payload
+}
+
+type jumbfBox struct {
+	typ     string
+	payload []byte
+}
+
+func readBoxes(data []byte) []jumbfBox {
+	var boxes []jumbfBox
+	for len(data) >= 8 {
+		size := int(binary.BigEndian.Uint32(data))
+		if size < 8 || size > len(data) {
+			break
+		}
+		boxes = append(boxes, jumbfBox{string(data[4:8]), data[8:size]})
+		data = data[size:]
+	}
+	return boxes
+}
+
+func findSuperbox(data []byte, label string) []byte {
+	for _, b := range readBoxes(data) {
+		if b.typ != "jumb" {
+			continue
+		}
+		children := readBoxes(b.payload)
+		// Description: 16-byte content type, toggles, NUL-terminated label
+		if len(children) > 0 && children[0].typ == "jumd" && len(children[0].payload) > 17 {
+			name, _, _ := bytes.Cut(children[0].payload[17:], []byte{0})
+			if string(name) == label {
+				return b.payload
+			}
+		}
+		if found := findSuperbox(b.payload, label); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// cborDecode decodes one CBOR item; maps become map[any]any with uint64,
+// int64 or string keys and negative integers become int64
+func cborDecode(t *testing.T, data []byte) any {
+	t.Helper()
+	v, rest, ok := decodeItem(data)
+	if !ok || len(rest) != 0 {
+		t.Fatalf("invalid CBOR (%d trailing bytes)", len(rest))
+	}
+	return v
+}
+
+func decodeItem(data []byte) (any, []byte, bool) {
+	if len(data) == 0 {
+		return nil, nil, false
+	}
+	major, info := data[0]>>5, data[0]&0x1f
+	data = data[1:]
+	var n uint64
+	switch {
+	case info < 24:
+		n = uint64(info)
+	case info <= 27:
+		size := 1 << (info - 24)
+		if len(data) < size {
+			return nil, nil, false
+		}
+		for _, b := range data[:size] {
+			n = n<<8 | uint64(b)
+		}
+		data = data[size:]
+	default:
+		return nil, nil, false
+	}
+
+	switch major {
+	case majorUint:
+		return n, data, true
+	case majorNegInt:
+		return -1 - int64(n), data, true
+	case majorBytes, majorText:
+		if uint64(len(data)) < n {
+			return nil, nil, false
+		}
+		if major == majorText {
+			return string(data[:n]), data[n:], true
+		}
+		return data[:n], data[n:], true
+	case majorArray:
+		items := []any{}
+		for i := uint64(0); i < n; i++ {
+			item, rest, ok := decodeItem(data)
+			if !ok {
+				return nil, nil, false
+			}
+			items, data = append(items, item), rest
+		}
+		return items, data, true
+	case majorMap:
+		m := map[any]any{}
+		for i := uint64(0); i < n; i++ {
+			key, rest, ok := decodeItem(data)
+			if !ok {
+				return nil, nil, false
+			}
+			value, rest, ok := decodeItem(rest)
+			if !ok {
+				return nil, nil, false
+			}
+			m[key], data = value, rest
+		}
+		return m, data, true
+	case majorTag:
+		value, rest, ok := decodeItem(data)
+		return cborTag{n, value}, rest, ok
+	default:
+		switch n {
+		case 20:
+			return false, data, true
+		case 21:
+			return true, data, true
+		case 22:
+			return nil, data, true
+		}
+		return nil, nil, false
+	}
+}
+
+// cborField returns a field of a decoded CBOR map
+func cborField(t *testing.T, m any, key any) any {
+	t.Helper()
+	fields, ok := m.(map[any]any)
+	if !ok {
+		t.Fatalf("%T is not a CBOR map", m)
+	}
+	value, ok := fields[key]
+	if !ok {
+		t.Fatalf("CBOR map has no %v", key)
+	}
+	return value
+}
@@ -0,0 +1,177 @@
+package c2pa
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"img-cli/pkg/config"
+	"img-cli/pkg/errors"
+	"math/big"
+	"os"
+	"reflect"
+)
+
+// COSE algorithm identifiers allowed by C2PA
+const (
+	algES256 = -7
+	algES384 = -35
+	algPS256 = -37
+	algEdDSA = -8
+)
+
+// Signer signs C2PA claims with a certificate and its private key
+type Signer struct {
+	Generator string
+
+	chain [][]byte // DER certificates, signing certificate first
+	key   crypto.Signer
+	alg   int
+	hash  crypto.Hash
+}
+
+// LoadSigner reads the certificate chain and key named by cfg
+func LoadSigner(cfg *config.SigningConfig) (*Signer, error) {
+	if !cfg.Configured() {
+		return nil, errors.New(errors.ConfigError,
+			"signing needs a certificate and key: set IMG_CLI_C2PA_CERT and IMG_CLI_C2PA_KEY")
+	}
+
+	chain, err := readCertificates(cfg.CertPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, errors.ConfigError, "invalid C2PA certificate %s", cfg.CertPath)
+	}
+	key, err := readPrivateKey(cfg.KeyPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, errors.ConfigError, "invalid C2PA key %s", cfg.KeyPath)
+	}
+
+	leaf, err := x509.ParseCertificate(chain[0])
+	if err != nil {
+		return nil, errors.Wrapf(err, errors.ConfigError, "invalid C2PA certificate %s", cfg.CertPath)
+	}
+	if !publicKeysEqual(leaf.PublicKey, key.Public()) {
+		return nil, errors.New(errors.ConfigError, "the C2PA key does not belong to the first certificate in the chain")
+	}
+
+	signer := &Signer{Generator: cfg.Generator, chain: chain, key: key}
+	switch pub := key.Public().(type) {
+	case *ecdsa.PublicKey:
+		switch pub.Curve {
+		case elliptic.P256():
+			signer.alg, signer.hash = algES256, crypto.SHA256
+		case elliptic.P384():
+			signer.alg, signer.hash = algES384, crypto.SHA384
+		default:
+			return nil, errors.New(errors.ConfigError, "C2PA EC keys must use P-256 or P-384")
+		}
+	case *rsa.PublicKey:
+		signer.alg, signer.hash = algPS256, crypto.SHA256
+	case ed25519.PublicKey:
+		signer.alg = algEdDSA
+	default:
+		return nil, errors.Newf(errors.ConfigError, "unsupported C2PA key type %T", pub)
+	}
+	return signer, nil
+}
+
+// sign produces a COSE signature over data in the encoding COSE expects
+func (s *Signer) sign(data []byte) ([]byte, error) {
+	switch s.alg {
+	case algEdDSA:
+		return s.key.Sign(rand.Reader, data, crypto.Hash(0))
+	case algPS256:
+		digest := digest(s.hash, data)
+		return s.key.Sign(rand.Reader, digest, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: s.hash})
+	default:
+		// COSE wants ECDSA signatures as fixed-size r||s instead of ASN.1
+		key, ok := s.key.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("unexpected EC key type %T", s.key)
+		}
+		r, sig, err := ecdsa.Sign(rand.Reader, key, digest(s.hash, data))
+		if err != nil {
+			return nil, err
+		}
+		size := (key.Curve.Params().BitSize + 7) / 8
+		return append(fixedBytes(r, size), fixedBytes(sig, size)...), nil
+	}
+}
+
+func digest(hash crypto.Hash, data []byte) []byte {
+	h := hash.New()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func fixedBytes(n *big.Int, size int) []byte {
+	return n.FillBytes(make([]byte, size))
+}
+
+func readCertificates(path string) ([][]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var chain [][]byte
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type == "CERTIFICATE" {
+			chain = append(chain, block.Bytes)
+		}
+	}
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("no PEM certificates found")
+	}
+	return chain, nil
+}
+
+func readPrivateKey(path string) (crypto.Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("no PEM private key found")
+		}
+
+		var key any
+		switch block.Type {
+		case "PRIVATE KEY":
+			key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+		case "EC PRIVATE KEY":
+			key, err = x509.ParseECPrivateKey(block.Bytes)
+		case "RSA PRIVATE KEY":
+			key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key type %T", key)
+		}
+		return signer, nil
+	}
+}
+
+func publicKeysEqual(a, b crypto.PublicKey) bool {
+	if eq, ok := a.(interface{ Equal(crypto.PublicKey) bool }); ok {
+		return eq.Equal(b)
+	}
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"img-cli/pkg/logger"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Cache files are shared by parallel workers and by separate img-cli processes.
+// Entries are written to a temporary file in the same directory and then
+// published in one step, so readers never see a partially written file.
+
+const (
+	readAttempts = 3
+	readBackoff  = 25 * time.Millisecond
+)
+
+// writeEntry atomically writes a cache file. Unless overwrite is set an
+// existing entry wins, so manual edits and a concurrent writer's entry are kept.
+func writeEntry(path string, data []byte, overwrite bool) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, 0644); err != nil {
+		return err
+	}
+
+	if overwrite {
+		return os.Rename(tmpPath, path)
+	}
+
+	// A hard link publishes the file only if no entry exists yet
+	err = os.Link(tmpPath, path)
+	if err == nil || errors.Is(err, os.ErrExist) {
+		return nil
+	}
+	// Filesystems without hard links fall back to a checked rename
+	if _, statErr := os.Stat(path); statErr == nil {
+		return nil
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// readEntry reads and decodes a cache file. A file that fails to decode is
+// re-read a few times in case it is being replaced, then moved aside as
+// <name>.corrupt so the next run analyzes the image again.
+func readEntry(path string) (*CacheEntry, error) {
+	var lastErr error
+	for attempt := 0; attempt < readAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(readBackoff * time.Duration(attempt))
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var entry CacheEntry
+		if lastErr = json.Unmarshal(data, &entry); lastErr == nil {
+			return &entry, nil
+		}
+	}
+
+	logger.Warn("Corrupt cache entry moved aside", "file", path, "error", lastErr)
+	os.Rename(path, path+".corrupt")
+	return nil, lastErr
+}
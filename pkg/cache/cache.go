@@ -5,17 +5,21 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"img-cli/pkg/config"
 	"img-cli/pkg/models"
+	"img-cli/pkg/workspace"
 	"io"
 	"os"
 	"path/filepath"
-	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type Cache struct {
 	cacheDir string
 	ttl      time.Duration
+	store    Store // Where entries live (see IMG_CLI_CACHE_BACKEND)
 }
 
 type CacheEntry struct {
@@ -25,14 +29,17 @@ type CacheEntry struct {
 	FilePath  string          `json:"file_path"`
 	FileHash  string          `json:"file_hash"`
 	Data      json.RawMessage `json:"data"`
+
+	// Set for text entries (see text.go) instead of FilePath
+	Text string `json:"text,omitempty"`
 }
 
 func NewCache(cacheDir string, ttl time.Duration) *Cache {
 	if cacheDir == "" {
-		cacheDir = "cache/analyses"
+		cacheDir = workspace.ProjectPath("cache", "analyses")
 	}
 	if ttl == 0 {
-		ttl = 24 * time.Hour * 7 // Default 7 days
+		ttl = config.CacheTTL("") // Default 7 days
 	}
 
 	os.MkdirAll(cacheDir, 0755)
@@ -40,34 +47,66 @@ func NewCache(cacheDir string, ttl time.Duration) *Cache {
 	return &Cache{
 		cacheDir: cacheDir,
 		ttl:      ttl,
+		store:    openStore(cacheDir),
 	}
 }
 
+// registeredDirs maps analysis types added with RegisterDir to their asset folder
+var (
+	registeredDirsMu sync.RWMutex
+	registeredDirs   = make(map[string]string)
+)
+
+// RegisterDir keeps the cache of an analysis type without a built-in folder
+// (see analyzer.Register) under the asset cache of dir
+func RegisterDir(analysisType, dir string) {
+	registeredDirsMu.Lock()
+	defer registeredDirsMu.Unlock()
+	registeredDirs[analysisType] = dir
+}
+
+func registeredDir(analysisType string) (string, bool) {
+	registeredDirsMu.RLock()
+	defer registeredDirsMu.RUnlock()
+	dir, ok := registeredDirs[analysisType]
+	return dir, ok
+}
+
 // NewCacheForType creates a cache instance for a specific analysis type
 func NewCacheForType(analysisType string, ttl time.Duration) *Cache {
 	var cacheDir string
 
 	switch analysisType {
 	case "outfit":
-		cacheDir = "outfits/cache"
+		cacheDir = workspace.AssetCacheDir("outfits")
 	case "visual_style", "art_style":
-		cacheDir = "styles/cache"
+		cacheDir = workspace.AssetCacheDir("styles")
 	case "hair_style":
-		cacheDir = "hair-style/cache"
+		cacheDir = workspace.AssetCacheDir("hair-style")
 	case "hair_color":
-		cacheDir = "hair-color/cache"
+		cacheDir = workspace.AssetCacheDir("hair-color")
 	case "makeup":
-		cacheDir = "makeup/cache"
+		cacheDir = workspace.AssetCacheDir("makeup")
 	case "expression":
-		cacheDir = "expressions/cache"
+		cacheDir = workspace.AssetCacheDir("expressions")
 	case "accessories":
-		cacheDir = "accessories/cache"
+		cacheDir = workspace.AssetCacheDir("accessories")
+	case "pose":
+		cacheDir = workspace.AssetCacheDir("poses")
+	case "background":
+		cacheDir = workspace.AssetCacheDir("backgrounds")
+	case "subject_check", "people", "face":
+		cacheDir = workspace.AssetCacheDir("subjects")
 	default:
-		cacheDir = "cache/analyses"
+		if dir, ok := registeredDir(analysisType); ok {
+			cacheDir = workspace.AssetCacheDir(dir)
+		} else {
+			cacheDir = workspace.ProjectPath("cache", "analyses")
+		}
 	}
 
 	if ttl == 0 {
-		ttl = 24 * time.Hour * 7 // Default 7 days
+		ttl = config.CacheTTL(analysisType) // Default 7 days
 	}
 
 	os.MkdirAll(cacheDir, 0755)
@@ -75,18 +114,10 @@ func NewCacheForType(analysisType string, ttl time.Duration) *Cache {
 	return &Cache{
 		cacheDir: cacheDir,
 		ttl:      ttl,
+		store:    openStore(cacheDir),
 	}
 }
 
-func (c *Cache) generateKey(analysisType, filePath string) string {
-	// Use just the filename (base name) for the key, not the full path
-	// This allows the cache to work even if files are moved to different directories
-	baseName := filepath.Base(filePath)
-	// Clean the filename to be filesystem-safe
-	cleanName := strings.ReplaceAll(baseName, " ", "_")
-	return fmt.Sprintf("%s_%s", analysisType, cleanName)
-}
-
 func (c *Cache) getFileHash(filePath string) (string, error) {
 	// Calculate hash based on actual file content, not path
 	// This ensures the same file has the same hash regardless of location
@@ -118,44 +149,72 @@ func (c *Cache) getFileHash(filePath string) (string, error) {
 	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
-func (c *Cache) Get(analysisType, filePath string) (json.RawMessage, bool) {
-	key := c.generateKey(analysisType, filePath)
-	cachePath := filepath.Join(c.cacheDir, key+".json")
+// Counters are the cache lookups and writes of this process, for every type
+type Counters struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+	Writes int64 `json:"writes"`
+}
 
-	data, err := os.ReadFile(cachePath)
-	if err != nil {
-		return nil, false
+var hits, misses, writes atomic.Int64
+
+// Activity returns the cache lookups and writes made so far
+func Activity() Counters {
+	return Counters{Hits: hits.Load(), Misses: misses.Load(), Writes: writes.Load()}
+}
+
+// countLookup records whether a lookup found an entry
+func countLookup(found bool) {
+	if found {
+		hits.Add(1)
+	} else {
+		misses.Add(1)
+	}
+}
+
+// countWrite records a stored entry
+func countWrite(err error) error {
+	if err == nil {
+		writes.Add(1)
 	}
+	return err
+}
+
+func (c *Cache) Get(analysisType, filePath string) (json.RawMessage, bool) {
+	key, hash := c.generateKey(analysisType, filePath)
 
-	var entry CacheEntry
-	if err := json.Unmarshal(data, &entry); err != nil {
+	entry, err := c.store.Read(key)
+	if os.IsNotExist(err) {
+		entry, err = c.migrateLegacy(analysisType, filePath, key, hash)
+	}
+	countLookup(err == nil)
+	if err != nil {
 		return nil, false
 	}
 
 	// IMPORTANT: Always use cached version if it exists
 	// This allows manual edits to be preserved
-	// We don't check TTL expiration or file hash changes
-	// The cache is based purely on filename, not path or content
+	// We don't check TTL expiration
+	// The key holds the file name and content hash, not the path, so moved
+	// copies still hit and different images with the same name don't collide
 
 	return entry.Data, true
 }
 
 func (c *Cache) Set(analysisType, filePath string, data json.RawMessage) error {
-	key := c.generateKey(analysisType, filePath)
-	cachePath := filepath.Join(c.cacheDir, key+".json")
+	return c.set(analysisType, filePath, data, false)
+}
 
-	// IMPORTANT: Never overwrite existing cache files
-	// This preserves manual edits made to cache files
-	if _, err := os.Stat(cachePath); err == nil {
-		// Cache file already exists, don't overwrite it
-		return nil
-	}
+// Replace stores data like Set but overwrites an existing entry, including
+// manual edits. It is for explicitly refreshing stale analyses.
+func (c *Cache) Replace(analysisType, filePath string, data json.RawMessage) error {
+	return c.set(analysisType, filePath, data, true)
+}
+
+func (c *Cache) set(analysisType, filePath string, data json.RawMessage, overwrite bool) error {
+	key, fileHash := c.generateKey(analysisType, filePath)
 
 	absPath, _ := filepath.Abs(filePath)
-	fileHash, err := c.getFileHash(filePath)
-	if err != nil {
-		fileHash = ""
-	}
 
 	entry := CacheEntry{
 		Key:       key,
@@ -166,56 +225,41 @@ func (c *Cache) Set(analysisType, filePath string, data json.RawMessage) error {
 		Data:      data,
 	}
 
-	jsonData, err := json.MarshalIndent(entry, "", "  ")
-	if err != nil {
-		return err
-	}
-
-	return os.WriteFile(cachePath, jsonData, 0644)
+	// IMPORTANT: Never overwrite existing cache entries unless asked to
+	// This preserves manual edits made to cache files
+	return countWrite(c.store.Write(&entry, overwrite))
 }
 
 func (c *Cache) Clear() error {
-	return os.RemoveAll(c.cacheDir)
+	return c.store.Clear()
 }
 
 func (c *Cache) ClearType(analysisType string) error {
-	files, err := os.ReadDir(c.cacheDir)
+	var keys []string
+	err := c.store.Scan(func(entry *CacheEntry, size int64) {
+		if entry.Type == analysisType {
+			keys = append(keys, entry.Key)
+		}
+	})
 	if err != nil {
 		return err
 	}
 
-	for _, file := range files {
-		if file.IsDir() {
-			continue
-		}
-
-		filePath := filepath.Join(c.cacheDir, file.Name())
-		data, err := os.ReadFile(filePath)
-		if err != nil {
-			continue
-		}
-
-		var entry CacheEntry
-		if err := json.Unmarshal(data, &entry); err != nil {
-			continue
-		}
-
-		if entry.Type == analysisType {
-			os.Remove(filePath)
-		}
+	for _, key := range keys {
+		c.store.Delete(key)
 	}
 
 	return nil
 }
 
 func (c *Cache) Stats() (map[string]interface{}, error) {
-	files, err := os.ReadDir(c.cacheDir)
+	keys, err := c.store.Keys()
 	if err != nil {
 		return nil, err
 	}
 
 	stats := map[string]interface{}{
-		"total_entries": len(files),
+		"total_entries": len(keys),
 		"cache_dir":     c.cacheDir,
 		"ttl_hours":     c.ttl.Hours(),
 		"by_type":       make(map[string]int),
@@ -223,23 +267,11 @@ func (c *Cache) Stats() (map[string]interface{}, error) {
 
 	byType := stats["by_type"].(map[string]int)
 
-	for _, file := range files {
-		if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
-			continue
-		}
-
-		filePath := filepath.Join(c.cacheDir, file.Name())
-		data, err := os.ReadFile(filePath)
-		if err != nil {
-			continue
-		}
-
-		var entry CacheEntry
-		if err := json.Unmarshal(data, &entry); err != nil {
-			continue
-		}
-
+	err = c.store.Scan(func(entry *CacheEntry, size int64) {
 		byType[entry.Type]++
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return stats, nil
@@ -247,40 +279,15 @@ func (c *Cache) Stats() (map[string]interface{}, error) {
 
 // GetStats returns cache statistics in the models.CacheStats format
 func (c *Cache) GetStats() (*models.CacheStats, error) {
-	files, err := os.ReadDir(c.cacheDir)
-	if err != nil {
-		return nil, err
-	}
-
 	stats := &models.CacheStats{
 		TotalEntries:  0,
 		EntriesByType: make(map[string]int),
 		TotalSize:     0,
 	}
 
-	for _, file := range files {
-		if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
-			continue
-		}
-
+	err := c.store.Scan(func(entry *CacheEntry, size int64) {
 		stats.TotalEntries++
-
-		info, err := file.Info()
-		if err == nil {
-			stats.TotalSize += info.Size()
-		}
-
-		filePath := filepath.Join(c.cacheDir, file.Name())
-		data, err := os.ReadFile(filePath)
-		if err != nil {
-			continue
-		}
-
-		var entry CacheEntry
-		if err := json.Unmarshal(data, &entry); err != nil {
-			continue
-		}
-
+		stats.TotalSize += size
 		stats.EntriesByType[entry.Type]++
 
 		// Track oldest/newest
@@ -290,7 +297,10 @@ func (c *Cache) GetStats() (*models.CacheStats, error) {
 		if stats.NewestEntry.IsZero() || entry.Timestamp.After(stats.NewestEntry) {
 			stats.NewestEntry = entry.Timestamp
 		}
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return stats, nil
-}
\ No newline at end of file
+}
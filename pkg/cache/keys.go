@@ -0,0 +1,119 @@
+package cache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// File entries are keyed by analysis type, file name and a prefix of the
+// content hash: "outfit_dress.png_3f2a9c81d0e4". The name keeps the cache
+// directory readable; the hash keeps two different images with the same name
+// apart while a moved copy of the same image still hits.
+//
+// Older entries were keyed by type and file name only ("outfit_dress.png").
+// Get migrates such an entry to the new key the first time its image is
+// looked up, if the hash recorded in the entry matches the image.
+
+// keyHashLength is how many hex digits of the content hash go into a key
+const keyHashLength = 12
+
+// generateKey returns the cache key for a file and its content hash. Files
+// that can't be read get the legacy name-only key and an empty hash.
+func (c *Cache) generateKey(analysisType, filePath string) (string, string) {
+	hash, err := c.getFileHash(filePath)
+	if err != nil {
+		return legacyKey(analysisType, filePath), ""
+	}
+	return fmt.Sprintf("%s_%s", legacyKey(analysisType, filePath), hash[:keyHashLength]), hash
+}
+
+// legacyKey returns the name-only key entries had before keys included the
+// content hash
+func legacyKey(analysisType, filePath string) string {
+	// Clean the filename to be filesystem-safe
+	cleanName := strings.ReplaceAll(filepath.Base(filePath), " ", "_")
+	return fmt.Sprintf("%s_%s", analysisType, cleanName)
+}
+
+// isHashedKey reports whether key is a hashed key starting with prefix
+func isHashedKey(key, prefix string) bool {
+	suffix, ok := strings.CutPrefix(key, prefix+"_")
+	if !ok || len(suffix) != keyHashLength {
+		return false
+	}
+	return strings.Trim(suffix, "0123456789abcdef") == ""
+}
+
+// migrateLegacy moves the name-only entry of a file to its hashed key. An
+// entry recorded for different content is left for the image it belongs to.
+func (c *Cache) migrateLegacy(analysisType, filePath, key, hash string) (*CacheEntry, error) {
+	legacy := legacyKey(analysisType, filePath)
+	if hash == "" || key == legacy {
+		return nil, os.ErrNotExist
+	}
+	entry, err := c.store.Read(legacy)
+	if err != nil {
+		return nil, err
+	}
+	if entry.FileHash != "" && entry.FileHash != hash {
+		return nil, os.ErrNotExist
+	}
+
+	entry.Key = key
+	entry.FileHash = hash
+	if err := c.store.Write(entry, false); err != nil {
+		return nil, err
+	}
+	c.store.Delete(legacy)
+	return entry, nil
+}
+
+// Entries returns the entries cached for a file, so single analyses can be
+// inspected (see Location for where each is stored). For a readable file
+// that is its own entry (or its legacy entry, until migrated); for a file
+// name that isn't on disk it is every entry cached under that name.
+func (c *Cache) Entries(analysisType, filePath string) []*CacheEntry {
+	key, hash := c.generateKey(analysisType, filePath)
+	legacy := legacyKey(analysisType, filePath)
+
+	var candidates []string
+	if hash != "" {
+		candidates = []string{key, legacy}
+	} else {
+		keys, _ := c.store.Keys()
+		for _, k := range keys {
+			if k == legacy || isHashedKey(k, legacy) {
+				candidates = append(candidates, k)
+			}
+		}
+	}
+
+	var entries []*CacheEntry
+	for _, k := range candidates {
+		entry, err := c.store.Read(k)
+		if err != nil {
+			continue
+		}
+		// A legacy entry made for different content belongs to another image
+		if hash != "" && k == legacy && entry.FileHash != "" && entry.FileHash != hash {
+			continue
+		}
+		entry.Key = k
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// Evict removes the entries Entries returns, so the next use of the file
+// analyzes it again, and reports how many it removed
+func (c *Cache) Evict(analysisType, filePath string) (int, error) {
+	entries := c.Entries(analysisType, filePath)
+	for i, entry := range entries {
+		if err := c.store.Delete(entry.Key); err != nil {
+			return i, err
+		}
+	}
+	return len(entries), nil
+}
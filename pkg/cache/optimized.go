@@ -131,16 +131,11 @@ func (c *OptimizedCache) GetOutfitAnalysis(filePath string) (*models.OutfitAnaly
 
 	// Load full data
 	cachePath := filepath.Join(c.cacheDir, key+".json")
-	data, err := os.ReadFile(cachePath)
+	cacheEntry, err := readEntry(cachePath)
 	if err != nil {
 		return nil, false
 	}
 
-	var cacheEntry CacheEntry
-	if err := json.Unmarshal(data, &cacheEntry); err != nil {
-		return nil, false
-	}
-
 	// Verify file hash if needed
 	currentHash, err := c.getFileHash(filePath)
 	if err == nil && currentHash != entry.FileHash {
@@ -186,16 +181,11 @@ func (c *OptimizedCache) GetVisualStyleAnalysis(filePath string) (*models.Visual
 	}
 
 	cachePath := filepath.Join(c.cacheDir, key+".json")
-	data, err := os.ReadFile(cachePath)
+	cacheEntry, err := readEntry(cachePath)
 	if err != nil {
 		return nil, false
 	}
 
-	var cacheEntry CacheEntry
-	if err := json.Unmarshal(data, &cacheEntry); err != nil {
-		return nil, false
-	}
-
 	var analysis models.VisualStyleAnalysis
 	if err := json.Unmarshal(cacheEntry.Data, &analysis); err != nil {
 		return nil, false
@@ -367,7 +357,7 @@ func (c *OptimizedCache) getFileHash(filePath string) (string, error) {
 	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
-// Set stores data in the cache (delegates to parent Cache.Set)
+// Set stores data in the cache, replacing any existing entry atomically
 func (c *OptimizedCache) Set(analysisType, filePath string, data json.RawMessage) error {
 	key := c.generateKey(analysisType, filePath)
 	cachePath := filepath.Join(c.cacheDir, key+".json")
@@ -404,5 +394,5 @@ func (c *OptimizedCache) Set(analysisType, filePath string, data json.RawMessage
 	}
 	c.mu.Unlock()
 
-	return os.WriteFile(cachePath, jsonData, 0644)
+	return writeEntry(cachePath, jsonData, true)
 }
\ No newline at end of file
@@ -0,0 +1,125 @@
+//go:build sqlite
+
+package cache
+
+import (
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "modernc.org/sqlite" // Pure Go driver, registered as "sqlite"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS entries (
+	key            TEXT PRIMARY KEY,
+	type           TEXT NOT NULL,
+	timestamp      TEXT NOT NULL,
+	file_path      TEXT NOT NULL DEFAULT '',
+	file_hash      TEXT NOT NULL DEFAULT '',
+	text           TEXT NOT NULL DEFAULT '',
+	data           TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS entries_type ON entries (type);
+CREATE INDEX IF NOT EXISTS entries_file_hash ON entries (file_hash);`
+
+// sqliteStore keeps the entries of a cache directory in <dir>/analyses.db.
+// Each entry is a row, with its analysis JSON in the data column.
+type sqliteStore struct {
+	db *sql.DB
+}
+
+func openSQLiteStore(dir string) (Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	// WAL and a busy timeout let parallel workers and other img-cli
+	// processes share the database
+	dsn := "file:" + filepath.Join(dir, sqliteFile) + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &sqliteStore{db: db}, nil
+}
+
+func (s *sqliteStore) Read(key string) (*CacheEntry, error) {
+	var entry CacheEntry
+	var timestamp, data string
+	err := s.db.QueryRow(`SELECT key, type, timestamp, file_path, file_hash, text, data
+		FROM entries WHERE key = ?`, key).
+		Scan(&entry.Key, &entry.Type, &timestamp, &entry.FilePath, &entry.FileHash, &entry.Text, &data)
+	if err == sql.ErrNoRows {
+		return nil, os.ErrNotExist
+	}
+	if err != nil {
+		return nil, err
+	}
+	entry.Timestamp, _ = time.Parse(time.RFC3339Nano, timestamp)
+	entry.Data = json.RawMessage(data)
+	return &entry, nil
+}
+
+func (s *sqliteStore) Write(entry *CacheEntry, overwrite bool) error {
+	verb := "INSERT OR IGNORE"
+	if overwrite {
+		verb = "INSERT OR REPLACE"
+	}
+	_, err := s.db.Exec(verb+` INTO entries (key, type, timestamp, file_path, file_hash, text, data)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		entry.Key, entry.Type, entry.Timestamp.Format(time.RFC3339Nano), entry.FilePath, entry.FileHash,
+		entry.Text, string(entry.Data))
+	return err
+}
+
+func (s *sqliteStore) Delete(key string) error {
+	_, err := s.db.Exec(`DELETE FROM entries WHERE key = ?`, key)
+	return err
+}
+
+func (s *sqliteStore) Keys() ([]string, error) {
+	rows, err := s.db.Query(`SELECT key FROM entries ORDER BY key`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (s *sqliteStore) Scan(fn func(entry *CacheEntry, size int64)) error {
+	rows, err := s.db.Query(`SELECT key, type, timestamp, file_path, file_hash, text, data FROM entries`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var entry CacheEntry
+		var timestamp, data string
+		if err := rows.Scan(&entry.Key, &entry.Type, &timestamp, &entry.FilePath, &entry.FileHash, &entry.Text, &data); err != nil {
+			return err
+		}
+		entry.Timestamp, _ = time.Parse(time.RFC3339Nano, timestamp)
+		entry.Data = json.RawMessage(data)
+		fn(&entry, int64(len(data)))
+	}
+	return rows.Err()
+}
+
+func (s *sqliteStore) Clear() error {
+	_, err := s.db.Exec(`DELETE FROM entries`)
+	return err
+}
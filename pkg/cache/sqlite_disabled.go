@@ -0,0 +1,11 @@
+//go:build !sqlite
+
+package cache
+
+import "errors"
+
+// openSQLiteStore is unavailable without the sqlite build tag, which keeps
+// the default build free of the SQLite driver
+func openSQLiteStore(dir string) (Store, error) {
+	return nil, errors.New("img-cli was built without SQLite support (build with -tags sqlite)")
+}
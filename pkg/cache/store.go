@@ -0,0 +1,209 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/config"
+	"img-cli/pkg/logger"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Store persists the entries of one cache directory by key. The file store
+// (the default) keeps one JSON file per entry; the SQLite store keeps them in
+// one database per directory, which stays fast with thousands of entries and
+// can be queried with sqlite3. IMG_CLI_CACHE_BACKEND selects the store.
+type Store interface {
+	// Read returns the entry for key, or an error satisfying os.IsNotExist
+	Read(key string) (*CacheEntry, error)
+	// Write stores entry under entry.Key. Unless overwrite is set an
+	// existing entry wins, so manual edits and a concurrent writer's entry are kept.
+	Write(entry *CacheEntry, overwrite bool) error
+	// Delete removes the entry for key; a missing entry is not an error
+	Delete(key string) error
+	// Keys lists the keys of all entries
+	Keys() ([]string, error)
+	// Scan calls fn with every entry and the bytes it takes up
+	Scan(fn func(entry *CacheEntry, size int64)) error
+	// Clear removes every entry
+	Clear() error
+}
+
+// sqliteFile is the database the SQLite store keeps in each cache directory
+const sqliteFile = "analyses.db"
+
+var (
+	storesMu sync.Mutex
+	stores   = make(map[string]Store) // Open stores by backend and directory
+)
+
+// openStore returns the configured store for a cache directory. Caches of
+// different types that share a directory share the store. If the SQLite
+// store can't be opened the file store is used and a warning is logged.
+func openStore(dir string) Store {
+	backend := config.CacheBackend()
+	storesMu.Lock()
+	defer storesMu.Unlock()
+	if store, ok := stores[backend+":"+dir]; ok {
+		return store
+	}
+
+	var store Store = &fileStore{dir: dir}
+	switch backend {
+	case config.CacheBackendFile:
+	case config.CacheBackendSQLite:
+		sqlite, err := openSQLiteStore(dir)
+		if err != nil {
+			logger.Warn("Using the file cache instead of SQLite", "dir", dir, "error", err)
+			break
+		}
+		store = sqlite
+	default:
+		logger.Warn("Unknown IMG_CLI_CACHE_BACKEND, using the file cache", "backend", backend)
+	}
+	stores[backend+":"+dir] = store
+	return store
+}
+
+// fileStore keeps every entry in <dir>/<key>.json
+type fileStore struct {
+	dir string
+}
+
+func (s *fileStore) path(key string) string {
+	return filepath.Join(s.dir, key+".json")
+}
+
+func (s *fileStore) Read(key string) (*CacheEntry, error) {
+	return readEntry(s.path(key))
+}
+
+func (s *fileStore) Write(entry *CacheEntry, overwrite bool) error {
+	// A cheap check first; writeEntry makes the final decision atomically
+	if _, err := os.Stat(s.path(entry.Key)); err == nil && !overwrite {
+		return nil
+	}
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeEntry(s.path(entry.Key), data, overwrite)
+}
+
+func (s *fileStore) Delete(key string) error {
+	if err := os.Remove(s.path(key)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *fileStore) Keys() ([]string, error) {
+	files, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".json" || strings.HasPrefix(file.Name(), ".") {
+			continue
+		}
+		keys = append(keys, strings.TrimSuffix(file.Name(), ".json"))
+	}
+	return keys, nil
+}
+
+func (s *fileStore) Scan(fn func(entry *CacheEntry, size int64)) error {
+	files, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
+			continue
+		}
+		info, err := file.Info()
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.dir, file.Name()))
+		if err != nil {
+			continue
+		}
+		var entry CacheEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			continue
+		}
+		fn(&entry, info.Size())
+	}
+	return nil
+}
+
+// Clear deletes every entry. Subfolders, such as the library catalog of
+// pkg/library, are left alone.
+func (s *fileStore) Clear() error {
+	keys, err := s.Keys()
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := s.Delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Backend returns the kind of store the cache uses: "file" or "sqlite"
+func (c *Cache) Backend() string {
+	if _, ok := c.store.(*fileStore); ok {
+		return config.CacheBackendFile
+	}
+	return config.CacheBackendSQLite
+}
+
+// Location describes where the entry for key is stored, for display
+func (c *Cache) Location(key string) string {
+	if files, ok := c.store.(*fileStore); ok {
+		return files.path(key)
+	}
+	return fmt.Sprintf("%s (key %s)", filepath.Join(c.cacheDir, sqliteFile), key)
+}
+
+// ImportFiles moves the JSON file entries of the cache directory into the
+// configured store, keeping entries the store already has. It returns how
+// many entries it moved; with the file store there is nothing to move.
+func (c *Cache) ImportFiles() (int, error) {
+	files := &fileStore{dir: c.cacheDir}
+	if _, ok := c.store.(*fileStore); ok {
+		return 0, nil
+	}
+	keys, err := files.Keys()
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	moved := 0
+	for _, key := range keys {
+		entry, err := files.Read(key)
+		if err != nil {
+			continue
+		}
+		entry.Key = key // Lookups go by file name, whatever the entry says
+		if err := c.store.Write(entry, false); err != nil {
+			return moved, fmt.Errorf("failed to import %s: %w", key, err)
+		}
+		if err := files.Delete(key); err != nil {
+			return moved, err
+		}
+		moved++
+	}
+	return moved, nil
+}
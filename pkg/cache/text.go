@@ -0,0 +1,57 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Text entries live alongside file entries in the same per-type directory.
+// Their key is a hash of the component type, the normalized text and the prompt
+// version (see prompts.Version), so editing the text or the prompt template,
+// including an override in prompts/, produces a new entry.
+
+// textKey returns the cache key for a text description
+func (c *Cache) textKey(analysisType, text, promptVersion string) (string, string) {
+	normalized := strings.Join(strings.Fields(strings.ToLower(text)), " ")
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%s", analysisType, promptVersion, normalized)))
+	hash := hex.EncodeToString(sum[:])
+	return fmt.Sprintf("%s_text_%s", analysisType, hash[:16]), hash
+}
+
+// GetText returns the cached data for a text description. Unlike image
+// analyses, text expansions expire after the cache TTL; an expired entry is
+// removed so the next SetText stores a fresh expansion.
+func (c *Cache) GetText(analysisType, text, promptVersion string) (json.RawMessage, bool) {
+	key, _ := c.textKey(analysisType, text, promptVersion)
+	entry, err := c.store.Read(key)
+	found := err == nil && time.Since(entry.Timestamp) <= c.ttl
+	if err == nil && !found {
+		c.store.Delete(key)
+	}
+	countLookup(found)
+	if !found {
+		return nil, false
+	}
+	return entry.Data, true
+}
+
+// SetText caches data for a text description. Like Set, existing entries are
+// never overwritten so manual edits are preserved.
+func (c *Cache) SetText(analysisType, text, promptVersion string, data json.RawMessage) error {
+	key, hash := c.textKey(analysisType, text, promptVersion)
+
+	entry := CacheEntry{
+		Key:       key,
+		Type:      analysisType,
+		Timestamp: time.Now(),
+		FileHash:  hash,
+		Text:      text,
+		Data:      data,
+	}
+
+	return countWrite(c.store.Write(&entry, false))
+}
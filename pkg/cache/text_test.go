@@ -0,0 +1,43 @@
+package cache
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestGetTextExpires(t *testing.T) {
+	c := NewCache(t.TempDir(), time.Hour)
+	text := "a red wool coat"
+	if err := c.SetText("outfit", text, "1", json.RawMessage(`"old"`)); err != nil {
+		t.Fatal(err)
+	}
+	if data, found := c.GetText("outfit", text, "1"); !found || string(data) != `"old"` {
+		t.Fatalf("GetText = %s, %v for a fresh entry", data, found)
+	}
+
+	if _, found := c.GetText("outfit", text, "2"); found {
+		t.Fatal("GetText returned an entry cached with another prompt version")
+	}
+
+	key, _ := c.textKey("outfit", text, "1")
+	entry, err := c.store.Read(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry.Timestamp = time.Now().Add(-2 * time.Hour)
+	if err := c.store.Write(entry, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, found := c.GetText("outfit", text, "1"); found {
+		t.Fatal("GetText returned an entry older than the TTL")
+	}
+
+	// The expired entry no longer blocks a fresh expansion
+	if err := c.SetText("outfit", text, "1", json.RawMessage(`"new"`)); err != nil {
+		t.Fatal(err)
+	}
+	if data, found := c.GetText("outfit", text, "1"); !found || string(data) != `"new"` {
+		t.Errorf("GetText = %s, %v after storing a fresh expansion", data, found)
+	}
+}
@@ -32,6 +32,13 @@ func DefaultRetryConfig() *RetryConfig {
 	}
 }
 
+// Backoff returns the wait before retry number attempt (0 for the first
+// retry): InitialBackoff grown by BackoffFactor per attempt, up to MaxBackoff
+func (r *RetryConfig) Backoff(attempt int) time.Duration {
+	backoff := float64(r.InitialBackoff) * math.Pow(r.BackoffFactor, float64(attempt))
+	return time.Duration(math.Min(backoff, float64(r.MaxBackoff)))
+}
+
 // RateLimiter implements a token bucket rate limiter
 type RateLimiter struct {
 	tokens    int
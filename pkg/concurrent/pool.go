@@ -180,6 +180,22 @@ func (t *ImageProcessingTask) GetID() string {
 	return t.ID
 }
 
+// FuncTask implements Task for a plain function
+type FuncTask struct {
+	ID string
+	Fn func(ctx context.Context) error
+}
+
+// Process runs the function
+func (t *FuncTask) Process(ctx context.Context) error {
+	return t.Fn(ctx)
+}
+
+// GetID returns the task identifier
+func (t *FuncTask) GetID() string {
+	return t.ID
+}
+
 // ParallelMap applies a function to items in parallel
 func ParallelMap[T any, R any](ctx context.Context, items []T, workers int, fn func(context.Context, T) (R, error)) ([]R, error) {
 	if len(items) == 0 {
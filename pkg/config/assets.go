@@ -0,0 +1,29 @@
+package config
+
+import (
+	"os"
+	"strconv"
+)
+
+// AssetsConfig controls how the tool treats the reference directories
+// (subjects/, outfits/, styles/, ...)
+type AssetsConfig struct {
+	// Never write caches or copied references into the asset directories, for
+	// assets on a shared mount. Caches move to cache/<dir> instead.
+	ReadOnly bool
+}
+
+// DefaultAssetsConfig returns the default asset configuration
+// These values can be overridden via environment variables:
+// - IMG_CLI_READONLY_ASSETS (default: false)
+func DefaultAssetsConfig() *AssetsConfig {
+	config := &AssetsConfig{}
+
+	if val := os.Getenv("IMG_CLI_READONLY_ASSETS"); val != "" {
+		if b, err := strconv.ParseBool(val); err == nil {
+			config.ReadOnly = b
+		}
+	}
+
+	return config
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultCacheTTL is how long analyses stay cached without IMG_CLI_CACHE_TTL
+const DefaultCacheTTL = 7 * 24 * time.Hour
+
+// CacheTTL returns how long analyses of a type stay cached
+// This value can be overridden via environment variables:
+// - IMG_CLI_CACHE_TTL_<TYPE>, e.g. IMG_CLI_CACHE_TTL_OUTFIT=720h
+// - IMG_CLI_CACHE_TTL for every type (default: 168h)
+func CacheTTL(analysisType string) time.Duration {
+	keys := []string{"IMG_CLI_CACHE_TTL"}
+	if analysisType != "" {
+		keys = append([]string{"IMG_CLI_CACHE_TTL_" + strings.ToUpper(analysisType)}, keys...)
+	}
+	for _, key := range keys {
+		if ttl := getEnvDuration(key); ttl > 0 {
+			return ttl
+		}
+	}
+	return DefaultCacheTTL
+}
+
+// Cache backends
+const (
+	CacheBackendFile   = "file"   // One JSON file per entry in each cache directory
+	CacheBackendSQLite = "sqlite" // One SQLite database per cache directory
+)
+
+// CacheBackend returns where analyses are stored: IMG_CLI_CACHE_BACKEND,
+// "file" (the default) or "sqlite"
+func CacheBackend() string {
+	backend := strings.ToLower(strings.TrimSpace(os.Getenv("IMG_CLI_CACHE_BACKEND")))
+	if backend == "" {
+		return CacheBackendFile
+	}
+	return backend
+}
+
+// getEnvDuration reads a duration such as "72h" from an environment variable.
+// Plain numbers are days.
+func getEnvDuration(key string) time.Duration {
+	val := strings.TrimSpace(os.Getenv(key))
+	if val == "" {
+		return 0
+	}
+	if days, err := strconv.ParseFloat(val, 64); err == nil {
+		return time.Duration(days * float64(24*time.Hour))
+	}
+	if ttl, err := time.ParseDuration(val); err == nil {
+		return ttl
+	}
+	return 0
+}
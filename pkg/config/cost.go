@@ -16,6 +16,10 @@ type CostConfig struct {
 
 	// Maximum allowed cost without override in dollars
 	MaximumCost float64
+
+	// Price of a million input and output tokens of an analysis in dollars
+	CostPerMillionInputTokens  float64
+	CostPerMillionOutputTokens float64
 }
 
 // DefaultCostConfig returns the default cost configuration
@@ -23,11 +27,16 @@ type CostConfig struct {
 // - IMG_CLI_COST_PER_IMAGE (default: 0.04)
 // - IMG_CLI_CONFIRM_THRESHOLD (default: 5.00)
 // - IMG_CLI_MAX_COST (default: 50.00)
+// - IMG_CLI_COST_PER_MTOK_IN (default: 0.30)
+// - IMG_CLI_COST_PER_MTOK_OUT (default: 2.50)
 func DefaultCostConfig() *CostConfig {
 	config := &CostConfig{
 		CostPerImage:          0.04,  // $0.04 per image
 		ConfirmationThreshold: 5.00,  // Confirm if over $5
 		MaximumCost:           50.00, // Hard limit at $50
+
+		CostPerMillionInputTokens:  0.30, // Gemini Flash text and image input
+		CostPerMillionOutputTokens: 2.50, // Gemini Flash text output
 	}
 
 	// Allow environment variable overrides
@@ -40,6 +49,12 @@ func DefaultCostConfig() *CostConfig {
 	if envMax := getEnvFloat("IMG_CLI_MAX_COST", 0); envMax > 0 {
 		config.MaximumCost = envMax
 	}
+	if envIn := getEnvFloat("IMG_CLI_COST_PER_MTOK_IN", 0); envIn > 0 {
+		config.CostPerMillionInputTokens = envIn
+	}
+	if envOut := getEnvFloat("IMG_CLI_COST_PER_MTOK_OUT", 0); envOut > 0 {
+		config.CostPerMillionOutputTokens = envOut
+	}
 
 	return config
 }
@@ -49,6 +64,11 @@ func (c *CostConfig) CalculateTotalCost(imageCount int) float64 {
 	return float64(imageCount) * c.CostPerImage
 }
 
+// CalculateTokenCost calculates the cost of an analysis's tokens
+func (c *CostConfig) CalculateTokenCost(inputTokens, outputTokens int) float64 {
+	return (float64(inputTokens)*c.CostPerMillionInputTokens + float64(outputTokens)*c.CostPerMillionOutputTokens) / 1e6
+}
+
 // RequiresConfirmation checks if the cost requires user confirmation
 func (c *CostConfig) RequiresConfirmation(imageCount int) bool {
 	return c.CalculateTotalCost(imageCount) > c.ConfirmationThreshold
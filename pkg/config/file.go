@@ -0,0 +1,217 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileName is the name of the user config file in the home directory
+// and of the project config file at the project root
+const ConfigFileName = ".img-cli.yaml"
+
+// fileKeys maps config file keys to the environment variables they set.
+// Sections are flattened with dots ("limits.generate_rps"). Any variable can
+// also be set through the env section ("env.IMG_CLI_SD_URL"), cache TTLs per
+// analysis type with "cache.ttl_<type>" ("cache.ttl_outfit"), and analysis
+// sampling per type with "analysis.<type>.<param>" ("analysis.outfit.temperature").
+var fileKeys = map[string]string{
+	"api_key":           "GEMINI_API_KEY",
+	"openai_api_key":    "OPENAI_API_KEY",
+	"provider":          "IMG_CLI_PROVIDER",
+	"fallback_provider": "IMG_CLI_FALLBACK_PROVIDER",
+	"project":           "IMG_CLI_PROJECT",
+	"output_dir":        "IMG_CLI_OUTPUT_DIR",
+	"prompts_dir":       "IMG_CLI_PROMPTS_DIR",
+	"watermark":         "IMG_CLI_WATERMARK",
+
+	"defaults.outfit":   "IMG_CLI_DEFAULT_OUTFIT",
+	"defaults.style":    "IMG_CLI_DEFAULT_STYLE",
+	"defaults.subjects": "IMG_CLI_DEFAULT_SUBJECTS",
+
+	"cost.per_image":         "IMG_CLI_COST_PER_IMAGE",
+	"cost.confirm_threshold": "IMG_CLI_CONFIRM_THRESHOLD",
+	"cost.max":               "IMG_CLI_MAX_COST",
+
+	"limits.analyze_rps":          "IMG_CLI_ANALYZE_RPS",
+	"limits.analyze_concurrency":  "IMG_CLI_ANALYZE_CONCURRENCY",
+	"limits.generate_rps":         "IMG_CLI_GENERATE_RPS",
+	"limits.generate_concurrency": "IMG_CLI_GENERATE_CONCURRENCY",
+	"limits.adaptive":             "IMG_CLI_ADAPTIVE_LIMITS",
+	"limits.adaptive_ceiling":     "IMG_CLI_ADAPTIVE_CEILING",
+	"limits.max_retries":          "IMG_CLI_MAX_RETRIES",
+
+	"generation.temperature": "IMG_CLI_TEMPERATURE",
+	"generation.top_k":       "IMG_CLI_TOP_K",
+	"generation.top_p":       "IMG_CLI_TOP_P",
+
+	"analysis.temperature": "IMG_CLI_ANALYZE_TEMPERATURE",
+	"analysis.top_k":       "IMG_CLI_ANALYZE_TOP_K",
+	"analysis.top_p":       "IMG_CLI_ANALYZE_TOP_P",
+
+	"cache.ttl":     "IMG_CLI_CACHE_TTL",
+	"cache.backend": "IMG_CLI_CACHE_BACKEND",
+
+	"video.ffmpeg":     "IMG_CLI_FFMPEG",
+	"video.fps":        "IMG_CLI_VIDEO_FPS",
+	"video.max_frames": "IMG_CLI_VIDEO_MAX_FRAMES",
+
+	"notify.url":     "IMG_CLI_NOTIFY_URL",
+	"notify.timeout": "IMG_CLI_NOTIFY_TIMEOUT",
+}
+
+// DefaultConfigFiles lists the config files loaded when --config is not
+// given, most specific first: the project config at root, then the user's
+func DefaultConfigFiles(root string) []string {
+	files := []string{filepath.Join(root, ConfigFileName)}
+	if home, err := os.UserHomeDir(); err == nil {
+		files = append(files,
+			filepath.Join(home, ConfigFileName),
+			filepath.Join(home, strings.TrimSuffix(ConfigFileName, ".yaml")+".toml"))
+	}
+	return files
+}
+
+// IsConfigFile reports whether path is a YAML or TOML config file rather
+// than a .env file
+func IsConfigFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".toml":
+		return true
+	}
+	return false
+}
+
+// LoadFile applies a YAML or TOML config file to the environment, where the
+// Default*Config functions read it. Variables that are already set win, so
+// the environment and .env override config files, and a file loaded earlier
+// overrides one loaded later. It returns the keys it did not recognize.
+func LoadFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var values map[string]string
+	if strings.ToLower(filepath.Ext(path)) == ".toml" {
+		values, err = parseTOML(data)
+	} else {
+		values, err = parseYAML(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var unknown []string
+	for key, value := range values {
+		name := envName(key)
+		if name == "" {
+			unknown = append(unknown, key)
+			continue
+		}
+		if _, set := os.LookupEnv(name); !set {
+			os.Setenv(name, expandHome(value))
+		}
+	}
+	sort.Strings(unknown)
+	return unknown, nil
+}
+
+// envName returns the environment variable a config key sets, or "" for unknown keys
+func envName(key string) string {
+	if name, ok := fileKeys[key]; ok {
+		return name
+	}
+	if name, ok := strings.CutPrefix(key, "env."); ok && name != "" {
+		return name
+	}
+	if typ, ok := strings.CutPrefix(key, "cache.ttl_"); ok && typ != "" {
+		return "IMG_CLI_CACHE_TTL_" + strings.ToUpper(typ)
+	}
+	if rest, ok := strings.CutPrefix(key, "analysis."); ok {
+		typ, param, found := strings.Cut(rest, ".")
+		if name, known := fileKeys["analysis."+param]; found && known && typ != "" {
+			return name + "_" + strings.ToUpper(typ)
+		}
+	}
+	return ""
+}
+
+// expandHome replaces a leading ~/ with the home directory
+func expandHome(value string) string {
+	if rest, ok := strings.CutPrefix(value, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return value
+}
+
+// parseYAML reads a YAML config file into flattened keys (see flatten)
+func parseYAML(data []byte) (map[string]string, error) {
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	values := make(map[string]string)
+	flatten(values, "", doc)
+	return values, nil
+}
+
+// parseTOML reads a TOML config file into flattened keys (see flatten)
+func parseTOML(data []byte) (map[string]string, error) {
+	var doc map[string]interface{}
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	values := make(map[string]string)
+	flatten(values, "", doc)
+	return values, nil
+}
+
+// flatten adds the scalars of a decoded config file to values under dotted
+// keys ("limits.generate_rps"). Lists of scalars are joined with commas;
+// keys without a value are left out.
+func flatten(values map[string]string, prefix string, doc map[string]interface{}) {
+	for key, value := range doc {
+		key = joinKey(prefix, key)
+		switch v := value.(type) {
+		case nil:
+		case map[string]interface{}:
+			flatten(values, key, v)
+		case []interface{}:
+			items := make([]string, 0, len(v))
+			for _, item := range v {
+				if item != nil {
+					items = append(items, scalar(item))
+				}
+			}
+			values[key] = strings.Join(items, ",")
+		default:
+			values[key] = scalar(v)
+		}
+	}
+}
+
+// scalar formats a decoded scalar the way it would be written in the
+// environment, floats without exponents
+func scalar(value interface{}) string {
+	if f, ok := value.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+// joinKey adds a key to a dotted section prefix
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
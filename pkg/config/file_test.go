@@ -0,0 +1,123 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// unsetEnv clears variables for the test and restores them afterwards
+func unsetEnv(t *testing.T, names ...string) {
+	for _, name := range names {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+}
+
+func loadConfig(t *testing.T, name, source string) []string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+	unknown, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return unknown
+}
+
+var configVars = []string{
+	"IMG_CLI_GENERATE_RPS", "IMG_CLI_ADAPTIVE_LIMITS", "IMG_CLI_DEFAULT_SUBJECTS", "IMG_CLI_DEFAULT_OUTFIT",
+	"IMG_CLI_CACHE_TTL_OUTFIT", "IMG_CLI_ANALYZE_TEMPERATURE_OUTFIT", "IMG_CLI_SD_URL", "IMG_CLI_WATERMARK",
+}
+
+func checkConfigVars(t *testing.T, unknown []string) {
+	t.Helper()
+	for name, want := range map[string]string{
+		"IMG_CLI_GENERATE_RPS":               "0.25",
+		"IMG_CLI_ADAPTIVE_LIMITS":            "false",
+		"IMG_CLI_DEFAULT_SUBJECTS":           "kat,jaimee",
+		"IMG_CLI_DEFAULT_OUTFIT":             "red # wool coat",
+		"IMG_CLI_CACHE_TTL_OUTFIT":           "72h",
+		"IMG_CLI_ANALYZE_TEMPERATURE_OUTFIT": "0.1",
+		"IMG_CLI_SD_URL":                     "http://localhost:7860",
+		"IMG_CLI_WATERMARK":                  "set in the environment",
+	} {
+		if got := os.Getenv(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if !reflect.DeepEqual(unknown, []string{"limits.no_such_limit"}) {
+		t.Errorf("unknown keys %v", unknown)
+	}
+}
+
+func TestLoadFileYAML(t *testing.T) {
+	unsetEnv(t, configVars...)
+	t.Setenv("IMG_CLI_WATERMARK", "set in the environment")
+
+	unknown := loadConfig(t, ConfigFileName, `
+watermark: from the file
+defaults:
+  outfit: "red # wool coat" # The comment is not part of the value
+  subjects:
+    - kat
+    - jaimee
+limits:
+  generate_rps: 0.25
+  adaptive: false
+  no_such_limit: 1
+cache:
+  ttl_outfit: 72h
+analysis:
+  outfit:
+    temperature: 0.1
+env:
+  IMG_CLI_SD_URL: http://localhost:7860
+`)
+	checkConfigVars(t, unknown)
+}
+
+func TestLoadFileTOML(t *testing.T) {
+	unsetEnv(t, configVars...)
+	t.Setenv("IMG_CLI_WATERMARK", "set in the environment")
+
+	unknown := loadConfig(t, ".img-cli.toml", `
+watermark = "from the file"
+
+[defaults]
+outfit = "red # wool coat" # The comment is not part of the value
+subjects = ["kat", "jaimee"]
+
+[limits]
+generate_rps = 0.25
+adaptive = false
+no_such_limit = 1
+
+[cache]
+ttl_outfit = "72h"
+
+[analysis.outfit]
+temperature = 0.1
+
+[env]
+IMG_CLI_SD_URL = "http://localhost:7860"
+`)
+	checkConfigVars(t, unknown)
+}
+
+func TestLoadFileRejectsInvalidSyntax(t *testing.T) {
+	for name, source := range map[string]string{
+		ConfigFileName:  "limits:\n  generate_rps: [0.5\n",
+		".img-cli.toml": "[limits\ngenerate_rps = 0.5\n",
+	} {
+		path := filepath.Join(t.TempDir(), name)
+		if err := os.WriteFile(path, []byte(source), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadFile(path); err == nil {
+			t.Errorf("%s: invalid file loaded without an error", name)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// InputsConfig holds the references outfit-swap uses when none are given
+type InputsConfig struct {
+	// Outfit used when no outfit argument is given
+	Outfit string
+
+	// Style reference used without --style
+	Style string
+
+	// Subjects used without -t (and for -t with no value). Empty means all
+	// of subjects/ without -t, and the single default subject with -t.
+	Subjects []string
+}
+
+// DefaultInputsConfig returns the default input configuration
+// These values can be overridden via environment variables:
+// - IMG_CLI_DEFAULT_OUTFIT (default: ./outfits/shearling-black.png)
+// - IMG_CLI_DEFAULT_STYLE (default: ./styles/plain-white.png)
+// - IMG_CLI_DEFAULT_SUBJECTS (comma or space separated, default: none)
+func DefaultInputsConfig() *InputsConfig {
+	config := &InputsConfig{
+		Outfit: "./outfits/shearling-black.png",
+		Style:  "./styles/plain-white.png",
+	}
+
+	if val := os.Getenv("IMG_CLI_DEFAULT_OUTFIT"); val != "" {
+		config.Outfit = val
+	}
+	if val := os.Getenv("IMG_CLI_DEFAULT_STYLE"); val != "" {
+		config.Style = val
+	}
+	if val := os.Getenv("IMG_CLI_DEFAULT_SUBJECTS"); val != "" {
+		config.Subjects = strings.FieldsFunc(val, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		})
+	}
+
+	return config
+}
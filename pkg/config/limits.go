@@ -0,0 +1,93 @@
+package config
+
+import (
+	"os"
+	"strconv"
+)
+
+// LimitsConfig holds per-operation rate limits for API calls.
+// Analyses are cheap and fast; generations are slow and quota-heavy,
+// so each gets its own request rate and parallelism cap.
+type LimitsConfig struct {
+	// Maximum analysis requests started per second
+	AnalyzeRPS float64
+
+	// Maximum analysis requests in flight at once
+	AnalyzeConcurrency int
+
+	// Maximum generation requests started per second
+	GenerateRPS float64
+
+	// Maximum generation requests in flight at once
+	GenerateConcurrency int
+
+	// Adapt request rates to the API: halve them on 429/5xx responses and
+	// ramp them up again while responses stay healthy (AIMD)
+	Adaptive bool
+
+	// Highest adaptive rate as a multiple of the configured RPS. The default
+	// of 1 only recovers from backoff; above 1 the rate may exceed the
+	// configured RPS, which is opt-in because quotas are per account.
+	AdaptiveCeiling float64
+
+	// Times a request is sent again after a 429, 5xx or network failure,
+	// with exponential backoff in between
+	MaxRetries int
+}
+
+// DefaultLimitsConfig returns the default rate limit configuration
+// These values can be overridden via environment variables:
+// - IMG_CLI_ANALYZE_RPS (default: 2)
+// - IMG_CLI_ANALYZE_CONCURRENCY (default: 4)
+// - IMG_CLI_GENERATE_RPS (default: 0.5)
+// - IMG_CLI_GENERATE_CONCURRENCY (default: 2)
+// - IMG_CLI_ADAPTIVE_LIMITS (default: true)
+// - IMG_CLI_ADAPTIVE_CEILING (default: 1)
+// - IMG_CLI_MAX_RETRIES (default: 3, 0 disables retries)
+func DefaultLimitsConfig() *LimitsConfig {
+	config := &LimitsConfig{
+		AnalyzeRPS:          2,
+		AnalyzeConcurrency:  4,
+		GenerateRPS:         0.5,
+		GenerateConcurrency: 2,
+		Adaptive:            true,
+		AdaptiveCeiling:     1,
+		MaxRetries:          3,
+	}
+
+	if rps := getEnvFloat("IMG_CLI_ANALYZE_RPS", 0); rps > 0 {
+		config.AnalyzeRPS = rps
+	}
+	if n := getEnvInt("IMG_CLI_ANALYZE_CONCURRENCY", 0); n > 0 {
+		config.AnalyzeConcurrency = n
+	}
+	if rps := getEnvFloat("IMG_CLI_GENERATE_RPS", 0); rps > 0 {
+		config.GenerateRPS = rps
+	}
+	if n := getEnvInt("IMG_CLI_GENERATE_CONCURRENCY", 0); n > 0 {
+		config.GenerateConcurrency = n
+	}
+	if val := os.Getenv("IMG_CLI_ADAPTIVE_LIMITS"); val != "" {
+		if b, err := strconv.ParseBool(val); err == nil {
+			config.Adaptive = b
+		}
+	}
+	if ceiling := getEnvFloat("IMG_CLI_ADAPTIVE_CEILING", 0); ceiling >= 1 {
+		config.AdaptiveCeiling = ceiling
+	}
+	if n := getEnvInt("IMG_CLI_MAX_RETRIES", -1); n >= 0 {
+		config.MaxRetries = n
+	}
+
+	return config
+}
+
+// getEnvInt reads an integer value from environment variable
+func getEnvInt(key string, defaultValue int) int {
+	if val := os.Getenv(key); val != "" {
+		if i, err := strconv.Atoi(val); err == nil {
+			return i
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,44 @@
+package config
+
+import "os"
+
+// LocalVisionConfig points analysis requests at a local vision model server.
+// Any server exposing the OpenAI-compatible /v1/chat/completions endpoint with
+// image input works (Ollama, llama.cpp server, LM Studio, vLLM).
+type LocalVisionConfig struct {
+	// Base URL of the server, e.g. http://localhost:11434/v1 (empty disables local analysis)
+	URL string
+
+	// Model name to request, e.g. llava
+	Model string
+
+	// Maximum analysis requests in flight on the local server
+	Concurrency int
+}
+
+// DefaultLocalVisionConfig returns the local vision configuration
+// These values can be set via environment variables:
+// - IMG_CLI_LOCAL_VISION_URL (default: unset, local analysis disabled)
+// - IMG_CLI_LOCAL_VISION_MODEL (default: llava)
+// - IMG_CLI_LOCAL_VISION_CONCURRENCY (default: 1)
+func DefaultLocalVisionConfig() *LocalVisionConfig {
+	config := &LocalVisionConfig{
+		URL:         os.Getenv("IMG_CLI_LOCAL_VISION_URL"),
+		Model:       "llava",
+		Concurrency: 1,
+	}
+
+	if model := os.Getenv("IMG_CLI_LOCAL_VISION_MODEL"); model != "" {
+		config.Model = model
+	}
+	if n := getEnvInt("IMG_CLI_LOCAL_VISION_CONCURRENCY", 0); n > 0 {
+		config.Concurrency = n
+	}
+
+	return config
+}
+
+// Enabled reports whether a local vision server is configured
+func (c *LocalVisionConfig) Enabled() bool {
+	return c.URL != ""
+}
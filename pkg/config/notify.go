@@ -0,0 +1,32 @@
+package config
+
+import (
+	"os"
+	"time"
+)
+
+// NotifyConfig controls the webhook called when a run finishes
+type NotifyConfig struct {
+	// Webhook that receives the run summary (empty = no notification)
+	URL string
+
+	// How long to wait for the webhook before giving up
+	Timeout time.Duration
+}
+
+// DefaultNotifyConfig returns the notification configuration
+// These values can be set via environment variables:
+// - IMG_CLI_NOTIFY_URL (default: unset)
+// - IMG_CLI_NOTIFY_TIMEOUT (default: 10s)
+func DefaultNotifyConfig() *NotifyConfig {
+	config := &NotifyConfig{
+		URL:     os.Getenv("IMG_CLI_NOTIFY_URL"),
+		Timeout: 10 * time.Second,
+	}
+
+	if timeout, err := time.ParseDuration(os.Getenv("IMG_CLI_NOTIFY_TIMEOUT")); err == nil && timeout > 0 {
+		config.Timeout = timeout
+	}
+
+	return config
+}
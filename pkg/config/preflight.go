@@ -0,0 +1,22 @@
+package config
+
+// PreflightConfig holds the thresholds for validating subject photos before a run
+type PreflightConfig struct {
+	// Minimum length in pixels of the shorter side of a subject photo
+	MinSubjectSize int
+}
+
+// DefaultPreflightConfig returns the default pre-flight configuration
+// These values can be overridden via environment variables:
+// - IMG_CLI_MIN_SUBJECT_SIZE (default: 512)
+func DefaultPreflightConfig() *PreflightConfig {
+	config := &PreflightConfig{
+		MinSubjectSize: 512,
+	}
+
+	if envSize := getEnvInt("IMG_CLI_MIN_SUBJECT_SIZE", 0); envSize > 0 {
+		config.MinSubjectSize = envSize
+	}
+
+	return config
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"fmt"
+	"os"
+)
+
+// Providers that can serve analysis and generation requests
+const (
+	ProviderGemini = "gemini"
+	ProviderOpenAI = "openai"
+	ProviderSD     = "sd" // Local Stable Diffusion server (A1111 WebUI or ComfyUI)
+)
+
+// Stable Diffusion server APIs
+const (
+	SDBackendA1111   = "a1111"
+	SDBackendComfyUI = "comfyui"
+)
+
+// ProviderConfig selects the image model API and holds the settings of the
+// non-Gemini providers (the Gemini key comes from GEMINI_API_KEY or --api-key)
+type ProviderConfig struct {
+	// Provider for all requests: gemini, openai or sd
+	Name string
+
+	// Provider retried when the main one is down (5xx, 429 or unreachable); empty disables fallback
+	Fallback string
+
+	// OpenAI API key, base URL and models for analysis and image generation
+	OpenAIKey        string
+	OpenAIBaseURL    string
+	OpenAIModel      string
+	OpenAIImageModel string
+
+	// Stable Diffusion server URL and API (a1111 or comfyui)
+	SDURL     string
+	SDBackend string
+
+	// ComfyUI workflow in API format with "{{prompt}}", "{{negative_prompt}}",
+	// "{{image}}" (the uploaded subject photo), "{{seed}}", "{{denoise}}" and
+	// "{{mask}}" (the uploaded inpainting mask) placeholders
+	SDWorkflow string
+
+	// A1111 sampling settings; Denoise is how far img2img may move from the
+	// subject photo (also ComfyUI's "{{denoise}}"), unless --strength sets it
+	SDSteps    int
+	SDCFGScale float64
+	SDDenoise  float64
+	SDWidth    int
+	SDHeight   int
+
+	// Negative prompt sent with every Stable Diffusion generation
+	SDNegativePrompt string
+}
+
+// DefaultProviderConfig returns the provider configuration
+// These values can be set via environment variables:
+// - IMG_CLI_PROVIDER (default: gemini)
+// - IMG_CLI_FALLBACK_PROVIDER (default: unset, no fallback)
+// - OPENAI_API_KEY
+// - IMG_CLI_OPENAI_BASE_URL (default: https://api.openai.com/v1)
+// - IMG_CLI_OPENAI_MODEL (default: gpt-4o)
+// - IMG_CLI_OPENAI_IMAGE_MODEL (default: gpt-image-1)
+// - IMG_CLI_SD_URL (default: http://127.0.0.1:7860)
+// - IMG_CLI_SD_BACKEND (default: a1111)
+// - IMG_CLI_SD_WORKFLOW (required for comfyui)
+// - IMG_CLI_SD_STEPS (default: 30)
+// - IMG_CLI_SD_CFG_SCALE (default: 7)
+// - IMG_CLI_SD_DENOISE (default: 0.55)
+// - IMG_CLI_SD_SIZE (default: 832x1216)
+// - IMG_CLI_SD_NEGATIVE_PROMPT
+func DefaultProviderConfig() *ProviderConfig {
+	config := &ProviderConfig{
+		Name:             ProviderGemini,
+		Fallback:         os.Getenv("IMG_CLI_FALLBACK_PROVIDER"),
+		OpenAIKey:        os.Getenv("OPENAI_API_KEY"),
+		OpenAIBaseURL:    "https://api.openai.com/v1",
+		OpenAIModel:      "gpt-4o",
+		OpenAIImageModel: "gpt-image-1",
+		SDURL:            "http://127.0.0.1:7860",
+		SDBackend:        SDBackendA1111,
+		SDWorkflow:       os.Getenv("IMG_CLI_SD_WORKFLOW"),
+		SDSteps:          getEnvInt("IMG_CLI_SD_STEPS", 30),
+		SDCFGScale:       getEnvFloat("IMG_CLI_SD_CFG_SCALE", 7),
+		SDDenoise:        getEnvFloat("IMG_CLI_SD_DENOISE", 0.55),
+		SDWidth:          832,
+		SDHeight:         1216,
+		SDNegativePrompt: "deformed, disfigured, bad anatomy, extra limbs, extra fingers, blurry, low quality, watermark, text",
+	}
+
+	if name := os.Getenv("IMG_CLI_PROVIDER"); name != "" {
+		config.Name = name
+	}
+	if url := os.Getenv("IMG_CLI_OPENAI_BASE_URL"); url != "" {
+		config.OpenAIBaseURL = url
+	}
+	if model := os.Getenv("IMG_CLI_OPENAI_MODEL"); model != "" {
+		config.OpenAIModel = model
+	}
+	if model := os.Getenv("IMG_CLI_OPENAI_IMAGE_MODEL"); model != "" {
+		config.OpenAIImageModel = model
+	}
+	if url := os.Getenv("IMG_CLI_SD_URL"); url != "" {
+		config.SDURL = url
+	}
+	if backend := os.Getenv("IMG_CLI_SD_BACKEND"); backend != "" {
+		config.SDBackend = backend
+	}
+	if size := os.Getenv("IMG_CLI_SD_SIZE"); size != "" {
+		var width, height int
+		if _, err := fmt.Sscanf(size, "%dx%d", &width, &height); err == nil && width > 0 && height > 0 {
+			config.SDWidth, config.SDHeight = width, height
+		}
+	}
+	if negative := os.Getenv("IMG_CLI_SD_NEGATIVE_PROMPT"); negative != "" {
+		config.SDNegativePrompt = negative
+	}
+
+	return config
+}
+
+// Uses reports whether requests may be sent to the named provider
+func (c *ProviderConfig) Uses(name string) bool {
+	return c.Name == name || c.Fallback == name
+}
@@ -0,0 +1,40 @@
+package config
+
+import "os"
+
+// RemoteConfig controls how URL and S3 inputs are downloaded and outputs uploaded
+type RemoteConfig struct {
+	// Blob store directory shared by all runs (empty = .img-cli/blobs under the project root)
+	BlobDir string
+
+	// Bytes requested per range request, and bytes per part of S3 uploads (at
+	// least 5 MB); a failed chunk is retried on its own
+	ChunkSize int64
+
+	// Attempts per chunk before the transfer is given up (the partial file or
+	// finished parts are kept)
+	Retries int
+}
+
+// DefaultRemoteConfig returns the default download configuration
+// These values can be overridden via environment variables:
+// - IMG_CLI_BLOB_DIR (default: .img-cli/blobs)
+// - IMG_CLI_DOWNLOAD_CHUNK_MB (default: 8)
+// - IMG_CLI_DOWNLOAD_RETRIES (default: 3)
+func DefaultRemoteConfig() *RemoteConfig {
+	config := &RemoteConfig{
+		BlobDir:   os.Getenv("IMG_CLI_BLOB_DIR"),
+		ChunkSize: 8 << 20,
+		Retries:   3,
+	}
+
+	if chunkMB := getEnvInt("IMG_CLI_DOWNLOAD_CHUNK_MB", 0); chunkMB > 0 {
+		config.ChunkSize = int64(chunkMB) << 20
+	}
+
+	if retries := getEnvInt("IMG_CLI_DOWNLOAD_RETRIES", 0); retries > 0 {
+		config.Retries = retries
+	}
+
+	return config
+}
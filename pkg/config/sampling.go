@@ -0,0 +1,44 @@
+package config
+
+import "strings"
+
+// SamplingConfig overrides the sampling parameters of model requests. Zero
+// fields keep the built-in values of each request.
+type SamplingConfig struct {
+	Temperature float64
+	TopK        int
+	TopP        float64
+}
+
+// DefaultGenerationSampling returns the sampling defaults of image
+// generations, which --temperature, --top-k and --top-p override.
+// These values can be set via environment variables:
+// - IMG_CLI_TEMPERATURE (built-in: 0.8)
+// - IMG_CLI_TOP_K (built-in: 40)
+// - IMG_CLI_TOP_P (built-in: 0.95)
+func DefaultGenerationSampling() SamplingConfig {
+	return SamplingConfig{
+		Temperature: getEnvFloat("IMG_CLI_TEMPERATURE", 0),
+		TopK:        getEnvInt("IMG_CLI_TOP_K", 0),
+		TopP:        getEnvFloat("IMG_CLI_TOP_P", 0),
+	}
+}
+
+// AnalysisSampling returns the sampling overrides of one analyzer type, e.g.
+// "outfit". Lower values make repeated analyses of an image agree; higher ones
+// give richer descriptions. These values can be set via environment variables:
+// - IMG_CLI_ANALYZE_TEMPERATURE, IMG_CLI_ANALYZE_TOP_K, IMG_CLI_ANALYZE_TOP_P for every analyzer
+// - IMG_CLI_ANALYZE_TEMPERATURE_<TYPE> and so on for one, e.g. IMG_CLI_ANALYZE_TEMPERATURE_OUTFIT
+func AnalysisSampling(analysisType string) SamplingConfig {
+	suffix := "_" + strings.ToUpper(analysisType)
+	all := SamplingConfig{
+		Temperature: getEnvFloat("IMG_CLI_ANALYZE_TEMPERATURE", 0),
+		TopK:        getEnvInt("IMG_CLI_ANALYZE_TOP_K", 0),
+		TopP:        getEnvFloat("IMG_CLI_ANALYZE_TOP_P", 0),
+	}
+	return SamplingConfig{
+		Temperature: getEnvFloat("IMG_CLI_ANALYZE_TEMPERATURE"+suffix, all.Temperature),
+		TopK:        getEnvInt("IMG_CLI_ANALYZE_TOP_K"+suffix, all.TopK),
+		TopP:        getEnvFloat("IMG_CLI_ANALYZE_TOP_P"+suffix, all.TopP),
+	}
+}
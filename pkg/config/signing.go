@@ -0,0 +1,40 @@
+package config
+
+import "os"
+
+// SigningConfig locates the certificate used to sign outputs with C2PA
+// content credentials
+type SigningConfig struct {
+	// PEM certificate chain, signing certificate first
+	CertPath string
+
+	// PEM private key of the signing certificate (EC P-256/P-384, RSA or Ed25519)
+	KeyPath string
+
+	// Name recorded as the claim generator
+	Generator string
+}
+
+// DefaultSigningConfig returns the signing configuration
+// These values can be set via environment variables:
+// - IMG_CLI_C2PA_CERT (default: unset)
+// - IMG_CLI_C2PA_KEY (default: unset)
+// - IMG_CLI_C2PA_GENERATOR (default: img-cli)
+func DefaultSigningConfig() *SigningConfig {
+	config := &SigningConfig{
+		CertPath:  os.Getenv("IMG_CLI_C2PA_CERT"),
+		KeyPath:   os.Getenv("IMG_CLI_C2PA_KEY"),
+		Generator: "img-cli",
+	}
+
+	if generator := os.Getenv("IMG_CLI_C2PA_GENERATOR"); generator != "" {
+		config.Generator = generator
+	}
+
+	return config
+}
+
+// Configured reports whether a certificate and key are set
+func (c *SigningConfig) Configured() bool {
+	return c.CertPath != "" && c.KeyPath != ""
+}
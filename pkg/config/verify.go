@@ -0,0 +1,37 @@
+package config
+
+// VerifyConfig holds the thresholds for automated checks on generated images
+type VerifyConfig struct {
+	// Maximum color distance (0-1) between an output and its style reference
+	ColorTolerance float64
+	// Minimum consistency scores (0-1) before a variation set is reported as unstable
+	MinIdentityConsistency float64
+	MinColorConsistency    float64
+}
+
+// DefaultVerifyConfig returns the default verification configuration
+// These values can be overridden via environment variables:
+// - IMG_CLI_COLOR_TOLERANCE (default: 0.15)
+// - IMG_CLI_MIN_IDENTITY_CONSISTENCY (default: 0.75)
+// - IMG_CLI_MIN_COLOR_CONSISTENCY (default: 0.6)
+func DefaultVerifyConfig() *VerifyConfig {
+	config := &VerifyConfig{
+		ColorTolerance:         0.15,
+		MinIdentityConsistency: 0.75,
+		MinColorConsistency:    0.6,
+	}
+
+	if envTolerance := getEnvFloat("IMG_CLI_COLOR_TOLERANCE", 0); envTolerance > 0 {
+		config.ColorTolerance = envTolerance
+	}
+
+	if envIdentity := getEnvFloat("IMG_CLI_MIN_IDENTITY_CONSISTENCY", 0); envIdentity > 0 {
+		config.MinIdentityConsistency = envIdentity
+	}
+
+	if envColor := getEnvFloat("IMG_CLI_MIN_COLOR_CONSISTENCY", 0); envColor > 0 {
+		config.MinColorConsistency = envColor
+	}
+
+	return config
+}
@@ -0,0 +1,40 @@
+package config
+
+import "os"
+
+// VideoConfig controls the video command
+type VideoConfig struct {
+	// ffmpeg binary used to extract and assemble frames
+	FFmpeg string
+
+	// Frames extracted per second of video, and the most extracted per run
+	FPS       float64
+	MaxFrames int
+}
+
+// DefaultVideoConfig returns the default video configuration
+// These values can be overridden via environment variables:
+// - IMG_CLI_FFMPEG (default: ffmpeg on the PATH)
+// - IMG_CLI_VIDEO_FPS (default: 8)
+// - IMG_CLI_VIDEO_MAX_FRAMES (default: 120)
+func DefaultVideoConfig() *VideoConfig {
+	config := &VideoConfig{
+		FFmpeg:    "ffmpeg",
+		FPS:       8,
+		MaxFrames: 120,
+	}
+
+	if ffmpeg := os.Getenv("IMG_CLI_FFMPEG"); ffmpeg != "" {
+		config.FFmpeg = ffmpeg
+	}
+
+	if fps := getEnvFloat("IMG_CLI_VIDEO_FPS", 0); fps > 0 {
+		config.FPS = fps
+	}
+
+	if maxFrames := getEnvInt("IMG_CLI_VIDEO_MAX_FRAMES", 0); maxFrames > 0 {
+		config.MaxFrames = maxFrames
+	}
+
+	return config
+}
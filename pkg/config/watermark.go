@@ -0,0 +1,28 @@
+package config
+
+import (
+	"os"
+	"strconv"
+)
+
+// WatermarkConfig controls the invisible AI-generated marker in outputs
+type WatermarkConfig struct {
+	// Mark every generated PNG as AI-generated, for clients with disclosure
+	// requirements; usually set in the project config
+	Enabled bool
+}
+
+// DefaultWatermarkConfig returns the default watermark configuration
+// These values can be overridden via environment variables:
+// - IMG_CLI_WATERMARK (default: false)
+func DefaultWatermarkConfig() *WatermarkConfig {
+	config := &WatermarkConfig{}
+
+	if val := os.Getenv("IMG_CLI_WATERMARK"); val != "" {
+		if b, err := strconv.ParseBool(val); err == nil {
+			config.Enabled = b
+		}
+	}
+
+	return config
+}
@@ -0,0 +1,138 @@
+// Package cost prices image generations and guards what a run may spend.
+// Every command estimates, caps and confirms its runs through this package
+// so the per-image price, the confirmation threshold and the budget cap are
+// applied the same way everywhere.
+package cost
+
+import (
+	stderrors "errors"
+	"fmt"
+	"img-cli/pkg/config"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/output"
+	"img-cli/pkg/prompt"
+	"img-cli/pkg/usage"
+	"io"
+	"sync"
+)
+
+// ErrCancelled is returned by Check when the user declines the cost
+var ErrCancelled = stderrors.New("cancelled by user")
+
+var (
+	maxBudgetMu sync.RWMutex
+	maxBudget   float64 // --max-budget, ahead of IMG_CLI_MAX_COST
+)
+
+// SetMaxBudget caps what a run may spend, in dollars, overriding
+// IMG_CLI_MAX_COST. Zero or less restores the configured cap.
+func SetMaxBudget(dollars float64) {
+	maxBudgetMu.Lock()
+	defer maxBudgetMu.Unlock()
+	maxBudget = dollars
+}
+
+// Limit returns the most a run may spend: --max-budget, else IMG_CLI_MAX_COST
+func Limit() float64 {
+	maxBudgetMu.RLock()
+	defer maxBudgetMu.RUnlock()
+	if maxBudget > 0 {
+		return maxBudget
+	}
+	return config.DefaultCostConfig().MaximumCost
+}
+
+// PerImage returns the configured price of one generated image
+func PerImage() float64 {
+	return config.DefaultCostConfig().CostPerImage
+}
+
+// Of returns the price of generating images
+func Of(images int) float64 {
+	return config.DefaultCostConfig().CalculateTotalCost(images)
+}
+
+// Tokens of a typical cheap text or vision call, such as scoring an image or
+// polishing its alt text, used to estimate what such calls will cost
+const (
+	callInputTokens  = 1500 // An image and a short prompt
+	callOutputTokens = 300
+)
+
+// OfCalls returns the estimated price of cheap text and vision calls
+func OfCalls(calls int) float64 {
+	return config.DefaultCostConfig().CalculateTokenCost(calls*callInputTokens, calls*callOutputTokens)
+}
+
+// Format formats a dollar amount: $1.20
+func Format(dollars float64) string {
+	return fmt.Sprintf("$%.2f", dollars)
+}
+
+// Breakdown explains the price of images: "12 images × $0.04 = $0.48"
+func Breakdown(images int) string {
+	return config.DefaultCostConfig().GetCostBreakdown(images)
+}
+
+// PrintEstimate shows what a run of images will cost under title
+func PrintEstimate(title string, images int) {
+	output.Progress.Printf("\n📊 %s:\n", title)
+	output.Progress.Printf("   Images to generate: %d\n", images)
+	output.Progress.Printf("   Cost breakdown: %s\n", Breakdown(images))
+}
+
+// PrintCalls adds the cheap text and vision calls of a run to the estimate
+// PrintEstimate showed
+func PrintCalls(calls int) {
+	if calls > 0 {
+		output.Progress.Printf("   Extra API calls: %d (~%s)\n", calls, Format(OfCalls(calls)))
+	}
+}
+
+// CheckOptions tunes Check for a command
+type CheckOptions struct {
+	Calls       int  // Cheap text and vision calls made on top of the images (see OfCalls)
+	SkipConfirm bool // --no-confirm: never ask
+	Confirmed   bool // The user already accepted the cost (e.g. in the --pick table)
+	DryRun      bool // Nothing is spent, so nothing is capped or asked
+}
+
+// Check guards a run of images before it starts. It fails when the run would
+// exceed the budget cap (--max-budget or IMG_CLI_MAX_COST) or the active
+// project's budget, and asks for confirmation when the cost is above
+// IMG_CLI_CONFIRM_THRESHOLD. It returns ErrCancelled if the user declines.
+// The estimate is added to the Session.
+func Check(images int, opts CheckOptions) error {
+	noteEstimate(images)
+	if opts.DryRun {
+		output.Progress.Println("   Dry run: prompts are built and written to files, no images are generated")
+		return nil
+	}
+
+	total := Of(images) + OfCalls(opts.Calls)
+	if limit := Limit(); total > limit {
+		return errors.Newf(errors.ValidationError,
+			"estimated cost %s for %d images exceeds the budget cap of %s (--max-budget or IMG_CLI_MAX_COST)",
+			Format(total), images, Format(limit)).
+			WithContext("estimated_cost", total).
+			WithContext("max_budget", limit)
+	}
+	if err := usage.CheckBudget(total); err != nil {
+		return err
+	}
+
+	if opts.SkipConfirm || opts.Confirmed || total <= config.DefaultCostConfig().ConfirmationThreshold {
+		return nil
+	}
+	confirmed, err := prompt.ConfirmExpensiveOperation(fmt.Sprintf("This run will generate %d images", images), Format(total))
+	if err != nil && err != io.EOF {
+		return errors.Wrap(err, errors.ValidationError, "failed to get cost confirmation")
+	}
+	if !confirmed {
+		output.Progress.Println("❌ Cancelled by user")
+		noteCancelled()
+		return ErrCancelled
+	}
+	output.Progress.Println("✅ Proceeding...")
+	return nil
+}
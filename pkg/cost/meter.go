@@ -0,0 +1,49 @@
+package cost
+
+import (
+	"img-cli/pkg/errors"
+	"sync"
+)
+
+// Meter keeps a run under its budget cap while it runs, for what the
+// up-front estimate can't see: chained outputs, resumed or regenerated
+// images. Each generation reserves its price first and releases it again if
+// no image was produced. Parallel generations may share one.
+type Meter struct {
+	mu       sync.Mutex
+	limit    float64
+	reserved float64
+}
+
+// NewMeter creates a meter that allows limit dollars; zero or less allows anything
+func NewMeter(limit float64) *Meter {
+	return &Meter{limit: limit}
+}
+
+// Reserve claims the price of one image, failing once the cap would be exceeded
+func (m *Meter) Reserve() error {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	price := PerImage()
+	if m.limit > 0 && m.reserved+price > m.limit+1e-9 {
+		return errors.Newf(errors.ValidationError,
+			"budget cap of %s reached (%s spent); not generating more images",
+			Format(m.limit), Format(m.reserved)).
+			WithContext("max_budget", m.limit)
+	}
+	m.reserved += price
+	return nil
+}
+
+// Release returns the reservation of an image that was not generated
+func (m *Meter) Release() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reserved = max(0, m.reserved-PerImage())
+}
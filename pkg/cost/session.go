@@ -0,0 +1,48 @@
+package cost
+
+import "sync"
+
+// Totals is what this process estimated and spent, as reported by
+// --output-format json
+type Totals struct {
+	EstimatedImages int     // Images runs were estimated at (see Check)
+	Images          int     // Images generated
+	Spent           float64 // Their price
+	Cancelled       bool    // The user declined a run's cost
+}
+
+var (
+	sessionMu sync.Mutex
+	session   Totals
+)
+
+// Record tallies generated images at the current price. The API calls that
+// made them are recorded in the usage ledger, which project budgets total.
+func Record(images int) {
+	price := Of(images)
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	session.Images += images
+	session.Spent += price
+}
+
+// Session returns what this process estimated and spent so far
+func Session() Totals {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	return session
+}
+
+// noteEstimate adds a run's estimate to the session
+func noteEstimate(images int) {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	session.EstimatedImages += images
+}
+
+// noteCancelled marks the session as declined by the user
+func noteCancelled() {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	session.Cancelled = true
+}
@@ -0,0 +1,210 @@
+// Package dataset packages generated images and their captions into common
+// training dataset layouts.
+package dataset
+
+import (
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/logger"
+	"img-cli/pkg/workflow"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Supported dataset layouts
+const (
+	FormatJSONL   = "jsonl"   // images/ plus metadata.jsonl with {"image", "caption"} lines
+	FormatFolders = "folders" // <class>/<image> with a <image>.txt caption next to each
+)
+
+// Options controls an export
+type Options struct {
+	Format         string
+	OutputDir      string
+	ClassBy        string // Component used as the class folder in the folders layout
+	Trigger        string // Optional token prepended to every caption
+	IncludeFlagged bool   // Also export images that failed automated checks
+}
+
+// Result summarizes an export
+type Result struct {
+	Exported int
+	Skipped  []string // Inputs left out, with the reason
+}
+
+// Record is one line of a JSONL export
+type Record struct {
+	Image   string `json:"image"`
+	Caption string `json:"caption"`
+	AltText string `json:"alt_text,omitempty"`
+}
+
+// Export copies the generated images found in inputs (files or directories) into
+// the dataset layout, captioning each from its sidecar metadata
+func Export(inputs []string, opts Options) (*Result, error) {
+	images, err := collect(inputs)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(opts.OutputDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	var jsonl *os.File
+	if opts.Format == FormatJSONL {
+		jsonl, err = os.Create(filepath.Join(opts.OutputDir, "metadata.jsonl"))
+		if err != nil {
+			return nil, err
+		}
+		defer jsonl.Close()
+	}
+
+	result := &Result{}
+	used := make(map[string]bool)
+	for _, path := range images {
+		sidecar, err := workflow.ReadSidecar(path)
+		if err != nil {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("%s: no metadata", path))
+			continue
+		}
+		if len(sidecar.Flags) > 0 && !opts.IncludeFlagged {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("%s: flagged (%s)", path, strings.Join(sidecar.Flags, ", ")))
+			continue
+		}
+
+		caption := sidecar.Caption()
+		if opts.Trigger != "" {
+			caption = opts.Trigger + ", " + caption
+		}
+
+		dir := "images"
+		if opts.Format == FormatFolders {
+			dir = className(sidecar, opts.ClassBy)
+		}
+		rel := uniqueName(used, dir, filepath.Base(path))
+		dest := filepath.Join(opts.OutputDir, rel)
+
+		if err := copyFile(path, dest); err != nil {
+			return result, fmt.Errorf("failed to copy %s: %w", path, err)
+		}
+
+		switch opts.Format {
+		case FormatJSONL:
+			line, _ := json.Marshal(Record{Image: filepath.ToSlash(rel), Caption: caption, AltText: sidecar.AltText})
+			if _, err := jsonl.Write(append(line, '\n')); err != nil {
+				return result, err
+			}
+		case FormatFolders:
+			captionPath := strings.TrimSuffix(dest, filepath.Ext(dest)) + ".txt"
+			if err := os.WriteFile(captionPath, []byte(caption+"\n"), 0644); err != nil {
+				return result, err
+			}
+			if sidecar.AltText != "" {
+				altPath := strings.TrimSuffix(dest, filepath.Ext(dest)) + ".alt.txt"
+				if err := os.WriteFile(altPath, []byte(sidecar.AltText+"\n"), 0644); err != nil {
+					return result, err
+				}
+			}
+		}
+		result.Exported++
+	}
+
+	logger.Info("Dataset exported", "format", opts.Format, "output", opts.OutputDir, "images", result.Exported, "skipped", len(result.Skipped))
+	return result, nil
+}
+
+// collect expands the inputs into a list of image files
+func collect(inputs []string) ([]string, error) {
+	var images []string
+	for _, input := range inputs {
+		info, err := os.Stat(input)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			images = append(images, input)
+			continue
+		}
+		err = filepath.WalkDir(input, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			switch strings.ToLower(filepath.Ext(path)) {
+			case ".png", ".jpg", ".jpeg", ".webp":
+				images = append(images, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return images, nil
+}
+
+var unsafeChars = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// className names the class folder of an image from one of its recorded inputs
+func className(sidecar *workflow.Sidecar, classBy string) string {
+	var value string
+	if classBy == "subject" {
+		value = sidecar.Provenance.Subject.File
+	} else if source, ok := sidecar.Provenance.Components[strings.ReplaceAll(classBy, "-", "_")]; ok {
+		value = source.File
+		if value == "" {
+			value = source.Text
+		}
+	}
+	if value == "" {
+		return "unknown"
+	}
+
+	name := strings.TrimSuffix(filepath.Base(value), filepath.Ext(value))
+	name = strings.Trim(unsafeChars.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if len(name) > 40 {
+		name = name[:40]
+	}
+	if name == "" {
+		return "unknown"
+	}
+	return name
+}
+
+// uniqueName returns dir/base, numbering it when the name is already taken
+func uniqueName(used map[string]bool, dir, base string) string {
+	rel := filepath.Join(dir, base)
+	ext := filepath.Ext(base)
+	for i := 2; used[rel]; i++ {
+		rel = filepath.Join(dir, fmt.Sprintf("%s_%d%s", strings.TrimSuffix(base, ext), i, ext))
+	}
+	used[rel] = true
+	return rel
+}
+
+func copyFile(src, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
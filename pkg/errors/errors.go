@@ -102,6 +102,17 @@ func Wrap(err error, errType ErrorType, message string) *AppError {
 		}
 	}
 
+	// An AppError further down the chain (e.g. behind fmt.Errorf) also keeps its type
+	var inner *AppError
+	if errors.As(err, &inner) {
+		return &AppError{
+			Type:    inner.Type,
+			Message: message,
+			Cause:   err,
+			Context: inner.Context,
+		}
+	}
+
 	return &AppError{
 		Type:    errType,
 		Message: message,
@@ -135,6 +146,17 @@ func GetType(err error) ErrorType {
 	return InternalError
 }
 
+// ContextString returns a string recorded in the context of an AppError in err's chain
+func ContextString(err error, key string) string {
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		if value, ok := appErr.Context[key].(string); ok {
+			return value
+		}
+	}
+	return ""
+}
+
 // Validation errors
 
 // ErrInvalidInput creates a validation error for invalid input
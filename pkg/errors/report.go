@@ -0,0 +1,66 @@
+package errors
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// exitCodes maps each error type to a stable process exit code.
+// Codes are part of the CLI contract: append new types, never renumber.
+var exitCodes = map[ErrorType]int{
+	InternalError:   1,
+	ValidationError: 2,
+	FileError:       3,
+	APIError:        4,
+	CacheError:      5,
+	ConfigError:     6,
+	GenerationError: 7,
+	AnalysisError:   8,
+	WorkflowError:   9,
+}
+
+// ExitCode returns the process exit code for an error (0 for nil)
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	if code, ok := exitCodes[GetType(err)]; ok {
+		return code
+	}
+	return 1
+}
+
+// Report is the machine-readable form of an error, as printed by --errors-json
+type Report struct {
+	Type     ErrorType              `json:"type"`
+	ExitCode int                    `json:"exit_code"`
+	Message  string                 `json:"message"`
+	Cause    string                 `json:"cause,omitempty"`
+	Context  map[string]interface{} `json:"context,omitempty"`
+}
+
+// NewReport builds a Report from any error. Plain errors are reported as INTERNAL_ERROR.
+func NewReport(err error) *Report {
+	report := &Report{
+		Type:     GetType(err),
+		ExitCode: ExitCode(err),
+		Message:  err.Error(),
+	}
+
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		report.Message = appErr.Message
+		report.Context = appErr.Context
+		if appErr.Cause != nil {
+			report.Cause = appErr.Cause.Error()
+		}
+	}
+	return report
+}
+
+// MarshalReport returns the JSON document written to stderr for an error
+func MarshalReport(err error) ([]byte, error) {
+	return json.Marshal(struct {
+		Error *Report `json:"error"`
+	}{NewReport(err)})
+}
@@ -3,11 +3,15 @@
 package gemini
 
 import (
-	"bytes"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"io"
+	"img-cli/pkg/client"
+	"img-cli/pkg/config"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/logger"
+	"img-cli/pkg/output"
+	"img-cli/pkg/usage"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -16,23 +20,114 @@ import (
 )
 
 const (
-	APIURL = "https://generativelanguage.googleapis.com/v1beta/models/gemini-2.5-flash-image-preview:generateContent"
+	// ImageModel is the Gemini model used for analysis and generation
+	ImageModel = "gemini-2.5-flash-image-preview"
+
+	APIURL = "https://generativelanguage.googleapis.com/v1beta/models/" + ImageModel + ":generateContent"
 )
 
 type Client struct {
-	apiKey     string
-	httpClient *http.Client
+	provider        Provider
+	fallback        Provider // Optional provider for when the main one is down
+	analyzeLimiter  *limiter
+	generateLimiter *limiter
+	local           *localVision // Optional local server for analysis requests
+	dryRun          bool         // Return generation requests unsent (see SetDryRun)
+	retry           *client.RetryConfig // Backoff for 429/5xx/network failures; nil sends once
 }
 
+// NewClient creates a client for the configured provider (Gemini by default).
+// apiKey is the Gemini API key.
 func NewClient(apiKey string) *Client {
-	return &Client{
-		apiKey: apiKey,
-		httpClient: &http.Client{
-			Timeout: 180 * time.Second, // 3 minutes for image generation
-		},
+	c := &Client{
+		provider: &geminiProvider{apiKey: apiKey, httpClient: newHTTPClient()},
+	}
+	cfg := ProviderConfig()
+	if cfg.Name != config.ProviderGemini || cfg.Fallback != "" {
+		if err := c.SetProvider(apiKey, cfg); err != nil {
+			logger.Warn("Provider configuration invalid, using Gemini", "error", err)
+		}
+	}
+	c.SetLimits(config.DefaultLimitsConfig())
+	if local := config.DefaultLocalVisionConfig(); local.Enabled() {
+		c.SetLocalVision(local)
+	}
+	return c
+}
+
+// SetProvider replaces the main and fallback providers. apiKey is the Gemini
+// API key. The client is unchanged when either provider can't be created.
+func (c *Client) SetProvider(apiKey string, cfg *config.ProviderConfig) error {
+	provider, err := NewProvider(cfg.Name, apiKey, cfg)
+	if err != nil {
+		return err
+	}
+	var fallback Provider
+	if cfg.Fallback != "" && cfg.Fallback != cfg.Name {
+		if fallback, err = NewProvider(cfg.Fallback, apiKey, cfg); err != nil {
+			return err
+		}
+	}
+	c.provider, c.fallback = provider, fallback
+	return nil
+}
+
+// Provider returns the name of the main provider
+func (c *Client) Provider() string {
+	return c.provider.Name()
+}
+
+// Model returns the image generation model of the main provider
+func (c *Client) Model() string {
+	return c.provider.Model()
+}
+
+func newHTTPClient() *http.Client {
+	return &http.Client{
+		Timeout: 180 * time.Second, // 3 minutes for image generation
 	}
 }
 
+// SetLocalVision routes analysis requests to a local vision server first.
+// Requests fall back to Gemini if the local server fails. Pass nil to disable.
+func (c *Client) SetLocalVision(cfg *config.LocalVisionConfig) {
+	if cfg == nil || !cfg.Enabled() {
+		c.local = nil
+		return
+	}
+	c.local = newLocalVision(cfg)
+}
+
+// SetLimits replaces the per-operation rate limits, concurrency caps and retries
+func (c *Client) SetLimits(limits *config.LimitsConfig) {
+	c.analyzeLimiter = newLimiter(limits.AnalyzeRPS, limits.AnalyzeConcurrency)
+	c.generateLimiter = newLimiter(limits.GenerateRPS, limits.GenerateConcurrency)
+	if limits.Adaptive {
+		c.analyzeLimiter.enableAdaptive(limits.AdaptiveCeiling)
+		c.generateLimiter.enableAdaptive(limits.AdaptiveCeiling)
+	}
+	c.retry = retryConfig(limits.MaxRetries)
+}
+
+// Throughput reports the achieved request rate of each operation that sent requests
+func (c *Client) Throughput() []Throughput {
+	var stats []Throughput
+	for _, op := range []Operation{OpAnalyze, OpGenerate} {
+		if t := c.limiterFor(op).throughput(op); t.Requests > 0 {
+			stats = append(stats, t)
+		}
+	}
+	return stats
+}
+
+// limiterFor returns the limiter that governs an operation
+func (c *Client) limiterFor(op Operation) *limiter {
+	if op == OpGenerate {
+		return c.generateLimiter
+	}
+	return c.analyzeLimiter
+}
+
 func LoadImageAsBase64(imagePath string) (string, string, error) {
 	imageData, err := os.ReadFile(imagePath)
 	if err != nil {
@@ -57,35 +152,18 @@ func LoadImageAsBase64(imagePath string) (string, string, error) {
 }
 
 func (c *Client) SendRequest(request Request) (*Response, error) {
-	jsonData, err := json.Marshal(request)
-	if err != nil {
-		return nil, fmt.Errorf("error marshaling request: %w", err)
-	}
-
-	req, err := http.NewRequest("POST", APIURL+"?key="+c.apiKey, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("error creating request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("error sending request: %w", err)
+	if c.local != nil && request.Operation == OpAnalyze {
+		resp, err := c.local.send(request)
+		if err == nil {
+			recordTokens(usage.Entry{Type: usage.Analyze, Provider: usage.LocalVision, Model: c.local.model}, resp.UsageMetadata)
+			return resp, nil
+		}
+		logger.Warn("Local vision analysis failed, falling back to the provider", "provider", c.provider.Name(), "error", err)
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	body, _, err := c.send(request)
 	if err != nil {
-		return nil, fmt.Errorf("error reading response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		var geminiResp Response
-		if err := json.Unmarshal(body, &geminiResp); err == nil && geminiResp.Error != nil {
-			return nil, fmt.Errorf("API error: %s", geminiResp.Error.Message)
-		}
-		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(body))
+		return nil, err
 	}
 
 	var geminiResp Response
@@ -97,43 +175,79 @@ func (c *Client) SendRequest(request Request) (*Response, error) {
 }
 
 func (c *Client) SendRequestRaw(request Request) (map[string]interface{}, error) {
-	jsonData, err := json.Marshal(request)
+	rawResp, _, err := c.SendRequestRawFrom(request)
+	return rawResp, err
+}
+
+// Origin names the provider and model that answered a request
+type Origin struct {
+	Provider string
+	Model    string
+}
+
+// SendRequestRawFrom is SendRequestRaw that also reports who answered: the
+// fallback provider when the main one failed, so generated images are
+// attributed to the model that actually made them
+func (c *Client) SendRequestRawFrom(request Request) (map[string]interface{}, Origin, error) {
+	body, provider, err := c.send(request)
 	if err != nil {
-		return nil, fmt.Errorf("error marshaling request: %w", err)
+		return nil, Origin{}, err
 	}
 
-	req, err := http.NewRequest("POST", APIURL+"?key="+c.apiKey, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("error creating request: %w", err)
+	var rawResp map[string]interface{}
+	if err := json.Unmarshal(body, &rawResp); err != nil {
+		return nil, Origin{}, fmt.Errorf("error parsing response: %w", err)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	return rawResp, Origin{Provider: provider.Name(), Model: provider.Model()}, nil
+}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("error sending request: %w", err)
+// send sends a request to the provider, retrying transient failures and then
+// trying the fallback provider when the main one stays unreachable, rate
+// limited or failing. Answered calls go to the usage ledger. It returns the
+// provider that answered.
+func (c *Client) send(request Request) ([]byte, Provider, error) {
+	if c.dryRun && request.Operation == OpGenerate {
+		return nil, nil, dryRunError(request)
+	}
+	provider := c.provider
+	body, status, err := c.sendWithRetry(provider, request)
+	if err != nil && c.fallback != nil && (status == 0 || status == http.StatusTooManyRequests || status >= 500) {
+		logger.Warn("Provider failed, retrying with fallback", "provider", c.provider.Name(), "fallback", c.fallback.Name(), "error", err)
+		provider = c.fallback
+		body, _, err = c.sendWithRetry(provider, request)
 	}
-	defer resp.Body.Close()
+	if err == nil {
+		recordUsage(provider, request, body)
+	}
+	return body, provider, err
+}
 
-	body, err := io.ReadAll(resp.Body)
+// sendWith sends a request to one provider under the operation's rate limit
+func (c *Client) sendWith(provider Provider, request Request) ([]byte, int, error) {
+	limiter := c.limiterFor(request.Operation)
+	release := limiter.acquire()
+	defer release()
+
+	sent := time.Now()
+	status, body, err := provider.Send(request)
+	limiter.observe(request.Operation, status, time.Since(sent))
 	if err != nil {
-		return nil, fmt.Errorf("error reading response: %w", err)
+		if status == 0 {
+			return nil, 0, errors.ErrAPIRequest(provider.Name(), err)
+		}
+		return nil, status, err
 	}
 
-	if resp.StatusCode != http.StatusOK {
+	if status != http.StatusOK {
 		var geminiResp Response
 		if err := json.Unmarshal(body, &geminiResp); err == nil && geminiResp.Error != nil {
-			return nil, fmt.Errorf("API error: %s", geminiResp.Error.Message)
+			return nil, status, errors.ErrAPIResponse(provider.Name(), status, geminiResp.Error.Message)
 		}
-		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(body))
+		return nil, status, errors.ErrAPIResponse(provider.Name(), status, string(body))
 	}
 
-	var rawResp map[string]interface{}
-	if err := json.Unmarshal(body, &rawResp); err != nil {
-		return nil, fmt.Errorf("error parsing response: %w", err)
-	}
-
-	return rawResp, nil
+	return body, status, nil
 }
 
 func ExtractTextFromResponse(resp *Response) string {
@@ -152,14 +266,19 @@ func ExtractTextFromResponse(resp *Response) string {
 	return ""
 }
 
+// ExtractGeneratedImage returns the first image in a raw response. When the model
+// answers with text instead (usually a refusal) the error carries that text in its
+// "refusal" context, and any non-STOP finish reason in "finish_reason".
 func ExtractGeneratedImage(rawResp map[string]interface{}) ([]byte, string, error) {
+	var finishReason string
 	if candidates, ok := rawResp["candidates"].([]interface{}); ok && len(candidates) > 0 {
 		if candidate, ok := candidates[0].(map[string]interface{}); ok {
 			// Check for finish reason first
-			if finishReason, ok := candidate["finishReason"].(string); ok && finishReason != "" {
+			if reason, ok := candidate["finishReason"].(string); ok && reason != "" {
 				// Only show finish reason for non-STOP cases
-				if finishReason != "STOP" {
-					fmt.Printf("\n[API] Finish Reason: %s\n", finishReason)
+				if reason != "STOP" {
+					output.Detail.Printf("\n[API] Finish Reason: %s\n", reason)
+					finishReason = reason
 				}
 			}
 
@@ -195,17 +314,28 @@ func ExtractGeneratedImage(rawResp map[string]interface{}) ([]byte, string, erro
 					// If we got here, no image was found but we might have text
 					if textContent != "" {
 						// Print the text content for debugging
-						fmt.Println("\n=== API Response (Text Instead of Image) ===")
-						fmt.Println(textContent)
-						fmt.Println("===========================================\n")
-						return nil, "", fmt.Errorf("no image found in response, received text instead (see above)")
+						output.Progress.Println("\n=== API Response (Text Instead of Image) ===")
+						output.Progress.Println(textContent)
+						output.Progress.Println("===========================================")
+						output.Progress.Println()
+						return nil, "", noImageError("no image found in response, received text instead (see above)", finishReason).
+							WithContext("refusal", textContent)
 					}
 				}
 			}
 		}
 	}
 
-	return nil, "", fmt.Errorf("no image found in response")
+	return nil, "", noImageError("no image found in response", finishReason)
+}
+
+// noImageError reports a generation response without an image
+func noImageError(message, finishReason string) *errors.AppError {
+	err := errors.New(errors.GenerationError, message)
+	if finishReason != "" {
+		err.WithContext("finish_reason", finishReason)
+	}
+	return err
 }
 
 // LoadFile loads a file as bytes
@@ -0,0 +1,43 @@
+package gemini
+
+import (
+	stderrors "errors"
+	"fmt"
+)
+
+// DryRunError is returned instead of sending a generation request while the
+// client is in dry-run mode. It carries what would have been sent, so callers
+// can show the exact prompt of every planned image.
+type DryRunError struct {
+	Prompt string
+	Images int // Reference images attached to the request
+	Config *GenerationConfig
+}
+
+func (e *DryRunError) Error() string {
+	return fmt.Sprintf("dry run: generation request with %d image(s) not sent", e.Images)
+}
+
+// AsDryRun returns the unsent request behind err, if err is a dry run
+func AsDryRun(err error) (*DryRunError, bool) {
+	var dryRun *DryRunError
+	if stderrors.As(err, &dryRun) {
+		return dryRun, true
+	}
+	return nil, false
+}
+
+// SetDryRun stops the client from sending generation requests; each one
+// returns a *DryRunError instead. Analysis requests are still sent.
+func (c *Client) SetDryRun(enabled bool) {
+	c.dryRun = enabled
+}
+
+// dryRunError builds the error returned for an unsent generation request
+func dryRunError(request Request) error {
+	text, images, err := requestParts(request)
+	if err != nil {
+		return err
+	}
+	return &DryRunError{Prompt: text, Images: len(images), Config: request.GenerationConfig}
+}
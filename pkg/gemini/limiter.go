@@ -0,0 +1,186 @@
+package gemini
+
+import (
+	"img-cli/pkg/logger"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Operation identifies the kind of API call so it can be rate limited separately
+type Operation int
+
+const (
+	// OpAnalyze is a text-only analysis request (the default)
+	OpAnalyze Operation = iota
+	// OpGenerate is an image generation request
+	OpGenerate
+)
+
+// String returns the operation name used in logs
+func (op Operation) String() string {
+	if op == OpGenerate {
+		return "generate"
+	}
+	return "analyze"
+}
+
+// AIMD tuning for adaptive limiters
+const (
+	aimdDecrease     = 0.5 // Rate multiplier when the API pushes back
+	aimdStepFraction = 0.1 // Additive increase per healthy response, as a fraction of the configured rate
+	aimdFloorDivisor = 8   // Lowest rate is the configured rate divided by this
+	aimdSlowFactor   = 2   // A response slower than this multiple of the average holds the rate
+)
+
+// Throughput reports what a limiter achieved over a run
+type Throughput struct {
+	Operation    string
+	Requests     int
+	Throttled    int // Responses that triggered a backoff (429, 5xx, transport errors)
+	Elapsed      time.Duration
+	EffectiveRPS float64 // Completed requests per second between the first start and the last response
+	FinalRPS     float64 // Rate the limiter settled on (the configured rate when not adaptive)
+}
+
+// limiter caps both the start rate and the number of in-flight requests.
+// An adaptive limiter adjusts its rate with AIMD: it halves on throttling
+// and grows a step after each healthy response, between a floor and a ceiling.
+type limiter struct {
+	slots    chan struct{}
+	interval time.Duration
+	mu       sync.Mutex
+	next     time.Time
+
+	adaptive     bool
+	rate         float64
+	minRate      float64
+	maxRate      float64
+	step         float64
+	latency      time.Duration // Moving average of healthy response times
+	lastDecrease time.Time
+
+	requests  int
+	throttled int
+	first     time.Time
+	last      time.Time
+}
+
+func newLimiter(requestsPerSecond float64, concurrency int) *limiter {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	l := &limiter{slots: make(chan struct{}, concurrency), rate: requestsPerSecond}
+	if requestsPerSecond > 0 {
+		l.interval = time.Duration(float64(time.Second) / requestsPerSecond)
+	}
+	return l
+}
+
+// enableAdaptive lets the rate move between a fraction of the configured
+// rate and ceiling times it. Unlimited limiters stay unlimited.
+func (l *limiter) enableAdaptive(ceiling float64) {
+	if l.rate <= 0 {
+		return
+	}
+	if ceiling < 1 {
+		ceiling = 1
+	}
+	l.adaptive = true
+	l.minRate = l.rate / aimdFloorDivisor
+	l.maxRate = l.rate * ceiling
+	l.step = l.rate * aimdStepFraction
+}
+
+// acquire blocks until a request may start and returns the function that releases its slot
+func (l *limiter) acquire() func() {
+	l.slots <- struct{}{}
+
+	l.mu.Lock()
+	now := time.Now()
+	start := l.next
+	if start.Before(now) {
+		start = now
+	}
+	l.next = start.Add(l.interval)
+	if l.first.IsZero() {
+		l.first = start
+	}
+	l.mu.Unlock()
+
+	if wait := time.Until(start); wait > 0 {
+		time.Sleep(wait)
+	}
+
+	return func() { <-l.slots }
+}
+
+// observe records the outcome of a request (status 0 for transport errors)
+// and, for adaptive limiters, adjusts the rate
+func (l *limiter) observe(op Operation, status int, latency time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.requests++
+	l.last = now
+
+	congested := status == 0 || status == http.StatusTooManyRequests || status >= 500
+	if congested {
+		l.throttled++
+	}
+	if !l.adaptive {
+		return
+	}
+
+	if congested {
+		// Requests already in flight at the last cut are covered by it
+		if now.Add(-latency).Before(l.lastDecrease) {
+			return
+		}
+		l.lastDecrease = now
+		l.setRate(l.rate * aimdDecrease)
+		logger.Debug("Backing off request rate", "operation", op, "status", status, "rps", l.rate)
+		return
+	}
+
+	slow := l.latency > 0 && latency > aimdSlowFactor*l.latency
+	if l.latency == 0 {
+		l.latency = latency
+	} else {
+		l.latency = (4*l.latency + latency) / 5
+	}
+	if !slow {
+		l.setRate(l.rate + l.step)
+	}
+}
+
+// setRate clamps the rate to the adaptive range and updates the start interval
+func (l *limiter) setRate(rate float64) {
+	if rate < l.minRate {
+		rate = l.minRate
+	}
+	if rate > l.maxRate {
+		rate = l.maxRate
+	}
+	l.rate = rate
+	l.interval = time.Duration(float64(time.Second) / rate)
+}
+
+// throughput summarizes the requests seen so far
+func (l *limiter) throughput(op Operation) Throughput {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	t := Throughput{
+		Operation: op.String(),
+		Requests:  l.requests,
+		Throttled: l.throttled,
+		FinalRPS:  l.rate,
+	}
+	if l.requests > 0 && !l.first.IsZero() && l.last.After(l.first) {
+		t.Elapsed = l.last.Sub(l.first)
+		t.EffectiveRPS = float64(l.requests) / t.Elapsed.Seconds()
+	}
+	return t
+}
@@ -0,0 +1,150 @@
+package gemini
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/config"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// localVision sends analysis requests to a local OpenAI-compatible vision server
+// so bulk analysis doesn't consume paid API quota. Generation always stays on Gemini.
+type localVision struct {
+	baseURL    string
+	model      string
+	httpClient *http.Client
+	limiter    *limiter
+}
+
+type chatMessage struct {
+	Role    string        `json:"role"`
+	Content []chatContent `json:"content"`
+}
+
+type chatContent struct {
+	Type     string        `json:"type"`
+	Text     string        `json:"text,omitempty"`
+	ImageURL *chatImageURL `json:"image_url,omitempty"`
+}
+
+type chatImageURL struct {
+	URL string `json:"url"`
+}
+
+type chatRequest struct {
+	Model       string        `json:"model"`
+	Messages    []chatMessage `json:"messages"`
+	Temperature float64       `json:"temperature,omitempty"`
+	TopP        float64       `json:"top_p,omitempty"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage,omitempty"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// usage returns the token counts of the answer, when the server sent them
+func (r chatResponse) usage() *UsageMetadata {
+	if r.Usage == nil {
+		return nil
+	}
+	return &UsageMetadata{PromptTokenCount: r.Usage.PromptTokens, CandidatesTokenCount: r.Usage.CompletionTokens}
+}
+
+func newLocalVision(cfg *config.LocalVisionConfig) *localVision {
+	return &localVision{
+		baseURL: strings.TrimSuffix(cfg.URL, "/"),
+		model:   cfg.Model,
+		httpClient: &http.Client{
+			Timeout: 300 * time.Second, // Local models on modest GPUs can be slow
+		},
+		limiter: newLimiter(0, cfg.Concurrency),
+	}
+}
+
+// send translates a Gemini analysis request to a chat completion and the answer back
+func (l *localVision) send(request Request) (*Response, error) {
+	message := chatMessage{Role: "user"}
+	for _, content := range request.Contents {
+		for _, part := range content.Parts {
+			switch p := part.(type) {
+			case TextPart:
+				message.Content = append(message.Content, chatContent{Type: "text", Text: p.Text})
+			case BlobPart:
+				message.Content = append(message.Content, chatContent{
+					Type:     "image_url",
+					ImageURL: &chatImageURL{URL: fmt.Sprintf("data:%s;base64,%s", p.InlineData.MimeType, p.InlineData.Data)},
+				})
+			default:
+				return nil, fmt.Errorf("unsupported request part %T for local vision", part)
+			}
+		}
+	}
+
+	chatReq := chatRequest{
+		Model:    l.model,
+		Messages: []chatMessage{message},
+	}
+	if request.GenerationConfig != nil {
+		chatReq.Temperature = request.GenerationConfig.Temperature
+		chatReq.TopP = request.GenerationConfig.TopP
+	}
+
+	jsonData, err := json.Marshal(chatReq)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling request: %w", err)
+	}
+
+	release := l.limiter.acquire()
+	defer release()
+
+	resp, err := l.httpClient.Post(l.baseURL+"/chat/completions", "application/json", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response: %w", err)
+	}
+
+	var chatResp chatResponse
+	if err := json.Unmarshal(body, &chatResp); err != nil {
+		return nil, fmt.Errorf("unexpected response (status %d): %s", resp.StatusCode, string(body))
+	}
+	if chatResp.Error != nil {
+		return nil, fmt.Errorf("local vision error: %s", chatResp.Error.Message)
+	}
+	if resp.StatusCode != http.StatusOK || len(chatResp.Choices) == 0 {
+		return nil, fmt.Errorf("unexpected response (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	// Shape the answer like a Gemini response so the analyzers don't need to know
+	return &Response{
+		Candidates: []Candidate{
+			{
+				Content: Content{
+					Parts: []interface{}{
+						map[string]interface{}{"text": chatResp.Choices[0].Message.Content},
+					},
+				},
+			},
+		},
+		UsageMetadata: chatResp.usage(),
+	}, nil
+}
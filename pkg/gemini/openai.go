@@ -0,0 +1,349 @@
+package gemini
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"img-cli/pkg/config"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strings"
+)
+
+// Sizes offered by the OpenAI image API. Portrait is the default, the closest
+// match to the portraits Gemini generates; other aspect ratios get the nearest
+// shape and are cropped to the exact ratio afterwards.
+const (
+	openAIImageSize     = "1024x1536"
+	openAISquareSize    = "1024x1024"
+	openAILandscapeSize = "1536x1024"
+)
+
+// openAIRefusalCodes are error codes the OpenAI image API uses for moderation
+var openAIRefusalCodes = map[string]bool{
+	"moderation_blocked":       true,
+	"content_policy_violation": true,
+}
+
+// openAIProvider translates Gemini requests to the OpenAI API: analysis goes to
+// chat completions, generation to the image API (edits when reference images are
+// attached). Answers are shaped like Gemini responses.
+type openAIProvider struct {
+	apiKey     string
+	baseURL    string
+	model      string
+	imageModel string
+	httpClient *http.Client
+}
+
+type openAIImageResponse struct {
+	Data []struct {
+		B64JSON string `json:"b64_json"`
+		URL     string `json:"url"`
+	} `json:"data"`
+	Error *openAIError `json:"error,omitempty"`
+}
+
+type openAIError struct {
+	Message string `json:"message"`
+	Code    string `json:"code"`
+}
+
+func newOpenAIProvider(cfg *config.ProviderConfig) *openAIProvider {
+	return &openAIProvider{
+		apiKey:     cfg.OpenAIKey,
+		baseURL:    strings.TrimSuffix(cfg.OpenAIBaseURL, "/"),
+		model:      cfg.OpenAIModel,
+		imageModel: cfg.OpenAIImageModel,
+		httpClient: newHTTPClient(),
+	}
+}
+
+func (p *openAIProvider) Name() string {
+	return config.ProviderOpenAI
+}
+
+func (p *openAIProvider) Model() string {
+	return p.imageModel
+}
+
+func (p *openAIProvider) Send(request Request) (int, []byte, error) {
+	if request.Operation == OpGenerate {
+		return p.generate(request)
+	}
+	return p.chat(request)
+}
+
+// chat sends an analysis or text request as a chat completion
+func (p *openAIProvider) chat(request Request) (int, []byte, error) {
+	message := chatMessage{Role: "user"}
+	for _, content := range request.Contents {
+		for _, part := range content.Parts {
+			switch part := part.(type) {
+			case TextPart:
+				message.Content = append(message.Content, chatContent{Type: "text", Text: part.Text})
+			case BlobPart:
+				message.Content = append(message.Content, chatContent{
+					Type:     "image_url",
+					ImageURL: &chatImageURL{URL: fmt.Sprintf("data:%s;base64,%s", part.InlineData.MimeType, part.InlineData.Data)},
+				})
+			default:
+				return 0, nil, fmt.Errorf("unsupported request part %T for openai", part)
+			}
+		}
+	}
+
+	chatReq := chatRequest{
+		Model:    p.model,
+		Messages: []chatMessage{message},
+	}
+	if request.GenerationConfig != nil {
+		chatReq.Temperature = request.GenerationConfig.Temperature
+		chatReq.TopP = request.GenerationConfig.TopP
+	}
+
+	jsonData, err := json.Marshal(chatReq)
+	if err != nil {
+		return 0, nil, fmt.Errorf("error marshaling request: %w", err)
+	}
+
+	status, body, err := p.post("/chat/completions", "application/json", jsonData)
+	if err != nil || status != http.StatusOK {
+		return status, openAIErrorBody(body), err
+	}
+
+	var chatResp chatResponse
+	if err := json.Unmarshal(body, &chatResp); err != nil || len(chatResp.Choices) == 0 {
+		return 0, nil, fmt.Errorf("unexpected openai response: %s", string(body))
+	}
+	return status, withUsage(textResponse(chatResp.Choices[0].Message.Content), chatResp.usage()), nil
+}
+
+// generate creates an image from the request's prompt, editing the attached
+// reference images (subject, outfit, style) when there are any
+func (p *openAIProvider) generate(request Request) (int, []byte, error) {
+	prompt, images, err := requestParts(request)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	size := openAISize(request)
+	var status int
+	var body []byte
+	if len(images) == 0 {
+		params := map[string]interface{}{
+			"model":  p.imageModel,
+			"prompt": prompt,
+			"size":   size,
+		}
+		if p.dallE() {
+			params["response_format"] = "b64_json"
+		}
+		jsonData, err := json.Marshal(params)
+		if err != nil {
+			return 0, nil, fmt.Errorf("error marshaling request: %w", err)
+		}
+		status, body, err = p.post("/images/generations", "application/json", jsonData)
+		if err != nil {
+			return 0, nil, err
+		}
+	} else {
+		var mask *InlineData
+		if request.GenerationConfig != nil {
+			mask = request.GenerationConfig.Mask
+		}
+		form, contentType, err := p.editForm(prompt, size, images, mask)
+		if err != nil {
+			return 0, nil, err
+		}
+		status, body, err = p.post("/images/edits", contentType, form)
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+
+	var imageResp openAIImageResponse
+	json.Unmarshal(body, &imageResp)
+	if imageResp.Error != nil && openAIRefusalCodes[imageResp.Error.Code] {
+		// Report refusals the way Gemini does so they are labeled safety refusals
+		return http.StatusOK, candidateResponse(map[string]interface{}{"text": imageResp.Error.Message}, "SAFETY"), nil
+	}
+	if status != http.StatusOK {
+		return status, openAIErrorBody(body), nil
+	}
+	if len(imageResp.Data) == 0 {
+		return 0, nil, fmt.Errorf("unexpected openai response: %s", string(body))
+	}
+
+	data := imageResp.Data[0].B64JSON
+	if data == "" && imageResp.Data[0].URL != "" {
+		if data, err = p.download(imageResp.Data[0].URL); err != nil {
+			return 0, nil, err
+		}
+	}
+	return status, imageResponse("image/png", data), nil
+}
+
+// openAISize picks the image size closest to the requested aspect ratio
+func openAISize(request Request) string {
+	w, h := requestAspect(request)
+	switch {
+	case w == 0 || w < h:
+		return openAIImageSize
+	case w > h:
+		return openAILandscapeSize
+	default:
+		return openAISquareSize
+	}
+}
+
+// editForm builds the multipart body of an image edit request. A mask applies
+// to the first image.
+func (p *openAIProvider) editForm(prompt, size string, images []BlobPart, mask *InlineData) ([]byte, string, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	fields := map[string]string{"model": p.imageModel, "prompt": prompt, "size": size}
+	if p.dallE() {
+		fields["response_format"] = "b64_json"
+	}
+	for name, value := range fields {
+		if err := writer.WriteField(name, value); err != nil {
+			return nil, "", err
+		}
+	}
+
+	field := "image[]"
+	if p.dallE() {
+		field = "image" // DALL·E edits a single image
+		images = images[:1]
+	}
+	for i, image := range images {
+		data, err := base64.StdEncoding.DecodeString(image.InlineData.Data)
+		if err != nil {
+			return nil, "", fmt.Errorf("error decoding image: %w", err)
+		}
+		ext := strings.TrimPrefix(image.InlineData.MimeType, "image/")
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="image-%d.%s"`, field, i+1, ext))
+		header.Set("Content-Type", image.InlineData.MimeType)
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, "", err
+		}
+		if _, err := part.Write(data); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if mask != nil {
+		data, err := openAIMask(*mask)
+		if err != nil {
+			return nil, "", err
+		}
+		part, err := writer.CreateFormFile("mask", "mask.png")
+		if err != nil {
+			return nil, "", err
+		}
+		if _, err := part.Write(data); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), writer.FormDataContentType(), nil
+}
+
+// openAIMask converts a white-on-black inpainting mask to the OpenAI format,
+// where the region to edit is transparent
+func openAIMask(mask InlineData) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(mask.Data)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding mask: %w", err)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("error decoding mask: %w", err)
+	}
+	b := img.Bounds()
+	converted := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			if gray := color.GrayModel.Convert(img.At(b.Min.X+x, b.Min.Y+y)).(color.Gray); gray.Y < 128 {
+				converted.SetNRGBA(x, y, color.NRGBA{A: 255})
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, converted); err != nil {
+		return nil, fmt.Errorf("error encoding mask: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (p *openAIProvider) post(path, contentType string, data []byte) (int, []byte, error) {
+	req, err := http.NewRequest("POST", p.baseURL+path, bytes.NewBuffer(data))
+	if err != nil {
+		return 0, nil, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("error reading response: %w", err)
+	}
+	return resp.StatusCode, body, nil
+}
+
+// download fetches an image the API returned by URL and encodes it
+func (p *openAIProvider) download(url string) (string, error) {
+	resp, err := p.httpClient.Get(url)
+	if err != nil {
+		return "", fmt.Errorf("error downloading image: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("error downloading image: status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("error downloading image: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// dallE reports whether the image model is a DALL·E model, which returns URLs
+// unless asked for base64 and edits only one image
+func (p *openAIProvider) dallE() bool {
+	return strings.HasPrefix(p.imageModel, "dall-e")
+}
+
+// openAIErrorBody reshapes an OpenAI error body like a Gemini error so the
+// client can report its message
+func openAIErrorBody(body []byte) []byte {
+	var errResp struct {
+		Error *openAIError `json:"error"`
+	}
+	if err := json.Unmarshal(body, &errResp); err != nil || errResp.Error == nil {
+		return body
+	}
+	reshaped, _ := json.Marshal(map[string]interface{}{
+		"error": map[string]interface{}{"message": errResp.Error.Message},
+	})
+	return reshaped
+}
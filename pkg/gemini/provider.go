@@ -0,0 +1,188 @@
+package gemini
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/config"
+	"img-cli/pkg/errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Provider sends requests to an image model API. Requests and response bodies
+// use the Gemini format, so analyzers and generators work with any provider;
+// other providers translate in both directions, like the local vision server.
+type Provider interface {
+	// Name identifies the provider in errors and logs, e.g. "gemini"
+	Name() string
+
+	// Model is the model that generates images, recorded in run manifests
+	Model() string
+
+	// Send performs one request and returns the HTTP status and the response
+	// body in the Gemini format. err is set only when no response arrived.
+	Send(request Request) (status int, body []byte, err error)
+}
+
+// providerOverride is the provider chosen with --provider, ahead of IMG_CLI_PROVIDER
+var providerOverride string
+
+// SetDefaultProvider selects the provider of clients created afterwards
+func SetDefaultProvider(name string) error {
+	if err := validateProvider(name); err != nil {
+		return err
+	}
+	providerOverride = name
+	return nil
+}
+
+// ProviderConfig returns the provider configuration clients are created with
+func ProviderConfig() *config.ProviderConfig {
+	cfg := config.DefaultProviderConfig()
+	if providerOverride != "" {
+		cfg.Name = providerOverride
+	}
+	return cfg
+}
+
+func validateProvider(name string) error {
+	switch name {
+	case config.ProviderGemini, config.ProviderOpenAI, config.ProviderSD:
+		return nil
+	}
+	return errors.ErrInvalidInput("provider", fmt.Sprintf("unknown provider %q (use %s, %s or %s)", name, config.ProviderGemini, config.ProviderOpenAI, config.ProviderSD))
+}
+
+// NewProvider creates the named provider. apiKey is the Gemini API key.
+func NewProvider(name, apiKey string, cfg *config.ProviderConfig) (Provider, error) {
+	if err := validateProvider(name); err != nil {
+		return nil, err
+	}
+	switch name {
+	case config.ProviderOpenAI:
+		if cfg.OpenAIKey == "" {
+			return nil, errors.New(errors.ConfigError, "OPENAI_API_KEY is required for the openai provider")
+		}
+		return newOpenAIProvider(cfg), nil
+	case config.ProviderSD:
+		return newSDProvider(cfg)
+	}
+	return &geminiProvider{apiKey: apiKey, httpClient: newHTTPClient()}, nil
+}
+
+// geminiProvider sends requests to the Gemini API as-is
+type geminiProvider struct {
+	apiKey     string
+	httpClient *http.Client
+}
+
+func (g *geminiProvider) Name() string {
+	return config.ProviderGemini
+}
+
+func (g *geminiProvider) Model() string {
+	return ImageModel
+}
+
+func (g *geminiProvider) Send(request Request) (int, []byte, error) {
+	if cfg := request.GenerationConfig; cfg != nil && cfg.Mask != nil {
+		request = withMaskImage(request, *cfg.Mask)
+	}
+	jsonData, err := json.Marshal(request)
+	if err != nil {
+		return 0, nil, fmt.Errorf("error marshaling request: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", APIURL+"?key="+g.apiKey, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return 0, nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("error reading response: %w", err)
+	}
+	return resp.StatusCode, body, nil
+}
+
+// withMaskImage appends an inpainting mask to the request's last turn as an
+// image with an explanation, for models without a mask input
+func withMaskImage(request Request, mask InlineData) Request {
+	contents := append([]Content(nil), request.Contents...)
+	if len(contents) == 0 {
+		contents = []Content{{}}
+	}
+	last := &contents[len(contents)-1]
+	last.Parts = append(append([]interface{}(nil), last.Parts...),
+		TextPart{Text: "The next image is the edit mask for the first image, at the same size: change only the area that is white in the mask and leave every pixel under the black area unchanged."},
+		BlobPart{InlineData: mask},
+	)
+	request.Contents = contents
+	return request
+}
+
+// textResponse builds a Gemini-format response body holding one text answer
+func textResponse(text string) []byte {
+	return candidateResponse(map[string]interface{}{"text": text}, "STOP")
+}
+
+// imageResponse builds a Gemini-format response body holding one generated image
+func imageResponse(mimeType, data string) []byte {
+	return candidateResponse(map[string]interface{}{
+		"inlineData": map[string]interface{}{"mimeType": mimeType, "data": data},
+	}, "STOP")
+}
+
+func candidateResponse(part map[string]interface{}, finishReason string) []byte {
+	body, _ := json.Marshal(map[string]interface{}{
+		"candidates": []interface{}{
+			map[string]interface{}{
+				"content":      map[string]interface{}{"parts": []interface{}{part}},
+				"finishReason": finishReason,
+			},
+		},
+	})
+	return body
+}
+
+// requestParts splits a request into its text and images
+func requestParts(request Request) (string, []BlobPart, error) {
+	var texts []string
+	var images []BlobPart
+	for _, content := range request.Contents {
+		for _, part := range content.Parts {
+			switch p := part.(type) {
+			case TextPart:
+				texts = append(texts, p.Text)
+			case BlobPart:
+				images = append(images, p)
+			default:
+				return "", nil, fmt.Errorf("unsupported request part %T", part)
+			}
+		}
+	}
+	return strings.Join(texts, "\n\n"), images, nil
+}
+
+// requestAspect returns the width:height ratio a generation request asks for,
+// or 0, 0 when it leaves the shape to the provider
+func requestAspect(request Request) (int, int) {
+	if request.GenerationConfig == nil || request.GenerationConfig.ImageConfig == nil {
+		return 0, 0
+	}
+	var w, h int
+	if _, err := fmt.Sscanf(request.GenerationConfig.ImageConfig.AspectRatio, "%d:%d", &w, &h); err != nil || w <= 0 || h <= 0 {
+		return 0, 0
+	}
+	return w, h
+}
@@ -0,0 +1,55 @@
+package gemini
+
+import (
+	stderrors "errors"
+	"img-cli/pkg/client"
+	"img-cli/pkg/logger"
+	"net"
+	"net/http"
+	"time"
+)
+
+// SetRetry replaces how transient failures are retried; nil disables retries
+func (c *Client) SetRetry(retry *client.RetryConfig) {
+	c.retry = retry
+}
+
+// retryConfig builds the retry behavior for the configured limits
+func retryConfig(maxRetries int) *client.RetryConfig {
+	retry := client.DefaultRetryConfig()
+	retry.MaxRetries = maxRetries
+	return retry
+}
+
+// retryable reports whether a failed request may succeed when sent again:
+// rate limiting, server errors and network failures. Other errors, such as a
+// rejected prompt or a request that could not be built, fail the same way again.
+func retryable(status int, err error) bool {
+	if status == http.StatusTooManyRequests || status >= 500 {
+		return true
+	}
+	var netErr net.Error
+	return status == 0 && stderrors.As(err, &netErr)
+}
+
+// sendWithRetry sends a request to one provider, sending it again with
+// exponential backoff while it fails transiently, so a 429 or 5xx in the
+// middle of a batch doesn't fail the combination. The concurrency slot is
+// released while waiting.
+func (c *Client) sendWithRetry(provider Provider, request Request) ([]byte, int, error) {
+	for attempt := 0; ; attempt++ {
+		body, status, err := c.sendWith(provider, request)
+		if err == nil || c.retry == nil || attempt >= c.retry.MaxRetries || !retryable(status, err) {
+			return body, status, err
+		}
+		wait := c.retry.Backoff(attempt)
+		logger.Warn("Request failed, retrying",
+			"provider", provider.Name(),
+			"operation", request.Operation.String(),
+			"attempt", attempt+1,
+			"max_attempts", c.retry.MaxRetries+1,
+			"wait", wait,
+			"error", err)
+		time.Sleep(wait)
+	}
+}
@@ -0,0 +1,472 @@
+package gemini
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/config"
+	"img-cli/pkg/errors"
+	"io"
+	"math"
+	"math/rand"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// sdPromptLimit keeps translated prompts within what Stable Diffusion models
+// follow; A1111 chunks longer prompts but later chunks carry little weight
+const sdPromptLimit = 1200
+
+// comfyPollInterval is how often a queued ComfyUI prompt is checked
+const comfyPollInterval = time.Second
+
+// sdProvider generates images with a local Stable Diffusion server, through the
+// A1111 WebUI API (img2img from the subject photo) or a ComfyUI workflow. It
+// can't analyze images; analysis goes to the local vision server.
+type sdProvider struct {
+	baseURL    string
+	backend    string
+	workflow   string
+	model      string // Backend and, for ComfyUI, the workflow file
+	steps      int
+	cfgScale   float64
+	denoise    float64
+	width      int
+	height     int
+	negative   string
+	httpClient *http.Client
+}
+
+func newSDProvider(cfg *config.ProviderConfig) (*sdProvider, error) {
+	p := &sdProvider{
+		baseURL:    strings.TrimSuffix(cfg.SDURL, "/"),
+		backend:    cfg.SDBackend,
+		steps:      cfg.SDSteps,
+		cfgScale:   cfg.SDCFGScale,
+		denoise:    cfg.SDDenoise,
+		width:      cfg.SDWidth,
+		height:     cfg.SDHeight,
+		negative:   cfg.SDNegativePrompt,
+		httpClient: newHTTPClient(),
+	}
+
+	switch p.backend {
+	case config.SDBackendA1111:
+		p.model = config.SDBackendA1111
+	case config.SDBackendComfyUI:
+		if cfg.SDWorkflow == "" {
+			return nil, errors.New(errors.ConfigError, "IMG_CLI_SD_WORKFLOW is required for the comfyui backend (export the workflow with \"Save (API Format)\")")
+		}
+		data, err := os.ReadFile(cfg.SDWorkflow)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ConfigError, "failed to read ComfyUI workflow")
+		}
+		if !bytes.Contains(data, []byte(`"{{prompt}}"`)) {
+			return nil, errors.New(errors.ConfigError, "ComfyUI workflow has no \"{{prompt}}\" placeholder").
+				WithContext("path", cfg.SDWorkflow)
+		}
+		p.workflow = string(data)
+		p.model = config.SDBackendComfyUI + ":" + filepath.Base(cfg.SDWorkflow)
+	default:
+		return nil, errors.ErrInvalidInput("sd backend", fmt.Sprintf("unknown backend %q (use %s or %s)", p.backend, config.SDBackendA1111, config.SDBackendComfyUI))
+	}
+	return p, nil
+}
+
+func (p *sdProvider) Name() string {
+	return config.ProviderSD
+}
+
+func (p *sdProvider) Model() string {
+	return p.model
+}
+
+func (p *sdProvider) Send(request Request) (int, []byte, error) {
+	if request.Operation != OpGenerate {
+		return 0, nil, fmt.Errorf("the sd provider only generates images; set IMG_CLI_LOCAL_VISION_URL to analyze locally")
+	}
+
+	text, images, err := requestParts(request)
+	if err != nil {
+		return 0, nil, err
+	}
+	prompt := sdPrompt(text)
+
+	// The first reference is the subject photo (or a framing style sent ahead
+	// of it); Stable Diffusion keeps identity by starting from that image
+	var init *BlobPart
+	if len(images) > 0 {
+		init = &images[0]
+	}
+
+	var seed int64
+	var mask *InlineData
+	denoise := p.denoise
+	if cfg := request.GenerationConfig; cfg != nil {
+		seed = cfg.Seed
+		mask = cfg.Mask
+		if cfg.Strength > 0 {
+			denoise = cfg.Strength
+		}
+	}
+	if p.backend == config.SDBackendComfyUI {
+		return p.comfy(prompt, init, mask, seed, denoise)
+	}
+	width, height := p.size(request)
+	return p.a1111(prompt, init, mask, width, height, seed, denoise)
+}
+
+// size returns the configured image size, reshaped to the requested aspect
+// ratio with about the same pixel count. Sides are multiples of 64, which
+// Stable Diffusion models expect.
+func (p *sdProvider) size(request Request) (int, int) {
+	aw, ah := requestAspect(request)
+	if aw == 0 {
+		return p.width, p.height
+	}
+	area := float64(p.width * p.height)
+	width := math.Sqrt(area * float64(aw) / float64(ah))
+	height := width * float64(ah) / float64(aw)
+	return roundTo64(width), roundTo64(height)
+}
+
+// roundTo64 rounds a side length to the nearest multiple of 64, at least 64
+func roundTo64(side float64) int {
+	if n := int(math.Round(side/64)) * 64; n > 64 {
+		return n
+	}
+	return 64
+}
+
+// a1111 generates through the WebUI API: img2img when there is a reference
+// image, inpainting when there is also a mask, txt2img otherwise. A zero seed
+// lets the WebUI pick one.
+func (p *sdProvider) a1111(prompt string, init *BlobPart, mask *InlineData, width, height int, seed int64, denoise float64) (int, []byte, error) {
+	if seed == 0 {
+		seed = -1
+	}
+	params := map[string]interface{}{
+		"prompt":          prompt,
+		"negative_prompt": p.negative,
+		"steps":           p.steps,
+		"cfg_scale":       p.cfgScale,
+		"width":           width,
+		"height":          height,
+		"seed":            seed,
+	}
+	endpoint := "/sdapi/v1/txt2img"
+	if init != nil {
+		endpoint = "/sdapi/v1/img2img"
+		params["init_images"] = []string{init.InlineData.Data}
+		params["denoising_strength"] = denoise
+		if mask != nil {
+			params["mask"] = mask.Data
+			params["inpainting_fill"] = 1 // Start the region from the original pixels
+			params["mask_blur"] = 4
+		}
+	}
+
+	jsonData, err := json.Marshal(params)
+	if err != nil {
+		return 0, nil, fmt.Errorf("error marshaling request: %w", err)
+	}
+	status, body, err := p.do("POST", endpoint, "application/json", jsonData)
+	if err != nil {
+		return 0, nil, err
+	}
+	if status != http.StatusOK {
+		return status, sdErrorBody(body), nil
+	}
+
+	var resp struct {
+		Images []string `json:"images"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil || len(resp.Images) == 0 {
+		return 0, nil, fmt.Errorf("unexpected sd response: %s", truncateBody(body))
+	}
+	return status, imageResponse("image/png", resp.Images[0]), nil
+}
+
+// comfy fills the workflow placeholders, queues it and waits for its first
+// output image. A zero seed is replaced by a random one. Workflows without a
+// {{mask}} placeholder ignore inpainting masks; the edit is still confined to
+// the region when it is composited over the original.
+func (p *sdProvider) comfy(prompt string, init *BlobPart, mask *InlineData, seed int64, denoise float64) (int, []byte, error) {
+	if seed == 0 {
+		seed = rand.Int63n(1 << 32)
+	}
+	workflow := p.workflow
+	if strings.Contains(workflow, `"{{image}}"`) {
+		if init == nil {
+			return 0, nil, fmt.Errorf("the ComfyUI workflow needs an {{image}} but the request has no reference image")
+		}
+		name, err := p.comfyUpload(init)
+		if err != nil {
+			return 0, nil, err
+		}
+		workflow = strings.ReplaceAll(workflow, `"{{image}}"`, jsonString(name))
+	}
+	if strings.Contains(workflow, `"{{mask}}"`) {
+		if mask == nil {
+			return 0, nil, fmt.Errorf("the ComfyUI workflow needs a {{mask}} but the request has no inpainting mask")
+		}
+		name, err := p.comfyUpload(&BlobPart{InlineData: *mask})
+		if err != nil {
+			return 0, nil, err
+		}
+		workflow = strings.ReplaceAll(workflow, `"{{mask}}"`, jsonString(name))
+	}
+	workflow = strings.NewReplacer(
+		`"{{prompt}}"`, jsonString(prompt),
+		`"{{negative_prompt}}"`, jsonString(p.negative),
+		`"{{seed}}"`, fmt.Sprint(seed),
+		`"{{denoise}}"`, fmt.Sprint(denoise),
+	).Replace(workflow)
+
+	var graph json.RawMessage
+	if err := json.Unmarshal([]byte(workflow), &graph); err != nil {
+		return 0, nil, fmt.Errorf("invalid ComfyUI workflow: %w", err)
+	}
+	jsonData, err := json.Marshal(map[string]interface{}{"prompt": graph})
+	if err != nil {
+		return 0, nil, fmt.Errorf("error marshaling request: %w", err)
+	}
+	status, body, err := p.do("POST", "/prompt", "application/json", jsonData)
+	if err != nil {
+		return 0, nil, err
+	}
+	if status != http.StatusOK {
+		return status, sdErrorBody(body), nil
+	}
+	var queued struct {
+		PromptID string `json:"prompt_id"`
+	}
+	if err := json.Unmarshal(body, &queued); err != nil || queued.PromptID == "" {
+		return 0, nil, fmt.Errorf("unexpected ComfyUI response: %s", truncateBody(body))
+	}
+
+	image, err := p.comfyWait(queued.PromptID)
+	if err != nil {
+		return 0, nil, err
+	}
+	query := url.Values{"filename": {image.Filename}, "subfolder": {image.Subfolder}, "type": {image.Type}}
+	status, data, err := p.do("GET", "/view?"+query.Encode(), "", nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	if status != http.StatusOK {
+		return status, data, nil
+	}
+	return status, imageResponse("image/png", base64.StdEncoding.EncodeToString(data)), nil
+}
+
+type comfyImage struct {
+	Filename  string `json:"filename"`
+	Subfolder string `json:"subfolder"`
+	Type      string `json:"type"`
+}
+
+// comfyWait polls the history of a queued prompt until it has finished
+func (p *sdProvider) comfyWait(promptID string) (*comfyImage, error) {
+	deadline := time.Now().Add(p.httpClient.Timeout)
+	for time.Now().Before(deadline) {
+		status, body, err := p.do("GET", "/history/"+promptID, "", nil)
+		if err != nil {
+			return nil, err
+		}
+		if status != http.StatusOK {
+			return nil, fmt.Errorf("ComfyUI history returned status %d", status)
+		}
+
+		var history map[string]struct {
+			Status struct {
+				StatusStr string `json:"status_str"`
+				Completed bool   `json:"completed"`
+			} `json:"status"`
+			Outputs map[string]struct {
+				Images []comfyImage `json:"images"`
+			} `json:"outputs"`
+		}
+		if err := json.Unmarshal(body, &history); err != nil {
+			return nil, fmt.Errorf("unexpected ComfyUI history: %s", truncateBody(body))
+		}
+		if entry, ok := history[promptID]; ok {
+			if entry.Status.StatusStr == "error" {
+				return nil, fmt.Errorf("ComfyUI workflow failed")
+			}
+			if entry.Status.Completed {
+				nodes := make([]string, 0, len(entry.Outputs))
+				for node := range entry.Outputs {
+					nodes = append(nodes, node)
+				}
+				sort.Strings(nodes)
+				for _, node := range nodes {
+					if images := entry.Outputs[node].Images; len(images) > 0 {
+						return &images[0], nil
+					}
+				}
+				return nil, fmt.Errorf("ComfyUI workflow produced no image")
+			}
+		}
+		time.Sleep(comfyPollInterval)
+	}
+	return nil, fmt.Errorf("timed out waiting for ComfyUI prompt %s", promptID)
+}
+
+// comfyUpload uploads a reference image to ComfyUI's input folder
+func (p *sdProvider) comfyUpload(image *BlobPart) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(image.InlineData.Data)
+	if err != nil {
+		return "", fmt.Errorf("error decoding image: %w", err)
+	}
+
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	ext := strings.TrimPrefix(image.InlineData.MimeType, "image/")
+	part, err := writer.CreateFormFile("image", fmt.Sprintf("img-cli-%d.%s", time.Now().UnixNano(), ext))
+	if err != nil {
+		return "", err
+	}
+	if _, err := part.Write(data); err != nil {
+		return "", err
+	}
+	writer.WriteField("overwrite", "true")
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	status, body, err := p.do("POST", "/upload/image", writer.FormDataContentType(), buf.Bytes())
+	if err != nil {
+		return "", err
+	}
+	var uploaded struct {
+		Name      string `json:"name"`
+		Subfolder string `json:"subfolder"`
+	}
+	if status != http.StatusOK || json.Unmarshal(body, &uploaded) != nil || uploaded.Name == "" {
+		return "", fmt.Errorf("ComfyUI image upload failed (status %d): %s", status, truncateBody(body))
+	}
+	if uploaded.Subfolder != "" {
+		return uploaded.Subfolder + "/" + uploaded.Name, nil
+	}
+	return uploaded.Name, nil
+}
+
+func (p *sdProvider) do(method, path, contentType string, data []byte) (int, []byte, error) {
+	req, err := http.NewRequest(method, p.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return 0, nil, fmt.Errorf("error creating request: %w", err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("error reading response: %w", err)
+	}
+	return resp.StatusCode, body, nil
+}
+
+// sdInstructionWords mark prompt lines that instruct the model rather than
+// describe the image; Stable Diffusion would render them as noise
+var sdInstructionWords = regexp.MustCompile(`\b(MUST|EXACT|EXACTLY|CRITICAL|ONLY|NOT|DO NOT|IDENTICAL|IMPORTANT|ABSOLUTE|REMINDER|NOTE|THIS|THEM|SAME|ARE)\b`)
+
+// sdInstructionPhrases mark lines about the reference images, which img2img
+// handles by starting from the subject photo, and about the prompt itself
+var sdInstructionPhrases = []string{"identity", "same person", "source portrait", "reference", "subject image", "person image",
+	"provided person", "the image provided", " above", " below"}
+
+// sdSectionLabel matches an upper-case section label such as "HAIR COLOR:" or
+// "- POSE (MUST MATCH):" at the start of a line
+var sdSectionLabel = regexp.MustCompile(`^[-\d.\s]*[^a-z:]*[A-Z][^a-z:]*:\s*`)
+
+// sdPrompt translates an instruction-heavy Gemini prompt into a descriptive
+// Stable Diffusion prompt: section labels, rules and identity instructions are
+// dropped and the descriptions that remain are joined into one line
+func sdPrompt(text string) string {
+	var kept []string
+	for _, line := range strings.Split(text, "\n") {
+		if sdInstructionWords.MatchString(line) {
+			continue
+		}
+		line = sdSectionLabel.ReplaceAllString(strings.TrimSpace(line), "")
+		line = strings.TrimSpace(strings.TrimLeft(line, "-•*0123456789. "))
+		if line == "" || strings.Trim(line, "=") == "" {
+			continue
+		}
+		lower := strings.ToLower(line)
+		instruction := false
+		for _, phrase := range sdInstructionPhrases {
+			if strings.Contains(lower, phrase) {
+				instruction = true
+				break
+			}
+		}
+		if instruction {
+			continue
+		}
+		kept = append(kept, strings.TrimRight(line, ".,;: "))
+	}
+
+	prompt := strings.Join(append([]string{"photo of a person"}, kept...), ", ")
+	if len(prompt) > sdPromptLimit {
+		cut := strings.LastIndex(prompt[:sdPromptLimit], ",")
+		if cut <= 0 {
+			cut = sdPromptLimit
+		}
+		prompt = prompt[:cut]
+	}
+	return prompt
+}
+
+// sdErrorBody reshapes an A1111 or ComfyUI error body like a Gemini error
+func sdErrorBody(body []byte) []byte {
+	var errResp struct {
+		Error  interface{} `json:"error"`
+		Detail interface{} `json:"detail"`
+	}
+	if err := json.Unmarshal(body, &errResp); err != nil {
+		return body
+	}
+	message := errResp.Detail
+	if message == nil {
+		message = errResp.Error
+	}
+	if m, ok := message.(map[string]interface{}); ok && m["message"] != nil {
+		message = m["message"]
+	}
+	if message == nil {
+		return body
+	}
+	reshaped, _ := json.Marshal(map[string]interface{}{
+		"error": map[string]interface{}{"message": fmt.Sprint(message)},
+	})
+	return reshaped
+}
+
+func jsonString(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
+
+func truncateBody(body []byte) string {
+	if len(body) > 300 {
+		return string(body[:300]) + "..."
+	}
+	return string(body)
+}
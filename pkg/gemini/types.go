@@ -1,17 +1,30 @@
 package gemini
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"img-cli/pkg/config"
+)
 
 type Request struct {
 	Contents         []Content         `json:"contents"`
 	GenerationConfig *GenerationConfig `json:"generationConfig,omitempty"`
+	Operation        Operation         `json:"-"` // Selects the rate limiter; defaults to OpAnalyze
 }
 
 type GenerationConfig struct {
-	ResponseMimeType string  `json:"responseMimeType,omitempty"`
-	Temperature      float64 `json:"temperature,omitempty"`
-	TopK             int     `json:"topK,omitempty"`
-	TopP             float64 `json:"topP,omitempty"`
+	ResponseMimeType string       `json:"responseMimeType,omitempty"`
+	Temperature      float64      `json:"temperature,omitempty"`
+	TopK             int          `json:"topK,omitempty"`
+	TopP             float64      `json:"topP,omitempty"`
+	ImageConfig      *ImageConfig `json:"imageConfig,omitempty"`
+	Seed             int64        `json:"seed,omitempty"` // Same prompt and seed reproduce the image where the backend supports it (0 = random)
+	Strength         float64      `json:"-"`              // Image-to-image strength (--strength) for providers with a native control; Gemini gets it as prompt phrasing (0 = provider default)
+	Mask             *InlineData  `json:"-"`              // Inpainting mask, a PNG the size of the first image with the region to edit in white; Gemini gets it as an extra image
+}
+
+// ImageConfig shapes generated images
+type ImageConfig struct {
+	AspectRatio string `json:"aspectRatio,omitempty"` // e.g. "9:16" or "16:9"
 }
 
 type Content struct {
@@ -32,8 +45,15 @@ type BlobPart struct {
 }
 
 type Response struct {
-	Candidates []Candidate `json:"candidates"`
-	Error      *APIError   `json:"error,omitempty"`
+	Candidates    []Candidate    `json:"candidates"`
+	UsageMetadata *UsageMetadata `json:"usageMetadata,omitempty"`
+	Error         *APIError      `json:"error,omitempty"`
+}
+
+// UsageMetadata counts the tokens of a request and its answer
+type UsageMetadata struct {
+	PromptTokenCount     int `json:"promptTokenCount"`
+	CandidatesTokenCount int `json:"candidatesTokenCount"`
 }
 
 type Candidate struct {
@@ -102,4 +122,25 @@ type VisualStyle struct {
 type ImageData struct {
 	Data     []byte
 	MimeType string
-}
\ No newline at end of file
+}
+// AnalyzerConfig is the shared generation config for image analysis requests
+var AnalyzerConfig = &GenerationConfig{
+	Temperature: 0.3,
+	TopK:        20,
+	TopP:        0.8,
+}
+
+// WithSampling returns a copy of the config with the set fields of s in place
+// of its own
+func (c GenerationConfig) WithSampling(s config.SamplingConfig) *GenerationConfig {
+	if s.Temperature > 0 {
+		c.Temperature = s.Temperature
+	}
+	if s.TopK > 0 {
+		c.TopK = s.TopK
+	}
+	if s.TopP > 0 {
+		c.TopP = s.TopP
+	}
+	return &c
+}
@@ -0,0 +1,59 @@
+package gemini
+
+import (
+	"encoding/json"
+	"img-cli/pkg/usage"
+)
+
+// recordUsage appends a successful call to the usage ledger with the tokens
+// and images of its answer
+func recordUsage(provider Provider, request Request, body []byte) {
+	var resp struct {
+		Candidates []struct {
+			Content struct {
+				Parts []struct {
+					InlineData *InlineData `json:"inlineData"`
+				} `json:"parts"`
+			} `json:"content"`
+		} `json:"candidates"`
+		UsageMetadata *UsageMetadata `json:"usageMetadata"`
+	}
+	json.Unmarshal(body, &resp) // An answer that doesn't parse is still a call
+
+	entry := usage.Entry{Type: request.Operation.String(), Provider: provider.Name(), Model: provider.Model()}
+	for _, candidate := range resp.Candidates {
+		for _, part := range candidate.Content.Parts {
+			if part.InlineData != nil {
+				entry.Images++
+			}
+		}
+	}
+	recordTokens(entry, resp.UsageMetadata)
+}
+
+// recordTokens appends a call to the usage ledger with the token counts of
+// its answer, when the provider sent them
+func recordTokens(entry usage.Entry, counts *UsageMetadata) {
+	if counts != nil {
+		entry.InputTokens = counts.PromptTokenCount
+		entry.OutputTokens = counts.CandidatesTokenCount
+	}
+	usage.Record(entry)
+}
+
+// withUsage adds the token counts of a translated answer to its Gemini-format body
+func withUsage(body []byte, counts *UsageMetadata) []byte {
+	if counts == nil {
+		return body
+	}
+	var resp map[string]interface{}
+	if json.Unmarshal(body, &resp) != nil {
+		return body
+	}
+	resp["usageMetadata"] = counts
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return body
+	}
+	return data
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"img-cli/pkg/gemini"
+	"img-cli/pkg/workspace"
 	"os"
 	"path/filepath"
 	"strings"
@@ -28,15 +29,20 @@ func (a *ArtStyleGenerator) Generate(params GenerateParams) (*GenerateResult, er
 	// 2. Image-to-image style transfer
 
 	var request gemini.Request
+	var err error
 
 	if params.ImagePath != "" && !strings.HasSuffix(params.ImagePath, ".json") {
 		// Image-to-image style transfer mode
-		request = a.createImageStyleTransferRequest(params)
+		request, err = a.createImageStyleTransferRequest(params)
 	} else {
 		// Text-to-image with style mode
-		request = a.createTextToImageWithStyleRequest(params)
+		request, err = a.createTextToImageWithStyleRequest(params)
+	}
+	if err != nil {
+		return nil, err
 	}
 
+	request.Operation = gemini.OpGenerate
 	resp, err := a.client.SendRequest(request)
 	if err != nil {
 		return nil, fmt.Errorf("error generating styled image: %w", err)
@@ -60,7 +66,7 @@ func (a *ArtStyleGenerator) Generate(params GenerateParams) (*GenerateResult, er
 		now := time.Now()
 		dateFolder := now.Format("2006-01-02")
 		timestampFolder := now.Format("150405")
-		params.OutputDir = filepath.Join("output", dateFolder, timestampFolder)
+		params.OutputDir = filepath.Join(workspace.OutputPath(), dateFolder, timestampFolder)
 	}
 
 	if err := os.MkdirAll(params.OutputDir, 0755); err != nil {
@@ -97,6 +103,9 @@ func (a *ArtStyleGenerator) Generate(params GenerateParams) (*GenerateResult, er
 	if err := os.WriteFile(outputPath, imageData.Data, 0644); err != nil {
 		return nil, fmt.Errorf("error saving image: %w", err)
 	}
+	if err := conformOutput(outputPath, params.Aspect, params.Resolution); err != nil {
+		return nil, fmt.Errorf("error resizing image: %w", err)
+	}
 
 	return &GenerateResult{
 		Message:    "Styled image generated successfully",
@@ -104,7 +113,7 @@ func (a *ArtStyleGenerator) Generate(params GenerateParams) (*GenerateResult, er
 	}, nil
 }
 
-func (a *ArtStyleGenerator) createTextToImageWithStyleRequest(params GenerateParams) gemini.Request {
+func (a *ArtStyleGenerator) createTextToImageWithStyleRequest(params GenerateParams) (gemini.Request, error) {
 	parts := []interface{}{}
 
 	// Add style reference image if provided
@@ -121,7 +130,11 @@ func (a *ArtStyleGenerator) createTextToImageWithStyleRequest(params GeneratePar
 	}
 
 	// Build the prompt
-	promptText := a.buildTextToImagePrompt(params)
+	format, err := formatSection(params.Aspect)
+	if err != nil {
+		return gemini.Request{}, err
+	}
+	promptText := a.buildTextToImagePrompt(params) + format
 	parts = append(parts, gemini.TextPart{Text: promptText})
 
 	return gemini.Request{
@@ -132,24 +145,18 @@ func (a *ArtStyleGenerator) createTextToImageWithStyleRequest(params GeneratePar
 			Temperature: 0.8,
 			TopK:        40,
 			TopP:        0.95,
+			ImageConfig: imageConfig(params.Aspect),
 		},
-	}
+	}, nil
 }
 
-func (a *ArtStyleGenerator) createImageStyleTransferRequest(params GenerateParams) gemini.Request {
+func (a *ArtStyleGenerator) createImageStyleTransferRequest(params GenerateParams) (gemini.Request, error) {
 	parts := []interface{}{}
 
 	// Load the input image
 	imageData, mimeType, err := gemini.LoadImageAsBase64(params.ImagePath)
 	if err != nil {
-		// Return request with error in prompt
-		return gemini.Request{
-			Contents: []gemini.Content{
-				{Parts: []interface{}{
-					gemini.TextPart{Text: fmt.Sprintf("Error loading image: %v", err)},
-				}},
-			},
-		}
+		return gemini.Request{}, fmt.Errorf("error loading image: %w", err)
 	}
 
 	parts = append(parts, gemini.BlobPart{
@@ -173,7 +180,11 @@ func (a *ArtStyleGenerator) createImageStyleTransferRequest(params GenerateParam
 	}
 
 	// Build the prompt
-	promptText := a.buildImageStyleTransferPrompt(params)
+	format, err := formatSection(params.Aspect)
+	if err != nil {
+		return gemini.Request{}, err
+	}
+	promptText := a.buildImageStyleTransferPrompt(params) + format
 	parts = append(parts, gemini.TextPart{Text: promptText})
 
 	return gemini.Request{
@@ -184,8 +195,9 @@ func (a *ArtStyleGenerator) createImageStyleTransferRequest(params GenerateParam
 			Temperature: 0.7,
 			TopK:        35,
 			TopP:        0.9,
+			ImageConfig: imageConfig(params.Aspect),
 		},
-	}
+	}, nil
 }
 
 func (a *ArtStyleGenerator) buildTextToImagePrompt(params GenerateParams) string {
@@ -246,7 +258,7 @@ CRITICAL INSTRUCTIONS:
 - Match the line work, shading, textures, and overall aesthetic
 - The result should look like the original subject was illustrated by the artist of the style reference
 
-Generate a high-quality transformation that perfectly applies the reference style while preserving the original content.`)
+Generate a high-quality transformation that perfectly applies the reference style while preserving the original content.`, styleDescription)
 	}
 
 	if styleDescription != "" {
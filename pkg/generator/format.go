@@ -0,0 +1,93 @@
+package generator
+
+import (
+	"fmt"
+	"img-cli/pkg/gemini"
+	"img-cli/pkg/imaging"
+	"img-cli/pkg/logger"
+	"img-cli/pkg/prompts"
+	"path/filepath"
+)
+
+// ImageFormat is the shape prompts describe: "9:16 vertical portrait" unless
+// --aspect asks for another one
+type ImageFormat struct {
+	Ratio       string // e.g. "16:9"
+	Shape       string // "portrait", "square" or "landscape"
+	Orientation string // "vertical portrait", "square" or "horizontal landscape"
+}
+
+// NewImageFormat describes an aspect ratio for prompts. Aspects are validated
+// when flags are parsed; anything else falls back to the default.
+func NewImageFormat(aspect string) ImageFormat {
+	a, err := imaging.ParseAspect(aspect)
+	if err != nil {
+		a, _ = imaging.ParseAspect(imaging.DefaultAspect)
+	}
+	format := ImageFormat{Ratio: a.String(), Shape: "square", Orientation: a.Orientation()}
+	if a.W < a.H {
+		format.Shape = "portrait"
+	} else if a.W > a.H {
+		format.Shape = "landscape"
+	}
+	return format
+}
+
+// formatSection is the prompt paragraph that asks for the --aspect shape, ""
+// without --aspect
+func formatSection(aspect string) (string, error) {
+	if aspect == "" {
+		return "", nil
+	}
+	return prompts.Render("format", NewImageFormat(aspect))
+}
+
+// imageConfig asks the provider for the requested aspect ratio. Without
+// --aspect the request is left as it always was.
+func imageConfig(aspect string) *gemini.ImageConfig {
+	if aspect == "" {
+		return nil
+	}
+	return &gemini.ImageConfig{AspectRatio: NewImageFormat(aspect).Ratio}
+}
+
+// conformOutput makes a saved image match --aspect and --resolution. Models
+// don't always return the requested shape, so an image off by more than a
+// rounding error is center-cropped; --resolution then scales it to the exact
+// pixel size. Without either flag the image is left untouched.
+func conformOutput(path, aspect string, resolution int) error {
+	if aspect == "" && resolution <= 0 {
+		return nil
+	}
+	a, err := imaging.ParseAspect(aspect)
+	if err != nil {
+		return err
+	}
+
+	w, h, err := imaging.Size(path)
+	if err != nil {
+		return err
+	}
+	tw, th := w, h
+	if !a.Matches(w, h) {
+		logger.Warn("Generated image has the wrong aspect ratio, cropping",
+			"image", filepath.Base(path), "size", fmt.Sprintf("%dx%d", w, h), "aspect", a.String())
+		tw, th = 0, 0
+	}
+	if resolution > 0 {
+		tw, th = a.Dimensions(resolution)
+	}
+	if tw == w && th == h {
+		return nil
+	}
+
+	img, err := imaging.Load(path)
+	if err != nil {
+		return err
+	}
+	cropped := imaging.CropToAspect(img, a)
+	if tw == 0 {
+		return imaging.Save(path, cropped)
+	}
+	return imaging.Save(path, imaging.Resize(cropped, tw, th))
+}
@@ -0,0 +1,151 @@
+package generator
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image/png"
+	"img-cli/pkg/config"
+	"img-cli/pkg/gemini"
+	"img-cli/pkg/imaging"
+	"img-cli/pkg/output"
+	"img-cli/pkg/prompts"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// InpaintParameters are the sampling parameters of inpainting; a low
+// temperature keeps the edit close to the description
+var InpaintParameters = gemini.GenerationConfig{
+	Temperature: 0.4,
+	TopP:        0.9,
+	TopK:        32,
+}
+
+// InpaintGenerator edits only the masked region of an image. The model gets
+// the mask with the photo, and its output is composited over the original
+// through the mask, so every pixel outside the region stays identical
+// whatever the model changed.
+type InpaintGenerator struct {
+	BaseGenerator
+	client *gemini.Client
+}
+
+func NewInpaintGenerator(client *gemini.Client) *InpaintGenerator {
+	return &InpaintGenerator{
+		BaseGenerator: BaseGenerator{Type: "inpaint"},
+		client:        client,
+	}
+}
+
+// inpaintPromptData is what the inpaint template sees
+type inpaintPromptData struct {
+	Edit  string
+	Avoid []string
+}
+
+func (g *InpaintGenerator) Generate(params GenerateParams) (*GenerateResult, error) {
+	source, err := imaging.Load(params.ImagePath)
+	if err != nil {
+		return nil, fmt.Errorf("error loading image: %w", err)
+	}
+	w, h := source.Bounds().Dx(), source.Bounds().Dy()
+	mask, err := imaging.LoadMask(params.Mask, w, h)
+	if err != nil {
+		return nil, fmt.Errorf("error loading mask: %w", err)
+	}
+	if imaging.MaskCoverage(mask) == 0 {
+		return nil, fmt.Errorf("the mask %s marks nothing to edit (white is the region to edit)", filepath.Base(params.Mask))
+	}
+	var maskPNG bytes.Buffer
+	if err := png.Encode(&maskPNG, mask); err != nil {
+		return nil, fmt.Errorf("error encoding mask: %w", err)
+	}
+	imageData, mimeType, err := gemini.LoadImageAsBase64(params.ImagePath)
+	if err != nil {
+		return nil, fmt.Errorf("error loading image: %w", err)
+	}
+
+	prompt, err := prompts.Render("inpaint", inpaintPromptData{Edit: params.Prompt, Avoid: params.Avoid})
+	if err != nil {
+		return nil, err
+	}
+	strength, err := StrengthSection(params.Strength)
+	if err != nil {
+		return nil, err
+	}
+	prompt += strength
+	if params.DebugPrompt {
+		output.Println("\n[DEBUG] Inpaint Generation Prompt:")
+		output.Println("=========================================")
+		output.Printf("Image: %s\nMask: %s\n%s\n", filepath.Base(params.ImagePath), filepath.Base(params.Mask), prompt)
+		output.Println("=========================================")
+		output.Println()
+	}
+
+	genConfig := InpaintParameters.WithSampling(config.SamplingConfig{Temperature: params.Temperature, TopK: params.TopK, TopP: params.TopP})
+	genConfig.Seed = params.Seed
+	genConfig.Strength = params.Strength
+	genConfig.Mask = &gemini.InlineData{MimeType: "image/png", Data: base64.StdEncoding.EncodeToString(maskPNG.Bytes())}
+	request := gemini.Request{
+		Contents: []gemini.Content{
+			{
+				Parts: []interface{}{
+					gemini.BlobPart{
+						InlineData: gemini.InlineData{
+							MimeType: mimeType,
+							Data:     imageData,
+						},
+					},
+					gemini.TextPart{Text: prompt},
+				},
+			},
+		},
+		GenerationConfig: genConfig,
+		Operation:        gemini.OpGenerate,
+	}
+	rawResp, origin, err := g.client.SendRequestRawFrom(request)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %w", err)
+	}
+	imageBytes, _, err := gemini.ExtractGeneratedImage(rawResp)
+	if err != nil {
+		return nil, fmt.Errorf("error extracting image: %w", err)
+	}
+
+	// Models return their own sizes, so bring the edit to the original's
+	// shape and size before pasting the region back
+	edited, err := imaging.Decode(imageBytes)
+	if err != nil {
+		return nil, err
+	}
+	edited = imaging.Resize(imaging.CropToAspect(edited, imaging.Aspect{W: w, H: h}), w, h)
+	feather := max(2, min(w, h)/200)
+	var out bytes.Buffer
+	if err := png.Encode(&out, imaging.Composite(source, edited, mask, feather)); err != nil {
+		return nil, fmt.Errorf("error encoding image: %w", err)
+	}
+
+	baseName := strings.TrimSuffix(filepath.Base(params.ImagePath), filepath.Ext(params.ImagePath))
+	timestamp := time.Now().Format("20060102_150405")
+	outputPath := filepath.Join(params.OutputDir, fmt.Sprintf("%s_inpaint%s_%s.png", baseName, seedFileTag(params.Seed), timestamp))
+	if err := os.MkdirAll(params.OutputDir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating output directory: %w", err)
+	}
+	outputPath, err = saveOutput(outputPath, out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("error saving image: %w", err)
+	}
+
+	return &GenerateResult{
+		Type:       g.Type,
+		OutputPath: outputPath,
+		Message:    "Edited the masked region",
+		Prompt:     prompt,
+		Parameters: genConfig,
+		Provider:   origin.Provider,
+		Model:      origin.Model,
+	}, nil
+}
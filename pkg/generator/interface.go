@@ -1,6 +1,9 @@
 package generator
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"img-cli/pkg/gemini"
+)
 
 type Generator interface {
 	Generate(params GenerateParams) (*GenerateResult, error)
@@ -17,20 +20,35 @@ type GenerateParams struct {
 	StyleReference  string          // Path to style reference image
 	OutfitReference string          // Path to outfit reference image (for --send-original)
 	OutputDir       string
-	Temperature     float64
+	Temperature     float64  // Sampling parameters (--temperature, --top-k, --top-p); 0 keeps the built-in value
+	TopK            int
+	TopP            float64
 	DebugPrompt     bool
-	OutfitSource    string // Name of outfit source file (without extension)
-	StyleSource     string // Name of style source file (without extension)
-	HairSource      string // Name of hair source file (without extension)
-	VariationIndex  int    // Which variation this is (1, 2, 3, etc.)
-	TotalVariations int    // Total number of variations being generated
-	SendOriginal    bool   // Whether to include the outfit reference image in the request
+	OutfitSource    string   // Name of outfit source file (without extension)
+	StyleSource     string   // Name of style source file (without extension)
+	HairSource      string   // Name of hair source file (without extension)
+	VariationIndex  int      // Which variation this is (1, 2, 3, etc.)
+	TotalVariations int      // Total number of variations being generated
+	SendOriginal    bool     // Whether to include the outfit reference image in the request
+	SaveToOutputDir bool     // Style guide: save into OutputDir instead of the styles folder
+	Avoid           []string // Elements that must not appear in the image (--avoid)
+	Keep            []string // Traits of a registered subject that must not change
+	Aspect          string   // Aspect ratio such as "16:9" (--aspect); "" keeps the default 9:16
+	Resolution      int      // Long side of the saved image in pixels (--resolution); 0 keeps the model's size
+	Seed            int64    // Generation seed (--seed); 0 leaves it to the backend
+	Strength        float64  // How much the source image may change, 0-1 (--strength); 0 leaves it to the model
+	Mask            string   // Inpainting mask of ImagePath (--mask): white marks the region to edit
+	NameTemplate    string   // Output name template (--name-template); "" keeps the built-in name
 }
 
 type GenerateResult struct {
-	Type       string `json:"type"`
-	OutputPath string `json:"output_path"`
-	Message    string `json:"message"`
+	Type       string                   `json:"type"`
+	OutputPath string                   `json:"output_path"`
+	Message    string                   `json:"message"`
+	Prompt     string                   `json:"prompt,omitempty"`     // Full prompt sent with the images
+	Parameters *gemini.GenerationConfig `json:"parameters,omitempty"` // Sampling parameters of the request
+	Provider   string                   `json:"provider,omitempty"`   // Provider that answered, the fallback one if the main one failed
+	Model      string                   `json:"model,omitempty"`      // Model of that provider
 }
 
 type BaseGenerator struct {
@@ -39,4 +57,4 @@ type BaseGenerator struct {
 
 func (b *BaseGenerator) GetType() string {
 	return b.Type
-}
\ No newline at end of file
+}
@@ -2,14 +2,23 @@ package generator
 
 import (
 	"fmt"
+	"img-cli/pkg/analyzer"
+	"img-cli/pkg/config"
 	"img-cli/pkg/gemini"
 	"img-cli/pkg/models"
-	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 )
 
+// ModularParameters are the sampling parameters of modular generations
+var ModularParameters = gemini.GenerationConfig{
+	Temperature: 0.8,
+	TopP:        0.95,
+	TopK:        40,
+}
+
 type ModularGenerator struct {
 	BaseGenerator
 	client *gemini.Client
@@ -21,6 +30,24 @@ type ModularRequest struct {
 	Components    *models.ModularComponents
 	SendOriginals bool
 	OutputDir     string
+	Tag           string // Extra file name part, e.g. the ambient of a sweep
+	Aspect        string // Aspect ratio asked for with --aspect ("" = default 9:16)
+	Resolution    int    // Long side of the saved image in pixels (0 = as generated)
+	Seed          int64  // Generation seed (0 = left to the backend)
+	Sampling      config.SamplingConfig // --temperature, --top-k and --top-p
+	Strength      float64 // Image-to-image strength for providers with a native control (0 = provider default)
+	Variation     int     // Which variation of the combination this is, for name templates
+	NameTemplate  string  // Output name template (--name-template); "" keeps the built-in name
+}
+
+// Parameters returns the sampling parameters of the request: ModularParameters
+// with the request's sampling overrides, seed and strength applied
+func (req ModularRequest) Parameters() gemini.GenerationConfig {
+	params := ModularParameters.WithSampling(req.Sampling)
+	params.ImageConfig = imageConfig(req.Aspect)
+	params.Seed = req.Seed
+	params.Strength = req.Strength
+	return *params
 }
 
 func NewModularGenerator(client *gemini.Client) *ModularGenerator {
@@ -30,11 +57,11 @@ func NewModularGenerator(client *gemini.Client) *ModularGenerator {
 	}
 }
 
-func (g *ModularGenerator) Generate(req ModularRequest) (string, error) {
+func (g *ModularGenerator) Generate(req ModularRequest) (*GenerateResult, error) {
 	// Load subject image
 	subjectData, subjectMime, err := gemini.LoadImageAsBase64(req.SubjectPath)
 	if err != nil {
-		return "", fmt.Errorf("error loading subject image: %w", err)
+		return nil, fmt.Errorf("error loading subject image: %w", err)
 	}
 
 	// Build request parts
@@ -175,6 +202,54 @@ func (g *ModularGenerator) Generate(req ModularRequest) (string, error) {
 				})
 			}
 		}
+
+		// Add pose reference if available
+		if req.Components.Pose != nil && req.Components.Pose.ImagePath != "" {
+			poseData, poseMime, err := gemini.LoadImageAsBase64(req.Components.Pose.ImagePath)
+			if err == nil {
+				parts = append(parts, gemini.BlobPart{
+					InlineData: gemini.InlineData{
+						MimeType: poseMime,
+						Data:     poseData,
+					},
+				})
+			}
+		}
+
+		// Add background reference if available
+		if req.Components.Background != nil && req.Components.Background.ImagePath != "" {
+			bgData, bgMime, err := gemini.LoadImageAsBase64(req.Components.Background.ImagePath)
+			if err == nil {
+				parts = append(parts, gemini.BlobPart{
+					InlineData: gemini.InlineData{
+						MimeType: bgMime,
+						Data:     bgData,
+					},
+				})
+			}
+		}
+
+		// Add references of registered components, in a stable order
+		names := make([]string, 0, len(req.Components.Extra))
+		for name := range req.Components.Extra {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			component := req.Components.Extra[name]
+			if component == nil || component.ImagePath == "" {
+				continue
+			}
+			data, mime, err := gemini.LoadImageAsBase64(component.ImagePath)
+			if err == nil {
+				parts = append(parts, gemini.BlobPart{
+					InlineData: gemini.InlineData{
+						MimeType: mime,
+						Data:     data,
+					},
+				})
+			}
+		}
 	}
 
 	// Add the prompt text
@@ -183,28 +258,26 @@ func (g *ModularGenerator) Generate(req ModularRequest) (string, error) {
 	})
 
 	// Create the API request
+	params := req.Parameters()
 	request := gemini.Request{
 		Contents: []gemini.Content{
 			{
 				Parts: parts,
 			},
 		},
-		GenerationConfig: &gemini.GenerationConfig{
-			Temperature: 0.8,
-			TopP:        0.95,
-			TopK:        40,
-		},
+		GenerationConfig: &params,
 	}
 
 	// Generate the image
-	rawResp, err := g.client.SendRequestRaw(request)
+	request.Operation = gemini.OpGenerate
+	rawResp, origin, err := g.client.SendRequestRawFrom(request)
 	if err != nil {
-		return "", fmt.Errorf("error sending request: %w", err)
+		return nil, fmt.Errorf("error sending request: %w", err)
 	}
 
 	imageBytes, imageMimeType, err := gemini.ExtractGeneratedImage(rawResp)
 	if err != nil {
-		return "", fmt.Errorf("error extracting image: %w", err)
+		return nil, fmt.Errorf("error extracting image: %w", err)
 	}
 
 	extension := ".png"
@@ -217,7 +290,8 @@ func (g *ModularGenerator) Generate(req ModularRequest) (string, error) {
 	}
 
 	// Generate output filename
-	timestamp := time.Now().Format("20060102_150405")
+	now := time.Now()
+	timestamp := now.Format("20060102_150405")
 	subjectName := filepath.Base(req.SubjectPath)
 	subjectName = subjectName[:len(subjectName)-len(filepath.Ext(subjectName))]
 
@@ -238,25 +312,67 @@ func (g *ModularGenerator) Generate(req ModularRequest) (string, error) {
 		filenameParts = append(filenameParts, styleName)
 	}
 
+	if req.Tag != "" {
+		filenameParts = append(filenameParts, req.Tag)
+	}
+
 	// Always add subject name
-	filenameParts = append(filenameParts, subjectName)
+	filenameParts = append(filenameParts, subjectName+seedFileTag(req.Seed))
 
 	// Add timestamp
 	filenameParts = append(filenameParts, timestamp)
 
-	outputFilename := strings.Join(filenameParts, "_") + extension
-	outputPath := filepath.Join(req.OutputDir, outputFilename)
-
-	// Ensure output directory exists
-	if err := os.MkdirAll(req.OutputDir, 0755); err != nil {
-		return "", fmt.Errorf("error creating output directory: %w", err)
+	// Apply --name-template and create the output folders
+	outputPath, err := outputName(req.OutputDir, req.NameTemplate, strings.Join(filenameParts, "_"), extension, req.nameFields(subjectName, now))
+	if err != nil {
+		return nil, err
 	}
 
 	// Save the image
-	if err := os.WriteFile(outputPath, imageBytes, 0644); err != nil {
-		return "", fmt.Errorf("error saving image: %w", err)
+	outputPath, err = saveOutput(outputPath, imageBytes)
+	if err != nil {
+		return nil, fmt.Errorf("error saving image: %w", err)
+	}
+	if err := conformOutput(outputPath, req.Aspect, req.Resolution); err != nil {
+		return nil, fmt.Errorf("error resizing image: %w", err)
 	}
 
-	return outputPath, nil
+	return &GenerateResult{
+		Type:       g.Type,
+		OutputPath: outputPath,
+		Parameters: &params,
+		Provider:   origin.Provider,
+		Model:      origin.Model,
+	}, nil
 }
 
+
+// nameFields are the values --name-template sees for a modular request
+func (req ModularRequest) nameFields(subjectName string, now time.Time) NameFields {
+	fields := NameFields{
+		Subject:   subjectName,
+		Tag:       req.Tag,
+		Variation: req.Variation,
+		Seed:      req.Seed,
+		Time:      now,
+	}
+	if c := req.Components; c != nil {
+		fields.Outfit = componentName(c.Outfit)
+		fields.Style = componentName(c.Style)
+		fields.Components = map[string]string{
+			"hair-style":  componentName(c.HairStyle),
+			"hair-color":  componentName(c.HairColor),
+			"makeup":      componentName(c.Makeup),
+			"expression":  componentName(c.Expression),
+			"accessories": componentName(c.Accessories),
+			"pose":        componentName(c.Pose),
+			"background":  componentName(c.Background),
+		}
+		for name, data := range c.Extra {
+			if _, component, ok := analyzer.LookupComponent(name); ok {
+				fields.Components[component.Flag] = componentName(data)
+			}
+		}
+	}
+	return fields
+}
@@ -0,0 +1,194 @@
+package generator
+
+import (
+	"fmt"
+	"img-cli/pkg/analyzer"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/models"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NameFields are the values an output name template (--name-template) can use
+type NameFields struct {
+	Subject    string
+	Outfit     string
+	Style      string
+	Tag        string            // Extra part such as the ambient of a sweep
+	Components map[string]string // Other modular components by placeholder name, e.g. "hair-color"
+	Variation  int
+	Seed       int64
+	Time       time.Time
+}
+
+// NamePlaceholders lists the placeholders of name templates. Components
+// added with analyzer.Register are placeholders too, named by their flag.
+var NamePlaceholders = []string{
+	"subject", "outfit", "style", "tag",
+	"hair-style", "hair-color", "makeup", "expression", "accessories", "pose", "background",
+	"variation", "seed", "date", "time", "timestamp",
+}
+
+// placeholderPattern matches {name} and {name:width}; the width zero-pads numbers
+var placeholderPattern = regexp.MustCompile(`\{([a-z-]+)(?::(\d+))?\}`)
+
+// ValidateNameTemplate checks that a name template only uses known
+// placeholders and stays inside the output directory
+func ValidateNameTemplate(template string) error {
+	if template == "" {
+		return nil
+	}
+	if filepath.IsAbs(template) || strings.HasPrefix(template, "/") {
+		return errors.ErrInvalidInput("name-template", "must be relative to the output directory")
+	}
+	for _, match := range placeholderPattern.FindAllStringSubmatch(template, -1) {
+		if !isPlaceholder(match[1]) {
+			return errors.ErrInvalidInput("name-template", fmt.Sprintf("unknown placeholder {%s} (use %s)", match[1], placeholderList()))
+		}
+	}
+	if rest := placeholderPattern.ReplaceAllString(template, ""); strings.ContainsAny(rest, "{}") {
+		return errors.ErrInvalidInput("name-template", fmt.Sprintf("malformed placeholder in %q (use {name} or {name:width}, e.g. {variation:3})", template))
+	}
+	for _, segment := range strings.Split(filepath.ToSlash(template), "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return errors.ErrInvalidInput("name-template", fmt.Sprintf("%q has an empty, . or .. folder", template))
+		}
+	}
+	return nil
+}
+
+func isPlaceholder(name string) bool {
+	for _, p := range NamePlaceholders {
+		if p == name {
+			return true
+		}
+	}
+	_, component, ok := analyzer.LookupComponent(name)
+	return ok && component.Flag == name
+}
+
+func placeholderList() string {
+	var names []string
+	for _, p := range NamePlaceholders {
+		names = append(names, "{"+p+"}")
+	}
+	for _, name := range analyzer.Components() {
+		_, component, _ := analyzer.LookupComponent(name)
+		names = append(names, "{"+component.Flag+"}")
+	}
+	return strings.Join(names, ", ")
+}
+
+// Render fills a name template, returning a relative path without extension.
+// Missing components render as "none"; "/" in the template makes subfolders.
+func (f NameFields) Render(template string) string {
+	return placeholderPattern.ReplaceAllStringFunc(template, func(placeholder string) string {
+		match := placeholderPattern.FindStringSubmatch(placeholder)
+		width, _ := strconv.Atoi(match[2])
+		switch match[1] {
+		case "subject":
+			return nameValue(f.Subject)
+		case "outfit":
+			return nameValue(f.Outfit)
+		case "style":
+			return nameValue(f.Style)
+		case "tag":
+			return nameValue(f.Tag)
+		case "variation":
+			return padNumber(int64(max(f.Variation, 1)), width)
+		case "seed":
+			return padNumber(f.Seed, width)
+		case "date":
+			return f.Time.Format("2006-01-02")
+		case "time":
+			return f.Time.Format("150405")
+		case "timestamp":
+			return f.Time.Format("20060102_150405")
+		}
+		return nameValue(f.Components[match[1]])
+	})
+}
+
+// nameValue makes a field safe for a single path segment
+func nameValue(value string) string {
+	if value == "" {
+		return "none"
+	}
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`<>:"/\|?*`, r) || r < ' ' {
+			return '-'
+		}
+		return r
+	}, value)
+}
+
+func padNumber(n int64, width int) string {
+	return fmt.Sprintf("%0*d", width, n)
+}
+
+// outputName returns where a generated image is saved: the default name in
+// dir, or the rendered template relative to dir with its folders created
+func outputName(dir, template, defaultName, extension string, fields NameFields) (string, error) {
+	path := filepath.Join(dir, defaultName+extension)
+	if template != "" {
+		path = filepath.Join(dir, filepath.FromSlash(fields.Render(template))+extension)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("error creating output directory: %w", err)
+	}
+	return path, nil
+}
+
+// OutputRoot returns the output directory of an image saved with a name
+// template, above the subfolders the template made
+func OutputRoot(outputPath, template string) string {
+	dir := filepath.Dir(outputPath)
+	for i := 0; i < strings.Count(filepath.ToSlash(template), "/"); i++ {
+		dir = filepath.Dir(dir)
+	}
+	return dir
+}
+
+// ReferenceName is how a component appears in output names: the base name of
+// its reference image, or a short slug of its text
+func ReferenceName(imagePath, text string) string {
+	if imagePath != "" {
+		return strings.TrimSuffix(filepath.Base(imagePath), filepath.Ext(imagePath))
+	}
+	return slug(text)
+}
+
+// componentName is the ReferenceName of a modular component, "" when absent
+func componentName(c *models.ComponentData) string {
+	if c == nil {
+		return ""
+	}
+	text := c.Text
+	if text == "" {
+		text = c.Description
+	}
+	return ReferenceName(c.ImagePath, text)
+}
+
+// slug turns a description into a short lowercase file name part
+func slug(text string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(text) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+		if b.Len() >= 32 {
+			break
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
@@ -4,9 +4,11 @@ import (
 	"encoding/base64"
 	"fmt"
 	"img-cli/pkg/gemini"
-	"os"
+	"img-cli/pkg/output"
+	"img-cli/pkg/prompts"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 type OutfitGenerator struct {
@@ -40,27 +42,33 @@ func (o *OutfitGenerator) Generate(params GenerateParams) (*GenerateResult, erro
 		}
 	}
 
-	fullPrompt := fmt.Sprintf(`Generate a 9:16 portrait format image of this person wearing EXACTLY the following outfit with PRECISE COLOR ACCURACY:
+	eyewear, err := prompts.Render("eyewear", "keep")
+	if err != nil {
+		return nil, err
+	}
+	format := NewImageFormat(params.Aspect)
+	fullPrompt := fmt.Sprintf(`Generate a %s %s format image of this person wearing EXACTLY the following outfit with PRECISE COLOR ACCURACY:
 %s
 
 CRITICAL REQUIREMENTS:
 - Every color mentioned must be reproduced EXACTLY as specified (e.g., if a white collar is mentioned, it MUST be white, not black or any other color)
 - All garment details, trims, patterns, and color combinations must match the description precisely
 - Keep their face and features exactly the same
-- IMPORTANT: If the person is wearing glasses in the original image, they MUST keep wearing the exact same glasses. If they're not wearing glasses, they should not have glasses in the generated image
-- Glasses are NOT part of the outfit - preserve the subject's original eyewear status
 - Show them from the waist up against a pure black background
 - Put them in a different, natural pose from the source image
-- Image must be in 9:16 aspect ratio (portrait/vertical format)
+- Image must be in %s aspect ratio (%s format)
+
+The outfit details provided are from a fashion designer's specification and MUST be followed exactly.
 
-The outfit details provided are from a fashion designer's specification and MUST be followed exactly.`, enhancedPrompt)
+%s`, format.Ratio, format.Shape, enhancedPrompt, format.Ratio, format.Orientation, eyewear)
 
 	if params.DebugPrompt {
-		fmt.Println("\n[DEBUG] Outfit Generation Prompt:")
-		fmt.Println("================================")
-		fmt.Printf("Image: %s\n", filepath.Base(params.ImagePath))
-		fmt.Printf("Prompt:\n%s\n", fullPrompt)
-		fmt.Println("================================\n")
+		output.Println("\n[DEBUG] Outfit Generation Prompt:")
+		output.Println("================================")
+		output.Printf("Image: %s\n", filepath.Base(params.ImagePath))
+		output.Printf("Prompt:\n%s\n", fullPrompt)
+		output.Println("================================")
+		output.Println()
 	}
 
 	// Build parts for the request
@@ -77,7 +85,7 @@ The outfit details provided are from a fashion designer's specification and MUST
 	if params.SendOriginal && params.OutfitReference != "" {
 		outfitData, outfitMimeType, err := gemini.LoadImageAsBase64(params.OutfitReference)
 		if err != nil {
-			fmt.Printf("Warning: Could not load outfit reference image: %v\n", err)
+			output.Progress.Printf("Warning: Could not load outfit reference image: %v\n", err)
 		} else {
 			parts = append(parts, gemini.BlobPart{
 				InlineData: gemini.InlineData{
@@ -86,7 +94,7 @@ The outfit details provided are from a fashion designer's specification and MUST
 				},
 			})
 			// Modify prompt to reference the outfit image
-			fullPrompt = fmt.Sprintf(`Generate a 9:16 portrait format image of the person from the first image wearing the outfit shown in the reference image(s).
+			fullPrompt = fmt.Sprintf(`Generate a %s %s format image of the person from the first image wearing the outfit shown in the reference image(s).
 
 Outfit description: %s
 
@@ -94,13 +102,13 @@ CRITICAL REQUIREMENTS:
 - Match the outfit from the reference image as closely as possible
 - Every color and detail from the reference must be reproduced accurately
 - Keep the person's face and features exactly the same as the first image
-- IMPORTANT: If the person is wearing glasses in the original image, they MUST keep wearing the exact same glasses. If they're not wearing glasses, they should not have glasses in the generated image
-- Glasses are NOT part of the outfit - preserve the subject's original eyewear status
 - Show them from the waist up against a pure black background
 - Put them in a different, natural pose from the source image
-- Image must be in 9:16 aspect ratio (portrait/vertical format)
+- Image must be in %s aspect ratio (%s format)
 
-The outfit details provided are from a fashion designer's specification and MUST be followed exactly.`, enhancedPrompt)
+The outfit details provided are from a fashion designer's specification and MUST be followed exactly.
+
+%s`, format.Ratio, format.Shape, enhancedPrompt, format.Ratio, format.Orientation, eyewear)
 		}
 	}
 
@@ -119,6 +127,7 @@ The outfit details provided are from a fashion designer's specification and MUST
 			Temperature: params.Temperature,
 			TopK:        40,
 			TopP:        0.95,
+			ImageConfig: imageConfig(params.Aspect),
 		},
 	}
 
@@ -126,6 +135,7 @@ The outfit details provided are from a fashion designer's specification and MUST
 		request.GenerationConfig.Temperature = 0.8
 	}
 
+	request.Operation = gemini.OpGenerate
 	rawResp, err := o.client.SendRequestRaw(request)
 	if err != nil {
 		return nil, fmt.Errorf("error sending request: %w", err)
@@ -146,15 +156,24 @@ The outfit details provided are from a fashion designer's specification and MUST
 	}
 
 	baseName := strings.TrimSuffix(filepath.Base(params.ImagePath), filepath.Ext(params.ImagePath))
-	outputPath := filepath.Join(params.OutputDir, fmt.Sprintf("%s_outfit%s", baseName, extension))
-
-	if err := os.MkdirAll(params.OutputDir, 0755); err != nil {
-		return nil, fmt.Errorf("error creating output directory: %w", err)
+	outputPath, err := outputName(params.OutputDir, params.NameTemplate, baseName+"_outfit", extension, NameFields{
+		Subject:   baseName,
+		Outfit:    ReferenceName(params.OutfitReference, prompt),
+		Variation: params.VariationIndex,
+		Seed:      params.Seed,
+		Time:      time.Now(),
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	if err := os.WriteFile(outputPath, imageBytes, 0644); err != nil {
+	outputPath, err = saveOutput(outputPath, imageBytes)
+	if err != nil {
 		return nil, fmt.Errorf("error saving image: %w", err)
 	}
+	if err := conformOutput(outputPath, params.Aspect, params.Resolution); err != nil {
+		return nil, fmt.Errorf("error resizing image: %w", err)
+	}
 
 	return &GenerateResult{
 		Type:       o.Type,
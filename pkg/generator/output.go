@@ -0,0 +1,43 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// saveOutput writes a generated image without replacing an existing file and
+// returns the path it was written to. Outputs are named by the second they
+// were saved, so generations running in parallel (or a resumed run) can pick
+// the same name; later ones get a _2, _3, ... suffix.
+func saveOutput(outputPath string, data []byte) (string, error) {
+	ext := filepath.Ext(outputPath)
+	base := strings.TrimSuffix(outputPath, ext)
+
+	path := outputPath
+	for i := 2; ; i++ {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if os.IsExist(err) {
+			path = fmt.Sprintf("%s_%d%s", base, i, ext)
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		_, err = f.Write(data)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		return path, err
+	}
+}
+
+// seedFileTag is the file name part recording a --seed generation's seed,
+// e.g. "_seed1234"; images without one get none
+func seedFileTag(seed int64) string {
+	if seed == 0 {
+		return ""
+	}
+	return fmt.Sprintf("_seed%d", seed)
+}
@@ -0,0 +1,33 @@
+package generator
+
+import "img-cli/pkg/prompts"
+
+// strengthData is what the strength template sees
+type strengthData struct {
+	Value float64
+	Level string // subtle, moderate, strong or complete
+}
+
+// strengthLevel names the band of an image-to-image strength the prompt
+// phrases it as
+func strengthLevel(strength float64) string {
+	switch {
+	case strength <= 0.25:
+		return "subtle"
+	case strength <= 0.5:
+		return "moderate"
+	case strength <= 0.75:
+		return "strong"
+	default:
+		return "complete"
+	}
+}
+
+// StrengthSection is the prompt paragraph that asks for the --strength
+// amount of change, "" without --strength
+func StrengthSection(strength float64) (string, error) {
+	if strength <= 0 {
+		return "", nil
+	}
+	return prompts.Render("strength", strengthData{Value: strength, Level: strengthLevel(strength)})
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"img-cli/pkg/gemini"
+	"img-cli/pkg/output"
 	"os"
 	"path/filepath"
 	"strings"
@@ -50,21 +51,30 @@ func (s *StyleTransferGenerator) Generate(params GenerateParams) (*GenerateResul
 		stylePrompt = "Apply a dramatic, high-contrast visual style"
 	}
 
+	strength, err := StrengthSection(params.Strength)
+	if err != nil {
+		return nil, err
+	}
+	format, err := formatSection(params.Aspect)
+	if err != nil {
+		return nil, err
+	}
 	fullPrompt := fmt.Sprintf(`Generate a new version of this image with the following requirements:
 %s
 
 Keep the subject and composition similar but apply the requested visual style changes.
-Maintain high quality and artistic coherence.`, stylePrompt)
+Maintain high quality and artistic coherence.`, stylePrompt) + strength + format
 
 	if params.DebugPrompt {
-		fmt.Println("\n[DEBUG] Style Transfer Generation Prompt:")
-		fmt.Println("=========================================")
-		fmt.Printf("Image: %s\n", filepath.Base(params.ImagePath))
-		fmt.Printf("Style Prompt:\n%s\n", fullPrompt)
+		output.Println("\n[DEBUG] Style Transfer Generation Prompt:")
+		output.Println("=========================================")
+		output.Printf("Image: %s\n", filepath.Base(params.ImagePath))
+		output.Printf("Style Prompt:\n%s\n", fullPrompt)
 		if params.StyleData != nil {
-			fmt.Printf("Style Data: %s\n", string(params.StyleData))
+			output.Printf("Style Data: %s\n", string(params.StyleData))
 		}
-		fmt.Println("=========================================\n")
+		output.Println("=========================================")
+		output.Println()
 	}
 
 	request := gemini.Request{
@@ -87,6 +97,8 @@ Maintain high quality and artistic coherence.`, stylePrompt)
 			Temperature: params.Temperature,
 			TopK:        40,
 			TopP:        0.95,
+			ImageConfig: imageConfig(params.Aspect),
+			Strength:    params.Strength,
 		},
 	}
 
@@ -94,6 +106,7 @@ Maintain high quality and artistic coherence.`, stylePrompt)
 		request.GenerationConfig.Temperature = 0.7
 	}
 
+	request.Operation = gemini.OpGenerate
 	rawResp, err := s.client.SendRequestRaw(request)
 	if err != nil {
 		return nil, fmt.Errorf("error sending request: %w", err)
@@ -123,6 +136,9 @@ Maintain high quality and artistic coherence.`, stylePrompt)
 	if err := os.WriteFile(outputPath, imageBytes, 0644); err != nil {
 		return nil, fmt.Errorf("error saving image: %w", err)
 	}
+	if err := conformOutput(outputPath, params.Aspect, params.Resolution); err != nil {
+		return nil, fmt.Errorf("error resizing image: %w", err)
+	}
 
 	return &GenerateResult{
 		Type:       s.Type,
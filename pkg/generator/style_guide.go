@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"img-cli/pkg/gemini"
+	"img-cli/pkg/logger"
+	"img-cli/pkg/workspace"
 	"os"
 	"path/filepath"
 	"strings"
@@ -59,6 +61,7 @@ func (s *StyleGuideGenerator) Generate(params GenerateParams) (*GenerateResult,
 		},
 	}
 
+	request.Operation = gemini.OpGenerate
 	resp, err := s.client.SendRequest(request)
 	if err != nil {
 		return nil, fmt.Errorf("error generating style guide: %w", err)
@@ -78,8 +81,11 @@ func (s *StyleGuideGenerator) Generate(params GenerateParams) (*GenerateResult,
 	}
 
 	// Ensure styles directory exists
-	stylesDir := "styles"
-	if params.OutputDir != "" && strings.Contains(params.OutputDir, "styles") {
+	stylesDir := workspace.ProjectPath("styles")
+	if workspace.ReadOnlyAssets() {
+		stylesDir = workspace.OutputPath("styles") // Copy it into styles/ by hand
+	}
+	if params.OutputDir != "" && (params.SaveToOutputDir || strings.Contains(params.OutputDir, "styles")) {
 		stylesDir = params.OutputDir
 	}
 
@@ -109,7 +115,7 @@ func (s *StyleGuideGenerator) Generate(params GenerateParams) (*GenerateResult,
 	jsonPath := strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + ".json"
 	if err := os.WriteFile(jsonPath, params.StyleAnalysis, 0644); err != nil {
 		// Non-fatal error
-		fmt.Printf("Warning: Could not save style analysis JSON: %v\n", err)
+		logger.Warn("Could not save style analysis JSON", "path", jsonPath, "error", err)
 	}
 
 	return &GenerateResult{
@@ -204,7 +210,7 @@ func (s *StyleGuideGenerator) GenerateBatch(params GenerateParams, count int) ([
 	for i := 0; i < count; i++ {
 		result, err := s.Generate(params)
 		if err != nil {
-			fmt.Printf("Warning: Failed to generate style guide variation %d: %v\n", i+1, err)
+			logger.Warn("Failed to generate style guide variation", "variation", i+1, "error", err)
 			continue
 		}
 		results = append(results, result)
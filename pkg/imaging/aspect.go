@@ -0,0 +1,154 @@
+package imaging
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultAspect is the shape of generated images unless --aspect says otherwise
+const DefaultAspect = "9:16"
+
+// Aspects lists the supported aspect ratios, width:height
+var Aspects = []string{"9:16", "1:1", "16:9", "4:5", "3:2"}
+
+// aspectTolerance is how far a generated image's ratio may drift from the
+// requested one before it is cropped
+const aspectTolerance = 0.02
+
+// Aspect is an image shape such as 9:16
+type Aspect struct {
+	W, H int
+}
+
+// ParseAspect parses one of the supported aspect ratios; "" is the default
+func ParseAspect(s string) (Aspect, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		s = DefaultAspect
+	}
+	for _, supported := range Aspects {
+		if s == supported {
+			w, h, _ := strings.Cut(s, ":")
+			aw, _ := strconv.Atoi(w)
+			ah, _ := strconv.Atoi(h)
+			return Aspect{W: aw, H: ah}, nil
+		}
+	}
+	return Aspect{}, fmt.Errorf("unsupported aspect ratio %q (use %s)", s, strings.Join(Aspects, ", "))
+}
+
+func (a Aspect) String() string {
+	return fmt.Sprintf("%d:%d", a.W, a.H)
+}
+
+// Ratio is width divided by height
+func (a Aspect) Ratio() float64 {
+	return float64(a.W) / float64(a.H)
+}
+
+// Orientation describes the shape in words, as used in prompts
+func (a Aspect) Orientation() string {
+	switch {
+	case a.W < a.H:
+		return "vertical portrait"
+	case a.W > a.H:
+		return "horizontal landscape"
+	default:
+		return "square"
+	}
+}
+
+// Dimensions returns the pixel size with the given long side
+func (a Aspect) Dimensions(longSide int) (int, int) {
+	if a.W >= a.H {
+		return longSide, maxInt(1, int(math.Round(float64(longSide)*float64(a.H)/float64(a.W))))
+	}
+	return maxInt(1, int(math.Round(float64(longSide)*float64(a.W)/float64(a.H)))), longSide
+}
+
+// Matches reports whether a w×h image has this shape, within a small tolerance
+// for the rounding models apply to their output sizes
+func (a Aspect) Matches(w, h int) bool {
+	if w <= 0 || h <= 0 {
+		return false
+	}
+	return math.Abs(float64(w)/float64(h)/a.Ratio()-1) <= aspectTolerance
+}
+
+// CropToAspect cuts the largest centered region of the given shape out of img
+func CropToAspect(img image.Image, a Aspect) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	cw, ch := w, int(math.Round(float64(w)/a.Ratio()))
+	if ch > h {
+		cw, ch = int(math.Round(float64(h)*a.Ratio())), h
+	}
+	x0, y0 := b.Min.X+(w-cw)/2, b.Min.Y+(h-ch)/2
+	crop := image.NewNRGBA(image.Rect(0, 0, cw, ch))
+	for y := 0; y < ch; y++ {
+		for x := 0; x < cw; x++ {
+			crop.Set(x, y, img.At(x0+x, y0+y))
+		}
+	}
+	return crop
+}
+
+// Resize scales img to exactly w×h. Shrinking averages the source pixels under
+// each output pixel like Thumbnail; enlarging interpolates bilinearly.
+func Resize(img image.Image, w, h int) *image.NRGBA {
+	b := img.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	out := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for ty := 0; ty < h; ty++ {
+		for tx := 0; tx < w; tx++ {
+			var r, g, bl int
+			if w <= sw && h <= sh {
+				y0, y1 := ty*sh/h, maxInt((ty+1)*sh/h, ty*sh/h+1)
+				x0, x1 := tx*sw/w, maxInt((tx+1)*sw/w, tx*sw/w+1)
+				n := 0
+				for y := y0; y < y1; y++ {
+					for x := x0; x < x1; x++ {
+						pr, pg, pb := rgb8(img, b.Min.X+x, b.Min.Y+y)
+						r, g, bl, n = r+int(pr), g+int(pg), bl+int(pb), n+1
+					}
+				}
+				r, g, bl = r/n, g/n, bl/n
+			} else {
+				r, g, bl = bilinear(img, (float64(tx)+0.5)*float64(sw)/float64(w)-0.5, (float64(ty)+0.5)*float64(sh)/float64(h)-0.5)
+			}
+			out.SetNRGBA(tx, ty, color.NRGBA{uint8(r), uint8(g), uint8(bl), 255})
+		}
+	}
+	return out
+}
+
+// bilinear samples img at a fractional position relative to its bounds
+func bilinear(img image.Image, fx, fy float64) (int, int, int) {
+	b := img.Bounds()
+	fx = math.Max(0, math.Min(fx, float64(b.Dx()-1)))
+	fy = math.Max(0, math.Min(fy, float64(b.Dy()-1)))
+	x0, y0 := int(fx), int(fy)
+	x1, y1 := minInt(x0+1, b.Dx()-1), minInt(y0+1, b.Dy()-1)
+	dx, dy := fx-float64(x0), fy-float64(y0)
+
+	var out [3]float64
+	for _, s := range []struct {
+		x, y int
+		wt   float64
+	}{
+		{x0, y0, (1 - dx) * (1 - dy)},
+		{x1, y0, dx * (1 - dy)},
+		{x0, y1, (1 - dx) * dy},
+		{x1, y1, dx * dy},
+	} {
+		r, g, bl := rgb8(img, b.Min.X+s.x, b.Min.Y+s.y)
+		out[0] += float64(r) * s.wt
+		out[1] += float64(g) * s.wt
+		out[2] += float64(bl) * s.wt
+	}
+	return int(math.Round(out[0])), int(math.Round(out[1])), int(math.Round(out[2]))
+}
@@ -0,0 +1,39 @@
+package imaging
+
+import "math"
+
+// ColorDistance measures how far apart the color distributions of two images are.
+// It is the mean per-channel earth mover's distance on normalized values, so 0 means
+// identical histograms and 1 means all pixels sit at opposite ends of the range.
+// Image sizes and composition don't matter, only the overall palette and tonality.
+func ColorDistance(a, b *Histogram) float64 {
+	pairs := [][2]channelHistogram{
+		{a.Red, b.Red},
+		{a.Green, b.Green},
+		{a.Blue, b.Blue},
+	}
+
+	total := 0.0
+	for _, pair := range pairs {
+		cdfA, cdfB := pair[0].cdf(), pair[1].cdf()
+		sum := 0.0
+		for v := 0; v < 255; v++ {
+			sum += math.Abs(cdfA[v] - cdfB[v])
+		}
+		total += sum / 255
+	}
+	return total / float64(len(pairs))
+}
+
+// CompareColorFiles returns the color distance between two image files
+func CompareColorFiles(pathA, pathB string) (float64, error) {
+	imgA, err := Load(pathA)
+	if err != nil {
+		return 0, err
+	}
+	imgB, err := Load(pathB)
+	if err != nil {
+		return 0, err
+	}
+	return ColorDistance(NewHistogram(imgA), NewHistogram(imgB)), nil
+}
@@ -0,0 +1,68 @@
+package imaging
+
+import "image"
+
+// channelHistogram counts 8-bit values for a single channel
+type channelHistogram [256]float64
+
+// Histogram holds per-channel and luma distributions of an image
+type Histogram struct {
+	Red    channelHistogram
+	Green  channelHistogram
+	Blue   channelHistogram
+	Luma   channelHistogram
+	Pixels int
+}
+
+// NewHistogram computes the color histograms of an image
+func NewHistogram(img image.Image) *Histogram {
+	return NewRegionHistogram(img, img.Bounds())
+}
+
+// NewRegionHistogram computes the color histograms of part of an image
+func NewRegionHistogram(img image.Image, region image.Rectangle) *Histogram {
+	h := &Histogram{}
+	bounds := region.Intersect(img.Bounds())
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b := rgb8(img, x, y)
+			h.Red[r]++
+			h.Green[g]++
+			h.Blue[b]++
+			h.Luma[luma8(r, g, b)]++
+			h.Pixels++
+		}
+	}
+	return h
+}
+
+// cdf returns the normalized cumulative distribution of the channel
+func (c channelHistogram) cdf() [256]float64 {
+	var out [256]float64
+	total := 0.0
+	for _, v := range c {
+		total += v
+	}
+	if total == 0 {
+		for i := range out {
+			out[i] = float64(i+1) / 256
+		}
+		return out
+	}
+	running := 0.0
+	for i, v := range c {
+		running += v
+		out[i] = running / total
+	}
+	return out
+}
+
+// SubjectRegion returns the central area of a portrait where the subject's
+// clothing usually is, leaving out most of the background and the head
+func SubjectRegion(bounds image.Rectangle) image.Rectangle {
+	w, h := bounds.Dx(), bounds.Dy()
+	return image.Rect(
+		bounds.Min.X+w/4, bounds.Min.Y+h/4,
+		bounds.Max.X-w/4, bounds.Max.Y-h/10,
+	)
+}
@@ -0,0 +1,91 @@
+// Package imaging provides pixel-level utilities for reference and generated images,
+// such as loading/saving, color grading LUTs, and color distribution measurements.
+// Everything here runs locally and never calls the Gemini API.
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif" // register GIF decoder
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Load decodes an image file (PNG, JPEG or GIF)
+func Load(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding image %s: %w", filepath.Base(path), err)
+	}
+	return img, nil
+}
+
+// Decode decodes an image held in memory (PNG, JPEG or GIF)
+func Decode(data []byte) (image.Image, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("error decoding image: %w", err)
+	}
+	return img, nil
+}
+
+// Size reads the pixel dimensions of an image file without decoding the pixels
+func Size(path string) (int, int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer file.Close()
+
+	cfg, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error reading image size %s: %w", filepath.Base(path), err)
+	}
+	return cfg.Width, cfg.Height, nil
+}
+
+// Save encodes an image using the format implied by the file extension.
+// JPEG is used for .jpg/.jpeg, PNG for everything else.
+func Save(path string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jpg", ".jpeg":
+		err = jpeg.Encode(file, img, &jpeg.Options{Quality: 95})
+	default:
+		err = png.Encode(file, img)
+	}
+	if err != nil {
+		return fmt.Errorf("error encoding image %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// rgb8 returns the 8-bit RGB components of the pixel at (x, y)
+func rgb8(img image.Image, x, y int) (uint8, uint8, uint8) {
+	r, g, b, _ := img.At(x, y).RGBA()
+	return uint8(r >> 8), uint8(g >> 8), uint8(b >> 8)
+}
+
+// luma8 returns the Rec. 601 luma of an 8-bit RGB triple
+func luma8(r, g, b uint8) uint8 {
+	return uint8((299*int(r) + 587*int(g) + 114*int(b) + 500) / 1000)
+}
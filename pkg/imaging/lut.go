@@ -0,0 +1,258 @@
+package imaging
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DefaultLUTSize is the grid size used for extracted 3D LUTs
+const DefaultLUTSize = 33
+
+// LUT is a 3D color lookup table in the Adobe/Resolve .cube layout.
+// Table entries are ordered with red changing fastest, then green, then blue.
+type LUT struct {
+	Title string
+	Size  int
+	Table [][3]float64
+}
+
+// ExtractLUT estimates the color grading of a reference image as a 3D LUT.
+//
+// The grade is approximated with per-channel tone curves: for every brightness
+// level, each channel is mapped to the value that sits at the same percentile
+// in the reference's channel distribution as that level does in its luma
+// distribution. This captures tints in shadows/highlights (split toning) and
+// per-channel contrast while leaving overall exposure to the generator.
+// Strength blends the curves with the identity (0 = no change, 1 = full grade).
+func ExtractLUT(img image.Image, size int, strength float64) *LUT {
+	if size < 2 {
+		size = DefaultLUTSize
+	}
+	strength = math.Max(0, math.Min(1, strength))
+
+	hist := NewHistogram(img)
+	lumaCDF := hist.Luma.cdf()
+
+	var curves [3][256]float64
+	for c, channel := range []channelHistogram{hist.Red, hist.Green, hist.Blue} {
+		channelCDF := channel.cdf()
+		for v := 0; v < 256; v++ {
+			graded := quantile(channelCDF, lumaCDF[v]) / 255
+			identity := float64(v) / 255
+			curves[c][v] = identity + (graded-identity)*strength
+		}
+	}
+
+	lut := &LUT{
+		Size:  size,
+		Table: make([][3]float64, 0, size*size*size),
+	}
+	for b := 0; b < size; b++ {
+		for g := 0; g < size; g++ {
+			for r := 0; r < size; r++ {
+				lut.Table = append(lut.Table, [3]float64{
+					sampleCurve(curves[0], float64(r)/float64(size-1)),
+					sampleCurve(curves[1], float64(g)/float64(size-1)),
+					sampleCurve(curves[2], float64(b)/float64(size-1)),
+				})
+			}
+		}
+	}
+	return lut
+}
+
+// Apply returns a graded copy of img using trilinear interpolation
+func (l *LUT) Apply(img image.Image) *image.NRGBA {
+	bounds := img.Bounds()
+	out := image.NewNRGBA(bounds)
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			rgb := l.lookup(float64(c.R)/255, float64(c.G)/255, float64(c.B)/255)
+			out.SetNRGBA(x, y, color.NRGBA{
+				R: to8(rgb[0]),
+				G: to8(rgb[1]),
+				B: to8(rgb[2]),
+				A: c.A,
+			})
+		}
+	}
+	return out
+}
+
+// lookup interpolates the table at normalized input coordinates
+func (l *LUT) lookup(r, g, b float64) [3]float64 {
+	n := float64(l.Size - 1)
+	r0, rf := split(r * n)
+	g0, gf := split(g * n)
+	b0, bf := split(b * n)
+	r1, g1, b1 := minInt(r0+1, l.Size-1), minInt(g0+1, l.Size-1), minInt(b0+1, l.Size-1)
+
+	at := func(ri, gi, bi int) [3]float64 {
+		return l.Table[ri+gi*l.Size+bi*l.Size*l.Size]
+	}
+
+	var out [3]float64
+	for i := 0; i < 3; i++ {
+		c00 := lerp(at(r0, g0, b0)[i], at(r1, g0, b0)[i], rf)
+		c10 := lerp(at(r0, g1, b0)[i], at(r1, g1, b0)[i], rf)
+		c01 := lerp(at(r0, g0, b1)[i], at(r1, g0, b1)[i], rf)
+		c11 := lerp(at(r0, g1, b1)[i], at(r1, g1, b1)[i], rf)
+		out[i] = lerp(lerp(c00, c10, gf), lerp(c01, c11, gf), bf)
+	}
+	return out
+}
+
+// WriteCube writes the LUT in .cube format
+func (l *LUT) WriteCube(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	if l.Title != "" {
+		fmt.Fprintf(bw, "TITLE \"%s\"\n", l.Title)
+	}
+	fmt.Fprintf(bw, "LUT_3D_SIZE %d\n", l.Size)
+	fmt.Fprintln(bw, "DOMAIN_MIN 0.0 0.0 0.0")
+	fmt.Fprintln(bw, "DOMAIN_MAX 1.0 1.0 1.0")
+	for _, entry := range l.Table {
+		fmt.Fprintf(bw, "%.6f %.6f %.6f\n", entry[0], entry[1], entry[2])
+	}
+	return bw.Flush()
+}
+
+// SaveCube writes the LUT to a .cube file
+func (l *LUT) SaveCube(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return l.WriteCube(file)
+}
+
+// ReadCube parses a 3D LUT in .cube format
+func ReadCube(r io.Reader) (*LUT, error) {
+	lut := &LUT{}
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		switch fields[0] {
+		case "TITLE":
+			lut.Title = strings.Trim(strings.TrimSpace(strings.TrimPrefix(text, "TITLE")), `"`)
+		case "LUT_3D_SIZE":
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("line %d: malformed LUT_3D_SIZE", line)
+			}
+			size, err := strconv.Atoi(fields[1])
+			if err != nil || size < 2 {
+				return nil, fmt.Errorf("line %d: invalid LUT_3D_SIZE %q", line, fields[1])
+			}
+			lut.Size = size
+		case "LUT_1D_SIZE":
+			return nil, fmt.Errorf("line %d: 1D LUTs are not supported", line)
+		case "DOMAIN_MIN", "DOMAIN_MAX":
+			// Only the default 0..1 domain is produced and consumed
+		default:
+			if len(fields) != 3 {
+				return nil, fmt.Errorf("line %d: expected 3 values, got %d", line, len(fields))
+			}
+			var entry [3]float64
+			for i, field := range fields {
+				v, err := strconv.ParseFloat(field, 64)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: invalid value %q", line, field)
+				}
+				entry[i] = v
+			}
+			lut.Table = append(lut.Table, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if lut.Size == 0 {
+		return nil, fmt.Errorf("missing LUT_3D_SIZE")
+	}
+	if expected := lut.Size * lut.Size * lut.Size; len(lut.Table) != expected {
+		return nil, fmt.Errorf("expected %d LUT entries, found %d", expected, len(lut.Table))
+	}
+	return lut, nil
+}
+
+// LoadCube reads a .cube file from disk
+func LoadCube(path string) (*LUT, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadCube(file)
+}
+
+// quantile returns the 8-bit value at which the given CDF first reaches p
+func quantile(cdf [256]float64, p float64) float64 {
+	for v := 0; v < 256; v++ {
+		if cdf[v] >= p {
+			if v == 0 {
+				return 0
+			}
+			// Interpolate within the bin for smoother curves
+			prev := cdf[v-1]
+			if cdf[v] > prev {
+				return float64(v-1) + (p-prev)/(cdf[v]-prev)
+			}
+			return float64(v)
+		}
+	}
+	return 255
+}
+
+// sampleCurve linearly samples a 256-entry curve at a normalized position
+func sampleCurve(curve [256]float64, x float64) float64 {
+	i, f := split(x * 255)
+	if i >= 255 {
+		return curve[255]
+	}
+	return lerp(curve[i], curve[i+1], f)
+}
+
+func split(v float64) (int, float64) {
+	i := int(math.Floor(v))
+	return i, v - float64(i)
+}
+
+func lerp(a, b, t float64) float64 {
+	return a + (b-a)*t
+}
+
+func to8(v float64) uint8 {
+	return uint8(math.Round(math.Max(0, math.Min(1, v)) * 255))
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
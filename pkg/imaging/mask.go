@@ -0,0 +1,133 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// LoadMask reads an inpainting mask and scales it to w x h. White marks the
+// region to edit and black what to keep; masks with transparency (the OpenAI
+// convention) mark the region to edit as transparent instead. The result is
+// pure black and white.
+func LoadMask(path string, w, h int) (*image.Gray, error) {
+	img, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	scaled := Resize(img, w, h)
+
+	transparent := false
+	for i := 3; i < len(scaled.Pix); i += 4 {
+		if scaled.Pix[i] < 128 {
+			transparent = true
+			break
+		}
+	}
+
+	mask := image.NewGray(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			p := scaled.NRGBAAt(x, y)
+			edit := luma8(p.R, p.G, p.B) >= 128
+			if transparent {
+				edit = p.A < 128
+			}
+			if edit {
+				mask.SetGray(x, y, color.Gray{Y: 255})
+			}
+		}
+	}
+	return mask, nil
+}
+
+// RectMask returns a w x h mask whose edit region is r
+func RectMask(w, h int, r image.Rectangle) *image.Gray {
+	mask := image.NewGray(image.Rect(0, 0, w, h))
+	draw.Draw(mask, r.Intersect(mask.Bounds()), image.White, image.Point{}, draw.Src)
+	return mask
+}
+
+// MaskCoverage returns the share of a mask's pixels marked for editing
+func MaskCoverage(mask *image.Gray) float64 {
+	if len(mask.Pix) == 0 {
+		return 0
+	}
+	edit := 0
+	for _, v := range mask.Pix {
+		if v >= 128 {
+			edit++
+		}
+	}
+	return float64(edit) / float64(len(mask.Pix))
+}
+
+// Composite pastes the masked region of edit over base, which must have the
+// same size. The seam fades over feather pixels inside the region, so every
+// pixel outside the mask stays exactly as it is in base.
+func Composite(base, edit image.Image, mask *image.Gray, feather int) *image.NRGBA {
+	b := base.Bounds()
+	out := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	weights := insetWeights(mask, feather)
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			br, bg, bb := rgb8(base, b.Min.X+x, b.Min.Y+y)
+			w := weights[y*b.Dx()+x]
+			if w == 0 {
+				out.SetNRGBA(x, y, color.NRGBA{br, bg, bb, 255})
+				continue
+			}
+			er, eg, eb := rgb8(edit, edit.Bounds().Min.X+x, edit.Bounds().Min.Y+y)
+			out.SetNRGBA(x, y, color.NRGBA{
+				R: uint8(lerp(float64(br), float64(er), w) + 0.5),
+				G: uint8(lerp(float64(bg), float64(eg), w) + 0.5),
+				B: uint8(lerp(float64(bb), float64(eb), w) + 0.5),
+				A: 255,
+			})
+		}
+	}
+	return out
+}
+
+// insetWeights returns the blend weight of each mask pixel: 0 outside the
+// region, rising to 1 over radius pixels from its edge inwards (a box blur of
+// the mask, clamped to the region)
+func insetWeights(mask *image.Gray, radius int) []float64 {
+	w, h := mask.Rect.Dx(), mask.Rect.Dy()
+	weights := make([]float64, w*h)
+	if radius <= 0 {
+		for i, v := range mask.Pix {
+			if v >= 128 {
+				weights[i] = 1
+			}
+		}
+		return weights
+	}
+
+	// Summed-area table of the region for constant-time box sums
+	sums := make([]int, (w+1)*(h+1))
+	for y := 0; y < h; y++ {
+		row := 0
+		for x := 0; x < w; x++ {
+			if mask.Pix[y*mask.Stride+x] >= 128 {
+				row++
+			}
+			sums[(y+1)*(w+1)+x+1] = sums[y*(w+1)+x+1] + row
+		}
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if mask.Pix[y*mask.Stride+x] < 128 {
+				continue
+			}
+			x0, y0 := maxInt(x-radius, 0), maxInt(y-radius, 0)
+			x1, y1 := minInt(x+radius+1, w), minInt(y+radius+1, h)
+			inside := sums[y1*(w+1)+x1] - sums[y0*(w+1)+x1] - sums[y1*(w+1)+x0] + sums[y0*(w+1)+x0]
+			full := (x1 - x0) * (y1 - y0)
+			// The box around a pixel at the region's edge is about half inside;
+			// boxes clipped by the image border only count what is in the image
+			weights[y*w+x] = min(1, max(0, 2*float64(inside)/float64(full)-1))
+		}
+	}
+	return weights
+}
@@ -0,0 +1,134 @@
+package imaging
+
+import (
+	"image"
+	"math"
+	"math/bits"
+	"sort"
+)
+
+// pHashSize is the side of the grayscale thumbnail the DCT is computed on
+const pHashSize = 32
+
+// PHash computes a 64-bit perceptual hash of an image.
+//
+// The image is reduced to a 32x32 grayscale thumbnail, transformed with a 2D DCT,
+// and the 8x8 lowest frequencies are compared against their median. Images that
+// look alike (same composition, pose and tones) get hashes a few bits apart even
+// when pixels differ, so the Hamming distance works as a similarity measure.
+func PHash(img image.Image) uint64 {
+	gray := grayThumbnail(img, pHashSize)
+
+	// Separable 2D DCT-II, keeping only the 8x8 low-frequency block
+	var rows [pHashSize][8]float64
+	for y := 0; y < pHashSize; y++ {
+		for u := 0; u < 8; u++ {
+			sum := 0.0
+			for x := 0; x < pHashSize; x++ {
+				sum += gray[y][x] * math.Cos(float64(2*x+1)*float64(u)*math.Pi/(2*pHashSize))
+			}
+			rows[y][u] = sum
+		}
+	}
+	var coeffs [64]float64
+	for v := 0; v < 8; v++ {
+		for u := 0; u < 8; u++ {
+			sum := 0.0
+			for y := 0; y < pHashSize; y++ {
+				sum += rows[y][u] * math.Cos(float64(2*y+1)*float64(v)*math.Pi/(2*pHashSize))
+			}
+			coeffs[v*8+u] = sum
+		}
+	}
+
+	// Median of the AC coefficients; the DC term only reflects average brightness
+	sorted := append([]float64(nil), coeffs[1:]...)
+	sort.Float64s(sorted)
+	median := (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+
+	var hash uint64
+	for i, c := range coeffs {
+		if c > median {
+			hash |= 1 << uint(i)
+		}
+	}
+	return hash
+}
+
+// HashDistance returns the number of differing bits between two perceptual hashes
+func HashDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// ClusterHashes groups hashes whose distance to any member of a group is at most
+// threshold (single linkage). Groups hold indexes into hashes, largest group first.
+func ClusterHashes(hashes []uint64, threshold int) [][]int {
+	parent := make([]int, len(hashes))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	for i := range hashes {
+		for j := i + 1; j < len(hashes); j++ {
+			if HashDistance(hashes[i], hashes[j]) <= threshold {
+				parent[find(i)] = find(j)
+			}
+		}
+	}
+
+	groups := make(map[int][]int)
+	var roots []int
+	for i := range hashes {
+		root := find(i)
+		if _, seen := groups[root]; !seen {
+			roots = append(roots, root)
+		}
+		groups[root] = append(groups[root], i)
+	}
+
+	clusters := make([][]int, 0, len(roots))
+	for _, root := range roots {
+		clusters = append(clusters, groups[root])
+	}
+	sort.SliceStable(clusters, func(a, b int) bool {
+		return len(clusters[a]) > len(clusters[b])
+	})
+	return clusters
+}
+
+// grayThumbnail averages the image down to a size x size luma grid
+func grayThumbnail(img image.Image, size int) [][]float64 {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+
+	grid := make([][]float64, size)
+	for ty := 0; ty < size; ty++ {
+		grid[ty] = make([]float64, size)
+		y0 := bounds.Min.Y + ty*h/size
+		y1 := maxInt(y0+1, bounds.Min.Y+(ty+1)*h/size)
+		for tx := 0; tx < size; tx++ {
+			x0 := bounds.Min.X + tx*w/size
+			x1 := maxInt(x0+1, bounds.Min.X+(tx+1)*w/size)
+
+			sum, n := 0.0, 0
+			for y := y0; y < y1 && y < bounds.Max.Y; y++ {
+				for x := x0; x < x1 && x < bounds.Max.X; x++ {
+					r, g, b := rgb8(img, x, y)
+					sum += float64(luma8(r, g, b))
+					n++
+				}
+			}
+			if n > 0 {
+				grid[ty][tx] = sum / float64(n)
+			}
+		}
+	}
+	return grid
+}
@@ -0,0 +1,132 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+)
+
+// Contact sheet layout in pixels
+const (
+	sheetPadding   = 12
+	sheetTextScale = 2
+	sheetRowLabel  = 180 // Width of the row label column
+)
+
+var (
+	sheetBackground = color.NRGBA{0xF4, 0xF4, 0xF4, 0xFF}
+	sheetCellEmpty  = color.NRGBA{0xDD, 0xDD, 0xDD, 0xFF}
+	sheetText       = color.NRGBA{0x22, 0x22, 0x22, 0xFF}
+	sheetFlagged    = color.NRGBA{0xD0, 0x3A, 0x2F, 0xFF}
+)
+
+// Sheet is a labeled grid of images, such as the outputs of a batch run with
+// a row per subject and a column per outfit and style
+type Sheet struct {
+	Title   string
+	Columns []string // Column labels; "\n" starts another line
+	Rows    []SheetRow
+}
+
+// SheetRow is one labeled row of a Sheet
+type SheetRow struct {
+	Label string
+	Cells []SheetCell // One per column; missing cells are left empty
+}
+
+// SheetCell is one image of a Sheet
+type SheetCell struct {
+	Path    string // Image file; "" leaves the cell empty
+	Flagged bool   // Outlined in red, e.g. for images that failed a check
+}
+
+// RenderSheet draws s with each image scaled to fit a cell whose longer side
+// is cellSize pixels. Images that fail to load are left as empty cells.
+func RenderSheet(s Sheet, cellSize int) *image.NRGBA {
+	lineHeight := TextHeight(sheetTextScale) + sheetPadding
+	cellW, cellH := cellSize*9/16, cellSize // Generated images are portrait unless --aspect says otherwise
+	for _, row := range s.Rows {
+		for _, cell := range row.Cells {
+			if w, h, err := Size(cell.Path); err == nil && w > h {
+				cellW, cellH = cellSize, cellSize // Make room for landscape images
+			}
+		}
+	}
+
+	labelLines := 1
+	for _, label := range s.Columns {
+		labelLines = maxInt(labelLines, strings.Count(label, "\n")+1)
+	}
+	top := sheetPadding + lineHeight*(1+labelLines) // Title and column labels
+	width := sheetPadding + sheetRowLabel + len(s.Columns)*(cellW+sheetPadding)
+	height := top + len(s.Rows)*(cellH+sheetPadding)
+	sheet := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(sheet, sheet.Bounds(), &image.Uniform{C: sheetBackground}, image.Point{}, draw.Src)
+
+	DrawText(sheet, sheetPadding, sheetPadding, FitText(s.Title, width-2*sheetPadding, sheetTextScale), sheetTextScale, sheetText)
+	for c, label := range s.Columns {
+		x := sheetPadding + sheetRowLabel + c*(cellW+sheetPadding)
+		for i, line := range strings.Split(label, "\n") {
+			DrawText(sheet, x, sheetPadding+lineHeight*(1+i), FitText(line, cellW, sheetTextScale), sheetTextScale, sheetText)
+		}
+	}
+
+	for r, row := range s.Rows {
+		y := top + r*(cellH+sheetPadding)
+		DrawText(sheet, sheetPadding, y+(cellH-TextHeight(sheetTextScale))/2,
+			FitText(row.Label, sheetRowLabel-sheetPadding, sheetTextScale), sheetTextScale, sheetText)
+		for c := range s.Columns {
+			x := sheetPadding + sheetRowLabel + c*(cellW+sheetPadding)
+			cellRect := image.Rect(x, y, x+cellW, y+cellH)
+			var cell SheetCell
+			if c < len(row.Cells) {
+				cell = row.Cells[c]
+			}
+			drawSheetCell(sheet, cellRect, cell)
+		}
+	}
+	return sheet
+}
+
+// drawSheetCell draws an image centered in its cell
+func drawSheetCell(sheet *image.NRGBA, cellRect image.Rectangle, cell SheetCell) {
+	var img image.Image
+	if cell.Path != "" {
+		img, _ = Load(cell.Path)
+	}
+	if img == nil {
+		draw.Draw(sheet, cellRect, &image.Uniform{C: sheetCellEmpty}, image.Point{}, draw.Src)
+		return
+	}
+
+	b := img.Bounds()
+	w, h := cellRect.Dx(), cellRect.Dy()
+	if b.Dx()*h > b.Dy()*w {
+		h = maxInt(1, b.Dy()*w/b.Dx())
+	} else {
+		w = maxInt(1, b.Dx()*h/b.Dy())
+	}
+	x0 := cellRect.Min.X + (cellRect.Dx()-w)/2
+	y0 := cellRect.Min.Y + (cellRect.Dy()-h)/2
+	placed := image.Rect(x0, y0, x0+w, y0+h)
+	draw.Draw(sheet, placed, Resize(img, w, h), image.Point{}, draw.Src)
+
+	if cell.Flagged {
+		for i := 0; i < 3; i++ {
+			outline(sheet, placed.Inset(i), sheetFlagged)
+		}
+	}
+}
+
+// outline draws the one-pixel border of r
+func outline(img *image.NRGBA, r image.Rectangle, c color.Color) {
+	for x := r.Min.X; x < r.Max.X; x++ {
+		img.Set(x, r.Min.Y, c)
+		img.Set(x, r.Max.Y-1, c)
+	}
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		img.Set(r.Min.X, y, c)
+		img.Set(r.Max.X-1, y, c)
+	}
+}
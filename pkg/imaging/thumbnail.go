@@ -0,0 +1,51 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+)
+
+// Thumbnail scales img down so its longer side is at most maxSide, averaging
+// the source pixels under each output pixel. Smaller images are copied as is.
+func Thumbnail(img image.Image, maxSide int) *image.NRGBA {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	tw, th := w, h
+	if w >= h && w > maxSide {
+		tw, th = maxSide, maxInt(1, h*maxSide/w)
+	} else if h > w && h > maxSide {
+		tw, th = maxInt(1, w*maxSide/h), maxSide
+	}
+
+	thumb := image.NewNRGBA(image.Rect(0, 0, tw, th))
+	for ty := 0; ty < th; ty++ {
+		y0, y1 := b.Min.Y+ty*h/th, b.Min.Y+maxInt((ty+1)*h/th, ty*h/th+1)
+		for tx := 0; tx < tw; tx++ {
+			x0, x1 := b.Min.X+tx*w/tw, b.Min.X+maxInt((tx+1)*w/tw, tx*w/tw+1)
+			var r, g, bl, n int
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					pr, pg, pb := rgb8(img, x, y)
+					r, g, bl, n = r+int(pr), g+int(pg), bl+int(pb), n+1
+				}
+			}
+			thumb.SetNRGBA(tx, ty, color.NRGBA{uint8(r / n), uint8(g / n), uint8(bl / n), 255})
+		}
+	}
+	return thumb
+}
+
+// ThumbnailJPEG loads an image file and returns a JPEG-encoded thumbnail of it
+func ThumbnailJPEG(path string, maxSide int) ([]byte, error) {
+	img, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, Thumbnail(img, maxSide), &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
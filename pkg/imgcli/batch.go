@@ -0,0 +1,84 @@
+package imgcli
+
+import (
+	"context"
+	"img-cli/pkg/logger"
+)
+
+// BatchRunner runs a list of recipes on one Client, one after the other, so
+// the analysis cache and the adaptive rate limits are shared across the batch
+type BatchRunner struct {
+	client *Client
+
+	// StopOnError ends the batch at the first recipe that returns an error.
+	// Otherwise failed recipes are recorded and the batch continues.
+	StopOnError bool
+
+	// OnResult, when set, is called after each recipe finishes
+	OnResult func(item BatchItem)
+}
+
+// BatchItem is the outcome of one recipe in a batch
+type BatchItem struct {
+	Index  int
+	Recipe Recipe
+	Result *Result
+	Err    error
+}
+
+// NewBatchRunner creates a BatchRunner that generates with client
+func NewBatchRunner(client *Client) *BatchRunner {
+	return &BatchRunner{client: client}
+}
+
+// Run generates every recipe in order. Cancelling ctx stops the batch before
+// the next recipe starts; the items finished so far are returned with ctx.Err().
+func (b *BatchRunner) Run(ctx context.Context, recipes []Recipe) ([]BatchItem, error) {
+	items := make([]BatchItem, 0, len(recipes))
+	for i, recipe := range recipes {
+		if err := ctx.Err(); err != nil {
+			logger.Info("Batch stopped", "completed", len(items), "remaining", len(recipes)-i)
+			return items, err
+		}
+
+		result, err := b.client.Generate(recipe)
+		item := BatchItem{Index: i, Recipe: recipe, Result: result, Err: err}
+		items = append(items, item)
+		if b.OnResult != nil {
+			b.OnResult(item)
+		}
+		if err != nil {
+			logger.Warn("Batch recipe failed", "index", i, "subject", recipe.Subject, "error", err)
+			if b.StopOnError {
+				return items, err
+			}
+		}
+	}
+	return items, nil
+}
+
+// Combinations returns one recipe per subject × outfit × style, each a copy of
+// base with those three components set. Empty lists leave base's value.
+func Combinations(base Recipe, subjects, outfits, styles []string) []Recipe {
+	if len(subjects) == 0 {
+		subjects = []string{base.Subject}
+	}
+	if len(outfits) == 0 {
+		outfits = []string{base.Outfit}
+	}
+	if len(styles) == 0 {
+		styles = []string{base.Style}
+	}
+
+	var recipes []Recipe
+	for _, subject := range subjects {
+		for _, outfit := range outfits {
+			for _, style := range styles {
+				recipe := base
+				recipe.Subject, recipe.Outfit, recipe.Style = subject, outfit, style
+				recipes = append(recipes, recipe)
+			}
+		}
+	}
+	return recipes
+}
@@ -0,0 +1,229 @@
+// Package imgcli is the supported Go API for embedding the img-cli pipeline in
+// other programs. It wraps the workflow, workspace and config packages with
+// constructors and option structs that stay stable across releases, and does
+// the glue the cobra commands do: asset name resolution, the project lock and
+// per-run result collection.
+//
+// The pipeline reports progress on stdout and through pkg/logger, like the CLI.
+// Analyses and generations can be observed or changed with middleware (see
+// Config). It never asks for cost confirmation.
+//
+//	client, err := imgcli.NewClient(imgcli.Config{APIKey: key})
+//	if err != nil { ... }
+//	defer client.Close()
+//	result, err := client.Generate(imgcli.Recipe{Subject: "jaimee", Outfit: "shearling-black"})
+package imgcli
+
+import (
+	"encoding/json"
+	"img-cli/pkg/c2pa"
+	"img-cli/pkg/config"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/gemini"
+	"img-cli/pkg/library"
+	"img-cli/pkg/workflow"
+	"img-cli/pkg/workspace"
+	"os"
+	"sync"
+)
+
+// Config holds the settings of a Client
+type Config struct {
+	// APIKey is the Gemini API key; GEMINI_API_KEY is used when empty
+	APIKey string
+
+	// DisableCache skips the analysis cache for reads and writes
+	DisableCache bool
+
+	// Limits replaces the default API rate limits (config.DefaultLimitsConfig)
+	Limits *config.LimitsConfig
+
+	// Provider selects the image model API (gemini, openai or sd) for the whole
+	// process; IMG_CLI_PROVIDER is used when empty
+	Provider string
+
+	// Project selects a client project (see "img-cli project"); its outputs,
+	// caches and budget are used for the whole process
+	Project string
+
+	// Lock takes the project lock for the lifetime of the client, like a CLI
+	// invocation, so concurrent img-cli runs in the same project wait their turn
+	Lock bool
+
+	// Signer embeds C2PA content credentials in generated images (see c2pa.LoadSigner)
+	Signer *c2pa.Signer
+
+	// Middleware wrapping every analysis and generation
+	AnalyzerMiddleware  []workflow.AnalyzerMiddleware
+	GeneratorMiddleware []workflow.GeneratorMiddleware
+}
+
+// Client runs analyses and generations. Calls are serialized, so each result
+// reports exactly the failures and scores of its own call; use one Client per
+// goroutine for parallel work.
+type Client struct {
+	orchestrator *workflow.Orchestrator
+	run          *workspace.Run
+	mu           sync.Mutex
+}
+
+// NewClient creates a Client from cfg
+func NewClient(cfg Config) (*Client, error) {
+	if cfg.Provider != "" {
+		if err := gemini.SetDefaultProvider(cfg.Provider); err != nil {
+			return nil, err
+		}
+	}
+	providerCfg := gemini.ProviderConfig()
+
+	apiKey := cfg.APIKey
+	if apiKey == "" {
+		apiKey = os.Getenv("GEMINI_API_KEY")
+	}
+	if apiKey == "" && providerCfg.Uses(config.ProviderGemini) {
+		return nil, errors.New(errors.ConfigError, "GEMINI_API_KEY is required. Set Config.APIKey or the GEMINI_API_KEY environment variable")
+	}
+	if providerCfg.OpenAIKey == "" && providerCfg.Uses(config.ProviderOpenAI) {
+		return nil, errors.New(errors.ConfigError, "OPENAI_API_KEY is required for the openai provider")
+	}
+
+	opts := []workflow.Option{
+		workflow.WithCache(!cfg.DisableCache),
+		workflow.WithAnalyzerMiddleware(cfg.AnalyzerMiddleware...),
+		workflow.WithGeneratorMiddleware(cfg.GeneratorMiddleware...),
+	}
+	if cfg.Limits != nil {
+		opts = append(opts, workflow.WithLimits(cfg.Limits))
+	}
+	if cfg.Signer != nil {
+		opts = append(opts, workflow.WithSigner(cfg.Signer))
+	}
+
+	if cfg.Project != "" {
+		if err := workspace.UseProject(cfg.Project); err != nil {
+			return nil, err
+		}
+	}
+
+	client := &Client{orchestrator: workflow.NewOrchestrator(apiKey, opts...)}
+	if cfg.Lock {
+		run, err := workspace.Start(workspace.Root(), "imgcli")
+		if err != nil {
+			return nil, err
+		}
+		client.run = run
+	}
+	return client, nil
+}
+
+// Close releases the project lock taken with Config.Lock
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.run != nil {
+		c.run.Finish(true)
+		c.run = nil
+	}
+	return nil
+}
+
+// Orchestrator returns the underlying workflow orchestrator for features the
+// facade does not cover. Its API may change between releases.
+func (c *Client) Orchestrator() *workflow.Orchestrator {
+	return c.orchestrator
+}
+
+// Throughput reports the request rates achieved against the API so far
+func (c *Client) Throughput() []gemini.Throughput {
+	return c.orchestrator.Throughput()
+}
+
+// Analyze runs one analyzer ("outfit", "visual_style" or "art_style") on an
+// image. The image can be a path or an asset name from the matching folder.
+func (c *Client) Analyze(analyzerType, image string) (json.RawMessage, error) {
+	kind := "outfit"
+	if analyzerType != "outfit" {
+		kind = "style"
+	}
+	path, err := workspace.ResolveAssetPath(kind, image)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.orchestrator.AnalyzeImage(analyzerType, path)
+}
+
+// Generate runs one modular recipe
+func (c *Client) Generate(recipe Recipe) (*Result, error) {
+	cfg, err := recipe.modularConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	mark := c.mark()
+	images, err := c.orchestrator.RunModularWorkflow(cfg)
+	result := c.collect(mark, images)
+	if err != nil {
+		return result, errors.Wrap(err, errors.WorkflowError, "modular generation failed")
+	}
+	return result, nil
+}
+
+// OutfitSwap runs the outfit-swap workflow on an outfit image, folder or
+// asset name. Cost confirmation is always skipped.
+func (c *Client) OutfitSwap(outfit string, options OutfitSwapOptions) (*OutfitSwapResult, error) {
+	outfit, err := library.Expand("outfit", outfit)
+	if err != nil {
+		return nil, err
+	}
+	path, err := workspace.ResolveAssetPath("outfit", outfit)
+	if err != nil {
+		return nil, err
+	}
+	workflowOptions, err := options.workflowOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	result, err := c.orchestrator.RunWorkflow("outfit-swap", path, workflowOptions)
+	if result == nil {
+		return nil, err
+	}
+	return newOutfitSwapResult(result), err
+}
+
+// mark records how many failures and scores existed before a call
+type mark struct {
+	failures    int
+	consistency int
+}
+
+func (c *Client) mark() mark {
+	return mark{
+		failures:    len(c.orchestrator.Failures()),
+		consistency: len(c.orchestrator.ConsistencyScores()),
+	}
+}
+
+// collect builds the result of the call started at m
+func (c *Client) collect(m mark, images []string) *Result {
+	result := &Result{
+		Images:      images,
+		Flags:       make(map[string][]string),
+		Failures:    c.orchestrator.Failures()[m.failures:],
+		Consistency: c.orchestrator.ConsistencyScores()[m.consistency:],
+	}
+	for _, image := range images {
+		if flags := c.orchestrator.ReviewFlags(image); len(flags) > 0 {
+			result.Flags[image] = flags
+		}
+	}
+	return result
+}
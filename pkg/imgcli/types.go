@@ -0,0 +1,415 @@
+package imgcli
+
+import (
+	"img-cli/pkg/analyzer"
+	"img-cli/pkg/config"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/generator"
+	"img-cli/pkg/imaging"
+	"img-cli/pkg/library"
+	"img-cli/pkg/workflow"
+	"img-cli/pkg/workspace"
+	"time"
+)
+
+// Recipe describes one modular generation. Every component accepts an image
+// path, an asset name ("shearling-black") or, except Subject and Style, a text
+// description. Empty components are left out. The JSON form is what the serve
+// command accepts.
+type Recipe struct {
+	Subject     string `json:"subject,omitempty"`
+	Outfit      string `json:"outfit,omitempty"`
+	OverOutfit  string `json:"over_outfit,omitempty"` // Base layer the outfit is worn over
+	Style       string `json:"style,omitempty"`
+	HairStyle   string `json:"hair_style,omitempty"`
+	HairColor   string `json:"hair_color,omitempty"`
+	Makeup      string `json:"makeup,omitempty"`
+	Expression  string `json:"expression,omitempty"`
+	Accessories string `json:"accessories,omitempty"`
+	Pose        string `json:"pose,omitempty"`       // Body pose; replaces the pose of the style reference
+	Background  string `json:"background,omitempty"` // Environment; replaces the background of the style reference
+
+	Extra map[string]string `json:"extra,omitempty"` // Components added with analyzer.Register, by analyzer type or flag
+
+	Variations   int  `json:"variations,omitempty"`    // Images to generate (default 1)
+	SendOriginal bool `json:"send_original,omitempty"` // Include reference images in the generation request
+	EnhanceText  bool `json:"enhance_text,omitempty"`  // Expand short text components into structured descriptions
+
+	OutfitCheck      string   `json:"outfit_check,omitempty"`      // warn (default), fill or off
+	MaxAccessories   int      `json:"max_accessories,omitempty"`   // Keep only the N most important accessories (0 = no limit)
+	AllowImplausible bool     `json:"allow_implausible,omitempty"` // Generate even when garments clash with the style's scene
+	LUT              string   `json:"lut,omitempty"`               // .cube file applied to every output
+	Upscale          int      `json:"upscale,omitempty"`           // Also write an _upscaled copy at 2x or 4x (0 = off)
+	Upscaler         string   `json:"upscaler,omitempty"`          // regenerate (default) or resample
+	FaceLock         bool     `json:"face_lock,omitempty"`         // Paste the subject's face back over every output
+	Avoid            []string `json:"avoid,omitempty"`             // Elements that must not appear in the image
+	Aspect           string   `json:"aspect,omitempty"`            // 9:16 (default), 1:1, 16:9, 4:5 or 3:2
+	Resolution       int      `json:"resolution,omitempty"`        // Long side of saved images in pixels (0 = as generated)
+	Seed             int64    `json:"seed,omitempty"`              // Generation seed; variation i uses seed+i (0 = random)
+	Temperature      float64  `json:"temperature,omitempty"`       // Generation temperature, 0-2 (0 = generation.temperature in the config, else the model's)
+	TopK             int      `json:"top_k,omitempty"`             // Generation top-k (0 = generation.top_k in the config, else the model's)
+	TopP             float64  `json:"top_p,omitempty"`             // Generation top-p, 0-1 (0 = generation.top_p in the config, else the model's)
+	Strength         float64  `json:"strength,omitempty"`          // How much the subject photo may change, 0-1 (0 = left to the model)
+	NameTemplate     string   `json:"name_template,omitempty"`     // Output file name template, e.g. "{subject}/{outfit}-v{variation:2}"
+	SkipExisting     bool     `json:"skip_existing,omitempty"`     // Skip variations already generated under the output root
+	OutputDir        string   `json:"output_dir,omitempty"`        // Default: a new timestamped folder under output/
+
+	ColorCheck       bool    `json:"color_check,omitempty"`        // Flag outputs whose outfit colors drift from the style reference
+	Consistency      bool    `json:"consistency,omitempty"`        // Score identity and color stability across variations
+	MinIdentityScore float64 `json:"min_identity_score,omitempty"` // Regenerate outputs whose face scores below this against the subject (0 = off)
+	Judge            bool    `json:"judge,omitempty"`              // Score outfit fidelity, style fidelity and artifacts, and rank the outputs
+	BestOf           int     `json:"best_of,omitempty"`            // Generate this many candidates per image and keep the best (0 or 1 = off)
+
+	Stop func() bool `json:"-"` // Checked before each variation; true ends the run with the images generated so far
+}
+
+// Result is the outcome of one Generate call
+type Result struct {
+	Images      []string                    // Generated image paths
+	Flags       map[string][]string         // Image path -> failed automated checks
+	Failures    []workflow.Failure          // Variations that produced no image
+	Consistency []workflow.ConsistencyScore // Set when Recipe.Consistency is on
+}
+
+// OutfitSwapOptions configures Client.OutfitSwap, the outfit-swap command in
+// library form. Components take the same values as in a Recipe; Subjects and
+// Style take image paths, folders or asset names.
+type OutfitSwapOptions struct {
+	Subjects    []string
+	OutfitText  string // Text description used instead of the outfit argument when that is ""
+	OverOutfit  string
+	Style       string // Default: the outfit image is its own style
+	StyleBlend  bool   // Style lists several style images, comma-separated, blended into one
+	HairStyle   string
+	HairColor   string
+	Makeup      string
+	Expression  string
+	Accessories string
+	Pose        string
+	Background  string
+
+	Extra   map[string]string  // Components added with analyzer.Register, by analyzer type or flag
+	Weights map[string]float64 // Prompt emphasis by component (see workflow.SplitWeight)
+
+	Variations   int  // Images per combination (default 1)
+	SendOriginal bool // Include reference images in the generation request
+	EnhanceText  bool // Expand short text components into structured descriptions
+
+	Sample     int      // Generate only this many combinations drawn at random (0 = all)
+	SampleSeed int64    // Seed of the Sample draw
+	Pairwise   bool     // Generate a small set of combinations covering every pair of component values
+	Ambient    []string // Lighting/ambient sweep: each combination is generated once per entry
+
+	OutfitCheck      string   // warn (default), fill or off
+	MaxAccessories   int      // Keep only the N most important accessories (0 = no limit)
+	AllowImplausible bool     // Generate even when garments clash with the style's scene
+	LUT              string   // .cube file applied to every output
+	Upscale          int      // Also write an _upscaled copy at 2x or 4x (0 = off)
+	Upscaler         string   // regenerate (default) or resample
+	FaceLock         bool     // Paste the subject's face back over every output
+	Avoid            []string // Elements that must not appear in any image
+	Aspect           string   // 9:16 (default), 1:1, 16:9, 4:5 or 3:2
+	Resolution       int      // Long side of saved images in pixels (0 = as generated)
+	Seed             int64    // Generation seed; variation i uses seed+i (0 = random)
+	Temperature      float64  // Generation temperature, 0-2 (0 = generation.temperature in the config, else the model's)
+	TopK             int      // Generation top-k (0 = generation.top_k in the config, else the model's)
+	TopP             float64  // Generation top-p, 0-1 (0 = generation.top_p in the config, else the model's)
+	Strength         float64  // How much the subject photo may change, 0-1 (0 = left to the model)
+	NameTemplate     string   // Output file name template, e.g. "{subject}/{outfit}-v{variation:2}"
+	OrganizeBy       string   // Per-component subfolders of OutputDir: subject, outfit or style ("" = none)
+	Chain            []string // Other outputs generated from each image (see the --chain flag)
+	ArtStyle         string   // Art style reference of the art-style chain step
+	OutputDir        string   // Default: a new timestamped folder under output/
+
+	ColorCheck       bool    // Flag outputs whose outfit colors drift from the style reference
+	Consistency      bool    // Score identity and color stability across variations
+	MinIdentityScore float64 // Regenerate outputs whose face scores below this against the subject (0 = off)
+	Judge            bool    // Score outfit fidelity, style fidelity and artifacts, and rank the outputs
+	BestOf           int     // Generate this many candidates per image and keep the best (0 or 1 = off)
+
+	SkipPreflight bool          // Skip checking subject photos before the run
+	SkipExisting  bool          // Skip variations already generated under the output root
+	Resume        bool          // Skip combinations whose images are already in OutputDir
+	Parallel      int           // Generations run at once (0 or 1 = one after another)
+	MaxDuration   time.Duration // Stop starting new combinations after this long (0 = no limit)
+	Stop          func() bool   // Checked as the run goes; true ends it early like MaxDuration
+}
+
+// OutfitSwapResult is the outcome of Client.OutfitSwap
+type OutfitSwapResult struct {
+	Result
+	Stopped   bool // MaxDuration or Stop ended the run early; the rest is in run_state.json
+	Remaining int  // Combinations not generated when Stopped
+}
+
+// modularConfig resolves asset names and validates the recipe
+func (r Recipe) modularConfig() (workflow.ModularConfig, error) {
+	cfg := workflow.ModularConfig{
+		Variations:       r.Variations,
+		SendOriginal:     r.SendOriginal,
+		EnhanceText:      r.EnhanceText,
+		OutfitCheck:      r.OutfitCheck,
+		MaxAccessories:   r.MaxAccessories,
+		AllowImplausible: r.AllowImplausible,
+		Avoid:            r.Avoid,
+		Aspect:           r.Aspect,
+		Resolution:       r.Resolution,
+		Seed:             r.Seed,
+		Strength:         r.Strength,
+		NameTemplate:     r.NameTemplate,
+		SkipExisting:     r.SkipExisting,
+		Stop:             r.Stop,
+		OutputDir:        r.OutputDir,
+		Post:             workflow.PostOptions{LUTPath: workspace.Resolve(r.LUT), Upscale: r.Upscale, Upscaler: r.Upscaler, FaceLock: r.FaceLock},
+		Verify: workflow.VerifyOptions{
+			ColorCheck:       r.ColorCheck,
+			Consistency:      r.Consistency,
+			MinIdentityScore: r.MinIdentityScore,
+			Judge:            r.Judge,
+			BestOf:           r.BestOf,
+			IdentityRetries:  workflow.DefaultIdentityRetries,
+		},
+	}
+	if cfg.Variations <= 0 {
+		cfg.Variations = 1
+	}
+	if cfg.OutfitCheck == "" {
+		cfg.OutfitCheck = workflow.OutfitCheckWarn
+	}
+
+	if r.Subject == "" {
+		return cfg, errors.ErrInvalidInput("subject", "a subject image is required")
+	}
+	for _, component := range []struct {
+		kind  string
+		value string
+	}{
+		{"subject", r.Subject},
+		{"outfit", r.Outfit},
+		{"over-outfit", r.OverOutfit},
+		{"style", r.Style},
+		{"hair-style", r.HairStyle},
+		{"hair-color", r.HairColor},
+		{"makeup", r.Makeup},
+		{"expression", r.Expression},
+		{"accessories", r.Accessories},
+		{"pose", r.Pose},
+		{"background", r.Background},
+	} {
+		if err := workflow.ApplyOverride(&cfg, component.kind, component.value); err != nil {
+			return cfg, err
+		}
+	}
+	for kind, value := range r.Extra {
+		if err := workflow.ApplyOverride(&cfg, kind, value); err != nil {
+			return cfg, err
+		}
+	}
+
+	switch cfg.OutfitCheck {
+	case workflow.OutfitCheckWarn, workflow.OutfitCheckFill, workflow.OutfitCheckOff:
+	default:
+		return cfg, errors.ErrInvalidInput("outfit-check", "must be warn, fill or off, got "+cfg.OutfitCheck)
+	}
+	if cfg.MaxAccessories < 0 {
+		return cfg, errors.ErrInvalidInput("max-accessories", "must be 0 (no limit) or a positive number")
+	}
+	if cfg.Post.LUTPath != "" {
+		if _, err := imaging.LoadCube(cfg.Post.LUTPath); err != nil {
+			return cfg, errors.Wrapf(err, errors.ValidationError, "invalid LUT file %s", cfg.Post.LUTPath)
+		}
+	}
+	if err := workflow.ValidateFormat(cfg.Aspect, cfg.Resolution); err != nil {
+		return cfg, err
+	}
+	if err := workflow.ValidateSeed(cfg.Seed); err != nil {
+		return cfg, err
+	}
+	sampling, err := workflow.GenerationSampling(r.Temperature, r.TopK, r.TopP)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.Sampling = sampling
+	if err := workflow.ValidateStrength(cfg.Strength); err != nil {
+		return cfg, err
+	}
+	if err := generator.ValidateNameTemplate(cfg.NameTemplate); err != nil {
+		return cfg, err
+	}
+	if cfg.Post.Upscale != 0 && cfg.Post.Upscale != 2 && cfg.Post.Upscale != 4 {
+		return cfg, errors.ErrInvalidInput("upscale", "must be 0 (off), 2 or 4")
+	}
+	if err := workflow.ValidateIdentityFlags(cfg.Verify.MinIdentityScore, cfg.Verify.IdentityRetries); err != nil {
+		return cfg, err
+	}
+	if cfg.Verify.BestOf > 1 {
+		if err := workflow.ValidateBestOf(cfg.Verify.BestOf); err != nil {
+			return cfg, err
+		}
+	}
+	return cfg, nil
+}
+
+// workflowOptions resolves asset names and validates the options
+func (o OutfitSwapOptions) workflowOptions() (workflow.WorkflowOptions, error) {
+	options := workflow.WorkflowOptions{
+		OutputDir:        o.OutputDir,
+		OutfitText:       o.OutfitText,
+		StyleReference:   o.Style,
+		StyleBlend:       o.StyleBlend,
+		Weights:          o.Weights,
+		Variations:       o.Variations,
+		SendOriginal:     o.SendOriginal,
+		EnhanceText:      o.EnhanceText,
+		Sample:           o.Sample,
+		SampleSeed:       o.SampleSeed,
+		Pairwise:         o.Pairwise,
+		Ambient:          o.Ambient,
+		OutfitCheck:      o.OutfitCheck,
+		MaxAccessories:   o.MaxAccessories,
+		AllowImplausible: o.AllowImplausible,
+		Avoid:            o.Avoid,
+		Aspect:           o.Aspect,
+		Resolution:       o.Resolution,
+		Seed:             o.Seed,
+		Strength:         o.Strength,
+		NameTemplate:     o.NameTemplate,
+		OrganizeBy:       o.OrganizeBy,
+		SkipCostConfirm:  true,
+		SkipPreflight:    o.SkipPreflight,
+		SkipExisting:     o.SkipExisting,
+		Resume:           o.Resume,
+		Parallel:         o.Parallel,
+		MaxDuration:      o.MaxDuration,
+		Stop:             o.Stop,
+		Post:             workflow.PostOptions{LUTPath: workspace.Resolve(o.LUT), Upscale: o.Upscale, Upscaler: o.Upscaler, FaceLock: o.FaceLock},
+		Chain:            workflow.ChainOptions{Steps: o.Chain},
+		Verify: workflow.VerifyOptions{
+			ColorCheck:       o.ColorCheck,
+			ColorTolerance:   config.DefaultVerifyConfig().ColorTolerance,
+			Consistency:      o.Consistency,
+			MinIdentityScore: o.MinIdentityScore,
+			Judge:            o.Judge,
+			BestOf:           o.BestOf,
+			IdentityRetries:  workflow.DefaultIdentityRetries,
+		},
+	}
+	if options.Variations <= 0 {
+		options.Variations = 1
+	}
+	if options.OutfitCheck == "" {
+		options.OutfitCheck = workflow.OutfitCheckWarn
+	}
+
+	for _, subject := range o.Subjects {
+		path, err := workspace.ResolveAssetPath("subject", subject)
+		if err != nil {
+			return options, err
+		}
+		options.TargetImages = append(options.TargetImages, path)
+	}
+	if len(options.TargetImages) == 0 {
+		return options, errors.ErrMissingRequired("subjects")
+	}
+	for _, component := range []struct {
+		kind  string
+		value string
+		field *string
+	}{
+		{"style", o.Style, &options.StyleReference},
+		{"style", o.ArtStyle, &options.Chain.ArtStyleRef},
+		{"over-outfit", o.OverOutfit, &options.OverOutfitRef},
+		{"hair-style", o.HairStyle, &options.HairStyleRef},
+		{"hair-color", o.HairColor, &options.HairColorRef},
+		{"makeup", o.Makeup, &options.MakeupRef},
+		{"expression", o.Expression, &options.ExpressionRef},
+		{"accessories", o.Accessories, &options.AccessoriesRef},
+		{"pose", o.Pose, &options.PoseRef},
+		{"background", o.Background, &options.BackgroundRef},
+	} {
+		expanded, err := library.Expand(component.kind, component.value)
+		if err != nil {
+			return options, err
+		}
+		if *component.field, err = workspace.ResolveAsset(component.kind, expanded); err != nil {
+			return options, err
+		}
+	}
+	if len(o.Extra) > 0 {
+		options.Extra = make(map[string]string, len(o.Extra))
+		for kind, value := range o.Extra {
+			name, component, ok := analyzer.LookupComponent(kind)
+			if !ok {
+				return options, errors.ErrInvalidInput("extra", "unknown component "+kind)
+			}
+			expanded, err := library.Expand(component.Flag, value)
+			if err != nil {
+				return options, err
+			}
+			if options.Extra[name], err = workspace.ResolveAsset(component.Flag, expanded); err != nil {
+				return options, err
+			}
+		}
+	}
+
+	switch options.OutfitCheck {
+	case workflow.OutfitCheckWarn, workflow.OutfitCheckFill, workflow.OutfitCheckOff:
+	default:
+		return options, errors.ErrInvalidInput("outfit-check", "must be warn, fill or off, got "+options.OutfitCheck)
+	}
+	if err := workflow.ValidateFormat(options.Aspect, options.Resolution); err != nil {
+		return options, err
+	}
+	if err := workflow.ValidateSeed(options.Seed); err != nil {
+		return options, err
+	}
+	sampling, err := workflow.GenerationSampling(o.Temperature, o.TopK, o.TopP)
+	if err != nil {
+		return options, err
+	}
+	options.Sampling = sampling
+	if err := workflow.ValidateStrength(options.Strength); err != nil {
+		return options, err
+	}
+	if err := generator.ValidateNameTemplate(options.NameTemplate); err != nil {
+		return options, err
+	}
+	if err := workflow.ValidateOrganizeBy(options.OrganizeBy); err != nil {
+		return options, err
+	}
+	if options.Post.Upscale != 0 && options.Post.Upscale != 2 && options.Post.Upscale != 4 {
+		return options, errors.ErrInvalidInput("upscale", "must be 0 (off), 2 or 4")
+	}
+	if err := workflow.ValidateIdentityFlags(options.Verify.MinIdentityScore, options.Verify.IdentityRetries); err != nil {
+		return options, err
+	}
+	if options.Verify.BestOf > 1 {
+		if err := workflow.ValidateBestOf(options.Verify.BestOf); err != nil {
+			return options, err
+		}
+	}
+	return options, options.Chain.Validate()
+}
+
+// newOutfitSwapResult converts the workflow result of an outfit-swap run
+func newOutfitSwapResult(result *workflow.WorkflowResult) *OutfitSwapResult {
+	swap := &OutfitSwapResult{
+		Result: Result{
+			Flags:       make(map[string][]string),
+			Failures:    result.Failures,
+			Consistency: result.Consistency,
+		},
+		Stopped:   result.Stopped,
+		Remaining: result.Remaining,
+	}
+	for _, step := range result.Steps {
+		if step.Type != "generation" || step.OutputPath == "" {
+			continue
+		}
+		swap.Images = append(swap.Images, step.OutputPath)
+		if len(step.Flags) > 0 {
+			swap.Flags[step.OutputPath] = step.Flags
+		}
+	}
+	return swap
+}
@@ -0,0 +1,305 @@
+// Package jobs persists the jobs of the serve command, one JSON file per job
+// under .img-cli/jobs in the project. Queued work survives restarts, jobs that
+// were running when the process died are queued again, and other img-cli
+// processes can list and cancel jobs while the server runs. A cancellation is
+// also recorded in a <id>.cancel marker file, which no other process's write
+// of the job can overwrite.
+package jobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/workflow"
+	"img-cli/pkg/workspace"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Job kinds
+const (
+	KindModular    = "modular"
+	KindOutfitSwap = "outfit-swap"
+)
+
+// Job statuses
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+// Job is a workflow waiting for, or run by, the server
+type Job struct {
+	ID        string             `json:"id"`
+	Kind      string             `json:"kind"`
+	Status    string             `json:"status"`
+	Request   json.RawMessage    `json:"request"` // Body the job was submitted with
+	Created   time.Time          `json:"created"`
+	Started   *time.Time         `json:"started,omitempty"`
+	Finished  *time.Time         `json:"finished,omitempty"`
+	Attempts  int                `json:"attempts,omitempty"` // Runs started, more than 1 after a restart
+	OutputDir string             `json:"output_dir"`
+	Images    []Image            `json:"images,omitempty"`
+	Failures  []workflow.Failure `json:"failures,omitempty"` // Generations that produced no image
+	Error     *errors.Report     `json:"error,omitempty"`
+	// Set by Cancel on a running job; the workflow stops before its next combination
+	CancelRequested bool `json:"cancel_requested,omitempty"`
+}
+
+// Image is a generated image of a job
+type Image struct {
+	Name  string   `json:"name"`
+	Path  string   `json:"path"`
+	Flags []string `json:"flags,omitempty"`
+}
+
+// Done reports whether the job has finished, one way or another
+func (j *Job) Done() bool {
+	return j.Status == StatusCompleted || j.Status == StatusFailed || j.Status == StatusCancelled
+}
+
+// Store keeps jobs in a directory
+type Store struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// DefaultDir is the job directory of the active project
+func DefaultDir() string {
+	return workspace.ProjectPath(workspace.Dir, "jobs")
+}
+
+// Open returns the store in dir, creating the directory if needed
+func Open(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.Wrapf(err, errors.FileError, "failed to create job directory %s", dir)
+	}
+	return &Store{dir: dir}, nil
+}
+
+// Create queues a new job. outputDir names the job's output folder from its ID.
+func (s *Store) Create(kind string, request interface{}, outputDir func(id string) string) (*Job, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.InternalError, "failed to encode job request")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs, err := s.list()
+	if err != nil {
+		return nil, err
+	}
+	next := 1
+	for _, job := range jobs {
+		if id, err := strconv.Atoi(job.ID); err == nil && id >= next {
+			next = id + 1
+		}
+	}
+
+	job := &Job{
+		ID:      strconv.Itoa(next),
+		Kind:    kind,
+		Status:  StatusQueued,
+		Request: data,
+		Created: time.Now(),
+	}
+	job.OutputDir = outputDir(job.ID)
+	return job, s.write(job)
+}
+
+// Get loads a job
+func (s *Store) Get(id string) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.read(id)
+}
+
+// List returns every job, oldest first
+func (s *Store) List() ([]*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.list()
+}
+
+// Update changes a job and saves it
+func (s *Store) Update(id string, change func(job *Job)) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, err := s.read(id)
+	if err != nil {
+		return nil, err
+	}
+	change(job)
+	return job, s.write(job)
+}
+
+// Next returns the oldest queued job, or nil when none is waiting
+func (s *Store) Next() (*Job, error) {
+	jobs, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, job := range jobs {
+		if job.Status == StatusQueued {
+			return job, nil
+		}
+	}
+	return nil, nil
+}
+
+// Queued counts the jobs waiting to run
+func (s *Store) Queued() (int, error) {
+	jobs, err := s.List()
+	if err != nil {
+		return 0, err
+	}
+	queued := 0
+	for _, job := range jobs {
+		if job.Status == StatusQueued {
+			queued++
+		}
+	}
+	return queued, nil
+}
+
+// Recover queues again the jobs left running by a process that died, and
+// cancels those whose cancellation it never saw through. It returns the
+// recovered jobs.
+func (s *Store) Recover() ([]*Job, error) {
+	jobs, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	var recovered []*Job
+	for _, job := range jobs {
+		if job.Status != StatusRunning {
+			continue
+		}
+		job, err := s.Update(job.ID, func(job *Job) {
+			if job.CancelRequested {
+				finished := time.Now()
+				job.Status = StatusCancelled
+				job.Finished = &finished
+				return
+			}
+			job.Status = StatusQueued
+		})
+		if err != nil {
+			return recovered, err
+		}
+		recovered = append(recovered, job)
+	}
+	return recovered, nil
+}
+
+// Cancel stops a job: a queued job is cancelled right away, a running job is
+// asked to stop before its next combination
+func (s *Store) Cancel(id string) (*Job, error) {
+	job, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if job.Done() {
+		return job, errors.ErrInvalidInput("id", fmt.Sprintf("job %s already %s", id, job.Status))
+	}
+
+	// The server may be saving the job it read before this cancellation, which
+	// would drop CancelRequested; the marker keeps the request regardless
+	if err := os.WriteFile(s.cancelPath(id), nil, 0644); err != nil {
+		return nil, errors.Wrapf(err, errors.FileError, "failed to cancel job %s", id)
+	}
+	return s.Update(id, func(job *Job) {
+		if job.Status == StatusQueued {
+			finished := time.Now()
+			job.Status = StatusCancelled
+			job.Finished = &finished
+		}
+	})
+}
+
+// CancelRequested reports whether a running job was asked to stop
+func (s *Store) CancelRequested(id string) bool {
+	job, err := s.Get(id)
+	return err == nil && job.CancelRequested
+}
+
+func (s *Store) path(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+func (s *Store) cancelPath(id string) string {
+	return filepath.Join(s.dir, id+".cancel")
+}
+
+func (s *Store) read(id string) (*Job, error) {
+	if _, err := strconv.Atoi(id); err != nil {
+		return nil, errors.ErrInvalidInput("id", fmt.Sprintf("no job %q", id))
+	}
+	data, err := os.ReadFile(s.path(id))
+	if os.IsNotExist(err) {
+		return nil, errors.ErrInvalidInput("id", fmt.Sprintf("no job %q", id))
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, errors.FileError, "failed to read job %s", id)
+	}
+	var job Job
+	if err := json.Unmarshal(data, &job); err != nil {
+		return nil, errors.Wrapf(err, errors.FileError, "failed to parse job %s", id)
+	}
+	if _, err := os.Stat(s.cancelPath(id)); err == nil {
+		job.CancelRequested = true
+	}
+	return &job, nil
+}
+
+func (s *Store) list() ([]*Job, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, errors.FileError, "failed to list jobs in %s", s.dir)
+	}
+	var jobs []*Job
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		job, err := s.read(id)
+		if err != nil {
+			continue // Not a job file, or one being replaced
+		}
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		a, _ := strconv.Atoi(jobs[i].ID)
+		b, _ := strconv.Atoi(jobs[j].ID)
+		return a < b
+	})
+	return jobs, nil
+}
+
+// write saves a job atomically, so other processes never read half a file
+func (s *Store) write(job *Job) error {
+	data, err := json.MarshalIndent(job, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, errors.InternalError, "failed to encode job")
+	}
+	path := s.path(job.ID)
+	tmp := fmt.Sprintf("%s.tmp-%d", path, os.Getpid())
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return errors.Wrapf(err, errors.FileError, "failed to save job %s", job.ID)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return errors.Wrapf(err, errors.FileError, "failed to save job %s", job.ID)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package jobs
+
+import "testing"
+
+func openTestStores(t *testing.T) (*Store, *Store) {
+	dir := t.TempDir()
+	server, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return server, other
+}
+
+func createJob(t *testing.T, store *Store) *Job {
+	job, err := store.Create(KindModular, map[string]string{"subject": "kat"}, func(id string) string { return "output/job" + id })
+	if err != nil {
+		t.Fatal(err)
+	}
+	return job
+}
+
+// The stores share a directory but not a mutex, like the server and a
+// "jobs cancel" in another terminal
+func TestCancelSurvivesAConcurrentUpdateFromAnotherProcess(t *testing.T) {
+	server, other := openTestStores(t)
+	job := createJob(t, server)
+	if _, err := server.Update(job.ID, func(job *Job) { job.Status = StatusRunning }); err != nil {
+		t.Fatal(err)
+	}
+
+	// The server saves the job it read before the cancellation
+	_, err := server.Update(job.ID, func(job *Job) {
+		if _, err := other.Cancel(job.ID); err != nil {
+			t.Fatal(err)
+		}
+		job.Attempts++
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !server.CancelRequested(job.ID) {
+		t.Error("cancellation lost to the server's write")
+	}
+	saved, err := other.Get(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !saved.CancelRequested || saved.Attempts != 1 {
+		t.Errorf("saved job has cancel_requested %v, attempts %d; want both writes", saved.CancelRequested, saved.Attempts)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	store, _ := openTestStores(t)
+	queued := createJob(t, store)
+	running := createJob(t, store)
+	if _, err := store.Update(running.ID, func(job *Job) { job.Status = StatusRunning }); err != nil {
+		t.Fatal(err)
+	}
+
+	job, err := store.Cancel(queued.ID)
+	if err != nil || job.Status != StatusCancelled || job.Finished == nil {
+		t.Errorf("queued job after cancel: %+v, %v; want cancelled", job, err)
+	}
+	job, err = store.Cancel(running.ID)
+	if err != nil || job.Status != StatusRunning || !job.CancelRequested {
+		t.Errorf("running job after cancel: %+v, %v; want running with cancel requested", job, err)
+	}
+	if _, err := store.Cancel(queued.ID); err == nil {
+		t.Error("cancelling a cancelled job succeeded")
+	}
+
+	// A server that died before seeing the request finishes the cancellation
+	recovered, err := store.Recover()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recovered) != 1 || recovered[0].Status != StatusCancelled {
+		t.Errorf("recovered %+v, want the running job cancelled", recovered)
+	}
+}
@@ -0,0 +1,280 @@
+// Package library catalogs the reference images of the asset folders
+// (outfits/, styles/, hair-style/, ...) for the library command: tags to find
+// them by and thumbnails to browse them with. Workflows take tag selectors
+// ("tag:formal") that Expand into the matching images. Each folder's catalog lives in a
+// library/ subfolder of its analysis cache (catalog.json and thumbs/), keyed by
+// asset name, so tags follow the names used on the command line and survive
+// cache clears.
+package library
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/imaging"
+	"img-cli/pkg/workspace"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// CatalogFile is the name of a folder's catalog in its library folder
+const CatalogFile = "catalog.json"
+
+// ThumbnailSize is the longer side of generated thumbnails in pixels
+const ThumbnailSize = 256
+
+// Kinds lists the component kinds the library catalogs
+func Kinds() []string {
+	var kinds []string
+	for kind := range workspace.AssetDirs {
+		if kind != "over-outfit" { // Shares outfits/
+			kinds = append(kinds, kind)
+		}
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// ValidateKind checks kind is one of Kinds
+func ValidateKind(kind string) error {
+	for _, known := range Kinds() {
+		if kind == known {
+			return nil
+		}
+	}
+	return errors.ErrInvalidInput("kind", fmt.Sprintf("unknown kind %q (use %s)", kind, strings.Join(Kinds(), ", ")))
+}
+
+// Item is one cataloged reference image
+type Item struct {
+	Kind      string   `json:"kind"`
+	Name      string   `json:"name"` // Asset name, as given on the command line
+	Path      string   `json:"path"`
+	Tags      []string `json:"tags,omitempty"`
+	Thumbnail string   `json:"thumbnail,omitempty"` // Set once generated with Thumbnail
+}
+
+// Catalog holds the tags of one kind's assets
+type Catalog struct {
+	kind string
+	path string
+	Tags map[string][]string `json:"tags"` // By normalized asset name
+}
+
+// Open loads the catalog of a kind; a kind never tagged has an empty one
+func Open(kind string) (*Catalog, error) {
+	if err := ValidateKind(kind); err != nil {
+		return nil, err
+	}
+	catalog := &Catalog{
+		kind: kind,
+		path: filepath.Join(libraryDir(kind), CatalogFile),
+		Tags: make(map[string][]string),
+	}
+	data, err := os.ReadFile(catalog.path)
+	if os.IsNotExist(err) {
+		return catalog, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, errors.FileError, "failed to read %s", catalog.path)
+	}
+	if err := json.Unmarshal(data, catalog); err != nil {
+		return nil, errors.Wrapf(err, errors.FileError, "failed to parse %s", catalog.path)
+	}
+	if catalog.Tags == nil {
+		catalog.Tags = make(map[string][]string)
+	}
+	return catalog, nil
+}
+
+// Items lists the kind's reference images with their tags and thumbnails
+func (c *Catalog) Items() []Item {
+	var items []Item
+	for _, path := range workspace.Assets(c.kind) {
+		name := assetName(path)
+		item := Item{Kind: c.kind, Name: name, Path: path, Tags: c.Tags[normalize(name)]}
+		if thumb := thumbnailPath(c.kind, name, path); fresh(thumb, path) {
+			item.Thumbnail = thumb
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// Find returns the item named name, or an error naming the known assets
+func (c *Catalog) Find(name string) (Item, error) {
+	want := normalize(name)
+	for _, item := range c.Items() {
+		if normalize(item.Name) == want {
+			return item, nil
+		}
+	}
+	return Item{}, errors.ErrInvalidInput("name", fmt.Sprintf("no %s named %q in %s/", c.kind, name, workspace.AssetDirs[c.kind]))
+}
+
+// Tag adds tags to an asset, ignoring ones it already has
+func (c *Catalog) Tag(name string, tags []string) {
+	key := normalize(name)
+	existing := c.Tags[key]
+	for _, tag := range tags {
+		tag = normalizeTag(tag)
+		if tag != "" && !slices.Contains(existing, tag) {
+			existing = append(existing, tag)
+		}
+	}
+	sort.Strings(existing)
+	c.Tags[key] = existing
+}
+
+// Untag removes tags from an asset
+func (c *Catalog) Untag(name string, tags []string) {
+	key := normalize(name)
+	var kept []string
+	for _, tag := range c.Tags[key] {
+		remove := false
+		for _, t := range tags {
+			remove = remove || normalizeTag(t) == tag
+		}
+		if !remove {
+			kept = append(kept, tag)
+		}
+	}
+	if len(kept) == 0 {
+		delete(c.Tags, key)
+		return
+	}
+	c.Tags[key] = kept
+}
+
+// Save writes the catalog atomically
+func (c *Catalog) Save() error {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return errors.Wrapf(err, errors.FileError, "failed to create %s", filepath.Dir(c.path))
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, errors.InternalError, "failed to encode the library catalog")
+	}
+	tmp := fmt.Sprintf("%s.tmp-%d", c.path, os.Getpid())
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return errors.Wrapf(err, errors.FileError, "failed to write %s", c.path)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		os.Remove(tmp)
+		return errors.Wrapf(err, errors.FileError, "failed to write %s", c.path)
+	}
+	return nil
+}
+
+// Query selects items for Search
+type Query struct {
+	Kinds []string // Kinds to search; all when empty
+	Tags  []string // Items must have every tag
+	Text  string   // Items must contain the text in their name or a tag
+}
+
+// Search returns the items matching q, grouped by kind
+func Search(q Query) ([]Item, error) {
+	kinds := q.Kinds
+	if len(kinds) == 0 {
+		kinds = Kinds()
+	}
+	text := strings.ToLower(strings.TrimSpace(q.Text))
+
+	var found []Item
+	for _, kind := range kinds {
+		catalog, err := Open(kind)
+		if err != nil {
+			return nil, err
+		}
+	items:
+		for _, item := range catalog.Items() {
+			for _, tag := range q.Tags {
+				if !slices.Contains(item.Tags, normalizeTag(tag)) {
+					continue items
+				}
+			}
+			if text != "" && !strings.Contains(strings.ToLower(item.Name), text) && !containsText(item.Tags, text) {
+				continue
+			}
+			found = append(found, item)
+		}
+	}
+	return found, nil
+}
+
+// Thumbnail makes the thumbnail of an item unless an up-to-date one exists,
+// and returns its path and whether it was generated
+func Thumbnail(item Item) (string, bool, error) {
+	thumb := thumbnailPath(item.Kind, item.Name, item.Path)
+	if fresh(thumb, item.Path) {
+		return thumb, false, nil
+	}
+	data, err := imaging.ThumbnailJPEG(item.Path, ThumbnailSize)
+	if err != nil {
+		return "", false, errors.Wrapf(err, errors.FileError, "failed to read %s", item.Path)
+	}
+	if err := os.MkdirAll(filepath.Dir(thumb), 0755); err != nil {
+		return "", false, errors.Wrapf(err, errors.FileError, "failed to create %s", filepath.Dir(thumb))
+	}
+	if err := os.WriteFile(thumb, data, 0644); err != nil {
+		return "", false, errors.Wrapf(err, errors.FileError, "failed to write %s", thumb)
+	}
+	return thumb, true, nil
+}
+
+// libraryDir is where a kind's catalog and thumbnails live, inside the cache
+// of its analyses
+func libraryDir(kind string) string {
+	return filepath.Join(workspace.AssetCacheDir(workspace.AssetDirs[kind]), "library")
+}
+
+// thumbnailPath names thumbnails after the image's path as well as its name,
+// so images with the same name in different subfolders get their own
+func thumbnailPath(kind, name, path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	h := fnv.New32a()
+	h.Write([]byte(abs))
+	return filepath.Join(libraryDir(kind), "thumbs", fmt.Sprintf("%s-%08x.jpg", normalize(name), h.Sum32()))
+}
+
+// fresh reports whether derived exists and is newer than source
+func fresh(derived, source string) bool {
+	d, err := os.Stat(derived)
+	if err != nil {
+		return false
+	}
+	s, err := os.Stat(source)
+	return err == nil && !d.ModTime().Before(s.ModTime())
+}
+
+func assetName(path string) string {
+	base := filepath.Base(path)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// normalize makes "Shearling_Black" and "shearling-black" compare equal, like
+// asset names on the command line
+func normalize(name string) string {
+	return strings.NewReplacer("_", "-", " ", "-").Replace(strings.ToLower(name))
+}
+
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+func containsText(list []string, text string) bool {
+	for _, v := range list {
+		if strings.Contains(v, text) {
+			return true
+		}
+	}
+	return false
+}
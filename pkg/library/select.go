@@ -0,0 +1,132 @@
+package library
+
+import (
+	"fmt"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/workspace"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// SelectorPrefix starts a component value that selects reference images by
+// tag instead of naming them, e.g. "tag:formal"
+const SelectorPrefix = "tag:"
+
+// IsSelector reports whether a component value is a tag selector
+func IsSelector(value string) bool {
+	return strings.HasPrefix(strings.ToLower(value), SelectorPrefix)
+}
+
+// Select returns the kind's reference images matching a selector. Several
+// comma-separated terms ("tag:formal,black") must all match. An image matches
+// a term when it is tagged with it or, for folders organized by filename
+// convention, when its name contains it.
+func Select(kind, selector string) ([]Item, error) {
+	if kind == "over-outfit" { // Shares outfits/
+		kind = "outfit"
+	}
+	terms := selectorTerms(selector)
+	if len(terms) == 0 {
+		return nil, errors.ErrInvalidInput(kind, fmt.Sprintf("%q names no tag, e.g. %sformal", selector, SelectorPrefix))
+	}
+
+	catalog, err := Open(kind)
+	if err != nil {
+		return nil, err
+	}
+	var selected []Item
+	for _, item := range catalog.Items() {
+		matches := true
+		for _, term := range terms {
+			matches = matches && matchesTerm(item, term)
+		}
+		if matches {
+			selected = append(selected, item)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, errors.ErrInvalidInput(kind, fmt.Sprintf("no %s matches %q (tag images with: img-cli library tag %s <name> <tag>)", kind, selector, kind))
+	}
+	return selected, nil
+}
+
+// Expand turns a tag selector into a folder of the matching reference images
+// in the run's temp directory, so workflows take it like a folder given on
+// the command line. Other values are returned unchanged.
+func Expand(kind, value string) (string, error) {
+	if !IsSelector(value) {
+		return value, nil
+	}
+	items, err := Select(kind, value)
+	if err != nil {
+		return "", err
+	}
+	// Name the folder after the selector; workflows print it as the source
+	parent, err := os.MkdirTemp(workspace.TempDir(), "select-"+kind+"-")
+	if err != nil {
+		return "", errors.Wrap(err, errors.FileError, "failed to create selection folder")
+	}
+	name := strings.NewReplacer("/", "-", "\\", "-").Replace(strings.Join(selectorTerms(value), "+"))
+	dir := filepath.Join(parent, "tag-"+name)
+	if err := os.Mkdir(dir, 0755); err != nil {
+		return "", errors.Wrap(err, errors.FileError, "failed to create selection folder")
+	}
+	// Keep the file names, which name the outputs, apart from duplicates
+	taken := make(map[string]bool)
+	for _, item := range items {
+		ext := filepath.Ext(item.Path)
+		name := item.Name + ext
+		for n := 2; taken[name]; n++ {
+			name = fmt.Sprintf("%s-%d%s", item.Name, n, ext)
+		}
+		taken[name] = true
+		if err := linkOrCopy(item.Path, filepath.Join(dir, name)); err != nil {
+			return "", err
+		}
+	}
+	return dir, nil
+}
+
+// selectorTerms returns the normalized terms of a selector
+func selectorTerms(selector string) []string {
+	var terms []string
+	for _, term := range strings.Split(selector[len(SelectorPrefix):], ",") {
+		if term = normalizeTag(term); term != "" {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// matchesTerm reports whether an item is tagged with term or has it in its name
+func matchesTerm(item Item, term string) bool {
+	return slices.Contains(item.Tags, term) || strings.Contains(normalize(item.Name), normalize(term))
+}
+
+// linkOrCopy hard-links src to dest, copying it when the two are on
+// different filesystems
+func linkOrCopy(src, dest string) error {
+	if err := os.Link(src, dest); err == nil {
+		return nil
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return errors.Wrapf(err, errors.FileError, "failed to read %s", src)
+	}
+	defer in.Close()
+	out, err := os.Create(dest)
+	if err != nil {
+		return errors.Wrapf(err, errors.FileError, "failed to create %s", dest)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return errors.Wrapf(err, errors.FileError, "failed to copy %s", src)
+	}
+	if err := out.Close(); err != nil {
+		return errors.Wrapf(err, errors.FileError, "failed to write %s", dest)
+	}
+	return nil
+}
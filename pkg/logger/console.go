@@ -0,0 +1,155 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Options configures the default logger (see Setup)
+type Options struct {
+	Level   LogLevel // Least level written to the log file
+	Console LogLevel // Least level shown on the console
+	JSON    bool     // JSON records instead of text, on the console and in the file
+	File    string   // Log file to append to ("" = none)
+}
+
+// Setup makes the default logger show records from opts.Console up on
+// stderr, as short lines meant for people unless opts.JSON is set, and
+// append structured records from opts.Level up to opts.File. The returned
+// function closes the file.
+func Setup(opts Options) (func() error, error) {
+	var console slog.Handler
+	if opts.JSON {
+		console = slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: toSlogLevel(opts.Console)})
+	} else {
+		console = &consoleHandler{level: toSlogLevel(opts.Console), mu: &sync.Mutex{}}
+	}
+	SetDefault(slog.New(console)) // Also what reports a file that fails to open
+	if opts.File == "" {
+		return func() error { return nil }, nil
+	}
+
+	file, err := os.OpenFile(opts.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	fileOpts := &slog.HandlerOptions{Level: toSlogLevel(opts.Level), AddSource: opts.Level == DebugLevel}
+	var logFile slog.Handler = slog.NewTextHandler(file, fileOpts)
+	if opts.JSON {
+		logFile = slog.NewJSONHandler(file, fileOpts)
+	}
+	SetDefault(slog.New(teeHandler{console, logFile}))
+	return file.Close, nil
+}
+
+// consoleHandler writes records as one short line each: the message, then
+// its attributes as key=value
+type consoleHandler struct {
+	level  slog.Level
+	attrs  []slog.Attr
+	prefix string // Group of attributes added from now on, with a trailing dot
+	mu     *sync.Mutex
+}
+
+var levelMarks = map[slog.Level]string{
+	slog.LevelDebug: "🔧 ",
+	slog.LevelInfo:  "ℹ️  ",
+	slog.LevelWarn:  "⚠️  ",
+	slog.LevelError: "❌ ",
+}
+
+func (h *consoleHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *consoleHandler) Handle(_ context.Context, r slog.Record) error {
+	var line strings.Builder
+	line.WriteString(levelMarks[r.Level])
+	line.WriteString(r.Message)
+	write := func(a slog.Attr) {
+		if a.Equal(slog.Attr{}) {
+			return
+		}
+		value := a.Value.Resolve().String()
+		if value == "" || strings.ContainsAny(value, " \t\"=") {
+			value = strconv.Quote(value)
+		}
+		fmt.Fprintf(&line, "  %s=%s", a.Key, value)
+	}
+	for _, a := range h.attrs {
+		write(a)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		a.Key = h.prefix + a.Key
+		write(a)
+		return true
+	})
+	line.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := os.Stderr.WriteString(line.String())
+	return err
+}
+
+func (h *consoleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append([]slog.Attr(nil), h.attrs...)
+	for _, a := range attrs {
+		a.Key = h.prefix + a.Key
+		clone.attrs = append(clone.attrs, a)
+	}
+	return &clone
+}
+
+func (h *consoleHandler) WithGroup(name string) slog.Handler {
+	clone := *h
+	clone.prefix = h.prefix + name + "."
+	return &clone
+}
+
+// teeHandler sends each record to every handler that takes its level
+type teeHandler []slog.Handler
+
+func (t teeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range t {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t teeHandler) Handle(ctx context.Context, r slog.Record) error {
+	var firstErr error
+	for _, h := range t {
+		if !h.Enabled(ctx, r.Level) {
+			continue
+		}
+		if err := h.Handle(ctx, r.Clone()); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (t teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(teeHandler, len(t))
+	for i, h := range t {
+		handlers[i] = h.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+func (t teeHandler) WithGroup(name string) slog.Handler {
+	handlers := make(teeHandler, len(t))
+	for i, h := range t {
+		handlers[i] = h.WithGroup(name)
+	}
+	return handlers
+}
@@ -0,0 +1,58 @@
+package metadata
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+// EXIF tags written to IFD0
+const (
+	tagImageDescription = 0x010E
+	tagSoftware         = 0x0131
+	tagDateTime         = 0x0132
+)
+
+// buildEXIF returns a big-endian TIFF structure holding the basics of info as
+// ASCII tags of IFD0, the payload of a PNG eXIf chunk (and, after "Exif\0\0",
+// of a JPEG APP1 segment)
+func buildEXIF(info Info) []byte {
+	tags := map[uint16]string{
+		tagImageDescription: fmt.Sprintf("Generated by img-cli (%s workflow)", info.Workflow),
+		tagSoftware:         "img-cli",
+		tagDateTime:         info.Created.Format("2006:01:02 15:04:05"),
+	}
+	if info.Model != "" {
+		tags[tagSoftware] = fmt.Sprintf("img-cli (%s)", info.Model)
+	}
+	ids := make([]uint16, 0, len(tags))
+	for id := range tags {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	// Header, entry count, entries and next-IFD offset; values longer than 4
+	// bytes follow the IFD
+	buf := []byte("MM\x00\x2a")
+	buf = binary.BigEndian.AppendUint32(buf, 8)
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(ids)))
+	valueOffset := 8 + 2 + 12*len(ids) + 4
+	var values []byte
+	for _, id := range ids {
+		value := append([]byte(tags[id]), 0)
+		buf = binary.BigEndian.AppendUint16(buf, id)
+		buf = binary.BigEndian.AppendUint16(buf, 2) // ASCII
+		buf = binary.BigEndian.AppendUint32(buf, uint32(len(value)))
+		if len(value) <= 4 {
+			buf = append(buf, append(value, make([]byte, 4-len(value))...)...)
+			continue
+		}
+		buf = binary.BigEndian.AppendUint32(buf, uint32(valueOffset+len(values)))
+		values = append(values, value...)
+		if len(values)%2 == 1 {
+			values = append(values, 0) // Values start on word boundaries
+		}
+	}
+	buf = binary.BigEndian.AppendUint32(buf, 0)
+	return append(buf, values...)
+}
@@ -0,0 +1,105 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// JPEG markers and APP1 signatures
+const (
+	markerAPP0 = 0xE0
+	markerAPP1 = 0xE1
+	markerSOS  = 0xDA
+)
+
+var (
+	exifHeader = []byte("Exif\x00\x00")
+	xmpHeader  = []byte("http://ns.adobe.com/xap/1.0/\x00")
+)
+
+// maxSegment is the largest payload a JPEG segment can carry
+const maxSegment = 0xFFFF - 2
+
+// embedJPEG replaces the EXIF and XMP segments of a JPEG file. The new ones go
+// after the SOI marker and any APP0 (JFIF) segments, which must come first.
+func embedJPEG(data, packet, exif []byte) ([]byte, error) {
+	exifPayload := append(append([]byte{}, exifHeader...), exif...)
+	xmpPayload := append(append([]byte{}, xmpHeader...), packet...)
+	if len(xmpPayload) > maxSegment || len(exifPayload) > maxSegment {
+		return nil, fmt.Errorf("metadata too large for a JPEG segment")
+	}
+
+	var out bytes.Buffer
+	out.Write(jpegSOI)
+	inserted := false
+	insert := func() {
+		if !inserted {
+			out.Write(jpegSegment(markerAPP1, exifPayload))
+			out.Write(jpegSegment(markerAPP1, xmpPayload))
+			inserted = true
+		}
+	}
+	rest, ok := walkSegments(data, func(marker byte, segment, payload []byte) {
+		if marker == markerAPP1 && (bytes.HasPrefix(payload, exifHeader) || bytes.HasPrefix(payload, xmpHeader)) {
+			return
+		}
+		if marker != markerAPP0 {
+			insert()
+		}
+		out.Write(segment)
+	})
+	if !ok {
+		return nil, fmt.Errorf("truncated JPEG segment")
+	}
+	insert()
+	out.Write(rest)
+	return out.Bytes(), nil
+}
+
+// jpegXMP returns the XMP packet of a JPEG file, nil if it has none
+func jpegXMP(data []byte) ([]byte, error) {
+	var packet []byte
+	_, ok := walkSegments(data, func(marker byte, segment, payload []byte) {
+		if packet == nil && marker == markerAPP1 && bytes.HasPrefix(payload, xmpHeader) {
+			packet = payload[len(xmpHeader):]
+		}
+	})
+	if !ok {
+		return nil, fmt.Errorf("truncated JPEG segment")
+	}
+	return packet, nil
+}
+
+// walkSegments calls fn with every marker segment between the SOI marker and
+// the start of scan, and returns the rest of the file from the SOS marker on.
+// It reports false if the segments are malformed.
+func walkSegments(data []byte, fn func(marker byte, segment, payload []byte)) ([]byte, bool) {
+	pos := len(jpegSOI)
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return nil, false
+		}
+		marker := data[pos+1]
+		if marker == 0xFF {
+			pos++ // Fill byte
+			continue
+		}
+		if marker == markerSOS {
+			return data[pos:], true
+		}
+		end := pos + 2 + int(binary.BigEndian.Uint16(data[pos+2:]))
+		if end > len(data) || end < pos+4 {
+			return nil, false
+		}
+		fn(marker, data[pos:end], data[pos+4:end])
+		pos = end
+	}
+	return nil, false
+}
+
+func jpegSegment(marker byte, payload []byte) []byte {
+	buf := []byte{0xFF, marker}
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(payload)+2))
+	return append(buf, payload...)
+}
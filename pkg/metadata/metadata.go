@@ -0,0 +1,116 @@
+// Package metadata embeds the provenance of generated images in the image
+// files themselves, so it survives when they are copied out of the output tree
+// away from their sidecars. The full record is an XMP packet in the img-cli
+// namespace; the workflow, tool and creation time are also written as EXIF
+// tags for tools that don't read XMP. PNG and JPEG files are supported.
+//
+// Like content credentials, the record only holds file names and hashes: the
+// prompt and text components are recorded by the SHA-256 of their text.
+package metadata
+
+import (
+	"bytes"
+	stderrors "errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Namespace is the XMP namespace of img-cli's properties
+const Namespace = "http://ns.img-cli.org/1.0/"
+
+// ErrNoMetadata is returned by Read for images without an img-cli record
+var ErrNoMetadata = stderrors.New("no img-cli metadata")
+
+// Info is the provenance embedded in a generated image
+type Info struct {
+	Workflow     string // img-cli workflow that produced it
+	Provider     string // Image provider: gemini, openai, sd
+	Model        string // Generation model
+	Created      time.Time
+	PromptSHA256 string // Hex SHA-256 of the generation prompt
+	Inputs       []Input
+}
+
+// Input is one source image or text that went into a generation
+type Input struct {
+	Role   string // subject, outfit, style, ...
+	File   string // Base name of the input file; empty for text inputs
+	SHA256 string // Hex SHA-256 of the input file, or of the text for text inputs
+}
+
+var (
+	pngSignature = []byte("\x89PNG\r\n\x1a\n")
+	jpegSOI      = []byte{0xFF, 0xD8}
+)
+
+// Embed writes info into a PNG or JPEG file, replacing any img-cli record it
+// already carries
+func Embed(path string, info Info) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	packet := buildXMP(info)
+	exif := buildEXIF(info)
+
+	switch {
+	case bytes.HasPrefix(data, pngSignature):
+		data, err = embedPNG(data, packet, exif)
+	case bytes.HasPrefix(data, jpegSOI):
+		data, err = embedJPEG(data, packet, exif)
+	default:
+		err = fmt.Errorf("not a PNG or JPEG file")
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	return writeFile(path, data)
+}
+
+// Read returns the img-cli record embedded in a PNG or JPEG file, or
+// ErrNoMetadata if it has none
+func Read(path string) (*Info, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var packet []byte
+	switch {
+	case bytes.HasPrefix(data, pngSignature):
+		packet, err = pngXMP(data)
+	case bytes.HasPrefix(data, jpegSOI):
+		packet, err = jpegXMP(data)
+	default:
+		err = fmt.Errorf("not a PNG or JPEG file")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	if packet == nil {
+		return nil, ErrNoMetadata
+	}
+	return parseXMP(packet)
+}
+
+// writeFile replaces a file through a temporary file in the same directory
+func writeFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if info, err := os.Stat(path); err == nil {
+		os.Chmod(tmp.Name(), info.Mode())
+	}
+	return os.Rename(tmp.Name(), path)
+}
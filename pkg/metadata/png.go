@@ -0,0 +1,96 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+)
+
+// xmpKeyword is the iTXt keyword of XMP packets in PNG files
+const xmpKeyword = "XML:com.adobe.xmp"
+
+// embedPNG replaces the XMP and EXIF chunks of a PNG file. The new ones go
+// right after the IHDR chunk.
+func embedPNG(data, packet, exif []byte) ([]byte, error) {
+	if len(data) < len(pngSignature)+25 || string(data[12:16]) != "IHDR" {
+		return nil, fmt.Errorf("PNG does not start with an IHDR chunk")
+	}
+
+	// Keyword, compression flag and method, empty language tag and translated keyword
+	itxt := append([]byte(xmpKeyword), 0, 0, 0, 0, 0)
+	itxt = append(itxt, packet...)
+
+	var out bytes.Buffer
+	out.Write(pngSignature)
+	ok := walkChunks(data, func(typ string, chunk, payload []byte) {
+		if typ == "eXIf" || isXMPChunk(typ, payload) {
+			return
+		}
+		out.Write(chunk)
+		if typ == "IHDR" {
+			out.Write(pngChunk("eXIf", exif))
+			out.Write(pngChunk("iTXt", itxt))
+		}
+	})
+	if !ok {
+		return nil, fmt.Errorf("truncated PNG chunk")
+	}
+	return out.Bytes(), nil
+}
+
+// pngXMP returns the XMP packet of a PNG file, nil if it has none
+func pngXMP(data []byte) ([]byte, error) {
+	var packet []byte
+	ok := walkChunks(data, func(typ string, chunk, payload []byte) {
+		if packet != nil || !isXMPChunk(typ, payload) {
+			return
+		}
+		// Skip the keyword, the compression flag and method, and the two
+		// null-terminated strings after them
+		rest := payload[len(xmpKeyword)+1:]
+		if len(rest) < 2 || rest[0] != 0 {
+			return // Compressed packets aren't written by img-cli
+		}
+		rest = rest[2:]
+		for i := 0; i < 2; i++ {
+			end := bytes.IndexByte(rest, 0)
+			if end < 0 {
+				return
+			}
+			rest = rest[end+1:]
+		}
+		packet = rest
+	})
+	if !ok {
+		return nil, fmt.Errorf("truncated PNG chunk")
+	}
+	return packet, nil
+}
+
+func isXMPChunk(typ string, payload []byte) bool {
+	return typ == "iTXt" && bytes.HasPrefix(payload, append([]byte(xmpKeyword), 0))
+}
+
+// walkChunks calls fn with every chunk and its payload; it reports false if a
+// chunk runs past the end of the file
+func walkChunks(data []byte, fn func(typ string, chunk, payload []byte)) bool {
+	pos := len(pngSignature)
+	for pos+12 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		end := pos + 12 + length
+		if length < 0 || end > len(data) {
+			return false
+		}
+		fn(string(data[pos+4:pos+8]), data[pos:end], data[pos+8:end-4])
+		pos = end
+	}
+	return pos == len(data)
+}
+
+func pngChunk(typ string, payload []byte) []byte {
+	buf := binary.BigEndian.AppendUint32(nil, uint32(len(payload)))
+	buf = append(buf, typ...)
+	buf = append(buf, payload...)
+	return binary.BigEndian.AppendUint32(buf, crc32.ChecksumIEEE(buf[4:]))
+}
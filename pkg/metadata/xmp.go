@@ -0,0 +1,94 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// xmpDescription is the part of an XMP packet img-cli reads back
+type xmpDescription struct {
+	CreateDate   string `xml:"http://ns.adobe.com/xap/1.0/ CreateDate"`
+	Workflow     string `xml:"http://ns.img-cli.org/1.0/ Workflow"`
+	Provider     string `xml:"http://ns.img-cli.org/1.0/ Provider"`
+	Model        string `xml:"http://ns.img-cli.org/1.0/ Model"`
+	PromptSHA256 string `xml:"http://ns.img-cli.org/1.0/ PromptSHA256"`
+	Inputs       struct {
+		Items []struct {
+			Role   string `xml:"http://ns.img-cli.org/1.0/ Role"`
+			File   string `xml:"http://ns.img-cli.org/1.0/ File"`
+			SHA256 string `xml:"http://ns.img-cli.org/1.0/ SHA256"`
+		} `xml:"Bag>li"`
+	} `xml:"http://ns.img-cli.org/1.0/ Inputs"`
+}
+
+type xmpMeta struct {
+	Descriptions []xmpDescription `xml:"RDF>Description"`
+}
+
+// buildXMP serializes info as an XMP packet
+func buildXMP(info Info) []byte {
+	var b strings.Builder
+	b.WriteString("<?xpacket begin=\"\ufeff\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n")
+	b.WriteString("<x:xmpmeta xmlns:x=\"adobe:ns:meta/\">\n")
+	b.WriteString(" <rdf:RDF xmlns:rdf=\"http://www.w3.org/1999/02/22-rdf-syntax-ns#\">\n")
+	fmt.Fprintf(&b, "  <rdf:Description rdf:about=\"\" xmlns:xmp=\"http://ns.adobe.com/xap/1.0/\" xmlns:imgcli=%q>\n", Namespace)
+	property(&b, "xmp:CreatorTool", "img-cli")
+	property(&b, "xmp:CreateDate", info.Created.Format(time.RFC3339))
+	property(&b, "imgcli:Workflow", info.Workflow)
+	property(&b, "imgcli:Provider", info.Provider)
+	property(&b, "imgcli:Model", info.Model)
+	property(&b, "imgcli:PromptSHA256", info.PromptSHA256)
+	if len(info.Inputs) > 0 {
+		b.WriteString("   <imgcli:Inputs>\n    <rdf:Bag>\n")
+		for _, input := range info.Inputs {
+			b.WriteString("     <rdf:li rdf:parseType=\"Resource\">\n")
+			property(&b, "imgcli:Role", input.Role)
+			property(&b, "imgcli:File", input.File)
+			property(&b, "imgcli:SHA256", input.SHA256)
+			b.WriteString("     </rdf:li>\n")
+		}
+		b.WriteString("    </rdf:Bag>\n   </imgcli:Inputs>\n")
+	}
+	b.WriteString("  </rdf:Description>\n </rdf:RDF>\n</x:xmpmeta>\n")
+	b.WriteString("<?xpacket end=\"w\"?>")
+	return []byte(b.String())
+}
+
+// property writes one simple XMP property, skipping empty values
+func property(b *strings.Builder, name, value string) {
+	if value == "" {
+		return
+	}
+	var escaped bytes.Buffer
+	xml.EscapeText(&escaped, []byte(value))
+	fmt.Fprintf(b, "   <%s>%s</%s>\n", name, escaped.String(), name)
+}
+
+// parseXMP reads the img-cli record from an XMP packet, or ErrNoMetadata if
+// the packet was written by another tool
+func parseXMP(packet []byte) (*Info, error) {
+	var meta xmpMeta
+	if err := xml.Unmarshal(packet, &meta); err != nil {
+		return nil, fmt.Errorf("invalid XMP packet: %w", err)
+	}
+	for _, d := range meta.Descriptions {
+		if d.Workflow == "" {
+			continue
+		}
+		info := &Info{
+			Workflow:     d.Workflow,
+			Provider:     d.Provider,
+			Model:        d.Model,
+			PromptSHA256: d.PromptSHA256,
+		}
+		info.Created, _ = time.Parse(time.RFC3339, d.CreateDate)
+		for _, item := range d.Inputs.Items {
+			info.Inputs = append(info.Inputs, Input{Role: item.Role, File: item.File, SHA256: item.SHA256})
+		}
+		return info, nil
+	}
+	return nil, ErrNoMetadata
+}
@@ -12,6 +12,10 @@ type ModularComponents struct {
 	Makeup      *ComponentData
 	Expression  *ComponentData
 	Accessories *ComponentData
+	Pose        *ComponentData // Body pose, independent of the style reference
+	Background  *ComponentData // Environment, independent of the style reference
+
+	Extra map[string]*ComponentData // Registered components (see analyzer.Register) by analyzer type
 }
 
 // ComponentData holds analyzed data for a single component
@@ -20,4 +24,7 @@ type ComponentData struct {
 	Description string
 	JSONData    json.RawMessage
 	ImagePath   string
-}
\ No newline at end of file
+	Text        string   // Original text when the component came from a text description
+	Filters     []string // What filters removed from or added to the analyzed description
+	Weight      float64  // Prompt emphasis from a ":weight" suffix, 1 = normal (0 = not weighted)
+}
@@ -0,0 +1,118 @@
+// Package notify posts a run summary to a webhook when a long run finishes,
+// so unattended batches don't need the terminal watched. The payload carries
+// a "text" field for Slack, a "content" field for Discord and the structured
+// summary for anything else.
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/config"
+	"img-cli/pkg/cost"
+	"img-cli/pkg/errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxListedFailures caps the failures spelled out in the chat message; the
+// structured summary lists them all
+const maxListedFailures = 5
+
+// Run statuses
+const (
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+// Summary describes a finished run
+type Summary struct {
+	Command   string    `json:"command"`
+	Status    string    `json:"status"` // completed or failed
+	Images    int       `json:"images"`
+	Flagged   int       `json:"flagged"`
+	Failed    int       `json:"failed"`
+	Cost      float64   `json:"cost"` // Estimated, in dollars
+	OutputDir string    `json:"output_dir,omitempty"`
+	Started   time.Time `json:"started"`
+	Duration  string    `json:"duration"`
+	Error     string    `json:"error,omitempty"` // Why the run failed
+	Failures  []string  `json:"failures,omitempty"`
+}
+
+type payload struct {
+	Text    string  `json:"text"`
+	Content string  `json:"content"`
+	Summary Summary `json:"summary"`
+}
+
+// Send posts the summary to a webhook URL
+func Send(url string, summary Summary) error {
+	text := Message(summary)
+	data, err := json.Marshal(payload{Text: text, Content: text, Summary: summary})
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	client := &http.Client{Timeout: config.DefaultNotifyConfig().Timeout}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Message is the human-readable form of a summary, e.g.
+// "✅ img-cli outfit-swap completed in 42m10s: 24 images (2 flagged), $0.96"
+func Message(s Summary) string {
+	var b strings.Builder
+	icon, outcome := "✅", "completed in"
+	if s.Status == StatusFailed {
+		icon, outcome = "❌", "failed after"
+	}
+	fmt.Fprintf(&b, "%s img-cli %s %s %s: %d image(s)", icon, s.Command, outcome, s.Duration, s.Images)
+	var notes []string
+	if s.Flagged > 0 {
+		notes = append(notes, fmt.Sprintf("%d flagged", s.Flagged))
+	}
+	if s.Failed > 0 {
+		notes = append(notes, fmt.Sprintf("%d failed", s.Failed))
+	}
+	if len(notes) > 0 {
+		fmt.Fprintf(&b, " (%s)", strings.Join(notes, ", "))
+	}
+	fmt.Fprintf(&b, ", %s", cost.Format(s.Cost))
+
+	if s.Error != "" {
+		fmt.Fprintf(&b, "\nError: %s", s.Error)
+	}
+	if s.OutputDir != "" {
+		fmt.Fprintf(&b, "\nOutput: %s", s.OutputDir)
+	}
+	for i, failure := range s.Failures {
+		if i == maxListedFailures {
+			fmt.Fprintf(&b, "\n• ... and %d more", len(s.Failures)-i)
+			break
+		}
+		fmt.Fprintf(&b, "\n• %s", failure)
+	}
+	return b.String()
+}
+
+// Validate checks a webhook URL; empty means no notification
+func Validate(url string) error {
+	if url == "" {
+		return nil
+	}
+	lower := strings.ToLower(url)
+	if !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://") {
+		return errors.ErrInvalidInput("notify", fmt.Sprintf("expected an http(s) webhook URL, got %q", url))
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+// Package output reports what a command produced. In the default text format
+// commands print their progress and summaries as they go; with
+// --output-format json that text moves to stderr and the command's result is
+// written to stdout as a single JSON object when it ends, so other tools can
+// script the CLI.
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/errors"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Output formats
+const (
+	FormatText = "text" // Human-readable progress on stdout (default)
+	FormatJSON = "json" // One result object on stdout, progress on stderr
+)
+
+// Formats lists the accepted --output-format values
+var Formats = []string{FormatText, FormatJSON}
+
+// Result is the JSON object a command writes in the json format
+type Result struct {
+	Command  string                 `json:"command"`
+	Status   string                 `json:"status"` // "ok", "cancelled" or "error"
+	Duration string                 `json:"duration,omitempty"`
+	Outputs  []string               `json:"outputs,omitempty"` // Files the command wrote
+	Counts   map[string]int         `json:"counts,omitempty"`  // e.g. images, failed, flagged
+	Cost     *Cost                  `json:"cost,omitempty"`
+	Cache    *CacheStats            `json:"cache,omitempty"`
+	Data     map[string]interface{} `json:"data,omitempty"` // Command-specific details
+	Error    *errors.Report         `json:"error,omitempty"`
+}
+
+// Cost is what a command estimated and spent on image generation
+type Cost struct {
+	EstimatedImages int     `json:"estimated_images,omitempty"`
+	EstimatedUSD    float64 `json:"estimated_usd,omitempty"`
+	Images          int     `json:"images"`
+	SpentUSD        float64 `json:"spent_usd"`
+	PerImageUSD     float64 `json:"per_image_usd"`
+}
+
+// CacheStats are the analysis cache lookups and writes of a command
+type CacheStats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+	Writes int64 `json:"writes"`
+}
+
+var (
+	mu        sync.Mutex
+	format              = FormatText
+	textOut   io.Writer = os.Stdout // Where progress and summaries go
+	resultOut io.Writer = os.Stdout // Where the json result goes
+	started   time.Time
+	cancelled bool
+	result    = Result{Counts: map[string]int{}, Data: map[string]interface{}{}}
+)
+
+// SetFormat selects the output format; call Start afterwards
+func SetFormat(name string) error {
+	switch name {
+	case "", FormatText:
+		format = FormatText
+	case FormatJSON:
+		format = FormatJSON
+	default:
+		return errors.ErrInvalidInput("output-format", fmt.Sprintf("unknown format %q (use %s or %s)", name, FormatText, FormatJSON))
+	}
+	return nil
+}
+
+// JSON reports whether the result is written as JSON
+func JSON() bool {
+	return format == FormatJSON
+}
+
+// Start begins the result of command. In the json format the command's
+// text goes to stderr instead, keeping stdout for the result.
+func Start(command string) {
+	mu.Lock()
+	defer mu.Unlock()
+	result.Command = command
+	started = time.Now()
+	textOut = os.Stdout
+	if format == FormatJSON {
+		textOut = os.Stderr
+	}
+}
+
+// Writer returns where the command's text goes: stdout, or stderr in the
+// json format. Terminal prompts and progress bars write here too.
+func Writer() io.Writer {
+	mu.Lock()
+	defer mu.Unlock()
+	return textOut
+}
+
+// AddFiles records files the command wrote
+func AddFiles(paths ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, path := range paths {
+		if path != "" {
+			result.Outputs = append(result.Outputs, path)
+		}
+	}
+}
+
+// Count sets a count of the result, e.g. Count("images", 12)
+func Count(name string, n int) {
+	mu.Lock()
+	defer mu.Unlock()
+	result.Counts[name] = n
+}
+
+// Set records a command-specific detail of the result
+func Set(key string, value interface{}) {
+	mu.Lock()
+	defer mu.Unlock()
+	result.Data[key] = value
+}
+
+// SetCost records what the command estimated and spent
+func SetCost(c Cost) {
+	mu.Lock()
+	defer mu.Unlock()
+	result.Cost = &c
+}
+
+// SetCache records the command's cache lookups and writes
+func SetCache(c CacheStats) {
+	mu.Lock()
+	defer mu.Unlock()
+	result.Cache = &c
+}
+
+// Cancel marks the result as cancelled, e.g. when the user declines the cost
+func Cancel() {
+	mu.Lock()
+	defer mu.Unlock()
+	cancelled = true
+}
+
+// Finish writes the result in the json format, with err as its error. It
+// does nothing in the text format.
+func Finish(err error) {
+	if format != FormatJSON {
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+
+	result.Status = "ok"
+	if cancelled {
+		result.Status = "cancelled"
+	}
+	if err != nil {
+		result.Status = "error"
+		result.Error = errors.NewReport(err)
+	}
+	if result.Command == "" {
+		result.Command = "img-cli" // Failed before a command ran, e.g. on a bad flag
+	}
+	if !started.IsZero() {
+		result.Duration = time.Since(started).Round(time.Millisecond).String()
+	}
+	sort.Strings(result.Outputs)
+
+	data, jerr := json.MarshalIndent(result, "", "  ")
+	if jerr != nil {
+		fmt.Fprintf(os.Stderr, "failed to encode the result: %v\n", jerr)
+		return
+	}
+	fmt.Fprintln(resultOut, string(data))
+}
@@ -0,0 +1,84 @@
+package output
+
+import (
+	"fmt"
+)
+
+// Verbosity selects which console messages are shown
+type Verbosity int
+
+const (
+	Quiet   Verbosity = iota - 1 // --quiet: results, warnings and errors only
+	Normal                       // Results and progress
+	Verbose                      // --verbose: also details of each step
+)
+
+var verbosity = Normal
+
+// SetVerbosity selects which console messages are shown
+func SetVerbosity(v Verbosity) {
+	mu.Lock()
+	defer mu.Unlock()
+	verbosity = v
+}
+
+// Printer writes console messages of one kind: Progress, Detail, or with
+// the package functions, results. Messages go to Writer: stdout, or stderr
+// in the json format.
+type Printer struct {
+	min Verbosity // Least verbosity showing its messages
+}
+
+var (
+	// Progress reports what a command is doing; --quiet hides it
+	Progress = &Printer{min: Normal}
+	// Detail reports the inner steps of a command; only --verbose shows it
+	Detail = &Printer{min: Verbose}
+	// results are what a command was asked for: listings, summaries,
+	// requested prompt dumps; always shown
+	results = &Printer{min: Quiet}
+)
+
+// Enabled reports whether the printer's messages are shown, for callers
+// that build expensive messages
+func (p *Printer) Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return verbosity >= p.min
+}
+
+// Printf writes a formatted message
+func (p *Printer) Printf(format string, a ...interface{}) {
+	if p.Enabled() {
+		fmt.Fprintf(Writer(), format, a...)
+	}
+}
+
+// Println writes its operands followed by a newline
+func (p *Printer) Println(a ...interface{}) {
+	if p.Enabled() {
+		fmt.Fprintln(Writer(), a...)
+	}
+}
+
+// Print writes its operands
+func (p *Printer) Print(a ...interface{}) {
+	if p.Enabled() {
+		fmt.Fprint(Writer(), a...)
+	}
+}
+
+// Printf writes part of a command's result, shown even with --quiet
+func Printf(format string, a ...interface{}) {
+	results.Printf(format, a...)
+}
+
+// Println writes part of a command's result followed by a newline
+func Println(a ...interface{}) {
+	results.Println(a...)
+}
+
+// Print writes part of a command's result
+func Print(a ...interface{}) {
+	results.Print(a...)
+}
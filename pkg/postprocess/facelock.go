@@ -0,0 +1,196 @@
+// Package postprocess changes generated images after generation using their
+// source images, such as pasting the subject's real face back over a face the
+// model drifted from (face lock). Everything here runs locally on pixels;
+// locating faces is left to the caller, which asks the vision model.
+package postprocess
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"img-cli/pkg/imaging"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FaceFeather is the share of the face's radius over which the pasted face
+// fades into the generated image
+const FaceFeather = 0.3
+
+// maxColorGain limits how far FaceLock stretches the source face's contrast to
+// match the output's lighting
+const maxColorGain = 2.0
+
+// FaceLock pastes the face of the source image over the face of a generated
+// image and overwrites it. The faces are given as boxes: the source face is
+// scaled and moved onto the output's, matched to the output's color and
+// brightness so it takes on the new lighting, and blended through an ellipse
+// inside the output box whose edge fades over FaceFeather of its radius, so
+// hair, ears and everything around the face stay generated.
+func FaceLock(sourcePath, outputPath string, sourceFace, outputFace image.Rectangle) error {
+	source, err := imaging.Load(sourcePath)
+	if err != nil {
+		return err
+	}
+	output, err := imaging.Load(outputPath)
+	if err != nil {
+		return err
+	}
+	sourceFace = sourceFace.Intersect(source.Bounds())
+	outputFace = outputFace.Intersect(output.Bounds())
+	if sourceFace.Dx() < 8 || sourceFace.Dy() < 8 || outputFace.Dx() < 8 || outputFace.Dy() < 8 {
+		return fmt.Errorf("face too small to lock (source %dx%d, output %dx%d)",
+			sourceFace.Dx(), sourceFace.Dy(), outputFace.Dx(), outputFace.Dy())
+	}
+
+	// One scale for both axes keeps the face's proportions
+	scale := (float64(outputFace.Dx())/float64(sourceFace.Dx()) + float64(outputFace.Dy())/float64(sourceFace.Dy())) / 2
+	ocx, ocy := center(outputFace)
+	scx, scy := center(sourceFace)
+	rx, ry := float64(outputFace.Dx())/2, float64(outputFace.Dy())/2
+
+	// Sample the source face into the output's frame
+	type pixel struct {
+		x, y   int
+		weight float64
+		rgb    [3]float64
+	}
+	var pixels []pixel
+	var sourceStats, outputStats channelStats
+	for y := outputFace.Min.Y; y < outputFace.Max.Y; y++ {
+		for x := outputFace.Min.X; x < outputFace.Max.X; x++ {
+			dx, dy := (float64(x)+0.5-ocx)/rx, (float64(y)+0.5-ocy)/ry
+			d := math.Sqrt(dx*dx + dy*dy)
+			if d >= 1 {
+				continue
+			}
+			rgb, ok := sampleBilinear(source, scx+(float64(x)+0.5-ocx)/scale, scy+(float64(y)+0.5-ocy)/scale)
+			if !ok {
+				continue
+			}
+			weight := fade(d)
+			pixels = append(pixels, pixel{x, y, weight, rgb})
+			if weight == 1 {
+				sourceStats.add(rgb)
+				outputStats.add(rgbAt(output, x, y))
+			}
+		}
+	}
+	if sourceStats.n == 0 {
+		return fmt.Errorf("the faces don't overlap the images")
+	}
+
+	ob := output.Bounds()
+	result := image.NewNRGBA(image.Rect(0, 0, ob.Dx(), ob.Dy()))
+	draw.Draw(result, result.Rect, output, ob.Min, draw.Src)
+	for _, p := range pixels {
+		base := rgbAt(output, p.x, p.y)
+		matched := matchColor(p.rgb, sourceStats, outputStats)
+		var c [3]uint8
+		for i := range c {
+			c[i] = uint8(math.Round(clamp255(base[i] + (matched[i]-base[i])*p.weight)))
+		}
+		result.SetNRGBA(p.x-ob.Min.X, p.y-ob.Min.Y, color.NRGBA{c[0], c[1], c[2], 255})
+	}
+
+	// Write beside the output first so a crash never leaves a half-written image
+	ext := filepath.Ext(outputPath)
+	tempPath := strings.TrimSuffix(outputPath, ext) + ".facelock" + ext
+	if err := imaging.Save(tempPath, result); err != nil {
+		return err
+	}
+	if err := os.Rename(tempPath, outputPath); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	return nil
+}
+
+// fade is the blend weight at elliptical distance d from the face's center:
+// 1 inside, easing to 0 at the edge over FaceFeather
+func fade(d float64) float64 {
+	if d <= 1-FaceFeather {
+		return 1
+	}
+	t := (1 - d) / FaceFeather
+	return t * t * (3 - 2*t)
+}
+
+func center(r image.Rectangle) (float64, float64) {
+	return float64(r.Min.X+r.Max.X) / 2, float64(r.Min.Y+r.Max.Y) / 2
+}
+
+// channelStats accumulates the mean and spread of RGB samples
+type channelStats struct {
+	n          int
+	sum, sumSq [3]float64
+}
+
+func (s *channelStats) add(rgb [3]float64) {
+	s.n++
+	for i, v := range rgb {
+		s.sum[i] += v
+		s.sumSq[i] += v * v
+	}
+}
+
+func (s *channelStats) mean(i int) float64 {
+	return s.sum[i] / float64(s.n)
+}
+
+func (s *channelStats) stddev(i int) float64 {
+	m := s.mean(i)
+	return math.Sqrt(math.Max(s.sumSq[i]/float64(s.n)-m*m, 0))
+}
+
+// matchColor moves a source face color to the output face's mean and spread
+// per channel, so the pasted face takes on the generated lighting
+func matchColor(rgb [3]float64, source, output channelStats) [3]float64 {
+	var matched [3]float64
+	for i, v := range rgb {
+		gain := 1.0
+		if sd := source.stddev(i); sd > 1 {
+			gain = math.Min(math.Max(output.stddev(i)/sd, 1/maxColorGain), maxColorGain)
+		}
+		matched[i] = clamp255((v-source.mean(i))*gain + output.mean(i))
+	}
+	return matched
+}
+
+// sampleBilinear reads img at a fractional pixel position, reporting false
+// outside the image
+func sampleBilinear(img image.Image, fx, fy float64) ([3]float64, bool) {
+	b := img.Bounds()
+	fx, fy = fx-0.5, fy-0.5
+	if fx < float64(b.Min.X)-0.5 || fy < float64(b.Min.Y)-0.5 || fx > float64(b.Max.X)-0.5 || fy > float64(b.Max.Y)-0.5 {
+		return [3]float64{}, false
+	}
+	x0, y0 := int(math.Floor(fx)), int(math.Floor(fy))
+	tx, ty := fx-float64(x0), fy-float64(y0)
+	clampX := func(x int) int { return min(max(x, b.Min.X), b.Max.X-1) }
+	clampY := func(y int) int { return min(max(y, b.Min.Y), b.Max.Y-1) }
+	p00 := rgbAt(img, clampX(x0), clampY(y0))
+	p10 := rgbAt(img, clampX(x0+1), clampY(y0))
+	p01 := rgbAt(img, clampX(x0), clampY(y0+1))
+	p11 := rgbAt(img, clampX(x0+1), clampY(y0+1))
+	var out [3]float64
+	for i := range out {
+		top := p00[i] + (p10[i]-p00[i])*tx
+		bottom := p01[i] + (p11[i]-p01[i])*tx
+		out[i] = top + (bottom-top)*ty
+	}
+	return out, true
+}
+
+// rgbAt returns the 8-bit RGB values of a pixel as floats
+func rgbAt(img image.Image, x, y int) [3]float64 {
+	r, g, b, _ := img.At(x, y).RGBA()
+	return [3]float64{float64(r >> 8), float64(g >> 8), float64(b >> 8)}
+}
+
+func clamp255(v float64) float64 {
+	return math.Min(math.Max(v, 0), 255)
+}
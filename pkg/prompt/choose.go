@@ -0,0 +1,275 @@
+package prompt
+
+import (
+	"bufio"
+	"fmt"
+	"img-cli/pkg/output"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Option is one answer Dialog.Choose offers
+type Option struct {
+	Label string // Shown in the list and matched against what the user types
+	Value string // Returned when chosen ("" = the label)
+}
+
+func (o Option) value() string {
+	if o.Value == "" {
+		return o.Label
+	}
+	return o.Value
+}
+
+// ChooseOptions tunes Dialog.Choose for a question
+type ChooseOptions struct {
+	Multi     bool   // Several answers, separated by commas or spaces
+	Optional  bool   // An empty answer chooses nothing
+	AllowText bool   // Text matching no option is returned as typed (descriptions, paths)
+	Default   string // Chosen on an empty answer
+}
+
+// maxListed is how many options Choose lists before asking for a filter
+const maxListed = 15
+
+// Dialog asks a series of questions on one input, like the wizard command
+type Dialog struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// NewDialog asks on the terminal
+func NewDialog() *Dialog {
+	return newDialog(os.Stdin, output.Writer())
+}
+
+func newDialog(in io.Reader, out io.Writer) *Dialog {
+	return &Dialog{in: bufio.NewReader(in), out: out}
+}
+
+// Printf writes to the dialog's output
+func (d *Dialog) Printf(format string, args ...interface{}) {
+	fmt.Fprintf(d.out, format, args...)
+}
+
+// Ask asks for free text, returning def on an empty answer. io.EOF means
+// the input ended.
+func (d *Dialog) Ask(question, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(d.out, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(d.out, "%s: ", question)
+	}
+	line, err := d.in.ReadString('\n')
+	if err != nil && (err != io.EOF || strings.TrimSpace(line) == "") {
+		return "", err
+	}
+	if answer := strings.TrimSpace(line); answer != "" {
+		return answer, nil
+	}
+	return def, nil
+}
+
+// AskInt asks for a whole number of at least min
+func (d *Dialog) AskInt(question string, def, min int) (int, error) {
+	for {
+		answer, err := d.Ask(question, strconv.Itoa(def))
+		if err != nil {
+			return 0, err
+		}
+		n, err := strconv.Atoi(answer)
+		if err == nil && n >= min {
+			return n, nil
+		}
+		fmt.Fprintf(d.out, "Enter a number of at least %d\n", min)
+	}
+}
+
+// Choose asks for one option, or several with Multi. The options are listed
+// with numbers; the user answers with numbers ("2", "1,4", "3-5") or with
+// text, which picks the option it names or fuzzy-matches ("shrlng" finds
+// "shearling-black"). Text matching several options lists those to choose
+// from. It returns the chosen values.
+func (d *Dialog) Choose(question string, options []Option, opts ChooseOptions) ([]string, error) {
+	shown := options
+	fmt.Fprintf(d.out, "\n%s\n", question)
+	for {
+		d.list(shown)
+		answer, err := d.Ask(d.hint(opts, len(options) > 0), opts.Default)
+		if err != nil {
+			return nil, err
+		}
+		if answer == "" {
+			if opts.Optional {
+				return nil, nil
+			}
+			fmt.Fprintln(d.out, "An answer is required")
+			continue
+		}
+		if answer == "?" {
+			shown = options
+			continue
+		}
+
+		if selected, ok := parseRowSpec(strings.ReplaceAll(answer, " ", ""), len(shown)); ok {
+			if len(selected) > 1 && !opts.Multi {
+				fmt.Fprintln(d.out, "Choose one")
+				continue
+			}
+			var values []string
+			for _, i := range selected {
+				values = append(values, shown[i].value())
+			}
+			return values, nil
+		}
+
+		terms := []string{answer}
+		if opts.Multi {
+			terms = strings.FieldsFunc(answer, func(r rune) bool { return r == ',' || r == ' ' })
+		}
+		var values []string
+		var narrowed []Option
+		for _, term := range terms {
+			// Descriptions would often fuzzy-match some name, so text that
+			// may be one only picks options containing it
+			matches := matchOptions(options, term, !opts.AllowText)
+			switch {
+			case len(matches) == 1:
+				values = append(values, matches[0].value())
+			case len(matches) > 1:
+				fmt.Fprintf(d.out, "%q matches %d options\n", term, len(matches))
+				narrowed = matches
+			case opts.AllowText:
+				values = append(values, term)
+			default:
+				fmt.Fprintf(d.out, "Nothing matches %q (? lists every option)\n", term)
+				narrowed = shown
+			}
+		}
+		if narrowed != nil {
+			shown = narrowed
+			continue
+		}
+		for _, value := range values {
+			if !containsValue(options, value) {
+				continue
+			}
+			fmt.Fprintf(d.out, "  → %s\n", value)
+		}
+		return values, nil
+	}
+}
+
+// Confirm asks a yes/no question
+func (d *Dialog) Confirm(question string, def bool) (bool, error) {
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+	answer, err := d.Ask(fmt.Sprintf("%s (%s)", question, hint), "")
+	if err != nil {
+		return false, err
+	}
+	if answer == "" {
+		return def, nil
+	}
+	answer = strings.ToLower(answer)
+	return answer == "y" || answer == "yes", nil
+}
+
+func (d *Dialog) list(options []Option) {
+	width := len(strconv.Itoa(min(len(options), maxListed)))
+	for i, option := range options {
+		if i == maxListed {
+			fmt.Fprintf(d.out, "  ... %d more: type part of a name to filter\n", len(options)-maxListed)
+			break
+		}
+		fmt.Fprintf(d.out, "  %*d) %s\n", width, i+1, option.Label)
+	}
+}
+
+func (d *Dialog) hint(opts ChooseOptions, listed bool) string {
+	var hints []string
+	switch {
+	case !listed:
+		hints = append(hints, "a path or description")
+	case opts.Multi:
+		hints = append(hints, "numbers or names, several allowed")
+	default:
+		hints = append(hints, "number or name")
+	}
+	if opts.AllowText && listed {
+		hints = append(hints, "or a path or description")
+	}
+	if opts.Optional && opts.Default == "" {
+		hints = append(hints, "empty to skip")
+	}
+	return "  " + strings.Join(hints, ", ")
+}
+
+// matchOptions returns the options text names: the one whose label or value
+// equals it (ignoring case), else those containing it, else, when loose,
+// those containing its letters in order; closest first
+func matchOptions(options []Option, text string, loose bool) []Option {
+	want := strings.ToLower(strings.TrimSpace(text))
+	if want == "" {
+		return nil
+	}
+	type match struct {
+		option Option
+		rank   int
+	}
+	var matches []match
+	for _, option := range options {
+		label := strings.ToLower(option.Label)
+		switch {
+		case label == want || strings.ToLower(option.value()) == want:
+			return []Option{option}
+		case strings.HasPrefix(label, want):
+			matches = append(matches, match{option, 0})
+		case strings.Contains(label, want):
+			matches = append(matches, match{option, 1})
+		case loose && isSubsequence(want, label):
+			matches = append(matches, match{option, 2})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].rank != matches[j].rank {
+			return matches[i].rank < matches[j].rank
+		}
+		return len(matches[i].option.Label) < len(matches[j].option.Label)
+	})
+	// Prefix and substring matches hide the looser ones
+	var found []Option
+	for _, m := range matches {
+		if len(found) > 0 && m.rank == 2 && matches[0].rank < 2 {
+			break
+		}
+		found = append(found, m.option)
+	}
+	return found
+}
+
+// isSubsequence reports whether the letters of want appear in s in order
+func isSubsequence(want, s string) bool {
+	letters := []rune(want)
+	i := 0
+	for _, r := range s {
+		if i < len(letters) && letters[i] == r {
+			i++
+		}
+	}
+	return i == len(letters)
+}
+
+func containsValue(options []Option, value string) bool {
+	for _, option := range options {
+		if option.value() == value {
+			return true
+		}
+	}
+	return false
+}
@@ -3,6 +3,7 @@ package prompt
 import (
 	"bufio"
 	"fmt"
+	"img-cli/pkg/output"
 	"os"
 	"strings"
 )
@@ -11,10 +12,10 @@ import (
 func ConfirmExpensiveOperation(message string, cost string) (bool, error) {
 	reader := bufio.NewReader(os.Stdin)
 
-	fmt.Printf("\n⚠️  COST WARNING ⚠️\n")
-	fmt.Printf("%s\n", message)
-	fmt.Printf("Estimated cost: %s\n", cost)
-	fmt.Printf("\nDo you want to proceed? (yes/no): ")
+	fmt.Fprintf(output.Writer(), "\n⚠️  COST WARNING ⚠️\n")
+	fmt.Fprintf(output.Writer(), "%s\n", message)
+	fmt.Fprintf(output.Writer(), "Estimated cost: %s\n", cost)
+	fmt.Fprintf(output.Writer(), "\nDo you want to proceed? (yes/no): ")
 
 	response, err := reader.ReadString('\n')
 	if err != nil {
@@ -27,6 +28,6 @@ func ConfirmExpensiveOperation(message string, cost string) (bool, error) {
 
 // ShowCostEstimate displays a cost estimate without requiring confirmation
 func ShowCostEstimate(message string, cost string) {
-	fmt.Printf("\n💰 Cost Estimate: %s\n", cost)
-	fmt.Printf("%s\n\n", message)
+	fmt.Fprintf(output.Writer(), "\n💰 Cost Estimate: %s\n", cost)
+	fmt.Fprintf(output.Writer(), "%s\n\n", message)
 }
\ No newline at end of file
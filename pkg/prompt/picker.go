@@ -0,0 +1,195 @@
+package prompt
+
+import (
+	"bufio"
+	"fmt"
+	"img-cli/pkg/output"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// PickRow is one planned unit of work shown by PickRows
+type PickRow struct {
+	Label      string
+	Variations int // Images for this row; 0 leaves it out
+}
+
+const pickerHelp = `Commands:
+  3  3-7  2,5,9     toggle rows
+  on <rows|text>    include rows (text matches any row containing it)
+  off <rows|text>   leave rows out
+  v <rows|text> N   set variations for rows
+  all / none        include or leave out every row
+  go                launch the included rows
+  q                 cancel`
+
+// PickRows prints the planned rows and reads line commands from stdin to
+// toggle them and change their variations, reprinting the rows and the total
+// cost after each one. cost converts an image count into dollars. It returns the rows with their
+// final variations, or ok=false if the user cancelled.
+func PickRows(rows []PickRow, cost func(images int) float64) ([]PickRow, bool, error) {
+	return pickRows(os.Stdin, output.Writer(), rows, cost)
+}
+
+func pickRows(in io.Reader, out io.Writer, rows []PickRow, cost func(images int) float64) ([]PickRow, bool, error) {
+	rows = append([]PickRow(nil), rows...)
+	// Variations a row returns to when toggled back on
+	restore := make([]int, len(rows))
+	for i, row := range rows {
+		restore[i] = max(row.Variations, 1)
+	}
+
+	reader := bufio.NewReader(in)
+	fmt.Fprintln(out, "\n"+pickerHelp)
+	for {
+		printPickTable(out, rows, cost)
+		fmt.Fprint(out, "pick> ")
+
+		line, err := reader.ReadString('\n')
+		if err != nil && (err != io.EOF || strings.TrimSpace(line) == "") {
+			if err == io.EOF {
+				return nil, false, nil
+			}
+			return nil, false, err
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch command := strings.ToLower(fields[0]); command {
+		case "go":
+			if pickedImages(rows) == 0 {
+				fmt.Fprintln(out, "Nothing selected")
+				continue
+			}
+			return rows, true, nil
+		case "q", "quit":
+			return nil, false, nil
+		case "?", "h", "help":
+			fmt.Fprintln(out, pickerHelp)
+		case "all", "none":
+			for i := range rows {
+				rows[i].Variations = 0
+				if command == "all" {
+					rows[i].Variations = restore[i]
+				}
+			}
+		case "on", "off":
+			if len(fields) < 2 {
+				fmt.Fprintf(out, "Usage: %s <rows|text>\n", command)
+				continue
+			}
+			selected := selectRows(rows, strings.Join(fields[1:], " "))
+			if len(selected) == 0 {
+				fmt.Fprintln(out, "No matching rows")
+			}
+			for _, i := range selected {
+				rows[i].Variations = 0
+				if command == "on" {
+					rows[i].Variations = restore[i]
+				}
+			}
+		case "v":
+			count, err := strconv.Atoi(fields[len(fields)-1])
+			if len(fields) < 3 || err != nil || count < 0 {
+				fmt.Fprintln(out, "Usage: v <rows|text> <variations>")
+				continue
+			}
+			selected := selectRows(rows, strings.Join(fields[1:len(fields)-1], " "))
+			if len(selected) == 0 {
+				fmt.Fprintln(out, "No matching rows")
+			}
+			for _, i := range selected {
+				rows[i].Variations = count
+				if count > 0 {
+					restore[i] = count
+				}
+			}
+		default:
+			selected, ok := parseRowSpec(strings.Join(fields, ""), len(rows))
+			if !ok {
+				fmt.Fprintf(out, "Unknown command %q (? for help)\n", strings.TrimSpace(line))
+				continue
+			}
+			for _, i := range selected {
+				if rows[i].Variations > 0 {
+					rows[i].Variations = 0
+				} else {
+					rows[i].Variations = restore[i]
+				}
+			}
+		}
+	}
+}
+
+func printPickTable(out io.Writer, rows []PickRow, cost func(images int) float64) {
+	width := len(strconv.Itoa(len(rows)))
+	fmt.Fprintln(out)
+	for i, row := range rows {
+		mark := " "
+		if row.Variations > 0 {
+			mark = "x"
+		}
+		fmt.Fprintf(out, "  %*d [%s] ×%-2d %s\n", width, i+1, mark, row.Variations, row.Label)
+	}
+	images := pickedImages(rows)
+	picked := 0
+	for _, row := range rows {
+		if row.Variations > 0 {
+			picked++
+		}
+	}
+	fmt.Fprintf(out, "\n  %d of %d rows, %d images, $%.2f\n", picked, len(rows), images, cost(images))
+}
+
+func pickedImages(rows []PickRow) int {
+	total := 0
+	for _, row := range rows {
+		total += row.Variations
+	}
+	return total
+}
+
+// selectRows resolves a row spec, or failing that every row whose label
+// contains the text (case-insensitive)
+func selectRows(rows []PickRow, spec string) []int {
+	if selected, ok := parseRowSpec(strings.ReplaceAll(spec, " ", ""), len(rows)); ok {
+		return selected
+	}
+	var selected []int
+	text := strings.ToLower(spec)
+	for i, row := range rows {
+		if strings.Contains(strings.ToLower(row.Label), text) {
+			selected = append(selected, i)
+		}
+	}
+	return selected
+}
+
+// parseRowSpec parses 1-based rows like "3", "3-7" or "2,5,9-11" into indices
+func parseRowSpec(spec string, count int) ([]int, bool) {
+	var selected []int
+	for _, part := range strings.Split(spec, ",") {
+		from, to, isRange := strings.Cut(part, "-")
+		start, err := strconv.Atoi(from)
+		if err != nil {
+			return nil, false
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(to); err != nil {
+				return nil, false
+			}
+		}
+		if start < 1 || end > count || start > end {
+			return nil, false
+		}
+		for i := start; i <= end; i++ {
+			selected = append(selected, i-1)
+		}
+	}
+	return selected, len(selected) > 0
+}
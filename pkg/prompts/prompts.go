@@ -0,0 +1,181 @@
+// Package prompts renders the text sent to the models from Go text/templates.
+// The built-in templates are embedded in the binary; a file with the same name
+// in the prompts folder replaces one, so prompts can be tuned without
+// recompiling.
+package prompts
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/workspace"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// Ext is the file extension of a template
+const Ext = ".tmpl"
+
+//go:embed defaults/*.tmpl
+var defaults embed.FS
+
+// parsed caches templates by source; overrides are re-read when they change
+var (
+	mu     sync.Mutex
+	parsed = make(map[string]parsedTemplate)
+)
+
+type parsedTemplate struct {
+	modTime time.Time
+	tmpl    *template.Template
+}
+
+// Dir is the folder whose templates replace the built-in ones:
+// IMG_CLI_PROMPTS_DIR (prompts_dir in the config file) or prompts/ in the
+// project root
+func Dir() string {
+	if dir := os.Getenv("IMG_CLI_PROMPTS_DIR"); dir != "" {
+		return workspace.Resolve(dir)
+	}
+	return workspace.Path("prompts")
+}
+
+// Names lists the built-in templates
+func Names() []string {
+	entries, _ := fs.ReadDir(defaults, "defaults")
+	var names []string
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), Ext))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Default returns the source of a built-in template
+func Default(name string) (string, bool) {
+	data, err := defaults.ReadFile("defaults/" + name + Ext)
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+// Override returns the path of the template that replaces a built-in one, or
+// "" when the built-in template is used
+func Override(name string) string {
+	path := filepath.Join(Dir(), name+Ext)
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		return path
+	}
+	return ""
+}
+
+// Version identifies the text of the template a name renders with: a short
+// hash of the replacement file, or of the built-in template
+func Version(name string) string {
+	source, _ := Default(name)
+	if path := Override(name); path != "" {
+		if data, err := os.ReadFile(path); err == nil {
+			source = string(data)
+		}
+	}
+	sum := sha256.Sum256([]byte(source))
+	return hex.EncodeToString(sum[:6])
+}
+
+// Render executes the named template with data. A replacement template that
+// fails to parse or execute is an error, not a fall back to the built-in one,
+// so a broken edit never silently changes what a run sends.
+func Render(name string, data any) (string, error) {
+	if path := Override(name); path != "" {
+		tmpl, err := loadFile(path)
+		if err != nil {
+			return "", errors.Wrapf(err, errors.ConfigError, "prompt template %s is invalid", path)
+		}
+		out, err := execute(tmpl, data)
+		if err != nil {
+			return "", errors.Wrapf(err, errors.ConfigError, "prompt template %s failed", path)
+		}
+		return out, nil
+	}
+
+	tmpl, err := loadDefault(name)
+	if err != nil {
+		return "", errors.Wrapf(err, errors.InternalError, "built-in prompt template %s is invalid", name)
+	}
+	out, err := execute(tmpl, data)
+	if err != nil {
+		return "", errors.Wrapf(err, errors.InternalError, "built-in prompt template %s failed", name)
+	}
+	return out, nil
+}
+
+// funcs are available to every template
+func funcs() template.FuncMap {
+	return template.FuncMap{
+		"join": strings.Join,
+		// section renders another template, e.g. {{section "avoid" .Avoid}}
+		"section": Render,
+	}
+}
+
+// loadDefault parses a built-in template once
+func loadDefault(name string) (*template.Template, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	key := "default:" + name
+	if cached, ok := parsed[key]; ok {
+		return cached.tmpl, nil
+	}
+	source, ok := Default(name)
+	if !ok {
+		return nil, fmt.Errorf("no template named %q", name)
+	}
+	tmpl, err := template.New(name).Funcs(funcs()).Parse(source)
+	if err != nil {
+		return nil, err
+	}
+	parsed[key] = parsedTemplate{tmpl: tmpl}
+	return tmpl, nil
+}
+
+// loadFile parses a template file, again only when it has been modified
+func loadFile(path string) (*template.Template, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if cached, ok := parsed[path]; ok && cached.modTime.Equal(info.ModTime()) {
+		return cached.tmpl, nil
+	}
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := template.New(filepath.Base(path)).Funcs(funcs()).Parse(string(source))
+	if err != nil {
+		return nil, err
+	}
+	parsed[path] = parsedTemplate{modTime: info.ModTime(), tmpl: tmpl}
+	return tmpl, nil
+}
+
+// execute runs a template. The newline that ends the file is not part of the prompt.
+func execute(tmpl *template.Template, data any) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
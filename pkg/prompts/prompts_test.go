@@ -0,0 +1,59 @@
+package prompts
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeOverride(t *testing.T, name, source string) string {
+	dir := t.TempDir()
+	t.Setenv("IMG_CLI_PROMPTS_DIR", dir)
+	path := filepath.Join(dir, name+Ext)
+	if err := os.WriteFile(path, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRenderUsesOverride(t *testing.T) {
+	writeOverride(t, "emphasis", "{{.Level}} emphasis on {{.Label}}")
+	got, err := Render("emphasis", struct{ Level, Label string }{"strong", "the outfit"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "strong emphasis on the outfit" {
+		t.Errorf("Render = %q", got)
+	}
+}
+
+func TestRenderFailsOnBrokenOverride(t *testing.T) {
+	for source, what := range map[string]string{
+		"{{.Level":          "parse",
+		"{{.Missing.Deep}}": "execute",
+	} {
+		path := writeOverride(t, "emphasis", source)
+		got, err := Render("emphasis", struct{ Level, Label string }{"strong", "the outfit"})
+		if err == nil {
+			t.Errorf("override that fails to %s rendered %q, want an error", what, got)
+			continue
+		}
+		if !strings.Contains(err.Error(), path) {
+			t.Errorf("error %q doesn't name %s", err, path)
+		}
+	}
+}
+
+func TestRenderBuiltIn(t *testing.T) {
+	t.Setenv("IMG_CLI_PROMPTS_DIR", t.TempDir())
+	for _, name := range []string{"analyze_pose", "validate_identity"} {
+		got, err := Render(name, nil)
+		if err != nil || strings.TrimSpace(got) == "" {
+			t.Errorf("Render(%q) = %q, %v", name, got, err)
+		}
+	}
+	if _, err := Render("no_such_template", nil); err == nil {
+		t.Error("unknown template rendered without an error")
+	}
+}
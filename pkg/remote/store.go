@@ -0,0 +1,492 @@
+// Package remote fetches URL and S3 inputs into a local content-addressed blob
+// store shared across runs, and uploads outputs to URL and S3 locations.
+// Downloads are made in ranged chunks into a partial file, so an interrupted
+// transfer resumes where it stopped, and an input whose ETag hasn't changed
+// since the last run is never downloaded again. Large S3 uploads go in parts
+// that are recorded as they finish, and a file already uploaded unchanged is
+// not sent again.
+//
+// Layout of the store directory:
+//
+//	sha256/<hash>           blob content, named by its SHA-256
+//	refs/<key>.json         what a URL last resolved to (key = SHA-256 of the URL)
+//	partial/<key>[.json]    unfinished download and the ETag it was started with
+//	uploads/<key>.json      unfinished multipart upload and its finished parts
+//	files/<hash[:16]>/<name> blob under the input's original file name
+package remote
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/config"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/logger"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	blobsDir   = "sha256"
+	refsDir    = "refs"
+	partialDir = "partial"
+	filesDir   = "files"
+)
+
+// contentTypeExtensions names downloads whose URL has no image extension
+var contentTypeExtensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/webp": ".webp",
+	"image/gif":  ".gif",
+}
+
+// IsRemote reports whether value is a URL or S3 location the store can fetch
+func IsRemote(value string) bool {
+	lower := strings.ToLower(value)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") || strings.HasPrefix(lower, "s3://")
+}
+
+// Store is a content-addressed blob store for remote inputs
+type Store struct {
+	dir       string
+	chunkSize int64
+	retries   int
+	client    *http.Client
+
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex // Per-URL, so concurrent fetches of one input download it once
+}
+
+// ref records what a URL resolved to on its last download
+type ref struct {
+	URL     string    `json:"url"`
+	SHA256  string    `json:"sha256"`
+	ETag    string    `json:"etag,omitempty"`
+	Size    int64     `json:"size"`
+	Name    string    `json:"name"`
+	Fetched time.Time `json:"fetched"`
+}
+
+// partialMeta identifies the remote version an unfinished download belongs to
+type partialMeta struct {
+	ETag string `json:"etag,omitempty"`
+	Size int64  `json:"size"`
+}
+
+// NewStore creates a store in dir
+func NewStore(dir string, cfg *config.RemoteConfig) *Store {
+	if cfg == nil {
+		cfg = config.DefaultRemoteConfig()
+	}
+	return &Store{
+		dir:       dir,
+		chunkSize: cfg.ChunkSize,
+		retries:   max(cfg.Retries, 1),
+		client:    &http.Client{Timeout: 5 * time.Minute},
+		locks:     make(map[string]*sync.Mutex),
+	}
+}
+
+// Fetch returns a local path holding the content of a URL or S3 location,
+// downloading it only if the store has no current copy. The path keeps the
+// input's file name so outputs named after their inputs stay readable.
+func (s *Store) Fetch(location string) (string, error) {
+	t, err := newTarget(location)
+	if err != nil {
+		return "", err
+	}
+	key := hashString(location)
+
+	lock := s.lock(key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if r := s.readRef(key); r != nil && fileExists(s.blobPath(r.SHA256)) {
+		etag, size, _, err := s.head(t)
+		switch {
+		case err != nil:
+			logger.Warn("Could not check remote input, using stored copy", "url", t.display, "error", err)
+			return s.view(r)
+		case (etag != "" && etag == r.ETag) || (etag == "" && size == r.Size):
+			logger.Debug("Remote input unchanged", "url", t.display, "sha256", r.SHA256)
+			return s.view(r)
+		}
+		logger.Info("Remote input changed, downloading again", "url", t.display)
+	}
+
+	r, err := s.download(t, key)
+	if err != nil {
+		return "", errors.Wrapf(err, errors.FileError, "failed to download %s", t.display).
+			WithContext("url", t.display)
+	}
+	if err := s.writeRef(key, r); err != nil {
+		logger.Warn("Failed to record remote input", "url", t.display, "error", err)
+	}
+	return s.view(r)
+}
+
+// download transfers a remote input into the blob store, resuming a partial
+// download of the same version if one exists
+func (s *Store) download(t *target, key string) (*ref, error) {
+	etag, size, contentType, err := s.head(t)
+	if err != nil {
+		return nil, err
+	}
+
+	partial := filepath.Join(s.dir, partialDir, key)
+	if err := os.MkdirAll(filepath.Dir(partial), 0755); err != nil {
+		return nil, err
+	}
+	meta := partialMeta{ETag: etag, Size: size}
+	if previous := readPartialMeta(partial); previous == nil || *previous != meta {
+		os.Remove(partial)
+	}
+	if err := writeJSON(partial+".json", meta); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(partial, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	offset, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if offset > 0 {
+		logger.Info("Resuming download", "url", t.display, "offset", offset, "size", size)
+	} else {
+		logger.Info("Downloading remote input", "url", t.display, "size", size)
+	}
+
+	if size < 0 {
+		// Unknown length: ranges can't be planned, so stream the whole body
+		err = s.retry(t, func() error { return s.fetchRange(t, file, 0, -1, etag) })
+	} else {
+		for offset < size && err == nil {
+			end := min(offset+s.chunkSize, size) - 1
+			err = s.retry(t, func() error { return s.fetchRange(t, file, offset, end, etag) })
+			offset, _ = file.Seek(0, io.SeekEnd)
+		}
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sum, written, err := hashFile(partial)
+	if err != nil {
+		return nil, err
+	}
+	if size >= 0 && written != size {
+		os.Remove(partial)
+		return nil, fmt.Errorf("downloaded %d bytes, expected %d", written, size)
+	}
+	blob := s.blobPath(sum)
+	if err := os.MkdirAll(filepath.Dir(blob), 0755); err != nil {
+		return nil, err
+	}
+	if fileExists(blob) {
+		os.Remove(partial) // Same content under another URL
+	} else if err := os.Rename(partial, blob); err != nil {
+		return nil, err
+	}
+	os.Remove(partial + ".json")
+
+	return &ref{
+		URL:     t.display,
+		SHA256:  sum,
+		ETag:    etag,
+		Size:    written,
+		Name:    t.fileName(contentType),
+		Fetched: time.Now(),
+	}, nil
+}
+
+// fetchRange appends bytes [start, end] of the input to file (end < 0 means
+// to the end). A server that ignores the range restarts the file from zero.
+func (s *Store) fetchRange(t *target, file *os.File, start, end int64, etag string) error {
+	current, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	if current > start {
+		start = current // A failed attempt already wrote part of this chunk
+	}
+	if end >= 0 && start > end {
+		return nil
+	}
+
+	req, err := t.request(http.MethodGet)
+	if err != nil {
+		return err
+	}
+	if start > 0 || end >= 0 {
+		if end >= 0 {
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+		} else {
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-", start))
+		}
+		if etag != "" {
+			req.Header.Set("If-Range", etag)
+		}
+	}
+	t.sign(req)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		// Full content: either no range was asked for or the server doesn't do ranges
+		if err := file.Truncate(0); err != nil {
+			return err
+		}
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+	default:
+		return statusError(resp)
+	}
+
+	_, err = io.Copy(file, resp.Body)
+	return err
+}
+
+// head returns the ETag, size (-1 if unknown) and content type of an input.
+// Servers that refuse HEAD, such as presigned S3 URLs signed for GET only,
+// are asked for the first byte instead.
+func (s *Store) head(t *target) (etag string, size int64, contentType string, err error) {
+	err = s.retry(t, func() error {
+		var headErr error
+		etag, size, contentType, headErr = s.probe(t, http.MethodHead)
+		if headErr == nil || !permanent(headErr) {
+			return headErr
+		}
+		logger.Debug("HEAD refused, asking for the first byte", "url", t.display, "error", headErr)
+		etag, size, contentType, err = s.probe(t, http.MethodGet)
+		return err
+	})
+	return etag, size, contentType, err
+}
+
+// probe reads the metadata of an input from a HEAD request or from a GET of
+// its first byte
+func (s *Store) probe(t *target, method string) (etag string, size int64, contentType string, err error) {
+	req, err := t.request(method)
+	if err != nil {
+		return "", 0, "", err
+	}
+	if method == http.MethodGet {
+		req.Header.Set("Range", "bytes=0-0")
+	}
+	t.sign(req)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", 0, "", err
+	}
+	resp.Body.Close()
+
+	switch {
+	case method == http.MethodGet && resp.StatusCode == http.StatusPartialContent:
+		size = contentRangeSize(resp.Header.Get("Content-Range"))
+	case resp.StatusCode == http.StatusOK:
+		size = resp.ContentLength
+	default:
+		return "", 0, "", statusError(resp)
+	}
+	return resp.Header.Get("ETag"), size, resp.Header.Get("Content-Type"), nil
+}
+
+// contentRangeSize returns the complete length of a Content-Range header such
+// as "bytes 0-0/1234", or -1 when the server didn't give it
+func contentRangeSize(header string) int64 {
+	_, total, ok := strings.Cut(header, "/")
+	if !ok {
+		return -1
+	}
+	size, err := strconv.ParseInt(strings.TrimSpace(total), 10, 64)
+	if err != nil {
+		return -1
+	}
+	return size
+}
+
+// retry runs fn up to the configured number of attempts with a growing pause
+func (s *Store) retry(t *target, fn func() error) error {
+	var err error
+	for attempt := 1; attempt <= s.retries; attempt++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+		if permanent(err) || attempt == s.retries {
+			break
+		}
+		logger.Debug("Transfer failed, retrying", "url", t.display, "attempt", attempt, "error", err)
+		time.Sleep(time.Duration(attempt) * time.Second)
+	}
+	return err
+}
+
+// view exposes a blob under the input's file name
+func (s *Store) view(r *ref) (string, error) {
+	viewPath := filepath.Join(s.dir, filesDir, r.SHA256[:16], r.Name)
+	if fileExists(viewPath) {
+		return viewPath, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(viewPath), 0755); err != nil {
+		return "", errors.Wrap(err, errors.FileError, "failed to create blob view")
+	}
+	if err := os.Link(s.blobPath(r.SHA256), viewPath); err != nil {
+		// Hard links may not be supported; fall back to a copy
+		data, readErr := os.ReadFile(s.blobPath(r.SHA256))
+		if readErr != nil {
+			return "", errors.Wrap(readErr, errors.FileError, "failed to read blob")
+		}
+		if err := os.WriteFile(viewPath, data, 0644); err != nil {
+			return "", errors.Wrap(err, errors.FileError, "failed to write blob view")
+		}
+	}
+	return viewPath, nil
+}
+
+func (s *Store) lock(key string) *sync.Mutex {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.locks[key] == nil {
+		s.locks[key] = &sync.Mutex{}
+	}
+	return s.locks[key]
+}
+
+func (s *Store) blobPath(sum string) string {
+	return filepath.Join(s.dir, blobsDir, sum)
+}
+
+func (s *Store) readRef(key string) *ref {
+	data, err := os.ReadFile(filepath.Join(s.dir, refsDir, key+".json"))
+	if err != nil {
+		return nil
+	}
+	var r ref
+	if json.Unmarshal(data, &r) != nil || len(r.SHA256) < 16 || r.Name == "" {
+		return nil
+	}
+	return &r
+}
+
+func (s *Store) writeRef(key string, r *ref) error {
+	path := filepath.Join(s.dir, refsDir, key+".json")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return writeJSON(path, r)
+}
+
+func readPartialMeta(partial string) *partialMeta {
+	data, err := os.ReadFile(partial + ".json")
+	if err != nil {
+		return nil
+	}
+	var meta partialMeta
+	if json.Unmarshal(data, &meta) != nil {
+		return nil
+	}
+	return &meta
+}
+
+// writeJSON writes a small metadata file atomically
+func writeJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := fmt.Sprintf("%s.tmp-%d", path, os.Getpid())
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func hashFile(path string) (string, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer file.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, file)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+func hashString(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// httpStatusError is a non-success response; client errors are not retried
+type httpStatusError struct {
+	code   int
+	status string
+}
+
+func (e *httpStatusError) Error() string {
+	return "server returned " + e.status
+}
+
+func statusError(resp *http.Response) error {
+	return &httpStatusError{code: resp.StatusCode, status: resp.Status}
+}
+
+func permanent(err error) bool {
+	statusErr, ok := err.(*httpStatusError)
+	return ok && statusErr.code >= 400 && statusErr.code < 500 && statusErr.code != http.StatusTooManyRequests
+}
+
+// fileName picks the local name of a download: the last path segment of the
+// URL, with an extension from the content type when it has none
+func (t *target) fileName(contentType string) string {
+	name := path.Base(t.url.Path)
+	if unescaped, err := url.PathUnescape(name); err == nil {
+		name = unescaped
+	}
+	name = strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == 0 {
+			return '_'
+		}
+		return r
+	}, name)
+	if name == "" || name == "." || name == "/" {
+		name = "input"
+	}
+	if filepath.Ext(name) == "" {
+		mediaType := strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0])
+		name += contentTypeExtensions[strings.ToLower(mediaType)]
+	}
+	return name
+}
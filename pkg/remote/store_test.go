@@ -0,0 +1,164 @@
+package remote
+
+import (
+	"bytes"
+	"img-cli/pkg/config"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fileServer serves one file with ranges and an ETag and counts requests
+type fileServer struct {
+	content    []byte
+	etag       string
+	refuseHead bool // Like a presigned S3 URL signed for GET only
+
+	mu       sync.Mutex
+	requests []string // Method and Range of each request
+}
+
+func (f *fileServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.requests = append(f.requests, r.Method+" "+r.Header.Get("Range"))
+	f.mu.Unlock()
+	if r.Method == http.MethodHead && f.refuseHead {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	w.Header().Set("ETag", f.etag)
+	w.Header().Set("Content-Type", "image/png")
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(f.content))
+}
+
+// gets counts the GET requests that transferred more than the first byte
+func (f *fileServer) gets() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, request := range f.requests {
+		if strings.HasPrefix(request, http.MethodGet) && request != "GET bytes=0-0" {
+			n++
+		}
+	}
+	return n
+}
+
+func newTestStore(t *testing.T) *Store {
+	return NewStore(t.TempDir(), &config.RemoteConfig{ChunkSize: 4, Retries: 1})
+}
+
+func TestFetchDownloadsInChunksAndReusesTheBlob(t *testing.T) {
+	files := &fileServer{content: []byte("0123456789abcdef!"), etag: `"v1"`}
+	server := httptest.NewServer(files)
+	defer server.Close()
+	store := newTestStore(t)
+
+	path, err := store.Fetch(server.URL + "/refs/look")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(path) != "look.png" {
+		t.Errorf("stored as %s, want look.png from the content type", filepath.Base(path))
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || !bytes.Equal(data, files.content) {
+		t.Fatalf("stored content %q, %v; want %q", data, err, files.content)
+	}
+	if got := files.gets(); got != 5 {
+		t.Errorf("downloaded in %d requests, want 5 chunks of 4 bytes", got)
+	}
+
+	again, err := store.Fetch(server.URL + "/refs/look")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != path || files.gets() != 5 {
+		t.Errorf("unchanged input was downloaded again")
+	}
+
+	files.content, files.etag = []byte("changed"), `"v2"`
+	if _, err := store.Fetch(server.URL + "/refs/look"); err != nil {
+		t.Fatal(err)
+	}
+	if files.gets() == 5 {
+		t.Errorf("changed input was not downloaded again")
+	}
+}
+
+// Presigned S3 GET URLs answer HEAD with 403
+func TestFetchFallsBackToRangedGetWhenHeadIsRefused(t *testing.T) {
+	files := &fileServer{content: []byte("presigned content"), etag: `"abc"`, refuseHead: true}
+	server := httptest.NewServer(files)
+	defer server.Close()
+	store := newTestStore(t)
+
+	url := server.URL + "/bucket/key.png?X-Amz-Signature=secret"
+	path, err := store.Fetch(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if !bytes.Equal(data, files.content) {
+		t.Fatalf("stored content %q, want %q", data, files.content)
+	}
+
+	downloads := files.gets()
+	if _, err := store.Fetch(url); err != nil {
+		t.Fatal(err)
+	}
+	if files.gets() != downloads {
+		t.Errorf("unchanged input was downloaded again")
+	}
+}
+
+func TestFetchResumesPartialDownload(t *testing.T) {
+	files := &fileServer{content: []byte("0123456789"), etag: `"v1"`}
+	server := httptest.NewServer(files)
+	defer server.Close()
+	store := newTestStore(t)
+
+	// An earlier run stopped after the first chunk
+	location := server.URL + "/big.png"
+	partial := filepath.Join(store.dir, partialDir, hashString(location))
+	if err := os.MkdirAll(filepath.Dir(partial), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(partial, []byte("0123"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeJSON(partial+".json", partialMeta{ETag: `"v1"`, Size: 10}); err != nil {
+		t.Fatal(err)
+	}
+
+	path, err := store.Fetch(location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if !bytes.Equal(data, files.content) {
+		t.Fatalf("stored content %q, want %q", data, files.content)
+	}
+	for _, request := range files.requests {
+		if request == "GET bytes=0-3" {
+			t.Errorf("the chunk already downloaded was requested again")
+		}
+	}
+}
+
+func TestContentRangeSize(t *testing.T) {
+	for header, want := range map[string]int64{
+		"bytes 0-0/1234": 1234,
+		"bytes 0-0/*":    -1,
+		"":               -1,
+	} {
+		if got := contentRangeSize(header); got != want {
+			t.Errorf("contentRangeSize(%q) = %d, want %d", header, got, want)
+		}
+	}
+}
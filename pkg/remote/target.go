@@ -0,0 +1,223 @@
+package remote
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"img-cli/pkg/errors"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// emptyPayloadHash is the SHA-256 of an empty request body
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// target is a remote input or output resolved to an HTTP(S) URL
+type target struct {
+	url      *url.URL
+	display  string // Location without query string, safe to log (presigned URLs carry credentials)
+	s3Object bool   // Given as s3://bucket/key, so the S3 API (multipart uploads) is available
+	s3       *s3Credentials
+}
+
+// s3Credentials sign requests to S3 with AWS Signature Version 4
+type s3Credentials struct {
+	accessKey    string
+	secretKey    string
+	sessionToken string
+	region       string
+}
+
+// newTarget parses an http(s):// URL or an s3://bucket/key location.
+// S3 locations use the standard AWS environment variables: AWS_REGION (or
+// AWS_DEFAULT_REGION), AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and
+// AWS_SESSION_TOKEN; without keys the object must be public. AWS_ENDPOINT_URL_S3
+// (or AWS_ENDPOINT_URL) points at S3-compatible storage with path-style URLs.
+func newTarget(location string) (*target, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, errors.ErrInvalidInput("url", err.Error())
+	}
+	display := (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: u.Path}).String()
+
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		return &target{url: u, display: display}, nil
+	case "s3":
+	default:
+		return nil, errors.ErrInvalidInput("url", "unsupported scheme "+u.Scheme)
+	}
+
+	bucket, key := u.Host, strings.TrimPrefix(u.Path, "/")
+	if bucket == "" || key == "" {
+		return nil, errors.ErrInvalidInput("url", "S3 locations look like s3://bucket/key, got "+location)
+	}
+	region := firstEnv("AWS_REGION", "AWS_DEFAULT_REGION")
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	var endpoint *url.URL
+	if custom := firstEnv("AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL"); custom != "" {
+		if endpoint, err = url.Parse(custom); err != nil {
+			return nil, errors.ErrInvalidInput("AWS_ENDPOINT_URL", err.Error())
+		}
+		endpoint.Path = strings.TrimSuffix(endpoint.Path, "/") + "/" + bucket + "/" + key
+	} else {
+		endpoint = &url.URL{Scheme: "https", Host: fmt.Sprintf("%s.s3.%s.amazonaws.com", bucket, region), Path: "/" + key}
+	}
+
+	endpoint.RawPath = awsEscapePath(endpoint.Path) // Send the path exactly as it is signed
+
+	t := &target{url: endpoint, display: display, s3Object: true}
+	if accessKey := os.Getenv("AWS_ACCESS_KEY_ID"); accessKey != "" {
+		t.s3 = &s3Credentials{
+			accessKey:    accessKey,
+			secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+			region:       region,
+		}
+	}
+	return t, nil
+}
+
+func (t *target) request(method string) (*http.Request, error) {
+	return http.NewRequest(method, t.url.String(), nil)
+}
+
+// requestWith builds a request with a body and, for S3 API calls, a query
+// that replaces the location's own
+func (t *target) requestWith(method string, query url.Values, body []byte) (*http.Request, error) {
+	u := *t.url
+	if len(query) > 0 {
+		u.RawQuery = query.Encode()
+	}
+	return http.NewRequest(method, u.String(), bytes.NewReader(body))
+}
+
+// sign adds S3 authentication to a bodyless request once all its headers are set
+func (t *target) sign(req *http.Request) {
+	t.signPayload(req, emptyPayloadHash)
+}
+
+// signBody adds S3 authentication to a request that sends body
+func (t *target) signBody(req *http.Request, body []byte) {
+	sum := sha256.Sum256(body)
+	t.signPayload(req, hex.EncodeToString(sum[:]))
+}
+
+func (t *target) signPayload(req *http.Request, payloadHash string) {
+	if t.s3 != nil {
+		t.s3.sign(req, time.Now().UTC(), payloadHash)
+	}
+}
+
+// sign applies AWS Signature Version 4 to a request whose body has the given
+// SHA-256, covering the host, Range and x-amz-* headers
+func (c *s3Credentials) sign(req *http.Request, now time.Time, payloadHash string) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+	if c.sessionToken != "" {
+		req.Header.Set("x-amz-security-token", c.sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "range" || strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		awsEscapePath(req.URL.Path),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + c.region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+c.secretKey), date)
+	for _, part := range []string{c.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// awsEscapePath percent-encodes a path the way SigV4 expects: everything but
+// unreserved characters and the separators
+func awsEscapePath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		if c == '/' || c == '-' || c == '_' || c == '.' || c == '~' ||
+			('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, key := range keys {
+		values := append([]string(nil), query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			parts = append(parts, strings.ReplaceAll(url.QueryEscape(key), "+", "%20")+"="+strings.ReplaceAll(url.QueryEscape(value), "+", "%20"))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,312 @@
+package remote
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/logger"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// uploadsDir holds the state of unfinished S3 multipart uploads, one
+// <key>.json per location
+const uploadsDir = "uploads"
+
+// minPartSize is the smallest part S3 accepts in a multipart upload; only the
+// last part may be smaller
+const minPartSize = 5 << 20
+
+// uploadState records a multipart upload in progress, so an interrupted
+// upload continues with its next part
+type uploadState struct {
+	SHA256   string         `json:"sha256"` // Content being uploaded; a changed file starts over
+	UploadID string         `json:"upload_id"`
+	PartSize int64          `json:"part_size"`
+	Parts    []uploadedPart `json:"parts"`
+}
+
+type uploadedPart struct {
+	Number int    `json:"number"`
+	ETag   string `json:"etag"`
+}
+
+// Upload copies a local file to a URL or S3 location and reports whether it
+// was sent. A file whose content was uploaded to the location before, and
+// which the server still holds unchanged, is skipped. S3 files larger than a
+// chunk go up as a multipart upload that resumes at its next part; URLs, such
+// as presigned S3 PUT URLs, get one PUT.
+func (s *Store) Upload(localPath, location string) (bool, error) {
+	t, err := newTarget(location)
+	if err != nil {
+		return false, err
+	}
+	sum, size, err := hashFile(localPath)
+	if err != nil {
+		return false, errors.Wrapf(err, errors.FileError, "failed to read %s", localPath)
+	}
+	key := hashString(location)
+
+	lock := s.lock(key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if r := s.readRef(key); r != nil && r.SHA256 == sum {
+		etag, remoteSize, _, err := s.head(t)
+		if err == nil && ((etag != "" && etag == r.ETag) || (etag == "" && remoteSize == r.Size)) {
+			logger.Debug("Remote output unchanged", "url", t.display, "sha256", sum)
+			return false, nil
+		}
+	}
+
+	var etag string
+	if partSize := max(s.chunkSize, minPartSize); t.s3Object && size > partSize {
+		etag, err = s.uploadParts(t, key, localPath, sum, size, partSize)
+	} else {
+		etag, err = s.put(t, localPath)
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, errors.FileError, "failed to upload %s", t.display).
+			WithContext("url", t.display)
+	}
+
+	// The location now resolves to this content, so Fetch can reuse it too
+	r := &ref{URL: t.display, SHA256: sum, ETag: etag, Size: size, Name: filepath.Base(localPath), Fetched: time.Now()}
+	if err := s.writeRef(key, r); err != nil {
+		logger.Warn("Failed to record remote output", "url", t.display, "error", err)
+	}
+	return true, nil
+}
+
+// put sends a whole file in one request and returns the ETag it got
+func (s *Store) put(t *target, localPath string) (string, error) {
+	body, err := os.ReadFile(localPath)
+	if err != nil {
+		return "", err
+	}
+	logger.Info("Uploading", "url", t.display, "size", len(body))
+
+	var etag string
+	err = s.retry(t, func() error {
+		req, err := t.requestWith(http.MethodPut, nil, body)
+		if err != nil {
+			return err
+		}
+		if contentType := mime.TypeByExtension(filepath.Ext(localPath)); contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		t.signBody(req, body)
+		resp, err := s.client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return statusError(resp)
+		}
+		etag = resp.Header.Get("ETag")
+		return nil
+	})
+	return etag, err
+}
+
+// uploadParts sends a file as an S3 multipart upload, continuing an
+// unfinished upload of the same content
+func (s *Store) uploadParts(t *target, key, localPath, sum string, size, partSize int64) (string, error) {
+	statePath := filepath.Join(s.dir, uploadsDir, key+".json")
+	state := readUploadState(statePath)
+	if state != nil && state.SHA256 != sum {
+		s.abortUpload(t, state.UploadID) // Parts of older content are never completed
+		state = nil
+	}
+	if state == nil {
+		uploadID, err := s.createUpload(t)
+		if err != nil {
+			return "", err
+		}
+		state = &uploadState{SHA256: sum, UploadID: uploadID, PartSize: partSize}
+		if err := os.MkdirAll(filepath.Dir(statePath), 0755); err != nil {
+			return "", err
+		}
+		if err := writeJSON(statePath, state); err != nil {
+			return "", err
+		}
+		logger.Info("Uploading", "url", t.display, "size", size)
+	} else {
+		logger.Info("Resuming upload", "url", t.display, "parts_done", len(state.Parts), "size", size)
+	}
+
+	file, err := os.Open(localPath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	parts := int((size + state.PartSize - 1) / state.PartSize)
+	buf := make([]byte, state.PartSize)
+	for number := len(state.Parts) + 1; number <= parts; number++ {
+		n, err := file.ReadAt(buf, int64(number-1)*state.PartSize)
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+		var etag string
+		err = s.retry(t, func() error {
+			var err error
+			etag, err = s.uploadPart(t, state.UploadID, number, buf[:n])
+			return err
+		})
+		if err != nil {
+			forgetExpiredUpload(statePath, err)
+			return "", err
+		}
+		state.Parts = append(state.Parts, uploadedPart{Number: number, ETag: etag})
+		if err := writeJSON(statePath, state); err != nil {
+			return "", err
+		}
+	}
+
+	var etag string
+	err = s.retry(t, func() error {
+		var err error
+		etag, err = s.completeUpload(t, state)
+		return err
+	})
+	if err != nil {
+		forgetExpiredUpload(statePath, err)
+		return "", err
+	}
+	os.Remove(statePath)
+	return etag, nil
+}
+
+// createUpload starts a multipart upload and returns its ID
+func (s *Store) createUpload(t *target) (string, error) {
+	var result struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := s.s3Call(t, http.MethodPost, url.Values{"uploads": {""}}, nil, &result); err != nil {
+		return "", err
+	}
+	if result.UploadID == "" {
+		return "", fmt.Errorf("S3 returned no upload ID")
+	}
+	return result.UploadID, nil
+}
+
+// uploadPart sends one part and returns its ETag
+func (s *Store) uploadPart(t *target, uploadID string, number int, body []byte) (string, error) {
+	query := url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {uploadID}}
+	resp, err := s.s3Request(t, http.MethodPut, query, body)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	return resp.Header.Get("ETag"), nil
+}
+
+// completeUpload joins the uploaded parts into the object and returns its ETag
+func (s *Store) completeUpload(t *target, state *uploadState) (string, error) {
+	type part struct {
+		PartNumber int
+		ETag       string
+	}
+	request := struct {
+		XMLName xml.Name `xml:"CompleteMultipartUpload"`
+		Parts   []part   `xml:"Part"`
+	}{}
+	for _, uploaded := range state.Parts {
+		request.Parts = append(request.Parts, part{PartNumber: uploaded.Number, ETag: uploaded.ETag})
+	}
+	body, err := xml.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		ETag string `xml:"ETag"`
+	}
+	if err := s.s3Call(t, http.MethodPost, url.Values{"uploadId": {state.UploadID}}, body, &result); err != nil {
+		return "", err
+	}
+	return result.ETag, nil
+}
+
+// abortUpload discards the parts of an upload that will not be completed.
+// Failures only leave the parts to the bucket's lifecycle rules.
+func (s *Store) abortUpload(t *target, uploadID string) {
+	resp, err := s.s3Request(t, http.MethodDelete, url.Values{"uploadId": {uploadID}}, nil)
+	if err != nil {
+		logger.Debug("Failed to abort upload", "url", t.display, "error", err)
+		return
+	}
+	resp.Body.Close()
+}
+
+// s3Call makes an S3 API request and decodes its XML answer into result. S3
+// can answer 200 with an error document, which is returned as an error.
+func (s *Store) s3Call(t *target, method string, query url.Values, body []byte, result interface{}) error {
+	resp, err := s.s3Request(t, method, query, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	var s3Err struct {
+		XMLName xml.Name
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	if xml.Unmarshal(data, &s3Err) == nil && s3Err.XMLName.Local == "Error" {
+		return fmt.Errorf("S3 %s: %s", s3Err.Code, s3Err.Message)
+	}
+	return xml.Unmarshal(data, result)
+}
+
+// s3Request sends a signed S3 API request and returns a successful response
+func (s *Store) s3Request(t *target, method string, query url.Values, body []byte) (*http.Response, error) {
+	req, err := t.requestWith(method, query, body)
+	if err != nil {
+		return nil, err
+	}
+	t.signBody(req, body)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		return nil, statusError(resp)
+	}
+	return resp, nil
+}
+
+// forgetExpiredUpload drops the state of an upload S3 no longer knows, so the
+// next attempt starts a new one
+func forgetExpiredUpload(statePath string, err error) {
+	if statusErr, ok := err.(*httpStatusError); ok && statusErr.code == http.StatusNotFound {
+		os.Remove(statePath)
+	}
+}
+
+func readUploadState(path string) *uploadState {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var state uploadState
+	if json.Unmarshal(data, &state) != nil || state.UploadID == "" || state.PartSize <= 0 {
+		return nil
+	}
+	return &state
+}
@@ -0,0 +1,157 @@
+package remote
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// fakeS3 stores objects from single PUTs and multipart uploads
+type fakeS3 struct {
+	mu       sync.Mutex
+	objects  map[string][]byte
+	etags    map[string]string
+	parts    map[int][]byte
+	sent     []int // Part numbers in the order they arrived
+	failPart int   // Part refused once with 403
+	t        *testing.T
+}
+
+func newFakeS3(t *testing.T) *fakeS3 {
+	return &fakeS3{t: t, objects: make(map[string][]byte), etags: make(map[string]string), parts: make(map[int][]byte)}
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	body, _ := io.ReadAll(r.Body)
+	if r.Header.Get("Authorization") == "" {
+		f.t.Errorf("%s %s was not signed", r.Method, r.URL)
+	}
+	if sum := sha256.Sum256(body); r.Header.Get("x-amz-content-sha256") != hex.EncodeToString(sum[:]) {
+		f.t.Errorf("%s %s signed with the wrong payload hash", r.Method, r.URL)
+	}
+	query := r.URL.Query()
+	object := r.URL.Path
+
+	switch {
+	case r.Method == http.MethodHead:
+		data, ok := f.objects[object]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("ETag", f.etags[object])
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		fmt.Fprint(w, `<InitiateMultipartUploadResult><UploadId>upload-1</UploadId></InitiateMultipartUploadResult>`)
+	case r.Method == http.MethodPut && query.Get("uploadId") != "":
+		number, _ := strconv.Atoi(query.Get("partNumber"))
+		if number == f.failPart {
+			f.failPart = 0
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		f.parts[number] = body
+		f.sent = append(f.sent, number)
+		w.Header().Set("ETag", fmt.Sprintf(`"part-%d"`, number))
+	case r.Method == http.MethodPost && query.Get("uploadId") != "":
+		var numbers []int
+		for number := range f.parts {
+			numbers = append(numbers, number)
+		}
+		sort.Ints(numbers)
+		var data []byte
+		for _, number := range numbers {
+			data = append(data, f.parts[number]...)
+		}
+		f.objects[object] = data
+		f.etags[object] = `"multipart-3"`
+		fmt.Fprint(w, `<CompleteMultipartUploadResult><ETag>"multipart-3"</ETag></CompleteMultipartUploadResult>`)
+	case r.Method == http.MethodPut:
+		f.objects[object] = body
+		f.etags[object] = `"single"`
+		w.Header().Set("ETag", `"single"`)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func withFakeS3(t *testing.T) *fakeS3 {
+	s3 := newFakeS3(t)
+	server := httptest.NewServer(s3)
+	t.Cleanup(server.Close)
+	t.Setenv("AWS_ENDPOINT_URL_S3", server.URL)
+	t.Setenv("AWS_ACCESS_KEY_ID", "test-key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test-secret")
+	return s3
+}
+
+func TestUploadSkipsUnchangedFiles(t *testing.T) {
+	s3 := withFakeS3(t)
+	store := newTestStore(t)
+	local := filepath.Join(t.TempDir(), "kat_suit_1.png")
+	if err := os.WriteFile(local, []byte("image"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sent, err := store.Upload(local, "s3://renders/fw24/kat_suit_1.png")
+	if err != nil || !sent {
+		t.Fatalf("first upload: sent %v, %v", sent, err)
+	}
+	if got := string(s3.objects["/renders/fw24/kat_suit_1.png"]); got != "image" {
+		t.Fatalf("stored %q", got)
+	}
+
+	sent, err = store.Upload(local, "s3://renders/fw24/kat_suit_1.png")
+	if err != nil || sent {
+		t.Errorf("unchanged upload: sent %v, %v; want skipped", sent, err)
+	}
+
+	if err := os.WriteFile(local, []byte("new image"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sent, err = store.Upload(local, "s3://renders/fw24/kat_suit_1.png")
+	if err != nil || !sent {
+		t.Errorf("changed upload: sent %v, %v; want sent", sent, err)
+	}
+}
+
+func TestUploadResumesMultipartUpload(t *testing.T) {
+	s3 := withFakeS3(t)
+	s3.failPart = 2
+	store := newTestStore(t)
+	content := bytes.Repeat([]byte("0123456789"), (2*minPartSize+minPartSize/2)/10)
+	local := filepath.Join(t.TempDir(), "sheet.png")
+	if err := os.WriteFile(local, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Upload(local, "s3://renders/sheet.png"); err == nil {
+		t.Fatal("upload with a refused part succeeded")
+	}
+	sent, err := store.Upload(local, "s3://renders/sheet.png")
+	if err != nil || !sent {
+		t.Fatalf("resumed upload: sent %v, %v", sent, err)
+	}
+
+	if fmt.Sprint(s3.sent) != "[1 2 3]" {
+		t.Errorf("parts sent %v, want [1 2 3] with part 1 not sent again", s3.sent)
+	}
+	if !bytes.Equal(s3.objects["/renders/sheet.png"], content) {
+		t.Errorf("assembled object differs from the file")
+	}
+	if _, err := os.Stat(filepath.Join(store.dir, uploadsDir, hashString("s3://renders/sheet.png")+".json")); !os.IsNotExist(err) {
+		t.Errorf("upload state kept after completion: %v", err)
+	}
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/imgcli"
+	"img-cli/pkg/remote"
+	"img-cli/pkg/workspace"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxUploadBytes caps the size of images posted to /uploads
+const maxUploadBytes = 25 << 20
+
+// uploadsDir is the workspace state folder posted images are kept in
+const uploadsDir = "uploads"
+
+// uploadTypes are the image types /uploads accepts, with their extensions
+var uploadTypes = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/webp": ".webp",
+	"image/gif":  ".gif",
+}
+
+// checkInputs refuses request inputs the server must not read on a client's
+// behalf: URLs and S3 locations, which would make the server fetch any
+// address it can reach, and paths outside the workspace. Asset names, text
+// descriptions and images posted to /uploads pass.
+func checkInputs(inputs map[string]string) error {
+	for field, value := range inputs {
+		if value == "" {
+			continue
+		}
+		if remote.IsRemote(value) {
+			return errors.ErrInvalidInput(field, "the server doesn't fetch URLs; POST the image to /uploads and use the path it returns")
+		}
+		if !insideWorkspace(value) {
+			return errors.ErrInvalidInput(field, fmt.Sprintf("%s is outside the workspace", value))
+		}
+	}
+	return nil
+}
+
+// insideWorkspace reports whether a value that names a file stays inside the
+// workspace root, symlinks followed. Values that aren't paths (absolute,
+// existing or climbing with "..") are names or text and pass.
+func insideWorkspace(value string) bool {
+	path := workspace.Resolve(value)
+	_, statErr := os.Stat(path)
+	climbs := false
+	for _, element := range strings.FieldsFunc(value, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if element == ".." {
+			climbs = true
+		}
+	}
+	if !filepath.IsAbs(value) && statErr != nil && !climbs {
+		return true
+	}
+
+	root := workspace.Root()
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+	rel, err := filepath.Rel(root, abs)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// recipeInputs lists the inputs of a modular job by field
+func recipeInputs(recipe imgcli.Recipe) map[string]string {
+	inputs := map[string]string{
+		"subject":     recipe.Subject,
+		"outfit":      recipe.Outfit,
+		"over_outfit": recipe.OverOutfit,
+		"style":       recipe.Style,
+		"hair_style":  recipe.HairStyle,
+		"hair_color":  recipe.HairColor,
+		"makeup":      recipe.Makeup,
+		"expression":  recipe.Expression,
+		"accessories": recipe.Accessories,
+		"pose":        recipe.Pose,
+		"background":  recipe.Background,
+		"lut":         recipe.LUT,
+	}
+	for name, value := range recipe.Extra {
+		inputs["extra."+name] = value
+	}
+	return inputs
+}
+
+// inputs lists the inputs of an outfit-swap job by field
+func (req OutfitSwapRequest) inputs() map[string]string {
+	inputs := map[string]string{
+		"outfit":      req.Outfit,
+		"style":       req.Style,
+		"over_outfit": req.OverOutfit,
+		"hair_style":  req.HairStyle,
+		"hair_color":  req.HairColor,
+		"makeup":      req.Makeup,
+		"expression":  req.Expression,
+		"accessories": req.Accessories,
+		"pose":        req.Pose,
+		"background":  req.Background,
+	}
+	for i, subject := range req.Subjects {
+		inputs[fmt.Sprintf("subjects[%d]", i)] = subject
+	}
+	return inputs
+}
+
+// handleUpload keeps a posted image in the workspace and answers with the
+// path to use for it in later requests. Images are stored by content, so
+// posting one twice gives the same path.
+func (s *Server) handleUpload(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxUploadBytes))
+	if err != nil {
+		writeError(w, errors.ErrInvalidInput("body", err.Error()))
+		return
+	}
+	contentType := http.DetectContentType(data)
+	ext, ok := uploadTypes[contentType]
+	if !ok {
+		writeError(w, errors.ErrInvalidInput("body", fmt.Sprintf("want a PNG, JPEG, WebP or GIF image, got %s", contentType)))
+		return
+	}
+
+	sum := sha256.Sum256(data)
+	name := hex.EncodeToString(sum[:])[:16] + ext
+	path := filepath.Join(workspace.Root(), workspace.Dir, uploadsDir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		writeError(w, errors.Wrap(err, errors.FileError, "failed to store upload"))
+		return
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		writeError(w, errors.Wrap(err, errors.FileError, "failed to store upload"))
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"image": filepath.ToSlash(filepath.Join(workspace.Dir, uploadsDir, name)),
+		"size":  len(data),
+	})
+}
@@ -0,0 +1,215 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/imgcli"
+	"img-cli/pkg/jobs"
+	"img-cli/pkg/logger"
+	"img-cli/pkg/workflow"
+	"img-cli/pkg/workspace"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// maxQueuedJobs is how many jobs may wait for the worker; more are refused
+const maxQueuedJobs = 64
+
+// retryInterval is how long the worker waits after failing to read the store
+const retryInterval = 5 * time.Second
+
+// errQueueFull is returned when maxQueuedJobs are already waiting
+var errQueueFull = errors.New(errors.ValidationError, "job queue is full, try again later")
+
+// jobView is a job as the API returns it, with download URLs for its images
+type jobView struct {
+	*jobs.Job
+	Images []imageView `json:"images,omitempty"`
+}
+
+type imageView struct {
+	jobs.Image
+	URL string `json:"url"` // Download path on this server
+}
+
+func newJobView(job *jobs.Job) jobView {
+	view := jobView{Job: job}
+	for _, image := range job.Images {
+		view.Images = append(view.Images, imageView{Image: image, URL: "/jobs/" + job.ID + "/images/" + image.Name})
+	}
+	return view
+}
+
+// submit queues a job with the request it was submitted with
+func (s *Server) submit(kind string, request interface{}) (*jobs.Job, error) {
+	queued, err := s.store.Queued()
+	if err != nil {
+		return nil, err
+	}
+	if queued >= maxQueuedJobs {
+		return nil, errQueueFull
+	}
+
+	// A folder per job, so jobs never share an output folder
+	now := time.Now()
+	job, err := s.store.Create(kind, request, func(id string) string {
+		return filepath.Join(workspace.OutputPath(), now.Format("2006-01-02"), now.Format("150405")+"-job"+id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("Job queued", "id", job.ID, "kind", kind)
+
+	select {
+	case s.wakeup <- struct{}{}:
+	default:
+	}
+	return job, nil
+}
+
+// Work runs queued jobs, oldest first, until ctx is cancelled. Jobs left in
+// the store by an earlier server run first.
+func (s *Server) Work(ctx context.Context) {
+	for ctx.Err() == nil {
+		job, err := s.store.Next()
+		if err != nil {
+			logger.Warn("Failed to read job queue", "error", err)
+		}
+		if job != nil && s.runJob(job) {
+			continue
+		}
+
+		wait := time.NewTimer(retryInterval)
+		select {
+		case <-ctx.Done():
+		case <-s.wakeup:
+		case <-wait.C:
+		}
+		wait.Stop()
+	}
+}
+
+// runJob runs a job under the project lock and reports whether it ran. A job
+// whose lock is held by another img-cli command stays queued for the next try.
+func (s *Server) runJob(job *jobs.Job) bool {
+	id := job.ID
+	run, err := workspace.Start(workspace.Root(), runCommand+" "+job.Kind)
+	if err != nil {
+		logger.Debug("Job waiting for the project lock", "id", id, "error", err)
+		return false
+	}
+	var runErr error
+	defer func() { run.Finish(runErr == nil) }()
+
+	started := time.Now()
+	job, err = s.store.Update(id, func(job *jobs.Job) {
+		// Cancelled from another process while it was being picked up
+		if job.CancelRequested {
+			job.Status = jobs.StatusCancelled
+			job.Finished = &started
+			return
+		}
+		job.Status = jobs.StatusRunning
+		job.Started = &started
+		job.Attempts++
+	})
+	if err != nil {
+		logger.Warn("Failed to start job", "id", id, "error", err)
+		return true
+	}
+	if job.Status == jobs.StatusCancelled {
+		logger.Info("Job cancelled before it started", "id", id)
+		return true
+	}
+
+	logger.Info("Job started", "id", job.ID, "kind", job.Kind, "attempt", job.Attempts)
+	var images []jobs.Image
+	var failures []workflow.Failure
+	images, failures, runErr = s.execute(job)
+
+	job, err = s.store.Update(id, func(job *jobs.Job) {
+		finished := time.Now()
+		job.Finished = &finished
+		job.Images = images
+		job.Failures = failures
+		switch {
+		case job.CancelRequested:
+			job.Status = jobs.StatusCancelled
+		case runErr != nil:
+			job.Status = jobs.StatusFailed
+			job.Error = errors.NewReport(runErr)
+		default:
+			job.Status = jobs.StatusCompleted
+		}
+	})
+	if err != nil {
+		logger.Warn("Failed to save job result", "id", id, "error", err)
+		return true
+	}
+	logger.Info("Job finished", "id", job.ID, "status", job.Status, "images", len(images), "duration", time.Since(started).Round(time.Second))
+	return true
+}
+
+// execute runs a job's workflow from the request it was submitted with
+func (s *Server) execute(job *jobs.Job) ([]jobs.Image, []workflow.Failure, error) {
+	switch job.Kind {
+	case jobs.KindModular:
+		var recipe imgcli.Recipe
+		if err := json.Unmarshal(job.Request, &recipe); err != nil {
+			return nil, nil, errors.Wrap(err, errors.ValidationError, "invalid job request")
+		}
+		recipe.OutputDir = job.OutputDir
+		recipe.Stop = func() bool { return s.store.CancelRequested(job.ID) }
+		result, err := s.runner.Generate(recipe)
+		if result == nil {
+			return nil, nil, err
+		}
+		var images []jobs.Image
+		for _, path := range result.Images {
+			images = append(images, jobs.Image{Name: filepath.Base(path), Path: path, Flags: result.Flags[path]})
+		}
+		return images, result.Failures, err
+
+	case jobs.KindOutfitSwap:
+		var req OutfitSwapRequest
+		if err := json.Unmarshal(job.Request, &req); err != nil {
+			return nil, nil, errors.Wrap(err, errors.ValidationError, "invalid job request")
+		}
+		options := req.Options(job.OutputDir)
+		options.Stop = func() bool { return s.store.CancelRequested(job.ID) }
+		// A job interrupted by a restart keeps the images it already recorded
+		if job.Attempts > 1 {
+			if _, err := os.Stat(filepath.Join(job.OutputDir, workflow.ManifestFile)); err == nil {
+				options.Resume = true
+			}
+		}
+		result, err := s.runner.OutfitSwap(req.Outfit, options)
+		if result == nil {
+			return nil, nil, err
+		}
+		var images []jobs.Image
+		for _, path := range result.Images {
+			images = append(images, jobs.Image{Name: filepath.Base(path), Path: path, Flags: result.Flags[path]})
+		}
+		return images, result.Failures, err
+	}
+	return nil, nil, errors.New(errors.InternalError, fmt.Sprintf("unknown job kind %q", job.Kind))
+}
+
+// Pending counts the jobs that are queued or running
+func (s *Server) Pending() int {
+	all, err := s.store.List()
+	if err != nil {
+		return 0
+	}
+	pending := 0
+	for _, job := range all {
+		if !job.Done() {
+			pending++
+		}
+	}
+	return pending
+}
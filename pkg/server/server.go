@@ -0,0 +1,372 @@
+// Package server exposes the img-cli pipeline over HTTP for the serve
+// command, so web UIs and other services can drive it without shelling out.
+// Analyses answer synchronously; workflows are submitted as jobs (see
+// pkg/jobs) that run one at a time, and their images are downloaded from the
+// server when done.
+//
+//	GET  /health                     liveness check
+//	POST /uploads                    an image as the body; answers the path to use for it
+//	POST /analyze                    {"type": "outfit", "image": "suit.png"}
+//	POST /jobs/modular               an imgcli.Recipe as JSON
+//	POST /jobs/outfit-swap           an OutfitSwapRequest as JSON
+//	GET  /jobs                       every job, oldest first
+//	GET  /jobs/{id}                  status, images and failures of a job
+//	POST /jobs/{id}/cancel           cancel a queued job or stop a running one
+//	GET  /jobs/{id}/images/{name}    download a generated image
+//
+// Inputs are asset names, text or paths inside the workspace; the server never
+// reads other files or fetches URLs for a client. With a token, every route
+// but /health needs "Authorization: Bearer <token>". Errors are returned as
+// the JSON document of --errors-json.
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/config"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/imgcli"
+	"img-cli/pkg/jobs"
+	"img-cli/pkg/logger"
+	"img-cli/pkg/workflow"
+	"net/http"
+	"strings"
+)
+
+// runCommand is the command of the server's runs, followed by the job kind
+// ("img-cli serve outfit-swap"), as the project lock and the usage ledger
+// show it
+const runCommand = "img-cli serve"
+
+// maxRequestBytes caps the size of request bodies
+const maxRequestBytes = 1 << 20
+
+// Server answers the HTTP API. Analyses use their own client so they aren't
+// held up by a running job.
+type Server struct {
+	analyzer *imgcli.Client
+	runner   *imgcli.Client
+	store    *jobs.Store
+	token    string
+	wakeup   chan struct{} // Signals the worker that a job was queued
+}
+
+// Options configures a Server
+type Options struct {
+	// Token, when set, is required as "Authorization: Bearer <token>" on every
+	// route but /health
+	Token string
+}
+
+// New creates a Server that analyzes with analyzer and runs the jobs of store
+// with runner. Call Work to start running jobs.
+func New(analyzer, runner *imgcli.Client, store *jobs.Store, opts Options) *Server {
+	return &Server{
+		analyzer: analyzer,
+		runner:   runner,
+		store:    store,
+		token:    opts.Token,
+		wakeup:   make(chan struct{}, 1),
+	}
+}
+
+// Handler returns the routes of the API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /health", s.handleHealth)
+	mux.HandleFunc("POST /uploads", s.handleUpload)
+	mux.HandleFunc("POST /analyze", s.handleAnalyze)
+	mux.HandleFunc("POST /jobs/modular", s.handleModular)
+	mux.HandleFunc("POST /jobs/outfit-swap", s.handleOutfitSwap)
+	mux.HandleFunc("GET /jobs", s.handleJobs)
+	mux.HandleFunc("GET /jobs/{id}", s.handleJob)
+	mux.HandleFunc("POST /jobs/{id}/cancel", s.handleCancel)
+	mux.HandleFunc("GET /jobs/{id}/images/{name}", s.handleImage)
+	if s.token == "" {
+		return mux
+	}
+	return s.authorize(mux)
+}
+
+// authorize answers 401 to requests without the bearer token
+func (s *Server) authorize(next http.Handler) http.Handler {
+	want := []byte("Bearer " + s.token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" && subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), want) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="img-cli"`)
+			writeErrorStatus(w, http.StatusUnauthorized, errors.New(errors.ValidationError, "missing or wrong bearer token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// AnalyzeRequest is the body of POST /analyze
+type AnalyzeRequest struct {
+	Type  string `json:"type"`  // One of workflow.AnalysisTypes
+	Image string `json:"image"` // Path, asset name or URL
+}
+
+// OutfitSwapRequest is the body of POST /jobs/outfit-swap, and the preset file
+// of the watch command. Outfit and Style default to the outfit-swap defaults;
+// components take an image path, asset name or text description, as on the
+// command line.
+type OutfitSwapRequest struct {
+	Outfit      string   `json:"outfit,omitempty"` // Image, folder or asset name
+	Style       string   `json:"style,omitempty"`
+	Subjects    []string `json:"subjects"`
+	Variations  int      `json:"variations,omitempty"`
+	OverOutfit  string   `json:"over_outfit,omitempty"`
+	HairStyle   string   `json:"hair_style,omitempty"`
+	HairColor   string   `json:"hair_color,omitempty"`
+	Makeup      string   `json:"makeup,omitempty"`
+	Expression  string   `json:"expression,omitempty"`
+	Accessories string   `json:"accessories,omitempty"`
+	Pose        string   `json:"pose,omitempty"`
+	Background  string   `json:"background,omitempty"`
+	Avoid       []string `json:"avoid,omitempty"`
+	Aspect      string   `json:"aspect,omitempty"`
+	Resolution  int      `json:"resolution,omitempty"`
+	Seed        int64    `json:"seed,omitempty"` // Variation i uses seed+i (0 = random)
+	Temperature float64  `json:"temperature,omitempty"`
+	TopK        int      `json:"top_k,omitempty"`
+	TopP        float64  `json:"top_p,omitempty"`
+	Strength    float64  `json:"strength,omitempty"` // How much the subjects may change, 0-1
+
+	SkipPreflight bool `json:"skip_preflight,omitempty"` // Skip checking the subject photos
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok", "pending_jobs": s.Pending()})
+}
+
+func (s *Server) handleAnalyze(w http.ResponseWriter, r *http.Request) {
+	var req AnalyzeRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if !workflow.IsAnalysisType(req.Type) {
+		writeError(w, errors.ErrInvalidInput("type", fmt.Sprintf("unknown analysis type %q (use %s)", req.Type, strings.Join(workflow.AnalysisTypes(), ", "))))
+		return
+	}
+	if req.Image == "" {
+		writeError(w, errors.ErrMissingRequired("image"))
+		return
+	}
+	if err := checkInputs(map[string]string{"image": req.Image}); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	analysis, err := s.analyzer.Analyze(req.Type, req.Image)
+	if err != nil {
+		writeError(w, errors.Wrapf(err, errors.AnalysisError, "failed to analyze %s", req.Type))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"type": req.Type, "image": req.Image, "analysis": analysis})
+}
+
+func (s *Server) handleModular(w http.ResponseWriter, r *http.Request) {
+	var recipe imgcli.Recipe
+	if !decode(w, r, &recipe) {
+		return
+	}
+	if recipe.Subject == "" {
+		writeError(w, errors.ErrMissingRequired("subject"))
+		return
+	}
+	if err := checkInputs(recipeInputs(recipe)); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	s.queueJob(w, jobs.KindModular, recipe)
+}
+
+func (s *Server) handleOutfitSwap(w http.ResponseWriter, r *http.Request) {
+	var req OutfitSwapRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if len(req.Subjects) == 0 {
+		writeError(w, errors.ErrMissingRequired("subjects"))
+		return
+	}
+	req.ApplyDefaults()
+	if err := checkInputs(req.inputs()); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	s.queueJob(w, jobs.KindOutfitSwap, req)
+}
+
+// ApplyDefaults fills in the outfit and style defaults of outfit-swap and a
+// single variation
+func (req *OutfitSwapRequest) ApplyDefaults() {
+	inputs := config.DefaultInputsConfig()
+	if req.Outfit == "" {
+		req.Outfit = inputs.Outfit
+	}
+	if req.Style == "" {
+		req.Style = inputs.Style
+	}
+	if req.Variations <= 0 {
+		req.Variations = 1
+	}
+}
+
+// Options converts the request into outfit-swap options writing to outputDir.
+// Out-of-range values are rejected when the job starts.
+func (req OutfitSwapRequest) Options(outputDir string) imgcli.OutfitSwapOptions {
+	return imgcli.OutfitSwapOptions{
+		OutputDir:     outputDir,
+		Style:         req.Style,
+		Subjects:      req.Subjects,
+		Variations:    req.Variations,
+		OverOutfit:    req.OverOutfit,
+		HairStyle:     req.HairStyle,
+		HairColor:     req.HairColor,
+		Makeup:        req.Makeup,
+		Expression:    req.Expression,
+		Accessories:   req.Accessories,
+		Pose:          req.Pose,
+		Background:    req.Background,
+		OutfitCheck:   workflow.OutfitCheckWarn,
+		Avoid:         req.Avoid,
+		Aspect:        req.Aspect,
+		Resolution:    req.Resolution,
+		Seed:          req.Seed,
+		Temperature:   req.Temperature,
+		TopK:          req.TopK,
+		TopP:          req.TopP,
+		Strength:      req.Strength,
+		SkipPreflight: req.SkipPreflight,
+		Parallel:      1,
+	}
+}
+
+// queueJob submits a job and answers 202 with it
+func (s *Server) queueJob(w http.ResponseWriter, kind string, request interface{}) {
+	job, err := s.submit(kind, request)
+	if err == errQueueFull {
+		writeErrorStatus(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, newJobView(job))
+}
+
+func (s *Server) handleJobs(w http.ResponseWriter, r *http.Request) {
+	all, err := s.store.List()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	views := make([]jobView, 0, len(all))
+	for _, job := range all {
+		views = append(views, newJobView(job))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"jobs": views})
+}
+
+func (s *Server) handleJob(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.getJob(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, newJobView(job))
+}
+
+func (s *Server) handleCancel(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.getJob(w, r); !ok {
+		return
+	}
+	job, err := s.store.Cancel(r.PathValue("id"))
+	if err != nil {
+		writeErrorStatus(w, http.StatusConflict, err)
+		return
+	}
+	logger.Info("Job cancelled", "id", job.ID, "status", job.Status)
+	writeJSON(w, http.StatusOK, newJobView(job))
+}
+
+// handleImage serves a generated image. Only images listed on the job can be
+// downloaded, never other files.
+func (s *Server) handleImage(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.getJob(w, r)
+	if !ok {
+		return
+	}
+	name := r.PathValue("name")
+	for _, image := range job.Images {
+		if image.Name == name {
+			http.ServeFile(w, r, image.Path)
+			return
+		}
+	}
+	writeErrorStatus(w, http.StatusNotFound, errors.ErrInvalidInput("name", fmt.Sprintf("job %s has no image %q", job.ID, name)))
+}
+
+// getJob loads the job named in the path, answering 404 for unknown jobs
+func (s *Server) getJob(w http.ResponseWriter, r *http.Request) (*jobs.Job, bool) {
+	job, err := s.store.Get(r.PathValue("id"))
+	if errors.Is(err, errors.ValidationError) {
+		writeErrorStatus(w, http.StatusNotFound, err)
+		return nil, false
+	}
+	if err != nil {
+		writeError(w, err)
+		return nil, false
+	}
+	return job, true
+}
+
+// decode reads a JSON request body, answering 400 for malformed bodies and
+// unknown fields
+func decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		writeError(w, errors.ErrInvalidInput("body", err.Error()))
+		return false
+	}
+	return true
+}
+
+// writeError answers with the --errors-json document of err: 400 for invalid
+// input, 500 otherwise
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, errors.ValidationError) {
+		status = http.StatusBadRequest
+	}
+	writeErrorStatus(w, status, err)
+}
+
+func writeErrorStatus(w http.ResponseWriter, status int, err error) {
+	data, merr := errors.MarshalReport(err)
+	if merr != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(data, '\n'))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		logger.Error("Failed to encode response", "error", err)
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(data, '\n'))
+}
@@ -0,0 +1,126 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"img-cli/pkg/jobs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestMain runs the tests in an empty project so uploads and jobs they write
+// stay out of the source tree
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "img-cli-server-test")
+	if err != nil {
+		panic(err)
+	}
+	if err := os.WriteFile(dir+"/.img-cli.yaml", nil, 0644); err != nil {
+		panic(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		panic(err)
+	}
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func newTestServer(t *testing.T, token string) http.Handler {
+	store, err := jobs.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(nil, nil, store, Options{Token: token}).Handler()
+}
+
+func TestCheckInputs(t *testing.T) {
+	if err := os.MkdirAll("outfits", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join("outfits", "suit.png"), []byte("png"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outside := filepath.Join(t.TempDir(), "secret.png")
+	if err := os.WriteFile(outside, []byte("png"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, "link.png"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove("link.png")
+	cwd, _ := os.Getwd()
+
+	for value, allowed := range map[string]bool{
+		"suit":                                 true, // Asset name
+		"copper red":                           true, // Text description
+		"outfits/suit.png":                     true,
+		filepath.Join(cwd, "outfits/suit.png"): true,
+		outside:                                false,
+		"../../etc/passwd":                     false,
+		"link.png":                             false,
+		"https://169.254.169.254/latest":       false,
+		"s3://bucket/key.png":                  false,
+	} {
+		err := checkInputs(map[string]string{"outfit": value})
+		if (err == nil) != allowed {
+			t.Errorf("checkInputs(%q) = %v, want allowed %v", value, err, allowed)
+		}
+	}
+}
+
+func TestTokenIsRequiredExceptForHealth(t *testing.T) {
+	handler := newTestServer(t, "s3cret")
+	for _, tc := range []struct {
+		path, auth string
+		want       int
+	}{
+		{"/health", "", http.StatusOK},
+		{"/jobs", "", http.StatusUnauthorized},
+		{"/jobs", "Bearer wrong", http.StatusUnauthorized},
+		{"/jobs", "Bearer s3cret", http.StatusOK},
+	} {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		if tc.auth != "" {
+			req.Header.Set("Authorization", tc.auth)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			t.Errorf("GET %s with %q: status %d, want %d", tc.path, tc.auth, rec.Code, tc.want)
+		}
+	}
+}
+
+func TestUploadReturnsAWorkspacePath(t *testing.T) {
+	handler := newTestServer(t, "")
+	png := append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 32)...)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/uploads", bytes.NewReader(png)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var answer struct {
+		Image string `json:"image"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &answer); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(answer.Image, ".img-cli/uploads/") || !strings.HasSuffix(answer.Image, ".png") {
+		t.Errorf("upload stored as %q", answer.Image)
+	}
+	if err := checkInputs(map[string]string{"subject": answer.Image}); err != nil {
+		t.Errorf("uploaded path refused: %v", err)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/uploads", strings.NewReader("not an image")))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("non-image upload: status %d, want 400", rec.Code)
+	}
+}
@@ -0,0 +1,136 @@
+// Package subjects is the registry of named subjects: a subjects.json file in
+// a subjects folder that gives each subject a name, aliases and metadata on
+// top of its reference image. The workspace package consults it when a
+// subject is given by name, and the workflows use its metadata (preferred hair
+// color, attributes that must never change) when generating.
+package subjects
+
+import (
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RegistryFile is the name of the registry inside a subjects folder
+const RegistryFile = "subjects.json"
+
+// Subject is a registered subject
+type Subject struct {
+	Name      string    `json:"name"`
+	Image     string    `json:"image"` // Reference image, relative to the subjects folder
+	Aliases   []string  `json:"aliases,omitempty"`
+	HairColor string    `json:"hair_color,omitempty"` // Used when a generation sets no hair color
+	Keep      []string  `json:"keep,omitempty"`       // Attributes generations must not change, e.g. "freckles"
+	Notes     string    `json:"notes,omitempty"`
+	Added     time.Time `json:"added"`
+}
+
+// Registry is the subjects.json of one subjects folder
+type Registry struct {
+	dir      string
+	Subjects []Subject `json:"subjects"`
+}
+
+// Load reads the registry of a subjects folder. A folder without one has an
+// empty registry.
+func Load(dir string) (*Registry, error) {
+	registry := &Registry{dir: dir}
+	data, err := os.ReadFile(filepath.Join(dir, RegistryFile))
+	if os.IsNotExist(err) {
+		return registry, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, errors.FileError, "failed to read %s", filepath.Join(dir, RegistryFile))
+	}
+	if err := json.Unmarshal(data, registry); err != nil {
+		return nil, errors.Wrapf(err, errors.FileError, "failed to parse %s", filepath.Join(dir, RegistryFile))
+	}
+	return registry, nil
+}
+
+// Find returns the subject with the given name or alias, or nil. Names
+// compare like asset names: "Jaimee_B" matches "jaimee-b".
+func (r *Registry) Find(name string) *Subject {
+	want := normalize(name)
+	for i := range r.Subjects {
+		subject := &r.Subjects[i]
+		if normalize(subject.Name) == want {
+			return subject
+		}
+		for _, alias := range subject.Aliases {
+			if normalize(alias) == want {
+				return subject
+			}
+		}
+	}
+	return nil
+}
+
+// ForImage returns the subject whose reference image is path, or nil
+func (r *Registry) ForImage(path string) *Subject {
+	want, err := filepath.Abs(path)
+	if err != nil {
+		return nil
+	}
+	for i := range r.Subjects {
+		if image, err := filepath.Abs(r.ImagePath(&r.Subjects[i])); err == nil && image == want {
+			return &r.Subjects[i]
+		}
+	}
+	return nil
+}
+
+// ImagePath returns the path of a subject's reference image
+func (r *Registry) ImagePath(subject *Subject) string {
+	return filepath.Join(r.dir, subject.Image)
+}
+
+// Add registers a subject. Its name and aliases must not be taken by another
+// subject.
+func (r *Registry) Add(subject Subject) error {
+	if strings.TrimSpace(subject.Name) == "" {
+		return errors.ErrMissingRequired("name")
+	}
+	for _, name := range append([]string{subject.Name}, subject.Aliases...) {
+		if existing := r.Find(name); existing != nil {
+			return errors.ErrInvalidInput("name", fmt.Sprintf("%q is already used by subject %s", name, existing.Name))
+		}
+	}
+	if subject.Added.IsZero() {
+		subject.Added = time.Now()
+	}
+	r.Subjects = append(r.Subjects, subject)
+	sort.Slice(r.Subjects, func(i, j int) bool { return r.Subjects[i].Name < r.Subjects[j].Name })
+	return nil
+}
+
+// Save writes the registry atomically
+func (r *Registry) Save() error {
+	if err := os.MkdirAll(r.dir, 0755); err != nil {
+		return errors.Wrapf(err, errors.FileError, "failed to create %s", r.dir)
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, errors.InternalError, "failed to encode the subject registry")
+	}
+	path := filepath.Join(r.dir, RegistryFile)
+	tmp := fmt.Sprintf("%s.tmp-%d", path, os.Getpid())
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return errors.Wrapf(err, errors.FileError, "failed to write %s", path)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return errors.Wrapf(err, errors.FileError, "failed to write %s", path)
+	}
+	return nil
+}
+
+// normalize makes "Jaimee_B" and "jaimee-b" compare equal
+func normalize(name string) string {
+	return strings.NewReplacer("_", "-", " ", "-").Replace(strings.ToLower(strings.TrimSpace(name)))
+}
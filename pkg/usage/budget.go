@@ -0,0 +1,82 @@
+package usage
+
+import (
+	"bufio"
+	"encoding/json"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/workspace"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// legacySpendFile is the per-project spend ledger of earlier versions, which
+// only priced generated images. Its entries from before the usage ledger
+// started still count toward the project's budget.
+const legacySpendFile = "spend.jsonl"
+
+// SpentByProject totals the cost of each project's calls, by project name
+func SpentByProject() map[string]float64 {
+	spent := make(map[string]float64)
+	entries, _ := Load(time.Time{}) // An unreadable ledger counts as empty
+	var started time.Time
+	for _, entry := range entries {
+		if started.IsZero() || entry.Time.Before(started) {
+			started = entry.Time
+		}
+		if entry.Project != "" {
+			spent[entry.Project] += entry.CostUSD
+		}
+	}
+
+	projects, _ := workspace.Projects()
+	for _, project := range projects {
+		spent[project.Name] += legacySpent(project.Name, started)
+	}
+	return spent
+}
+
+// Spent totals the cost of a project's calls
+func Spent(project string) float64 {
+	return SpentByProject()[project]
+}
+
+// legacySpent totals a project's legacy spend entries made before the usage
+// ledger started, or all of them when it hasn't
+func legacySpent(project string, started time.Time) float64 {
+	file, err := os.Open(filepath.Join(workspace.Root(), workspace.ProjectsDir, project, legacySpendFile))
+	if err != nil {
+		return 0
+	}
+	defer file.Close()
+
+	total := 0.0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry struct {
+			Time    time.Time `json:"time"`
+			CostUSD float64   `json:"cost_usd"`
+		}
+		if json.Unmarshal(scanner.Bytes(), &entry) == nil && (started.IsZero() || entry.Time.Before(started)) {
+			total += entry.CostUSD
+		}
+	}
+	return total
+}
+
+// CheckBudget fails when a run estimated to cost estimate would take the
+// active project over its budget
+func CheckBudget(estimate float64) error {
+	project := workspace.ActiveProject()
+	if project == nil || project.BudgetUSD <= 0 {
+		return nil
+	}
+	spent := Spent(project.Name)
+	if spent+estimate > project.BudgetUSD {
+		return errors.Newf(errors.ValidationError,
+			"project %s budget exceeded: $%.2f spent + $%.2f estimated > $%.2f budget",
+			project.Name, spent, estimate, project.BudgetUSD).
+			WithContext("project", project.Name)
+	}
+	return nil
+}
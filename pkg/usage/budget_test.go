@@ -0,0 +1,63 @@
+package usage
+
+import (
+	"encoding/json"
+	"img-cli/pkg/workspace"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func appendLines(t *testing.T, path string, entries ...interface{}) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	for _, entry := range entries {
+		data, _ := json.Marshal(entry)
+		file.Write(append(data, '\n'))
+	}
+}
+
+func TestSpentByProjectCountsLegacySpendOnlyBeforeTheLedger(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".img-cli.yaml"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	cwd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(cwd)
+	if _, err := workspace.CreateProject("acme", "", 10); err != nil {
+		t.Fatal(err)
+	}
+
+	started := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	appendLines(t, Path(),
+		Entry{Time: started, Type: Generate, Project: "acme", Images: 1, CostUSD: 0.04},
+		Entry{Time: started.Add(time.Hour), Type: Analyze, Project: "acme", CostUSD: 0.01},
+		Entry{Time: started.Add(time.Hour), Type: Generate, Project: "other", Images: 1, CostUSD: 0.04},
+	)
+	type legacy struct {
+		Time    time.Time `json:"time"`
+		CostUSD float64   `json:"cost_usd"`
+	}
+	appendLines(t, filepath.Join(dir, workspace.ProjectsDir, "acme", legacySpendFile),
+		legacy{Time: started.Add(-24 * time.Hour), CostUSD: 1},
+		legacy{Time: started, CostUSD: 0.04}, // Also in the usage ledger
+	)
+
+	spent := SpentByProject()
+	if got := spent["acme"]; got < 1.0499 || got > 1.0501 {
+		t.Errorf("acme spent $%.4f, want $1.05 with the legacy entry from the same time left out", got)
+	}
+	if got := spent["other"]; got != 0.04 {
+		t.Errorf("other spent $%.4f, want $0.04", got)
+	}
+}
@@ -0,0 +1,212 @@
+// Package usage keeps a ledger of every API call: when it was made, whether
+// it analyzed or generated, for which workflow, how many tokens and images it
+// used and what it cost. The estimate shown before a run is forgotten once the
+// run ends; the ledger remembers, so `img-cli usage` can total the spend by
+// day, month and workflow.
+package usage
+
+import (
+	"bufio"
+	"encoding/json"
+	"img-cli/pkg/config"
+	"img-cli/pkg/logger"
+	"img-cli/pkg/workspace"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ledgerFile is the ledger in the workspace state directory, one JSON entry
+// per line
+const ledgerFile = "usage.jsonl"
+
+// Call types
+const (
+	Analyze  = "analyze"
+	Generate = "generate"
+)
+
+// LocalVision is the provider name of analyses answered by the local vision
+// server (IMG_CLI_LOCAL_VISION_URL)
+const LocalVision = "local"
+
+// Entry is one API call in the ledger
+type Entry struct {
+	Time         time.Time `json:"time"`
+	Type         string    `json:"type"` // Analyze or Generate
+	Provider     string    `json:"provider"`
+	Model        string    `json:"model,omitempty"`
+	Workflow     string    `json:"workflow,omitempty"` // Command that made the call, e.g. "outfit-swap" or "serve modular"
+	Project      string    `json:"project,omitempty"`
+	RunID        string    `json:"run_id,omitempty"`
+	InputTokens  int       `json:"input_tokens,omitempty"`
+	OutputTokens int       `json:"output_tokens,omitempty"`
+	Images       int       `json:"images,omitempty"`
+	CostUSD      float64   `json:"cost_usd"`
+}
+
+var (
+	ledgerMu sync.Mutex
+	// outsideRuns names the calls made outside of a run (see SetWorkflow)
+	outsideRuns string
+)
+
+// Path returns the ledger file of the workspace
+func Path() string {
+	return filepath.Join(workspace.Root(), workspace.Dir, ledgerFile)
+}
+
+// Price estimates what a call cost: its images at the per-image price, a call
+// without images at the token prices, and nothing for calls answered locally
+func Price(e Entry) float64 {
+	if e.Provider == config.ProviderSD || e.Provider == LocalVision {
+		return 0
+	}
+	costs := config.DefaultCostConfig()
+	if e.Images > 0 {
+		return costs.CalculateTotalCost(e.Images)
+	}
+	return costs.CalculateTokenCost(e.InputTokens, e.OutputTokens)
+}
+
+// SetWorkflow names the calls a command makes outside of the runs it starts,
+// such as the analyses of serve, by its command path
+func SetWorkflow(command string) {
+	ledgerMu.Lock()
+	defer ledgerMu.Unlock()
+	outsideRuns = command
+}
+
+// Record appends a call to the ledger. The time, the workflow and run of the
+// active run, the active project and the price are filled in.
+func Record(e Entry) {
+	ledgerMu.Lock()
+	defer ledgerMu.Unlock()
+
+	e.Time = time.Now()
+	if run := workspace.Current(); run != nil {
+		e.RunID = run.ID
+		e.Workflow = workflowName(run.Command)
+	} else if outsideRuns != "" {
+		e.Workflow = workflowName(outsideRuns)
+	}
+	if project := workspace.ActiveProject(); project != nil {
+		e.Project = project.Name
+	}
+	e.CostUSD = Price(e)
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+
+	path := Path()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		logger.Warn("Failed to record API usage", "error", err)
+		return
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		logger.Warn("Failed to record API usage", "error", err)
+		return
+	}
+	defer file.Close()
+	file.Write(append(data, '\n'))
+}
+
+// workflowName drops the program name from a command path:
+// "img-cli cache warm" is "cache warm"
+func workflowName(command string) string {
+	if _, rest, ok := strings.Cut(command, " "); ok {
+		return rest
+	}
+	return command
+}
+
+// Load reads the ledger entries made at or after since. A missing ledger has
+// no entries; unreadable lines are skipped.
+func Load(since time.Time) ([]Entry, error) {
+	file, err := os.Open(Path())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry Entry
+		if json.Unmarshal(scanner.Bytes(), &entry) != nil {
+			continue
+		}
+		if !entry.Time.Before(since) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// Summary totals the calls of one day, month or workflow
+type Summary struct {
+	Key          string  `json:"key"`
+	Analyses     int     `json:"analyses"`
+	Generations  int     `json:"generations"`
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	Images       int     `json:"images"`
+	CostUSD      float64 `json:"cost_usd"`
+}
+
+// Add totals one call into the summary
+func (s *Summary) Add(e Entry) {
+	if e.Type == Generate {
+		s.Generations++
+	} else {
+		s.Analyses++
+	}
+	s.InputTokens += e.InputTokens
+	s.OutputTokens += e.OutputTokens
+	s.Images += e.Images
+	s.CostUSD += e.CostUSD
+}
+
+// Summarize totals entries grouped by key, in key order
+func Summarize(entries []Entry, key func(Entry) string) []Summary {
+	index := make(map[string]int)
+	var summaries []Summary
+	for _, entry := range entries {
+		k := key(entry)
+		i, ok := index[k]
+		if !ok {
+			i = len(summaries)
+			index[k] = i
+			summaries = append(summaries, Summary{Key: k})
+		}
+		summaries[i].Add(entry)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Key < summaries[j].Key })
+	return summaries
+}
+
+// Day groups entries by local calendar day: "2026-10-16"
+func Day(e Entry) string {
+	return e.Time.Local().Format("2006-01-02")
+}
+
+// Month groups entries by local calendar month: "2026-10"
+func Month(e Entry) string {
+	return e.Time.Local().Format("2006-01")
+}
+
+// Workflow groups entries by the command that made them
+func Workflow(e Entry) string {
+	if e.Workflow == "" {
+		return "(no run)"
+	}
+	return e.Workflow
+}
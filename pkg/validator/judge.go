@@ -0,0 +1,77 @@
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/analyzer"
+	"img-cli/pkg/gemini"
+	"img-cli/pkg/prompts"
+)
+
+// JudgeBrief is what a generated image was asked to show, as text
+type JudgeBrief struct {
+	Outfit string // Outfit description; empty when the recipe set none
+	Style  string // Photographic style description; empty when the recipe set none
+}
+
+// JudgeScores rates a generated image from 0 (worst) to 1 (best)
+type JudgeScores struct {
+	Outfit    *float64 `json:"outfit,omitempty"` // Fidelity to the outfit description
+	Style     *float64 `json:"style,omitempty"`  // Fidelity to the style description
+	Artifacts float64  `json:"artifacts"`        // 1 means free of artifacts (extra fingers, warped text, smeared faces)
+	Overall   float64  `json:"overall"`          // Mean of the scores above
+	Notes     string   `json:"notes,omitempty"`
+}
+
+// Judge scores generated images for outfit fidelity, style fidelity and
+// artifacts, with one cheap analysis request per image
+type Judge struct {
+	client *gemini.Client
+}
+
+func NewJudge(client *gemini.Client) *Judge {
+	return &Judge{client: client}
+}
+
+func (j *Judge) Judge(outputPath string, brief JudgeBrief) (*JudgeScores, error) {
+	prompt, err := prompts.Render("judge", brief)
+	if err != nil {
+		return nil, err
+	}
+	request, err := analyzer.BuildImageAnalysisRequest(outputPath, prompt, gemini.AnalyzerConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := j.client.SendRequest(*request)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %w", err)
+	}
+
+	data, err := analyzer.CleanAndValidateJSONResponse(gemini.ExtractTextFromResponse(resp))
+	if err != nil {
+		return nil, err
+	}
+
+	var scores JudgeScores
+	if err := json.Unmarshal(data, &scores); err != nil {
+		return nil, fmt.Errorf("error parsing judge scores: %w", err)
+	}
+	// Only score what the recipe asked for, whatever the model returned
+	if brief.Outfit == "" {
+		scores.Outfit = nil
+	}
+	if brief.Style == "" {
+		scores.Style = nil
+	}
+
+	sum, n := scores.Artifacts, 1.0
+	for _, score := range []*float64{scores.Outfit, scores.Style} {
+		if score != nil {
+			sum += *score
+			n++
+		}
+	}
+	scores.Overall = sum / n
+	return &scores, nil
+}
@@ -0,0 +1,76 @@
+// Package validator checks generated images against their inputs. Its first
+// check is identity: does the generated person still look like the subject?
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/analyzer"
+	"img-cli/pkg/gemini"
+	"img-cli/pkg/prompts"
+)
+
+// IdentityResult is how well a generated image preserves the subject's face
+type IdentityResult struct {
+	Score  float64 `json:"score"`  // 0 (clearly a different person) to 1 (certainly the same person)
+	Reason string  `json:"reason"` // Short explanation of the score
+}
+
+// IdentityValidator compares the face in a generated image with the subject
+// photo it was generated from. Implementations backed by a face-embedding
+// model can replace the bundled FaceJudge (see workflow.WithIdentityValidator).
+type IdentityValidator interface {
+	ValidateIdentity(subjectPath, outputPath string) (*IdentityResult, error)
+}
+
+// FaceJudge asks the vision model whether two photos show the same person,
+// with one cheap analysis request per image
+type FaceJudge struct {
+	client *gemini.Client
+}
+
+func NewFaceJudge(client *gemini.Client) *FaceJudge {
+	return &FaceJudge{client: client}
+}
+
+func (j *FaceJudge) ValidateIdentity(subjectPath, outputPath string) (*IdentityResult, error) {
+	var parts []interface{}
+	for _, path := range []string{subjectPath, outputPath} {
+		data, mimeType, err := gemini.LoadImageAsBase64(path)
+		if err != nil {
+			return nil, fmt.Errorf("error loading image: %w", err)
+		}
+		parts = append(parts, gemini.BlobPart{
+			InlineData: gemini.InlineData{MimeType: mimeType, Data: data},
+		})
+	}
+	prompt, err := prompts.Render("validate_identity", nil)
+	if err != nil {
+		return nil, err
+	}
+	parts = append(parts, gemini.TextPart{Text: prompt})
+
+	request := gemini.Request{
+		Contents:         []gemini.Content{{Parts: parts}},
+		GenerationConfig: gemini.AnalyzerConfig,
+	}
+
+	resp, err := j.client.SendRequest(request)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %w", err)
+	}
+
+	data, err := analyzer.CleanAndValidateJSONResponse(gemini.ExtractTextFromResponse(resp))
+	if err != nil {
+		return nil, err
+	}
+
+	var result IdentityResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("error parsing identity validation: %w", err)
+	}
+	if result.Score < 0 || result.Score > 1 {
+		return nil, fmt.Errorf("identity score %v out of range 0-1", result.Score)
+	}
+	return &result, nil
+}
@@ -0,0 +1,107 @@
+// Package video extracts frames from videos and assembles image sequences
+// back into videos. Both run ffmpeg (IMG_CLI_FFMPEG, else the one on the
+// PATH), which reads every common container and codec.
+package video
+
+import (
+	"fmt"
+	"img-cli/pkg/config"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/workspace"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// FramePattern names extracted frames and assembled sequences
+const FramePattern = "frame_%05d"
+
+// Extract writes the frames of a video sampled at fps into dir as PNGs and
+// returns their paths in order. maxFrames caps the count (0 = every frame).
+func Extract(path, dir string, fps float64, maxFrames int) ([]string, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, errors.ErrFileNotFound(path)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.Wrapf(err, errors.FileError, "failed to create %s", dir)
+	}
+	args := []string{"-i", path, "-vf", fmt.Sprintf("fps=%g", fps)}
+	if maxFrames > 0 {
+		args = append(args, "-frames:v", fmt.Sprint(maxFrames))
+	}
+	args = append(args, filepath.Join(dir, FramePattern+".png"))
+	if err := run(args...); err != nil {
+		return nil, errors.Wrapf(err, errors.FileError, "failed to extract frames from %s", path)
+	}
+
+	frames, _ := filepath.Glob(filepath.Join(dir, "frame_*.png"))
+	sort.Strings(frames)
+	if len(frames) == 0 {
+		return nil, errors.ErrInvalidInput("video", fmt.Sprintf("no frames could be extracted from %s", path))
+	}
+	return frames, nil
+}
+
+// Assemble encodes images, shown for 1/fps seconds each, into an H.264 video
+// at out. The images may differ in format; odd sizes are rounded down to the
+// even sizes the encoder needs.
+func Assemble(frames []string, fps float64, out string) error {
+	if len(frames) == 0 {
+		return errors.ErrInvalidInput("frames", "nothing to assemble")
+	}
+	list, err := os.CreateTemp(workspace.TempDir(), "frames-*.txt")
+	if err != nil {
+		return errors.Wrap(err, errors.FileError, "failed to create the frame list")
+	}
+	defer os.Remove(list.Name())
+
+	// The concat demuxer ignores the duration of the last entry unless the
+	// file is listed again after it
+	var b strings.Builder
+	for _, frame := range append(frames, frames[len(frames)-1]) {
+		abs, err := filepath.Abs(frame)
+		if err != nil {
+			abs = frame
+		}
+		fmt.Fprintf(&b, "file '%s'\nduration %g\n", strings.ReplaceAll(abs, "'", `'\''`), 1/fps)
+	}
+	if _, err := list.WriteString(b.String()); err != nil {
+		list.Close()
+		return errors.Wrap(err, errors.FileError, "failed to write the frame list")
+	}
+	if err := list.Close(); err != nil {
+		return errors.Wrap(err, errors.FileError, "failed to write the frame list")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+		return errors.Wrapf(err, errors.FileError, "failed to create %s", filepath.Dir(out))
+	}
+	err = run("-y", "-f", "concat", "-safe", "0", "-i", list.Name(),
+		"-vf", fmt.Sprintf("fps=%g,scale=trunc(iw/2)*2:trunc(ih/2)*2,format=yuv420p", fps),
+		"-c:v", "libx264", out)
+	if err != nil {
+		return errors.Wrapf(err, errors.FileError, "failed to assemble %s", out)
+	}
+	return nil
+}
+
+// run runs ffmpeg quietly, returning its last error line on failure
+func run(args ...string) error {
+	bin := config.DefaultVideoConfig().FFmpeg
+	path, err := exec.LookPath(bin)
+	if err != nil {
+		return errors.Newf(errors.ConfigError, "ffmpeg not found (%s); install it or set IMG_CLI_FFMPEG", bin)
+	}
+	cmd := exec.Command(path, append([]string{"-hide_banner", "-loglevel", "error"}, args...)...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+		if last := strings.TrimSpace(lines[len(lines)-1]); last != "" {
+			return fmt.Errorf("%w: %s", err, last)
+		}
+		return err
+	}
+	return nil
+}
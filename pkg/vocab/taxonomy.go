@@ -0,0 +1,132 @@
+package vocab
+
+// Color families and the words the model uses for them
+var colorSynonyms = map[string]string{
+	"black": "black", "jet": "black", "jet black": "black", "onyx": "black", "ebony": "black", "inky": "black",
+
+	"white": "white", "ivory": "white", "cream": "white", "off-white": "white", "winter white": "white",
+	"snow": "white", "eggshell": "white", "chalk": "white", "pearl": "white",
+
+	"gray": "gray", "grey": "gray", "charcoal": "gray", "slate": "gray", "heather": "gray", "ash": "gray",
+	"graphite": "gray", "dove": "gray", "gunmetal": "gray", "smoke": "gray",
+
+	"beige": "beige", "camel": "beige", "tan": "beige", "khaki": "beige", "sand": "beige", "ecru": "beige",
+	"taupe": "beige", "nude": "beige", "stone": "beige", "oatmeal": "beige", "fawn": "beige", "biscuit": "beige",
+
+	"brown": "brown", "chocolate": "brown", "cognac": "brown", "chestnut": "brown", "espresso": "brown",
+	"coffee": "brown", "mocha": "brown", "walnut": "brown", "tobacco": "brown", "umber": "brown",
+	"mahogany": "brown", "caramel": "brown", "cinnamon": "brown", "saddle": "brown",
+
+	"red": "red", "crimson": "red", "scarlet": "red", "cherry": "red", "ruby": "red", "brick": "red",
+	"vermilion": "red", "cardinal": "red", "tomato": "red",
+
+	"burgundy": "burgundy", "oxblood": "burgundy", "wine": "burgundy", "maroon": "burgundy",
+	"bordeaux": "burgundy", "claret": "burgundy", "merlot": "burgundy",
+
+	"pink": "pink", "blush": "pink", "rose": "pink", "fuchsia": "pink", "magenta": "pink", "salmon": "pink",
+	"dusty rose": "pink", "hot pink": "pink", "bubblegum": "pink",
+
+	"orange": "orange", "rust": "orange", "terracotta": "orange", "coral": "orange", "tangerine": "orange",
+	"burnt orange": "orange", "apricot": "orange", "peach": "orange", "copper": "orange",
+
+	"yellow": "yellow", "mustard": "yellow", "lemon": "yellow", "ochre": "yellow", "canary": "yellow",
+	"butter": "yellow", "saffron": "yellow",
+
+	"green": "green", "olive": "green", "emerald": "green", "sage": "green", "forest": "green", "mint": "green",
+	"moss": "green", "hunter": "green", "jade": "green", "lime": "green", "pistachio": "green", "kelly": "green",
+	"bottle green": "green", "army green": "green",
+
+	"teal": "teal", "turquoise": "teal", "aqua": "teal", "cyan": "teal", "petrol": "teal",
+
+	"blue": "blue", "cobalt": "blue", "royal blue": "blue", "sky": "blue", "denim": "blue", "azure": "blue",
+	"cerulean": "blue", "sapphire": "blue", "powder blue": "blue", "periwinkle": "blue", "cornflower": "blue",
+
+	"navy": "navy", "navy blue": "navy", "midnight": "navy", "midnight blue": "navy", "indigo": "navy",
+	"ink": "navy", "ink blue": "navy",
+
+	"purple": "purple", "violet": "purple", "lavender": "purple", "lilac": "purple", "plum": "purple",
+	"mauve": "purple", "eggplant": "purple", "aubergine": "purple", "amethyst": "purple", "orchid": "purple",
+
+	"gold": "gold", "golden": "gold", "gold-tone": "gold", "brass": "gold", "bronze": "gold", "rose gold": "gold",
+
+	"silver": "silver", "silver-tone": "silver", "chrome": "silver", "pewter": "silver", "platinum": "silver",
+
+	"multicolor": "multicolor", "multicolored": "multicolor", "multi-colored": "multicolor",
+	"multi-color": "multicolor", "rainbow": "multicolor",
+}
+
+// Garment types and the words the model uses for them
+var garmentSynonyms = map[string]string{
+	"coat": "coat", "overcoat": "coat", "topcoat": "coat", "peacoat": "coat", "trench": "coat",
+	"trench coat": "coat", "duster": "coat", "parka": "coat", "anorak": "coat", "raincoat": "coat",
+	"cape": "coat", "cloak": "coat", "poncho": "coat", "puffer": "coat",
+
+	"jacket": "jacket", "bomber": "jacket", "windbreaker": "jacket", "shacket": "jacket", "shearling": "jacket",
+
+	"blazer": "blazer", "sport coat": "blazer", "suit jacket": "blazer", "tuxedo jacket": "blazer",
+	"dinner jacket": "blazer",
+
+	"vest": "vest", "waistcoat": "vest", "gilet": "vest",
+
+	"sweater": "sweater", "jumper": "sweater", "pullover": "sweater", "turtleneck": "sweater",
+	"cardigan":   "cardigan",
+	"sweatshirt": "sweatshirt", "hoodie": "sweatshirt", "crewneck sweatshirt": "sweatshirt",
+
+	"shirt": "shirt", "button-down": "shirt", "button-up": "shirt", "dress shirt": "shirt", "flannel": "shirt",
+	"polo": "shirt", "polo shirt": "shirt",
+	"t-shirt": "t-shirt", "tee": "t-shirt", "tshirt": "t-shirt",
+	"blouse": "blouse", "tunic": "blouse",
+	"top": "top", "tank": "top", "tank top": "top", "camisole": "top", "cami": "top", "crop top": "top",
+	"halter": "top", "halter top": "top", "tube top": "top", "corset": "top", "bustier": "top", "bodysuit": "top",
+	"bralette": "top",
+
+	"trousers": "trousers", "pants": "trousers", "slacks": "trousers", "chinos": "trousers",
+	"joggers": "trousers", "sweatpants": "trousers", "culottes": "trousers", "cargo pants": "trousers",
+	"jeans":  "jeans",
+	"shorts": "shorts", "bermuda shorts": "shorts",
+	"skirt": "skirt", "miniskirt": "skirt", "kilt": "skirt",
+	"leggings": "leggings", "tights": "leggings",
+
+	"dress": "dress", "gown": "dress", "sundress": "dress", "slip dress": "dress", "kaftan": "dress",
+	"cheongsam": "dress", "sari": "dress", "kimono": "dress",
+	"jumpsuit": "jumpsuit", "romper": "jumpsuit", "playsuit": "jumpsuit", "overalls": "jumpsuit",
+	"dungarees": "jumpsuit", "boilersuit": "jumpsuit", "catsuit": "jumpsuit",
+	"suit": "suit", "tuxedo": "suit", "three-piece suit": "suit",
+
+	"bikini": "swimwear", "swimsuit": "swimwear", "swimwear": "swimwear", "swim trunks": "swimwear",
+
+	"shoes": "shoes", "shoe": "shoes", "sneakers": "shoes", "sneaker": "shoes", "trainers": "shoes",
+	"loafers": "shoes", "loafer": "shoes", "oxfords": "shoes", "brogues": "shoes", "heels": "shoes",
+	"pumps": "shoes", "stilettos": "shoes", "flats": "shoes", "mules": "shoes", "clogs": "shoes",
+	"espadrilles": "shoes", "derbies": "shoes", "slippers": "shoes",
+	"boots": "boots", "boot": "boots", "booties": "boots", "chelsea boots": "boots",
+	"sandals": "sandals", "sandal": "sandals", "flip-flops": "sandals", "slides": "sandals",
+
+	"hat": "hat", "cap": "hat", "beanie": "hat", "beret": "hat", "fedora": "hat", "bucket hat": "hat",
+}
+
+// garmentCategories groups garment types
+var garmentCategories = map[string]string{
+	"coat": "outerwear", "jacket": "outerwear", "blazer": "outerwear", "vest": "outerwear",
+	"sweater": "top", "cardigan": "top", "sweatshirt": "top", "shirt": "top", "t-shirt": "top",
+	"blouse": "top", "top": "top",
+	"trousers": "bottom", "jeans": "bottom", "shorts": "bottom", "skirt": "bottom", "leggings": "bottom",
+	"dress": "one-piece", "jumpsuit": "one-piece", "suit": "set", "swimwear": "swimwear",
+	"shoes": "footwear", "boots": "footwear", "sandals": "footwear",
+	"hat": "headwear",
+}
+
+// formalityRules are checked in order, so specific phrases ("smart casual")
+// are found before the general words they contain ("casual")
+var formalityRules = []struct {
+	value    string
+	keywords []string
+}{
+	{"black-tie", []string{"black tie", "black-tie", "white tie", "white-tie", "tuxedo", "ball gown", "evening gown", "red carpet", "gala"}},
+	{"smart-casual", []string{"smart casual", "smart-casual", "business casual", "business-casual", "dressy casual"}},
+	{"business", []string{"semi-formal", "semi formal", "business", "corporate", "professional", "office", "tailored"}},
+	{"formal", []string{"formal", "elegant", "evening", "cocktail", "dressy", "refined"}},
+	{"athletic", []string{"athletic", "sporty", "activewear", "athleisure", "sportswear", "gym", "workout"}},
+	{"loungewear", []string{"loungewear", "lounge", "sleepwear", "pajama", "pajamas", "homewear"}},
+	{"casual", []string{"casual", "relaxed", "streetwear", "everyday", "laid-back", "weekend", "informal"}},
+}
@@ -0,0 +1,238 @@
+// Package vocab maps the free text of analyses onto a controlled vocabulary:
+// color families, garment types with their category, and a formality scale.
+// The model describes the same coat as "midnight navy", "deep navy blue" or
+// "dark indigo"; filtering, search, deduplication and recoloring compare the
+// normalized values instead. Raw text is always kept next to each value.
+package vocab
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode"
+)
+
+// Term is a normalized value and the text it came from
+type Term struct {
+	Raw   string `json:"raw"`
+	Value string `json:"value"`
+}
+
+// Garment is a clothing item mapped onto the garment taxonomy
+type Garment struct {
+	Raw      string `json:"raw"`
+	Type     string `json:"type"`
+	Category string `json:"category"`
+	Color    string `json:"color,omitempty"`
+}
+
+// Terms is the normalized vocabulary of one analysis
+type Terms struct {
+	Colors    []Term    `json:"colors,omitempty"`
+	Garments  []Garment `json:"garments,omitempty"`
+	Formality *Term     `json:"formality,omitempty"`
+}
+
+// Empty reports whether nothing could be normalized
+func (t *Terms) Empty() bool {
+	return t == nil || (len(t.Colors) == 0 && len(t.Garments) == 0 && t.Formality == nil)
+}
+
+// Color returns the color family of a color description ("midnight navy" is
+// navy, "burnt orange" is orange), or "" if it names no known color.
+// English puts the head color last, so the rightmost match wins.
+func Color(raw string) string {
+	return lastMatch(raw, colorSynonyms)
+}
+
+// GarmentType returns the taxonomy type and category of a garment description,
+// or empty strings if it names no known garment. Only the head phrase is
+// considered, so "blazer with a white shirt collar" is a blazer.
+func GarmentType(raw string) (typ, category string) {
+	typ = lastMatch(headPhrase(raw), garmentSynonyms)
+	if typ == "" {
+		typ = firstMatch(raw, garmentSynonyms)
+	}
+	return typ, garmentCategories[typ]
+}
+
+// Formality returns where a style description sits on the formality scale:
+// athletic, loungewear, casual, smart-casual, business, formal or black-tie
+func Formality(raw string) string {
+	text := normalize(raw)
+	for _, level := range formalityRules {
+		for _, keyword := range level.keywords {
+			if strings.Contains(text, " "+keyword+" ") {
+				return level.value
+			}
+		}
+	}
+	return ""
+}
+
+// Outfit normalizes an outfit analysis
+func Outfit(data json.RawMessage) *Terms {
+	var outfit struct {
+		Clothing []interface{} `json:"clothing"`
+		Colors   []string      `json:"colors"`
+		Style    string        `json:"style"`
+		Overall  string        `json:"overall"`
+	}
+	if err := json.Unmarshal(data, &outfit); err != nil {
+		return nil
+	}
+
+	terms := &Terms{}
+	for _, item := range outfit.Clothing {
+		var garment Garment
+		switch v := item.(type) {
+		case string:
+			garment.Raw = v
+			garment.Type, garment.Category = GarmentType(v)
+			garment.Color = Color(headPhrase(v))
+		case map[string]interface{}:
+			name, _ := v["item"].(string)
+			desc, _ := v["description"].(string)
+			mainColor, _ := v["main_body_color"].(string)
+			garment.Raw = strings.TrimSpace(name + " " + desc)
+			if garment.Type, garment.Category = GarmentType(name); garment.Type == "" {
+				garment.Type, garment.Category = GarmentType(desc)
+			}
+			if garment.Color = Color(mainColor); garment.Color == "" {
+				garment.Color = Color(headPhrase(garment.Raw))
+			}
+		}
+		if garment.Type != "" {
+			terms.Garments = append(terms.Garments, garment)
+		}
+	}
+
+	terms.Colors = colorTerms(outfit.Colors)
+	for _, text := range []string{outfit.Style, outfit.Overall} {
+		if value := Formality(text); value != "" {
+			terms.Formality = &Term{Raw: text, Value: value}
+			break
+		}
+	}
+	return terms
+}
+
+// Style normalizes the palette of a visual style analysis
+func Style(data json.RawMessage) *Terms {
+	var style struct {
+		ColorPalette []string `json:"color_palette"`
+	}
+	if err := json.Unmarshal(data, &style); err != nil {
+		return nil
+	}
+	return &Terms{Colors: colorTerms(style.ColorPalette)}
+}
+
+// Text normalizes a free-text outfit description, one garment per clause
+func Text(description string) *Terms {
+	terms := &Terms{}
+	clauses := strings.FieldsFunc(strings.ReplaceAll(description, " and ", ","), func(r rune) bool {
+		return r == ',' || r == ';' || r == '.'
+	})
+	for _, clause := range clauses {
+		clause = strings.TrimSpace(clause)
+		typ, category := GarmentType(clause)
+		if typ == "" {
+			continue
+		}
+		terms.Garments = append(terms.Garments, Garment{Raw: clause, Type: typ, Category: category, Color: Color(headPhrase(clause))})
+	}
+	if value := Formality(description); value != "" {
+		terms.Formality = &Term{Raw: description, Value: value}
+	}
+	return terms
+}
+
+// ForAnalysis normalizes an analysis of the given analyzer type, falling back
+// to the description text when there is no structured analysis
+func ForAnalysis(analyzerType string, data json.RawMessage, description string) *Terms {
+	var terms *Terms
+	switch analyzerType {
+	case "outfit", "over_outfit":
+		if data != nil {
+			terms = Outfit(data)
+		} else if description != "" {
+			terms = Text(description)
+		}
+	case "visual_style", "style", "style_blend":
+		if data != nil {
+			terms = Style(data)
+		}
+	}
+	if terms.Empty() {
+		return nil
+	}
+	return terms
+}
+
+func colorTerms(raw []string) []Term {
+	var terms []Term
+	for _, color := range raw {
+		if value := Color(color); value != "" {
+			terms = append(terms, Term{Raw: color, Value: value})
+		}
+	}
+	return terms
+}
+
+// headPhrase is the part of a garment description before its details, where
+// the garment and its own color are named
+func headPhrase(raw string) string {
+	lower := strings.ToLower(raw)
+	for _, sep := range []string{",", ";", " with ", " featuring ", " worn ", " over ", " under ", " and "} {
+		if i := strings.Index(lower, sep); i > 0 {
+			raw, lower = raw[:i], lower[:i]
+		}
+	}
+	return raw
+}
+
+// normalize lowercases text and reduces it to space-separated words, padded
+// with spaces so keywords can be matched as whole words
+func normalize(text string) string {
+	return " " + strings.Join(strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '-'
+	}), " ") + " "
+}
+
+// lastMatch returns the value of the synonym ending furthest right in text;
+// at the same position the longer synonym wins ("navy blue" over "blue")
+func lastMatch(text string, synonyms map[string]string) string {
+	norm := normalize(text)
+	best, bestEnd, bestLen := "", -1, 0
+	for synonym, value := range synonyms {
+		for _, form := range []string{synonym, synonym + "s"} {
+			i := strings.LastIndex(norm, " "+form+" ")
+			if i < 0 {
+				continue
+			}
+			end := i + len(form)
+			if end > bestEnd || (end == bestEnd && len(form) > bestLen) {
+				best, bestEnd, bestLen = value, end, len(form)
+			}
+		}
+	}
+	return best
+}
+
+// firstMatch returns the value of the synonym starting furthest left in text
+func firstMatch(text string, synonyms map[string]string) string {
+	norm := normalize(text)
+	best, bestStart, bestLen := "", len(norm), 0
+	for synonym, value := range synonyms {
+		for _, form := range []string{synonym, synonym + "s"} {
+			i := strings.Index(norm, " "+form+" ")
+			if i < 0 {
+				continue
+			}
+			if i < bestStart || (i == bestStart && len(form) > bestLen) {
+				best, bestStart, bestLen = value, i, len(form)
+			}
+		}
+	}
+	return best
+}
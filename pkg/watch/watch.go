@@ -0,0 +1,93 @@
+// Package watch finds images dropped into a folder for the watch command. It
+// polls rather than subscribing to filesystem events, which behaves the same on
+// every platform and on network shares, and only reports a file once its size
+// has stopped changing so half-copied images are never picked up.
+package watch
+
+import (
+	"fmt"
+	"img-cli/pkg/errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Folders that inputs are moved to, inside the watched folder
+const (
+	DoneDir   = "done"
+	FailedDir = "failed"
+)
+
+var imageExtensions = map[string]bool{".png": true, ".jpg": true, ".jpeg": true, ".webp": true, ".gif": true}
+
+// Watcher reports the images that arrive in a folder. Only the top level is
+// watched, so the done/ and failed/ folders are never picked up again.
+type Watcher struct {
+	dir   string
+	sizes map[string]int64 // Size of each image at the previous poll
+}
+
+// New returns a Watcher for dir, which must be an existing folder
+func New(dir string) (*Watcher, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, errors.ErrInvalidInput("dir", fmt.Sprintf("cannot watch %s: %v", dir, err))
+	}
+	if !info.IsDir() {
+		return nil, errors.ErrInvalidInput("dir", fmt.Sprintf("%s is not a folder", dir))
+	}
+	return &Watcher{dir: dir, sizes: make(map[string]int64)}, nil
+}
+
+// Ready returns the images whose size is unchanged since the previous call,
+// sorted by name. An image is reported on every call until it is moved away,
+// so callers move each one out with Move once it has been handled.
+func (w *Watcher) Ready() ([]string, error) {
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, errors.FileError, "failed to read %s", w.dir)
+	}
+
+	sizes := make(map[string]int64)
+	var ready []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || !imageExtensions[strings.ToLower(filepath.Ext(name))] {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue // Moved away since the listing
+		}
+		path := filepath.Join(w.dir, name)
+		sizes[path] = info.Size()
+		if previous, ok := w.sizes[path]; ok && previous == info.Size() && info.Size() > 0 {
+			ready = append(ready, path)
+		}
+	}
+	w.sizes = sizes
+	sort.Strings(ready)
+	return ready, nil
+}
+
+// Move moves a handled image into folder (DoneDir or FailedDir) of the watched
+// folder, adding a timestamp to the name when an earlier input had the same
+// one. It returns the new path.
+func (w *Watcher) Move(path, folder string) (string, error) {
+	dir := filepath.Join(w.dir, folder)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", errors.Wrapf(err, errors.FileError, "failed to create %s", dir)
+	}
+	dest := filepath.Join(dir, filepath.Base(path))
+	if _, err := os.Stat(dest); err == nil {
+		ext := filepath.Ext(dest)
+		dest = fmt.Sprintf("%s_%s%s", strings.TrimSuffix(dest, ext), time.Now().Format("20060102150405"), ext)
+	}
+	if err := os.Rename(path, dest); err != nil {
+		return "", errors.Wrapf(err, errors.FileError, "failed to move %s to %s", path, dir)
+	}
+	delete(w.sizes, path)
+	return dest, nil
+}
@@ -0,0 +1,144 @@
+package workflow
+
+import (
+	"img-cli/pkg/output"
+	"sort"
+	"strings"
+)
+
+// accessoryItem is a single accessory considered for the generation prompt
+type accessoryItem struct {
+	Category string      // Analysis field the item came from (hats, earrings, ...)
+	Text     string      // Prompt text for the item
+	Source   interface{} // Original value, for list-based outfit analyses
+}
+
+// accessoryImportance ranks categories by how much they change the silhouette.
+// Large, visible pieces survive a --max-accessories cut before small jewelry.
+var accessoryImportance = map[string]int{
+	"hats":      10,
+	"bags":      9,
+	"scarves":   8,
+	"necklaces": 7,
+	"belts":     6,
+	"gloves":    5,
+	"earrings":  4,
+	"watches":   3,
+	"bracelets": 2,
+	"rings":     1,
+}
+
+// accessoryKeywords maps words in free-text accessory lists to a category
+var accessoryKeywords = []struct {
+	category string
+	words    []string
+}{
+	{"hats", []string{"hat", "cap", "beanie", "beret", "fedora", "headband", "tiara", "crown", "hood"}},
+	{"bags", []string{"bag", "purse", "clutch", "tote", "backpack", "handbag", "satchel"}},
+	{"scarves", []string{"scarf", "scarves", "shawl", "stole", "bandana"}},
+	{"necklaces", []string{"necklace", "choker", "pendant", "chain", "locket"}},
+	{"belts", []string{"belt", "sash"}},
+	{"gloves", []string{"glove", "mitten"}},
+	{"earrings", []string{"earring", "stud", "hoop"}},
+	{"watches", []string{"watch"}},
+	{"bracelets", []string{"bracelet", "bangle", "cuff"}},
+	{"rings", []string{"ring"}},
+}
+
+// placeholderAccessory reports analysis values that mean nothing is present
+func placeholderAccessory(text string) bool {
+	switch strings.ToLower(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(text), "."))) {
+	case "", "none", "n/a", "na", "no", "not visible", "none visible", "not present", "not applicable":
+		return true
+	}
+	return false
+}
+
+// accessoryCategory guesses the category of a free-text accessory
+func accessoryCategory(text string) string {
+	lower := strings.ToLower(text)
+	for _, entry := range accessoryKeywords {
+		if containsAnyWord(lower, entry.words) {
+			return entry.category
+		}
+	}
+	return ""
+}
+
+// limitAccessories keeps the maxItems most important accessories.
+// Kept items stay in their original order so the prompt reads naturally;
+// ties are broken by position, trusting the order the analyzer listed them in.
+// A maxItems of 0 or less keeps everything.
+func limitAccessories(items []accessoryItem, maxItems int) (kept, dropped []accessoryItem) {
+	if maxItems <= 0 || len(items) <= maxItems {
+		return items, nil
+	}
+
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return accessoryImportance[items[order[a]].Category] > accessoryImportance[items[order[b]].Category]
+	})
+
+	keep := make(map[int]bool, maxItems)
+	for _, i := range order[:maxItems] {
+		keep[i] = true
+	}
+	for i, item := range items {
+		if keep[i] {
+			kept = append(kept, item)
+		} else {
+			dropped = append(dropped, item)
+		}
+	}
+	return kept, dropped
+}
+
+// reportDroppedAccessories tells the user which accessories were left out of the prompt
+func reportDroppedAccessories(dropped []accessoryItem, maxItems int) {
+	if len(dropped) == 0 {
+		return
+	}
+	output.Progress.Printf("  Keeping top %d accessories, dropped %d: %s\n", maxItems, len(dropped), strings.Join(accessoryTexts(dropped), "; "))
+}
+
+// accessoryTexts returns the prompt text of each item
+func accessoryTexts(items []accessoryItem) []string {
+	var texts []string
+	for _, item := range items {
+		texts = append(texts, item.Text)
+	}
+	return texts
+}
+
+// droppedAccessoriesFilter describes a --max-accessories cut for provenance records
+func droppedAccessoriesFilter(dropped []string) string {
+	return "max-accessories dropped: " + strings.Join(dropped, "; ")
+}
+
+// limitAccessoryList applies --max-accessories to the accessories list of an outfit
+// analysis and returns the kept items and the text of the dropped ones
+func limitAccessoryList(accessories []interface{}, maxItems int) ([]interface{}, []string) {
+	if maxItems <= 0 || len(accessories) <= maxItems {
+		return accessories, nil
+	}
+
+	items := make([]accessoryItem, 0, len(accessories))
+	for _, acc := range accessories {
+		var builder strings.Builder
+		appendAccessoryItem(&builder, acc)
+		text := builder.String()
+		items = append(items, accessoryItem{Category: accessoryCategory(text), Text: text, Source: acc})
+	}
+
+	kept, dropped := limitAccessories(items, maxItems)
+	reportDroppedAccessories(dropped, maxItems)
+
+	result := make([]interface{}, len(kept))
+	for i, item := range kept {
+		result[i] = item.Source
+	}
+	return result, accessoryTexts(dropped)
+}
@@ -0,0 +1,209 @@
+package workflow
+
+import (
+	"encoding/json"
+	"img-cli/pkg/logger"
+	"img-cli/pkg/models"
+	"img-cli/pkg/vocab"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// altTextLimit keeps alt text within what screen readers and CMS fields handle well
+const altTextLimit = 150
+
+// maxAltTextGarments is how many garments alt text names before it stops
+const maxAltTextGarments = 4
+
+// pluralGarments are garment types named without an article
+var pluralGarments = map[string]bool{
+	"trousers": true, "jeans": true, "shorts": true, "leggings": true,
+	"shoes": true, "boots": true, "sandals": true, "swimwear": true,
+}
+
+// WithAltTextRefinement polishes the drafted alt text of every output with a
+// cheap text request. Without it the draft is stored as-is.
+func WithAltTextRefinement(enabled bool) Option {
+	return func(o *Orchestrator) {
+		o.refineAltText = enabled
+	}
+}
+
+// altText describes a generated image for accessibility. The draft comes from
+// the recipe (the style's framing and background, and the outfit in the
+// controlled vocabulary) and is optionally refined. Refinement failures fall
+// back to the draft.
+func (o *Orchestrator) altText(components map[string]*models.ComponentData, caption string) string {
+	draft := draftAltText(components)
+	if !o.refineAltText {
+		return draft
+	}
+	refined, err := o.altTextWriter.Refine(draft, caption, altTextLimit)
+	if err != nil {
+		logger.Warn("Alt text refinement failed, using draft", "error", err)
+		return draft
+	}
+	return truncateWords(refined, altTextLimit)
+}
+
+// draftAltText builds alt text like "Full body shot of a person wearing a navy
+// coat and black trousers, set against a plain white studio backdrop."
+func draftAltText(components map[string]*models.ComponentData) string {
+	var framing, background string
+	if style := components["style"]; style != nil {
+		framing, background = altTextScene(style)
+	}
+	if setting := altTextBackground(components["background"]); setting != "" {
+		background = setting
+	}
+
+	text := "Photo of a person"
+	if framing != "" {
+		text = upperFirst(framing) + " of a person"
+	}
+	if outfit := altTextOutfit(components["outfit"]); outfit != "" {
+		text += " wearing " + outfit
+		if over := altTextOutfit(components["over_outfit"]); over != "" {
+			text += " over " + over
+		}
+	}
+	if background != "" {
+		background = lowerFirst(background)
+		if first, _, _ := strings.Cut(background, " "); first != "a" && first != "an" && first != "the" {
+			background = article(background) + " " + background
+		}
+		text += ", set against " + background
+	}
+	return truncateWords(text, altTextLimit-1) + "."
+}
+
+// altTextOutfit names the garments of an outfit component, falling back to
+// its text when nothing maps onto the garment taxonomy
+func altTextOutfit(c *models.ComponentData) string {
+	if c == nil {
+		return ""
+	}
+	terms := vocab.ForAnalysis(c.Type, c.JSONData, c.Description)
+	if terms == nil || len(terms.Garments) == 0 {
+		if c.Text != "" && len(c.Text) <= 60 {
+			return strings.TrimSuffix(strings.TrimSpace(c.Text), ".")
+		}
+		return ""
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	for _, garment := range terms.Garments {
+		name := strings.TrimSpace(garment.Color + " " + garment.Type)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		if !pluralGarments[garment.Type] {
+			name = article(name) + " " + name
+		}
+		names = append(names, name)
+		if len(names) == maxAltTextGarments {
+			break
+		}
+	}
+	return joinList(names)
+}
+
+// altTextScene returns the first clause of the style's framing and background
+func altTextScene(style *models.ComponentData) (framing, background string) {
+	var fields struct {
+		Framing    string `json:"framing"`
+		Background string `json:"background"`
+	}
+	if style.JSONData != nil {
+		json.Unmarshal(style.JSONData, &fields)
+	}
+	framing = firstClause(fields.Framing)
+	background = firstClause(fields.Background)
+	if background == "" && style.JSONData == nil && style.ImagePath != "" {
+		// Only the reference name is known
+		name := strings.TrimSuffix(filepath.Base(style.ImagePath), filepath.Ext(style.ImagePath))
+		background = "a " + strings.NewReplacer("-", " ", "_", " ").Replace(name) + " backdrop"
+	}
+	return framing, background
+}
+
+// altTextBackground returns the first clause of a background component's
+// location (or setting), or of its text
+func altTextBackground(c *models.ComponentData) string {
+	if c == nil {
+		return ""
+	}
+	var fields struct {
+		Setting  string `json:"setting"`
+		Location string `json:"location"`
+	}
+	if c.JSONData != nil {
+		json.Unmarshal(c.JSONData, &fields)
+	}
+	for _, text := range []string{fields.Location, fields.Setting, c.Text} {
+		if clause := firstClause(text); clause != "" {
+			return clause
+		}
+	}
+	return ""
+}
+
+// firstClause cuts a description at its first clause boundary
+func firstClause(text string) string {
+	lower := strings.ToLower(text)
+	for _, sep := range []string{",", ";", ".", " with ", " featuring ", " - ", " (", ":"} {
+		if i := strings.Index(lower, sep); i > 0 {
+			text, lower = text[:i], lower[:i]
+		}
+	}
+	return strings.TrimSpace(text)
+}
+
+// truncateWords shortens text to at most limit characters at a word boundary
+func truncateWords(text string, limit int) string {
+	text = strings.TrimSpace(text)
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	head := string(runes[:limit])
+	cut := strings.LastIndex(head, " ")
+	if cut <= 0 {
+		cut = len(head)
+	}
+	return strings.TrimRight(head[:cut], " ,;")
+}
+
+func article(word string) string {
+	if word != "" && strings.ContainsRune("aeiou", unicode.ToLower(rune(word[0]))) {
+		return "an"
+	}
+	return "a"
+}
+
+func joinList(items []string) string {
+	switch len(items) {
+	case 0:
+		return ""
+	case 1:
+		return items[0]
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " and " + items[len(items)-1]
+}
+
+func upperFirst(text string) string {
+	if text == "" {
+		return text
+	}
+	return strings.ToUpper(text[:1]) + text[1:]
+}
+
+func lowerFirst(text string) string {
+	if text == "" {
+		return text
+	}
+	return strings.ToLower(text[:1]) + text[1:]
+}
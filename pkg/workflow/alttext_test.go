@@ -0,0 +1,24 @@
+package workflow
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateWords(t *testing.T) {
+	for _, tc := range []struct {
+		text  string
+		limit int
+		want  string
+	}{
+		{"a navy wool coat", 40, "a navy wool coat"},
+		{"a navy wool coat, a silk scarf", 18, "a navy wool coat"},
+		{"a crêpe de chine blouse", 11, "a crêpe de"},
+		{"日本の着物と帯", 4, "日本の着"},
+	} {
+		got := truncateWords(tc.text, tc.limit)
+		if got != tc.want || !utf8.ValidString(got) {
+			t.Errorf("truncateWords(%q, %d) = %q, want %q", tc.text, tc.limit, got, tc.want)
+		}
+	}
+}
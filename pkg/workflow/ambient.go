@@ -0,0 +1,137 @@
+package workflow
+
+import (
+	"fmt"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/output"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ambientPresets describe the lighting and atmosphere of each named ambient
+var ambientPresets = map[string]string{
+	"sunrise":     "early sunrise: low, soft warm light from near the horizon, long gentle shadows, pale peach and pink sky tones, fresh quiet morning atmosphere",
+	"golden-hour": "golden hour: low warm sunlight, long soft shadows, glowing amber highlights and warm rim light on the subject, rich honeyed color",
+	"noon":        "bright midday sun: hard light from directly overhead, short crisp shadows, high contrast, saturated true-to-life colors",
+	"overcast":    "overcast daylight: soft diffuse light from a uniformly cloudy sky, almost no shadows, muted cool-neutral colors, calm even exposure",
+	"blue-hour":   "blue hour just after sunset: deep blue ambient sky light, first artificial lights glowing warm against it, low contrast, tranquil mood",
+	"night":       "night: dark surroundings lit by practical sources such as street lamps and windows, pools of warm light, deep shadows, visible ambient glow",
+	"night-neon":  "night with neon signage: saturated magenta, cyan and electric blue neon light casting colored highlights on skin and clothing, reflections on wet surfaces, deep shadows",
+	"rain":        "rainy day: wet reflective surfaces, soft grey light, visible raindrops or mist, cool desaturated palette, moody atmosphere",
+	"candlelight": "candlelight: very warm, flickering low-key light from nearby flames, soft falloff into darkness, intimate atmosphere",
+	"studio":      "clean studio lighting: large softbox key light with gentle fill, controlled even illumination, neutral color balance",
+}
+
+// ambientSweepPrefix starts an --ambient value
+const ambientSweepPrefix = "sweep:"
+
+// ParseAmbient parses "sweep:golden-hour,noon,overcast" into ambient names. Each
+// entry is a preset or, when it contains a space, a free-text lighting description.
+func ParseAmbient(spec string) ([]string, error) {
+	if spec == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(spec, ambientSweepPrefix) {
+		return nil, errors.ErrInvalidInput("ambient", "expected sweep:<ambient>,<ambient>,... got "+spec)
+	}
+
+	var ambients []string
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(strings.TrimPrefix(spec, ambientSweepPrefix), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" || seen[entry] {
+			continue
+		}
+		if _, ok := ambientPresets[strings.ToLower(entry)]; ok {
+			entry = strings.ToLower(entry)
+		} else if !strings.Contains(entry, " ") {
+			return nil, errors.ErrInvalidInput("ambient", fmt.Sprintf("unknown ambient %q (presets: %s; or use a description with spaces)",
+				entry, strings.Join(AmbientPresets(), ", ")))
+		}
+		seen[entry] = true
+		ambients = append(ambients, entry)
+	}
+	if len(ambients) == 0 {
+		return nil, errors.ErrInvalidInput("ambient", "no ambients listed after sweep:")
+	}
+	return ambients, nil
+}
+
+// AmbientPresets lists the preset ambient names
+func AmbientPresets() []string {
+	names := make([]string, 0, len(ambientPresets))
+	for name := range ambientPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ambientDescription returns the lighting description of a preset or free-text ambient
+func ambientDescription(ambient string) string {
+	if desc, ok := ambientPresets[ambient]; ok {
+		return desc
+	}
+	return ambient
+}
+
+var nonAlphanumeric = regexp.MustCompile(`[^a-z0-9]+`)
+
+// ambientFileTag shortens an ambient into a file name part
+func ambientFileTag(ambient string) string {
+	if _, ok := ambientPresets[ambient]; ok {
+		return ambient
+	}
+	tag := strings.Trim(nonAlphanumeric.ReplaceAllString(strings.ToLower(ambient), "-"), "-")
+	if len(tag) > 24 {
+		tag = strings.TrimRight(tag[:24], "-")
+	}
+	return tag
+}
+
+// ambientPromptSection overrides the lighting of the style for one step of a
+// sweep while everything else in the image stays fixed
+func ambientPromptSection(ambient string) string {
+	return strings.Join([]string{
+		"",
+		"==================================================",
+		"💡 LIGHTING / AMBIENT OVERRIDE",
+		"==================================================",
+		ambientDescription(ambient),
+		"",
+		"This replaces any lighting, time of day, color grading and mood described above.",
+		"Keep EVERYTHING else identical: the same person, outfit, pose, framing, camera angle,",
+		"composition and background layout. Only the light and atmosphere change.",
+	}, "\n")
+}
+
+// RunAmbientSweep generates one modular recipe once per ambient into a single
+// output directory, so the images form a lighting study of the same look.
+// An ambient that fails is reported and the sweep continues.
+func (o *Orchestrator) RunAmbientSweep(config ModularConfig, ambients []string) ([]string, error) {
+	if config.OutputDir == "" {
+		config.OutputDir = generateOutputDir()
+	}
+
+	o.progress.begin(len(ambients) * config.Variations)
+	defer o.progress.end()
+
+	var results []string
+	var lastErr error
+	for i, ambient := range ambients {
+		output.Progress.Printf("\n💡 Ambient %d/%d: %s\n", i+1, len(ambients), ambient)
+		config.Ambient = ambient
+		paths, err := o.RunModularWorkflow(config)
+		if err != nil {
+			output.Progress.Printf("   ❌ Error: %v\n", err)
+			lastErr = err
+			continue
+		}
+		results = append(results, paths...)
+	}
+	if len(results) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return results, nil
+}
@@ -0,0 +1,24 @@
+package workflow
+
+import (
+	"img-cli/pkg/analyzer"
+	"slices"
+)
+
+// AnalysisTypes lists the component analyses the analyze command runs, in
+// the order "all" runs them: the built-in ones, then those added with
+// analyzer.Register
+func AnalysisTypes() []string {
+	return analyzer.Registered()
+}
+
+// IsAnalysisType reports whether analyzerType is one of AnalysisTypes
+func IsAnalysisType(analyzerType string) bool {
+	return slices.Contains(AnalysisTypes(), analyzerType)
+}
+
+// SetCacheRefresh makes analyses ignore cached results and write fresh ones
+// in their place, to re-warm entries that are stale
+func (o *Orchestrator) SetCacheRefresh(refresh bool) {
+	o.refreshCache = refresh
+}
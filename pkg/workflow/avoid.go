@@ -0,0 +1,20 @@
+package workflow
+
+import "strings"
+
+// ParseAvoid splits an --avoid value such as "hats, sunglasses, visible logos"
+// into the elements to keep out of generated images
+func ParseAvoid(spec string) []string {
+	var avoid []string
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		key := strings.ToLower(entry)
+		if entry == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		avoid = append(avoid, entry)
+	}
+	return avoid
+}
@@ -0,0 +1,54 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// backgroundRemovedFilter notes that the background was dropped from a style because --background supplies it
+const backgroundRemovedFilter = "background removed (supplied by background)"
+
+// extractBackgroundDescription extracts the environment description from analysis
+func (o *Orchestrator) extractBackgroundDescription(data json.RawMessage) string {
+	var result map[string]interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "Neutral background"
+	}
+
+	var parts []string
+	for _, field := range []struct{ key, label string }{
+		{"setting", "Setting"},
+		{"location", "Location"},
+		{"architecture", "Architecture"},
+		{"nature", "Nature"},
+		{"props", "Props"},
+		{"weather", "Weather"},
+		{"depth", "Depth"},
+		{"colors", "Colors and materials"},
+		{"atmosphere", "Atmosphere"},
+	} {
+		if value, ok := result[field.key].(string); ok && value != "" && !placeholderField(value) {
+			parts = append(parts, fmt.Sprintf("%s: %s", field.label, value))
+		}
+	}
+
+	if overall, ok := result["overall"].(string); ok && overall != "" {
+		parts = append(parts, overall)
+	}
+
+	if len(parts) > 0 {
+		return strings.Join(parts, ". ")
+	}
+
+	return "Neutral background"
+}
+
+// placeholderField reports analysis values that mean the field doesn't apply
+func placeholderField(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "none", "n/a", "not visible", "unclear":
+		return true
+	}
+	return false
+}
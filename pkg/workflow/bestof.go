@@ -0,0 +1,209 @@
+package workflow
+
+import (
+	"fmt"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/gemini"
+	"img-cli/pkg/generator"
+	"img-cli/pkg/logger"
+	"img-cli/pkg/models"
+	"img-cli/pkg/output"
+	"img-cli/pkg/validator"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// RejectedDir is the subfolder of an output folder that --best-of moves the
+// candidates that lost into
+const RejectedDir = "rejected"
+
+// MaxBestOf caps --best-of; every candidate is billed like an image
+const MaxBestOf = 8
+
+// ValidateBestOf checks --best-of
+func ValidateBestOf(n int) error {
+	if n < 1 || n > MaxBestOf {
+		return errors.ErrInvalidInput("best-of", fmt.Sprintf("must be between 1 and %d, got %d", MaxBestOf, n))
+	}
+	return nil
+}
+
+// ExtraImages is how many more images --best-of generates for n outputs
+func (v VerifyOptions) ExtraImages(n int) int {
+	if v.BestOf <= 1 {
+		return 0
+	}
+	return n * (v.BestOf - 1)
+}
+
+// candidates is how many images are generated per output. Dry runs plan one,
+// since every candidate would have the same prompt.
+func (o *Orchestrator) candidates(verify VerifyOptions) int {
+	if verify.BestOf <= 1 || o.dryRun {
+		return 1
+	}
+	return verify.BestOf
+}
+
+// candidate is one generated image of an output and the scores it earned
+type candidate struct {
+	result   *generator.GenerateResult
+	identity *float64
+	judged   *validator.JudgeScores
+	err      error
+	started  time.Time
+	took     time.Duration
+	skipped  bool // Not generated because the run was stopping
+}
+
+// rank orders candidates: one that reaches MinIdentityScore beats one that
+// doesn't, then the mean of the judge and identity scores decides
+func (c candidate) rank(verify VerifyOptions) (bool, float64) {
+	passes := c.identity == nil || verify.MinIdentityScore <= 0 || *c.identity >= verify.MinIdentityScore
+	sum, n := 0.0, 0.0
+	if c.judged != nil {
+		sum, n = sum+c.judged.Overall, n+1
+	}
+	if c.identity != nil {
+		sum, n = sum+*c.identity, n+1
+	}
+	if n == 0 {
+		return passes, 0
+	}
+	return passes, sum / n
+}
+
+// candidateSet collects the candidates of one output. Each candidate is its
+// own job on the generation queue; the job that completes the set picks the
+// winner.
+type candidateSet struct {
+	mu   sync.Mutex
+	want int
+	got  []candidate
+}
+
+func newCandidateSet(n int) *candidateSet {
+	return &candidateSet{want: n}
+}
+
+// add records a candidate and returns the whole set once it is complete
+func (s *candidateSet) add(c candidate) []candidate {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.got = append(s.got, c)
+	if len(s.got) < s.want {
+		return nil
+	}
+	return s.got
+}
+
+// startedCandidates drops the candidates a stopping run skipped
+func startedCandidates(all []candidate) []candidate {
+	var started []candidate
+	for _, c := range all {
+		if !c.skipped {
+			started = append(started, c)
+		}
+	}
+	return started
+}
+
+// generateCandidate generates one candidate and scores it. Candidates are
+// judged unless identity validation alone was asked for, so --best-of always
+// has something to compare.
+func (o *Orchestrator) generateCandidate(subjectPath string, components map[string]*models.ComponentData, verify VerifyOptions,
+	generate func() (*generator.GenerateResult, error)) candidate {
+	c := candidate{started: time.Now()}
+	c.result, c.err = generate()
+	c.took = time.Since(c.started)
+	if c.err != nil {
+		return c
+	}
+	if verify.validatesIdentity() && subjectPath != "" {
+		c.identity = o.identityScore(subjectPath, c.result.OutputPath)
+	}
+	checks := verify
+	if !checks.validatesIdentity() {
+		checks.Judge = true
+	}
+	c.judged = o.judgeOutput(c.result.OutputPath, components, checks)
+	return c
+}
+
+// generateBest generates the candidates of an output one after another and
+// keeps the best. Without --best-of it is generateValidated.
+func (o *Orchestrator) generateBest(subjectPath string, components map[string]*models.ComponentData, verify VerifyOptions,
+	generate func() (*generator.GenerateResult, error)) (candidate, error) {
+	n := o.candidates(verify)
+	if n == 1 {
+		return o.generateValidated(subjectPath, verify, generate)
+	}
+	var all []candidate
+	for i := 0; i < n; i++ {
+		all = append(all, o.generateCandidate(subjectPath, components, verify, generate))
+	}
+	return o.pickCandidate(all, verify)
+}
+
+// pickCandidate keeps the best-scoring candidate and moves the others into
+// the rejected/ subfolder. It fails only when every candidate failed.
+func (o *Orchestrator) pickCandidate(all []candidate, verify VerifyOptions) (candidate, error) {
+	best := -1
+	var lastErr error
+	for i, c := range all {
+		if c.err != nil {
+			if _, planned := gemini.AsDryRun(c.err); planned {
+				return c, c.err
+			}
+			logger.Warn("Candidate failed", "candidate", i+1, "error", c.err)
+			lastErr = c.err
+			continue
+		}
+		if best < 0 {
+			best = i
+			continue
+		}
+		passes, score := c.rank(verify)
+		bestPasses, bestScore := all[best].rank(verify)
+		if (passes && !bestPasses) || (passes == bestPasses && score > bestScore) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return candidate{took: time.Since(all[0].started), started: all[0].started}, lastErr
+	}
+
+	winner := all[best]
+	rejected := 0
+	for i, c := range all {
+		if i != best && c.err == nil {
+			rejectOutput(c.result.OutputPath)
+			rejected++
+		}
+	}
+	_, score := winner.rank(verify)
+	output.Progress.Printf("      🥇 Kept %s (score %.2f, best of %d); %d moved to %s/\n",
+		filepath.Base(winner.result.OutputPath), score, len(all), rejected, RejectedDir)
+
+	if winner.identity != nil && verify.MinIdentityScore > 0 && *winner.identity < verify.MinIdentityScore {
+		output.Progress.Printf("      ⚠️  %s may not show the subject (identity score %.2f < %.2f)\n",
+			filepath.Base(winner.result.OutputPath), *winner.identity, verify.MinIdentityScore)
+		o.flagForReview(winner.result.OutputPath, LabelIdentityDrift)
+	}
+	winner.took = time.Since(winner.started)
+	return winner, nil
+}
+
+// rejectOutput moves a losing candidate into the rejected/ subfolder next to it
+func rejectOutput(outputPath string) {
+	dir := filepath.Join(filepath.Dir(outputPath), RejectedDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		logger.Warn("Failed to create rejected folder", "dir", dir, "error", err)
+		return
+	}
+	if err := os.Rename(outputPath, filepath.Join(dir, filepath.Base(outputPath))); err != nil {
+		logger.Warn("Failed to move rejected candidate", "image", filepath.Base(outputPath), "error", err)
+	}
+}
@@ -0,0 +1,76 @@
+package workflow
+
+import (
+	"img-cli/pkg/analyzer"
+	"path/filepath"
+	"strings"
+)
+
+// captionEntry is a sidecar component and the text its caption part starts with
+type captionEntry struct {
+	name   string
+	prefix string
+}
+
+// captionOrder lists the sidecar components in the order they appear in a caption
+var captionOrder = []captionEntry{
+	{"outfit", "wearing "},
+	{"over_outfit", "worn over "},
+	{"hair_style", "hair: "},
+	{"hair_color", "hair color: "},
+	{"makeup", "makeup: "},
+	{"expression", "expression: "},
+	{"accessories", "accessories: "},
+	{"pose", "pose: "},
+	{"background", "background: "},
+	{"style", "photo style: "},
+}
+
+// Caption describes a generated image in plain text from the component
+// descriptions recorded in its sidecar, for use as training captions
+func (s *Sidecar) Caption() string {
+	parts := []string{"a photo of a person"}
+	for _, entry := range captionEntries() {
+		source, ok := s.Provenance.Components[entry.name]
+		if !ok {
+			continue
+		}
+		text := captionText(source)
+		if text == "" {
+			continue
+		}
+		if entry.name == "outfit" {
+			text = strings.TrimPrefix(text, "wearing exactly: ")
+		}
+		parts = append(parts, entry.prefix+text)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// captionEntries is captionOrder with the registered components (see
+// analyzer.Register) before the style
+func captionEntries() []captionEntry {
+	last := len(captionOrder) - 1
+	entries := append([]captionEntry{}, captionOrder[:last]...)
+	for _, name := range analyzer.Components() {
+		entries = append(entries, captionEntry{name, componentLabel(name) + ": "})
+	}
+	return append(entries, captionOrder[last])
+}
+
+// captionText returns the description of a component, cleaned of generation instructions
+func captionText(source ComponentSource) string {
+	text := source.Description
+	if text == "" {
+		text = source.Text
+	}
+	if text == "" && source.File != "" {
+		// Only the reference name is known (e.g. a style in the combined workflow)
+		text = strings.ReplaceAll(strings.TrimSuffix(filepath.Base(source.File), filepath.Ext(source.File)), "-", " ")
+	}
+
+	text = strings.ReplaceAll(text, ". "+outfitFillNote, ", with ")
+	text = strings.ReplaceAll(text, outfitFillNote, "with ")
+	text = strings.Join(strings.Fields(text), " ")
+	return strings.TrimSuffix(text, ".")
+}
@@ -0,0 +1,133 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/generator"
+	"img-cli/pkg/logger"
+	"img-cli/pkg/output"
+	"path/filepath"
+)
+
+// Chained generator types that can follow the main photo output
+const (
+	ChainArtStyle   = "art_style"   // Illustrated version of every generated photo
+	ChainStyleGuide = "style_guide" // One style guide sheet per run
+)
+
+// ChainSteps lists the accepted chain step types
+var ChainSteps = []string{ChainArtStyle, ChainStyleGuide}
+
+// ChainOptions adds outputs of other generator types to a run. The art style
+// reference is analyzed once and that analysis drives every chained output.
+type ChainOptions struct {
+	Steps       []string
+	ArtStyleRef string
+}
+
+// Has reports whether a chain step is enabled
+func (c ChainOptions) Has(step string) bool {
+	for _, s := range c.Steps {
+		if s == step {
+			return true
+		}
+	}
+	return false
+}
+
+// ExtraImages is the number of chained images generated for a run with n main outputs
+func (c ChainOptions) ExtraImages(n int) int {
+	extra := 0
+	if c.Has(ChainArtStyle) {
+		extra += n
+	}
+	if c.Has(ChainStyleGuide) {
+		extra++
+	}
+	return extra
+}
+
+// Validate checks the step names and that the steps have the reference they need
+func (c ChainOptions) Validate() error {
+	for _, step := range c.Steps {
+		if step != ChainArtStyle && step != ChainStyleGuide {
+			return errors.ErrInvalidInput("chain", fmt.Sprintf("unknown step %q (use %s or %s)", step, ChainArtStyle, ChainStyleGuide))
+		}
+	}
+	if len(c.Steps) > 0 && c.ArtStyleRef == "" {
+		return errors.ErrInvalidInput("chain", "requires --art-style with an art style reference image")
+	}
+	return nil
+}
+
+// chainRun holds the analysis shared by all chained outputs of one run
+type chainRun struct {
+	options  ChainOptions
+	analysis json.RawMessage
+}
+
+// prepareChain analyzes the art style reference once for the whole run
+func (o *Orchestrator) prepareChain(options ChainOptions) (*chainRun, error) {
+	if len(options.Steps) == 0 {
+		return nil, nil
+	}
+	output.Progress.Printf("\n🔗 Analyzing art style for chained outputs: %s\n", filepath.Base(options.ArtStyleRef))
+	analysis, err := o.AnalyzeImage("art_style", options.ArtStyleRef)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.AnalysisError, "failed to analyze art style for chained outputs")
+	}
+	return &chainRun{options: options, analysis: analysis}, nil
+}
+
+// chainOutput runs the per-image chain steps on one generated photo
+func (o *Orchestrator) chainOutput(chain *chainRun, outputPath, outputDir string, result *WorkflowResult) {
+	if chain == nil || !chain.options.Has(ChainArtStyle) {
+		return
+	}
+	output.Progress.Printf("      🔗 Illustrating %s\n", filepath.Base(outputPath))
+	styled, err := o.GenerateImage("art_style", generator.GenerateParams{
+		ImagePath:      outputPath,
+		StyleReference: chain.options.ArtStyleRef,
+		StyleAnalysis:  chain.analysis,
+		OutputDir:      outputDir,
+	})
+	if err != nil {
+		logger.Warn("Chained art style generation failed", "image", filepath.Base(outputPath), "error", err)
+		o.recordFailure(fmt.Sprintf("%s: %s", ChainArtStyle, filepath.Base(outputPath)), "", err)
+		return
+	}
+	result.Steps = append(result.Steps, StepResult{
+		Type:       "generation",
+		Name:       ChainArtStyle,
+		OutputPath: styled.OutputPath,
+		Message:    fmt.Sprintf("Illustrated %s", filepath.Base(outputPath)),
+	})
+}
+
+// finishChain runs the once-per-run chain steps
+func (o *Orchestrator) finishChain(chain *chainRun, outputDir string, result *WorkflowResult) {
+	if chain == nil || !chain.options.Has(ChainStyleGuide) {
+		return
+	}
+	output.Progress.Printf("\n🔗 Generating style guide sheet\n")
+	guide, err := o.GenerateImage("style_guide", generator.GenerateParams{
+		StyleAnalysis:   chain.analysis,
+		OutputDir:       outputDir,
+		SaveToOutputDir: true,
+	})
+	if o.Planned(err, outputDir, ChainStyleGuide) {
+		return
+	}
+	if err != nil {
+		logger.Warn("Chained style guide generation failed", "error", err)
+		o.recordFailure(ChainStyleGuide, "", err)
+		return
+	}
+	result.Steps = append(result.Steps, StepResult{
+		Type:       "generation",
+		Name:       ChainStyleGuide,
+		OutputPath: guide.OutputPath,
+		Message:    "Generated style guide",
+	})
+}
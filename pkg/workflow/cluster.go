@@ -0,0 +1,198 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/imaging"
+	"img-cli/pkg/logger"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultClusterThreshold is the perceptual hash distance (in bits) under which
+// two outputs count as the same look
+const DefaultClusterThreshold = 10
+
+// ClusterReport groups the outputs of a run into distinct looks
+type ClusterReport struct {
+	Dir          string               `json:"dir"`
+	Threshold    int                  `json:"threshold"`
+	Images       int                  `json:"images"`
+	Looks        []Look               `json:"looks"`
+	Combinations []CombinationSummary `json:"combinations"`
+	Skipped      []string             `json:"skipped,omitempty"` // Images that could not be decoded
+}
+
+// Look is a cluster of near-duplicate outputs
+type Look struct {
+	ID     int      `json:"id"`
+	Images []string `json:"images"`
+}
+
+// CombinationSummary reports how many distinct looks the variations of one
+// component combination produced
+type CombinationSummary struct {
+	Recipe    string   `json:"recipe"`
+	Images    []string `json:"images"`
+	Looks     []int    `json:"looks"`
+	Saturated bool     `json:"saturated"` // Several variations but only one look
+}
+
+// BuildClusterReport hashes every image under dir and clusters near-duplicates
+func BuildClusterReport(dir string, threshold int) (*ClusterReport, error) {
+	report := &ClusterReport{Dir: dir, Threshold: threshold}
+
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == "cache" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".png", ".jpg", ".jpeg", ".gif":
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", dir, err)
+	}
+
+	var images []string
+	var hashes []uint64
+	for _, path := range paths {
+		img, err := imaging.Load(path)
+		if err != nil {
+			logger.Warn("Skipping image", "path", path, "error", err)
+			report.Skipped = append(report.Skipped, path)
+			continue
+		}
+		images = append(images, path)
+		hashes = append(hashes, imaging.PHash(img))
+	}
+	report.Images = len(images)
+
+	lookOf := make(map[string]int, len(images))
+	for i, members := range imaging.ClusterHashes(hashes, threshold) {
+		look := Look{ID: i + 1}
+		for _, m := range members {
+			look.Images = append(look.Images, images[m])
+			lookOf[images[m]] = look.ID
+		}
+		report.Looks = append(report.Looks, look)
+	}
+
+	// Group variations of the same recipe to see whether they still add new looks
+	byRecipe := make(map[string]*CombinationSummary)
+	var recipes []string
+	for _, path := range images {
+		recipe := recipeKey(path)
+		summary, ok := byRecipe[recipe]
+		if !ok {
+			summary = &CombinationSummary{Recipe: recipe}
+			byRecipe[recipe] = summary
+			recipes = append(recipes, recipe)
+		}
+		summary.Images = append(summary.Images, path)
+		if !containsInt(summary.Looks, lookOf[path]) {
+			summary.Looks = append(summary.Looks, lookOf[path])
+		}
+	}
+	sort.Strings(recipes)
+	for _, recipe := range recipes {
+		summary := byRecipe[recipe]
+		sort.Ints(summary.Looks)
+		summary.Saturated = len(summary.Images) > 1 && len(summary.Looks) == 1
+		report.Combinations = append(report.Combinations, *summary)
+	}
+
+	return report, nil
+}
+
+// Save writes the report as JSON
+func (r *ClusterReport) Save(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// recipeKey identifies the component combination an output was generated from,
+// using its sidecar when available
+func recipeKey(imagePath string) string {
+	sidecar, err := ReadSidecar(imagePath)
+	if err != nil {
+		return "(no metadata) " + filepath.Base(imagePath)
+	}
+	config, err := sidecar.Recipe()
+	if err != nil {
+		return "(no metadata) " + filepath.Base(imagePath)
+	}
+	return recipeLabel(config)
+}
+
+// stepRecipe is the combination key of a generated output: the recipe it was
+// recorded with, or the one its sidecar rebuilds
+func stepRecipe(step StepResult) string {
+	if step.Recipe != "" {
+		return step.Recipe
+	}
+	return recipeKey(step.OutputPath)
+}
+
+// failureRecipe is the combination key of a failed generation: its recipe,
+// or its combination when it was recorded without one
+func failureRecipe(failure Failure) string {
+	if failure.Recipe != "" {
+		return failure.Recipe
+	}
+	return failure.Combination
+}
+
+// recipeLabel names a component combination, e.g. "subject=kat.png outfit=suit.png"
+func recipeLabel(config ModularConfig) string {
+	inputs := RecipeInputs(config)
+	var parts []string
+	for _, name := range RecipeComponents() {
+		if value, ok := inputs[name]; ok {
+			if isFilePath(value) {
+				value = filepath.Base(value)
+			}
+			parts = append(parts, name+"="+value)
+		}
+	}
+	if config.Ambient != "" {
+		parts = append(parts, "ambient="+ambientFileTag(config.Ambient))
+	}
+	return strings.Join(parts, " ")
+}
+
+// combinedRecipe names a combination of the combined workflow with
+// recipeLabel, as its sidecar rebuilds it: hair from a separate reference
+// stands for both hair components
+func combinedRecipe(subjectPath, outfitInput, stylePath, hairPath string) string {
+	config := ModularConfig{SubjectPath: subjectPath, OutfitRef: outfitInput, StyleRef: stylePath}
+	if hairPath != "" && hairPath != outfitInput {
+		config.HairStyleRef = hairPath
+		config.HairColorRef = hairPath
+	}
+	return recipeLabel(config)
+}
+
+func containsInt(values []int, v int) bool {
+	for _, existing := range values {
+		if existing == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,203 @@
+package workflow
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/analyzer"
+	"img-cli/pkg/models"
+	"img-cli/pkg/output"
+	"img-cli/pkg/prompts"
+	"path/filepath"
+	"strings"
+)
+
+// promptSection is the generation prompt section of a registered component.
+// Text is set instead of the other fields when the component renders its
+// section with its own template (see analyzer.Component.Section).
+type promptSection struct {
+	Heading     string
+	Description string
+	Instruction string
+	Text        string
+	Emphasis    string // Emphasis line of a weighted component (see SplitWeight)
+}
+
+// analyzeRegisteredComponents analyzes the registered components (see
+// analyzer.Register) a config sets, in registration order
+func (o *Orchestrator) analyzeRegisteredComponents(config ModularConfig, components *models.ModularComponents) error {
+	for _, name := range analyzer.Components() {
+		ref := config.Extra[name]
+		if ref == "" {
+			continue
+		}
+		if components.Extra == nil {
+			components.Extra = make(map[string]*models.ComponentData)
+		}
+
+		_, component, _ := analyzer.LookupComponent(name)
+		if keyword, ok := component.Keyword(ref); ok {
+			output.Progress.Printf("  Using %s: %s\n", componentLabel(name), keyword)
+			components.Extra[name] = &models.ComponentData{Type: name, Description: keyword, Text: keyword}
+			continue
+		}
+		if !isFilePath(ref) {
+			components.Extra[name] = o.textComponent(name, ref, config)
+			continue
+		}
+		if component.TextOnly {
+			return fmt.Errorf("%s takes a text description, not an image: %s", componentLabel(name), ref)
+		}
+
+		output.Progress.Printf("  Analyzing %s from: %s\n", componentLabel(name), filepath.Base(ref))
+		data, err := o.AnalyzeImage(name, ref)
+		if err != nil {
+			return fmt.Errorf("failed to analyze %s: %w", componentLabel(name), err)
+		}
+		components.Extra[name] = &models.ComponentData{
+			Type:        name,
+			Description: describeRegistered(name, data),
+			JSONData:    data,
+			ImagePath:   ref,
+		}
+	}
+	return nil
+}
+
+// describeRegistered turns the analysis of a registered component into its
+// prompt description
+func describeRegistered(name string, data json.RawMessage) string {
+	if _, component, ok := analyzer.LookupComponent(name); ok && component.Describe != nil {
+		return component.Describe(data)
+	}
+	return describeFields(data)
+}
+
+// describeFields lists the fields of an analysis as "Field: value" sentences
+// in the order the analyzer wrote them, with "overall" last and unlabeled
+func describeFields(data json.RawMessage) string {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if token, err := dec.Token(); err != nil || token != json.Delim('{') {
+		return ""
+	}
+
+	var parts []string
+	var overall string
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			break
+		}
+		key, _ := token.(string)
+		var value interface{}
+		if err := dec.Decode(&value); err != nil {
+			break
+		}
+		text := fieldText(value)
+		if text == "" || placeholderField(text) {
+			continue
+		}
+		if key == "overall" {
+			overall = text
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s: %s", upperFirst(strings.ReplaceAll(key, "_", " ")), text))
+	}
+	if overall != "" {
+		parts = append(parts, overall)
+	}
+	return strings.Join(parts, ". ")
+}
+
+// fieldText renders a string, number or list field of an analysis
+func fieldText(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64, bool:
+		return fmt.Sprint(v)
+	case []interface{}:
+		var items []string
+		for _, item := range v {
+			if text := fieldText(item); text != "" {
+				items = append(items, text)
+			}
+		}
+		return strings.Join(items, ", ")
+	}
+	return ""
+}
+
+// registeredStyleExclusions returns the style analysis fields the registered
+// components a config sets supply (see analyzer.Component.Replaces), and the
+// filters noting their removal
+func registeredStyleExclusions(config ModularConfig) ([]string, []string) {
+	var fields, filters []string
+	for _, name := range analyzer.Components() {
+		if config.Extra[name] == "" {
+			continue
+		}
+		_, component, _ := analyzer.LookupComponent(name)
+		for _, field := range component.Replaces {
+			fields = append(fields, field)
+			filters = append(filters, fmt.Sprintf("%s removed (supplied by %s)", field, componentLabel(name)))
+		}
+	}
+	return fields, filters
+}
+
+// registeredSections returns the prompt sections of the registered
+// components in a recipe, in registration order. Components the recipe
+// doesn't set get their default, if they have one.
+func registeredSections(components *models.ModularComponents, emphasis map[string]string) ([]promptSection, error) {
+	var sections []promptSection
+	for _, name := range analyzer.Components() {
+		_, component, _ := analyzer.LookupComponent(name)
+		description := component.Default
+		if data := components.Extra[name]; data != nil {
+			description = data.Description
+		}
+		switch {
+		case description == "":
+			continue
+		case component.Section != "":
+			// A section template may render nothing, e.g. for a keyword that lifts a default
+			text, err := prompts.Render(component.Section, description)
+			if err != nil {
+				return nil, err
+			}
+			if strings.TrimSpace(text) != "" {
+				sections = append(sections, promptSection{Text: text, Emphasis: emphasis[name]})
+			}
+		default:
+			sections = append(sections, promptSection{
+				Heading:     component.Heading,
+				Description: description,
+				Instruction: component.Instruction,
+				Emphasis:    emphasis[name],
+			})
+		}
+	}
+	return sections, nil
+}
+
+// registeredFiles collects the references of each registered component an
+// outfit-swap run sets, like collectFilesForComponent, in registration order.
+// Components the run doesn't set are left out.
+func registeredFiles(extra map[string]string) ([]string, [][]string, error) {
+	var names []string
+	var files [][]string
+	for _, name := range analyzer.Components() {
+		if extra[name] == "" {
+			continue
+		}
+		_, component, _ := analyzer.LookupComponent(name)
+		found, err := collectFilesForComponent(extra[name], component.Flag)
+		if err != nil {
+			return nil, nil, err
+		}
+		names = append(names, name)
+		files = append(files, found)
+	}
+	return names, files, nil
+}
@@ -0,0 +1,139 @@
+package workflow
+
+import (
+	"fmt"
+	"img-cli/pkg/analyzer"
+	"img-cli/pkg/config"
+	"img-cli/pkg/imaging"
+	"img-cli/pkg/logger"
+	"img-cli/pkg/output"
+	"math"
+)
+
+// colorSpreadScale is the mean pairwise color distance that scores 0 for color
+// consistency; variations of one combination normally sit well below it
+const colorSpreadScale = 0.25
+
+// ConsistencyScore rates how stable the variations of one combination are
+type ConsistencyScore struct {
+	Combination string   `json:"combination"`
+	Images      []string `json:"images"`
+	Identity    float64  `json:"identity"` // Mean pairwise same-person score, -1 if not measured
+	Color       float64  `json:"color"`    // 1 - scaled mean pairwise outfit color distance
+	Unstable    bool     `json:"unstable"`
+}
+
+// scoreVariations computes identity and outfit-color consistency across the
+// variations of one combination and records the result for the run summary
+func (o *Orchestrator) scoreVariations(combination string, outputs []string, verify VerifyOptions) {
+	if !verify.Consistency || len(outputs) < 2 {
+		return
+	}
+	thresholds := config.DefaultVerifyConfig()
+
+	score := ConsistencyScore{
+		Combination: combination,
+		Images:      outputs,
+		Identity:    -1,
+		Color:       colorConsistency(outputs),
+	}
+
+	comparison, err := analyzer.NewIdentityComparer(o.client).Compare(outputs)
+	if err != nil {
+		logger.Warn("Identity consistency check failed", "combination", combination, "error", err)
+	} else if len(comparison.Pairs) > 0 {
+		total := 0.0
+		for _, pair := range comparison.Pairs {
+			total += math.Max(0, math.Min(1, pair.Score))
+		}
+		score.Identity = total / float64(len(comparison.Pairs))
+	}
+
+	score.Unstable = score.Color < thresholds.MinColorConsistency ||
+		(score.Identity >= 0 && score.Identity < thresholds.MinIdentityConsistency)
+
+	identity := "n/a"
+	if score.Identity >= 0 {
+		identity = fmt.Sprintf("%.2f", score.Identity)
+	}
+	if score.Unstable {
+		output.Progress.Printf("   ⚠️  Unstable variations: identity %s, outfit color %.2f\n", identity, score.Color)
+	} else {
+		output.Progress.Printf("   Consistency: identity %s, outfit color %.2f\n", identity, score.Color)
+	}
+
+	o.consistencyMu.Lock()
+	o.consistency = append(o.consistency, score)
+	o.consistencyMu.Unlock()
+}
+
+// ExtraCalls is how many identity-scoring calls n outputs make for the cost
+// estimate: one per generated image (best-of candidates included) when
+// identity validation is on, and one per combination with two or more
+// variations when consistency is scored
+func (v VerifyOptions) ExtraCalls(n, combinations int) int {
+	calls := 0
+	if v.validatesIdentity() {
+		calls += n + v.ExtraImages(n)
+	}
+	if v.Consistency {
+		calls += combinations
+	}
+	return calls
+}
+
+// ConsistencyScores returns the consistency of every combination scored so far
+func (o *Orchestrator) ConsistencyScores() []ConsistencyScore {
+	o.consistencyMu.Lock()
+	defer o.consistencyMu.Unlock()
+	return append([]ConsistencyScore(nil), o.consistency...)
+}
+
+// colorConsistency compares the outfit area colors of every pair of images
+func colorConsistency(paths []string) float64 {
+	var histograms []*imaging.Histogram
+	for _, path := range paths {
+		img, err := imaging.Load(path)
+		if err != nil {
+			logger.Warn("Skipping image in color consistency", "image", path, "error", err)
+			continue
+		}
+		histograms = append(histograms, imaging.NewRegionHistogram(img, imaging.SubjectRegion(img.Bounds())))
+	}
+	if len(histograms) < 2 {
+		return 1
+	}
+
+	total, pairs := 0.0, 0
+	for i := range histograms {
+		for j := i + 1; j < len(histograms); j++ {
+			total += imaging.ColorDistance(histograms[i], histograms[j])
+			pairs++
+		}
+	}
+	return 1 - math.Min(1, total/float64(pairs)/colorSpreadScale)
+}
+
+// AverageConsistency returns the mean identity (over measured sets) and color
+// scores of a run, and how many sets were unstable
+func AverageConsistency(scores []ConsistencyScore) (identity, color float64, unstable int) {
+	identity = -1
+	identitySum, identityCount := 0.0, 0
+	for _, s := range scores {
+		color += s.Color
+		if s.Identity >= 0 {
+			identitySum += s.Identity
+			identityCount++
+		}
+		if s.Unstable {
+			unstable++
+		}
+	}
+	if len(scores) > 0 {
+		color /= float64(len(scores))
+	}
+	if identityCount > 0 {
+		identity = identitySum / float64(identityCount)
+	}
+	return identity, color, unstable
+}
@@ -0,0 +1,20 @@
+package workflow
+
+import "testing"
+
+func TestVerifyExtraCalls(t *testing.T) {
+	for _, tc := range []struct {
+		verify VerifyOptions
+		want   int
+	}{
+		{VerifyOptions{}, 0},
+		{VerifyOptions{Identity: true}, 12},
+		{VerifyOptions{MinIdentityScore: 0.7, BestOf: 3}, 36},
+		{VerifyOptions{Consistency: true}, 3},
+		{VerifyOptions{Identity: true, Consistency: true}, 15},
+	} {
+		if got := tc.verify.ExtraCalls(12, 3); got != tc.want {
+			t.Errorf("%+v: ExtraCalls(12, 3) = %d, want %d", tc.verify, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package workflow
+
+import (
+	"fmt"
+	"img-cli/pkg/imaging"
+	"img-cli/pkg/logger"
+	"path/filepath"
+	"strings"
+)
+
+// ContactSheetFileName is the name of the contact sheet written into a run's output folder
+const ContactSheetFileName = "contact_sheet.png"
+
+// contactSheetCell is the longer side, in pixels, of each image on the sheet
+const contactSheetCell = 256
+
+// WriteContactSheet composes the images of a run into one labeled grid PNG,
+// with a row per subject and a column per combination of the other
+// components. Variations of a combination get a column each. Images that
+// failed a check are outlined in red.
+func WriteContactSheet(path string, result *WorkflowResult) error {
+	sheet := imaging.Sheet{Title: fmt.Sprintf("%s %s", result.Workflow, result.StartTime.Format("2006-01-02 15:04"))}
+	rowIndex := make(map[string]int)
+	columnIndex := make(map[string]int)
+	images := 0
+
+	for _, step := range result.Steps {
+		if step.Type != "generation" || step.OutputPath == "" {
+			continue
+		}
+		subject, column := contactSheetLabels(step.OutputPath)
+
+		r, ok := rowIndex[subject]
+		if !ok {
+			r = len(sheet.Rows)
+			rowIndex[subject] = r
+			sheet.Rows = append(sheet.Rows, imaging.SheetRow{Label: subject})
+		}
+		// Variations of a combination fill the next column of the same label
+		label := column
+		for n := 2; ; n++ {
+			c, ok := columnIndex[label]
+			if !ok {
+				c = len(sheet.Columns)
+				columnIndex[label] = c
+				sheet.Columns = append(sheet.Columns, label)
+			}
+			row := &sheet.Rows[r]
+			for len(row.Cells) <= c {
+				row.Cells = append(row.Cells, imaging.SheetCell{})
+			}
+			if row.Cells[c].Path == "" {
+				row.Cells[c] = imaging.SheetCell{Path: step.OutputPath, Flagged: len(step.Flags) > 0}
+				break
+			}
+			label = fmt.Sprintf("%s\nvariation %d", column, n)
+		}
+		images++
+	}
+	if images == 0 {
+		return fmt.Errorf("no generated images to put on a contact sheet")
+	}
+
+	if err := imaging.Save(path, imaging.RenderSheet(sheet, contactSheetCell)); err != nil {
+		return fmt.Errorf("failed to write contact sheet: %w", err)
+	}
+	logger.Info("Contact sheet written", "path", path, "images", images, "rows", len(sheet.Rows), "columns", len(sheet.Columns))
+	return nil
+}
+
+// contactSheetLabels names the row (subject) and column (every other
+// component, one per line) of an image from its sidecar
+func contactSheetLabels(imagePath string) (string, string) {
+	sidecar, err := ReadSidecar(imagePath)
+	if err != nil {
+		return "(no metadata)", filepath.Base(imagePath)
+	}
+	config, err := sidecar.Recipe()
+	if err != nil {
+		return "(no metadata)", filepath.Base(imagePath)
+	}
+
+	subject := strings.TrimSuffix(filepath.Base(config.SubjectPath), filepath.Ext(config.SubjectPath))
+	inputs := RecipeInputs(config)
+	var parts []string
+	for _, name := range RecipeComponents() {
+		value, ok := inputs[name]
+		if !ok || name == "subject" {
+			continue
+		}
+		if isFilePath(value) {
+			value = strings.TrimSuffix(filepath.Base(value), filepath.Ext(value))
+		}
+		parts = append(parts, value)
+	}
+	if config.Ambient != "" {
+		parts = append(parts, ambientFileTag(config.Ambient))
+	}
+	if len(parts) == 0 {
+		return subject, "(subject only)"
+	}
+	return subject, strings.Join(parts, "\n")
+}
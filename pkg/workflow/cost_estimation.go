@@ -2,8 +2,7 @@ package workflow
 
 import (
 	"fmt"
-	"img-cli/pkg/config"
-	"img-cli/pkg/prompt"
+	"img-cli/pkg/cost"
 )
 
 // calculateOutfitSwapImageCount calculates how many images will be generated
@@ -19,43 +18,21 @@ func calculateOutfitSwapImageCount(numSubjects, numOutfits, numStyles, numVariat
 	return numSubjects * numOutfits * numStyles * numVariations
 }
 
-// checkWorkflowCost checks if a workflow will exceed cost thresholds and prompts for confirmation
-func checkWorkflowCost(workflowName string, imageCount int, skipConfirm bool) error {
-	costConfig := config.DefaultCostConfig()
-	totalCost := costConfig.CalculateTotalCost(imageCount)
-
-	// Show cost breakdown
-	fmt.Printf("\n📊 Workflow Cost Analysis for %s:\n", workflowName)
-	fmt.Printf("   Images to generate: %d\n", imageCount)
-	fmt.Printf("   Cost breakdown: %s\n", costConfig.GetCostBreakdown(imageCount))
-
-	// Check if confirmation is needed (unless skipped)
-	if !skipConfirm && costConfig.RequiresConfirmation(imageCount) {
-		message := fmt.Sprintf("This workflow will generate %d images", imageCount)
-		confirmed, err := prompt.ConfirmExpensiveOperation(
-			message,
-			costConfig.FormatCost(totalCost),
-		)
-		if err != nil {
-			return fmt.Errorf("failed to get user confirmation: %w", err)
-		}
-		if !confirmed {
-			return fmt.Errorf("workflow cancelled by user")
-		}
-		fmt.Println("✅ Proceeding with workflow...")
-	} else if imageCount > 10 {
-		// For moderately large batches, still show the cost
-		prompt.ShowCostEstimate(
-			fmt.Sprintf("This workflow will generate %d images", imageCount),
-			costConfig.FormatCost(totalCost),
-		)
-	}
+// checkWorkflowCost shows what a workflow's images and extra calls will cost
+// and runs the shared cost check: budget caps and, above the threshold,
+// confirmation unless the user already confirmed the cost (as --pick does).
+// A dry run only shows the cost.
+func checkWorkflowCost(workflowName string, imageCount, calls int, skipConfirm, confirmed, dryRun bool) error {
+	cost.PrintEstimate(fmt.Sprintf("Workflow Cost Analysis for %s", workflowName), imageCount)
+	cost.PrintCalls(calls)
+	return cost.Check(imageCount, cost.CheckOptions{Calls: calls, SkipConfirm: skipConfirm, Confirmed: confirmed, DryRun: dryRun})
+}
 
-	// Check hard limit
-	if totalCost > costConfig.MaximumCost {
-		return fmt.Errorf("workflow cost ($%.2f) exceeds maximum allowed ($%.2f)",
-			totalCost, costConfig.MaximumCost)
+// ExtraCalls is how many cheap text and vision calls a run of n outputs makes
+// on top of its images, for the cost estimate
+func ExtraCalls(n int, refineAltText bool) int {
+	if refineAltText {
+		return n
 	}
-
-	return nil
-}
\ No newline at end of file
+	return 0
+}
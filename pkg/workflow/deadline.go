@@ -0,0 +1,174 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/logger"
+	"img-cli/pkg/output"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// RunStateFile is written to the output directory when a batch run stops early
+const RunStateFile = "run_state.json"
+
+// deadline enforces --max-duration on batch runs. It refuses to start a new unit
+// of work when the average duration of the finished ones says it would not
+// finish in time. A nil deadline never expires. Parallel workers may share one
+// and check it right before each unit, so queued work is held to it too.
+type deadline struct {
+	end      time.Time
+	mu       sync.Mutex
+	finished int
+	took     time.Duration // Total duration of the finished units
+}
+
+func newDeadline(maxDuration time.Duration) *deadline {
+	if maxDuration <= 0 {
+		return nil
+	}
+	return &deadline{end: time.Now().Add(maxDuration)}
+}
+
+// allowsNext reports whether another unit of work can start
+func (d *deadline) allowsNext() bool {
+	if d == nil {
+		return true
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	expected := time.Duration(0)
+	if d.finished > 0 {
+		expected = d.took / time.Duration(d.finished)
+	}
+	return !time.Now().Add(expected).After(d.end)
+}
+
+// finish records a unit of work started at started as finished
+func (d *deadline) finish(started time.Time) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.finished++
+	d.took += time.Since(started)
+}
+
+// Combination is one set of inputs in a batch run. Empty fields are unused components.
+type Combination struct {
+	Subject     string            `json:"subject"`
+	Outfit      string            `json:"outfit,omitempty"`
+	OverOutfit  string            `json:"over_outfit,omitempty"`
+	Style       string            `json:"style,omitempty"`
+	HairStyle   string            `json:"hair_style,omitempty"`
+	HairColor   string            `json:"hair_color,omitempty"`
+	Makeup      string            `json:"makeup,omitempty"`
+	Expression  string            `json:"expression,omitempty"`
+	Accessories string            `json:"accessories,omitempty"`
+	Pose        string            `json:"pose,omitempty"`
+	Background  string            `json:"background,omitempty"`
+	Extra       map[string]string `json:"extra,omitempty"`      // Registered components (see analyzer.Register) by analyzer type
+	Ambient     string            `json:"ambient,omitempty"`    // Lighting/ambient of a sweep
+	Variations  int               `json:"variations,omitempty"` // Overrides the run's variations when set
+	Generated   int               `json:"generated,omitempty"`  // Variations an earlier run already generated (when resuming)
+}
+
+// variations is the number of images to generate for the combination
+func (c Combination) variations(runDefault int) int {
+	if c.Variations > 0 {
+		return c.Variations
+	}
+	return runDefault
+}
+
+// RunState records where a batch run stopped so it can be continued later
+type RunState struct {
+	Workflow     string          `json:"workflow"`
+	OutfitSource string          `json:"outfit_source,omitempty"`
+	Options      WorkflowOptions `json:"options"`
+	StoppedAt    time.Time       `json:"stopped_at"`
+	Reason       string          `json:"reason"`
+	Generated    []string        `json:"generated"`
+	Remaining    []Combination   `json:"remaining"`
+}
+
+// writeRunState saves the run state into the output directory
+func writeRunState(outputDir string, state *RunState) (string, error) {
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(outputDir, RunStateFile)
+	return path, os.WriteFile(path, data, 0644)
+}
+
+// stopForDeadline records the unfinished combinations of a run stopped by
+// MaxDuration or Stop and reports what remains
+func (o *Orchestrator) stopForDeadline(result *WorkflowResult, outfitSource, outputDir string, remaining []Combination, options WorkflowOptions) {
+	generated := []string{}
+	for _, step := range result.Steps {
+		if step.Type == "generation" && step.OutputPath != "" {
+			generated = append(generated, step.OutputPath)
+		}
+	}
+
+	state := &RunState{
+		Workflow:     result.Workflow,
+		OutfitSource: outfitSource,
+		Options:      options,
+		StoppedAt:    time.Now(),
+		Reason:       fmt.Sprintf("max duration %s reached", options.MaxDuration),
+		Generated:    generated,
+		Remaining:    remaining,
+	}
+
+	if options.stopRequested() {
+		state.Reason = "stop requested"
+		output.Progress.Printf("\n⏹️  Stop requested, not starting new combinations\n")
+	} else {
+		output.Progress.Printf("\n⏱️  Max duration (%s) reached, not starting new combinations\n", options.MaxDuration)
+	}
+	output.Progress.Printf("   Completed: %d images\n", len(generated))
+	output.Progress.Printf("   Remaining: %d combinations (× %d variations)\n", len(remaining), maxInt(1, options.Variations))
+
+	path, err := writeRunState(outputDir, state)
+	if err != nil {
+		logger.Warn("Failed to write run state", "error", err)
+		return
+	}
+	output.Progress.Printf("   State saved to: %s\n", path)
+
+	result.Stopped = true
+	result.Remaining = len(remaining)
+}
+
+// stopRequested reports whether the caller asked the run to stop early
+func (options WorkflowOptions) stopRequested() bool {
+	return options.Stop != nil && options.Stop()
+}
+
+// remainingOutfitPairs lists the subject/outfit pairs not yet started in the
+// outfit-swap workflow, starting at the given indices
+func remainingOutfitPairs(subjects, outfits []string, subjectIndex, outfitIndex int, style string) []Combination {
+	var remaining []Combination
+	for si := subjectIndex; si < len(subjects); si++ {
+		start := 0
+		if si == subjectIndex {
+			start = outfitIndex
+		}
+		for oi := start; oi < len(outfits); oi++ {
+			remaining = append(remaining, Combination{
+				Subject: subjects[si],
+				Outfit:  outfits[oi],
+				Style:   style,
+			})
+		}
+	}
+	return remaining
+}
@@ -0,0 +1,61 @@
+package workflow
+
+import (
+	"img-cli/pkg/config"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Parallel workers hold generations they picked up late to the deadline,
+// using how long the finished ones took rather than how many were queued
+func TestDeadlineEstimatesFromFinishedWork(t *testing.T) {
+	d := newDeadline(time.Hour)
+	for i := 0; i < 8; i++ {
+		if !d.allowsNext() {
+			t.Fatalf("generation %d refused before any finished", i+1)
+		}
+	}
+	d.finish(time.Now().Add(-10 * time.Minute))
+	if !d.allowsNext() {
+		t.Error("refused with 50 minutes left and 10 minute generations")
+	}
+	d.finish(time.Now().Add(-2 * time.Hour))
+	if d.allowsNext() {
+		t.Error("allowed a generation expected to take over an hour")
+	}
+
+	var none *deadline
+	none.finish(time.Now())
+	if !none.allowsNext() {
+		t.Error("nil deadline expired")
+	}
+}
+
+// A modular run asked to stop ends before its next variation and keeps the
+// images it already generated
+func TestModularStopsBetweenVariations(t *testing.T) {
+	var requests atomic.Int32
+	t.Setenv("IMG_CLI_PROVIDER", config.ProviderSD)
+	t.Setenv("IMG_CLI_SD_URL", fakeSD(t, &requests))
+
+	outputDir := filepath.Join(t.TempDir(), "stopped")
+	results, err := NewOrchestrator("test-key").RunModularWorkflow(ModularConfig{
+		SubjectPath: testSubject(t),
+		MakeupRef:   "smoky eye",
+		Variations:  3,
+		OutputDir:   outputDir,
+		Stop:        func() bool { return requests.Load() >= 1 },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || requests.Load() != 1 {
+		t.Fatalf("got %d image(s) from %d request(s), want 1 before the stop", len(results), requests.Load())
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, ManifestFile)); err != nil {
+		t.Errorf("no manifest for the stopped run: %v", err)
+	}
+}
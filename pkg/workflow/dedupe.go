@@ -0,0 +1,43 @@
+package workflow
+
+import (
+	"img-cli/pkg/logger"
+	"img-cli/pkg/output"
+	"path/filepath"
+)
+
+// dedupeByContent drops files whose content is identical to an earlier file
+// in the list, so copies of a reference don't multiply the combination
+// matrix, and reports how many it skipped
+func dedupeByContent(files []string, componentType string) []string {
+	kept, skipped := uniqueByContent(files)
+	if skipped > 0 {
+		logger.Info("Skipped duplicate references",
+			"component", componentType,
+			"duplicates", skipped,
+			"kept", len(kept))
+		output.Progress.Printf("   Skipped %d duplicate %s reference(s) with identical content\n", skipped, componentType)
+	}
+	return kept
+}
+
+// uniqueByContent returns files without those identical to an earlier file,
+// and how many it dropped. Text descriptions and unreadable files are kept.
+func uniqueByContent(files []string) ([]string, int) {
+	seen := make(map[string]string) // Content hash -> first file with it
+	kept := make([]string, 0, len(files))
+	for _, file := range files {
+		hash := fileSHA256(file)
+		if hash == "" {
+			kept = append(kept, file)
+			continue
+		}
+		if first, ok := seen[hash]; ok {
+			logger.Debug("Skipping duplicate reference", "file", filepath.Base(file), "same_as", filepath.Base(first))
+			continue
+		}
+		seen[hash] = file
+		kept = append(kept, file)
+	}
+	return kept, len(files) - len(kept)
+}
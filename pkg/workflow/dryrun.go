@@ -0,0 +1,91 @@
+package workflow
+
+import (
+	"fmt"
+	"img-cli/pkg/gemini"
+	"img-cli/pkg/logger"
+	"img-cli/pkg/output"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// WithDryRun plans a run without generating anything. Analyses still run
+// (cached ones cost nothing) and every generation prompt is built, but
+// instead of being sent each prompt is written to a .prompt.txt file in the
+// output folder. Cost confirmation, budgets, manifests and run state are
+// skipped since nothing is spent or produced.
+func WithDryRun(enabled bool) Option {
+	return func(o *Orchestrator) {
+		o.dryRun = enabled
+		o.client.SetDryRun(enabled)
+	}
+}
+
+// DryRun reports whether the orchestrator only plans generations
+func (o *Orchestrator) DryRun() bool {
+	return o.dryRun
+}
+
+// DryRunPrompts returns the prompt files a dry run wrote so far
+func (o *Orchestrator) DryRunPrompts() []string {
+	o.dryRunMu.Lock()
+	defer o.dryRunMu.Unlock()
+	return append([]string(nil), o.dryRunPrompts...)
+}
+
+// Planned handles a generation the client did not send because of a dry
+// run: it writes the prompt into outputDir and reports true. It reports
+// false for every other error, including nil.
+func (o *Orchestrator) Planned(err error, outputDir, label string) bool {
+	dryRun, ok := gemini.AsDryRun(err)
+	if !ok {
+		return false
+	}
+
+	o.dryRunMu.Lock()
+	defer o.dryRunMu.Unlock()
+
+	name := fmt.Sprintf("%03d_%s.prompt.txt", len(o.dryRunPrompts)+1, promptFileName(label))
+	path := filepath.Join(outputDir, name)
+
+	var header strings.Builder
+	fmt.Fprintf(&header, "# %s\n", label)
+	fmt.Fprintf(&header, "# Reference images: %d\n", dryRun.Images)
+	if c := dryRun.Config; c != nil {
+		fmt.Fprintf(&header, "# Temperature %.2f, top-k %d, top-p %.2f\n", c.Temperature, c.TopK, c.TopP)
+	}
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		logger.Warn("Failed to write dry-run prompt", "dir", outputDir, "error", err)
+	} else if err := os.WriteFile(path, []byte(header.String()+"\n"+dryRun.Prompt+"\n"), 0644); err != nil {
+		logger.Warn("Failed to write dry-run prompt", "file", name, "error", err)
+	}
+	output.Progress.Printf("      📝 Planned %s (%d reference image(s), not sent)\n", name, dryRun.Images)
+	o.dryRunPrompts = append(o.dryRunPrompts, path)
+	return true
+}
+
+// promptFileName turns a combination label into a file name part
+func promptFileName(label string) string {
+	var b strings.Builder
+	underscore := false
+	for _, r := range strings.ToLower(label) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' {
+			b.WriteRune(r)
+			underscore = false
+		} else if !underscore && b.Len() > 0 {
+			b.WriteByte('_')
+			underscore = true
+		}
+	}
+	name := strings.TrimSuffix(b.String(), "_")
+	if runes := []rune(name); len(runes) > 80 {
+		name = strings.TrimSuffix(string(runes[:80]), "_")
+	}
+	if name == "" {
+		name = "generation"
+	}
+	return name
+}
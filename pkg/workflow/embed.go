@@ -0,0 +1,78 @@
+package workflow
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"img-cli/pkg/logger"
+	"img-cli/pkg/metadata"
+	"img-cli/pkg/watermark"
+	"path/filepath"
+	"sort"
+)
+
+// watermarkOutput marks an image as AI-generated when watermarking is on. It
+// runs after every step that changes pixels and reports whether the image was
+// marked; failures are logged but never fail the generation.
+func watermarkOutput(outputPath string) bool {
+	if !watermark.Enabled() {
+		return false
+	}
+	if err := watermark.EmbedFile(outputPath, watermark.Text()); err != nil {
+		logger.Warn("Failed to watermark image", "image", filepath.Base(outputPath), "error", err)
+		return false
+	}
+	logger.Debug("Embedded watermark", "image", filepath.Base(outputPath))
+	return true
+}
+
+// embedMetadata writes an image's provenance record into the image file, so
+// it travels with copies that leave the output tree. It runs before signing,
+// which covers it. Failures are logged but never fail the generation.
+func (o *Orchestrator) embedMetadata(outputPath string, sidecar *Sidecar) {
+	info := metadata.Info{
+		Workflow: sidecar.Workflow,
+		Provider: sidecar.Provider,
+		Model:    sidecar.Model,
+		Created:  sidecar.Created,
+		Inputs:   []metadata.Input{metadataInput("subject", sidecar.Provenance.Subject)},
+	}
+	if sidecar.Prompt != "" {
+		info.PromptSHA256 = PromptSHA256(sidecar.Prompt)
+	}
+	names := make([]string, 0, len(sidecar.Provenance.Components))
+	for name := range sidecar.Provenance.Components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		info.Inputs = append(info.Inputs, metadataInput(name, sidecar.Provenance.Components[name]))
+	}
+
+	if err := metadata.Embed(outputPath, info); err != nil {
+		logger.Warn("Failed to embed metadata", "image", filepath.Base(outputPath), "error", err)
+		return
+	}
+	logger.Debug("Embedded metadata", "image", filepath.Base(outputPath))
+}
+
+// metadataInput describes one provenance entry by file name and hash; text
+// components are recorded by the hash of their text
+func metadataInput(role string, source ComponentSource) metadata.Input {
+	input := metadata.Input{Role: role, SHA256: source.SHA256}
+	switch {
+	case source.File != "":
+		input.File = filepath.Base(source.File)
+	case source.Text != "":
+		input.SHA256 = PromptSHA256(source.Text)
+	case source.Description != "":
+		input.SHA256 = PromptSHA256(source.Description)
+	}
+	return input
+}
+
+// PromptSHA256 returns the hex SHA-256 of a prompt or text component, as
+// embedded in generated images
+func PromptSHA256(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
@@ -107,12 +107,23 @@ func (o *Orchestrator) extractOuterLayerOnly(data json.RawMessage) string {
 	return ""
 }
 
-// extractStyleDescription extracts visual style description from analysis
-func (o *Orchestrator) extractStyleDescription(data json.RawMessage) string {
+// styleExclusions are the parts of a style analysis supplied by other components
+type styleExclusions struct {
+	Pose       bool     // Pose and body position come from --pose
+	Background bool     // Background comes from --background
+	Fields     []string // Fields supplied by registered components (see registeredStyleExclusions)
+}
+
+// extractStyleDescription extracts visual style description from analysis,
+// leaving out the parts other components supply
+func (o *Orchestrator) extractStyleDescription(data json.RawMessage, exclude styleExclusions) string {
 	var result map[string]interface{}
 	if err := json.Unmarshal(data, &result); err != nil {
 		return "Natural photographic style"
 	}
+	for _, field := range exclude.Fields {
+		delete(result, field)
+	}
 
 	var parts []string
 
@@ -125,12 +136,14 @@ func (o *Orchestrator) extractStyleDescription(data json.RawMessage) string {
 		parts = append(parts, fmt.Sprintf("CAMERA ANGLE (MUST MATCH EXACTLY): %s", cameraAngle))
 	}
 
-	if pose, ok := result["pose"].(string); ok && pose != "" {
-		parts = append(parts, fmt.Sprintf("POSE: %s", pose))
-	}
+	if !exclude.Pose {
+		if pose, ok := result["pose"].(string); ok && pose != "" {
+			parts = append(parts, fmt.Sprintf("POSE: %s", pose))
+		}
 
-	if bodyPosition, ok := result["body_position"].(string); ok && bodyPosition != "" {
-		parts = append(parts, fmt.Sprintf("BODY POSITION: %s", bodyPosition))
+		if bodyPosition, ok := result["body_position"].(string); ok && bodyPosition != "" {
+			parts = append(parts, fmt.Sprintf("BODY POSITION: %s", bodyPosition))
+		}
 	}
 
 	if composition, ok := result["composition"].(string); ok && composition != "" {
@@ -141,7 +154,7 @@ func (o *Orchestrator) extractStyleDescription(data json.RawMessage) string {
 		parts = append(parts, fmt.Sprintf("Lighting: %s", lighting))
 	}
 
-	if background, ok := result["background"].(string); ok && background != "" {
+	if background, ok := result["background"].(string); ok && background != "" && !exclude.Background {
 		parts = append(parts, fmt.Sprintf("Background: %s", background))
 	}
 
@@ -382,63 +395,63 @@ func (o *Orchestrator) extractExpressionDescription(data json.RawMessage, exclud
 	return "Natural expression"
 }
 
-// extractAccessoriesDescription extracts accessories description from analysis
-func (o *Orchestrator) extractAccessoriesDescription(data json.RawMessage) string {
+// extractAccessoriesDescription extracts accessories description from analysis.
+// maxItems keeps only the most important accessories (0 = no limit); the
+// accessories left out are returned alongside the description.
+func (o *Orchestrator) extractAccessoriesDescription(data json.RawMessage, maxItems int) (string, []string) {
 	var result map[string]interface{}
 	if err := json.Unmarshal(data, &result); err != nil {
-		return "No accessories"
+		return "No accessories", nil
 	}
 
-	var parts []string
+	var items []accessoryItem
 
 	// Extract jewelry
 	if jewelry, ok := result["jewelry"].(map[string]interface{}); ok {
-		var jewelryParts []string
-		if earrings, ok := jewelry["earrings"].(string); ok && earrings != "" {
-			jewelryParts = append(jewelryParts, fmt.Sprintf("Earrings: %s", earrings))
-		}
-		if necklaces, ok := jewelry["necklaces"].(string); ok && necklaces != "" {
-			jewelryParts = append(jewelryParts, fmt.Sprintf("Necklaces: %s", necklaces))
-		}
-		if bracelets, ok := jewelry["bracelets"].(string); ok && bracelets != "" {
-			jewelryParts = append(jewelryParts, fmt.Sprintf("Bracelets: %s", bracelets))
-		}
-		if rings, ok := jewelry["rings"].(string); ok && rings != "" {
-			jewelryParts = append(jewelryParts, fmt.Sprintf("Rings: %s", rings))
-		}
-		if len(jewelryParts) > 0 {
-			parts = append(parts, "Jewelry: "+strings.Join(jewelryParts, ", "))
+		for _, field := range []string{"earrings", "necklaces", "bracelets", "rings"} {
+			if value, ok := jewelry[field].(string); ok && !placeholderAccessory(value) {
+				items = append(items, accessoryItem{Category: field, Text: value})
+			}
 		}
 	}
 
 	// Extract other accessories
-	if bags, ok := result["bags"].(string); ok && bags != "" {
-		parts = append(parts, fmt.Sprintf("Bags: %s", bags))
-	}
-
-	if belts, ok := result["belts"].(string); ok && belts != "" {
-		parts = append(parts, fmt.Sprintf("Belts: %s", belts))
+	for _, field := range []string{"bags", "belts", "scarves", "hats", "watches"} {
+		if value, ok := result[field].(string); ok && !placeholderAccessory(value) {
+			items = append(items, accessoryItem{Category: field, Text: value})
+		}
 	}
 
-	if scarves, ok := result["scarves"].(string); ok && scarves != "" {
-		parts = append(parts, fmt.Sprintf("Scarves: %s", scarves))
-	}
+	items, dropped := limitAccessories(items, maxItems)
+	reportDroppedAccessories(dropped, maxItems)
 
-	if hats, ok := result["hats"].(string); ok && hats != "" {
-		parts = append(parts, fmt.Sprintf("Hats: %s", hats))
+	var parts []string
+	var jewelryParts []string
+	for _, item := range items {
+		label := strings.ToUpper(item.Category[:1]) + item.Category[1:]
+		switch item.Category {
+		case "earrings", "necklaces", "bracelets", "rings":
+			jewelryParts = append(jewelryParts, fmt.Sprintf("%s: %s", label, item.Text))
+		default:
+			if len(jewelryParts) > 0 {
+				parts = append(parts, "Jewelry: "+strings.Join(jewelryParts, ", "))
+				jewelryParts = nil
+			}
+			parts = append(parts, fmt.Sprintf("%s: %s", label, item.Text))
+		}
 	}
-
-	if watches, ok := result["watches"].(string); ok && watches != "" {
-		parts = append(parts, fmt.Sprintf("Watches: %s", watches))
+	if len(jewelryParts) > 0 {
+		parts = append(parts, "Jewelry: "+strings.Join(jewelryParts, ", "))
 	}
 
-	if overall, ok := result["overall"].(string); ok && overall != "" {
+	// The overall summary describes the full set, so leave it out when items were dropped
+	if overall, ok := result["overall"].(string); ok && overall != "" && len(dropped) == 0 {
 		parts = append(parts, overall)
 	}
 
 	if len(parts) > 0 {
-		return strings.Join(parts, ". ")
+		return strings.Join(parts, ". "), accessoryTexts(dropped)
 	}
 
-	return "No accessories"
+	return "No accessories", accessoryTexts(dropped)
 }
\ No newline at end of file
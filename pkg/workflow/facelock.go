@@ -0,0 +1,78 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"img-cli/pkg/analyzer"
+	"img-cli/pkg/imaging"
+	"img-cli/pkg/logger"
+	"img-cli/pkg/output"
+	"img-cli/pkg/postprocess"
+	"path/filepath"
+)
+
+// lockFace pastes the subject's face from its source photo back over the face
+// of a generated image when --face-lock is set. The subject's face is found
+// once and cached; the output's costs one analysis per image. Failures only
+// warn since the generated image is still there.
+func (o *Orchestrator) lockFace(subjectPath, outputPath string, post PostOptions) {
+	if !post.FaceLock || subjectPath == "" {
+		return
+	}
+	o.setAnalyzer("face", func() analyzer.Analyzer { return analyzer.NewFaceAnalyzer(o.client) })
+
+	sourceFace, err := o.findFace(subjectPath, true)
+	if err != nil {
+		logger.Warn("Face lock skipped: no face in the subject photo", "subject", filepath.Base(subjectPath), "error", err)
+		return
+	}
+	outputFace, err := o.findFace(outputPath, false)
+	if err != nil {
+		logger.Warn("Face lock skipped: no face in the output", "image", filepath.Base(outputPath), "error", err)
+		return
+	}
+	if err := postprocess.FaceLock(subjectPath, outputPath, sourceFace, outputFace); err != nil {
+		logger.Warn("Face lock failed", "image", filepath.Base(outputPath), "error", err)
+		return
+	}
+	output.Progress.Printf("      🔒 Face locked: %s\n", filepath.Base(outputPath))
+}
+
+// findFace returns the largest face in an image in pixels. Subject photos go
+// through the analysis cache; generated images are new every time.
+func (o *Orchestrator) findFace(imagePath string, cached bool) (image.Rectangle, error) {
+	var data json.RawMessage
+	var err error
+	if cached {
+		data, err = o.AnalyzeImage("face", imagePath)
+	} else {
+		data, err = o.analyzeThrough("face", imagePath, func(_, path string) (json.RawMessage, error) {
+			faces, _ := o.analyzerFor("face")
+			return faces.Analyze(path)
+		})
+	}
+	if err != nil {
+		return image.Rectangle{}, err
+	}
+	var found analyzer.Region
+	if err := json.Unmarshal(data, &found); err != nil {
+		return image.Rectangle{}, fmt.Errorf("failed to parse the face region: %w", err)
+	}
+	if !found.Found || len(found.Boxes) == 0 {
+		return image.Rectangle{}, fmt.Errorf("no face found")
+	}
+
+	w, h, err := imaging.Size(imagePath)
+	if err != nil {
+		return image.Rectangle{}, err
+	}
+	var largest image.Rectangle
+	for _, box := range found.Boxes {
+		r := regionRect(box, w, h, 0)
+		if r.Dx()*r.Dy() > largest.Dx()*largest.Dy() {
+			largest = r
+		}
+	}
+	return largest, nil
+}
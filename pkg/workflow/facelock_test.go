@@ -0,0 +1,29 @@
+package workflow
+
+import (
+	"img-cli/pkg/analyzer"
+	"sync"
+	"testing"
+)
+
+// Workers of a --face-lock --parallel run add the face analyzer while others
+// look analyzers up; run with -race
+func TestAnalyzersConcurrentAccess(t *testing.T) {
+	o := NewOrchestrator("test-key")
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			o.setAnalyzer("face", func() analyzer.Analyzer { return analyzer.NewFaceAnalyzer(o.client) })
+			o.addAnalyzer("outfit")
+			if _, ok := o.analyzerFor("face"); !ok {
+				t.Error("face analyzer missing after setAnalyzer")
+			}
+			if o.cacheFor("outfit") == nil {
+				t.Error("outfit cache missing after addAnalyzer")
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,30 @@
+package workflow
+
+import (
+	"img-cli/pkg/errors"
+)
+
+// recordFailure keeps a failed generation for the run summary and report.
+// The recipe is the recipeLabel of its combination, or empty outside the
+// outfit-swap pipelines; the report matches it against its outputs' recipes.
+func (o *Orchestrator) recordFailure(combination, recipe string, err error) {
+	failure := Failure{
+		Combination:  combination,
+		Recipe:       recipe,
+		Error:        err.Error(),
+		Refusal:      errors.ContextString(err, "refusal"),
+		FinishReason: errors.ContextString(err, "finish_reason"),
+		Label:        failureLabel(err),
+	}
+
+	o.failuresMu.Lock()
+	o.failures = append(o.failures, failure)
+	o.failuresMu.Unlock()
+}
+
+// Failures returns the generations that failed so far
+func (o *Orchestrator) Failures() []Failure {
+	o.failuresMu.Lock()
+	defer o.failuresMu.Unlock()
+	return append([]Failure(nil), o.failures...)
+}
@@ -0,0 +1,34 @@
+package workflow
+
+import (
+	"fmt"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/imaging"
+)
+
+// minResolution keeps --resolution from asking for thumbnails
+const minResolution = 256
+
+// ValidateFormat checks --aspect and --resolution before any work is done
+func ValidateFormat(aspect string, resolution int) error {
+	if _, err := imaging.ParseAspect(aspect); err != nil {
+		return errors.ErrInvalidInput("aspect", err.Error())
+	}
+	if resolution != 0 && resolution < minResolution {
+		return errors.ErrInvalidInput("resolution", fmt.Sprintf("must be at least %d pixels on the long side", minResolution))
+	}
+	return nil
+}
+
+// FormatLabel describes the image shape for run summaries, e.g. "16:9, 1920x1080"
+func FormatLabel(aspect string, resolution int) string {
+	a, err := imaging.ParseAspect(aspect)
+	if err != nil {
+		return aspect
+	}
+	if resolution <= 0 {
+		return a.String()
+	}
+	w, h := a.Dimensions(resolution)
+	return fmt.Sprintf("%s, %dx%d", a, w, h)
+}
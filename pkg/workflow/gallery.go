@@ -0,0 +1,186 @@
+package workflow
+
+import (
+	"fmt"
+	"html/template"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/logger"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// GalleryFileName is the gallery page written into an output folder by --report html
+const GalleryFileName = "index.html"
+
+// ReportFormats are the values accepted by --report
+var ReportFormats = []string{"html"}
+
+// ValidateReportFormat checks a --report value; empty means no report
+func ValidateReportFormat(format string) error {
+	if format == "" {
+		return nil
+	}
+	for _, f := range ReportFormats {
+		if format == f {
+			return nil
+		}
+	}
+	return errors.ErrInvalidInput("report", fmt.Sprintf("unknown format %q (expected %s)", format, strings.Join(ReportFormats, ", ")))
+}
+
+type galleryPage struct {
+	Title  string
+	Images []galleryImage
+}
+
+type galleryImage struct {
+	Name       string
+	Href       string // Relative to the gallery, so the folder can be zipped and shared
+	Thumb      template.URL
+	Workflow   string
+	Subject    galleryRef
+	Components []galleryRef
+	Prompt     string
+	Started    string
+	Took       time.Duration
+	Scores     string
+}
+
+type galleryRef struct {
+	Name  string
+	Value string
+	Thumb template.URL // Set when the reference is an image file
+}
+
+// WriteGallery writes GalleryFileName into an output folder: every image in
+// the folder's manifest with a thumbnail linking to the full-size file, the
+// subject and component references that produced it, its prompt and how long
+// it took to generate. It returns the path of the page.
+func WriteGallery(outputDir string) (string, error) {
+	manifest, err := ReadManifest(outputDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	// References repeat across images; embed each one once
+	refThumbs := make(map[string]template.URL)
+	ref := func(name, value string) galleryRef {
+		r := galleryRef{Name: name, Value: value}
+		thumb, ok := refThumbs[value]
+		if !ok {
+			// Text descriptions are not files and get no thumbnail
+			if info, err := os.Stat(value); err == nil && !info.IsDir() {
+				thumb = thumbnailURL(value)
+			}
+			refThumbs[value] = thumb
+		}
+		if thumb != "" {
+			r.Value = filepath.Base(value)
+			r.Thumb = thumb
+		}
+		return r
+	}
+
+	page := galleryPage{Title: filepath.Base(outputDir)}
+	for _, entry := range manifest.Images {
+		imagePath := filepath.Join(outputDir, entry.Image)
+		if _, err := os.Stat(imagePath); err != nil {
+			continue // Moved or deleted since the run
+		}
+		image := galleryImage{
+			Name:     entry.Image,
+			Href:     filepath.ToSlash(entry.Image),
+			Thumb:    thumbnailURL(imagePath),
+			Workflow: entry.Workflow,
+			Subject:  ref("subject", entry.Subject),
+			Prompt:   entry.Prompt,
+			Started:  entry.Started.Format("2006-01-02 15:04:05"),
+			Took:     (time.Duration(entry.DurationMS) * time.Millisecond).Round(100 * time.Millisecond),
+			Scores:   galleryScores(entry),
+		}
+
+		names := make([]string, 0, len(entry.Components))
+		for name := range entry.Components {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			image.Components = append(image.Components, ref(name, entry.Components[name]))
+		}
+		page.Images = append(page.Images, image)
+	}
+
+	path := filepath.Join(outputDir, GalleryFileName)
+	file, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to create gallery: %w", err)
+	}
+	defer file.Close()
+
+	if err := galleryTemplate.Execute(file, page); err != nil {
+		return "", fmt.Errorf("failed to render gallery: %w", err)
+	}
+	logger.Info("Gallery written", "path", path, "images", len(page.Images))
+	return path, nil
+}
+
+// galleryScores summarizes the identity and judge scores of an image, if any
+func galleryScores(entry ManifestImage) string {
+	var parts []string
+	if entry.IdentityScore != nil {
+		parts = append(parts, fmt.Sprintf("identity %.2f", *entry.IdentityScore))
+	}
+	if entry.Judge != nil {
+		parts = append(parts, fmt.Sprintf("judge %.2f (%s)", entry.Judge.Overall, judgeBreakdown(entry.Judge)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+var galleryTemplate = template.Must(template.New("gallery").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>img-cli gallery {{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", sans-serif; margin: 2em; color: #222; }
+.meta { color: #666; margin-bottom: 1.5em; }
+.gallery { display: grid; grid-template-columns: repeat(auto-fill, minmax(280px, 1fr)); gap: 1.2em; }
+.card { border: 1px solid #ddd; border-radius: 6px; padding: 0.8em; }
+.card > a img { width: 100%; border-radius: 4px; }
+.name { font-family: monospace; font-size: 0.8em; word-break: break-all; margin: 0.4em 0; }
+.refs { list-style: none; padding: 0; margin: 0.4em 0; font-size: 0.85em; }
+.refs li { display: flex; align-items: center; gap: 0.5em; margin-bottom: 0.3em; }
+.refs img { width: 40px; height: 40px; object-fit: cover; border-radius: 3px; }
+.refs b { min-width: 6em; font-weight: 600; }
+.time, .scores { font-size: 0.8em; color: #555; }
+details { font-size: 0.8em; margin-top: 0.4em; }
+pre { white-space: pre-wrap; font-size: 0.95em; background: #f6f6f6; padding: 0.5em; border-radius: 4px; }
+</style>
+</head>
+<body>
+<h1>img-cli gallery</h1>
+<div class="meta">{{.Title}}, {{len .Images}} image(s). Click an image for the full-size file.</div>
+
+<div class="gallery">
+{{range .Images}}
+  <div class="card">
+    <a href="{{.Href}}">{{if .Thumb}}<img src="{{.Thumb}}" alt="{{.Name}}">{{else}}{{.Name}}{{end}}</a>
+    <div class="name">{{.Name}}</div>
+    <ul class="refs">
+      {{with .Subject}}<li>{{if .Thumb}}<img src="{{.Thumb}}" alt="">{{end}}<b>{{.Name}}</b> {{.Value}}</li>{{end}}
+      {{range .Components}}<li>{{if .Thumb}}<img src="{{.Thumb}}" alt="">{{end}}<b>{{.Name}}</b> {{.Value}}</li>{{end}}
+    </ul>
+    <div class="time">{{.Workflow}}, started {{.Started}}, generated in {{.Took}}</div>
+    {{if .Scores}}<div class="scores">{{.Scores}}</div>{{end}}
+    {{if .Prompt}}<details><summary>Prompt</summary><pre>{{.Prompt}}</pre></details>{{end}}
+  </div>
+{{else}}
+  <p>No images in this folder's manifest.</p>
+{{end}}
+</div>
+</body>
+</html>
+`))
@@ -0,0 +1,99 @@
+package workflow
+
+import (
+	"fmt"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/generator"
+	"img-cli/pkg/logger"
+	"img-cli/pkg/output"
+	"img-cli/pkg/validator"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultIdentityRetries is how many times an output scoring below
+// --min-identity-score is regenerated before the best attempt is kept
+const DefaultIdentityRetries = 2
+
+// WithIdentityValidator replaces the bundled same-person judging call used by
+// identity validation, e.g. with one backed by a face-embedding model
+func WithIdentityValidator(v validator.IdentityValidator) Option {
+	return func(o *Orchestrator) {
+		o.identityValidator = v
+	}
+}
+
+// ValidateIdentityFlags checks --min-identity-score and --identity-retries
+func ValidateIdentityFlags(minScore float64, retries int) error {
+	if minScore < 0 || minScore > 1 {
+		return errors.ErrInvalidInput("min-identity-score", fmt.Sprintf("must be between 0 and 1, got %v", minScore))
+	}
+	if retries < 0 {
+		return errors.ErrInvalidInput("identity-retries", fmt.Sprintf("must be 0 or more, got %d", retries))
+	}
+	return nil
+}
+
+// validatesIdentity reports whether outputs are scored against the subject
+func (v VerifyOptions) validatesIdentity() bool {
+	return v.Identity || v.MinIdentityScore > 0
+}
+
+// generateValidated runs generate and, when identity validation is on, scores
+// the output against the subject photo. An output below MinIdentityScore is
+// regenerated up to IdentityRetries times; the best-scoring attempt is kept
+// and the others are deleted. Each retry is billed like an image. The
+// identity score is nil when validation is off or the check itself failed.
+func (o *Orchestrator) generateValidated(subjectPath string, verify VerifyOptions,
+	generate func() (*generator.GenerateResult, error)) (candidate, error) {
+	start := time.Now()
+	result, err := generate()
+	if err != nil || !verify.validatesIdentity() || subjectPath == "" {
+		return candidate{result: result, err: err, started: start, took: time.Since(start)}, err
+	}
+
+	best, bestScore := result, o.identityScore(subjectPath, result.OutputPath)
+	for retry := 1; bestScore != nil && *bestScore < verify.MinIdentityScore && retry <= verify.IdentityRetries; retry++ {
+		output.Progress.Printf("      🔁 Identity score %.2f is below %.2f, regenerating (retry %d/%d)\n",
+			*bestScore, verify.MinIdentityScore, retry, verify.IdentityRetries)
+		again, err := generate()
+		if err != nil {
+			logger.Warn("Identity retry failed", "image", filepath.Base(best.OutputPath), "error", err)
+			break
+		}
+		score := o.identityScore(subjectPath, again.OutputPath)
+		if score == nil || *score <= *bestScore {
+			removeOutput(again.OutputPath)
+			continue
+		}
+		removeOutput(best.OutputPath)
+		best, bestScore = again, score
+	}
+
+	if bestScore != nil && verify.MinIdentityScore > 0 && *bestScore < verify.MinIdentityScore {
+		output.Progress.Printf("      ⚠️  %s may not show the subject (identity score %.2f < %.2f)\n",
+			filepath.Base(best.OutputPath), *bestScore, verify.MinIdentityScore)
+		o.flagForReview(best.OutputPath, LabelIdentityDrift)
+	}
+	return candidate{result: best, identity: bestScore, started: start, took: time.Since(start)}, nil
+}
+
+// identityScore validates one output. Validation failures are logged and
+// never fail the run.
+func (o *Orchestrator) identityScore(subjectPath, outputPath string) *float64 {
+	result, err := o.identityValidator.ValidateIdentity(subjectPath, outputPath)
+	if err != nil {
+		logger.Warn("Identity validation failed", "image", filepath.Base(outputPath), "error", err)
+		return nil
+	}
+	logger.Debug("Identity validated", "image", filepath.Base(outputPath), "score", result.Score, "reason", result.Reason)
+	return &result.Score
+}
+
+// removeOutput deletes a rejected attempt
+func removeOutput(outputPath string) {
+	if err := os.Remove(outputPath); err != nil && !os.IsNotExist(err) {
+		logger.Warn("Failed to remove rejected output", "image", filepath.Base(outputPath), "error", err)
+	}
+}
@@ -0,0 +1,186 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"img-cli/pkg/analyzer"
+	"img-cli/pkg/config"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/generator"
+	"img-cli/pkg/imaging"
+	"img-cli/pkg/logger"
+	"img-cli/pkg/models"
+	"img-cli/pkg/output"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// regionPadding widens the boxes of --region masks by this share of the
+// image's short side, so the edit covers the edges of what was found
+const regionPadding = 0.02
+
+// InpaintOptions configures an inpaint run
+type InpaintOptions struct {
+	ImagePath  string
+	MaskPath   string // White marks the region to edit (--mask)
+	Region     string // Part of the image to find and mask instead, e.g. "jacket" (--region)
+	Edit       string // What the region becomes: a description, or an outfit reference image
+	Variations int
+	Seed       int64 // Seed of the first variation; variation i uses Seed+i (0 = random)
+	Strength   float64
+	Sampling   config.SamplingConfig
+	Avoid      []string
+	OutputDir  string // Optional: if not specified, will generate one
+	Debug      bool
+}
+
+// ValidateInpaintOptions checks that a run has exactly one mask source and an edit
+func ValidateInpaintOptions(options InpaintOptions) error {
+	switch {
+	case options.MaskPath == "" && options.Region == "":
+		return errors.ErrInvalidInput("mask", "give a mask image with --mask, or the part to edit with --region (e.g. --region jacket)")
+	case options.MaskPath != "" && options.Region != "":
+		return errors.ErrInvalidInput("mask", "use --mask or --region, not both")
+	case strings.TrimSpace(options.Edit) == "":
+		return errors.ErrInvalidInput("edit", "describe what the region becomes, e.g. --edit \"black leather biker jacket\", or give an outfit reference image")
+	case options.Variations < 1:
+		return errors.ErrInvalidInput("variations", "must be at least 1")
+	}
+	return nil
+}
+
+// RunInpaint edits only the masked region of an image, once per variation.
+// With --region the mask is made from where the vision model finds that part
+// of the image and saved next to the outputs as <image>_mask.png, so it can be
+// checked and reused with --mask.
+func (o *Orchestrator) RunInpaint(options InpaintOptions) ([]string, error) {
+	start := time.Now()
+	outputDir := options.OutputDir
+	if outputDir == "" {
+		outputDir = generateOutputDir()
+	}
+
+	maskPath := options.MaskPath
+	if options.Region != "" {
+		var err error
+		if maskPath, err = o.regionMask(options.ImagePath, options.Region, outputDir); err != nil {
+			return nil, err
+		}
+	}
+
+	// An outfit reference image is edited in as its description
+	edit := &models.ComponentData{Type: "edit", Text: options.Edit, Description: options.Edit}
+	if isFilePath(options.Edit) {
+		output.Progress.Printf("  Analyzing edit reference: %s\n", filepath.Base(options.Edit))
+		data, err := o.AnalyzeImage("outfit", options.Edit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to analyze %s: %w", filepath.Base(options.Edit), err)
+		}
+		edit = &models.ComponentData{Type: "outfit", Description: extractDescriptionFromAnalysis("outfit", data), JSONData: data, ImagePath: options.Edit}
+	}
+	components := map[string]*models.ComponentData{
+		"mask": {Description: "region to edit", ImagePath: maskPath},
+		"edit": edit,
+	}
+
+	var results []string
+	var manifest []ManifestImage
+	name := filepath.Base(options.ImagePath)
+	for i := 0; i < options.Variations; i++ {
+		label := fmt.Sprintf("%s (variation %d)", name, i+1)
+		o.progress.generating(label, i+1, options.Variations)
+		started := time.Now()
+		result, err := o.GenerateImage("inpaint", generator.GenerateParams{
+			ImagePath:       options.ImagePath,
+			Mask:            maskPath,
+			Prompt:          edit.Description,
+			OutputDir:       outputDir,
+			VariationIndex:  i + 1,
+			TotalVariations: options.Variations,
+			Avoid:           options.Avoid,
+			Seed:            offsetSeed(options.Seed, i),
+			Strength:        options.Strength,
+			Temperature:     options.Sampling.Temperature,
+			TopK:            options.Sampling.TopK,
+			TopP:            options.Sampling.TopP,
+			DebugPrompt:     options.Debug,
+		})
+		took := time.Since(started)
+		if err != nil {
+			logger.Warn("Failed to inpaint image", "variation", i+1, "error", err)
+			o.recordFailure(label, "", err)
+			o.progress.done(label, "", took, err, false)
+			continue
+		}
+		o.progress.done(label, result.OutputPath, took, nil, false)
+
+		o.writeSidecar(result, "inpaint", result.Prompt, options.ImagePath, components, nil)
+		manifest = append(manifest, o.manifestImage("inpaint", result, options.ImagePath, result.Prompt, components,
+			nil, i+1, started, took))
+		results = append(results, result.OutputPath)
+	}
+
+	o.recordManifest(outputDir, ManifestRun{
+		Workflow: "inpaint",
+		Started:  start,
+		Finished: time.Now(),
+		Failures: options.Variations - len(results),
+	}, manifest)
+
+	logger.Info("Inpaint workflow completed",
+		"duration", time.Since(start),
+		"images_generated", len(results))
+	return results, nil
+}
+
+// regionMask finds a named part of an image and writes a mask covering it
+// into outputDir
+func (o *Orchestrator) regionMask(imagePath, region, outputDir string) (string, error) {
+	output.Progress.Printf("🔍 Finding the %s...\n", region)
+	data, err := analyzer.NewRegionAnalyzer(o.client, region).Analyze(imagePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to find the %s: %w", region, err)
+	}
+	var found analyzer.Region
+	if err := json.Unmarshal(data, &found); err != nil {
+		return "", fmt.Errorf("failed to parse the %s region: %w", region, err)
+	}
+	if !found.Found || len(found.Boxes) == 0 {
+		return "", errors.ErrInvalidInput("region", fmt.Sprintf("no %s found in %s; draw a mask and use --mask instead", region, filepath.Base(imagePath)))
+	}
+
+	w, h, err := imaging.Size(imagePath)
+	if err != nil {
+		return "", err
+	}
+	pad := int(float64(min(w, h)) * regionPadding)
+	mask := image.NewGray(image.Rect(0, 0, w, h))
+	for _, box := range found.Boxes {
+		rect := imaging.RectMask(w, h, regionRect(box, w, h, pad))
+		for i, v := range rect.Pix {
+			mask.Pix[i] = max(mask.Pix[i], v)
+		}
+	}
+	if imaging.MaskCoverage(mask) == 0 {
+		return "", errors.ErrInvalidInput("region", fmt.Sprintf("the %s region found in %s is empty; draw a mask and use --mask instead", region, filepath.Base(imagePath)))
+	}
+
+	base := strings.TrimSuffix(filepath.Base(imagePath), filepath.Ext(imagePath))
+	path := filepath.Join(outputDir, base+"_mask.png")
+	if err := imaging.Save(path, mask); err != nil {
+		return "", errors.Wrap(err, errors.FileError, "failed to save the mask")
+	}
+	output.Progress.Printf("   Mask: %s (%.0f%% of the image)\n", path, imaging.MaskCoverage(mask)*100)
+	return path, nil
+}
+
+// regionRect converts a box found by a RegionAnalyzer to pixels of a w x h
+// image, widened by pad pixels on every side
+func regionRect(box [4]int, w, h, pad int) image.Rectangle {
+	return image.Rect(
+		box[1]*w/analyzer.RegionScale-pad, box[0]*h/analyzer.RegionScale-pad,
+		box[3]*w/analyzer.RegionScale+pad, box[2]*h/analyzer.RegionScale+pad,
+	)
+}
@@ -0,0 +1,119 @@
+package workflow
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/logger"
+	"img-cli/pkg/models"
+	"img-cli/pkg/output"
+	"img-cli/pkg/validator"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// RankingFile lists the judged images of an output folder, best first
+const RankingFile = "ranking.txt"
+
+// judgeOutput scores a generated image against the recipe's outfit and style
+// descriptions. Judge failures are logged and never fail the run.
+func (o *Orchestrator) judgeOutput(outputPath string, components map[string]*models.ComponentData, verify VerifyOptions) *validator.JudgeScores {
+	if !verify.Judge {
+		return nil
+	}
+	brief := validator.JudgeBrief{
+		Outfit: judgeText(components["outfit"]),
+		Style:  judgeText(components["style"]),
+	}
+	if over := judgeText(components["over_outfit"]); over != "" {
+		brief.Outfit = strings.TrimSpace(brief.Outfit + "\nWorn under the outer layer above: " + over)
+	}
+
+	scores, err := o.judge.Judge(outputPath, brief)
+	if err != nil {
+		logger.Warn("Judging failed", "image", filepath.Base(outputPath), "error", err)
+		return nil
+	}
+	logger.Debug("Judged output", "image", filepath.Base(outputPath), "overall", scores.Overall, "notes", scores.Notes)
+	return scores
+}
+
+// judgeText is the description of a component the judge compares against
+func judgeText(c *models.ComponentData) string {
+	switch {
+	case c == nil:
+		return ""
+	case c.Description != "":
+		return c.Description
+	case c.Text != "":
+		return c.Text
+	case c.JSONData != nil:
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, c.JSONData); err == nil {
+			return compact.String()
+		}
+	}
+	return ""
+}
+
+// rankOutputs writes the judged images of an output folder to RankingFile,
+// best first, and prints the top few. Earlier runs into the same folder are
+// ranked too, since they share its manifest.
+func (o *Orchestrator) rankOutputs(outputDir string) {
+	if outputDir == "" || o.dryRun {
+		return
+	}
+	manifest, err := ReadManifest(outputDir)
+	if err != nil {
+		logger.Warn("Failed to read manifest for ranking", "dir", outputDir, "error", err)
+		return
+	}
+	var judged []ManifestImage
+	for _, image := range manifest.Images {
+		if image.Judge != nil {
+			judged = append(judged, image)
+		}
+	}
+	if len(judged) == 0 {
+		return
+	}
+	sort.SliceStable(judged, func(i, j int) bool {
+		return judged[i].Judge.Overall > judged[j].Judge.Overall
+	})
+
+	var b strings.Builder
+	for i, image := range judged {
+		fmt.Fprintf(&b, "%d. %.2f  %s  (%s)\n", i+1, image.Judge.Overall, image.Image, judgeBreakdown(image.Judge))
+	}
+	path := filepath.Join(outputDir, RankingFile)
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		logger.Warn("Failed to write ranking", "path", path, "error", err)
+		return
+	}
+
+	output.Progress.Printf("\n🏆 Judged %d image(s), best first (%s):\n", len(judged), path)
+	for i, image := range judged {
+		if i == 3 {
+			break
+		}
+		output.Progress.Printf("   %d. %.2f %s\n", i+1, image.Judge.Overall, image.Image)
+	}
+}
+
+// judgeBreakdown lists the individual scores, e.g. "outfit 0.90, artifacts 0.75"
+func judgeBreakdown(scores *validator.JudgeScores) string {
+	var parts []string
+	if scores.Outfit != nil {
+		parts = append(parts, fmt.Sprintf("outfit %.2f", *scores.Outfit))
+	}
+	if scores.Style != nil {
+		parts = append(parts, fmt.Sprintf("style %.2f", *scores.Style))
+	}
+	parts = append(parts, fmt.Sprintf("artifacts %.2f", scores.Artifacts))
+	if scores.Notes != "" {
+		parts = append(parts, scores.Notes)
+	}
+	return strings.Join(parts, ", ")
+}
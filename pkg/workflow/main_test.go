@@ -0,0 +1,24 @@
+package workflow
+
+import (
+	"os"
+	"testing"
+)
+
+// TestMain runs the tests in an empty project so caches and outputs they
+// write stay out of the source tree
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "img-cli-workflow-test")
+	if err != nil {
+		panic(err)
+	}
+	if err := os.WriteFile(dir+"/.img-cli.yaml", nil, 0644); err != nil {
+		panic(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		panic(err)
+	}
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
@@ -0,0 +1,176 @@
+package workflow
+
+import (
+	"encoding/json"
+	"img-cli/pkg/generator"
+	"img-cli/pkg/logger"
+	"img-cli/pkg/models"
+	"img-cli/pkg/validator"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ManifestFile is the per-run index written into each output directory
+const ManifestFile = "manifest.json"
+
+// manifestVersion is bumped when the manifest layout changes incompatibly
+const manifestVersion = 1
+
+// Manifest lists every image generated into an output directory with the
+// inputs, prompt and model parameters that produced it. Workflows that write
+// into the same directory (batches, sweeps) add to one manifest.
+type Manifest struct {
+	Version int             `json:"version"`
+	Runs    []ManifestRun   `json:"runs"`
+	Images  []ManifestImage `json:"images"`
+}
+
+// ManifestRun times one workflow call
+type ManifestRun struct {
+	Workflow   string    `json:"workflow"`
+	Started    time.Time `json:"started"`
+	Finished   time.Time `json:"finished"`
+	DurationMS int64     `json:"duration_ms"`
+	Images     int       `json:"images"`
+	Failures   int       `json:"failures"`
+}
+
+// ManifestImage describes one generated image
+type ManifestImage struct {
+	Image       string            `json:"image"`   // Relative to the output directory
+	Sidecar     string            `json:"sidecar"` // Full provenance record (see Sidecar)
+	Workflow    string            `json:"workflow"`
+	Subject     string            `json:"subject"`
+	Components  map[string]string `json:"components"` // Component -> reference file or text
+	Prompt      string            `json:"prompt,omitempty"`
+	Model       ManifestModel     `json:"model"`
+	Settings    *RecipeSettings   `json:"settings,omitempty"`
+	Combination *Combination      `json:"combination,omitempty"` // Batch inputs, used by --resume
+	Hash        string            `json:"hash,omitempty"`        // Combination hash, used by --skip-existing
+	Variation   int               `json:"variation,omitempty"`
+	Started     time.Time         `json:"started"`
+	DurationMS  int64             `json:"duration_ms"` // Generation request including retries
+	// Face similarity to the subject (0-1), when identity validation is on
+	IdentityScore *float64 `json:"identity_score,omitempty"`
+	// Quality scores, when --judge is on
+	Judge *validator.JudgeScores `json:"judge,omitempty"`
+}
+
+// ManifestModel records the provider, model and sampling parameters of a generation
+type ManifestModel struct {
+	Provider    string  `json:"provider"`
+	Model       string  `json:"model"`
+	Temperature float64 `json:"temperature,omitempty"`
+	TopK        int     `json:"top_k,omitempty"`
+	TopP        float64 `json:"top_p,omitempty"`
+	Seed        int64   `json:"seed,omitempty"`     // Set with --seed
+	Strength    float64 `json:"strength,omitempty"` // Set with --strength
+}
+
+// ReadManifest loads the manifest of an output directory
+func ReadManifest(outputDir string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(outputDir, ManifestFile))
+	if err != nil {
+		return nil, err
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// manifestImage builds the manifest entry of a generated image
+func (o *Orchestrator) manifestImage(workflow string, result *generator.GenerateResult, subjectPath, prompt string, components map[string]*models.ComponentData,
+	settings *RecipeSettings, variation int, started time.Time, took time.Duration) ManifestImage {
+	provider, model := o.generatedBy(result)
+	entry := ManifestImage{
+		Image:      result.OutputPath,
+		Sidecar:    SidecarPath(result.OutputPath),
+		Workflow:   workflow,
+		Subject:    subjectPath,
+		Components: make(map[string]string),
+		Prompt:     prompt,
+		Model:      ManifestModel{Provider: provider, Model: model},
+		Settings:   settings,
+		Variation:  variation,
+		Started:    started,
+		DurationMS: took.Milliseconds(),
+	}
+	if params := result.Parameters; params != nil {
+		entry.Model.Temperature = params.Temperature
+		entry.Model.TopK = params.TopK
+		entry.Model.TopP = params.TopP
+		entry.Model.Seed = params.Seed
+		entry.Model.Strength = params.Strength
+	}
+	for name, c := range components {
+		if c == nil {
+			continue
+		}
+		switch {
+		case c.ImagePath != "":
+			entry.Components[name] = c.ImagePath
+		case c.Text != "":
+			entry.Components[name] = c.Text
+		default:
+			entry.Components[name] = c.Description
+		}
+	}
+	return entry
+}
+
+// recordManifest adds a workflow call and its images to the manifest of the
+// output directory. Failures are logged but never fail the run. Dry runs
+// produce no images and leave the manifest alone.
+func (o *Orchestrator) recordManifest(outputDir string, run ManifestRun, images []ManifestImage) {
+	if outputDir == "" || o.dryRun {
+		return
+	}
+	run.DurationMS = run.Finished.Sub(run.Started).Milliseconds()
+	run.Images = len(images)
+
+	o.manifestMu.Lock()
+	defer o.manifestMu.Unlock()
+
+	manifest, err := ReadManifest(outputDir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Warn("Replacing unreadable manifest", "dir", outputDir, "error", err)
+		}
+		manifest = &Manifest{}
+	}
+	manifest.Version = manifestVersion
+	manifest.Runs = append(manifest.Runs, run)
+	for _, image := range images {
+		// --name-template can put images in subfolders of the output directory
+		image.Image = manifestPath(outputDir, image.Image)
+		image.Sidecar = manifestPath(outputDir, image.Sidecar)
+		manifest.Images = append(manifest.Images, image)
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		logger.Warn("Failed to encode manifest", "error", err)
+		return
+	}
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		logger.Warn("Failed to write manifest", "dir", outputDir, "error", err)
+		return
+	}
+	if err := os.WriteFile(filepath.Join(outputDir, ManifestFile), data, 0644); err != nil {
+		logger.Warn("Failed to write manifest", "dir", outputDir, "error", err)
+	}
+}
+
+// manifestPath makes path relative to the output directory, falling back to
+// its base name when it lies outside it
+func manifestPath(outputDir, path string) string {
+	rel, err := filepath.Rel(outputDir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.Base(path)
+	}
+	return rel
+}
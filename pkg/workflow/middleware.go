@@ -0,0 +1,70 @@
+package workflow
+
+import (
+	"encoding/json"
+	"img-cli/pkg/cost"
+	"img-cli/pkg/generator"
+)
+
+// AnalyzeFunc performs one analysis. analyzerType is the cache/analyzer type
+// ("outfit", "visual_style", "hair_style", ...).
+type AnalyzeFunc func(analyzerType, imagePath string) (json.RawMessage, error)
+
+// GenerateFunc performs one image generation. generatorType is the registered
+// generator ("combined", "art_style", ...) or "modular" for the modular
+// workflow, whose params carry the subject in ImagePath and the full prompt.
+type GenerateFunc func(generatorType string, params generator.GenerateParams) (*generator.GenerateResult, error)
+
+// AnalyzerMiddleware wraps every analysis, including cache lookups. It can
+// observe, short-circuit or post-process the call by deciding whether and how
+// to call next.
+type AnalyzerMiddleware func(next AnalyzeFunc) AnalyzeFunc
+
+// GeneratorMiddleware wraps every image generation. Changing params.Prompt
+// before calling next mutates the prompt sent to the model; note the combined
+// generator builds its own prompt when Prompt is empty (--send-original).
+type GeneratorMiddleware func(next GenerateFunc) GenerateFunc
+
+// WithAnalyzerMiddleware adds analyzer middleware. The first registered
+// middleware is the outermost and sees each call first.
+func WithAnalyzerMiddleware(middleware ...AnalyzerMiddleware) Option {
+	return func(o *Orchestrator) {
+		o.analyzerMiddleware = append(o.analyzerMiddleware, middleware...)
+	}
+}
+
+// WithGeneratorMiddleware adds generator middleware. The first registered
+// middleware is the outermost and sees each call first.
+func WithGeneratorMiddleware(middleware ...GeneratorMiddleware) Option {
+	return func(o *Orchestrator) {
+		o.generatorMiddleware = append(o.generatorMiddleware, middleware...)
+	}
+}
+
+// analyzeThrough runs an analysis through the registered middleware
+func (o *Orchestrator) analyzeThrough(analyzerType, imagePath string, final AnalyzeFunc) (json.RawMessage, error) {
+	handler := final
+	for i := len(o.analyzerMiddleware) - 1; i >= 0; i-- {
+		handler = o.analyzerMiddleware[i](handler)
+	}
+	return handler(analyzerType, imagePath)
+}
+
+// generateThrough runs a generation through the registered middleware
+func (o *Orchestrator) generateThrough(generatorType string, params generator.GenerateParams, final GenerateFunc) (*generator.GenerateResult, error) {
+	handler := final
+	for i := len(o.generatorMiddleware) - 1; i >= 0; i-- {
+		handler = o.generatorMiddleware[i](handler)
+	}
+	// Stay under the run's budget cap whatever the estimate missed
+	if err := o.spend.Reserve(); err != nil {
+		return nil, err
+	}
+	result, err := handler(generatorType, params)
+	if err != nil {
+		o.spend.Release()
+		return nil, err
+	}
+	cost.Record(1)
+	return result, nil
+}
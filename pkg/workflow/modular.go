@@ -5,9 +5,15 @@ import (
 	"fmt"
 	"img-cli/pkg/analyzer"
 	"img-cli/pkg/cache"
+	"img-cli/pkg/config"
+	"img-cli/pkg/cost"
 	"img-cli/pkg/generator"
 	"img-cli/pkg/logger"
 	"img-cli/pkg/models"
+	"img-cli/pkg/output"
+	"img-cli/pkg/prompts"
+	"img-cli/pkg/usage"
+	"img-cli/pkg/workspace"
 	"os"
 	"path/filepath"
 	"strings"
@@ -16,19 +22,44 @@ import (
 
 // ModularConfig holds configuration for modular generation
 type ModularConfig struct {
-	SubjectPath    string
-	OutfitRef      string
-	OverOutfitRef  string // Base layer outfit that the main outfit is worn over
-	StyleRef       string
-	HairStyleRef   string
-	HairColorRef   string
-	MakeupRef      string
-	ExpressionRef  string
-	AccessoriesRef string
-	Variations     int
-	SendOriginal   bool
-	Debug          bool
-	OutputDir      string // Optional: if not specified, will generate one
+	SubjectPath      string
+	OutfitRef        string
+	OverOutfitRef    string // Base layer outfit that the main outfit is worn over
+	StyleRef         string
+	StyleBlend       bool   // StyleRef lists several references, comma-separated, blended into one style
+	HairStyleRef     string
+	HairColorRef     string
+	MakeupRef        string
+	ExpressionRef    string
+	AccessoriesRef   string
+	PoseRef          string // Body pose; replaces the pose of the style reference
+	BackgroundRef    string // Environment; replaces the background of the style reference
+	Extra            map[string]string // Registered components (see analyzer.Register) by analyzer type: image path or text
+	Weights          map[string]float64 // Prompt emphasis by component ("outfit", "hair_style", analyzer type, ...; see SplitWeight)
+	Variations       int
+	Generated        int    // Variations an earlier run already generated (when resuming); numbering and seeds continue after them
+	SendOriginal     bool
+	Debug            bool
+	OutputDir        string // Optional: if not specified, will generate one
+	Subfolder        string // Subfolder of OutputDir the images go into (--organize-by); the manifest stays in OutputDir
+	Post             PostOptions
+	Verify           VerifyOptions
+	EnhanceText      bool     // Expand short text components into structured descriptions
+	OutfitCheck      string   // Outfit completeness mode: warn (default), fill or off
+	MaxAccessories   int      // Keep only the N most important accessories in the prompt (0 = no limit)
+	AllowImplausible bool     // Generate even when garments clash with the style's scene
+	Ambient          string   // Lighting/ambient that replaces the style's (preset name or description)
+	Avoid            []string // Elements that must not appear in the image
+	Aspect           string   // Aspect ratio such as "16:9" ("" = default 9:16)
+	Resolution       int      // Long side of saved images in pixels (0 = as generated)
+	NameTemplate     string   // Output file name template ("" = built-in names)
+	SkipExisting     bool     // Skip variations already generated anywhere under the output root (see combinationHash)
+	Seed             int64    // Seed of the first variation; variation i uses Seed+i (0 = random)
+	Sampling         config.SamplingConfig // Generation temperature, top-k and top-p (zero fields keep the defaults)
+	Strength         float64  // How much the subject photo may change, 0-1 (0 = left to the model)
+	Person           string   // Person of a group photo the components apply to ("2", "left"); "" = a single-person photo
+	People           []PersonComponents // People of a group photo with their own components (--for)
+	Stop             func() bool // Checked before each variation; true ends the run with the images generated so far
 }
 
 // isFilePath checks if a string is a file path or a text description
@@ -49,15 +80,28 @@ func isFilePath(input string) bool {
 	return false
 }
 
+// isStyleText reports whether a style value is a text description such as
+// "1990s film noir, heavy grain" rather than a path: nothing exists there and
+// it has no folders or file extension. Missing style files stay errors.
+func isStyleText(input string) bool {
+	if input == "" || strings.ContainsAny(input, "/\\") {
+		return false
+	}
+	if _, err := os.Stat(input); err == nil {
+		return false
+	}
+	return strings.ContainsAny(input, " \t") || filepath.Ext(input) == ""
+}
+
 // processComponentInput handles both file paths and text descriptions for a component
 func processComponentInput(input string, componentType string) (string, bool) {
 	if input == "" {
 		return "", false
 	}
 
-	// For style, always treat as file path
+	// Style is a file path unless it is clearly a description
 	if componentType == "style" || componentType == "visual_style" {
-		return input, true
+		return input, !isStyleText(input)
 	}
 
 	// Check if it's a file path
@@ -74,26 +118,88 @@ func processComponentInput(input string, componentType string) (string, bool) {
 func (o *Orchestrator) RunModularWorkflow(config ModularConfig) ([]string, error) {
 	start := time.Now()
 
+	// Part of the caller's batch when there is one; variations that are never
+	// attempted come off its total
+	o.progress.begin(config.Variations)
+	defer o.progress.end()
+	attempted := 0
+	defer func() { o.progress.skip(config.Variations - attempted) }()
+
+	// Checked per recipe so batches stop at the budget even without an up-front estimate
+	if !o.dryRun {
+		if err := usage.CheckBudget(cost.Of(config.Variations + config.Verify.ExtraImages(config.Variations))); err != nil {
+			return nil, err
+		}
+	}
+
+	// Failures are keyed by the combination as the caller asked for it, the
+	// key its outputs are reported under
+	recipe := recipeLabel(config)
+
+	// A registered subject brings its preferred hair color and the traits to keep
+	profile := workspace.SubjectProfile(config.SubjectPath)
+	if profile != nil && config.HairColorRef == "" && profile.HairColor != "" {
+		config.HairColorRef = profile.HairColor
+		output.Progress.Printf("💇 Using %s's preferred hair color: %s\n", profile.Name, profile.HairColor)
+	}
+
 	// Initialize additional analyzers and caches if needed
 	o.initializeModularComponents()
 
 	// Analyze all provided components
 	components, err := o.analyzeModularComponents(config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to analyze components: %w", err)
+		err = fmt.Errorf("failed to analyze components: %w", err)
+		o.recordFailure(recipeLabel(config), recipe, err)
+		return nil, err
+	}
+
+	// Refuse garment/scene clashes before paying for images reviewers would reject
+	if issues := modularPlausibility(components); len(issues) > 0 {
+		if !config.AllowImplausible {
+			return nil, plausibilityError(recipeLabel(config), issues)
+		}
+		warnImplausible(issues)
 	}
 
 	// Build the generation prompt
-	prompt := o.buildModularPrompt(components)
+	var keep []string
+	if profile != nil {
+		keep = profile.Keep
+	}
+	prompt, err := o.buildModularPrompt(components, config.Aspect, keep, config.Avoid)
+	if err != nil {
+		o.recordFailure(recipeLabel(config), recipe, err)
+		return nil, err
+	}
+	if config.Ambient != "" {
+		prompt += ambientPromptSection(config.Ambient)
+	}
+	strength, err := generator.StrengthSection(config.Strength)
+	if err != nil {
+		o.recordFailure(recipeLabel(config), recipe, err)
+		return nil, err
+	}
+	prompt += strength
+	if config.grouped() {
+		section, err := o.groupPromptSection(config)
+		if err != nil {
+			o.recordFailure(recipeLabel(config), recipe, err)
+			return nil, err
+		}
+		prompt += section
+	}
 
 	if config.Debug {
-		fmt.Println("\n=== DEBUG: Generation Prompt ===")
-		fmt.Println(prompt)
-		fmt.Println("=== END DEBUG ===\n")
+		output.Println("\n=== DEBUG: Generation Prompt ===")
+		output.Println(prompt)
+		output.Println("=== END DEBUG ===")
+		output.Println()
 	}
 
 	// Generate images
 	var results []string
+	var manifest []ManifestImage
 	outputDir := config.OutputDir
 	if outputDir == "" {
 		outputDir = generateOutputDir()
@@ -101,38 +207,132 @@ func (o *Orchestrator) RunModularWorkflow(config ModularConfig) ([]string, error
 
 	// Debug: Show the prompt if debug mode is enabled
 	if config.Debug {
-		fmt.Println("\n=== DEBUG: Final Generation Prompt ===")
-		fmt.Println(prompt)
-		fmt.Println("=== END PROMPT ===\n")
+		output.Println("\n=== DEBUG: Final Generation Prompt ===")
+		output.Println(prompt)
+		output.Println("=== END PROMPT ===")
+		output.Println()
 	}
 
-	for i := 0; i < config.Variations; i++ {
-		fmt.Printf("      Generating variation %d/%d...\n", i+1, config.Variations)
+	total := config.Generated + config.Variations
+	for i := config.Generated; i < total; i++ {
+		if config.Stop != nil && config.Stop() {
+			output.Progress.Printf("⏹️  Stop requested, not starting the remaining %d variation(s)\n", total-i)
+			break
+		}
+		label := fmt.Sprintf("%s (variation %d)", recipeLabel(config), i+1)
+		hash := combinationHash("modular", o.hashSettings(config.Seed, config.Strength, config.Sampling, config.Aspect, config.Resolution, config.Avoid), config.SubjectPath, modularComponentMap(components), i+1,
+			config.Ambient, config.Person, fmt.Sprintf("%+v", config.People))
+		if config.SkipExisting {
+			if existing := o.existingOutput(hash, outputDir); existing != "" {
+				output.Progress.Printf("⏭️  Skipping %s: already generated as %s\n", label, existing)
+				continue
+			}
+		}
+		o.progress.generating(label, i+1, total)
+		attempted++
 
 		// Use the modular generator
 		gen := generator.NewModularGenerator(o.client)
 
-		// Build generation request
-		genRequest := generator.ModularRequest{
-			SubjectPath:   config.SubjectPath,
-			Prompt:        prompt,
-			Components:    components,
-			SendOriginals: config.SendOriginal,
-			OutputDir:     outputDir,
+		nextSeed := seedSequence(config.Seed, i, total)
+		picked, err := o.generateBest(config.SubjectPath, modularComponentMap(components), config.Verify, func() (*generator.GenerateResult, error) {
+			return o.generateThrough("modular", generator.GenerateParams{
+				ImagePath:       config.SubjectPath,
+				Prompt:          prompt,
+				OutputDir:       filepath.Join(outputDir, config.Subfolder),
+				VariationIndex:  i + 1,
+				TotalVariations: total,
+				SendOriginal:    config.SendOriginal,
+				Aspect:          config.Aspect,
+				Resolution:      config.Resolution,
+				Seed:            nextSeed(),
+				Temperature:     config.Sampling.Temperature,
+				TopK:            config.Sampling.TopK,
+				TopP:            config.Sampling.TopP,
+				Strength:        config.Strength,
+			}, func(_ string, params generator.GenerateParams) (*generator.GenerateResult, error) {
+				req := generator.ModularRequest{
+					SubjectPath:   params.ImagePath,
+					Prompt:        params.Prompt,
+					Components:    components,
+					SendOriginals: params.SendOriginal,
+					OutputDir:     params.OutputDir,
+					Tag:           ambientFileTag(config.Ambient),
+					Aspect:        params.Aspect,
+					Resolution:    params.Resolution,
+					Seed:          params.Seed,
+					Sampling:      config.Sampling,
+					Strength:      params.Strength,
+					Variation:     params.VariationIndex,
+					NameTemplate:  config.NameTemplate,
+				}
+				return gen.Generate(req)
+			})
+		})
+		if o.Planned(err, outputDir, label) {
+			o.progress.done(label, "", 0, nil, true)
+			continue
 		}
-
-		outputPath, err := gen.Generate(genRequest)
 		if err != nil {
 			logger.Warn("Failed to generate image", "variation", i+1, "error", err)
+			o.recordFailure(label, recipe, err)
+			o.progress.done(label, "", picked.took, err, false)
 			continue
 		}
+		outputPath := picked.result.OutputPath
+		o.progress.done(label, outputPath, picked.took, nil, false)
+
+		// Before the LUT so the pasted face is graded with the rest
+		o.lockFace(config.SubjectPath, outputPath, config.Post)
+		if err := applyPostChain(outputPath, config.Post); err != nil {
+			logger.Warn("Post-processing failed", "image", filepath.Base(outputPath), "error", err)
+		}
+		o.upscaleOutput(outputPath, config.Post)
+		o.verifyOutput(outputPath, config.StyleRef, config.Verify)
+		o.reviewOutput(outputPath, config.SubjectPath, modularComponentMap(components), config.Verify)
+		judged := picked.judged
+		if judged == nil {
+			judged = o.judgeOutput(outputPath, modularComponentMap(components), config.Verify)
+		}
+		settings := &RecipeSettings{
+			SendOriginal:   config.SendOriginal,
+			EnhanceText:    config.EnhanceText,
+			OutfitCheck:    config.OutfitCheck,
+			MaxAccessories: config.MaxAccessories,
+			LUT:            config.Post.LUTPath,
+			Ambient:        config.Ambient,
+			Avoid:          config.Avoid,
+			Aspect:         config.Aspect,
+			Resolution:     config.Resolution,
+			Upscale:        config.Post.Upscale,
+			Upscaler:       config.Post.Upscaler,
+			FaceLock:       config.Post.FaceLock,
+			Person:         config.Person,
+			People:         config.People,
+		}
+		settings.recordGeneration(picked.result, config.Sampling, config.Strength)
+		o.writeSidecar(picked.result, "modular", prompt, config.SubjectPath, modularComponentMap(components), settings)
+		image := o.manifestImage("modular", picked.result, config.SubjectPath, prompt, modularComponentMap(components),
+			settings, i+1, picked.started, picked.took)
+		image.IdentityScore = picked.identity
+		image.Judge = judged
+		combo := config.combination()
+		image.Combination = &combo
+		image.Hash = hash
+		manifest = append(manifest, image)
 
 		results = append(results, outputPath)
+	}
 
-		// Rate limiting between API calls
-		if i < config.Variations-1 {
-			time.Sleep(2 * time.Second)
-		}
+	o.scoreVariations(recipeLabel(config), results, config.Verify)
+	o.recordManifest(outputDir, ManifestRun{
+		Workflow: "modular",
+		Started:  start,
+		Finished: time.Now(),
+		Failures: attempted - len(results),
+	}, manifest)
+	if config.Verify.Judge {
+		o.rankOutputs(outputDir)
 	}
 
 	logger.Info("Modular workflow completed",
@@ -142,31 +342,56 @@ func (o *Orchestrator) RunModularWorkflow(config ModularConfig) ([]string, error
 	return results, nil
 }
 
-// initializeModularComponents sets up analyzers and caches for new component types
+// initializeModularComponents sets up analyzers and caches for every
+// registered analyzer type (see analyzer.Register)
 func (o *Orchestrator) initializeModularComponents() {
-	// Add new analyzers if not already present
-	if _, exists := o.analyzers["hair_style"]; !exists {
-		o.analyzers["hair_style"] = analyzer.NewHairStyleAnalyzer(o.client)
-		o.caches["hair_style"] = cache.NewCacheForType("hair_style", 0)
+	for _, analyzerType := range analyzer.Registered() {
+		o.addAnalyzer(analyzerType)
 	}
-	if _, exists := o.analyzers["hair_color"]; !exists {
-		o.analyzers["hair_color"] = analyzer.NewHairColorAnalyzer(o.client)
-		o.caches["hair_color"] = cache.NewCacheForType("hair_color", 0)
-	}
-	if _, exists := o.analyzers["makeup"]; !exists {
-		o.analyzers["makeup"] = analyzer.NewMakeupAnalyzer(o.client)
-		o.caches["makeup"] = cache.NewCacheForType("makeup", 0)
-	}
-	if _, exists := o.analyzers["expression"]; !exists {
-		o.analyzers["expression"] = analyzer.NewExpressionAnalyzer(o.client)
-		o.caches["expression"] = cache.NewCacheForType("expression", 0)
+}
+
+// addAnalyzer constructs a registered analyzer type and its cache unless the
+// orchestrator already has them
+func (o *Orchestrator) addAnalyzer(analyzerType string) {
+	o.analyzersMu.Lock()
+	defer o.analyzersMu.Unlock()
+	if _, exists := o.analyzers[analyzerType]; exists {
+		return
 	}
-	if _, exists := o.analyzers["accessories"]; !exists {
-		o.analyzers["accessories"] = analyzer.NewAccessoriesAnalyzer(o.client)
-		o.caches["accessories"] = cache.NewCacheForType("accessories", 0)
+	if a, ok := analyzer.New(analyzerType, o.client); ok {
+		o.analyzers[analyzerType] = a
+		o.caches[analyzerType] = cache.NewCacheForType(analyzerType, 0)
 	}
 }
 
+// setAnalyzer adds an analyzer that isn't in the registry, with its cache,
+// unless the orchestrator already has one of that type. Workers of a
+// --parallel run may call it at the same time.
+func (o *Orchestrator) setAnalyzer(analyzerType string, newAnalyzer func() analyzer.Analyzer) {
+	o.analyzersMu.Lock()
+	defer o.analyzersMu.Unlock()
+	if _, exists := o.analyzers[analyzerType]; exists {
+		return
+	}
+	o.analyzers[analyzerType] = newAnalyzer()
+	o.caches[analyzerType] = cache.NewCacheForType(analyzerType, 0)
+}
+
+// analyzerFor returns the analyzer of a type, if the orchestrator has one
+func (o *Orchestrator) analyzerFor(analyzerType string) (analyzer.Analyzer, bool) {
+	o.analyzersMu.RLock()
+	defer o.analyzersMu.RUnlock()
+	a, ok := o.analyzers[analyzerType]
+	return a, ok
+}
+
+// cacheFor returns the analysis cache of a type, or nil
+func (o *Orchestrator) cacheFor(analyzerType string) *cache.Cache {
+	o.analyzersMu.RLock()
+	defer o.analyzersMu.RUnlock()
+	return o.caches[analyzerType]
+}
+
 // analyzeModularComponents analyzes all provided component images
 func (o *Orchestrator) analyzeModularComponents(config ModularConfig) (*models.ModularComponents, error) {
 	components := &models.ModularComponents{}
@@ -181,7 +406,7 @@ func (o *Orchestrator) analyzeModularComponents(config ModularConfig) (*models.M
 	// Analyze outfit with exclusions
 	if config.OutfitRef != "" {
 		if isFilePath(config.OutfitRef) {
-			fmt.Printf("  Analyzing outfit from: %s\n", filepath.Base(config.OutfitRef))
+			output.Progress.Printf("  Analyzing outfit from: %s\n", filepath.Base(config.OutfitRef))
 
 			// Use modular outfit analyzer with exclusions
 			modularAnalyzer := analyzer.NewModularOutfitAnalyzer(o.client, excludeOpts)
@@ -196,39 +421,42 @@ func (o *Orchestrator) analyzeModularComponents(config ModularConfig) (*models.M
 				desc = o.extractOuterLayerOnly(data)
 				if desc == "" {
 					// If no outer layer found, skip this outfit component
-					fmt.Printf("    No outer layer (jacket/coat) found in main outfit, will use over-outfit as complete outfit\n")
+					output.Progress.Printf("    No outer layer (jacket/coat) found in main outfit, will use over-outfit as complete outfit\n")
 					// Don't set components.Outfit so we only use the over-outfit
 				} else {
-					fmt.Printf("    Extracted outer layer only (jacket/coat) from main outfit\n")
+					output.Progress.Printf("    Extracted outer layer only (jacket/coat) from main outfit\n")
 					if config.Debug {
-						fmt.Printf("  DEBUG: Outer layer only extracted: %s\n", desc)
+						output.Printf("  DEBUG: Outer layer only extracted: %s\n", desc)
 					}
 					components.Outfit = &models.ComponentData{
 						Type:        "outfit",
 						Description: desc,
 						JSONData:    data,
 						ImagePath:   config.OutfitRef,
+						Filters:     append(exclusionFilters(excludeOpts), "outer layer only (worn over the over-outfit)"),
 					}
 				}
 			} else {
 				// No over-outfit, use the full outfit description
 				desc = o.extractOutfitDescription(data)
 				if config.Debug {
-					fmt.Printf("  DEBUG: Full outfit description extracted: %s\n", desc)
+					output.Printf("  DEBUG: Full outfit description extracted: %s\n", desc)
 				}
 				components.Outfit = &models.ComponentData{
 					Type:        "outfit",
 					Description: desc,
 					JSONData:    data,
 					ImagePath:   config.OutfitRef,
+					Filters:     exclusionFilters(excludeOpts),
 				}
 			}
 		} else {
 			// It's a text description
-			fmt.Printf("  Using text description for outfit: %s\n", config.OutfitRef)
+			output.Progress.Printf("  Using text description for outfit: %s\n", config.OutfitRef)
 			components.Outfit = &models.ComponentData{
 				Type:        "outfit",
 				Description: config.OutfitRef,
+				Text:        config.OutfitRef,
 				JSONData:    nil,
 				ImagePath:   "",
 			}
@@ -238,7 +466,7 @@ func (o *Orchestrator) analyzeModularComponents(config ModularConfig) (*models.M
 	// Analyze over-outfit (layered on top)
 	if config.OverOutfitRef != "" {
 		if isFilePath(config.OverOutfitRef) {
-			fmt.Printf("  Analyzing over-outfit from: %s\n", filepath.Base(config.OverOutfitRef))
+			output.Progress.Printf("  Analyzing over-outfit from: %s\n", filepath.Base(config.OverOutfitRef))
 
 			// Use modular outfit analyzer with exclusions for the over-outfit too
 			modularAnalyzer := analyzer.NewModularOutfitAnalyzer(o.client, excludeOpts)
@@ -249,54 +477,79 @@ func (o *Orchestrator) analyzeModularComponents(config ModularConfig) (*models.M
 
 			desc := o.extractOutfitDescription(data)
 			if config.Debug {
-				fmt.Printf("  DEBUG: Over-outfit description extracted: %s\n", desc)
+				output.Printf("  DEBUG: Over-outfit description extracted: %s\n", desc)
 			}
 			components.OverOutfit = &models.ComponentData{
 				Type:        "over_outfit",
 				Description: desc,
 				JSONData:    data,
 				ImagePath:   config.OverOutfitRef,
+				Filters:     exclusionFilters(excludeOpts),
 			}
 		} else {
 			// It's a text description
-			fmt.Printf("  Using text description for over-outfit: %s\n", config.OverOutfitRef)
+			output.Progress.Printf("  Using text description for over-outfit: %s\n", config.OverOutfitRef)
 			components.OverOutfit = &models.ComponentData{
 				Type:        "over_outfit",
 				Description: config.OverOutfitRef,
+				Text:        config.OverOutfitRef,
 				JSONData:    nil,
 				ImagePath:   "",
 			}
 		}
 	}
 
-	// Analyze style
-	if config.StyleRef != "" {
-		fmt.Printf("  Analyzing style from: %s\n", filepath.Base(config.StyleRef))
-		data, err := o.AnalyzeImage("visual_style", config.StyleRef)
+	// Analyze style; a description bypasses the analyzer and goes into the
+	// style section as it is
+	if isStyleText(config.StyleRef) && !config.StyleBlend {
+		components.Style = o.textComponent("visual_style", config.StyleRef, config)
+	} else if config.StyleRef != "" {
+		var data json.RawMessage
+		var err error
+		if config.StyleBlend {
+			data, err = o.blendStyles(config.StyleRef)
+		} else {
+			output.Progress.Printf("  Analyzing style from: %s\n", filepath.Base(config.StyleRef))
+			data, err = o.AnalyzeImage("visual_style", config.StyleRef)
+		}
 		if err != nil {
 			return nil, fmt.Errorf("failed to analyze style: %w", err)
 		}
 
-		desc := o.extractStyleDescription(data)
+		fields, filters := registeredStyleExclusions(config)
+		exclude := styleExclusions{Pose: config.PoseRef != "", Background: config.BackgroundRef != "", Fields: fields}
+		desc := o.extractStyleDescription(data, exclude)
 		components.Style = &models.ComponentData{
 			Type:        "visual_style",
 			Description: desc,
 			JSONData:    data,
 			ImagePath:   config.StyleRef,
 		}
+		if config.StyleBlend {
+			components.Style.Type = "style_blend"
+			components.Style.ImagePath = ""
+			components.Style.Text = config.StyleRef
+		}
+		if exclude.Pose {
+			components.Style.Filters = append(components.Style.Filters, poseRemovedFilter)
+		}
+		if exclude.Background {
+			components.Style.Filters = append(components.Style.Filters, backgroundRemovedFilter)
+		}
+		components.Style.Filters = append(components.Style.Filters, filters...)
 	}
 
 	// Analyze hair style
 	if config.HairStyleRef != "" {
 		if isFilePath(config.HairStyleRef) {
-			fmt.Printf("  Analyzing hair style from: %s\n", filepath.Base(config.HairStyleRef))
+			output.Progress.Printf("  Analyzing hair style from: %s\n", filepath.Base(config.HairStyleRef))
 
 			// Check if it's cached
-			if cache, exists := o.caches["hair_style"]; exists && o.enableCache {
+			if cache := o.cacheFor("hair_style"); cache != nil && o.enableCache {
 				if cachedData, found := cache.Get("hair_style", config.HairStyleRef); found {
-					fmt.Printf("    Using cached hair style analysis\n")
+					output.Detail.Printf("    Using cached hair style analysis\n")
 					if config.Debug {
-						fmt.Printf("    DEBUG: Cached hair style data: %s\n", string(cachedData))
+						output.Printf("    DEBUG: Cached hair style data: %s\n", string(cachedData))
 					}
 				}
 			}
@@ -308,8 +561,8 @@ func (o *Orchestrator) analyzeModularComponents(config ModularConfig) (*models.M
 
 			desc := o.extractHairStyleDescription(data)
 			if config.Debug {
-				fmt.Printf("  DEBUG: Raw hair style JSON: %s\n", string(data))
-				fmt.Printf("  DEBUG: Hair style description extracted: %s\n", desc)
+				output.Printf("  DEBUG: Raw hair style JSON: %s\n", string(data))
+				output.Printf("  DEBUG: Hair style description extracted: %s\n", desc)
 			}
 			components.HairStyle = &models.ComponentData{
 				Type:        "hair_style",
@@ -319,20 +572,14 @@ func (o *Orchestrator) analyzeModularComponents(config ModularConfig) (*models.M
 			}
 		} else {
 			// It's a text description
-			fmt.Printf("  Using text description for hair style: %s\n", config.HairStyleRef)
-			components.HairStyle = &models.ComponentData{
-				Type:        "hair_style",
-				Description: config.HairStyleRef,
-				JSONData:    nil,
-				ImagePath:   "",
-			}
+			components.HairStyle = o.textComponent("hair_style", config.HairStyleRef, config)
 		}
 	}
 
 	// Analyze hair color
 	if config.HairColorRef != "" {
 		if isFilePath(config.HairColorRef) {
-			fmt.Printf("  Analyzing hair color from: %s\n", filepath.Base(config.HairColorRef))
+			output.Progress.Printf("  Analyzing hair color from: %s\n", filepath.Base(config.HairColorRef))
 			data, err := o.AnalyzeImage("hair_color", config.HairColorRef)
 			if err != nil {
 				return nil, fmt.Errorf("failed to analyze hair color: %w", err)
@@ -347,20 +594,14 @@ func (o *Orchestrator) analyzeModularComponents(config ModularConfig) (*models.M
 			}
 		} else {
 			// It's a text description
-			fmt.Printf("  Using text description for hair color: %s\n", config.HairColorRef)
-			components.HairColor = &models.ComponentData{
-				Type:        "hair_color",
-				Description: config.HairColorRef,
-				JSONData:    nil,
-				ImagePath:   "",
-			}
+			components.HairColor = o.textComponent("hair_color", config.HairColorRef, config)
 		}
 	}
 
 	// Analyze makeup
 	if config.MakeupRef != "" {
 		if isFilePath(config.MakeupRef) {
-			fmt.Printf("  Analyzing makeup from: %s\n", filepath.Base(config.MakeupRef))
+			output.Progress.Printf("  Analyzing makeup from: %s\n", filepath.Base(config.MakeupRef))
 			data, err := o.AnalyzeImage("makeup", config.MakeupRef)
 			if err != nil {
 				return nil, fmt.Errorf("failed to analyze makeup: %w", err)
@@ -375,20 +616,14 @@ func (o *Orchestrator) analyzeModularComponents(config ModularConfig) (*models.M
 			}
 		} else {
 			// It's a text description
-			fmt.Printf("  Using text description for makeup: %s\n", config.MakeupRef)
-			components.Makeup = &models.ComponentData{
-				Type:        "makeup",
-				Description: config.MakeupRef,
-				JSONData:    nil,
-				ImagePath:   "",
-			}
+			components.Makeup = o.textComponent("makeup", config.MakeupRef, config)
 		}
 	}
 
 	// Analyze expression
 	if config.ExpressionRef != "" {
 		if isFilePath(config.ExpressionRef) {
-			fmt.Printf("  Analyzing expression from: %s\n", filepath.Base(config.ExpressionRef))
+			output.Progress.Printf("  Analyzing expression from: %s\n", filepath.Base(config.ExpressionRef))
 			data, err := o.AnalyzeImage("expression", config.ExpressionRef)
 			if err != nil {
 				return nil, fmt.Errorf("failed to analyze expression: %w", err)
@@ -402,58 +637,140 @@ func (o *Orchestrator) analyzeModularComponents(config ModularConfig) (*models.M
 				JSONData:    data,
 				ImagePath:   config.ExpressionRef,
 			}
+			if config.StyleRef != "" {
+				components.Expression.Filters = []string{gazeRemovedFilter}
+			}
 		} else {
 			// It's a text description
-			fmt.Printf("  Using text description for expression: %s\n", config.ExpressionRef)
-			components.Expression = &models.ComponentData{
-				Type:        "expression",
-				Description: config.ExpressionRef,
-				JSONData:    nil,
-				ImagePath:   "",
-			}
+			components.Expression = o.textComponent("expression", config.ExpressionRef, config)
 		}
 	}
 
 	// Analyze accessories
 	if config.AccessoriesRef != "" {
 		if isFilePath(config.AccessoriesRef) {
-			fmt.Printf("  Analyzing accessories from: %s\n", filepath.Base(config.AccessoriesRef))
+			output.Progress.Printf("  Analyzing accessories from: %s\n", filepath.Base(config.AccessoriesRef))
 			data, err := o.AnalyzeImage("accessories", config.AccessoriesRef)
 			if err != nil {
 				return nil, fmt.Errorf("failed to analyze accessories: %w", err)
 			}
 
-			desc := o.extractAccessoriesDescription(data)
+			desc, dropped := o.extractAccessoriesDescription(data, config.MaxAccessories)
 			components.Accessories = &models.ComponentData{
 				Type:        "accessories",
 				Description: desc,
 				JSONData:    data,
 				ImagePath:   config.AccessoriesRef,
 			}
+			if len(dropped) > 0 {
+				components.Accessories.Filters = []string{droppedAccessoriesFilter(dropped)}
+			}
 		} else {
 			// It's a text description
-			fmt.Printf("  Using text description for accessories: %s\n", config.AccessoriesRef)
+			output.Progress.Printf("  Using text description for accessories: %s\n", config.AccessoriesRef)
 			components.Accessories = &models.ComponentData{
 				Type:        "accessories",
 				Description: config.AccessoriesRef,
+				Text:        config.AccessoriesRef,
 				JSONData:    nil,
 				ImagePath:   "",
 			}
 		}
 	}
 
+	// Analyze pose
+	if config.PoseRef != "" {
+		if isFilePath(config.PoseRef) {
+			output.Progress.Printf("  Analyzing pose from: %s\n", filepath.Base(config.PoseRef))
+			data, err := o.AnalyzeImage("pose", config.PoseRef)
+			if err != nil {
+				return nil, fmt.Errorf("failed to analyze pose: %w", err)
+			}
+
+			desc := o.extractPoseDescription(data)
+			components.Pose = &models.ComponentData{
+				Type:        "pose",
+				Description: desc,
+				JSONData:    data,
+				ImagePath:   config.PoseRef,
+			}
+		} else {
+			// It's a text description
+			components.Pose = o.textComponent("pose", config.PoseRef, config)
+		}
+	}
+
+	// Analyze background
+	if config.BackgroundRef != "" {
+		if isFilePath(config.BackgroundRef) {
+			output.Progress.Printf("  Analyzing background from: %s\n", filepath.Base(config.BackgroundRef))
+			data, err := o.AnalyzeImage("background", config.BackgroundRef)
+			if err != nil {
+				return nil, fmt.Errorf("failed to analyze background: %w", err)
+			}
+
+			desc := o.extractBackgroundDescription(data)
+			components.Background = &models.ComponentData{
+				Type:        "background",
+				Description: desc,
+				JSONData:    data,
+				ImagePath:   config.BackgroundRef,
+			}
+		} else {
+			// It's a text description
+			components.Background = o.textComponent("background", config.BackgroundRef, config)
+		}
+	}
+
+	if err := o.analyzeRegisteredComponents(config, components); err != nil {
+		return nil, err
+	}
+	applyWeights(components, config.Weights)
+
+	// Make sure the outfit covers everything the style's framing will show
+	if components.Style != nil {
+		base := components.Outfit
+		if components.OverOutfit != nil {
+			base = components.OverOutfit // The over-outfit is the complete base layer
+		}
+		if base != nil {
+			var items []string
+			for _, c := range []*models.ComponentData{components.Outfit, components.OverOutfit} {
+				if c == nil {
+					continue
+				}
+				if c.JSONData != nil {
+					items = append(items, clothingItems(c.JSONData)...)
+				} else {
+					items = append(items, c.Description)
+				}
+			}
+			var added []string
+			base.Description, added = completeOutfitDescription(base.Description, items, components.Style.JSONData, config.OutfitCheck)
+			if len(added) > 0 {
+				base.Filters = append(base.Filters, "outfit-check added: "+strings.Join(added, ", "))
+			}
+		}
+	}
+
 	return components, nil
 }
 
 // analyzeWithCache analyzes an image using a custom analyzer with caching
 func (o *Orchestrator) analyzeWithCache(cacheType string, imagePath string, analyzer analyzer.Analyzer) (json.RawMessage, error) {
+	return o.analyzeThrough(cacheType, imagePath, func(cacheType, imagePath string) (json.RawMessage, error) {
+		return o.analyzeCustom(cacheType, imagePath, analyzer)
+	})
+}
+
+func (o *Orchestrator) analyzeCustom(cacheType string, imagePath string, analyzer analyzer.Analyzer) (json.RawMessage, error) {
 	// Try cache first
-	if cache, exists := o.caches[cacheType]; exists && o.enableCache {
+	if cache := o.cacheFor(cacheType); cache != nil && o.enableCache && !o.refreshCache {
 		if cached, found := cache.Get(cacheType, imagePath); found {
 			logger.Info("Using cached analysis",
 				"type", cacheType,
 				"file", filepath.Base(imagePath))
-			fmt.Printf("✓ Using cached %s analysis for %s\n", cacheType, filepath.Base(imagePath))
+			output.Detail.Printf("✓ Using cached %s analysis for %s\n", cacheType, filepath.Base(imagePath))
 			return cached, nil
 		}
 	}
@@ -465,24 +782,32 @@ func (o *Orchestrator) analyzeWithCache(cacheType string, imagePath string, anal
 	}
 
 	// Cache the result
-	if cache, exists := o.caches[cacheType]; exists && o.enableCache {
+	if cache := o.cacheFor(cacheType); cache != nil && o.enableCache && o.refreshCache {
+		cache.Replace(cacheType, imagePath, result)
+	} else if cache != nil && o.enableCache {
 		cache.Set(cacheType, imagePath, result)
 	}
 
 	return result, nil
 }
 
-// buildModularPrompt builds the generation prompt from components
-func (o *Orchestrator) buildModularPrompt(components *models.ModularComponents) string {
-	var parts []string
+// modularPromptData is what the modular template sees
+type modularPromptData struct {
+	*models.ModularComponents
+	POV    bool                  // The style is a first-person shot
+	Format generator.ImageFormat // Shape of the image (--aspect)
+	Keep   []string              // Traits of a registered subject that must not change
+	Avoid  []string              // Elements forbidden on top of the built-in exclusions
 
-	// Start with critical identity preservation instruction
-	parts = append(parts, "🔴 CRITICAL IDENTITY INSTRUCTION:")
-	parts = append(parts, "The person in the generated image MUST be the EXACT SAME INDIVIDUAL from the source portrait.")
-	parts = append(parts, "This is not about creating someone similar - it must be THEM, recognizable as the same person.")
-	parts = append(parts, "Preserve their exact facial features, bone structure, and identity throughout.")
-	parts = append(parts, "")
+	Emphasis map[string]string // Emphasis line of each weighted component, by name (see SplitWeight)
+	Sections []promptSection   // Registered components (see analyzer.Register), in registration order
+}
 
+// buildModularPrompt builds the generation prompt from components with the
+// "modular" template for an image of the given aspect ratio. Traits in keep
+// must not change; elements in avoid are forbidden on top of the built-in
+// exclusions.
+func (o *Orchestrator) buildModularPrompt(components *models.ModularComponents, aspect string, keep, avoid []string) (string, error) {
 	// Check if this is a POV/first-person style
 	isPOV := components.Style != nil && (
 		strings.Contains(strings.ToLower(components.Style.Description), "first-person") ||
@@ -490,220 +815,28 @@ func (o *Orchestrator) buildModularPrompt(components *models.ModularComponents)
 		strings.Contains(strings.ToLower(components.Style.Description), "pov") ||
 		strings.Contains(strings.ToLower(components.Style.Description), "extreme close-up on the subject's hands"))
 
-	// Only specify portrait format if no style is provided
-	// If style is provided, it controls the framing and composition
-	if isPOV {
-		parts = append(parts, "🚨 THIS IS A FIRST-PERSON POV SHOT - CRITICAL INSTRUCTIONS 🚨")
-		parts = append(parts, "")
-		parts = append(parts, "🔴 IDENTITY PRESERVATION: This is the SAME PERSON from the provided portrait.")
-		parts = append(parts, "Any visible reflections MUST show their EXACT facial features.")
-		parts = append(parts, "")
-		parts = append(parts, "1. FRAMING: Create a FIRST-PERSON PERSPECTIVE exactly as shown in the style image")
-		parts = append(parts, "2. The camera IS the subject's eyes - shoot FROM their viewpoint, not AT them")
-		parts = append(parts, "3. COPY THE EXACT FRAMING from the style image")
-		parts = append(parts, "")
-		parts = append(parts, "IMPORTANT: The person in the reference image IS the subject, but shown from THEIR OWN perspective:")
-		parts = append(parts, "- Their hands/arms in frame = the subject's own hands reaching forward")
-		parts = append(parts, "- If there's a mirror = show the subject's EXACT face/features reflected in it")
-		parts = append(parts, "- Preserve their facial features, hair, skin tone, and identity completely")
-		parts = append(parts, "- Apply their outfit to whatever body parts are visible in the POV framing")
-		parts = append(parts, "")
-	} else if components.Style != nil {
-		parts = append(parts, "⚠️ CRITICAL INSTRUCTION: Generate an image of THIS EXACT PERSON with the framing described below.")
-		parts = append(parts, "The subject's facial features and identity MUST be preserved exactly.")
-		parts = append(parts, "DO NOT create a portrait or full-body shot unless the style explicitly describes one.")
-		parts = append(parts, "The provided person is not just for reference - they ARE the subject.")
-		parts = append(parts, "If the style shows only legs, show ONLY legs (but they're still this person's legs).")
-		parts = append(parts, "If only arms, show ONLY arms (but they're still this person's arms).")
-		parts = append(parts, "")
-		parts = append(parts, "The style description below controls framing, but this remains the SAME PERSON.")
-	} else {
-		parts = append(parts, "Generate a professional 9:16 portrait photograph with the following specifications:")
-	}
-	parts = append(parts, "")
-
-	// Add outfit description
-	if components.Outfit != nil && components.OverOutfit != nil {
-		// Layered outfit: outer layer from main outfit + complete base outfit from --over-outfit
-		parts = append(parts, "LAYERED OUTFIT:")
-		parts = append(parts, "")
-		parts = append(parts, "COMPLETE BASE OUTFIT (all clothing worn underneath):")
-		parts = append(parts, components.OverOutfit.Description)  // --over-outfit provides the full base outfit
-		parts = append(parts, "")
-		parts = append(parts, "OUTER LAYER ONLY (jacket/coat worn over the base outfit):")
-		parts = append(parts, components.Outfit.Description)  // main outfit provides only the outer layer
-		parts = append(parts, "")
-		parts = append(parts, "IMPORTANT: The base outfit should be complete (shirt, pants/skirt, etc.), with the outer layer (jacket/coat) worn over it. Parts of the base outfit should be visible where the outer layer is open or doesn't cover (e.g., shirt collar, sleeves, pants/skirt).")
-		parts = append(parts, "")
-	} else if components.Outfit != nil {
-		// Single outfit
-		parts = append(parts, "OUTFIT:")
-		parts = append(parts, components.Outfit.Description)
-		parts = append(parts, "")
-	} else if components.OverOutfit != nil {
-		// Only over-outfit specified (treat as single outfit)
-		parts = append(parts, "OUTFIT:")
-		parts = append(parts, components.OverOutfit.Description)
-		parts = append(parts, "")
-	}
-
-	// Add hair style description
-	if components.HairStyle != nil {
-		// If no hair color is specified, make preservation VERY clear upfront
-		if components.HairColor == nil {
-			parts = append(parts, "⚠️ CRITICAL HAIR COLOR PRESERVATION ⚠️")
-			parts = append(parts, "DO NOT CHANGE THE SUBJECT'S HAIR COLOR! The subject's original hair color from the source portrait MUST be preserved EXACTLY.")
-			parts = append(parts, "If the subject has blonde hair, they MUST still have blonde hair in the result.")
-			parts = append(parts, "If the subject has red hair, they MUST still have red hair in the result.")
-			parts = append(parts, "If the subject has black hair, they MUST still have black hair in the result.")
-			parts = append(parts, "")
-		}
-
-		parts = append(parts, "HAIR STYLE (STRUCTURE/CUT/SHAPE ONLY - NOT COLOR):")
-		parts = append(parts, components.HairStyle.Description)
-
-		// Add another reminder if no color specified
-		if components.HairColor == nil {
-			parts = append(parts, "")
-			parts = append(parts, "REMINDER: Apply ONLY the hairstyle structure, cut, shape, and styling from the description above.")
-			parts = append(parts, "DO NOT change the hair color - keep the subject's ORIGINAL hair color from the source image.")
-			parts = append(parts, "The hair style description is about the CUT and STYLE only, not the color.")
-		}
-		parts = append(parts, "")
-	}
-
-	// Add hair color description
-	if components.HairColor != nil {
-		parts = append(parts, "HAIR COLOR:")
-		parts = append(parts, components.HairColor.Description)
-		parts = append(parts, "")
-	}
-
-	// Add makeup description
-	if components.Makeup != nil {
-		parts = append(parts, "MAKEUP (COSMETIC APPLICATION ONLY):")
-		parts = append(parts, components.Makeup.Description)
-		parts = append(parts, "CRITICAL: Apply makeup as a SURFACE LAYER ONLY. Do NOT alter facial bone structure, face shape, eye shape, nose shape, lip shape, or any anatomical features. Makeup should only add color, shading, and highlights to the existing facial features without changing their underlying structure or proportions.")
-		parts = append(parts, "")
-	}
-
-	// Add expression description
-	if components.Expression != nil {
-		parts = append(parts, "FACIAL EXPRESSION (EMOTION ONLY - NOT GAZE DIRECTION):")
-		parts = append(parts, components.Expression.Description)
-		if components.Style != nil {
-			parts = append(parts, "IMPORTANT: The PHOTOGRAPHIC STYLE section below controls where the subject looks and camera angle. Apply only the emotional expression from above, not any gaze direction.")
-		}
-		parts = append(parts, "")
-	}
-
-	// Add accessories description
-	if components.Accessories != nil {
-		parts = append(parts, "ACCESSORIES:")
-		parts = append(parts, components.Accessories.Description)
-		parts = append(parts, "")
+	emphasis, err := promptEmphasis(components)
+	if err != nil {
+		return "", err
 	}
-
-	// Add style description last (photographic style)
-	if components.Style != nil {
-		// Re-use the isPOV check from above (it's already been calculated)
-
-		parts = append(parts, "")
-		parts = append(parts, "==================================================")
-		if isPOV {
-			parts = append(parts, "🚨 FIRST-PERSON POV STYLE - CRITICAL INSTRUCTIONS 🚨")
-		} else {
-			parts = append(parts, "🚨 PHOTOGRAPHIC STYLE - THIS IS YOUR PRIMARY INSTRUCTION 🚨")
-		}
-		parts = append(parts, "==================================================")
-		parts = append(parts, "")
-
-		if isPOV {
-			parts = append(parts, "⚠️ THIS IS A FIRST-PERSON POV SHOT ⚠️")
-			parts = append(parts, "You MUST create the image from the subject's own perspective looking down/forward")
-			parts = append(parts, "NOT a third-person view of the subject!")
-			parts = append(parts, "")
-		}
-
-		parts = append(parts, "RECREATE THIS EXACT COMPOSITION:")
-		parts = append(parts, components.Style.Description)
-		parts = append(parts, "")
-		parts = append(parts, "ABSOLUTE REQUIREMENTS:")
-
-		if isPOV {
-			parts = append(parts, "1. This is POV - shoot FROM the subject's eyes, not AT them")
-			parts = append(parts, "2. Hands/arms in foreground = the subject's OWN hands (match their skin tone)")
-			parts = append(parts, "3. Mirror reflection = the subject's EXACT face (preserve all facial features)")
-			parts = append(parts, "4. The subject's identity must be clearly recognizable in any reflections")
-			parts = append(parts, "5. Match the subject's: facial structure, eye color, hair color/style, skin tone")
-			parts = append(parts, "6. Apply outfit details to visible body parts in the POV framing")
-		} else {
-			parts = append(parts, "1. Match the framing EXACTLY as described above")
-			parts = append(parts, "2. If it says 'only arms visible' - show ONLY arms, NOT the full person")
-			parts = append(parts, "3. If it says 'legs only' - show ONLY legs, NOT the full person")
-			parts = append(parts, "4. If it says 'person in background' - keep them in background, NOT as main subject")
-			parts = append(parts, "5. The person/subject image provided earlier is ONLY for outfit/appearance details")
-			parts = append(parts, "6. DO NOT create a portrait unless the style explicitly describes a portrait")
-		}
-
-		parts = append(parts, "")
-		parts = append(parts, "THINK OF THIS AS: Taking the outfit/appearance from the person image and applying it to")
-		parts = append(parts, "the EXACT framing/composition/perspective described in the style above.")
-		parts = append(parts, "")
-		parts = append(parts, "==================================================")
-		parts = append(parts, "")
-	}
-
-	// Add standard requirements
-	parts = append(parts, "TECHNICAL REQUIREMENTS:")
-	if isPOV {
-		parts = append(parts, "- 🔴 CRITICAL: This is the SAME PERSON from the source portrait")
-		parts = append(parts, "- Mirror reflections must show their EXACT face (same eyes, nose, mouth, bone structure)")
-		parts = append(parts, "- This person must be immediately recognizable as the individual from the reference")
-		parts = append(parts, "- Visible hands/arms must match the subject's skin tone and body type")
-		parts = append(parts, "- Maintain the subject's exact hair color, style, and facial structure")
-	} else if components.Style != nil {
-		parts = append(parts, "- 🔴 CRITICAL: This must be the EXACT SAME PERSON from the source portrait")
-		parts = append(parts, "- If face is visible, it must show their IDENTICAL facial features (not similar, IDENTICAL)")
-		parts = append(parts, "- Their identity must be unmistakably preserved - same eyes, nose, mouth, face shape")
-		parts = append(parts, "- Apply the clothing to THIS specific person, not a generic model")
-	} else {
-		parts = append(parts, "- 🔴 CRITICAL: Preserve the EXACT identity of the person from the source portrait")
-		parts = append(parts, "- This must be recognizably the SAME individual, not someone who looks similar")
-		parts = append(parts, "- Keep their exact facial features: eyes, nose, mouth, face shape, bone structure")
-	}
-	// Add makeup preservation note
-	if components.Makeup != nil {
-		parts = append(parts, "- PRESERVE facial bone structure, face shape, and all anatomical features - makeup is cosmetic only")
-	}
-	// Add hair color preservation if only style is specified
-	if components.HairStyle != nil && components.HairColor == nil {
-		parts = append(parts, "- ⚠️ CRITICAL: PRESERVE the subject's ORIGINAL HAIR COLOR exactly as shown in the source portrait")
-		parts = append(parts, "- The subject's hair color MUST NOT change - if they have blonde hair, keep it blonde")
-		parts = append(parts, "- Apply ONLY the hair CUT/STYLE/SHAPE, NOT the color")
-	}
-	parts = append(parts, "- Professional 9:16 vertical portrait format")
-	parts = append(parts, "- Waist-up framing showing outfit details")
-	parts = append(parts, "- Natural, professional pose")
-	parts = append(parts, "- High quality, detailed rendering")
-	parts = append(parts, "")
-	parts = append(parts, "IMPORTANT: Each component specified above should be applied independently without influencing other components.")
-
-	// Add extra emphasis on facial preservation when makeup is involved
-	if components.Makeup != nil {
-		parts = append(parts, "")
-		parts = append(parts, "FACIAL STRUCTURE PRESERVATION:")
-		parts = append(parts, "The subject's facial anatomy, bone structure, and features must remain EXACTLY as in the original portrait.")
-		parts = append(parts, "Makeup is ONLY a cosmetic surface application - like painting on skin.")
-		parts = append(parts, "Do NOT reshape eyes, nose, lips, jawline, or any facial features.")
-	}
-
-	return strings.Join(parts, "\n")
+	sections, err := registeredSections(components, emphasis)
+	if err != nil {
+		return "", err
+	}
+	return prompts.Render("modular", modularPromptData{
+		ModularComponents: components,
+		POV:               isPOV,
+		Format:            generator.NewImageFormat(aspect),
+		Keep:              keep,
+		Avoid:             avoid,
+		Emphasis:          emphasis,
+		Sections:          sections,
+	})
 }
 
 // generateOutputDir creates a timestamped output directory
 func generateOutputDir() string {
-	baseDir := "output"
+	baseDir := workspace.OutputPath()
 	dateDir := time.Now().Format("2006-01-02")
 	timeDir := time.Now().Format("150405")
 
@@ -8,51 +8,148 @@ import (
 	"encoding/json"
 	"fmt"
 	"img-cli/pkg/analyzer"
+	"img-cli/pkg/c2pa"
 	"img-cli/pkg/cache"
-	"img-cli/pkg/generator"
+	"img-cli/pkg/config"
+	"img-cli/pkg/cost"
 	"img-cli/pkg/gemini"
+	"img-cli/pkg/generator"
 	"img-cli/pkg/logger"
+	"img-cli/pkg/models"
+	"img-cli/pkg/output"
+	"img-cli/pkg/validator"
+	"img-cli/pkg/workspace"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 )
 
 type Orchestrator struct {
-	client      *gemini.Client
-	analyzers   map[string]analyzer.Analyzer
-	generators  map[string]generator.Generator
-	caches      map[string]*cache.Cache // Separate cache for each type
-	enableCache bool
+	client       *gemini.Client
+	analyzers    map[string]analyzer.Analyzer
+	generators   map[string]generator.Generator
+	upscalers    map[string]generator.Upscaler // --upscaler name -> upscaler
+	caches       map[string]*cache.Cache       // Separate cache for each type
+	analyzersMu  sync.RWMutex                  // Guards analyzers and caches, which workers add to
+	enableCache  bool
+	refreshCache bool                // Analyze again and overwrite cached results (see SetCacheRefresh)
+	reviewFlags  map[string][]string // Output path -> failed checks
+	reviewMu     sync.Mutex
+
+	textEnhancer *analyzer.TextEnhancer
+	enhancedText map[string]json.RawMessage // Text expansions and style blends made during this run
+	enhancedMu   sync.Mutex
+	styleBlender *analyzer.StyleBlender
+
+	consistency   []ConsistencyScore // Variation stability per combination
+	consistencyMu sync.Mutex
+	failures      []Failure // Generations that produced no image
+	failuresMu    sync.Mutex
+	manifestMu    sync.Mutex        // Serializes manifest.json updates
+	existingOnce  sync.Once         // Loads the --skip-existing index
+	existing      map[string]string // Combination hash -> existing image
+
+	dryRun        bool     // Plan generations without sending them (see WithDryRun)
+	dryRunPrompts []string // Prompt files written by the dry run
+	dryRunMu      sync.Mutex
+
+	analyzerMiddleware  []AnalyzerMiddleware
+	generatorMiddleware []GeneratorMiddleware
+
+	signer *c2pa.Signer // Embeds content credentials in outputs when set
+
+	altTextWriter *analyzer.AltTextWriter
+	refineAltText bool // Polish drafted alt text with a text request
+
+	outputReviewer    *analyzer.OutputReviewer
+	identityValidator validator.IdentityValidator // Scores outputs against the subject (see WithIdentityValidator)
+	judge             *validator.Judge
+
+	progress *progressTracker // Progress of batch runs (see WithProgress)
+	spend    *cost.Meter      // Keeps generations under the budget cap
 }
 
-func NewOrchestrator(apiKey string) *Orchestrator {
+func NewOrchestrator(apiKey string, opts ...Option) *Orchestrator {
 	client := gemini.NewClient(apiKey)
 
 	o := &Orchestrator{
 		client:      client,
 		analyzers:   make(map[string]analyzer.Analyzer),
 		generators:  make(map[string]generator.Generator),
+		upscalers:   make(map[string]generator.Upscaler),
 		caches:      make(map[string]*cache.Cache),
 		enableCache: true,
+		reviewFlags: make(map[string][]string),
 	}
 
-	// Initialize separate caches for different types
-	o.caches["outfit"] = cache.NewCacheForType("outfit", 0)
-	o.caches["visual_style"] = cache.NewCacheForType("visual_style", 0)
-	o.caches["art_style"] = cache.NewCacheForType("art_style", 0)
+	o.textEnhancer = analyzer.NewTextEnhancer(client)
+	o.enhancedText = make(map[string]json.RawMessage)
+	o.styleBlender = analyzer.NewStyleBlender(client)
+	o.altTextWriter = analyzer.NewAltTextWriter(client)
+	o.outputReviewer = analyzer.NewOutputReviewer(client)
+	o.identityValidator = validator.NewFaceJudge(client)
+	o.judge = validator.NewJudge(client)
+	o.progress = newProgressTracker(&barProgress{w: output.Writer()})
+	o.spend = cost.NewMeter(cost.Limit())
 
-	o.analyzers["outfit"] = analyzer.NewOutfitAnalyzer(client)
-	o.analyzers["visual_style"] = analyzer.NewVisualStyleAnalyzer(client)
-	o.analyzers["art_style"] = analyzer.NewArtStyleAnalyzer(client)
+	// Analyzers and their caches are added on first use (see addAnalyzer)
 
 	o.generators["outfit"] = generator.NewOutfitGenerator(client)
 	o.generators["style_transfer"] = generator.NewStyleTransferGenerator(client)
 	o.generators["combined"] = generator.NewCombinedGenerator(client)
 	o.generators["style_guide"] = generator.NewStyleGuideGenerator(client)
+	o.generators["art_style"] = generator.NewArtStyleGenerator(client)
+	o.generators["inpaint"] = generator.NewInpaintGenerator(client)
+
+	o.upscalers[UpscalerRegenerate] = generator.NewRegenerateUpscaler(client)
+	o.upscalers[UpscalerResample] = generator.NewResampleUpscaler()
+
+	for _, opt := range opts {
+		opt(o)
+	}
 
 	return o
 }
 
+// Option configures an Orchestrator
+type Option func(*Orchestrator)
+
+// WithLimits replaces the default API rate limits and concurrency caps
+func WithLimits(limits *config.LimitsConfig) Option {
+	return func(o *Orchestrator) {
+		o.client.SetLimits(limits)
+	}
+}
+
+// WithCache enables or disables the analysis cache
+func WithCache(enabled bool) Option {
+	return func(o *Orchestrator) {
+		o.enableCache = enabled
+	}
+}
+
+// WithSigner embeds signed C2PA content credentials in every generated image
+func WithSigner(signer *c2pa.Signer) Option {
+	return func(o *Orchestrator) {
+		o.signer = signer
+	}
+}
+
+// WithUpscaler registers an upscaler under a name that PostOptions.Upscaler
+// can select, replacing a bundled one of the same name. Upscalers other than
+// resample count as billed generations in cost estimates.
+func WithUpscaler(name string, upscaler generator.Upscaler) Option {
+	return func(o *Orchestrator) {
+		o.upscalers[name] = upscaler
+	}
+}
+
+// Throughput reports the request rates the client achieved against the API
+func (o *Orchestrator) Throughput() []gemini.Throughput {
+	return o.client.Throughput()
+}
+
 // SetCacheEnabled enables or disables caching
 func (o *Orchestrator) SetCacheEnabled(enabled bool) {
 	o.enableCache = enabled
@@ -60,14 +157,15 @@ func (o *Orchestrator) SetCacheEnabled(enabled bool) {
 
 // GetCacheForType returns the cache for a specific analyzer type
 func (o *Orchestrator) GetCacheForType(analyzerType string) *cache.Cache {
-	return o.caches[analyzerType]
+	o.addAnalyzer(analyzerType)
+	return o.cacheFor(analyzerType)
 }
 
-// AnalyzeAll analyzes an image with all available analyzers
+// AnalyzeAll analyzes an image with every component analyzer in AnalysisTypes
 func (o *Orchestrator) AnalyzeAll(imagePath string) (map[string]json.RawMessage, error) {
 	results := make(map[string]json.RawMessage)
 
-	for analyzerType := range o.analyzers {
+	for _, analyzerType := range AnalysisTypes() {
 		result, err := o.AnalyzeImage(analyzerType, imagePath)
 		if err != nil {
 			return nil, fmt.Errorf("failed to analyze %s: %w", analyzerType, err)
@@ -80,13 +178,18 @@ func (o *Orchestrator) AnalyzeAll(imagePath string) (map[string]json.RawMessage,
 
 // AnalyzeImage analyzes an image using the specified analyzer
 func (o *Orchestrator) AnalyzeImage(analyzerType string, imagePath string) (json.RawMessage, error) {
-	analyzer, ok := o.analyzers[analyzerType]
+	return o.analyzeThrough(analyzerType, imagePath, o.analyzeImage)
+}
+
+func (o *Orchestrator) analyzeImage(analyzerType string, imagePath string) (json.RawMessage, error) {
+	o.addAnalyzer(analyzerType)
+	analyzer, ok := o.analyzerFor(analyzerType)
 	if !ok {
 		return nil, fmt.Errorf("analyzer not found: %s", analyzerType)
 	}
 
 	// Get the appropriate cache for this analyzer type
-	c := o.caches[analyzerType]
+	c := o.cacheFor(analyzerType)
 	if c == nil || !o.enableCache {
 		// No cache configured or caching disabled
 		return analyzer.Analyze(imagePath)
@@ -94,12 +197,12 @@ func (o *Orchestrator) AnalyzeImage(analyzerType string, imagePath string) (json
 
 	// Try to get from cache
 	cached, found := c.Get(analyzerType, imagePath)
-	if found {
+	if found && !o.refreshCache {
 		logger.Info("Using cached analysis",
 			"type", analyzerType,
 			"file", filepath.Base(imagePath))
 		// Also print to console for visibility
-		fmt.Printf("✓ Using cached %s analysis for %s\n", analyzerType, filepath.Base(imagePath))
+		output.Detail.Printf("✓ Using cached %s analysis for %s\n", analyzerType, filepath.Base(imagePath))
 
 		// Check if cached data is the raw analysis or wrapped in a cache entry
 		// First try to parse as cache entry structure
@@ -137,7 +240,9 @@ func (o *Orchestrator) AnalyzeImage(analyzerType string, imagePath string) (json
 	}
 
 	cacheData, err := json.Marshal(cacheEntry)
-	if err == nil {
+	if err == nil && o.refreshCache {
+		c.Replace(analyzerType, imagePath, cacheData)
+	} else if err == nil {
 		c.Set(analyzerType, imagePath, cacheData)
 	}
 
@@ -178,6 +283,10 @@ func extractDescriptionFromAnalysis(analyzerType string, analysis json.RawMessag
 
 // GenerateImage generates an image using the specified generator
 func (o *Orchestrator) GenerateImage(generatorType string, params generator.GenerateParams) (*generator.GenerateResult, error) {
+	return o.generateThrough(generatorType, params, o.generateImage)
+}
+
+func (o *Orchestrator) generateImage(generatorType string, params generator.GenerateParams) (*generator.GenerateResult, error) {
 	gen, ok := o.generators[generatorType]
 	if !ok {
 		return nil, fmt.Errorf("generator not found: %s", generatorType)
@@ -192,6 +301,21 @@ func (o *Orchestrator) RunWorkflow(workflow string, imagePath string, options Wo
 		return nil, fmt.Errorf("unsupported workflow: %s (only 'outfit-swap' is supported)", workflow)
 	}
 
+	// Validate subject photos up front instead of failing combination by combination
+	if !options.SkipPreflight {
+		subjects := options.TargetImages
+		if len(subjects) == 0 && options.TargetImage != "" {
+			subjects = []string{options.TargetImage}
+		}
+		if len(subjects) > 0 {
+			usable, err := o.preflightSubjects(subjects)
+			if err != nil {
+				return nil, err
+			}
+			options.TargetImages = usable
+		}
+	}
+
 	// Check if modular components are specified
 	if hasModularComponents(options) {
 		logger.Info("Using modular workflow due to modular components")
@@ -219,9 +343,9 @@ func (o *Orchestrator) runOutfitSwapWorkflow(outfitSourcePath string, options Wo
 	}
 
 	if len(targetImages) == 1 {
-		fmt.Printf("Applying to subject: %s\n", filepath.Base(targetImages[0]))
+		output.Progress.Printf("Applying to subject: %s\n", filepath.Base(targetImages[0]))
 	} else {
-		fmt.Printf("Applying to %d subjects\n", len(targetImages))
+		output.Progress.Printf("Applying to %d subjects\n", len(targetImages))
 	}
 
 	// Determine number of variations to generate
@@ -234,20 +358,35 @@ func (o *Orchestrator) runOutfitSwapWorkflow(outfitSourcePath string, options Wo
 	var outfitFiles []string
 	if outfitSourcePath == "" && options.OutfitText != "" {
 		outfitFiles = []string{""} // Empty string signals text mode
-		fmt.Printf("Using text outfit description\n")
+		output.Progress.Printf("Using text outfit description\n")
 	} else if outfitSourcePath != "" {
 		var err error
 		outfitFiles, err = collectImageFiles(outfitSourcePath)
 		if err != nil {
 			return nil, err
 		}
+		outfitFiles = dedupeByContent(outfitFiles, "outfit")
 		if len(outfitFiles) > 1 {
-			fmt.Printf("Found %d outfit images in directory\n", len(outfitFiles))
+			output.Progress.Printf("Found %d outfit images in directory\n", len(outfitFiles))
 		}
 	} else {
 		return nil, fmt.Errorf("no outfit source provided: either specify an outfit image path or use --outfit-text")
 	}
 
+	// Let the user narrow the matrix before anything is estimated or confirmed
+	var picked *pickedCombinations
+	if options.Pick {
+		chosen, ok, err := pickCombinations(outfitSwapCombinations(targetImages, outfitFiles, options), options)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			output.Progress.Println("❌ Workflow cancelled by user")
+			return result, nil
+		}
+		picked = newPickedCombinations(chosen)
+	}
+
 	// Pre-count style files for accurate cost estimation
 	// We need to determine the style source to count properly
 	var numStyles int
@@ -257,7 +396,8 @@ func (o *Orchestrator) runOutfitSwapWorkflow(outfitSourcePath string, options Wo
 			// If we can't count styles, assume 1
 			numStyles = 1
 		} else {
-			numStyles = len(styleFiles)
+			unique, _ := uniqueByContent(styleFiles)
+			numStyles = len(unique)
 		}
 	} else {
 		// When no style specified or using outfit as style, count as 1
@@ -271,221 +411,495 @@ func (o *Orchestrator) runOutfitSwapWorkflow(outfitSourcePath string, options Wo
 		numStyles,
 		variations,
 	)
+	// Combinations of several variations each get one consistency check
+	scoredCombinations := 0
+	if variations > 1 {
+		scoredCombinations = len(targetImages) * len(outfitFiles) * numStyles
+	}
+	if picked != nil {
+		estimatedImages, scoredCombinations = 0, 0
+		for _, n := range picked.variations {
+			estimatedImages += n
+			if n > 1 {
+				scoredCombinations++
+			}
+		}
+	}
+	generations := estimatedImages
+	estimatedImages += options.Chain.ExtraImages(generations) + options.Post.ExtraImages(generations) + options.Verify.ExtraImages(generations)
+	calls := ExtraCalls(generations, o.refineAltText) + options.Verify.ExtraCalls(generations, scoredCombinations)
 
-	// Check cost and get user confirmation if needed
-	if err := checkWorkflowCost("outfit-swap", estimatedImages, options.SkipCostConfirm); err != nil {
+	// Check cost and get user confirmation if needed; picking already showed it
+	if err := checkWorkflowCost("outfit-swap", estimatedImages, calls, options.SkipCostConfirm, options.Pick, o.dryRun); err != nil {
 		return nil, err
 	}
 
-	// Process each subject
+	chain, err := o.prepareChain(options.Chain)
+	if err != nil {
+		return nil, err
+	}
+
+	// Images and failures of this run for the manifest
+	var manifest []ManifestImage
+	failuresBefore := len(o.Failures())
+
+	// Images an interrupted run in the same output directory already generated
+	var resumed resumeIndex
+	if options.Resume {
+		resumed, err = loadResumeIndex(options.OutputDir)
+		if err != nil {
+			return nil, err
+		}
+		output.Progress.Printf("\n♻️  Resuming %s: combinations with all their variations are skipped\n", options.OutputDir)
+	}
+
+	// Process each subject, queueing generations to run up to options.Parallel at once
+	o.progress.begin(generations)
+	defer o.progress.end()
+	dl := newDeadline(options.MaxDuration)
+	queue := newGenerationQueue(options.Parallel)
+	var remaining, unstarted []Combination
+subjects:
 	for subjectIndex, targetImage := range targetImages {
 		if len(targetImages) > 1 {
-			fmt.Printf("\n=== Subject %d/%d: %s ===\n", subjectIndex+1, len(targetImages), filepath.Base(targetImage))
+			output.Progress.Printf("\n=== Subject %d/%d: %s ===\n", subjectIndex+1, len(targetImages), filepath.Base(targetImage))
+		}
+
+		// Traits a registered subject must keep whatever the outfit and style
+		var keep []string
+		if profile := workspace.SubjectProfile(targetImage); profile != nil {
+			keep = profile.Keep
 		}
 
 		// Process each outfit for this subject
 		for outfitIndex, outfitPath := range outfitFiles {
-		var outfitPrompt string
-		var hairDataFromOutfit json.RawMessage
-		var outfitSourceName string
-
-		// Handle text outfit vs image outfit
-		if outfitPath == "" && options.OutfitText != "" {
-			// Text outfit mode
-			outfitPrompt = options.OutfitText
-			outfitSourceName = "text_outfit"
-			if len(outfitFiles) > 1 {
-				fmt.Printf("\n[Outfit %d/%d] Using text description\n", outfitIndex+1, len(outfitFiles))
+			if !dl.allowsNext() || options.stopRequested() {
+				unstarted = remainingOutfitPairs(targetImages, outfitFiles, subjectIndex, outfitIndex, options.StyleReference)
+				break subjects
 			}
-
-			result.Steps = append(result.Steps, StepResult{
-				Type:    "text_outfit",
-				Name:    "outfit_description",
-				Message: outfitPrompt,
-			})
-		} else {
-			// Image outfit mode
-			outfitSourceName = strings.TrimSuffix(filepath.Base(outfitPath), filepath.Ext(outfitPath))
-			if len(outfitFiles) > 1 {
-				fmt.Printf("\n[Outfit %d/%d] Processing: %s\n", outfitIndex+1, len(outfitFiles), filepath.Base(outfitPath))
-			} else {
-				fmt.Printf("Analyzing outfit from: %s\n", filepath.Base(outfitPath))
+			pickedOutfit := outfitPath
+			if pickedOutfit == "" {
+				pickedOutfit = options.OutfitText
 			}
-
-			// Analyze outfit from the source image
-			outfitData, err := o.AnalyzeImage("outfit", outfitPath)
-			if err != nil {
-				fmt.Printf("  Warning: Failed to analyze outfit %s: %v\n", filepath.Base(outfitPath), err)
+			if !picked.pair(targetImage, pickedOutfit) {
 				continue
 			}
 
-			result.Steps = append(result.Steps, StepResult{
-				Type: "analysis",
-				Name: "outfit_source",
-				Data: outfitData,
-			})
+			var outfitPrompt string
+			var outfitItems []string
+			var outfitFilters []string
+			var hairDataFromOutfit json.RawMessage
+			var outfitSourceName string
+
+			// Handle text outfit vs image outfit
+			if outfitPath == "" && options.OutfitText != "" {
+				// Text outfit mode
+				outfitPrompt = options.OutfitText
+				outfitSourceName = "text_outfit"
+				if len(outfitFiles) > 1 {
+					output.Progress.Printf("\n[Outfit %d/%d] Using text description\n", outfitIndex+1, len(outfitFiles))
+				}
 
-			// Extract outfit description and hair data
-			outfitPrompt, hairDataFromOutfit = extractOutfitPromptAndHair(outfitData)
+				result.Steps = append(result.Steps, StepResult{
+					Type:    "text_outfit",
+					Name:    "outfit_description",
+					Message: outfitPrompt,
+				})
+			} else {
+				// Image outfit mode
+				outfitSourceName = strings.TrimSuffix(filepath.Base(outfitPath), filepath.Ext(outfitPath))
+				if len(outfitFiles) > 1 {
+					output.Progress.Printf("\n[Outfit %d/%d] Processing: %s\n", outfitIndex+1, len(outfitFiles), filepath.Base(outfitPath))
+				} else {
+					output.Progress.Printf("Analyzing outfit from: %s\n", filepath.Base(outfitPath))
+				}
 
-			// Debug output
-			if options.DebugPrompt {
-				fmt.Printf("\n[DEBUG] Outfit prompt built from analysis:\n%s\n\n", outfitPrompt)
-			}
-		}
+				// Analyze outfit from the source image
+				outfitData, err := o.AnalyzeImage("outfit", outfitPath)
+				if err != nil {
+					output.Progress.Printf("  Warning: Failed to analyze outfit %s: %v\n", filepath.Base(outfitPath), err)
+					o.progress.skip(numStyles * variations)
+					continue
+				}
 
-		// Determine style source - use style-ref if provided, otherwise use the outfit source
-		styleSourcePath := options.StyleReference
-		if styleSourcePath == "" && outfitPath != "" {
-			// Only use outfit source for style if we have an outfit image
-			styleSourcePath = outfitPath
-			fmt.Printf("  Using same image for style: %s\n", filepath.Base(outfitPath))
-		} else if styleSourcePath != "" {
-			fmt.Printf("  Using style from: %s\n", filepath.Base(styleSourcePath))
-		}
+				result.Steps = append(result.Steps, StepResult{
+					Type: "analysis",
+					Name: "outfit_source",
+					Data: outfitData,
+				})
+
+				// Extract outfit description and hair data
+				var droppedAccessories []string
+				outfitPrompt, hairDataFromOutfit, droppedAccessories = extractOutfitPromptAndHair(outfitData, options.MaxAccessories)
+				if len(droppedAccessories) > 0 {
+					outfitFilters = append(outfitFilters, droppedAccessoriesFilter(droppedAccessories))
+				}
+				outfitItems = clothingItems(outfitData)
 
-		// Determine hair source and data
-		var hairData json.RawMessage
-		var hairSourceName string
-		if options.HairReference == "USE_OUTFIT_REF" {
-			// Use hair from outfit reference
-			hairData = hairDataFromOutfit
-			if outfitPath != "" {
-				hairSourceName = strings.TrimSuffix(filepath.Base(outfitPath), filepath.Ext(outfitPath))
+				// Debug output
+				if options.DebugPrompt {
+					output.Printf("\n[DEBUG] Outfit prompt built from analysis:\n%s\n\n", outfitPrompt)
+				}
 			}
-			if hairData != nil {
-				fmt.Printf("  Using hair from outfit reference\n")
+
+			// Determine style source - use style-ref if provided, otherwise use the outfit source
+			styleSourcePath := options.StyleReference
+			if styleSourcePath == "" && outfitPath != "" {
+				// Only use outfit source for style if we have an outfit image
+				styleSourcePath = outfitPath
+				output.Progress.Printf("  Using same image for style: %s\n", filepath.Base(outfitPath))
+			} else if styleSourcePath != "" {
+				output.Progress.Printf("  Using style from: %s\n", filepath.Base(styleSourcePath))
 			}
-		} else if options.HairReference != "" {
-		// Analyze hair from specified reference image
-		fmt.Printf("  Analyzing hair from: %s\n", filepath.Base(options.HairReference))
-		hairAnalysisResult, err := o.AnalyzeImage("outfit", options.HairReference)
-		if err != nil {
-			fmt.Printf("    Warning: Failed to analyze hair from %s: %v\n", filepath.Base(options.HairReference), err)
-		} else {
-			// Extract hair from analysis
-			var outfit gemini.OutfitDescription
-			if err := json.Unmarshal(hairAnalysisResult, &outfit); err == nil && outfit.Hair != nil {
-				hairData, _ = json.Marshal(outfit.Hair)
+
+			// Determine hair source and data
+			var hairData json.RawMessage
+			var hairSourceName string
+			var hairSourcePath string
+			if options.HairReference == "USE_OUTFIT_REF" {
+				// Use hair from outfit reference
+				hairData = hairDataFromOutfit
+				hairSourcePath = outfitPath
+				if outfitPath != "" {
+					hairSourceName = strings.TrimSuffix(filepath.Base(outfitPath), filepath.Ext(outfitPath))
+				}
+				if hairData != nil {
+					output.Progress.Printf("  Using hair from outfit reference\n")
+				}
+			} else if options.HairReference != "" {
+				// Analyze hair from specified reference image
+				output.Progress.Printf("  Analyzing hair from: %s\n", filepath.Base(options.HairReference))
+				hairAnalysisResult, err := o.AnalyzeImage("outfit", options.HairReference)
+				if err != nil {
+					output.Progress.Printf("    Warning: Failed to analyze hair from %s: %v\n", filepath.Base(options.HairReference), err)
+				} else {
+					// Extract hair from analysis
+					var outfit gemini.OutfitDescription
+					if err := json.Unmarshal(hairAnalysisResult, &outfit); err == nil && outfit.Hair != nil {
+						hairData, _ = json.Marshal(outfit.Hair)
+					}
+					if hairData != nil {
+						hairSourcePath = options.HairReference
+						hairSourceName = strings.TrimSuffix(filepath.Base(options.HairReference), filepath.Ext(options.HairReference))
+						output.Progress.Printf("    Successfully extracted hair data\n")
+					} else {
+						output.Progress.Printf("    Warning: No hair data found in analysis\n")
+					}
+
+					result.Steps = append(result.Steps, StepResult{
+						Type: "analysis",
+						Name: "hair_source",
+						Data: hairAnalysisResult,
+					})
+				}
 			}
-			if hairData != nil {
-				hairSourceName = strings.TrimSuffix(filepath.Base(options.HairReference), filepath.Ext(options.HairReference))
-				fmt.Printf("    Successfully extracted hair data\n")
+			// If no hair reference specified, hairData remains nil and original hair will be preserved
+
+			// Collect style sources
+			styleFiles, err := collectImageFiles(styleSourcePath)
+			if err != nil {
+				output.Progress.Printf("  Warning: Failed to collect style files: %v\n", err)
+				styleFiles = []string{""} // Use default style
 			} else {
-				fmt.Printf("    Warning: No hair data found in analysis\n")
+				styleFiles = dedupeByContent(styleFiles, "style")
+				if len(styleFiles) > 1 {
+					output.Progress.Printf("  Found %d style images in directory\n", len(styleFiles))
+				}
 			}
 
-			result.Steps = append(result.Steps, StepResult{
-				Type: "analysis",
-				Name: "hair_source",
-				Data: hairAnalysisResult,
-			})
-		}
-	}
-	// If no hair reference specified, hairData remains nil and original hair will be preserved
+			// Loop through all style files
+			for styleIndex, stylePath := range styleFiles {
+				var styleData json.RawMessage
+				styleSourceName := "default_style"
 
-	// Collect style sources
-	styleFiles, err := collectImageFiles(styleSourcePath)
-	if err != nil {
-		fmt.Printf("  Warning: Failed to collect style files: %v\n", err)
-		styleFiles = []string{""} // Use default style
-	} else if len(styleFiles) > 1 {
-		fmt.Printf("  Found %d style images in directory\n", len(styleFiles))
-	}
+				combo := Combination{Subject: targetImage, Outfit: outfitPath, Style: stylePath}
+				if combo.Outfit == "" {
+					combo.Outfit = options.OutfitText
+				}
+				variations := picked.variationsOf(combo, variations)
+				if variations == 0 {
+					continue
+				}
+				if picked != nil {
+					combo.Variations = variations
+				}
+				done := resumed.done(combo)
+				o.progress.skip(min(done, variations))
+				if done >= variations {
+					output.Progress.Printf("    ♻️  Skipping %s: %d image(s) already generated\n", filepath.Base(stylePath), done)
+					continue
+				}
 
-	// Loop through all style files
-	for styleIndex, stylePath := range styleFiles {
-		var styleData json.RawMessage
-		styleSourceName := "default_style"
+				// Analyze style if we have a style file
+				if stylePath != "" {
+					if len(styleFiles) > 1 {
+						output.Progress.Printf("    [Style %d/%d] Processing: %s\n", styleIndex+1, len(styleFiles), filepath.Base(stylePath))
+					}
 
-		// Analyze style if we have a style file
-		if stylePath != "" {
-			if len(styleFiles) > 1 {
-				fmt.Printf("    [Style %d/%d] Processing: %s\n", styleIndex+1, len(styleFiles), filepath.Base(stylePath))
-			}
+					var err error
+					styleData, err = o.AnalyzeImage("visual_style", stylePath)
+					if err != nil {
+						output.Progress.Printf("    Warning: Failed to analyze style %s: %v\n", filepath.Base(stylePath), err)
+						o.progress.skip(variations - done)
+						continue
+					}
 
-			var err error
-			styleData, err = o.AnalyzeImage("visual_style", stylePath)
-			if err != nil {
-				fmt.Printf("    Warning: Failed to analyze style %s: %v\n", filepath.Base(stylePath), err)
-				continue
-			}
+					styleSourceName = strings.TrimSuffix(filepath.Base(stylePath), filepath.Ext(stylePath))
 
-			styleSourceName = strings.TrimSuffix(filepath.Base(stylePath), filepath.Ext(stylePath))
+					result.Steps = append(result.Steps, StepResult{
+						Type: "analysis",
+						Name: "style_source",
+						Data: styleData,
+					})
+				}
 
-			result.Steps = append(result.Steps, StepResult{
-				Type: "analysis",
-				Name: "style_source",
-				Data: styleData,
-			})
-		}
+				// Skip styles whose scene clashes with the outfit before paying for the images
+				if issues := checkPlausibility(outfitPrompt+" "+strings.Join(outfitItems, " "), styleScene(styleData, true)); len(issues) > 0 {
+					if !options.AllowImplausible {
+						output.Progress.Printf("    ⚠️  Skipping style %s: %v\n", styleSourceName, plausibilityError(
+							fmt.Sprintf("subject=%s outfit=%s style=%s", filepath.Base(targetImage), outfitSourceName, styleSourceName), issues))
+						o.progress.skip(variations - done)
+						continue
+					}
+					warnImplausible(issues)
+				}
 
-		// Generate the specified number of variations for this combination
-		for v := 1; v <= variations; v++ {
-			if variations > 1 {
-				fmt.Printf("      Generating variation %d of %d...\n", v, variations)
-			} else {
-				fmt.Printf("      Generating image...\n")
-			}
+				// Check the outfit covers what this style's framing will show
+				styledOutfitPrompt, addedDefaults := completeOutfitDescription(outfitPrompt, outfitItems, styleData, options.OutfitCheck)
+				styledOutfitFilters := outfitFilters
+				if len(addedDefaults) > 0 {
+					styledOutfitFilters = append(append([]string(nil), outfitFilters...), "outfit-check added: "+strings.Join(addedDefaults, ", "))
+				}
 
-			// Pass outfit reference image if SendOriginal is true and we have an image
-			outfitRef := ""
-			promptToUse := outfitPrompt
-			if options.SendOriginal && outfitPath != "" {
-				outfitRef = outfitPath
-				// When using --send-original, use minimal prompt to let the image speak for itself
-				promptToUse = ""
-			}
+				// The combination's outputs and failures are reported under one key
+				outfitInput, hairInput := outfitPath, ""
+				if outfitInput == "" {
+					outfitInput = options.OutfitText
+				}
+				if hairData != nil {
+					hairInput = hairSourcePath
+				}
+				recipe := combinedRecipe(targetImage, outfitInput, stylePath, hairInput)
+
+				// Generate the specified number of variations for this combination,
+				// each into its own slot
+				generated := make([]*variationOutput, variations+1)
+				stopped := make([]bool, variations+1)
+				for v := done + 1; v <= variations; v++ {
+					label := fmt.Sprintf("subject=%s outfit=%s style=%s (variation %d)",
+						filepath.Base(targetImage), outfitSourceName, styleSourceName, v)
+
+					// Pass outfit reference image if SendOriginal is true and we have an image
+					outfitRef := ""
+					promptToUse := styledOutfitPrompt
+					if options.SendOriginal && outfitPath != "" {
+						outfitRef = outfitPath
+						// When using --send-original, use minimal prompt to let the image speak for itself
+						promptToUse = ""
+					}
+					nextSeed := seedSequence(options.Seed, v-1, variations)
+					generate := func() (*generator.GenerateResult, error) {
+						return o.GenerateImage("combined", generator.GenerateParams{
+							ImagePath:       targetImage,
+							Prompt:          promptToUse,
+							StyleData:       styleData,
+							HairData:        hairData,
+							OutputDir:       organizedDir(options.OutputDir, options.OrganizeBy, targetImage, outfitSourceName, styleSourceName),
+							DebugPrompt:     options.DebugPrompt,
+							OutfitSource:    outfitSourceName,
+							StyleSource:     styleSourceName,
+							HairSource:      hairSourceName,
+							VariationIndex:  v,
+							TotalVariations: variations,
+							OutfitReference: outfitRef,
+							SendOriginal:    options.SendOriginal,
+							Keep:            keep,
+							Avoid:           options.Avoid,
+							Aspect:          options.Aspect,
+							Resolution:      options.Resolution,
+							Seed:            nextSeed(),
+							Temperature:     options.Sampling.Temperature,
+							TopK:            options.Sampling.TopK,
+							TopP:            options.Sampling.TopP,
+							Strength:        options.Strength,
+							NameTemplate:    options.NameTemplate,
+						})
+					}
+					sources := map[string]*models.ComponentData{
+						"outfit": {Type: "outfit", Description: styledOutfitPrompt, ImagePath: outfitPath, Text: options.OutfitText, Filters: styledOutfitFilters},
+					}
+					if stylePath != "" {
+						sources["style"] = &models.ComponentData{Type: "visual_style", ImagePath: stylePath, JSONData: styleData}
+					}
+					if hairData != nil && hairSourcePath != "" {
+						sources["hair"] = &models.ComponentData{Type: "hair", ImagePath: hairSourcePath}
+					}
+					hash := combinationHash("combined", o.hashSettings(options.Seed, options.Strength, options.Sampling, options.Aspect, options.Resolution, options.Avoid),
+						targetImage, sources, v)
+					if options.SkipExisting {
+						if existing := o.existingOutput(hash, options.OutputDir); existing != "" {
+							output.Progress.Printf("    ⏭️  Skipping %s: already generated as %s\n", label, existing)
+							o.progress.skip(1)
+							continue
+						}
+					}
 
-			combinedResult, err := o.GenerateImage("combined", generator.GenerateParams{
-				ImagePath:       targetImage,
-				Prompt:          promptToUse,
-				StyleData:       styleData,
-				HairData:        hairData,
-				OutputDir:       options.OutputDir,
-				DebugPrompt:     options.DebugPrompt,
-				OutfitSource:    outfitSourceName,
-				StyleSource:     styleSourceName,
-				HairSource:      hairSourceName,
-				VariationIndex:  v,
-				TotalVariations: variations,
-				OutfitReference: outfitRef,
-				SendOriginal:    options.SendOriginal,
-			})
-			if err != nil {
-				fmt.Printf("    Warning: Failed to generate image with style %s: %v\n", styleSourceName, err)
-				continue
-			}
+					// finish post-processes and records the image kept for this variation
+					finish := func(picked candidate, err error) {
+						if o.Planned(err, options.OutputDir, label) {
+							o.progress.done(label, "", 0, nil, true)
+							return
+						}
+						if err != nil {
+							output.Progress.Printf("    Warning: Failed to generate image with style %s: %v\n", styleSourceName, err)
+							o.recordFailure(label, recipe, err)
+							o.progress.done(label, "", picked.took, err, false)
+							return
+						}
+						combinedResult := picked.result
+						o.progress.done(label, combinedResult.OutputPath, picked.took, nil, false)
+
+						o.lockFace(targetImage, combinedResult.OutputPath, options.Post)
+						if err := applyPostChain(combinedResult.OutputPath, options.Post); err != nil {
+							output.Progress.Printf("    Warning: Post-processing failed for %s: %v\n", filepath.Base(combinedResult.OutputPath), err)
+						}
+						o.upscaleOutput(combinedResult.OutputPath, options.Post)
+						o.verifyOutput(combinedResult.OutputPath, stylePath, options.Verify)
+						o.reviewOutput(combinedResult.OutputPath, targetImage, sources, options.Verify)
+						judged := picked.judged
+						if judged == nil {
+							judged = o.judgeOutput(combinedResult.OutputPath, sources, options.Verify)
+						}
+						settings := &RecipeSettings{
+							SendOriginal:   options.SendOriginal,
+							OutfitCheck:    options.OutfitCheck,
+							MaxAccessories: options.MaxAccessories,
+							LUT:            options.Post.LUTPath,
+							Avoid:          options.Avoid,
+							Aspect:         options.Aspect,
+							Resolution:     options.Resolution,
+							Upscale:        options.Post.Upscale,
+							Upscaler:       options.Post.Upscaler,
+							FaceLock:       options.Post.FaceLock,
+						}
+						settings.recordGeneration(picked.result, options.Sampling, options.Strength)
+						o.writeSidecar(combinedResult, "outfit-swap", "", targetImage, sources, settings)
+						image := o.manifestImage("outfit-swap", combinedResult, targetImage, combinedResult.Prompt,
+							sources, settings, v, picked.started, picked.took)
+						image.Combination = &combo
+						image.Hash = hash
+						image.IdentityScore = picked.identity
+						image.Judge = judged
+
+						message := fmt.Sprintf("Generated with %s outfit and %s style", outfitSourceName, styleSourceName)
+						if len(targetImages) > 1 {
+							message = fmt.Sprintf("Generated %s with %s outfit and %s style", filepath.Base(targetImage), outfitSourceName, styleSourceName)
+						}
+						collected := &WorkflowResult{}
+						collected.Steps = append(collected.Steps, StepResult{
+							Type:       "generation",
+							Name:       "combined",
+							OutputPath: combinedResult.OutputPath,
+							Message:    message,
+							Flags:      o.ReviewFlags(combinedResult.OutputPath),
+							Recipe:     recipe,
+						})
+						o.chainOutput(chain, combinedResult.OutputPath, options.OutputDir, collected)
+						generated[v] = &variationOutput{path: combinedResult.OutputPath, image: image, steps: collected.Steps}
+					}
 
-			message := fmt.Sprintf("Generated with %s outfit and %s style", outfitSourceName, styleSourceName)
-			if len(targetImages) > 1 {
-				message = fmt.Sprintf("Generated %s with %s outfit and %s style", filepath.Base(targetImage), outfitSourceName, styleSourceName)
-			}
-			result.Steps = append(result.Steps, StepResult{
-				Type:       "generation",
-				Name:       "combined",
-				OutputPath: combinedResult.OutputPath,
-				Message:    message,
-			})
-
-			// Brief pause between generations
-			if v < variations || styleIndex < len(styleFiles)-1 || outfitIndex < len(outfitFiles)-1 || subjectIndex < len(targetImages)-1 {
-				time.Sleep(1 * time.Second)
+					// With --best-of each candidate is its own generation on the queue;
+					// the one that completes the set picks the winner. Each generation
+					// checks the deadline when a worker picks it up, not when it is queued.
+					if n := o.candidates(options.Verify); n > 1 {
+						o.progress.generating(label, v, variations)
+						set := newCandidateSet(n)
+						for c := 0; c < n; c++ {
+							queue.run(func() {
+								next := candidate{skipped: true}
+								if dl.allowsNext() && !options.stopRequested() {
+									next = o.generateCandidate(targetImage, sources, options.Verify, generate)
+									dl.finish(next.started)
+								}
+								all := set.add(next)
+								if all == nil {
+									return
+								}
+								if all = startedCandidates(all); len(all) == 0 {
+									stopped[v] = true
+									o.progress.skip(1)
+									return
+								}
+								finish(o.pickCandidate(all, options.Verify))
+							})
+						}
+						continue
+					}
+					queue.run(func() {
+						if !dl.allowsNext() || options.stopRequested() {
+							stopped[v] = true
+							o.progress.skip(1)
+							return
+						}
+						started := time.Now()
+						defer dl.finish(started)
+						o.progress.generating(label, v, variations)
+						finish(o.generateValidated(targetImage, options.Verify, generate))
+					})
+				}
+
+				queue.then(func() {
+					for _, skipped := range stopped {
+						if skipped {
+							remaining = append(remaining, combo)
+							break
+						}
+					}
+					var variationOutputs []string
+					for _, out := range generated {
+						if out == nil {
+							continue
+						}
+						variationOutputs = append(variationOutputs, out.path)
+						manifest = append(manifest, out.image)
+						result.Steps = append(result.Steps, out.steps...)
+					}
+					o.scoreVariations(fmt.Sprintf("subject=%s outfit=%s style=%s",
+						filepath.Base(targetImage), outfitSourceName, styleSourceName), variationOutputs, options.Verify)
+				})
 			}
-		}
-	}
-	} // End of outfit loop
+		} // End of outfit loop
 	} // End of subject loop
 
+	queue.wait()
+	if remaining = append(remaining, unstarted...); remaining != nil {
+		o.stopForDeadline(result, outfitSourcePath, options.OutputDir, remaining, options)
+	}
+	o.finishChain(chain, options.OutputDir, result)
+	if options.Resume && !o.dryRun {
+		finishResume(options.OutputDir, result)
+	}
+
 	result.EndTime = time.Now()
+	o.recordManifest(options.OutputDir, ManifestRun{
+		Workflow: "outfit-swap",
+		Started:  result.StartTime,
+		Finished: result.EndTime,
+		Failures: len(o.Failures()) - failuresBefore,
+	}, manifest)
+	if options.Verify.Judge {
+		o.rankOutputs(options.OutputDir)
+	}
 	result.SubjectCount = len(targetImages)
 	result.OutfitCount = len(outfitFiles)
 	result.StyleCount = numStyles
 	result.VariationCount = variations
+	result.Consistency = o.ConsistencyScores()
+	result.Failures = o.Failures()
+	result.Taxonomy = SummarizeFailures(result)
 	return result, nil
 }
 
-
 // formatDescription formats a description with a label
 func formatDescription(label, description string) string {
 	if description == "" {
@@ -509,4 +923,4 @@ type Buffer struct {
 
 func (b *Buffer) Close() error {
 	return nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,65 @@
+package workflow
+
+import (
+	"fmt"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/generator"
+	"path/filepath"
+	"strings"
+)
+
+// Values of --organize-by: the component whose name becomes a subfolder of
+// the output directory
+const (
+	OrganizeBySubject = "subject"
+	OrganizeByOutfit  = "outfit"
+	OrganizeByStyle   = "style"
+)
+
+// OrganizeByValues are the values accepted by --organize-by
+var OrganizeByValues = []string{OrganizeBySubject, OrganizeByOutfit, OrganizeByStyle}
+
+// ValidateOrganizeBy checks an --organize-by value; empty keeps every image
+// in the output directory itself
+func ValidateOrganizeBy(by string) error {
+	if by == "" {
+		return nil
+	}
+	for _, v := range OrganizeByValues {
+		if by == v {
+			return nil
+		}
+	}
+	return errors.ErrInvalidInput("organize-by", fmt.Sprintf("unknown component %q (expected %s)", by, strings.Join(OrganizeByValues, ", ")))
+}
+
+// organizedDir returns the folder a combination's images go into: outputDir,
+// or its subfolder named after the subject, outfit or style
+func organizedDir(outputDir, by, subjectPath, outfitName, styleName string) string {
+	return filepath.Join(outputDir, organizedSubfolder(by, subjectPath, outfitName, styleName))
+}
+
+// organizedSubfolder is the subfolder of organizedDir, "" when images aren't organized
+func organizedSubfolder(by, subjectPath, outfitName, styleName string) string {
+	switch by {
+	case OrganizeBySubject:
+		return assetName(subjectPath)
+	case OrganizeByOutfit:
+		return outfitName
+	case OrganizeByStyle:
+		return styleName
+	}
+	return ""
+}
+
+// assetName names a component input in folder names: the base name of its
+// file, a short slug of a text description, or "none"
+func assetName(ref string) string {
+	if ref == "" {
+		return "none"
+	}
+	if isFilePath(ref) {
+		return strings.TrimSuffix(filepath.Base(ref), filepath.Ext(ref))
+	}
+	return generator.ReferenceName("", ref)
+}
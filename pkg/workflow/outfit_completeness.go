@@ -0,0 +1,155 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/output"
+	"strings"
+	"unicode"
+)
+
+// Outfit completeness modes (--outfit-check)
+const (
+	OutfitCheckWarn = "warn" // Report missing pieces, generate as-is
+	OutfitCheckFill = "fill" // Add neutral defaults for missing pieces
+	OutfitCheckOff  = "off"  // Skip the check
+)
+
+// outfitGap is a piece of clothing the shot will show but the outfit doesn't define
+type outfitGap struct {
+	Missing string
+	Default string
+}
+
+var (
+	fullBodyKeywords = []string{
+		"full body", "full-body", "full length", "full-length", "head to toe", "head-to-toe",
+		"wide shot", "long shot", "entire body", "whole body",
+	}
+	onePieceKeywords = []string{
+		"dress", "gown", "jumpsuit", "romper", "playsuit", "overalls", "dungarees", "coveralls",
+		"boilersuit", "catsuit", "onesie", "kimono", "robe", "sari", "kaftan", "cheongsam", "hanbok",
+	}
+	topKeywords = []string{
+		"shirt", "t-shirt", "tee", "blouse", "top", "sweater", "jumper", "hoodie", "cardigan", "jacket",
+		"coat", "blazer", "vest", "tank", "camisole", "bodysuit", "turtleneck", "polo", "corset", "bra",
+		"sweatshirt", "tunic", "crop top", "parka", "bomber",
+	}
+	bottomKeywords = []string{
+		"trousers", "pants", "jeans", "skirt", "shorts", "leggings", "chinos", "slacks", "culottes",
+		"joggers", "sweatpants", "kilt", "hakama", "tights", "bottoms",
+	}
+	footwearKeywords = []string{
+		"shoes", "shoe", "boots", "boot", "sneakers", "heels", "sandals", "loafers", "pumps", "flats",
+		"trainers", "oxfords", "mules", "clogs", "slippers", "stilettos", "brogues", "espadrilles",
+	}
+)
+
+// checkOutfitCompleteness finds pieces a full-body shot will show that the outfit doesn't cover.
+// Waist-up and closer framings never report gaps.
+func checkOutfitCompleteness(items []string, framing string) []outfitGap {
+	if !containsAnyWord(framing, fullBodyKeywords) {
+		return nil
+	}
+
+	text := strings.Join(items, " ")
+	var gaps []outfitGap
+
+	if containsAnyWord(text, topKeywords) && !containsAnyWord(text, bottomKeywords) && !containsAnyWord(text, onePieceKeywords) {
+		gaps = append(gaps, outfitGap{Missing: "bottoms", Default: "plain black trousers"})
+	}
+	if !containsAnyWord(text, footwearKeywords) {
+		gaps = append(gaps, outfitGap{Missing: "footwear", Default: "simple black shoes"})
+	}
+
+	return gaps
+}
+
+// outfitFillNote introduces the neutral defaults added in fill mode
+const outfitFillNote = "TO COMPLETE THE OUTFIT (not part of the reference, keep plain and understated): "
+
+// completeOutfitDescription reports outfit gaps for the given style and, in fill mode,
+// appends neutral defaults to the description with an explicit note for the model.
+// The defaults that were added are returned alongside the description.
+func completeOutfitDescription(desc string, items []string, styleData json.RawMessage, mode string) (string, []string) {
+	if mode == OutfitCheckOff || len(items) == 0 || styleData == nil {
+		return desc, nil
+	}
+
+	gaps := checkOutfitCompleteness(items, styleFraming(styleData))
+	if len(gaps) == 0 {
+		return desc, nil
+	}
+
+	var defaults []string
+	for _, gap := range gaps {
+		if mode == OutfitCheckFill {
+			output.Progress.Printf("    ℹ️  Outfit has no %s for this full-body style, adding %s\n", gap.Missing, gap.Default)
+			defaults = append(defaults, gap.Default)
+		} else {
+			output.Progress.Printf("    ⚠️  Outfit has no %s but the style is full-body; the model may invent them (use --outfit-check fill)\n", gap.Missing)
+		}
+	}
+	if len(defaults) == 0 {
+		return desc, nil
+	}
+
+	return fmt.Sprintf("%s. %s%s", strings.TrimSuffix(desc, "."), outfitFillNote, strings.Join(defaults, ", ")), defaults
+}
+
+// styleFraming returns the framing-related text of a visual style analysis
+func styleFraming(styleData json.RawMessage) string {
+	var style map[string]interface{}
+	if err := json.Unmarshal(styleData, &style); err != nil {
+		return ""
+	}
+	var parts []string
+	for _, field := range []string{"framing", "composition", "body_position"} {
+		if value, ok := style[field].(string); ok {
+			parts = append(parts, value)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// clothingItems returns the clothing list of an outfit analysis
+func clothingItems(outfitData json.RawMessage) []string {
+	var outfit struct {
+		Clothing []interface{} `json:"clothing"`
+	}
+	if err := json.Unmarshal(outfitData, &outfit); err != nil {
+		return nil
+	}
+
+	var items []string
+	for _, item := range outfit.Clothing {
+		switch v := item.(type) {
+		case string:
+			items = append(items, v)
+		case map[string]interface{}:
+			name, _ := v["item"].(string)
+			desc, _ := v["description"].(string)
+			items = append(items, strings.TrimSpace(name+" "+desc))
+		}
+	}
+	return items
+}
+
+// containsAnyWord reports whether text contains any keyword as whole words (plurals included)
+func containsAnyWord(text string, keywords []string) bool {
+	return firstWordMatch(text, keywords) != ""
+}
+
+// firstWordMatch returns the first keyword found in text, or "" if none is
+func firstWordMatch(text string, keywords []string) string {
+	normalized := " " + strings.Join(strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '-'
+	}), " ") + " "
+
+	for _, keyword := range keywords {
+		if strings.Contains(normalized, " "+keyword+" ") || strings.Contains(normalized, " "+keyword+"s ") {
+			return keyword
+		}
+	}
+	return ""
+}
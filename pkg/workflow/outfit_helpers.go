@@ -138,18 +138,22 @@ func appendAccessoryItem(builder *strings.Builder, item interface{}) {
 	}
 }
 
-// extractOutfitPromptAndHair extracts the outfit prompt and hair data from outfit analysis
-func extractOutfitPromptAndHair(outfitData json.RawMessage) (string, json.RawMessage) {
+// extractOutfitPromptAndHair extracts the outfit prompt and hair data from outfit analysis.
+// maxAccessories limits the accessories carried into the prompt (0 = no limit);
+// the accessories left out are returned last.
+func extractOutfitPromptAndHair(outfitData json.RawMessage, maxAccessories int) (string, json.RawMessage, []string) {
 	var outfit gemini.OutfitDescription
 	if err := json.Unmarshal(outfitData, &outfit); err != nil {
 		// Try to use the raw JSON as a string
 		var rawText string
 		if json.Unmarshal(outfitData, &rawText) == nil && rawText != "" {
-			return rawText, nil
+			return rawText, nil, nil
 		}
-		return "wearing the same outfit as shown in the reference image", nil
+		return "wearing the same outfit as shown in the reference image", nil, nil
 	}
 
+	var dropped []string
+	outfit.Accessories, dropped = limitAccessoryList(outfit.Accessories, maxAccessories)
 	outfitPrompt := buildOutfitPrompt(&outfit)
 
 	var hairData json.RawMessage
@@ -157,7 +161,7 @@ func extractOutfitPromptAndHair(outfitData json.RawMessage) (string, json.RawMes
 		hairData, _ = json.Marshal(outfit.Hair)
 	}
 
-	return outfitPrompt, hairData
+	return outfitPrompt, hairData, dropped
 }
 
 // extractHairFromAnalysis extracts hair data from an outfit analysis
@@ -2,6 +2,9 @@ package workflow
 
 import (
 	"fmt"
+	"img-cli/pkg/analyzer"
+	"img-cli/pkg/cost"
+	"img-cli/pkg/output"
 	"os"
 	"path/filepath"
 	"strings"
@@ -25,6 +28,7 @@ func (o *Orchestrator) runOutfitSwapModularWorkflow(outfitSourcePath string, opt
 	} else {
 		return nil, fmt.Errorf("target subject must be specified for outfit-swap workflow")
 	}
+	targetImages = dedupeByContent(targetImages, "subject")
 
 	// Collect files for each modular component that can be directories
 	outfitFiles, err := collectFilesForComponent(outfitSourcePath, "outfit")
@@ -32,9 +36,13 @@ func (o *Orchestrator) runOutfitSwapModularWorkflow(outfitSourcePath string, opt
 		return nil, err
 	}
 
-	styleFiles, err := collectFilesForComponent(options.StyleReference, "style")
-	if err != nil {
-		return nil, err
+	// A blend is one style, however many references it lists
+	styleFiles := []string{options.StyleReference}
+	if !options.StyleBlend {
+		styleFiles, err = collectFilesForComponent(options.StyleReference, "style")
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	hairStyleFiles, err := collectFilesForComponent(options.HairStyleRef, "hair-style")
@@ -67,156 +75,286 @@ func (o *Orchestrator) runOutfitSwapModularWorkflow(outfitSourcePath string, opt
 		return nil, err
 	}
 
+	poseFiles, err := collectFilesForComponent(options.PoseRef, "pose")
+	if err != nil {
+		return nil, err
+	}
+
+	backgroundFiles, err := collectFilesForComponent(options.BackgroundRef, "background")
+	if err != nil {
+		return nil, err
+	}
+
+	extraNames, extraFiles, err := registeredFiles(options.Extra)
+	if err != nil {
+		return nil, err
+	}
+
+	// Build every combination up front so the run can be stopped and resumed cleanly
+	axes := [][]string{
+		targetImages,
+		ensureAtLeastOne(outfitFiles),
+		ensureAtLeastOne(overOutfitFiles),
+		ensureAtLeastOne(styleFiles),
+		ensureAtLeastOne(hairStyleFiles),
+		ensureAtLeastOne(hairColorFiles),
+		ensureAtLeastOne(makeupFiles),
+		ensureAtLeastOne(expressionFiles),
+		ensureAtLeastOne(accessoriesFiles),
+		ensureAtLeastOne(poseFiles),
+		ensureAtLeastOne(backgroundFiles),
+		ensureAtLeastOne(options.Ambient),
+	}
+	axes = append(axes, extraFiles...)
+	matrixSize := 1
+	for _, axis := range axes {
+		matrixSize *= len(axis)
+	}
+	var combinations []Combination
+	if options.Pairwise {
+		combinations = pairwiseCombinations(axes, extraNames)
+		output.Progress.Printf("🧩 Pairwise: %d combinations cover every pair of component values (full matrix: %d)\n",
+			len(combinations), matrixSize)
+	} else {
+		combinations = matrixCombinations(axes, extraNames)
+	}
+
+	// Explore a large matrix cheaply; drawn before resuming so a resumed run
+	// given the same seed continues the same sample
+	if options.Sample > 0 && options.Sample < matrixSize {
+		combinations = sampleCombinations(combinations, options.Sample, options.SampleSeed)
+		output.Progress.Printf("🎲 Sampled %d of %d combinations (draw the same ones again with --sample-seed %d)\n",
+			len(combinations), matrixSize, options.SampleSeed)
+	}
+
+	// Skip what an interrupted run in the same output directory already generated
+	if options.Resume {
+		combinations, err = resumeCombinations(options.OutputDir, combinations, options.Variations)
+		if err != nil {
+			return nil, err
+		}
+		if len(combinations) == 0 {
+			output.Progress.Println("✅ Nothing left to generate")
+			result.EndTime = time.Now()
+			return result, nil
+		}
+	}
+
+	// Let the user narrow the matrix before anything is estimated or confirmed
+	if options.Pick {
+		picked, ok, err := pickCombinations(combinations, options)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			output.Progress.Println("❌ Workflow cancelled by user")
+			return result, nil
+		}
+		combinations = picked
+	}
+
 	// Calculate total images
-	totalImages := len(targetImages) *
-		maxInt(1, len(outfitFiles)) *
-		maxInt(1, len(overOutfitFiles)) *
-		maxInt(1, len(styleFiles)) *
-		maxInt(1, len(hairStyleFiles)) *
-		maxInt(1, len(hairColorFiles)) *
-		maxInt(1, len(makeupFiles)) *
-		maxInt(1, len(expressionFiles)) *
-		maxInt(1, len(accessoriesFiles)) *
-		options.Variations
-
-	estimatedCost := float64(totalImages) * 0.04
+	totalImages, scoredCombinations := 0, 0
+	for _, combo := range combinations {
+		n := combo.variations(options.Variations)
+		totalImages += n
+		if n > 1 {
+			scoredCombinations++
+		}
+	}
+	generations := totalImages
+	totalImages += options.Chain.ExtraImages(generations) + options.Post.ExtraImages(generations) + options.Verify.ExtraImages(generations)
+
+	calls := ExtraCalls(generations, o.refineAltText) + options.Verify.ExtraCalls(generations, scoredCombinations)
 
 	// Always show cost analysis
-	fmt.Printf("\n📊 Workflow Cost Analysis for outfit-swap:\n")
-	fmt.Printf("   Images to generate: %d\n", totalImages)
-	fmt.Printf("   Cost breakdown: %d images × $0.04 = $%.2f\n", totalImages, estimatedCost)
+	cost.PrintEstimate("Workflow Cost Analysis for outfit-swap", totalImages)
+	cost.PrintCalls(calls)
 
 	// Show component breakdown
-	fmt.Println("\n🎨 Component combinations:")
-	fmt.Printf("   Subjects: %d\n", len(targetImages))
+	output.Progress.Println("\n🎨 Component combinations:")
+	output.Progress.Printf("   Subjects: %d\n", len(targetImages))
 	if len(outfitFiles) > 0 {
-		fmt.Printf("   Outfits: %d\n", len(outfitFiles))
+		output.Progress.Printf("   Outfits: %d\n", len(outfitFiles))
 	}
 	if len(overOutfitFiles) > 0 {
-		fmt.Printf("   Over-outfits: %d\n", len(overOutfitFiles))
+		output.Progress.Printf("   Over-outfits: %d\n", len(overOutfitFiles))
 	}
 	if len(styleFiles) > 0 {
-		fmt.Printf("   Styles: %d\n", len(styleFiles))
+		output.Progress.Printf("   Styles: %d\n", len(styleFiles))
 	}
 	if len(hairStyleFiles) > 0 {
-		fmt.Printf("   Hair styles: %d\n", len(hairStyleFiles))
+		output.Progress.Printf("   Hair styles: %d\n", len(hairStyleFiles))
 	}
 	if len(hairColorFiles) > 0 {
-		fmt.Printf("   Hair colors: %d\n", len(hairColorFiles))
+		output.Progress.Printf("   Hair colors: %d\n", len(hairColorFiles))
 	}
 	if len(makeupFiles) > 0 {
-		fmt.Printf("   Makeup: %d\n", len(makeupFiles))
+		output.Progress.Printf("   Makeup: %d\n", len(makeupFiles))
 	}
 	if len(expressionFiles) > 0 {
-		fmt.Printf("   Expressions: %d\n", len(expressionFiles))
+		output.Progress.Printf("   Expressions: %d\n", len(expressionFiles))
 	}
 	if len(accessoriesFiles) > 0 {
-		fmt.Printf("   Accessories: %d\n", len(accessoriesFiles))
-	}
-	fmt.Printf("   Variations: %d\n", options.Variations)
-
-	// Only ask for confirmation if cost exceeds $5 (unless --no-confirm is used)
-	if !options.SkipCostConfirm && estimatedCost > 5.00 {
-		fmt.Printf("\n⚠️  This will cost more than $5 ($%.2f)\n", estimatedCost)
-		fmt.Print("   Proceed? (y/N): ")
-		var response string
-		fmt.Scanln(&response)
-		if strings.ToLower(response) != "y" {
-			fmt.Println("❌ Workflow cancelled by user")
-			return result, nil
+		output.Progress.Printf("   Accessories: %d\n", len(accessoriesFiles))
+	}
+	if len(poseFiles) > 0 {
+		output.Progress.Printf("   Poses: %d\n", len(poseFiles))
+	}
+	if len(backgroundFiles) > 0 {
+		output.Progress.Printf("   Backgrounds: %d\n", len(backgroundFiles))
+	}
+	for i, name := range extraNames {
+		output.Progress.Printf("   %s: %d\n", upperFirst(componentLabel(name)), len(extraFiles[i]))
+	}
+	if len(options.Ambient) > 0 {
+		output.Progress.Printf("   Ambients: %d (%s)\n", len(options.Ambient), strings.Join(options.Ambient, ", "))
+	}
+	if len(options.Avoid) > 0 {
+		output.Progress.Printf("   Avoiding: %s\n", strings.Join(options.Avoid, ", "))
+	}
+	if options.Aspect != "" || options.Resolution > 0 {
+		output.Progress.Printf("   Format: %s\n", FormatLabel(options.Aspect, options.Resolution))
+	}
+	if options.Post.Upscale > 0 {
+		output.Progress.Printf("   Upscale: %dx (%s)\n", options.Post.Upscale, options.Post.upscaler())
+	}
+	if options.Pick {
+		output.Progress.Printf("   Picked combinations: %d\n", len(combinations))
+	} else if len(combinations) < matrixSize && !options.Resume {
+		label := "Sampled"
+		if options.Pairwise {
+			label = "Pairwise"
 		}
+		output.Progress.Printf("   %s combinations: %d of %d\n", label, len(combinations), matrixSize)
+		output.Progress.Printf("   Variations: %d\n", options.Variations)
+	} else {
+		output.Progress.Printf("   Variations: %d\n", options.Variations)
+	}
+	if len(options.Chain.Steps) > 0 {
+		output.Progress.Printf("   Chained outputs: %s\n", strings.Join(options.Chain.Steps, ", "))
+	}
+
+	// Picking already showed the cost
+	if err := cost.Check(totalImages, cost.CheckOptions{
+		Calls:       calls,
+		SkipConfirm: options.SkipCostConfirm,
+		Confirmed:   options.Pick,
+		DryRun:      o.dryRun,
+	}); err != nil {
+		return nil, err
 	}
 
 	// Initialize modular components
 	o.initializeModularComponents()
 
+	chain, err := o.prepareChain(options.Chain)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create output directory once for all images
 	outputDir := options.OutputDir
 	if outputDir == "" {
 		outputDir = generateOutputDir()
 	}
 
-	// Process each combination
-	generatedCount := 0
-	for _, subject := range targetImages {
-		for _, outfit := range ensureAtLeastOne(outfitFiles) {
-			for _, overOutfit := range ensureAtLeastOne(overOutfitFiles) {
-				for _, style := range ensureAtLeastOne(styleFiles) {
-					for _, hairStyle := range ensureAtLeastOne(hairStyleFiles) {
-						for _, hairColor := range ensureAtLeastOne(hairColorFiles) {
-							for _, makeup := range ensureAtLeastOne(makeupFiles) {
-								for _, expression := range ensureAtLeastOne(expressionFiles) {
-									for _, accessories := range ensureAtLeastOne(accessoriesFiles) {
-										// Create modular config
-										config := ModularConfig{
-											SubjectPath:    subject,
-											OutfitRef:      outfit,
-											OverOutfitRef:  overOutfit,
-											StyleRef:       style,
-											HairStyleRef:   hairStyle,
-											HairColorRef:   hairColor,
-											MakeupRef:      makeup,
-											ExpressionRef:  expression,
-											AccessoriesRef: accessories,
-											Variations:     options.Variations,
-											SendOriginal:   options.SendOriginal,
-											Debug:          options.DebugPrompt,
-											OutputDir:      outputDir,
-										}
-
-									// Display current combination
-									fmt.Printf("\n🎨 Processing combination:\n")
-									fmt.Printf("   Subject: %s\n", filepath.Base(subject))
-									if outfit != "" {
-										fmt.Printf("   Outfit: %s\n", filepath.Base(outfit))
-									}
-									if overOutfit != "" {
-										fmt.Printf("   Over-outfit: %s\n", filepath.Base(overOutfit))
-									}
-									if style != "" {
-										fmt.Printf("   Style: %s\n", filepath.Base(style))
-									}
-									if hairStyle != "" {
-										fmt.Printf("   Hair style: %s\n", filepath.Base(hairStyle))
-									}
-									if hairColor != "" {
-										fmt.Printf("   Hair color: %s\n", filepath.Base(hairColor))
-									}
-									if makeup != "" {
-										fmt.Printf("   Makeup: %s\n", filepath.Base(makeup))
-									}
-									if expression != "" {
-										fmt.Printf("   Expression: %s\n", filepath.Base(expression))
-									}
-									if accessories != "" {
-										fmt.Printf("   Accessories: %s\n", filepath.Base(accessories))
-									}
-
-									// Run modular workflow
-									results, err := o.RunModularWorkflow(config)
-									if err != nil {
-										fmt.Printf("   ❌ Error: %v\n", err)
-										continue
-									}
-
-									// Add results to workflow
-									for _, outputPath := range results {
-										result.Steps = append(result.Steps, StepResult{
-											Type:       "generation",
-											Name:       "modular",
-											OutputPath: outputPath,
-											Message:    fmt.Sprintf("Generated %s", filepath.Base(outputPath)),
-										})
-										generatedCount++
-										}
-									}
-								}
-							}
-						}
-					}
-				}
+	// Process each combination, up to options.Parallel at once. Each one
+	// collects its steps separately so the result keeps the combination order.
+	o.progress.begin(generations)
+	defer o.progress.end()
+	dl := newDeadline(options.MaxDuration)
+	queue := newGenerationQueue(options.Parallel)
+	var remaining []Combination
+	for _, combo := range combinations {
+		var steps []StepResult
+		stopped := false
+		queue.run(func() {
+			if !dl.allowsNext() || options.stopRequested() {
+				stopped = true
+				return
 			}
-		}
+			started := time.Now()
+			defer dl.finish(started)
+
+			config := ModularConfig{
+				SubjectPath:      combo.Subject,
+				OutfitRef:        combo.Outfit,
+				OverOutfitRef:    combo.OverOutfit,
+				StyleRef:         combo.Style,
+				HairStyleRef:     combo.HairStyle,
+				HairColorRef:     combo.HairColor,
+				MakeupRef:        combo.Makeup,
+				ExpressionRef:    combo.Expression,
+				AccessoriesRef:   combo.Accessories,
+				PoseRef:          combo.Pose,
+				BackgroundRef:    combo.Background,
+				Extra:            combo.Extra,
+				Weights:          options.Weights,
+				Variations:       combo.variations(options.Variations),
+				Generated:        combo.Generated,
+				SendOriginal:     options.SendOriginal,
+				Debug:            options.DebugPrompt,
+				OutputDir:        outputDir,
+				Post:             options.Post,
+				Verify:           options.Verify,
+				EnhanceText:      options.EnhanceText,
+				StyleBlend:       options.StyleBlend,
+				OutfitCheck:      options.OutfitCheck,
+				MaxAccessories:   options.MaxAccessories,
+				AllowImplausible: options.AllowImplausible,
+				Ambient:          combo.Ambient,
+				Avoid:            options.Avoid,
+				Aspect:           options.Aspect,
+				Resolution:       options.Resolution,
+				Seed:             options.Seed,
+				Sampling:         options.Sampling,
+				Strength:         options.Strength,
+				SkipExisting:     options.SkipExisting,
+				NameTemplate:     options.NameTemplate,
+				Subfolder:        organizedSubfolder(options.OrganizeBy, combo.Subject, assetName(combo.Outfit), assetName(combo.Style)),
+			}
+
+			printCombination(combo)
+
+			// Run modular workflow
+			results, err := o.RunModularWorkflow(config)
+			if err != nil {
+				output.Progress.Printf("   ❌ Error: %v\n", err)
+				return
+			}
+
+			// Collect this combination's steps, chained outputs included
+			collected := &WorkflowResult{}
+			for _, outputPath := range results {
+				collected.Steps = append(collected.Steps, StepResult{
+					Type:       "generation",
+					Name:       "modular",
+					OutputPath: outputPath,
+					Message:    fmt.Sprintf("Generated %s", filepath.Base(outputPath)),
+					Flags:      o.ReviewFlags(outputPath),
+					Recipe:     recipeLabel(config),
+				})
+				o.chainOutput(chain, outputPath, outputDir, collected)
+			}
+			steps = collected.Steps
+		})
+
+		queue.then(func() {
+			if stopped {
+				remaining = append(remaining, combo)
+				return
+			}
+			result.Steps = append(result.Steps, steps...)
+		})
+	}
+	queue.wait()
+	if len(remaining) > 0 {
+		o.stopForDeadline(result, outfitSourcePath, outputDir, remaining, options)
+	}
+	o.finishChain(chain, outputDir, result)
+	if options.Resume && !o.dryRun {
+		finishResume(outputDir, result)
 	}
 
 	// Set result counts
@@ -224,19 +362,70 @@ func (o *Orchestrator) runOutfitSwapModularWorkflow(outfitSourcePath string, opt
 	result.OutfitCount = maxInt(1, len(outfitFiles))
 	result.StyleCount = maxInt(1, len(styleFiles))
 	result.VariationCount = options.Variations
+	result.Consistency = o.ConsistencyScores()
+	result.Failures = o.Failures()
+	result.Taxonomy = SummarizeFailures(result)
 	result.EndTime = time.Now()
 
 	return result, nil
 }
 
+// printCombination shows the inputs of the combination being processed
+func printCombination(combo Combination) {
+	output.Progress.Printf("\n🎨 Processing combination:\n")
+	output.Progress.Printf("   Subject: %s\n", filepath.Base(combo.Subject))
+	if combo.Outfit != "" {
+		output.Progress.Printf("   Outfit: %s\n", filepath.Base(combo.Outfit))
+	}
+	if combo.OverOutfit != "" {
+		output.Progress.Printf("   Over-outfit: %s\n", filepath.Base(combo.OverOutfit))
+	}
+	if combo.Style != "" {
+		output.Progress.Printf("   Style: %s\n", filepath.Base(combo.Style))
+	}
+	if combo.HairStyle != "" {
+		output.Progress.Printf("   Hair style: %s\n", filepath.Base(combo.HairStyle))
+	}
+	if combo.HairColor != "" {
+		output.Progress.Printf("   Hair color: %s\n", filepath.Base(combo.HairColor))
+	}
+	if combo.Makeup != "" {
+		output.Progress.Printf("   Makeup: %s\n", filepath.Base(combo.Makeup))
+	}
+	if combo.Expression != "" {
+		output.Progress.Printf("   Expression: %s\n", filepath.Base(combo.Expression))
+	}
+	if combo.Accessories != "" {
+		output.Progress.Printf("   Accessories: %s\n", filepath.Base(combo.Accessories))
+	}
+	if combo.Pose != "" {
+		output.Progress.Printf("   Pose: %s\n", filepath.Base(combo.Pose))
+	}
+	if combo.Background != "" {
+		output.Progress.Printf("   Background: %s\n", filepath.Base(combo.Background))
+	}
+	for _, name := range analyzer.Components() {
+		if ref := combo.Extra[name]; ref != "" {
+			output.Progress.Printf("   %s: %s\n", upperFirst(componentLabel(name)), filepath.Base(ref))
+		}
+	}
+	if combo.Ambient != "" {
+		output.Progress.Printf("   Ambient: %s\n", combo.Ambient)
+	}
+}
+
 // collectFilesForComponent collects files from a path (file or directory) or handles text descriptions
 func collectFilesForComponent(path string, componentType string) ([]string, error) {
 	if path == "" {
 		return []string{}, nil
 	}
 
-	// For style, always treat as file path
+	// For style, treat as file path unless it is clearly a description
 	if componentType == "style" || componentType == "visual_style" {
+		if isStyleText(path) {
+			return []string{path}, nil
+		}
+
 		// Check if it's a file or directory
 		info, err := os.Stat(path)
 		if err != nil {
@@ -255,7 +444,7 @@ func collectFilesForComponent(path string, componentType string) ([]string, erro
 			if len(files) == 0 {
 				return nil, fmt.Errorf("no image files found in %s directory: %s", componentType, path)
 			}
-			return files, nil
+			return dedupeByContent(files, componentType), nil
 		}
 
 		// Single file
@@ -287,13 +476,67 @@ func collectFilesForComponent(path string, componentType string) ([]string, erro
 		if len(files) == 0 {
 			return nil, fmt.Errorf("no image files found in %s directory: %s", componentType, path)
 		}
-		return files, nil
+		return dedupeByContent(files, componentType), nil
 	}
 
 	// Single file
 	return []string{path}, nil
 }
 
+// fixedAxes is the number of built-in component axes (see combinationOf)
+const fixedAxes = 12
+
+// matrixCombinations returns every combination of the component axes, the
+// first axis varying slowest. Axes are in the field order of combinationOf,
+// followed by one per registered component named in extra.
+func matrixCombinations(axes [][]string, extra []string) []Combination {
+	var combinations []Combination
+	values := make([]string, len(axes))
+	var fill func(axis int)
+	fill = func(axis int) {
+		if axis == len(axes) {
+			combinations = append(combinations, combinationOf(values, extra))
+			return
+		}
+		for _, value := range axes[axis] {
+			values[axis] = value
+			fill(axis + 1)
+		}
+	}
+	fill(0)
+	return combinations
+}
+
+// combinationOf builds a combination from one value per component axis:
+// subject, outfit, over-outfit, style, hair style, hair color, makeup,
+// expression, accessories, pose, background and ambient, then the registered
+// components named in extra
+func combinationOf(values []string, extra []string) Combination {
+	combo := Combination{
+		Subject:     values[0],
+		Outfit:      values[1],
+		OverOutfit:  values[2],
+		Style:       values[3],
+		HairStyle:   values[4],
+		HairColor:   values[5],
+		Makeup:      values[6],
+		Expression:  values[7],
+		Accessories: values[8],
+		Pose:        values[9],
+		Background:  values[10],
+		Ambient:     values[11],
+	}
+	for i, name := range extra {
+		if value := values[fixedAxes+i]; value != "" {
+			if combo.Extra == nil {
+				combo.Extra = make(map[string]string)
+			}
+			combo.Extra[name] = value
+		}
+	}
+	return combo
+}
+
 // ensureAtLeastOne returns the input slice or a slice with one empty string if input is empty
 func ensureAtLeastOne(files []string) []string {
 	if len(files) == 0 {
@@ -310,12 +553,29 @@ func maxInt(a, b int) int {
 	return b
 }
 
-// hasModularComponents checks if any modular components are specified
+// minInt returns the minimum of two integers
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// hasModularComponents checks if any modular components are specified.
+// Sampling, pairwise runs and ambient sweeps work on the modular combination
+// list, so they select this path too. --pick works on either path.
 func hasModularComponents(options WorkflowOptions) bool {
-	return options.HairStyleRef != "" ||
+	return options.Sample > 0 || options.Pairwise || len(options.Ambient) > 0 ||
+		options.HairStyleRef != "" ||
 		options.HairColorRef != "" ||
 		options.MakeupRef != "" ||
 		options.ExpressionRef != "" ||
 		options.AccessoriesRef != "" ||
-		options.OverOutfitRef != ""
+		options.PoseRef != "" ||
+		options.BackgroundRef != "" ||
+		options.OverOutfitRef != "" ||
+		isStyleText(options.StyleReference) ||
+		options.StyleBlend ||
+		len(options.Weights) > 0 ||
+		len(options.Extra) > 0
 }
\ No newline at end of file
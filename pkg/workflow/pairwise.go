@@ -0,0 +1,74 @@
+package workflow
+
+// pairwiseCombinations returns combinations of the component axes in which
+// every pair of values from two different axes appears at least once, like
+// pairwise test design: broad coverage of how outfits, styles, hair and the
+// rest interact at a fraction of the full matrix. Rows are built greedily,
+// each starting from the first pair not yet covered and filling the other
+// axes with the value covering the most new pairs. The result only depends
+// on the axes, so a resumed run rebuilds the same set. Axes are as for
+// matrixCombinations.
+func pairwiseCombinations(axes [][]string, extra []string) []Combination {
+	type pair struct{ axisA, valueA, axisB, valueB int }
+	pairOf := func(axisA, valueA, axisB, valueB int) pair {
+		if axisA > axisB {
+			axisA, valueA, axisB, valueB = axisB, valueB, axisA, valueA
+		}
+		return pair{axisA, valueA, axisB, valueB}
+	}
+
+	var pairs []pair
+	uncovered := make(map[pair]bool)
+	for a := range axes {
+		for b := a + 1; b < len(axes); b++ {
+			for va := range axes[a] {
+				for vb := range axes[b] {
+					p := pair{a, va, b, vb}
+					pairs = append(pairs, p)
+					uncovered[p] = true
+				}
+			}
+		}
+	}
+
+	var combinations []Combination
+	row := make([]int, len(axes))
+	for _, first := range pairs {
+		if !uncovered[first] {
+			continue
+		}
+		for axis := range row {
+			row[axis] = -1
+		}
+		row[first.axisA], row[first.axisB] = first.valueA, first.valueB
+
+		for axis := range axes {
+			if row[axis] >= 0 {
+				continue
+			}
+			best, bestGain := 0, -1
+			for value := range axes[axis] {
+				gain := 0
+				for other, otherValue := range row {
+					if otherValue >= 0 && other != axis && uncovered[pairOf(other, otherValue, axis, value)] {
+						gain++
+					}
+				}
+				if gain > bestGain {
+					best, bestGain = value, gain
+				}
+			}
+			row[axis] = best
+		}
+
+		values := make([]string, len(axes))
+		for axis, value := range row {
+			values[axis] = axes[axis][value]
+			for other := axis + 1; other < len(axes); other++ {
+				delete(uncovered, pair{axis, value, other, row[other]})
+			}
+		}
+		combinations = append(combinations, combinationOf(values, extra))
+	}
+	return combinations
+}
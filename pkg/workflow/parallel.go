@@ -0,0 +1,83 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"img-cli/pkg/concurrent"
+)
+
+// generationQueue runs the generations of a workflow on a worker pool, up to
+// --parallel at once. The client's rate limiter still caps how many requests
+// start per second and are in flight. With one worker every generation runs
+// inline, in order. Generations write into their own result slot; the
+// workflow merges the slots in a then callback, which runs on the caller's
+// goroutine, so workflow state needs no locking.
+type generationQueue struct {
+	pool     *concurrent.WorkerPool
+	drained  chan struct{}
+	queued   int
+	deferred []func()
+}
+
+func newGenerationQueue(workers int) *generationQueue {
+	q := &generationQueue{}
+	if workers <= 1 {
+		return q
+	}
+	q.pool = concurrent.NewWorkerPool(workers)
+	q.pool.Start()
+	q.drained = make(chan struct{})
+	go func() {
+		for range q.pool.Results() {
+		}
+		close(q.drained)
+	}()
+	return q
+}
+
+// run runs fn now or queues it for the next free worker
+func (q *generationQueue) run(fn func()) {
+	if q.pool == nil {
+		fn()
+		return
+	}
+	q.queued++
+	q.pool.Submit(&concurrent.FuncTask{
+		ID: fmt.Sprintf("generation-%d", q.queued),
+		Fn: func(context.Context) error {
+			fn()
+			return nil
+		},
+	})
+}
+
+// then runs fn once the generations queued so far have finished: right away
+// when running inline, otherwise in order from wait
+func (q *generationQueue) then(fn func()) {
+	if q.pool == nil {
+		fn()
+		return
+	}
+	q.deferred = append(q.deferred, fn)
+}
+
+// wait blocks until every queued generation has finished, then runs the
+// deferred callbacks
+func (q *generationQueue) wait() {
+	if q.pool != nil {
+		q.pool.Wait()
+		<-q.drained
+		q.pool = nil
+	}
+	for _, fn := range q.deferred {
+		fn()
+	}
+	q.deferred = nil
+}
+
+// variationOutput is what one queued outfit-swap generation produced
+type variationOutput struct {
+	path  string
+	image ManifestImage
+	steps []StepResult
+}
@@ -0,0 +1,233 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/analyzer"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/models"
+	"img-cli/pkg/output"
+	"img-cli/pkg/prompts"
+	"strconv"
+	"strings"
+)
+
+// PersonComponents are the components one person of a group photo gets on
+// top of the recipe's scene (--for)
+type PersonComponents struct {
+	Person         string `json:"person"` // Selector: "2", "left", "center", "right" or part of their description
+	OutfitRef      string `json:"outfit,omitempty"`
+	HairStyleRef   string `json:"hair_style,omitempty"`
+	HairColorRef   string `json:"hair_color,omitempty"`
+	MakeupRef      string `json:"makeup,omitempty"`
+	ExpressionRef  string `json:"expression,omitempty"`
+	AccessoriesRef string `json:"accessories,omitempty"`
+}
+
+// PersonComponentKinds lists the components --for assigns, in display order
+var PersonComponentKinds = []string{"outfit", "hair-style", "hair-color", "makeup", "expression", "accessories"}
+
+// Ref returns the field of a component kind, nil for unknown kinds
+func (p *PersonComponents) Ref(kind string) *string {
+	switch kind {
+	case "outfit":
+		return &p.OutfitRef
+	case "hair-style":
+		return &p.HairStyleRef
+	case "hair-color":
+		return &p.HairColorRef
+	case "makeup":
+		return &p.MakeupRef
+	case "expression":
+		return &p.ExpressionRef
+	case "accessories":
+		return &p.AccessoriesRef
+	}
+	return nil
+}
+
+// ParsePersonAssignments parses --for values of the form
+// <person>:<component>=<value>, e.g. "left:outfit=outfits/suit.png", merging
+// the components given for the same person. Values are returned unresolved.
+func ParsePersonAssignments(values []string) ([]PersonComponents, error) {
+	var people []PersonComponents
+	index := make(map[string]int)
+	for _, value := range values {
+		person, assignment, ok := strings.Cut(value, ":")
+		kind, ref, hasRef := strings.Cut(assignment, "=")
+		person, kind, ref = strings.TrimSpace(person), strings.TrimSpace(kind), strings.TrimSpace(ref)
+		if !ok || !hasRef || person == "" || ref == "" {
+			return nil, errors.ErrInvalidInput("for", fmt.Sprintf("%q must look like <person>:<component>=<value>, e.g. left:outfit=outfits/suit.png", value))
+		}
+
+		key := strings.ToLower(person)
+		i, seen := index[key]
+		if !seen {
+			i = len(people)
+			index[key] = i
+			people = append(people, PersonComponents{Person: person})
+		}
+		field := people[i].Ref(kind)
+		if field == nil {
+			return nil, errors.ErrInvalidInput("for", fmt.Sprintf("unknown component %q in %q (use %s)", kind, value, strings.Join(PersonComponentKinds, ", ")))
+		}
+		*field = ref
+	}
+	return people, nil
+}
+
+// grouped reports whether a recipe targets people in a group photo
+func (c ModularConfig) grouped() bool {
+	return c.Person != "" || len(c.People) > 0
+}
+
+// detectPeople lists the people in a photo from left to right
+func (o *Orchestrator) detectPeople(imagePath string) ([]analyzer.Person, error) {
+	o.setAnalyzer("people", func() analyzer.Analyzer { return analyzer.NewPeopleAnalyzer(o.client) })
+	data, err := o.AnalyzeImage("people", imagePath)
+	if err != nil {
+		return nil, err
+	}
+	var found analyzer.People
+	if err := json.Unmarshal(data, &found); err != nil {
+		return nil, fmt.Errorf("failed to parse people detection: %w", err)
+	}
+	if len(found.People) == 0 {
+		return nil, errors.ErrInvalidInput("subject", "no people found in the photo")
+	}
+	return found.People, nil
+}
+
+// selectPerson returns the index of the person a selector names: a number
+// counted from the left, left/center/right, or text from their description
+// that matches only them
+func selectPerson(people []analyzer.Person, selector string) (int, error) {
+	sel := strings.ToLower(strings.TrimSpace(selector))
+	if n, err := strconv.Atoi(sel); err == nil {
+		if n >= 1 && n <= len(people) {
+			return n - 1, nil
+		}
+		return 0, personNotFound(people, selector)
+	}
+	switch sel {
+	case "left", "leftmost":
+		return 0, nil
+	case "right", "rightmost":
+		return len(people) - 1, nil
+	case "center", "centre", "middle":
+		return (len(people) - 1) / 2, nil
+	}
+
+	match := -1
+	for i, person := range people {
+		if strings.Contains(strings.ToLower(person.Description), sel) {
+			if match >= 0 {
+				return 0, errors.ErrInvalidInput("person", fmt.Sprintf("%q matches more than one person; use their number instead.%s", selector, peopleList(people)))
+			}
+			match = i
+		}
+	}
+	if match < 0 {
+		return 0, personNotFound(people, selector)
+	}
+	return match, nil
+}
+
+func personNotFound(people []analyzer.Person, selector string) error {
+	return errors.ErrInvalidInput("person", fmt.Sprintf("no person %q in the photo.%s", selector, peopleList(people)))
+}
+
+// peopleList numbers the people found, for error messages
+func peopleList(people []analyzer.Person) string {
+	var b strings.Builder
+	b.WriteString(" People found, from the left:")
+	for i, person := range people {
+		fmt.Fprintf(&b, "\n  %d. %s", i+1, person.Description)
+	}
+	return b.String()
+}
+
+// groupPerson is a person in the group prompt
+type groupPerson struct {
+	Label       string // e.g. "person 2 from the left"
+	Description string
+	Components  *models.ModularComponents // Set for people with their own components
+}
+
+// groupPromptData is what the people template sees
+type groupPromptData struct {
+	Count  int
+	Target *groupPerson // Gets the recipe's components; nil when everyone without their own does
+	People []groupPerson
+}
+
+// groupPromptSection finds the people a recipe targets in its subject photo,
+// analyzes their own components and renders the "people" template appended to
+// the modular prompt
+func (o *Orchestrator) groupPromptSection(config ModularConfig) (string, error) {
+	people, err := o.detectPeople(config.SubjectPath)
+	if err != nil {
+		return "", err
+	}
+	output.Progress.Printf("👥 %d people in the photo\n", len(people))
+	label := func(i int) groupPerson {
+		return groupPerson{Label: fmt.Sprintf("person %d from the left", i+1), Description: people[i].Description}
+	}
+
+	data := groupPromptData{Count: len(people)}
+	if config.Person != "" {
+		i, err := selectPerson(people, config.Person)
+		if err != nil {
+			return "", err
+		}
+		target := label(i)
+		data.Target = &target
+		output.Progress.Printf("   Applying the recipe to %s: %s\n", target.Label, target.Description)
+	}
+
+	// Selectors naming the same person ("right" and "3") merge their components
+	var order []int
+	assignments := make(map[int]PersonComponents)
+	for _, assigned := range config.People {
+		i, err := selectPerson(people, assigned.Person)
+		if err != nil {
+			return "", err
+		}
+		merged, seen := assignments[i]
+		if !seen {
+			order = append(order, i)
+		}
+		for _, kind := range PersonComponentKinds {
+			if ref := *assigned.Ref(kind); ref != "" {
+				*merged.Ref(kind) = ref
+			}
+		}
+		assignments[i] = merged
+	}
+
+	for _, i := range order {
+		assigned := assignments[i]
+		// Only the person's own components; the scene comes from the recipe
+		personConfig := config
+		personConfig.OutfitRef = assigned.OutfitRef
+		personConfig.OverOutfitRef = ""
+		personConfig.StyleRef = ""
+		personConfig.HairStyleRef = assigned.HairStyleRef
+		personConfig.HairColorRef = assigned.HairColorRef
+		personConfig.MakeupRef = assigned.MakeupRef
+		personConfig.ExpressionRef = assigned.ExpressionRef
+		personConfig.AccessoriesRef = assigned.AccessoriesRef
+		personConfig.PoseRef = ""
+		personConfig.BackgroundRef = ""
+		components, err := o.analyzeModularComponents(personConfig)
+		if err != nil {
+			return "", fmt.Errorf("failed to analyze the components of person %d: %w", i+1, err)
+		}
+		person := label(i)
+		person.Components = components
+		data.People = append(data.People, person)
+		output.Progress.Printf("   Own components for %s: %s\n", person.Label, person.Description)
+	}
+
+	return prompts.Render("people", data)
+}
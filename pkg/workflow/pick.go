@@ -0,0 +1,134 @@
+package workflow
+
+import (
+	"img-cli/pkg/analyzer"
+	"img-cli/pkg/cost"
+	"img-cli/pkg/prompt"
+	"path/filepath"
+	"strings"
+)
+
+// pickCombinations lets the user choose which combinations to run and how many
+// variations each gets. It returns the chosen combinations with their
+// variations set, or ok=false if the user cancelled.
+func pickCombinations(combinations []Combination, options WorkflowOptions) ([]Combination, bool, error) {
+	rows := make([]prompt.PickRow, len(combinations))
+	for i, combo := range combinations {
+		rows[i] = prompt.PickRow{Label: combinationLabel(combo), Variations: combo.variations(options.Variations)}
+	}
+
+	picked, ok, err := prompt.PickRows(rows, func(images int) float64 {
+		return cost.Of(images + options.Chain.ExtraImages(images) + options.Post.ExtraImages(images) + options.Verify.ExtraImages(images))
+	})
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	var selected []Combination
+	for i, row := range picked {
+		if row.Variations > 0 {
+			combo := combinations[i]
+			combo.Variations = row.Variations
+			selected = append(selected, combo)
+		}
+	}
+	return selected, true, nil
+}
+
+// outfitSwapCombinations lists the subject × outfit × style combinations of
+// the standard outfit-swap workflow in the order it runs them. Without a
+// style reference an outfit image is its own style.
+func outfitSwapCombinations(subjects, outfits []string, options WorkflowOptions) []Combination {
+	var combinations []Combination
+	for _, subject := range subjects {
+		for _, outfit := range outfits {
+			styleSource := options.StyleReference
+			if styleSource == "" {
+				styleSource = outfit
+			}
+			styles := []string{""}
+			if styleSource != "" {
+				if files, err := collectImageFiles(styleSource); err == nil {
+					styles, _ = uniqueByContent(files)
+				}
+			}
+			for _, style := range styles {
+				combo := Combination{Subject: subject, Outfit: outfit, Style: style}
+				if combo.Outfit == "" {
+					combo.Outfit = options.OutfitText
+				}
+				combinations = append(combinations, combo)
+			}
+		}
+	}
+	return combinations
+}
+
+// pickedCombinations records what --pick chose for the standard outfit-swap
+// workflow. A nil one picks everything.
+type pickedCombinations struct {
+	variations map[string]int  // Variations of each chosen combination by resumeKey
+	pairs      map[string]bool // Subject/outfit pairs with a chosen combination
+}
+
+func newPickedCombinations(chosen []Combination) *pickedCombinations {
+	picked := &pickedCombinations{variations: make(map[string]int), pairs: make(map[string]bool)}
+	for _, combo := range chosen {
+		picked.variations[resumeKey(combo)] = combo.Variations
+		picked.pairs[resumeKey(Combination{Subject: combo.Subject, Outfit: combo.Outfit})] = true
+	}
+	return picked
+}
+
+// pair reports whether any combination of a subject and outfit was picked
+func (p *pickedCombinations) pair(subject, outfit string) bool {
+	return p == nil || p.pairs[resumeKey(Combination{Subject: subject, Outfit: outfit})]
+}
+
+// variationsOf returns the variations picked for a combination, 0 if it was
+// left out, or runDefault when nothing was picked
+func (p *pickedCombinations) variationsOf(combo Combination, runDefault int) int {
+	if p == nil {
+		return runDefault
+	}
+	return p.variations[resumeKey(combo)]
+}
+
+// combinationLabel is a one-line summary of a combination's inputs
+func combinationLabel(combo Combination) string {
+	var parts []string
+	inputs := []struct{ name, value string }{
+		{"", combo.Subject},
+		{"outfit", combo.Outfit},
+		{"over", combo.OverOutfit},
+		{"style", combo.Style},
+		{"hair", combo.HairStyle},
+		{"color", combo.HairColor},
+		{"makeup", combo.Makeup},
+		{"expr", combo.Expression},
+		{"acc", combo.Accessories},
+		{"pose", combo.Pose},
+		{"bg", combo.Background},
+		{"ambient", combo.Ambient},
+	}
+	for _, name := range analyzer.Components() {
+		_, component, _ := analyzer.LookupComponent(name)
+		inputs = append(inputs, struct{ name, value string }{component.Flag, combo.Extra[name]})
+	}
+	for _, input := range inputs {
+		if input.value == "" {
+			continue
+		}
+		value := input.value
+		if isFilePath(value) {
+			value = strings.TrimSuffix(filepath.Base(value), filepath.Ext(value))
+		} else {
+			value = `"` + value + `"`
+		}
+		if input.name != "" {
+			value = input.name + "=" + value
+		}
+		parts = append(parts, value)
+	}
+	return strings.Join(parts, "  ")
+}
@@ -0,0 +1,150 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/logger"
+	"img-cli/pkg/models"
+	"img-cli/pkg/output"
+	"strings"
+)
+
+// plausibilityRule pairs garments with scenes they don't belong in
+type plausibilityRule struct {
+	Garment  string // What the garments have in common, for messages
+	Scene    string // What the scenes have in common, for messages
+	Garments []string
+	Scenes   []string
+}
+
+// implausibility is a garment/scene pair found by a rule
+type implausibility struct {
+	Rule    plausibilityRule
+	Garment string // Matched garment keyword
+	Scene   string // Matched scene keyword
+}
+
+func (i implausibility) String() string {
+	return fmt.Sprintf("%s (%s) in a %s scene (%s)", i.Rule.Garment, i.Garment, i.Rule.Scene, i.Scene)
+}
+
+var (
+	hotSceneKeywords = []string{
+		"beach", "tropical", "tropics", "desert", "poolside", "swimming pool", "seaside", "sunbathing",
+		"palm tree", "summer", "heatwave", "savanna",
+	}
+	coldSceneKeywords = []string{
+		"snow", "snowy", "snowstorm", "snowfall", "blizzard", "winter", "wintry", "frozen", "arctic",
+		"glacier", "frost", "frosty", "sleet", "ski slope", "tundra",
+	}
+
+	plausibilityRules = []plausibilityRule{
+		{
+			Garment: "heavy cold-weather garment",
+			Scene:   "hot-weather",
+			Garments: []string{
+				"shearling", "parka", "puffer", "down jacket", "down coat", "fur coat", "overcoat", "wool coat",
+				"peacoat", "duffle coat", "snow boots", "ski jacket", "balaclava", "earmuffs", "mittens",
+			},
+			Scenes: hotSceneKeywords,
+		},
+		{
+			Garment: "exposed warm-weather garment",
+			Scene:   "cold-weather",
+			Garments: []string{
+				"strapless", "bikini", "swimsuit", "swimwear", "swim trunks", "sundress", "tank top", "shorts",
+				"sandals", "flip-flops", "sleeveless", "backless", "crop top", "halter", "sarong",
+			},
+			Scenes: coldSceneKeywords,
+		},
+	}
+)
+
+// checkPlausibility finds garments that clash with the scene they are placed in.
+// It is a keyword check: reviewers reject these images on sight, so a cheap
+// check before paying for generation is worth the occasional false alarm.
+func checkPlausibility(garments, scene string) []implausibility {
+	if garments == "" || scene == "" {
+		return nil
+	}
+
+	var found []implausibility
+	for _, rule := range plausibilityRules {
+		garment := firstWordMatch(garments, rule.Garments)
+		if garment == "" {
+			continue
+		}
+		if match := firstWordMatch(scene, rule.Scenes); match != "" {
+			found = append(found, implausibility{Rule: rule, Garment: garment, Scene: match})
+		}
+	}
+	return found
+}
+
+// plausibilityError reports implausible combinations as a validation error
+// naming the override flag
+func plausibilityError(label string, issues []implausibility) error {
+	reasons := make([]string, len(issues))
+	for i, issue := range issues {
+		reasons[i] = issue.String()
+		logger.Warn("Implausible combination", "recipe", label, "garment", issue.Garment, "scene", issue.Scene)
+	}
+	return errors.Newf(errors.ValidationError, "implausible combination: %s (use --allow-implausible to generate anyway)",
+		strings.Join(reasons, "; ")).WithContext("recipe", label)
+}
+
+// warnImplausible prints combinations that are generated despite failing the check
+func warnImplausible(issues []implausibility) {
+	for _, issue := range issues {
+		output.Progress.Printf("    ⚠️  Implausible: %s; generating anyway (--allow-implausible)\n", issue)
+	}
+}
+
+// styleScene returns the setting-related text of a visual style analysis.
+// Without the background only the mood and lighting are used.
+func styleScene(styleData json.RawMessage, withBackground bool) string {
+	var style map[string]interface{}
+	if err := json.Unmarshal(styleData, &style); err != nil {
+		return ""
+	}
+	fields := []string{"mood", "lighting"}
+	if withBackground {
+		fields = append([]string{"background"}, fields...)
+	}
+	var parts []string
+	for _, field := range fields {
+		if value, ok := style[field].(string); ok {
+			parts = append(parts, value)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// modularPlausibility checks the outfit components of a modular recipe
+// against its style and background components
+func modularPlausibility(components *models.ModularComponents) []implausibility {
+	if components.Style == nil && components.Background == nil {
+		return nil
+	}
+	var scenes []string
+	if components.Style != nil {
+		scene := components.Style.Description
+		if components.Style.JSONData != nil {
+			scene = styleScene(components.Style.JSONData, components.Background == nil)
+		}
+		scenes = append(scenes, scene)
+	}
+	if components.Background != nil {
+		scenes = append(scenes, components.Background.Description)
+	}
+	scene := strings.Join(scenes, " ")
+
+	var garments []string
+	for _, c := range []*models.ComponentData{components.Outfit, components.OverOutfit} {
+		if c != nil {
+			garments = append(garments, c.Description)
+		}
+	}
+	return checkPlausibility(strings.Join(garments, " "), scene)
+}
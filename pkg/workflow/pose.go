@@ -0,0 +1,45 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// poseRemovedFilter notes that the pose was dropped from a style because --pose supplies it
+const poseRemovedFilter = "pose removed (supplied by pose)"
+
+// extractPoseDescription extracts the pose description from analysis
+func (o *Orchestrator) extractPoseDescription(data json.RawMessage) string {
+	var result map[string]interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "Natural pose"
+	}
+
+	var parts []string
+	for _, field := range []struct{ key, label string }{
+		{"stance", "Stance"},
+		{"weight_distribution", "Weight"},
+		{"torso", "Torso"},
+		{"head", "Head"},
+		{"arms", "Arms"},
+		{"hands", "Hands"},
+		{"legs", "Legs"},
+		{"gesture", "Gesture"},
+		{"energy", "Energy"},
+	} {
+		if value, ok := result[field.key].(string); ok && value != "" && !placeholderField(value) {
+			parts = append(parts, fmt.Sprintf("%s: %s", field.label, value))
+		}
+	}
+
+	if overall, ok := result["overall"].(string); ok && overall != "" {
+		parts = append(parts, overall)
+	}
+
+	if len(parts) > 0 {
+		return strings.Join(parts, ". ")
+	}
+
+	return "Natural pose"
+}
@@ -0,0 +1,149 @@
+package workflow
+
+import (
+	"fmt"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/generator"
+	"img-cli/pkg/imaging"
+	"img-cli/pkg/logger"
+	"img-cli/pkg/output"
+	"img-cli/pkg/workspace"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Bundled upscalers (--upscaler)
+const (
+	UpscalerRegenerate = "regenerate" // Re-generate through the image provider; billed like a generation
+	UpscalerResample   = "resample"   // Interpolate locally; free, adds no detail
+)
+
+// PostOptions controls the post-processing applied to every generated image
+type PostOptions struct {
+	LUTPath  string // .cube LUT applied to each output for deterministic color grading
+	Upscale  int    // Also write an _upscaled copy of each output at 2x or 4x (0 = off)
+	Upscaler string // Upscaler for Upscale: regenerate (default), resample or one added with WithUpscaler
+	FaceLock bool   // Paste the subject's face from its photo back over each output's (costs one analysis per image)
+}
+
+// ExtraImages is the number of billed upscales for a run with n outputs
+func (p PostOptions) ExtraImages(n int) int {
+	if p.Upscale == 0 || p.Upscaler == UpscalerResample {
+		return 0
+	}
+	return n
+}
+
+// upscaler is the name of the upscaler to use
+func (p PostOptions) upscaler() string {
+	if p.Upscaler == "" {
+		return UpscalerRegenerate
+	}
+	return p.Upscaler
+}
+
+// ParseUpscale parses an --upscale factor: 2x or 4x ("" is off)
+func ParseUpscale(spec string) (int, error) {
+	if spec == "" {
+		return 0, nil
+	}
+	factor, err := strconv.Atoi(strings.TrimSuffix(strings.ToLower(strings.TrimSpace(spec)), "x"))
+	if err != nil || (factor != 2 && factor != 4) {
+		return 0, errors.ErrInvalidInput("upscale", fmt.Sprintf("must be 2x or 4x, got %q", spec))
+	}
+	return factor, nil
+}
+
+// ValidateUpscaler checks an --upscaler name against the bundled upscalers
+func ValidateUpscaler(name string) error {
+	switch name {
+	case "", UpscalerRegenerate, UpscalerResample:
+		return nil
+	}
+	return errors.ErrInvalidInput("upscaler", fmt.Sprintf("unknown upscaler %q (use %s or %s)", name, UpscalerRegenerate, UpscalerResample))
+}
+
+// applyPostChain runs the configured post-processing steps on a generated image in place
+func applyPostChain(outputPath string, post PostOptions) error {
+	if post.LUTPath != "" {
+		if err := applyLUT(outputPath, post.LUTPath); err != nil {
+			return fmt.Errorf("failed to apply LUT: %w", err)
+		}
+	}
+	return nil
+}
+
+// applyLUT grades an image with a .cube LUT and overwrites it
+func applyLUT(imagePath, lutPath string) error {
+	lut, err := imaging.LoadCube(lutPath)
+	if err != nil {
+		return fmt.Errorf("error loading LUT %s: %w", lutPath, err)
+	}
+
+	img, err := imaging.Load(imagePath)
+	if err != nil {
+		return err
+	}
+
+	// Write to the run's temp dir first so a crash never leaves a half-written output
+	graded := lut.Apply(img)
+	tempPath := filepath.Join(workspace.TempDir(), "lut_"+filepath.Base(imagePath))
+	if err := imaging.Save(tempPath, graded); err != nil {
+		return err
+	}
+	if err := os.Rename(tempPath, imagePath); err != nil {
+		// Temp dir may be on another volume; fall back to writing in place
+		os.Remove(tempPath)
+		if err := imaging.Save(imagePath, graded); err != nil {
+			return err
+		}
+	}
+
+	logger.Debug("Applied LUT", "image", filepath.Base(imagePath), "lut", filepath.Base(lutPath))
+	return nil
+}
+
+// upscaleOutput writes the _upscaled copy of a generated image next to it when
+// --upscale is set. It returns the copy's path, or "" when upscaling is off or
+// failed; a failed upscale only warns since the original is still there.
+func (o *Orchestrator) upscaleOutput(outputPath string, post PostOptions) string {
+	if post.Upscale == 0 {
+		return ""
+	}
+	name := post.upscaler()
+	upscaler, ok := o.upscalers[name]
+	if !ok {
+		logger.Warn("Unknown upscaler, not upscaling", "upscaler", name)
+		return ""
+	}
+
+	// Upscalers encode PNG unless the output is a JPEG
+	ext := strings.ToLower(filepath.Ext(outputPath))
+	if ext != ".jpg" && ext != ".jpeg" {
+		ext = ".png"
+	}
+	upscaledPath := strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + "_upscaled" + ext
+
+	upscale := func(_ string, params generator.GenerateParams) (*generator.GenerateResult, error) {
+		if err := upscaler.Upscale(params.ImagePath, upscaledPath, post.Upscale); err != nil {
+			return nil, err
+		}
+		return &generator.GenerateResult{Type: "upscale", OutputPath: upscaledPath}, nil
+	}
+	params := generator.GenerateParams{ImagePath: outputPath, OutputDir: filepath.Dir(outputPath)}
+	var err error
+	if name == UpscalerResample {
+		_, err = upscale("upscale", params)
+	} else {
+		// Billed like a generation, so it counts against the budget cap
+		_, err = o.generateThrough("upscale", params, upscale)
+	}
+	if err != nil {
+		logger.Warn("Upscaling failed", "image", filepath.Base(outputPath), "upscaler", name, "error", err)
+		return ""
+	}
+	output.Progress.Printf("      ⬆️  Upscaled %dx: %s\n", post.Upscale, filepath.Base(upscaledPath))
+	return upscaledPath
+}
@@ -0,0 +1,123 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/analyzer"
+	"img-cli/pkg/config"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/imaging"
+	"img-cli/pkg/logger"
+	"img-cli/pkg/output"
+	"path/filepath"
+)
+
+// subjectProblem is an issue found with a subject photo during pre-flight
+type subjectProblem struct {
+	Reason string
+	Fatal  bool // The subject is unusable and is dropped from the run
+}
+
+// preflightSubjects validates every subject photo before any generation starts.
+// Unusable subjects (no visible face, several people, too small) are reported up
+// front and dropped from the run; softer problems are reported as warnings.
+func (o *Orchestrator) preflightSubjects(subjects []string) ([]string, error) {
+	o.setAnalyzer("subject_check", func() analyzer.Analyzer { return analyzer.NewSubjectCheckAnalyzer(o.client) })
+	cfg := config.DefaultPreflightConfig()
+
+	output.Progress.Printf("Checking %d subject photo(s)...\n", len(subjects))
+
+	var usable []string
+	var dropped, warned int
+	for _, subject := range subjects {
+		problems := o.checkSubject(subject, cfg)
+
+		fatal := false
+		for _, p := range problems {
+			if p.Fatal {
+				fatal = true
+			}
+		}
+		if len(problems) > 0 {
+			status := "Warning"
+			if fatal {
+				status = "Skipping"
+			}
+			output.Progress.Printf("  %s %s:\n", status, filepath.Base(subject))
+			for _, p := range problems {
+				output.Progress.Printf("    - %s\n", p.Reason)
+			}
+		}
+
+		if fatal {
+			dropped++
+			logger.Warn("Subject failed pre-flight", "subject", subject)
+			continue
+		}
+		if len(problems) > 0 {
+			warned++
+		}
+		usable = append(usable, subject)
+	}
+
+	if len(usable) == 0 {
+		return nil, errors.New(errors.ValidationError, "no usable subject photos (see pre-flight report above)")
+	}
+	if dropped > 0 || warned > 0 {
+		output.Progress.Printf("Pre-flight: %d usable, %d skipped, %d with warnings\n\n", len(usable), dropped, warned)
+	} else {
+		output.Progress.Printf("✓ All subjects passed pre-flight\n\n")
+	}
+	return usable, nil
+}
+
+// checkSubject runs the local and vision checks on a single subject photo
+func (o *Orchestrator) checkSubject(subject string, cfg *config.PreflightConfig) []subjectProblem {
+	var problems []subjectProblem
+
+	if width, height, err := imaging.Size(subject); err == nil {
+		if minInt(width, height) < cfg.MinSubjectSize {
+			problems = append(problems, subjectProblem{
+				Reason: fmt.Sprintf("resolution %dx%d is below the %dpx minimum", width, height, cfg.MinSubjectSize),
+				Fatal:  true,
+			})
+		}
+	} else {
+		// Formats without a local decoder (e.g. WebP) are left to the vision check
+		logger.Debug("Could not read subject size", "subject", subject, "error", err)
+	}
+
+	data, err := o.AnalyzeImage("subject_check", subject)
+	if err != nil {
+		// Don't block a run because the check itself failed
+		return append(problems, subjectProblem{Reason: fmt.Sprintf("could not run face check: %v", err)})
+	}
+
+	var check analyzer.SubjectCheck
+	if err := json.Unmarshal(data, &check); err != nil {
+		return append(problems, subjectProblem{Reason: "could not parse face check result"})
+	}
+
+	switch {
+	case check.PersonCount == 0 || !check.FaceVisible:
+		problems = append(problems, subjectProblem{Reason: "no recognizable face found", Fatal: true})
+	case check.PersonCount > 1:
+		problems = append(problems, subjectProblem{
+			Reason: fmt.Sprintf("%d people in the photo; use a photo of a single person, or pick one with generate-modular --person", check.PersonCount),
+			Fatal:  true,
+		})
+	}
+
+	switch check.FaceOcclusion {
+	case "heavy":
+		problems = append(problems, subjectProblem{Reason: "face is heavily occluded", Fatal: true})
+	case "partial":
+		problems = append(problems, subjectProblem{Reason: "face is partially occluded"})
+	}
+
+	for _, issue := range check.Issues {
+		problems = append(problems, subjectProblem{Reason: issue})
+	}
+
+	return problems
+}
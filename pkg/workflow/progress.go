@@ -0,0 +1,245 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/cost"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Progress event kinds
+const (
+	ProgressStart      = "start"      // A batch begins; Total is set
+	ProgressGenerating = "generating" // An image request is about to be sent
+	ProgressGenerated  = "generated"  // An image was saved to Output
+	ProgressFailed     = "failed"     // An image request failed with Error
+	ProgressPlanned    = "planned"    // A dry run wrote the prompt instead
+	ProgressFinish     = "finish"     // The batch is over
+)
+
+// ProgressEvent is one update of a batch run. Counts cover the whole batch;
+// Completed includes failed and planned images.
+type ProgressEvent struct {
+	Event          string    `json:"event"`
+	Time           time.Time `json:"time"`
+	Label          string    `json:"label,omitempty"`
+	Variation      int       `json:"variation,omitempty"`
+	Variations     int       `json:"variations,omitempty"`
+	Output         string    `json:"output,omitempty"`
+	Error          string    `json:"error,omitempty"`
+	Completed      int       `json:"completed"`
+	Failed         int       `json:"failed"`
+	Total          int       `json:"total"`
+	ImageSeconds   float64   `json:"image_seconds,omitempty"` // Time the image took to generate
+	ElapsedSeconds float64   `json:"elapsed_seconds"`
+	ETASeconds     float64   `json:"eta_seconds,omitempty"` // From the rate of the last few images
+	CostUSD        float64   `json:"cost_usd"`              // Generated images times the configured cost per image
+}
+
+// ProgressReporter renders the progress of batch runs. Events of parallel
+// generations arrive one at a time.
+type ProgressReporter interface {
+	Report(event ProgressEvent)
+}
+
+// NewProgressReporter returns the reporter for a --progress format: "bar"
+// (the default) draws a bar line per image, "json" writes one JSON object per line
+func NewProgressReporter(format string, w io.Writer) (ProgressReporter, error) {
+	switch format {
+	case "", "bar":
+		return &barProgress{w: w}, nil
+	case "json":
+		return &jsonProgress{encoder: json.NewEncoder(w)}, nil
+	}
+	return nil, fmt.Errorf("unknown progress format %q (use bar or json)", format)
+}
+
+// WithProgress sends the progress of batch runs to reporter instead of the
+// default progress bar on stdout
+func WithProgress(reporter ProgressReporter) Option {
+	return func(o *Orchestrator) {
+		o.progress = newProgressTracker(reporter)
+	}
+}
+
+// barProgress prints a progress bar after each image
+type barProgress struct {
+	w io.Writer
+}
+
+const progressBarWidth = 20
+
+func (b *barProgress) Report(e ProgressEvent) {
+	switch e.Event {
+	case ProgressGenerating:
+		if e.Variations > 1 {
+			fmt.Fprintf(b.w, "      Generating variation %d of %d...\n", e.Variation, e.Variations)
+		} else {
+			fmt.Fprintf(b.w, "      Generating image...\n")
+		}
+	case ProgressGenerated, ProgressFailed, ProgressPlanned:
+		filled := 0
+		if e.Total > 0 {
+			filled = min(progressBarWidth, e.Completed*progressBarWidth/e.Total)
+		}
+		line := fmt.Sprintf("      [%s%s] %d/%d", strings.Repeat("█", filled), strings.Repeat("░", progressBarWidth-filled), e.Completed, e.Total)
+		if e.Failed > 0 {
+			line += fmt.Sprintf(" (%d failed)", e.Failed)
+		}
+		if e.ImageSeconds > 0 {
+			line += fmt.Sprintf(" · %s/image", progressDuration(e.ImageSeconds))
+		}
+		if e.ETASeconds > 0 {
+			line += fmt.Sprintf(" · ETA %s", progressDuration(e.ETASeconds))
+		}
+		line += fmt.Sprintf(" · $%.2f", e.CostUSD)
+		fmt.Fprintln(b.w, line)
+	case ProgressFinish:
+		if e.Completed > 0 {
+			fmt.Fprintf(b.w, "\n⏱️  %d/%d image(s) in %s, $%.2f\n", e.Completed, e.Total, progressDuration(e.ElapsedSeconds), e.CostUSD)
+		}
+	}
+}
+
+// progressDuration formats seconds for the progress bar: 850ms, 4.2s, 3m10s
+func progressDuration(seconds float64) string {
+	d := time.Duration(seconds * float64(time.Second))
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	if d < time.Minute {
+		return d.Round(100 * time.Millisecond).String()
+	}
+	return d.Round(time.Second).String()
+}
+
+// jsonProgress writes every event as a line of JSON
+type jsonProgress struct {
+	encoder *json.Encoder
+}
+
+func (j *jsonProgress) Report(e ProgressEvent) {
+	j.encoder.Encode(e)
+}
+
+// etaWindow is how many recent images the ETA is averaged over
+const etaWindow = 5
+
+// progressTracker counts the images of a batch and reports each change.
+// Batches nest: a workflow that runs others (outfit-swap combinations, ambient
+// sweeps) begins the batch with the full total and the inner runs only add
+// their images to it.
+type progressTracker struct {
+	mu           sync.Mutex
+	reporter     ProgressReporter
+	costPerImage float64
+
+	depth     int
+	total     int
+	completed int
+	failed    int
+	generated int
+	started   time.Time
+	finished  []time.Time // Start of the batch, then the last etaWindow completions
+}
+
+func newProgressTracker(reporter ProgressReporter) *progressTracker {
+	return &progressTracker{
+		reporter:     reporter,
+		costPerImage: cost.PerImage(),
+	}
+}
+
+// begin starts a batch of total images, or joins the batch already running
+func (p *progressTracker) begin(total int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.depth++
+	if p.depth > 1 {
+		return
+	}
+	p.total, p.completed, p.failed, p.generated = total, 0, 0, 0
+	p.started = time.Now()
+	p.finished = []time.Time{p.started}
+	p.report(ProgressEvent{Event: ProgressStart}, 0)
+}
+
+// end finishes the batch once the outermost run ends
+func (p *progressTracker) end() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.depth--
+	if p.depth == 0 {
+		p.report(ProgressEvent{Event: ProgressFinish}, 0)
+	}
+}
+
+// skip removes images from the total that won't be generated after all,
+// like combinations that are resumed or fail their analysis
+func (p *progressTracker) skip(images int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.total = max(p.completed, p.total-images)
+}
+
+// generating reports an image request about to be sent
+func (p *progressTracker) generating(label string, variation, variations int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.report(ProgressEvent{Event: ProgressGenerating, Label: label, Variation: variation, Variations: variations}, 0)
+}
+
+// done reports a finished image request: saved to output, failed with err,
+// or planned by a dry run
+func (p *progressTracker) done(label, output string, took time.Duration, err error, planned bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	event := ProgressEvent{Event: ProgressGenerated, Label: label, Output: output, ImageSeconds: took.Seconds()}
+	p.completed++
+	switch {
+	case planned:
+		event.Event, event.ImageSeconds = ProgressPlanned, 0
+	case err != nil:
+		event.Event, event.Error = ProgressFailed, err.Error()
+		p.failed++
+	default:
+		p.generated++
+	}
+	p.total = max(p.total, p.completed)
+
+	p.finished = append(p.finished, time.Now())
+	if len(p.finished) > etaWindow+1 {
+		p.finished = p.finished[1:]
+	}
+	p.report(event, p.eta())
+}
+
+// eta extrapolates the time between the last few completions, which
+// accounts for parallel generations, to the images still to come
+func (p *progressTracker) eta() time.Duration {
+	remaining := p.total - p.completed
+	if remaining <= 0 || len(p.finished) < 2 {
+		return 0
+	}
+	window := p.finished[len(p.finished)-1].Sub(p.finished[0])
+	return window / time.Duration(len(p.finished)-1) * time.Duration(remaining)
+}
+
+// report fills in the batch counts and sends the event; p.mu must be held
+func (p *progressTracker) report(event ProgressEvent, eta time.Duration) {
+	if p.reporter == nil {
+		return
+	}
+	event.Time = time.Now()
+	event.Completed = p.completed
+	event.Failed = p.failed
+	event.Total = p.total
+	event.ElapsedSeconds = time.Since(p.started).Seconds()
+	event.ETASeconds = eta.Seconds()
+	event.CostUSD = float64(p.generated) * p.costPerImage
+	p.reporter.Report(event)
+}
@@ -0,0 +1,63 @@
+package workflow
+
+import (
+	"img-cli/pkg/config"
+	"img-cli/pkg/metadata"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+// An image made by the fallback provider is attributed to it in the sidecar,
+// the embedded metadata and the manifest, not to the main provider
+func TestProvenanceNamesTheFallbackProvider(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":{"message":"overloaded"}}`, http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+	var requests atomic.Int32
+	t.Setenv("IMG_CLI_PROVIDER", config.ProviderOpenAI)
+	t.Setenv("OPENAI_API_KEY", "test-key")
+	t.Setenv("IMG_CLI_OPENAI_BASE_URL", failing.URL)
+	t.Setenv("IMG_CLI_FALLBACK_PROVIDER", config.ProviderSD)
+	t.Setenv("IMG_CLI_SD_URL", fakeSD(t, &requests))
+	t.Setenv("IMG_CLI_MAX_RETRIES", "0")
+
+	outputDir := filepath.Join(t.TempDir(), "fallback")
+	results, err := NewOrchestrator("test-key").RunModularWorkflow(ModularConfig{
+		SubjectPath: testSubject(t),
+		MakeupRef:   "smoky eye",
+		Variations:  1,
+		OutputDir:   outputDir,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || requests.Load() != 1 {
+		t.Fatalf("got %d image(s) from %d fallback request(s), want 1", len(results), requests.Load())
+	}
+
+	sidecar, err := ReadSidecar(results[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sidecar.Provider != config.ProviderSD || sidecar.Model != config.SDBackendA1111 {
+		t.Errorf("sidecar names %s/%s, want the fallback sd/a1111", sidecar.Provider, sidecar.Model)
+	}
+	embedded, err := metadata.Read(results[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if embedded.Provider != config.ProviderSD || embedded.Model != config.SDBackendA1111 {
+		t.Errorf("embedded metadata names %s/%s, want sd/a1111", embedded.Provider, embedded.Model)
+	}
+	manifest, err := ReadManifest(outputDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if model := manifest.Images[0].Model; model.Provider != config.ProviderSD || model.Model != config.SDBackendA1111 {
+		t.Errorf("manifest names %s/%s, want sd/a1111", model.Provider, model.Model)
+	}
+}
@@ -0,0 +1,244 @@
+package workflow
+
+import (
+	"fmt"
+	"img-cli/pkg/analyzer"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/workspace"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// RecipeComponents lists the component names accepted by --set, in display
+// order: the built-in components, then the flags of those added with
+// analyzer.Register
+func RecipeComponents() []string {
+	names := []string{
+		"subject", "outfit", "over-outfit", "style", "hair-style", "hair-color", "makeup", "expression", "accessories", "pose", "background",
+	}
+	for _, name := range analyzer.Components() {
+		_, component, _ := analyzer.LookupComponent(name)
+		names = append(names, component.Flag)
+	}
+	return names
+}
+
+// Recipe rebuilds the modular configuration that produced an image from its sidecar
+func (s *Sidecar) Recipe() (ModularConfig, error) {
+	if s.Workflow == "inpaint" {
+		return ModularConfig{}, errors.New(errors.ValidationError, "inpainted images can't be regenerated as a recipe; run inpaint again with the mask recorded in the sidecar")
+	}
+	config := ModularConfig{
+		SubjectPath: workspace.Resolve(s.Provenance.Subject.File),
+		Variations:  1,
+	}
+	if config.SubjectPath == "" {
+		return config, errors.New(errors.ValidationError, "sidecar does not record a subject image")
+	}
+
+	refs := map[string]*string{
+		"outfit":      &config.OutfitRef,
+		"over_outfit": &config.OverOutfitRef,
+		"style":       &config.StyleRef,
+		"hair_style":  &config.HairStyleRef,
+		"hair_color":  &config.HairColorRef,
+		"makeup":      &config.MakeupRef,
+		"expression":  &config.ExpressionRef,
+		"accessories": &config.AccessoriesRef,
+		"pose":        &config.PoseRef,
+		"background":  &config.BackgroundRef,
+	}
+	for name, source := range s.Provenance.Components {
+		if source.Weight != 0 {
+			if config.Weights == nil {
+				config.Weights = make(map[string]float64)
+			}
+			config.Weights[name] = source.Weight
+		}
+		if ref, ok := refs[name]; ok {
+			*ref = recipeInput(source)
+		} else if _, _, ok := analyzer.LookupComponent(name); ok {
+			if config.Extra == nil {
+				config.Extra = make(map[string]string)
+			}
+			config.Extra[name] = recipeInput(source)
+		}
+	}
+
+	if style, ok := s.Provenance.Components["style"]; ok && style.Analyzer == "style_blend" {
+		config.StyleBlend = true
+	}
+
+	// Combined-workflow hair from a separate reference maps onto both hair components;
+	// hair taken from the outfit reference comes back with the outfit analysis
+	if hair, ok := s.Provenance.Components["hair"]; ok {
+		if input := recipeInput(hair); input != "" && input != config.OutfitRef {
+			config.HairStyleRef = input
+			config.HairColorRef = input
+		}
+	}
+
+	if settings := s.Settings; settings != nil {
+		config.SendOriginal = settings.SendOriginal
+		config.EnhanceText = settings.EnhanceText
+		config.OutfitCheck = settings.OutfitCheck
+		config.MaxAccessories = settings.MaxAccessories
+		config.Post.LUTPath = workspace.Resolve(settings.LUT)
+		config.Ambient = settings.Ambient
+		config.Avoid = settings.Avoid
+		config.Aspect = settings.Aspect
+		config.Resolution = settings.Resolution
+		config.Seed = settings.Seed
+		config.Sampling.Temperature = settings.Temperature
+		config.Sampling.TopK = settings.TopK
+		config.Sampling.TopP = settings.TopP
+		config.Strength = settings.Strength
+		config.Post.Upscale = settings.Upscale
+		config.Post.Upscaler = settings.Upscaler
+		config.Post.FaceLock = settings.FaceLock
+		config.Person = settings.Person
+		config.People = settings.People
+	}
+	if config.OutfitCheck == "" {
+		config.OutfitCheck = OutfitCheckWarn
+	}
+	// The recorded image was already generated, so its combination was accepted
+	config.AllowImplausible = true
+
+	return config, nil
+}
+
+// ChangedInputs lists recorded input files that are missing or whose content
+// no longer matches the hash recorded at generation time
+func (s *Sidecar) ChangedInputs() []string {
+	sources := map[string]ComponentSource{"subject": s.Provenance.Subject}
+	for name, source := range s.Provenance.Components {
+		sources[name] = source
+	}
+
+	var changed []string
+	for name, source := range sources {
+		if source.File == "" || source.SHA256 == "" {
+			continue
+		}
+		switch current := fileSHA256(workspace.Resolve(source.File)); current {
+		case "":
+			changed = append(changed, fmt.Sprintf("%s: %s is missing", name, source.File))
+		case source.SHA256:
+		default:
+			changed = append(changed, fmt.Sprintf("%s: %s changed since the image was generated", name, source.File))
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// ApplyOverride replaces one component of a recipe. The value is an image path or a
+// text description, like the matching generate-modular flag; "none" or "" removes it,
+// except on components that take "none" as a keyword (see analyzer.Component).
+func ApplyOverride(config *ModularConfig, component, value string) error {
+	// Keywords such as "none" of a registered component are values of their own
+	if name, registered, ok := analyzer.LookupComponent(strings.ReplaceAll(strings.ToLower(component), "_", "-")); ok {
+		if keyword, ok := registered.Keyword(value); ok {
+			if config.Extra == nil {
+				config.Extra = make(map[string]string)
+			}
+			config.Extra[name] = keyword
+			return nil
+		}
+	}
+	if strings.EqualFold(value, "none") {
+		value = ""
+	}
+	component = strings.ReplaceAll(strings.ToLower(component), "_", "-")
+	value, err := workspace.ResolveAsset(component, value)
+	if err != nil {
+		return err
+	}
+
+	switch component {
+	case "subject":
+		if value == "" {
+			return errors.ErrInvalidInput("subject", "the subject can't be removed")
+		}
+		config.SubjectPath = value
+	case "outfit":
+		config.OutfitRef = value
+	case "over-outfit":
+		config.OverOutfitRef = value
+	case "style":
+		if value != "" && !isFilePath(value) && !isStyleText(value) {
+			return errors.ErrInvalidInput("style", "must be an existing image file or a description")
+		}
+		config.StyleRef = value
+	case "hair-style":
+		config.HairStyleRef = value
+	case "hair-color":
+		config.HairColorRef = value
+	case "makeup":
+		config.MakeupRef = value
+	case "expression":
+		config.ExpressionRef = value
+	case "accessories":
+		config.AccessoriesRef = value
+	case "pose":
+		config.PoseRef = value
+	case "background":
+		config.BackgroundRef = value
+	default:
+		name, _, ok := analyzer.LookupComponent(component)
+		if !ok {
+			return errors.ErrInvalidInput("set", fmt.Sprintf("unknown component %q (use one of: %s)",
+				component, strings.Join(RecipeComponents(), ", ")))
+		}
+		if config.Extra == nil {
+			config.Extra = make(map[string]string)
+		}
+		if value == "" {
+			delete(config.Extra, name)
+		} else {
+			config.Extra[name] = value
+		}
+	}
+	return nil
+}
+
+// RecipeInputs returns the non-empty inputs of a recipe keyed by component name
+func RecipeInputs(config ModularConfig) map[string]string {
+	inputs := make(map[string]string)
+	for name, value := range map[string]string{
+		"subject":     config.SubjectPath,
+		"outfit":      config.OutfitRef,
+		"over-outfit": config.OverOutfitRef,
+		"style":       config.StyleRef,
+		"hair-style":  config.HairStyleRef,
+		"hair-color":  config.HairColorRef,
+		"makeup":      config.MakeupRef,
+		"expression":  config.ExpressionRef,
+		"accessories": config.AccessoriesRef,
+		"pose":        config.PoseRef,
+		"background":  config.BackgroundRef,
+	} {
+		if value != "" {
+			inputs[name] = value
+		}
+	}
+	for name, value := range config.Extra {
+		if _, component, ok := analyzer.LookupComponent(name); ok && value != "" {
+			inputs[component.Flag] = value
+		}
+	}
+	return inputs
+}
+
+// recipeInput returns the original input of a recorded component
+func recipeInput(source ComponentSource) string {
+	if source.File != "" {
+		return workspace.Resolve(filepath.Clean(source.File))
+	}
+	if source.Text != "" {
+		return source.Text
+	}
+	return source.Description
+}
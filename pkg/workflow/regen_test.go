@@ -0,0 +1,37 @@
+package workflow
+
+import (
+	"img-cli/pkg/config"
+	"img-cli/pkg/gemini"
+	"img-cli/pkg/generator"
+	"testing"
+)
+
+// regen reproduces an image with its own seed and the run's sampling and strength
+func TestRecipeRestoresGenerationSettings(t *testing.T) {
+	settings := &RecipeSettings{}
+	settings.recordGeneration(&generator.GenerateResult{Parameters: &gemini.GenerationConfig{Seed: 1236, Temperature: 0.4}},
+		config.SamplingConfig{TopK: 32}, 0.6)
+	sidecar := &Sidecar{
+		Workflow:   "modular",
+		Provenance: Provenance{Subject: ComponentSource{File: "subjects/kat.png"}},
+		Settings:   settings,
+	}
+
+	recipe, err := sidecar.Recipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recipe.Seed != 1236 {
+		t.Errorf("seed %d, want the image's own 1236", recipe.Seed)
+	}
+	if recipe.Sampling != (config.SamplingConfig{TopK: 32}) {
+		t.Errorf("sampling %+v, want only the top-k the run set", recipe.Sampling)
+	}
+	if recipe.Strength != 0.6 {
+		t.Errorf("strength %v, want 0.6", recipe.Strength)
+	}
+	if next := seedSequence(recipe.Seed, 0, recipe.Variations); next() != 1236 {
+		t.Error("the first regenerated variation doesn't reuse the seed")
+	}
+}
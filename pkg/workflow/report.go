@@ -0,0 +1,264 @@
+package workflow
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"img-cli/pkg/config"
+	"img-cli/pkg/imaging"
+	"img-cli/pkg/logger"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// reportThumbSize is the longer side, in pixels, of thumbnails embedded in the report
+const reportThumbSize = 256
+
+// ReportFileName is the name of the HTML report written into a run's output folder
+const ReportFileName = "report.html"
+
+type htmlReport struct {
+	Workflow     string
+	Started      string
+	Duration     time.Duration
+	Images       int
+	Flagged      int
+	Failed       int
+	Cost         string
+	CostPerImage string
+	Stopped      bool
+	Remaining    int
+	Combinations []reportCombination
+	Failures     []Failure
+	Consistency  []ConsistencyScore
+	Taxonomy     *FailureSummary
+}
+
+type reportCombination struct {
+	Label   string
+	Status  string // ok, flagged or partial
+	Images  []reportImage
+	Failed  int
+	Flagged int
+}
+
+type reportImage struct {
+	Name  string
+	Thumb template.URL
+	Flags []string
+}
+
+// WriteHTMLReport writes a single self-contained HTML page summarizing a run:
+// totals and cost, every combination with thumbnails of its images, failures
+// with the model's refusal text, variation consistency scores and the failure
+// taxonomy with likely culprits
+func WriteHTMLReport(path string, result *WorkflowResult) error {
+	costs := config.DefaultCostConfig()
+	report := htmlReport{
+		Workflow:     result.Workflow,
+		Started:      result.StartTime.Format("2006-01-02 15:04:05"),
+		Duration:     result.EndTime.Sub(result.StartTime).Round(time.Second),
+		Failed:       len(result.Failures),
+		CostPerImage: costs.FormatCost(costs.CostPerImage),
+		Stopped:      result.Stopped,
+		Remaining:    result.Remaining,
+		Failures:     result.Failures,
+		Consistency:  result.Consistency,
+		Taxonomy:     result.Taxonomy,
+	}
+
+	byLabel := make(map[string]*reportCombination)
+	var labels []string
+	for _, step := range result.Steps {
+		if step.Type != "generation" || step.OutputPath == "" {
+			continue
+		}
+		report.Images++
+		if len(step.Flags) > 0 {
+			report.Flagged++
+		}
+
+		label := stepRecipe(step)
+		combo, ok := byLabel[label]
+		if !ok {
+			combo = &reportCombination{Label: label}
+			byLabel[label] = combo
+			labels = append(labels, label)
+		}
+		if len(step.Flags) > 0 {
+			combo.Flagged++
+		}
+		combo.Images = append(combo.Images, reportImage{
+			Name:  filepath.Base(step.OutputPath),
+			Thumb: thumbnailURL(step.OutputPath),
+			Flags: step.Flags,
+		})
+	}
+
+	sort.Strings(labels)
+	for _, label := range labels {
+		combo := byLabel[label]
+		for _, failure := range result.Failures {
+			if failure.Recipe == label {
+				combo.Failed++
+			}
+		}
+		switch {
+		case combo.Failed > 0:
+			combo.Status = "partial"
+		case combo.Flagged > 0:
+			combo.Status = "flagged"
+		default:
+			combo.Status = "ok"
+		}
+		report.Combinations = append(report.Combinations, *combo)
+	}
+	report.Cost = costs.FormatCost(costs.CalculateTotalCost(report.Images))
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create report directory: %w", err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create report: %w", err)
+	}
+	defer file.Close()
+
+	if err := reportTemplate.Execute(file, report); err != nil {
+		return fmt.Errorf("failed to render report: %w", err)
+	}
+	logger.Info("HTML report written", "path", path, "images", report.Images, "failures", report.Failed)
+	return nil
+}
+
+// thumbnailURL embeds a small JPEG of an image as a data URL
+func thumbnailURL(path string) template.URL {
+	data, err := imaging.ThumbnailJPEG(path, reportThumbSize)
+	if err != nil {
+		logger.Warn("Skipping thumbnail", "image", path, "error", err)
+		return ""
+	}
+	return template.URL("data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(data))
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"score": func(v float64) string {
+		if v < 0 {
+			return "n/a"
+		}
+		return fmt.Sprintf("%.2f", v)
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>img-cli {{.Workflow}} report {{.Started}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", sans-serif; margin: 2em; color: #222; }
+h1 { margin-bottom: 0.2em; }
+.meta { color: #666; margin-bottom: 1.5em; }
+.stats { display: flex; gap: 1em; flex-wrap: wrap; margin-bottom: 2em; }
+.stat { background: #f4f4f4; border-radius: 6px; padding: 0.8em 1.2em; }
+.stat b { display: block; font-size: 1.6em; }
+.combo { border: 1px solid #ddd; border-radius: 6px; padding: 1em; margin-bottom: 1em; }
+.combo h3 { margin: 0 0 0.6em; font-size: 1em; font-family: monospace; }
+.status { font-size: 0.8em; padding: 0.1em 0.5em; border-radius: 4px; margin-right: 0.5em; }
+.ok { background: #d8f5d8; } .flagged { background: #fff1c2; } .partial { background: #ffd6d6; }
+.images { display: flex; gap: 0.8em; flex-wrap: wrap; }
+figure { margin: 0; width: 256px; }
+figure img { max-width: 256px; border-radius: 4px; }
+figcaption { font-size: 0.75em; color: #555; word-break: break-all; }
+.flag { color: #a35c00; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2em; }
+th, td { text-align: left; border-bottom: 1px solid #eee; padding: 0.4em; vertical-align: top; }
+td.mono { font-family: monospace; }
+pre { white-space: pre-wrap; margin: 0; font-size: 0.85em; }
+</style>
+</head>
+<body>
+<h1>img-cli {{.Workflow}} report</h1>
+<div class="meta">Started {{.Started}}, ran for {{.Duration}}{{if .Stopped}}, stopped early with {{.Remaining}} combination(s) not started{{end}}</div>
+
+<div class="stats">
+  <div class="stat"><b>{{.Images}}</b>images generated</div>
+  <div class="stat"><b>{{.Flagged}}</b>flagged for review</div>
+  <div class="stat"><b>{{.Failed}}</b>failed generations</div>
+  <div class="stat"><b>{{.Cost}}</b>estimated cost ({{.CostPerImage}} per image)</div>
+</div>
+
+<h2>Combinations</h2>
+{{range .Combinations}}
+<div class="combo">
+  <h3><span class="status {{.Status}}">{{.Status}}</span>{{.Label}}</h3>
+  {{if .Failed}}<p>{{.Failed}} failed generation(s), see below</p>{{end}}
+  <div class="images">
+  {{range .Images}}
+    <figure>
+      {{if .Thumb}}<img src="{{.Thumb}}" alt="{{.Name}}">{{end}}
+      <figcaption>{{.Name}}{{range .Flags}}<br><span class="flag">⚠ {{.}}</span>{{end}}</figcaption>
+    </figure>
+  {{end}}
+  </div>
+</div>
+{{else}}
+<p>No images were generated.</p>
+{{end}}
+
+{{if .Failures}}
+<h2>Failures</h2>
+<table>
+<tr><th>Combination</th><th>Error</th><th>Model response</th></tr>
+{{range .Failures}}
+<tr>
+  <td class="mono">{{.Combination}}</td>
+  <td>{{.Error}}{{if .FinishReason}}<br>Finish reason: {{.FinishReason}}{{end}}</td>
+  <td>{{if .Refusal}}<pre>{{.Refusal}}</pre>{{end}}</td>
+</tr>
+{{end}}
+</table>
+{{end}}
+
+{{with .Taxonomy}}
+<h2>Failure Taxonomy</h2>
+<table>
+<tr><th>Label</th><th>Count</th><th></th></tr>
+{{range .Labels}}
+<tr><td class="mono">{{.Label}}</td><td>{{.Count}}</td><td>{{.Description}}</td></tr>
+{{end}}
+</table>
+<h3>Likely culprits</h3>
+<ul>
+{{range .Suggestions}}<li>{{.}}</li>{{end}}
+</ul>
+<table>
+<tr><th>Component</th><th>Input</th><th>Images</th><th>Labels</th></tr>
+{{range .Components}}
+<tr>
+  <td>{{.Component}}</td>
+  <td class="mono">{{.Input}}</td>
+  <td>{{.Images}}</td>
+  <td>{{range $label, $count := .Labels}}<span class="flag">{{$label}}</span> × {{$count}}<br>{{end}}</td>
+</tr>
+{{end}}
+</table>
+{{end}}
+
+{{if .Consistency}}
+<h2>Variation Consistency</h2>
+<table>
+<tr><th>Combination</th><th>Identity</th><th>Outfit color</th><th></th></tr>
+{{range .Consistency}}
+<tr>
+  <td class="mono">{{.Combination}}</td>
+  <td>{{score .Identity}}</td>
+  <td>{{score .Color}}</td>
+  <td>{{if .Unstable}}<span class="status partial">unstable</span>{{end}}</td>
+</tr>
+{{end}}
+</table>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,94 @@
+package workflow
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// touch creates empty files so recipeLabel sees them as inputs, not text
+func touch(t *testing.T, dir string, names ...string) []string {
+	t.Helper()
+	var paths []string
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// A combined-workflow failure names its sources by stem while outputs are
+// keyed by file name; both must land on the same report combination
+func TestReportCountsFailuresPerRecipe(t *testing.T) {
+	dir := t.TempDir()
+	inputs := touch(t, dir, "kat.png", "suit.png", "beach.png", "night.png")
+	subject, outfit, beach, night := inputs[0], inputs[1], inputs[2], inputs[3]
+	beachRecipe := combinedRecipe(subject, outfit, beach, "")
+	nightRecipe := combinedRecipe(subject, outfit, night, "")
+	if beachRecipe != "subject=kat.png outfit=suit.png style=beach.png" {
+		t.Fatalf("combinedRecipe = %q", beachRecipe)
+	}
+
+	result := &WorkflowResult{
+		Workflow: "outfit-swap",
+		Steps: []StepResult{
+			{Type: "generation", Name: "combined", OutputPath: filepath.Join(dir, "out1.png"), Recipe: beachRecipe},
+			{Type: "generation", Name: "combined", OutputPath: filepath.Join(dir, "out2.png"), Recipe: nightRecipe},
+		},
+		Failures: []Failure{
+			{Combination: "subject=kat.png outfit=suit style=beach (variation 2)", Recipe: beachRecipe, Error: "no image"},
+		},
+	}
+	path := filepath.Join(dir, ReportFileName)
+	if err := WriteHTMLReport(path, result); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := string(data)
+	for _, want := range []string{
+		`<span class="status partial">partial</span>` + beachRecipe,
+		`<span class="status ok">ok</span>` + nightRecipe,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("report is missing %q", want)
+		}
+	}
+	if n := strings.Count(html, "1 failed generation(s)"); n != 1 {
+		t.Errorf("failures counted on %d combinations, want 1", n)
+	}
+}
+
+// One recipe must not match another that merely starts with it
+func TestReportMatchesRecipesExactly(t *testing.T) {
+	dir := t.TempDir()
+	inputs := touch(t, dir, "kat.png", "suit.png", "hat.png")
+	short := combinedRecipe(inputs[0], inputs[1], "", "")
+	long := recipeLabel(ModularConfig{SubjectPath: inputs[0], OutfitRef: inputs[1], AccessoriesRef: inputs[2]})
+	if !strings.HasPrefix(long, short) {
+		t.Fatalf("test needs %q to start with %q", long, short)
+	}
+
+	result := &WorkflowResult{
+		Workflow: "outfit-swap",
+		Steps:    []StepResult{{Type: "generation", Name: "modular", OutputPath: filepath.Join(dir, "out.png"), Recipe: short}},
+		Failures: []Failure{{Combination: long + " (variation 1)", Recipe: long, Error: "no image"}},
+	}
+	path := filepath.Join(dir, ReportFileName)
+	if err := WriteHTMLReport(path, result); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `<span class="status ok">ok</span>`+short) {
+		t.Errorf("failure of %q was counted against %q", long, short)
+	}
+}
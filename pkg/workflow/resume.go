@@ -0,0 +1,137 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/output"
+	"os"
+	"path/filepath"
+)
+
+// resumeIndex counts the images each combination already produced in an output
+// directory, so a resumed run only generates what is missing. A nil index
+// resumes nothing.
+type resumeIndex map[string]int
+
+// loadResumeIndex reads the manifest of an interrupted run. Images listed in
+// the manifest but deleted since are generated again.
+func loadResumeIndex(outputDir string) (resumeIndex, error) {
+	manifest, err := ReadManifest(outputDir)
+	if os.IsNotExist(err) {
+		return nil, errors.ErrInvalidInput("resume", fmt.Sprintf("%s has no %s to resume from", outputDir, ManifestFile))
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, errors.FileError, "failed to read %s", ManifestFile)
+	}
+
+	index := make(resumeIndex)
+	seen := make(map[string]bool)
+	for _, image := range manifest.Images {
+		if image.Combination == nil || seen[image.Image] {
+			continue
+		}
+		seen[image.Image] = true
+		if _, err := os.Stat(filepath.Join(outputDir, image.Image)); err != nil {
+			continue
+		}
+		index[resumeKey(*image.Combination)]++
+	}
+	return index, nil
+}
+
+// done returns how many images of a combination exist already
+func (r resumeIndex) done(combo Combination) int {
+	return r[resumeKey(combo)]
+}
+
+// resumeKey identifies a combination by its inputs, ignoring the per-row
+// variation counts so resumed rows still match. Input files count by their
+// absolute path, so "kat.png" and "./kat.png" are the same combination.
+func resumeKey(combo Combination) string {
+	combo.Variations = 0
+	combo.Generated = 0
+	for _, input := range []*string{
+		&combo.Subject, &combo.Outfit, &combo.OverOutfit, &combo.Style, &combo.HairStyle, &combo.HairColor,
+		&combo.Makeup, &combo.Expression, &combo.Accessories, &combo.Pose, &combo.Background,
+	} {
+		*input = absInput(*input)
+	}
+	if combo.Extra != nil {
+		extra := make(map[string]string, len(combo.Extra))
+		for name, input := range combo.Extra {
+			extra[name] = absInput(input)
+		}
+		combo.Extra = extra
+	}
+	key, _ := json.Marshal(combo)
+	return string(key)
+}
+
+// absInput returns the absolute path of an input file; text inputs are kept
+func absInput(input string) string {
+	if !isFilePath(input) {
+		return input
+	}
+	if abs, err := filepath.Abs(input); err == nil {
+		return abs
+	}
+	return input
+}
+
+// resumeCombinations drops the combinations an interrupted run completed and
+// lowers the variations of those it completed partly
+func resumeCombinations(outputDir string, combinations []Combination, variations int) ([]Combination, error) {
+	index, err := loadResumeIndex(outputDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var remaining []Combination
+	complete, existing := 0, 0
+	for _, combo := range combinations {
+		want := combo.variations(variations)
+		done := min(index.done(combo), want)
+		existing += done
+		if done == want {
+			complete++
+			continue
+		}
+		if done > 0 {
+			combo.Variations = want - done
+			combo.Generated = done
+		}
+		remaining = append(remaining, combo)
+	}
+
+	output.Progress.Printf("\n♻️  Resuming %s: %d of %d combinations complete (%d images), %d to go\n",
+		outputDir, complete, len(combinations), existing, len(remaining))
+	return remaining, nil
+}
+
+// finishResume removes the run state of a resumed run that is now complete
+func finishResume(outputDir string, result *WorkflowResult) {
+	if result.Stopped {
+		return
+	}
+	os.Remove(filepath.Join(outputDir, RunStateFile))
+}
+
+// combination returns the batch combination a modular config generates
+func (c ModularConfig) combination() Combination {
+	return Combination{
+		Subject:     c.SubjectPath,
+		Outfit:      c.OutfitRef,
+		OverOutfit:  c.OverOutfitRef,
+		Style:       c.StyleRef,
+		HairStyle:   c.HairStyleRef,
+		HairColor:   c.HairColorRef,
+		Makeup:      c.MakeupRef,
+		Expression:  c.ExpressionRef,
+		Accessories: c.AccessoriesRef,
+		Pose:        c.PoseRef,
+		Background:  c.BackgroundRef,
+		Extra:       c.Extra,
+		Ambient:     c.Ambient,
+	}
+}
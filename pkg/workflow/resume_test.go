@@ -0,0 +1,101 @@
+package workflow
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeManifest records images of combinations in an output directory, the
+// way an interrupted run leaves it
+func writeManifest(t *testing.T, outputDir string, images map[string]Combination) {
+	t.Helper()
+	manifest := Manifest{Version: manifestVersion}
+	for image, combo := range images {
+		combo := combo
+		if err := os.WriteFile(filepath.Join(outputDir, image), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		manifest.Images = append(manifest.Images, ManifestImage{Image: image, Combination: &combo})
+	}
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(outputDir, ManifestFile), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResumeCombinations(t *testing.T) {
+	inputs := touch(t, ".", "resume-kat.png", "resume-suit.png", "resume-coat.png", "resume-dress.png")
+	subject, suit, coat, dress := inputs[0], inputs[1], inputs[2], inputs[3]
+	abs := func(path string) string {
+		p, err := filepath.Abs(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	outputDir := t.TempDir()
+	// The interrupted run was started with relative paths
+	writeManifest(t, outputDir, map[string]Combination{
+		"suit_1.png": {Subject: subject, Outfit: suit},
+		"suit_2.png": {Subject: subject, Outfit: suit},
+		"coat_1.png": {Subject: subject, Outfit: coat},
+		"coat_2.png": {Subject: subject, Outfit: coat},
+		"coat_3.png": {Subject: subject, Outfit: coat},
+	})
+
+	// ...and is resumed with absolute ones
+	combinations := []Combination{
+		{Subject: abs(subject), Outfit: abs(suit)},
+		{Subject: abs(subject), Outfit: abs(coat)},
+		{Subject: abs(subject), Outfit: abs(dress)},
+		{Subject: abs(subject), Outfit: "red leather jacket"},
+	}
+	remaining, err := resumeCombinations(outputDir, combinations, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(remaining) != 3 {
+		t.Fatalf("got %d remaining combinations, want 3: %+v", len(remaining), remaining)
+	}
+
+	partial := remaining[0]
+	if partial.Outfit != abs(suit) || partial.Generated != 2 || partial.Variations != 1 {
+		t.Errorf("partial combination resumes as generated %d, variations %d; want 2 and 1", partial.Generated, partial.Variations)
+	}
+	for _, fresh := range remaining[1:] {
+		if fresh.Generated != 0 || fresh.Variations != 0 {
+			t.Errorf("%s: generated %d, variations %d; want a fresh combination", fresh.Outfit, fresh.Generated, fresh.Variations)
+		}
+	}
+}
+
+// Images listed in the manifest but deleted since are generated again
+func TestResumeIndexSkipsDeletedImages(t *testing.T) {
+	inputs := touch(t, ".", "resume-deleted.png")
+	outputDir := t.TempDir()
+	combo := Combination{Subject: inputs[0], Outfit: "jeans"}
+	writeManifest(t, outputDir, map[string]Combination{"a.png": combo, "b.png": combo})
+	if err := os.Remove(filepath.Join(outputDir, "b.png")); err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := loadResumeIndex(outputDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := index.done(combo); got != 1 {
+		t.Errorf("done = %d, want 1", got)
+	}
+}
+
+func TestResumeWithoutManifest(t *testing.T) {
+	if _, err := loadResumeIndex(t.TempDir()); err == nil {
+		t.Error("resuming a directory without a manifest succeeded")
+	}
+}
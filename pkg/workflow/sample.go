@@ -0,0 +1,41 @@
+package workflow
+
+import (
+	"img-cli/pkg/errors"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// ValidateSample checks --sample, which can't be combined with --pairwise
+func ValidateSample(n int, pairwise bool) error {
+	if n < 0 {
+		return errors.ErrInvalidInput("sample", "must be 0 (every combination) or a positive number")
+	}
+	if n > 0 && pairwise {
+		return errors.ErrInvalidInput("sample", "can't be combined with --pairwise; use one or the other")
+	}
+	return nil
+}
+
+// NewSampleSeed returns a seed for runs that sample without one, to be shown
+// to the user so the sample can be drawn again
+func NewSampleSeed() int64 {
+	return time.Now().UnixNano() % 1_000_000
+}
+
+// sampleCombinations draws n combinations at random from the full matrix and
+// returns them in matrix order. The same seed draws the same combinations
+// from the same inputs, which --resume relies on.
+func sampleCombinations(combinations []Combination, n int, seed int64) []Combination {
+	if n <= 0 || n >= len(combinations) {
+		return combinations
+	}
+	indexes := rand.New(rand.NewSource(seed)).Perm(len(combinations))[:n]
+	sort.Ints(indexes)
+	sampled := make([]Combination, 0, n)
+	for _, i := range indexes {
+		sampled = append(sampled, combinations[i])
+	}
+	return sampled
+}
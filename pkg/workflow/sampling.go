@@ -0,0 +1,47 @@
+package workflow
+
+import (
+	"img-cli/pkg/config"
+	"img-cli/pkg/errors"
+)
+
+// ValidateSampling checks --temperature, --top-k and --top-p, where 0 keeps
+// the built-in value
+func ValidateSampling(s config.SamplingConfig) error {
+	if s.Temperature < 0 || s.Temperature > 2 {
+		return errors.ErrInvalidInput("temperature", "must be between 0 and 2 (0 = default)")
+	}
+	if s.TopK < 0 {
+		return errors.ErrInvalidInput("top-k", "must be a positive number (0 = default)")
+	}
+	if s.TopP < 0 || s.TopP > 1 {
+		return errors.ErrInvalidInput("top-p", "must be between 0 and 1 (0 = default)")
+	}
+	return nil
+}
+
+// ValidateStrength checks --strength, where 0 leaves the amount of change to
+// the model
+func ValidateStrength(strength float64) error {
+	if strength < 0 || strength > 1 {
+		return errors.ErrInvalidInput("strength", "must be between 0 and 1; low values restyle subtly, 1 transforms completely (0 = left to the model)")
+	}
+	return nil
+}
+
+// GenerationSampling applies --temperature, --top-k and --top-p over the
+// generation defaults of the config file and environment, and validates the
+// result
+func GenerationSampling(temperature float64, topK int, topP float64) (config.SamplingConfig, error) {
+	sampling := config.DefaultGenerationSampling()
+	if temperature != 0 {
+		sampling.Temperature = temperature
+	}
+	if topK != 0 {
+		sampling.TopK = topK
+	}
+	if topP != 0 {
+		sampling.TopP = topP
+	}
+	return sampling, ValidateSampling(sampling)
+}
@@ -0,0 +1,41 @@
+package workflow
+
+import (
+	"fmt"
+	"img-cli/pkg/errors"
+	"math"
+)
+
+// MaxSeed is the largest --seed; Gemini takes 32-bit seeds
+const MaxSeed = math.MaxInt32
+
+// ValidateSeed checks --seed
+func ValidateSeed(seed int64) error {
+	if seed < 0 || seed > MaxSeed {
+		return errors.ErrInvalidInput("seed", fmt.Sprintf("must be between 1 and %d, or 0 for a random seed", MaxSeed))
+	}
+	return nil
+}
+
+// offsetSeed returns seed advanced by n, wrapping within 1..MaxSeed. Variation
+// i of a combination uses offsetSeed(seed, i), so each variation reproduces
+// on its own. 0 (no seed) stays 0.
+func offsetSeed(seed int64, n int) int64 {
+	if seed == 0 {
+		return 0
+	}
+	return (seed-1+int64(n))%MaxSeed + 1
+}
+
+// seedSequence hands out the seeds of one variation's generation requests:
+// the variation's own seed first, then seeds past the combination's last
+// variation for --best-of candidates and identity retries, which would
+// otherwise repeat the first image
+func seedSequence(seed int64, variation, variations int) func() int64 {
+	attempt := 0
+	return func() int64 {
+		s := offsetSeed(seed, variation+attempt*variations)
+		attempt++
+		return s
+	}
+}
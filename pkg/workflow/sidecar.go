@@ -0,0 +1,274 @@
+package workflow
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/analyzer"
+	"img-cli/pkg/config"
+	"img-cli/pkg/generator"
+	"img-cli/pkg/logger"
+	"img-cli/pkg/models"
+	"img-cli/pkg/vocab"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sidecar is the metadata written next to each generated image as <image>.json
+type Sidecar struct {
+	Image       string          `json:"image"`
+	Created     time.Time       `json:"created"`
+	Workflow    string          `json:"workflow"`
+	Prompt      string          `json:"prompt,omitempty"`
+	AltText     string          `json:"alt_text,omitempty"` // Accessibility description of the image
+	Provider    string          `json:"provider,omitempty"` // Provider that generated the image, the fallback one if the main one failed
+	Model       string          `json:"model,omitempty"`
+	Provenance  Provenance      `json:"provenance"`
+	Settings    *RecipeSettings `json:"settings,omitempty"`
+	Flags       []string        `json:"flags,omitempty"`       // Automated checks the image failed
+	Signed      bool            `json:"signed,omitempty"`      // Image carries C2PA content credentials
+	Watermarked bool            `json:"watermarked,omitempty"` // Image carries the invisible AI-generated marker
+}
+
+// RecipeSettings are the generation options recorded so an image can be regenerated
+type RecipeSettings struct {
+	SendOriginal   bool               `json:"send_original,omitempty"`
+	EnhanceText    bool               `json:"enhance_text,omitempty"`
+	OutfitCheck    string             `json:"outfit_check,omitempty"`
+	MaxAccessories int                `json:"max_accessories,omitempty"`
+	LUT            string             `json:"lut,omitempty"`
+	Ambient        string             `json:"ambient,omitempty"`
+	Avoid          []string           `json:"avoid,omitempty"`
+	Aspect         string             `json:"aspect,omitempty"`
+	Resolution     int                `json:"resolution,omitempty"`
+	Seed           int64              `json:"seed,omitempty"` // The image's own seed, not the run's --seed
+	Temperature    float64            `json:"temperature,omitempty"`
+	TopK           int                `json:"top_k,omitempty"`
+	TopP           float64            `json:"top_p,omitempty"`
+	Strength       float64            `json:"strength,omitempty"`
+	Upscale        int                `json:"upscale,omitempty"`
+	Upscaler       string             `json:"upscaler,omitempty"`
+	FaceLock       bool               `json:"face_lock,omitempty"`
+	Person         string             `json:"person,omitempty"`
+	People         []PersonComponents `json:"people,omitempty"`
+}
+
+// recordGeneration adds the seed an image was generated with and the sampling
+// and strength the run asked for. Unset sampling fields stay unset, so regen
+// keeps the defaults of its own time.
+func (s *RecipeSettings) recordGeneration(result *generator.GenerateResult, sampling config.SamplingConfig, strength float64) {
+	if result != nil && result.Parameters != nil {
+		s.Seed = result.Parameters.Seed
+	}
+	s.Temperature = sampling.Temperature
+	s.TopK = sampling.TopK
+	s.TopP = sampling.TopP
+	s.Strength = strength
+}
+
+// Provenance records where every part of a generated image came from
+type Provenance struct {
+	Subject    ComponentSource            `json:"subject"`
+	Components map[string]ComponentSource `json:"components"`
+}
+
+// ComponentSource describes the input that supplied one component
+type ComponentSource struct {
+	File            string       `json:"file,omitempty"`
+	SHA256          string       `json:"sha256,omitempty"`
+	Text            string       `json:"text,omitempty"`
+	Analyzer        string       `json:"analyzer,omitempty"`
+	AnalyzerVersion int          `json:"analyzer_version,omitempty"`
+	Description     string       `json:"description,omitempty"`
+	Filters         []string     `json:"filters,omitempty"`
+	Vocabulary      *vocab.Terms `json:"vocabulary,omitempty"` // Colors, garments and formality in the controlled vocabulary
+	Weight          float64      `json:"weight,omitempty"`     // Prompt emphasis (see SplitWeight)
+}
+
+// gazeRemovedFilter notes that gaze was dropped from an expression because the style sets the camera
+const gazeRemovedFilter = "gaze direction removed (the style controls camera direction)"
+
+// exclusionFilters describes what the outfit analysis left out because other inputs supply it
+func exclusionFilters(opts analyzer.ExcludeOptions) []string {
+	var filters []string
+	if opts.Hair {
+		filters = append(filters, "hair excluded (supplied by hair style/color)")
+	}
+	if opts.Makeup {
+		filters = append(filters, "makeup excluded (supplied by makeup)")
+	}
+	if opts.Accessories {
+		filters = append(filters, "accessories excluded (supplied by accessories)")
+	}
+	return filters
+}
+
+// SidecarPath returns the sidecar metadata path for a generated image
+func SidecarPath(imagePath string) string {
+	return strings.TrimSuffix(imagePath, filepath.Ext(imagePath)) + ".json"
+}
+
+// ReadSidecar loads the sidecar metadata of a generated image. The path may be
+// the image itself or its .json sidecar.
+func ReadSidecar(path string) (*Sidecar, error) {
+	data, err := os.ReadFile(SidecarPath(path))
+	if err != nil {
+		return nil, err
+	}
+	var sidecar Sidecar
+	if err := json.Unmarshal(data, &sidecar); err != nil {
+		return nil, fmt.Errorf("error parsing sidecar for %s: %w", filepath.Base(path), err)
+	}
+	return &sidecar, nil
+}
+
+// generatedBy returns the provider and model that generated a result: the
+// ones that answered, or the main provider's when the result doesn't say
+func (o *Orchestrator) generatedBy(result *generator.GenerateResult) (provider, model string) {
+	if result != nil && result.Provider != "" {
+		return result.Provider, result.Model
+	}
+	return o.client.Provider(), o.client.Model()
+}
+
+// writeSidecar records the provenance of a generated image. Failures are logged
+// but never fail the generation.
+func (o *Orchestrator) writeSidecar(result *generator.GenerateResult, workflow, prompt, subjectPath string, components map[string]*models.ComponentData, settings *RecipeSettings) {
+	outputPath := result.OutputPath
+	provider, model := o.generatedBy(result)
+	sidecar := Sidecar{
+		Image:    filepath.Base(outputPath),
+		Created:  time.Now(),
+		Workflow: workflow,
+		Prompt:   prompt,
+		Provider: provider,
+		Model:    model,
+		Provenance: Provenance{
+			Subject:    ComponentSource{File: subjectPath, SHA256: fileSHA256(subjectPath)},
+			Components: make(map[string]ComponentSource),
+		},
+		Settings: settings,
+		Flags:    o.ReviewFlags(outputPath),
+	}
+
+	for name, c := range components {
+		if c != nil {
+			sidecar.Provenance.Components[name] = componentSource(c)
+		}
+	}
+	sidecar.AltText = o.altText(components, sidecar.Caption())
+
+	sidecar.Watermarked = watermarkOutput(outputPath)
+	o.embedMetadata(outputPath, &sidecar)
+	sidecar.Signed = o.signOutput(outputPath, &sidecar)
+
+	data, err := json.MarshalIndent(sidecar, "", "  ")
+	if err != nil {
+		logger.Warn("Failed to encode sidecar", "image", filepath.Base(outputPath), "error", err)
+		return
+	}
+	if err := os.WriteFile(SidecarPath(outputPath), data, 0644); err != nil {
+		logger.Warn("Failed to write sidecar", "image", filepath.Base(outputPath), "error", err)
+	}
+}
+
+// modularComponentMap names the components of a modular generation for provenance records
+func modularComponentMap(components *models.ModularComponents) map[string]*models.ComponentData {
+	m := map[string]*models.ComponentData{
+		"outfit":      components.Outfit,
+		"over_outfit": components.OverOutfit,
+		"style":       components.Style,
+		"hair_style":  components.HairStyle,
+		"hair_color":  components.HairColor,
+		"makeup":      components.Makeup,
+		"expression":  components.Expression,
+		"accessories": components.Accessories,
+		"pose":        components.Pose,
+		"background":  components.Background,
+	}
+	for name, c := range components.Extra {
+		m[name] = c
+	}
+	return m
+}
+
+// componentSource builds the provenance entry for an analyzed component
+func componentSource(c *models.ComponentData) ComponentSource {
+	source := ComponentSource{
+		Description: c.Description,
+		Filters:     c.Filters,
+		Vocabulary:  vocab.ForAnalysis(c.Type, c.JSONData, c.Description),
+		Weight:      c.Weight,
+	}
+
+	switch {
+	case c.Type == "style_blend":
+		// Several style references blended into one (--style-blend)
+		source.Text = c.Text
+		source.Analyzer = "style_blend"
+		source.AnalyzerVersion = analyzer.StyleBlendVersion
+	case c.ImagePath != "":
+		source.File = c.ImagePath
+		source.SHA256 = fileSHA256(c.ImagePath)
+		source.Analyzer = c.Type
+		if c.Type == "over_outfit" || c.Type == "hair" {
+			source.Analyzer = "outfit" // Over-outfits and combined-workflow hair use the outfit analyzer
+		}
+		source.AnalyzerVersion = analyzer.PromptVersions[source.Analyzer]
+	case c.JSONData != nil:
+		// Text expanded into structured fields
+		source.Text = c.Text
+		source.Analyzer = "text_enhancer"
+		source.AnalyzerVersion = analyzer.TextEnhancerVersion
+	default:
+		source.Text = c.Text
+		if source.Text == "" {
+			source.Text = c.Description
+		}
+	}
+	return source
+}
+
+// fileHashes memoizes fileSHA256 by path; an entry is used while the file
+// keeps its size and modification time
+var fileHashes sync.Map
+
+type fileHash struct {
+	size    int64
+	modTime time.Time
+	sum     string
+}
+
+// fileSHA256 returns the hex SHA-256 of a file, or "" if it can't be read
+func fileSHA256(path string) string {
+	if path == "" {
+		return ""
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	if cached, ok := fileHashes.Load(path); ok {
+		if c := cached.(fileHash); c.size == info.Size() && c.modTime.Equal(info.ModTime()) {
+			return c.sum
+		}
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return ""
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+	fileHashes.Store(path, fileHash{size: info.Size(), modTime: info.ModTime(), sum: sum})
+	return sum
+}
@@ -0,0 +1,49 @@
+package workflow
+
+import (
+	"img-cli/pkg/c2pa"
+	"img-cli/pkg/logger"
+	"path/filepath"
+	"strings"
+)
+
+// signOutput embeds content credentials built from an image's provenance
+// record. It reports whether the image was signed; failures are logged but
+// never fail the generation.
+func (o *Orchestrator) signOutput(outputPath string, sidecar *Sidecar) bool {
+	if o.signer == nil {
+		return false
+	}
+	if !strings.EqualFold(filepath.Ext(outputPath), ".png") {
+		logger.Warn("Content credentials are only embedded in PNG images", "image", filepath.Base(outputPath))
+		return false
+	}
+
+	info := c2pa.Info{
+		Title:    filepath.Base(outputPath),
+		Workflow: sidecar.Workflow,
+		Model:    sidecar.Model,
+		Created:  sidecar.Created,
+		Inputs:   []c2pa.Input{signingInput("subject", sidecar.Provenance.Subject)},
+	}
+	for name, source := range sidecar.Provenance.Components {
+		info.Inputs = append(info.Inputs, signingInput(name, source))
+	}
+
+	if err := c2pa.SignPNG(outputPath, info, o.signer); err != nil {
+		logger.Warn("Failed to sign image", "image", filepath.Base(outputPath), "error", err)
+		return false
+	}
+	logger.Debug("Embedded content credentials", "image", filepath.Base(outputPath))
+	return true
+}
+
+// signingInput describes one provenance entry for the content credentials.
+// Only file names and hashes are included, never local paths or text.
+func signingInput(role string, source ComponentSource) c2pa.Input {
+	input := c2pa.Input{Role: role, SHA256: source.SHA256}
+	if source.File != "" {
+		input.File = filepath.Base(source.File)
+	}
+	return input
+}
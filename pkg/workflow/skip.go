@@ -0,0 +1,122 @@
+package workflow
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"img-cli/pkg/config"
+	"img-cli/pkg/logger"
+	"img-cli/pkg/models"
+	"img-cli/pkg/prompts"
+	"img-cli/pkg/workspace"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// hashSettings are the generation settings that change an image made from
+// the same inputs, so they are part of its combination hash
+type hashSettings struct {
+	Provider   string
+	Model      string
+	Seed       int64
+	Strength   float64
+	Sampling   config.SamplingConfig
+	Aspect     string
+	Resolution int
+	Avoid      []string
+}
+
+// hashSettings returns the settings of a generation with the orchestrator's
+// provider and model
+func (o *Orchestrator) hashSettings(seed int64, strength float64, sampling config.SamplingConfig, aspect string, resolution int, avoid []string) hashSettings {
+	return hashSettings{
+		Provider:   o.client.Provider(),
+		Model:      o.client.Model(),
+		Seed:       seed,
+		Strength:   strength,
+		Sampling:   sampling,
+		Aspect:     aspect,
+		Resolution: resolution,
+		Avoid:      avoid,
+	}
+}
+
+// combinationHash identifies what an image is generated from: the generation
+// prompt template (and its version), the settings, the subject, the component
+// inputs and the variation number. Reference images count by content, so a
+// renamed file still matches. extra holds other inputs of the recipe, like
+// the ambient of a sweep.
+func combinationHash(template string, settings hashSettings, subjectPath string, components map[string]*models.ComponentData, variation int, extra ...string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "template=%s@%s\nsettings=%+v\nsubject=%s\nvariation=%d\n",
+		template, prompts.Version(template), settings, fileSHA256(subjectPath), variation)
+
+	names := make([]string, 0, len(components))
+	for name, c := range components {
+		if c != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		c := components[name]
+		switch {
+		case c.ImagePath != "":
+			fmt.Fprintf(h, "%s=file:%s\n", name, fileSHA256(c.ImagePath))
+		case c.Text != "":
+			fmt.Fprintf(h, "%s=text:%s\n", name, c.Text)
+		default:
+			fmt.Fprintf(h, "%s=text:%s\n", name, c.Description)
+		}
+		if c.Weight != 0 {
+			fmt.Fprintf(h, "%s.weight=%g\n", name, c.Weight)
+		}
+	}
+	for _, e := range extra {
+		fmt.Fprintf(h, "extra=%s\n", e)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// existingOutput returns an image already generated from a combination hash,
+// or "" if there is none. Images are found through the manifests under the
+// output root and outputDir, read once per orchestrator; images deleted
+// since don't count.
+func (o *Orchestrator) existingOutput(hash, outputDir string) string {
+	o.existingOnce.Do(func() {
+		o.existing = make(map[string]string)
+		for _, root := range []string{workspace.OutputPath(), outputDir} {
+			if root != "" {
+				o.indexOutputs(root)
+			}
+		}
+	})
+	return o.existing[hash]
+}
+
+// indexOutputs adds the hashed images of every manifest under root
+func (o *Orchestrator) indexOutputs(root string) {
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || d.Name() != ManifestFile {
+			return nil
+		}
+		dir := filepath.Dir(path)
+		manifest, err := ReadManifest(dir)
+		if err != nil {
+			logger.Warn("Skipping unreadable manifest", "path", path, "error", err)
+			return nil
+		}
+		for _, image := range manifest.Images {
+			if image.Hash == "" || o.existing[image.Hash] != "" {
+				continue
+			}
+			imagePath := filepath.Join(dir, image.Image)
+			if _, err := os.Stat(imagePath); err == nil {
+				o.existing[image.Hash] = imagePath
+			}
+		}
+		return nil
+	})
+}
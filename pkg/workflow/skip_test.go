@@ -0,0 +1,157 @@
+package workflow
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"image"
+	"image/png"
+	"img-cli/pkg/config"
+	"img-cli/pkg/models"
+	"img-cli/pkg/workspace"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCombinationHash(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	subject := write("kat.png", "kat")
+	outfit := map[string]*models.ComponentData{"outfit": {ImagePath: write("suit.png", "suit")}}
+	settings := hashSettings{Provider: "gemini", Model: "image", Seed: 7, Aspect: "9:16"}
+	hash := combinationHash("combined", settings, subject, outfit, 1)
+
+	renamed := map[string]*models.ComponentData{"outfit": {ImagePath: write("suit-copy.png", "suit")}}
+	if combinationHash("combined", settings, subject, renamed, 1) != hash {
+		t.Error("a renamed copy of the outfit changed the hash")
+	}
+
+	for name, changed := range map[string]hashSettings{
+		"provider":   {Provider: "openai", Model: "image", Seed: 7, Aspect: "9:16"},
+		"seed":       {Provider: "gemini", Model: "image", Seed: 8, Aspect: "9:16"},
+		"strength":   {Provider: "gemini", Model: "image", Seed: 7, Aspect: "9:16", Strength: 0.5},
+		"sampling":   {Provider: "gemini", Model: "image", Seed: 7, Aspect: "9:16", Sampling: config.SamplingConfig{Temperature: 0.2}},
+		"aspect":     {Provider: "gemini", Model: "image", Seed: 7, Aspect: "16:9"},
+		"resolution": {Provider: "gemini", Model: "image", Seed: 7, Aspect: "9:16", Resolution: 2048},
+		"avoid":      {Provider: "gemini", Model: "image", Seed: 7, Aspect: "9:16", Avoid: []string{"hats"}},
+	} {
+		if combinationHash("combined", changed, subject, outfit, 1) == hash {
+			t.Errorf("changing the %s kept the hash", name)
+		}
+	}
+	if combinationHash("combined", settings, subject, outfit, 2) == hash {
+		t.Error("another variation kept the hash")
+	}
+
+	// The memoized file hash follows edits
+	write("suit.png", "a different suit")
+	if combinationHash("combined", settings, subject, outfit, 1) == hash {
+		t.Error("editing the outfit image kept the hash")
+	}
+}
+
+func TestExistingOutput(t *testing.T) {
+	outputDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outputDir, "kept.png"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	manifest := Manifest{Version: manifestVersion, Images: []ManifestImage{
+		{Image: "kept.png", Hash: "kept"},
+		{Image: "deleted.png", Hash: "deleted"},
+	}}
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(outputDir, ManifestFile), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	o := &Orchestrator{}
+	if got := o.existingOutput("kept", outputDir); got != filepath.Join(outputDir, "kept.png") {
+		t.Errorf("existingOutput(kept) = %q", got)
+	}
+	if got := o.existingOutput("deleted", outputDir); got != "" {
+		t.Errorf("existingOutput(deleted) = %q, want none for a deleted image", got)
+	}
+}
+
+// A modular outfit-swap run (here selected by the makeup text) skips what an
+// earlier run already generated
+func TestSkipExistingModularOutfitSwap(t *testing.T) {
+	var requests atomic.Int32
+	t.Setenv("IMG_CLI_PROVIDER", config.ProviderSD)
+	t.Setenv("IMG_CLI_SD_URL", fakeSD(t, &requests))
+	subject := testSubject(t)
+	run := func(outputDir string, avoid ...string) {
+		t.Helper()
+		o := NewOrchestrator("test-key")
+		_, err := o.RunWorkflow("outfit-swap", "", WorkflowOptions{
+			TargetImages:    []string{subject},
+			OutfitText:      "a navy wool coat",
+			MakeupRef:       "smoky eye",
+			Variations:      2,
+			OutputDir:       outputDir,
+			SkipExisting:    true,
+			SkipCostConfirm: true,
+			SkipPreflight:   true,
+			Avoid:           avoid,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run(filepath.Join(workspace.OutputPath(), "skip-modular-first"), "hats")
+	if n := requests.Load(); n != 2 {
+		t.Fatalf("first run sent %d generations, want 2", n)
+	}
+	run(filepath.Join(workspace.OutputPath(), "skip-modular-second"), "hats")
+	if n := requests.Load(); n != 2 {
+		t.Errorf("rerun sent %d more generations, want none", n-2)
+	}
+	run(filepath.Join(workspace.OutputPath(), "skip-modular-third"), "sunglasses")
+	if n := requests.Load(); n != 4 {
+		t.Errorf("rerun avoiding something else sent %d more generations, want 2", n-2)
+	}
+}
+
+// fakeSD serves a blank image for every generation, like a Stable Diffusion
+// WebUI, and counts the requests. It returns the server URL.
+func fakeSD(t *testing.T, requests *atomic.Int32) string {
+	generated := base64.StdEncoding.EncodeToString(blankPNG(t))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		json.NewEncoder(w).Encode(map[string][]string{"images": {generated}})
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("IMG_CLI_GENERATE_RPS", "100")
+	return server.URL
+}
+
+// testSubject writes a blank subject photo
+func testSubject(t *testing.T) string {
+	subject := filepath.Join(t.TempDir(), "kat.png")
+	if err := os.WriteFile(subject, blankPNG(t), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return subject
+}
+
+func blankPNG(t *testing.T) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 64, 64))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
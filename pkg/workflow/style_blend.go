@@ -0,0 +1,96 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/analyzer"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/logger"
+	"img-cli/pkg/output"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// StyleRefs splits a --style-blend value into its references. The value is
+// a comma-separated list of images; a folder adds every image in it.
+func StyleRefs(value string) ([]string, error) {
+	var refs []string
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if info, err := os.Stat(part); err == nil && info.IsDir() {
+			files, err := collectImageFiles(part)
+			if err != nil {
+				return nil, err
+			}
+			refs = append(refs, files...)
+			continue
+		}
+		if !isFilePath(part) {
+			return nil, errors.ErrInvalidInput("style", fmt.Sprintf("%q is not an image to blend", part))
+		}
+		refs = append(refs, part)
+	}
+	if len(refs) < 2 {
+		return nil, errors.ErrInvalidInput("style-blend", "needs at least two style images, e.g. --style a.png,b.png")
+	}
+	return refs, nil
+}
+
+// blendStyles analyzes each style reference of a --style-blend value and
+// synthesizes them into one visual style analysis. Blends are cached like
+// text expansions, keyed by the analyses they were made from.
+func (o *Orchestrator) blendStyles(value string) (json.RawMessage, error) {
+	refs, err := StyleRefs(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var analyses []json.RawMessage
+	var parts []string
+	for _, ref := range refs {
+		output.Progress.Printf("  Analyzing style from: %s\n", filepath.Base(ref))
+		data, err := o.AnalyzeImage("visual_style", ref)
+		if err != nil {
+			return nil, err
+		}
+		analyses = append(analyses, data)
+		parts = append(parts, string(data))
+	}
+
+	output.Progress.Printf("  Blending %d styles into one\n", len(analyses))
+	text := strings.Join(parts, "\n")
+	key := "style_blend\x00" + text
+
+	o.enhancedMu.Lock()
+	cached, found := o.enhancedText[key]
+	o.enhancedMu.Unlock()
+	if found {
+		return cached, nil
+	}
+
+	c := o.GetCacheForType("visual_style")
+	if c != nil && o.enableCache {
+		if data, found := c.GetText("style_blend", text, analyzer.StyleBlendPromptVersion()); found {
+			output.Detail.Printf("    Using cached blend\n")
+			o.rememberEnhanced(key, data)
+			return data, nil
+		}
+	}
+
+	data, err := o.styleBlender.Blend(analyses)
+	if err != nil {
+		return nil, err
+	}
+
+	if c != nil && o.enableCache {
+		if err := c.SetText("style_blend", text, analyzer.StyleBlendPromptVersion(), data); err != nil {
+			logger.Warn("Failed to cache style blend", "error", err)
+		}
+	}
+	o.rememberEnhanced(key, data)
+	return data, nil
+}
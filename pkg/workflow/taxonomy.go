@@ -0,0 +1,354 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/analyzer"
+	"img-cli/pkg/config"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/logger"
+	"img-cli/pkg/models"
+	"img-cli/pkg/output"
+	"img-cli/pkg/vocab"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Failure taxonomy labels. The review pass attaches the first four to outputs
+// as review flags; safety refusals label generations that produced no image.
+const (
+	LabelIdentityDrift          = "identity_drift"
+	LabelWrongGarmentColor      = "wrong_garment_color"
+	LabelFramingIgnored         = "framing_ignored"
+	LabelAccessoryHallucination = "accessory_hallucination"
+	LabelSafetyRefusal          = "safety_refusal"
+)
+
+// failureClass describes a label and where its cause usually lies
+type failureClass struct {
+	description string
+	components  []string // Recipe components whose inputs most often cause it
+	blocks      string   // Prompt blocks to look at first
+	advice      string
+}
+
+var failureClasses = map[string]failureClass{
+	LabelIdentityDrift: {
+		description: "the person no longer looks like the subject",
+		components:  []string{"makeup", "expression", "hair-style", "style"},
+		blocks:      "MAKEUP and CRITICAL IDENTITY INSTRUCTION",
+		advice:      "heavy makeup and extreme framings pull the face away from the subject most often",
+	},
+	LabelWrongGarmentColor: {
+		description: "a garment came out in the wrong color",
+		components:  []string{"outfit", "over-outfit", "style"},
+		blocks:      "OUTFIT and the style's color grading",
+		advice:      "strong color grading in the style can repaint the outfit; --send-original gives the model the garment itself",
+	},
+	LabelFramingIgnored: {
+		description: "the framing of the style was not followed",
+		components:  []string{"style", "outfit"},
+		blocks:      "PHOTOGRAPHIC STYLE and TECHNICAL REQUIREMENTS (its fixed waist-up framing competes with the style)",
+		advice:      "unusual framings (POV, body-part crops) are the usual culprits",
+	},
+	LabelAccessoryHallucination: {
+		description: "accessories appeared that were not requested",
+		components:  []string{"accessories", "outfit", "style"},
+		blocks:      "ACCESSORIES and OUTFIT",
+		advice:      "long accessory lists invite extras; try --max-accessories",
+	},
+	LabelSafetyRefusal: {
+		description: "the model refused to generate the image",
+		components:  []string{"outfit", "style", "subject"},
+		blocks:      "OUTFIT",
+		advice:      "revealing garments and certain poses trigger refusals most often",
+	},
+	FlagColorMismatch: {
+		description: "the image doesn't match the colors of the style reference",
+		components:  []string{"style"},
+		blocks:      "PHOTOGRAPHIC STYLE",
+		advice:      "raise --color-tolerance for styles with subtle grading, or add a --lut",
+	},
+}
+
+// safetyFinishReasons are finish reasons that mean the model declined the request
+var safetyFinishReasons = map[string]bool{
+	"SAFETY": true, "IMAGE_SAFETY": true, "PROHIBITED_CONTENT": true, "BLOCKLIST": true, "SPII": true,
+}
+
+// failureLabel classifies a failed generation, or returns "" for failures that
+// say nothing about the recipe (network errors, timeouts)
+func failureLabel(err error) string {
+	if safetyFinishReasons[errors.ContextString(err, "finish_reason")] || errors.ContextString(err, "refusal") != "" {
+		return LabelSafetyRefusal
+	}
+	return ""
+}
+
+// reviewOutput runs the review pass on a generated image and flags it with the
+// taxonomy labels it earned. Review failures are logged and never fail the run.
+func (o *Orchestrator) reviewOutput(outputPath, subjectPath string, components map[string]*models.ComponentData, verify VerifyOptions) {
+	if !verify.Review || subjectPath == "" {
+		return
+	}
+	review, err := o.outputReviewer.Review(outputPath, subjectPath, reviewExpectations(components))
+	if err != nil {
+		logger.Warn("Output review failed", "image", filepath.Base(outputPath), "error", err)
+		return
+	}
+
+	labels := classifyReview(review, config.DefaultVerifyConfig().MinIdentityConsistency)
+	if len(labels) > 0 {
+		output.Progress.Printf("      ⚠️  Review: %s\n", strings.Join(labels, ", "))
+	}
+	for _, label := range labels {
+		o.flagForReview(outputPath, label)
+	}
+}
+
+// classifyReview maps a review onto taxonomy labels
+func classifyReview(review *analyzer.OutputReview, minIdentity float64) []string {
+	var labels []string
+	if review.Identity < minIdentity {
+		labels = append(labels, LabelIdentityDrift)
+	}
+	for _, garment := range review.Garments {
+		if !garment.Match {
+			labels = append(labels, LabelWrongGarmentColor)
+			break
+		}
+	}
+	if !review.FramingMatch {
+		labels = append(labels, LabelFramingIgnored)
+	}
+	if len(review.ExtraAccessories) > 0 {
+		labels = append(labels, LabelAccessoryHallucination)
+	}
+	return labels
+}
+
+// reviewExpectations lists what the recipe asked the image to show
+func reviewExpectations(components map[string]*models.ComponentData) analyzer.ReviewExpectations {
+	var expect analyzer.ReviewExpectations
+	for _, name := range []string{"outfit", "over_outfit"} {
+		c := components[name]
+		if c == nil {
+			continue
+		}
+		if terms := vocab.ForAnalysis(c.Type, c.JSONData, c.Description); terms != nil {
+			for _, garment := range terms.Garments {
+				expect.Garments = append(expect.Garments, strings.TrimSpace(garment.Color+" "+garment.Type))
+			}
+		}
+	}
+	if style := components["style"]; style != nil {
+		expect.Framing, _ = altTextScene(style)
+		if style.JSONData == nil {
+			expect.Framing = "" // Only the reference name is known
+		}
+	}
+
+	if accessories := components["accessories"]; accessories != nil {
+		expect.Accessories = accessories.Description
+	} else if outfit := components["outfit"]; outfit != nil && outfit.JSONData != nil {
+		var analysis struct {
+			Accessories []interface{} `json:"accessories"`
+		}
+		json.Unmarshal(outfit.JSONData, &analysis)
+		var items []string
+		for _, item := range analysis.Accessories {
+			if text, ok := item.(string); ok {
+				items = append(items, text)
+			} else if data, err := json.Marshal(item); err == nil {
+				items = append(items, string(data))
+			}
+		}
+		expect.Accessories = strings.Join(items, "; ")
+	}
+	return expect
+}
+
+// FailureSummary aggregates the taxonomy labels of a run
+type FailureSummary struct {
+	Labels      []LabelCount        `json:"labels"`
+	Components  []ComponentFailures `json:"components"`
+	Suggestions []string            `json:"suggestions"`
+}
+
+// LabelCount is how often one label occurred in a run
+type LabelCount struct {
+	Label       string `json:"label"`
+	Description string `json:"description"`
+	Count       int    `json:"count"`
+}
+
+// ComponentFailures counts the labels earned by images that used one input
+type ComponentFailures struct {
+	Component string         `json:"component"` // e.g. outfit
+	Input     string         `json:"input"`     // File name or text
+	Images    int            `json:"images"`    // Outputs and failed generations that used the input
+	Labels    map[string]int `json:"labels"`
+}
+
+// labeledUnit is one output or failed generation with its inputs and labels
+type labeledUnit struct {
+	inputs map[string]string
+	labels []string
+}
+
+// SummarizeFailures counts the taxonomy labels of a run's outputs and failures
+// per label and per component input, and suggests the inputs and prompt blocks
+// most likely at fault. It returns nil when nothing was labeled.
+func SummarizeFailures(result *WorkflowResult) *FailureSummary {
+	var units []labeledUnit
+	for _, step := range result.Steps {
+		if step.Type != "generation" || step.OutputPath == "" || step.Name == ChainArtStyle || step.Name == ChainStyleGuide {
+			continue
+		}
+		units = append(units, labeledUnit{inputs: labelInputs(stepRecipe(step)), labels: knownLabels(step.Flags)})
+	}
+	for _, failure := range result.Failures {
+		var labels []string
+		if failure.Label != "" {
+			labels = []string{failure.Label}
+		}
+		units = append(units, labeledUnit{inputs: labelInputs(failureRecipe(failure)), labels: labels})
+	}
+
+	summary := &FailureSummary{}
+	labelTotals := make(map[string]int)
+	byInput := make(map[string]*ComponentFailures)
+	for _, unit := range units {
+		for _, label := range unit.labels {
+			labelTotals[label]++
+		}
+		for component, input := range unit.inputs {
+			key := component + "=" + input
+			entry, ok := byInput[key]
+			if !ok {
+				entry = &ComponentFailures{Component: component, Input: input, Labels: make(map[string]int)}
+				byInput[key] = entry
+			}
+			entry.Images++
+			for _, label := range unit.labels {
+				entry.Labels[label]++
+			}
+		}
+	}
+	if len(labelTotals) == 0 {
+		return nil
+	}
+
+	for label, count := range labelTotals {
+		summary.Labels = append(summary.Labels, LabelCount{Label: label, Description: failureClasses[label].description, Count: count})
+	}
+	sort.Slice(summary.Labels, func(i, j int) bool {
+		if summary.Labels[i].Count != summary.Labels[j].Count {
+			return summary.Labels[i].Count > summary.Labels[j].Count
+		}
+		return summary.Labels[i].Label < summary.Labels[j].Label
+	})
+
+	for _, entry := range byInput {
+		if len(entry.Labels) > 0 {
+			summary.Components = append(summary.Components, *entry)
+		}
+	}
+	sort.Slice(summary.Components, func(i, j int) bool {
+		a, b := labeledTotal(summary.Components[i]), labeledTotal(summary.Components[j])
+		if a != b {
+			return a > b
+		}
+		if summary.Components[i].Component != summary.Components[j].Component {
+			return summary.Components[i].Component < summary.Components[j].Component
+		}
+		return summary.Components[i].Input < summary.Components[j].Input
+	})
+
+	for _, count := range summary.Labels {
+		summary.Suggestions = append(summary.Suggestions, suggestCulprit(count, byInput, len(units)))
+	}
+	return summary
+}
+
+// suggestCulprit names the input most associated with a label: among the
+// components that usually cause it, the input whose images earned the label
+// at the highest rate, if that rate stands out from the run as a whole
+func suggestCulprit(count LabelCount, byInput map[string]*ComponentFailures, totalUnits int) string {
+	class := failureClasses[count.Label]
+	runRate := float64(count.Count) / float64(max(totalUnits, 1))
+
+	var best *ComponentFailures
+	bestRate := 0.0
+	for _, component := range class.components {
+		for _, entry := range byInput {
+			if entry.Component != component || entry.Labels[count.Label] < 2 {
+				continue
+			}
+			rate := float64(entry.Labels[count.Label]) / float64(entry.Images)
+			if rate > bestRate {
+				best, bestRate = entry, rate
+			}
+		}
+	}
+
+	text := fmt.Sprintf("%s (%d): ", count.Label, count.Count)
+	if best != nil && bestRate > runRate {
+		text += fmt.Sprintf("%d of %d images with %s=%s; ", best.Labels[count.Label], best.Images, best.Component, best.Input)
+	} else {
+		text += "spread across inputs; "
+	}
+	return text + fmt.Sprintf("prompt blocks: %s (%s)", class.blocks, class.advice)
+}
+
+func labeledTotal(entry ComponentFailures) int {
+	total := 0
+	for _, count := range entry.Labels {
+		total += count
+	}
+	return total
+}
+
+func knownLabels(flags []string) []string {
+	var labels []string
+	for _, flag := range flags {
+		if _, ok := failureClasses[flag]; ok {
+			labels = append(labels, flag)
+		}
+	}
+	return labels
+}
+
+// labelComponent finds "component=" markers in a combination label, for
+// every component of RecipeComponents and the ambient
+func labelComponent() *regexp.Regexp {
+	names := append(RecipeComponents(), "ambient")
+	for i, name := range names {
+		names[i] = regexp.QuoteMeta(name)
+	}
+	return regexp.MustCompile(`(?:^|\s)(` + strings.Join(names, "|") + `)=`)
+}
+
+// variationSuffix is the " (variation N)" a failure label ends with
+var variationSuffix = regexp.MustCompile(`\s*\(variation \d+\)$`)
+
+// labelInputs parses a combination label such as
+// "subject=kat.png outfit=red leather jacket style=night.png" into its inputs.
+// Text values may contain spaces, so each value runs to the next marker.
+func labelInputs(label string) map[string]string {
+	label = variationSuffix.ReplaceAllString(label, "")
+	matches := labelComponent().FindAllStringSubmatchIndex(label, -1)
+	inputs := make(map[string]string)
+	for i, m := range matches {
+		end := len(label)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		if value := strings.TrimSpace(label[m[1]:end]); value != "" {
+			inputs[label[m[2]:m[3]]] = value
+		}
+	}
+	return inputs
+}
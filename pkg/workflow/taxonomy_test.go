@@ -0,0 +1,69 @@
+package workflow
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// Outputs and failures of one combination count toward the same input rows,
+// however the failure's combination text names the sources
+func TestSummarizeFailuresKeysInputsByRecipe(t *testing.T) {
+	dir := t.TempDir()
+	inputs := touch(t, dir, "kat.png", "suit.png", "beach.png")
+	recipe := combinedRecipe(inputs[0], inputs[1], inputs[2], "")
+
+	result := &WorkflowResult{
+		Steps: []StepResult{
+			{Type: "generation", Name: "combined", OutputPath: filepath.Join(dir, "out1.png"), Recipe: recipe, Flags: []string{LabelWrongGarmentColor}},
+		},
+		Failures: []Failure{
+			{Combination: "subject=kat.png outfit=suit style=beach (variation 2)", Recipe: recipe, Label: LabelSafetyRefusal},
+		},
+	}
+	summary := SummarizeFailures(result)
+	if summary == nil {
+		t.Fatal("SummarizeFailures returned nil for a labeled run")
+	}
+
+	rows := make(map[string]ComponentFailures)
+	for _, row := range summary.Components {
+		key := row.Component + "=" + row.Input
+		if _, dup := rows[key]; dup {
+			t.Errorf("duplicate row for %s", key)
+		}
+		rows[key] = row
+	}
+	if len(rows) != 3 {
+		t.Errorf("got %d input rows, want 3: %+v", len(rows), summary.Components)
+	}
+	for _, key := range []string{"subject=kat.png", "outfit=suit.png", "style=beach.png"} {
+		row, ok := rows[key]
+		if !ok {
+			t.Errorf("missing row for %s", key)
+			continue
+		}
+		if row.Images != 2 || row.Labels[LabelWrongGarmentColor] != 1 || row.Labels[LabelSafetyRefusal] != 1 {
+			t.Errorf("%s: images %d labels %v, want 2 images with one of each label", key, row.Images, row.Labels)
+		}
+	}
+}
+
+// Failures recorded without a recipe are keyed by their combination text
+func TestSummarizeFailuresFallsBackToCombination(t *testing.T) {
+	result := &WorkflowResult{
+		Failures: []Failure{{Combination: "subject=kat.png outfit=red leather jacket (variation 1)", Label: LabelSafetyRefusal}},
+	}
+	summary := SummarizeFailures(result)
+	if summary == nil {
+		t.Fatal("SummarizeFailures returned nil for a labeled run")
+	}
+	found := false
+	for _, row := range summary.Components {
+		if row.Component == "outfit" && row.Input == "red leather jacket" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("no outfit row for the text outfit: %+v", summary.Components)
+	}
+}
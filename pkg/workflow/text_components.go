@@ -0,0 +1,120 @@
+package workflow
+
+import (
+	"encoding/json"
+	"img-cli/pkg/analyzer"
+	"img-cli/pkg/logger"
+	"img-cli/pkg/models"
+	"img-cli/pkg/output"
+	"strings"
+)
+
+// textComponent builds component data from a free-text description. When text
+// enhancement is enabled and the type supports it, the text is first expanded into
+// the same structured fields an image analysis would produce.
+func (o *Orchestrator) textComponent(componentType, text string, config ModularConfig) *models.ComponentData {
+	component := &models.ComponentData{
+		Type:        componentType,
+		Description: text,
+		Text:        text,
+	}
+
+	if !config.EnhanceText || !o.textEnhancer.Supports(componentType) {
+		output.Progress.Printf("  Using text description for %s: %s\n", componentLabel(componentType), text)
+		return component
+	}
+
+	output.Progress.Printf("  Expanding text description for %s: %s\n", componentLabel(componentType), text)
+	data, err := o.enhanceText(componentType, text)
+	if err != nil {
+		logger.Warn("Text enhancement failed, using text as-is", "type", componentType, "error", err)
+		output.Progress.Printf("    Warning: could not expand description, using it as-is\n")
+		return component
+	}
+
+	var desc string
+	switch componentType {
+	case "hair_style":
+		desc = o.extractHairStyleDescription(data)
+	case "hair_color":
+		desc = o.extractHairColorDescription(data)
+	case "makeup":
+		desc = o.extractMakeupDescription(data)
+	case "expression":
+		desc = o.extractExpressionDescription(data, config.StyleRef != "")
+	case "pose":
+		desc = o.extractPoseDescription(data)
+	case "background":
+		desc = o.extractBackgroundDescription(data)
+	default:
+		desc = describeRegistered(componentType, data)
+	}
+	if desc == "" {
+		return component
+	}
+
+	if config.Debug {
+		output.Printf("  DEBUG: Expanded %s description: %s\n", componentType, desc)
+	}
+	component.Description = desc
+	component.JSONData = data
+	if componentType == "expression" && config.StyleRef != "" {
+		component.Filters = []string{gazeRemovedFilter}
+	}
+	return component
+}
+
+// enhanceText expands a text description, reusing earlier expansions from this run
+// and from the text cache
+func (o *Orchestrator) enhanceText(componentType, text string) (json.RawMessage, error) {
+	key := componentType + "\x00" + text
+
+	o.enhancedMu.Lock()
+	cached, found := o.enhancedText[key]
+	o.enhancedMu.Unlock()
+	if found {
+		return cached, nil
+	}
+
+	c := o.cacheFor(componentType)
+	if c != nil && o.enableCache {
+		if data, found := c.GetText(componentType, text, analyzer.EnhancerPromptVersion(componentType)); found {
+			output.Detail.Printf("    Using cached expansion\n")
+			o.rememberEnhanced(key, data)
+			return data, nil
+		}
+	}
+
+	data, err := o.textEnhancer.Enhance(componentType, text)
+	if err != nil {
+		return nil, err
+	}
+
+	if c != nil && o.enableCache {
+		if err := c.SetText(componentType, text, analyzer.EnhancerPromptVersion(componentType), data); err != nil {
+			logger.Warn("Failed to cache text expansion", "type", componentType, "error", err)
+		}
+	}
+	o.rememberEnhanced(key, data)
+	return data, nil
+}
+
+func (o *Orchestrator) rememberEnhanced(key string, data json.RawMessage) {
+	o.enhancedMu.Lock()
+	o.enhancedText[key] = data
+	o.enhancedMu.Unlock()
+}
+
+// componentLabel returns the human-readable name of a component type
+func componentLabel(componentType string) string {
+	switch componentType {
+	case "hair_style":
+		return "hair style"
+	case "hair_color":
+		return "hair color"
+	case "over_outfit":
+		return "over-outfit"
+	default:
+		return strings.ReplaceAll(componentType, "_", " ")
+	}
+}
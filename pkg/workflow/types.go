@@ -2,6 +2,7 @@ package workflow
 
 import (
 	"encoding/json"
+	"img-cli/pkg/config"
 	"time"
 )
 
@@ -17,28 +18,79 @@ type WorkflowOptions struct {
 	TargetImage     string   // Single target (for backward compatibility)
 	TargetImages    []string // Multiple targets for outfit-swap workflow
 	DebugPrompt     bool
-	SendOriginal    bool   // Include outfit reference image in generation request
+	SendOriginal    bool // Include outfit reference image in generation request
 	Variations      int
 	Prompt          string // For text-to-image generation and naming
 	SkipCostConfirm bool   // Skip cost confirmation prompts (for automation)
+	SkipPreflight   bool   // Skip subject photo validation before the run
 	// Modular component references
-	HairStyleRef   string
-	HairColorRef   string
-	MakeupRef      string
-	ExpressionRef  string
-	AccessoriesRef string
-	OverOutfitRef  string // Base layer outfit that the main outfit is worn over
+	HairStyleRef     string
+	HairColorRef     string
+	MakeupRef        string
+	ExpressionRef    string
+	AccessoriesRef   string
+	PoseRef          string
+	BackgroundRef    string
+	Extra            map[string]string     // Registered components (see analyzer.Register) by analyzer type: image, directory or text
+	Weights          map[string]float64    // Prompt emphasis by component ("outfit", "hair_style", analyzer type, ...; see SplitWeight)
+	OverOutfitRef    string                // Base layer outfit that the main outfit is worn over
+	EnhanceText      bool                  // Expand short text components into structured descriptions
+	StyleBlend       bool                  // StyleReference lists several style images, comma-separated, blended into one style
+	MaxDuration      time.Duration         // Stop launching new combinations after this long (0 = no limit)
+	OutfitCheck      string                // Outfit completeness mode: warn (default), fill or off
+	MaxAccessories   int                   // Keep only the N most important accessories in the prompt (0 = no limit)
+	AllowImplausible bool                  // Generate even when garments clash with the style's scene
+	Pick             bool                  // Choose combinations and per-row variations interactively before launching
+	Sample           int                   // Generate only this many combinations drawn at random from the matrix (0 = all)
+	SampleSeed       int64                 // Seed of the Sample draw; the same seed draws the same combinations
+	Pairwise         bool                  // Generate a small set of combinations covering every pair of component values
+	Ambient          []string              // Lighting/ambient sweep: each combination is generated once per entry
+	Resume           bool                  // Skip combinations whose images are already in OutputDir (see manifest.json)
+	SkipExisting     bool                  // Skip variations already generated anywhere under the output root (see combinationHash)
+	Parallel         int                   // Generations run at once (0 or 1 = one after another)
+	Avoid            []string              // Elements that must not appear in any image
+	Aspect           string                // Aspect ratio such as "16:9" ("" = default 9:16)
+	Resolution       int                   // Long side of saved images in pixels (0 = as generated)
+	Seed             int64                 // Generation seed; variation i of each combination uses Seed+i (0 = random)
+	Sampling         config.SamplingConfig // Generation temperature, top-k and top-p (zero fields keep the defaults)
+	Strength         float64               // How much the subject photo may change, 0-1 (0 = left to the model)
+	NameTemplate     string                // Output file name template, e.g. "{subject}/{outfit}-v{variation:2}" ("" = built-in names)
+	OrganizeBy       string                // Put images in per-component subfolders of OutputDir: subject, outfit or style ("" = none)
+	// Post-processing applied to each generated image
+	Post PostOptions
+	// Automated checks run on each generated image
+	Verify VerifyOptions
+	// Outputs of other generator types driven by the same run
+	Chain ChainOptions
+	// Stop, when set, is checked before each combination; returning true ends
+	// the run early like MaxDuration, with the rest saved to RunStateFile
+	Stop func() bool `json:"-"`
 }
 
 type WorkflowResult struct {
-	Workflow       string       `json:"workflow"`
-	StartTime      time.Time    `json:"start_time"`
-	EndTime        time.Time    `json:"end_time"`
-	Steps          []StepResult `json:"steps"`
-	SubjectCount   int          `json:"subject_count,omitempty"`
-	OutfitCount    int          `json:"outfit_count,omitempty"`
-	StyleCount     int          `json:"style_count,omitempty"`
-	VariationCount int          `json:"variation_count,omitempty"`
+	Workflow       string             `json:"workflow"`
+	StartTime      time.Time          `json:"start_time"`
+	EndTime        time.Time          `json:"end_time"`
+	Steps          []StepResult       `json:"steps"`
+	SubjectCount   int                `json:"subject_count,omitempty"`
+	OutfitCount    int                `json:"outfit_count,omitempty"`
+	StyleCount     int                `json:"style_count,omitempty"`
+	VariationCount int                `json:"variation_count,omitempty"`
+	Stopped        bool               `json:"stopped,omitempty"`     // Run ended early (see run_state.json)
+	Remaining      int                `json:"remaining,omitempty"`   // Combinations not started
+	Consistency    []ConsistencyScore `json:"consistency,omitempty"` // Variation stability per combination
+	Failures       []Failure          `json:"failures,omitempty"`    // Generations that produced no image
+	Taxonomy       *FailureSummary    `json:"taxonomy,omitempty"`    // Failure labels per component, with likely culprits
+}
+
+// Failure records a combination (or one of its variations) that produced no image
+type Failure struct {
+	Combination  string `json:"combination"`
+	Recipe       string `json:"recipe,omitempty"` // Combination without the variation, as recipeLabel names it
+	Error        string `json:"error"`
+	Refusal      string `json:"refusal,omitempty"`       // Text the model returned instead of an image
+	FinishReason string `json:"finish_reason,omitempty"` // e.g. IMAGE_SAFETY
+	Label        string `json:"label,omitempty"`         // Failure taxonomy label, e.g. safety_refusal
 }
 
 type StepResult struct {
@@ -47,4 +99,6 @@ type StepResult struct {
 	Data       json.RawMessage `json:"data,omitempty"`
 	OutputPath string          `json:"output_path,omitempty"`
 	Message    string          `json:"message,omitempty"`
-}
\ No newline at end of file
+	Flags      []string        `json:"flags,omitempty"`  // Failed checks, e.g. color_mismatch
+	Recipe     string          `json:"recipe,omitempty"` // Combination the output was generated from, as recipeLabel names it
+}
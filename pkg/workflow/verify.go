@@ -0,0 +1,63 @@
+package workflow
+
+import (
+	"img-cli/pkg/imaging"
+	"img-cli/pkg/logger"
+	"img-cli/pkg/output"
+	"path/filepath"
+)
+
+// Review flags attached to generated images that failed an automated check
+const (
+	FlagColorMismatch = "color_mismatch" // Output colors don't match the style reference
+)
+
+// VerifyOptions controls the automated checks run on every generated image
+type VerifyOptions struct {
+	ColorCheck     bool    // Compare output color distribution against the style reference
+	ColorTolerance float64 // Maximum allowed color distance (0-1)
+	Consistency    bool    // Score identity and outfit color consistency across each combination's variations
+	Review         bool    // Label each output with the failure taxonomy (one extra cheap API call per image)
+	Judge          bool    // Score outfit fidelity, style fidelity and artifacts, and rank the outputs (one extra cheap API call per image)
+
+	Identity         bool    // Score each output's face against the subject (one extra cheap API call per image)
+	MinIdentityScore float64 // Regenerate outputs scoring below this (0-1); implies Identity
+	IdentityRetries  int     // Regenerations allowed per output below MinIdentityScore
+
+	BestOf int // Generate this many candidates per output and keep the best-scoring one (see pickCandidate)
+}
+
+// verifyOutput runs the enabled checks on a generated image and flags failures for review
+func (o *Orchestrator) verifyOutput(outputPath, styleRef string, verify VerifyOptions) {
+	if verify.ColorCheck && styleRef != "" && isFilePath(styleRef) {
+		distance, err := imaging.CompareColorFiles(outputPath, styleRef)
+		if err != nil {
+			logger.Warn("Color check failed", "image", filepath.Base(outputPath), "error", err)
+		} else if distance > verify.ColorTolerance {
+			output.Progress.Printf("      ⚠️  %s doesn't match the colors of %s (distance %.3f > %.3f)\n",
+				filepath.Base(outputPath), filepath.Base(styleRef), distance, verify.ColorTolerance)
+			o.flagForReview(outputPath, FlagColorMismatch)
+		} else {
+			logger.Debug("Color check passed", "image", filepath.Base(outputPath), "distance", distance)
+		}
+	}
+}
+
+// flagForReview records that an output failed a check
+func (o *Orchestrator) flagForReview(outputPath, flag string) {
+	o.reviewMu.Lock()
+	defer o.reviewMu.Unlock()
+	for _, existing := range o.reviewFlags[outputPath] {
+		if existing == flag {
+			return
+		}
+	}
+	o.reviewFlags[outputPath] = append(o.reviewFlags[outputPath], flag)
+}
+
+// ReviewFlags returns the checks a generated image failed, if any
+func (o *Orchestrator) ReviewFlags(outputPath string) []string {
+	o.reviewMu.Lock()
+	defer o.reviewMu.Unlock()
+	return append([]string(nil), o.reviewFlags[outputPath]...)
+}
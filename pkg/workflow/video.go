@@ -0,0 +1,235 @@
+package workflow
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/logger"
+	"img-cli/pkg/output"
+	"img-cli/pkg/video"
+	"img-cli/pkg/workspace"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// VideoOptions configures a video run on top of its modular recipe
+type VideoOptions struct {
+	Path       string  // Source video
+	FPS        float64 // Frames extracted per second of video
+	MaxFrames  int     // Most frames extracted (0 = all)
+	FramesOnly bool    // Write the image sequence without assembling a video
+}
+
+// ValidateVideoOptions checks --fps and --max-frames
+func ValidateVideoOptions(options VideoOptions) error {
+	if options.FPS <= 0 || options.FPS > 60 {
+		return errors.ErrInvalidInput("fps", "must be above 0 and at most 60")
+	}
+	if options.MaxFrames < 0 {
+		return errors.ErrInvalidInput("max-frames", "must be 0 (every frame) or a positive number")
+	}
+	return nil
+}
+
+// VideoFrame is one extracted frame of a video run
+type VideoFrame struct {
+	Path   string // Extracted frame
+	Hash   string // Content hash; identical frames are generated once
+	Cached string // Generation kept from an earlier run, "" when it must be generated
+}
+
+// VideoPlan is a video run whose frames are extracted and checked against the
+// frame cache, ready to be priced and run
+type VideoPlan struct {
+	Config   ModularConfig // Recipe applied to every frame
+	Options  VideoOptions
+	Frames   []VideoFrame
+	cacheDir string
+}
+
+// PlanVideo extracts the frames of a video and looks each one up in the frame
+// cache. Every frame is generated with the same recipe and seed so the look
+// holds from frame to frame; without --seed one is picked for the whole run.
+func PlanVideo(config ModularConfig, options VideoOptions) (*VideoPlan, error) {
+	dir, err := os.MkdirTemp(workspace.TempDir(), "video-")
+	if err != nil {
+		return nil, errors.Wrap(err, errors.FileError, "failed to create the frame folder")
+	}
+	paths, err := video.Extract(options.Path, dir, options.FPS, options.MaxFrames)
+	if err != nil {
+		return nil, err
+	}
+
+	// Runs without --seed share cached frames whatever seed they picked
+	key, err := videoRecipeKey(config)
+	if err != nil {
+		return nil, err
+	}
+	if config.Seed == 0 {
+		config.Seed = rand.Int63n(MaxSeed) + 1
+	}
+	config.Variations = 1
+
+	plan := &VideoPlan{
+		Config:   config,
+		Options:  options,
+		cacheDir: workspace.ProjectPath(workspace.Dir, "video", key),
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, errors.FileError, "failed to read %s", path)
+		}
+		sum := sha256.Sum256(data)
+		frame := VideoFrame{Path: path, Hash: hex.EncodeToString(sum[:8])}
+		frame.Cached = plan.cached(frame.Hash)
+		plan.Frames = append(plan.Frames, frame)
+	}
+	return plan, nil
+}
+
+// Uncached returns how many images the run generates: one per distinct frame
+// not in the cache
+func (p *VideoPlan) Uncached() int {
+	seen := make(map[string]bool)
+	for _, frame := range p.Frames {
+		if frame.Cached == "" && !seen[frame.Hash] {
+			seen[frame.Hash] = true
+		}
+	}
+	return len(seen)
+}
+
+// cached returns the cached generation of a frame, or ""
+func (p *VideoPlan) cached(hash string) string {
+	matches, _ := filepath.Glob(filepath.Join(p.cacheDir, hash+".*"))
+	if len(matches) == 0 {
+		return ""
+	}
+	return matches[0]
+}
+
+// VideoResult is the outcome of RunVideo
+type VideoResult struct {
+	Video     string   // Assembled video, "" with FramesOnly
+	Frames    []string // Image sequence in order
+	Generated int
+	Cached    int
+	Failed    int // Frames kept as extracted because generation failed
+}
+
+// RunVideo generates every frame of a plan with its recipe, writes them as an
+// image sequence in the output folder and assembles the video. Generated frames
+// go into the frame cache as they finish, so an interrupted run picks up where
+// it stopped.
+func (o *Orchestrator) RunVideo(plan *VideoPlan) (*VideoResult, error) {
+	start := time.Now()
+	outputDir := plan.Config.OutputDir
+	if outputDir == "" {
+		outputDir = generateOutputDir()
+	}
+	framesDir := filepath.Join(outputDir, "frames")
+	if err := os.MkdirAll(framesDir, 0755); err != nil {
+		return nil, errors.Wrapf(err, errors.FileError, "failed to create %s", framesDir)
+	}
+
+	result := &VideoResult{}
+	total := len(plan.Frames)
+	for i, frame := range plan.Frames {
+		source := plan.cached(frame.Hash) // Also finds frames generated earlier in this run
+		switch {
+		case source != "":
+			result.Cached++
+			output.Progress.Printf("🎞️  Frame %d/%d: cached\n", i+1, total)
+		default:
+			output.Progress.Printf("🎞️  Frame %d/%d: generating\n", i+1, total)
+			generated, err := o.generateVideoFrame(plan, frame)
+			if err != nil {
+				logger.Warn("Failed to generate frame, keeping it as extracted", "frame", i+1, "error", err)
+				o.recordFailure(fmt.Sprintf("frame %d", i+1), "", err)
+				result.Failed++
+				source = frame.Path
+				break
+			}
+			result.Generated++
+			source = generated
+		}
+
+		dest := filepath.Join(framesDir, fmt.Sprintf(video.FramePattern, i+1)+strings.ToLower(filepath.Ext(source)))
+		if err := copyFile(source, dest); err != nil {
+			return result, err
+		}
+		result.Frames = append(result.Frames, dest)
+	}
+
+	if !plan.Options.FramesOnly {
+		name := strings.TrimSuffix(filepath.Base(plan.Options.Path), filepath.Ext(plan.Options.Path))
+		result.Video = filepath.Join(outputDir, name+"_swapped.mp4")
+		output.Progress.Printf("🎬 Assembling %d frames at %g fps...\n", len(result.Frames), plan.Options.FPS)
+		if err := video.Assemble(result.Frames, plan.Options.FPS, result.Video); err != nil {
+			return result, err
+		}
+	}
+
+	logger.Info("Video workflow completed",
+		"duration", time.Since(start),
+		"frames", total,
+		"generated", result.Generated,
+		"cached", result.Cached)
+	return result, nil
+}
+
+// generateVideoFrame runs the plan's recipe on one frame and caches the output
+func (o *Orchestrator) generateVideoFrame(plan *VideoPlan, frame VideoFrame) (string, error) {
+	config := plan.Config
+	config.SubjectPath = frame.Path
+	config.OutputDir = filepath.Join(workspace.TempDir(), "video-generated")
+	results, err := o.RunModularWorkflow(config)
+	if err != nil {
+		return "", err
+	}
+	if len(results) == 0 {
+		return "", errors.New(errors.GenerationError, "no image was generated")
+	}
+
+	if err := os.MkdirAll(plan.cacheDir, 0755); err != nil {
+		return "", errors.Wrapf(err, errors.FileError, "failed to create %s", plan.cacheDir)
+	}
+	cached := filepath.Join(plan.cacheDir, frame.Hash+strings.ToLower(filepath.Ext(results[0])))
+	if err := copyFile(results[0], cached); err != nil {
+		return "", err
+	}
+	return cached, nil
+}
+
+// videoRecipeKey identifies what a frame's generation depends on besides the
+// frame itself, naming its folder in the frame cache
+func videoRecipeKey(config ModularConfig) (string, error) {
+	config.SubjectPath = ""
+	config.OutputDir = ""
+	config.Debug = false
+	config.Variations = 0
+	data, err := json.Marshal(config)
+	if err != nil {
+		return "", errors.Wrap(err, errors.InternalError, "failed to encode the video recipe")
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8]), nil
+}
+
+// copyFile copies src to dest
+func copyFile(src, dest string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return errors.Wrapf(err, errors.FileError, "failed to read %s", src)
+	}
+	if err := os.WriteFile(dest, data, 0644); err != nil {
+		return errors.Wrapf(err, errors.FileError, "failed to write %s", dest)
+	}
+	return nil
+}
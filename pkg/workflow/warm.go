@@ -0,0 +1,62 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"img-cli/pkg/concurrent"
+	"img-cli/pkg/output"
+	"img-cli/pkg/workspace"
+	"path/filepath"
+	"sync"
+)
+
+// WarmResult reports what WarmCache did with the images of a directory
+type WarmResult struct {
+	Images   int       // Images found
+	Cached   int       // Images already cached and skipped
+	Analyzed int       // Images analyzed and cached
+	Failures []Failure // Images whose analysis failed
+}
+
+// WarmCache runs the analyzerType analyzer on every image under dir that is
+// not cached yet, up to workers at once, so later runs find the analyses in
+// the per-type cache. The client's rate limits still apply. With
+// SetCacheRefresh every image is analyzed again and its entry overwritten.
+func (o *Orchestrator) WarmCache(dir, analyzerType string, workers int) (*WarmResult, error) {
+	if !IsAnalysisType(analyzerType) {
+		return nil, fmt.Errorf("unknown analysis type: %s", analyzerType)
+	}
+	if !o.enableCache {
+		return nil, fmt.Errorf("the cache is disabled")
+	}
+	o.initializeModularComponents()
+	c := o.cacheFor(analyzerType)
+
+	images := workspace.ListImages(dir)
+	result := &WarmResult{Images: len(images)}
+	var pending []string
+	for _, image := range images {
+		if _, found := c.Get(analyzerType, image); found && !o.refreshCache {
+			result.Cached++
+			continue
+		}
+		pending = append(pending, image)
+	}
+
+	var mu sync.Mutex
+	concurrent.ParallelMap(context.Background(), pending, max(1, workers), func(_ context.Context, image string) (struct{}, error) {
+		_, err := o.AnalyzeImage(analyzerType, image)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			result.Failures = append(result.Failures, Failure{Combination: filepath.Base(image), Error: err.Error()})
+			output.Progress.Printf("  ❌ %s: %v\n", filepath.Base(image), err)
+			return struct{}{}, nil
+		}
+		result.Analyzed++
+		output.Progress.Printf("  ✓ %s (%d/%d)\n", filepath.Base(image), result.Analyzed+len(result.Failures), len(pending))
+		return struct{}{}, nil
+	})
+	return result, nil
+}
@@ -0,0 +1,81 @@
+package workflow
+
+import (
+	"img-cli/pkg/models"
+	"img-cli/pkg/prompts"
+	"strconv"
+	"strings"
+)
+
+// MaxWeight is the largest component weight
+const MaxWeight = 3.0
+
+// SplitWeight splits a ":weight" suffix off a component value, e.g.
+// "outfits/coat.png:1.5". Weights are numbers above 0 up to MaxWeight; 1 is
+// the normal emphasis. Values without a weight return 0. Other suffixes, like
+// the ":9" of "16:9" or the port of a URL, stay part of the value.
+func SplitWeight(value string) (string, float64) {
+	i := strings.LastIndex(value, ":")
+	if i <= 0 {
+		return value, 0
+	}
+	weight, err := strconv.ParseFloat(strings.TrimSpace(value[i+1:]), 64)
+	if err != nil || weight <= 0 || weight > MaxWeight {
+		return value, 0
+	}
+	return value[:i], weight
+}
+
+// emphasisLevel names the prompt emphasis of a component weight: "critical",
+// "strong", "soft", "subtle", or "" for the normal emphasis
+func emphasisLevel(weight float64) string {
+	switch {
+	case weight == 0 || weight == 1:
+		return ""
+	case weight >= 1.5:
+		return "critical"
+	case weight > 1:
+		return "strong"
+	case weight > 0.5:
+		return "soft"
+	default:
+		return "subtle"
+	}
+}
+
+// applyWeights records the weight of each component a config weights
+func applyWeights(components *models.ModularComponents, weights map[string]float64) {
+	for name, c := range modularComponentMap(components) {
+		if c != nil && weights[name] != 0 {
+			c.Weight = weights[name]
+		}
+	}
+}
+
+// promptEmphasis renders the emphasis line of each weighted component with
+// the "emphasis" template, by component name. Components at the normal
+// emphasis have none.
+func promptEmphasis(components *models.ModularComponents) (map[string]string, error) {
+	var emphasis map[string]string
+	for name, c := range modularComponentMap(components) {
+		if c == nil {
+			continue
+		}
+		level := emphasisLevel(c.Weight)
+		if level == "" {
+			continue
+		}
+		if emphasis == nil {
+			emphasis = make(map[string]string)
+		}
+		text, err := prompts.Render("emphasis", struct {
+			Level string
+			Label string
+		}{level, componentLabel(name)})
+		if err != nil {
+			return nil, err
+		}
+		emphasis[name] = strings.TrimSpace(text)
+	}
+	return emphasis, nil
+}
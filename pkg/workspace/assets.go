@@ -0,0 +1,262 @@
+package workspace
+
+import (
+	"fmt"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/logger"
+	"img-cli/pkg/remote"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// AssetDirs maps each component kind to the conventional directory holding its references
+var AssetDirs = map[string]string{
+	"subject":     "subjects",
+	"outfit":      "outfits",
+	"over-outfit": "outfits",
+	"style":       "styles",
+	"hair-style":  "hair-style",
+	"hair-color":  "hair-color",
+	"makeup":      "makeup",
+	"expression":  "expressions",
+	"accessories": "accessories",
+	"pose":        "poses",
+	"background":  "backgrounds",
+}
+
+// textKinds are components that also accept a text description instead of an image
+var textKinds = map[string]bool{
+	"outfit":      true,
+	"over-outfit": true,
+	"hair-style":  true,
+	"hair-color":  true,
+	"makeup":      true,
+	"expression":  true,
+	"accessories": true,
+	"pose":        true,
+	"background":  true,
+	"style":       true,
+}
+
+// RegisterAssetKind adds a component kind (see analyzer.Register) whose
+// references live in dir and which also accepts text descriptions. Call it
+// before resolving assets, typically from an init function.
+func RegisterAssetKind(kind, dir string) {
+	AssetDirs[kind] = dir
+	textKinds[kind] = true
+}
+
+var assetExtensions = map[string]bool{".png": true, ".jpg": true, ".jpeg": true, ".webp": true, ".gif": true}
+
+// ResolveAsset turns a component value into something the workflows accept:
+//   - existing paths are resolved with Resolve
+//   - http(s):// and s3:// locations are downloaded with FetchRemote
+//   - subject names and aliases registered with "img-cli subjects add" are
+//     looked up in the subject registries (see pkg/subjects)
+//   - single-word names ("shearling-black") are looked up by filename stem
+//     in the component's directory, including subfolders, in the active
+//     project first
+//   - anything else is returned unchanged as a text description
+//
+// Unknown names are an error for image-only components (subject) and
+// fall back to a text description for the others; either way close matches
+// are suggested.
+func ResolveAsset(kind, value string) (string, error) {
+	return resolveAsset(kind, value, textKinds[kind])
+}
+
+// ResolveAssetPath is ResolveAsset for call sites that need an image (or
+// directory) and never a text description
+func ResolveAssetPath(kind, value string) (string, error) {
+	return resolveAsset(kind, value, false)
+}
+
+func resolveAsset(kind, value string, allowText bool) (string, error) {
+	if value == "" || exists(value) {
+		return value, nil
+	}
+	if remote.IsRemote(value) {
+		return FetchRemote(value)
+	}
+	if resolved := Resolve(value); resolved != value {
+		return resolved, nil
+	}
+	if allowText && strings.ContainsAny(value, " \t") {
+		return value, nil
+	}
+	if strings.ContainsAny(value, "/\\") || assetExtensions[strings.ToLower(filepath.Ext(value))] {
+		return value, nil
+	}
+	if kind == "subject" {
+		if subject, path := FindSubject(value); subject != nil {
+			logger.Debug("Resolved subject", "name", value, "subject", subject.Name, "path", path)
+			return path, nil
+		}
+	}
+	dir, ok := AssetDirs[kind]
+	if !ok {
+		return value, nil
+	}
+
+	// The active project's folder is searched before the shared one
+	var assets, matches []string
+	want := normalizeName(value)
+	for _, base := range assetSearchDirs(dir) {
+		found := listAssets(base)
+		assets = append(assets, found...)
+		if len(matches) > 0 {
+			continue
+		}
+		for _, asset := range found {
+			if normalizeName(stem(asset)) == want {
+				matches = append(matches, asset)
+			}
+		}
+	}
+
+	switch {
+	case len(matches) == 1:
+		logger.Debug("Resolved asset name", "kind", kind, "name", value, "path", matches[0])
+		return matches[0], nil
+	case len(matches) > 1:
+		return "", errors.ErrInvalidInput(kind, fmt.Sprintf("%q matches several files: %s", value, strings.Join(matches, ", ")))
+	}
+
+	suggestions := suggestAssets(value, assets)
+	if allowText {
+		if len(suggestions) > 0 {
+			logger.Warn("No asset with that name, using it as a text description",
+				"kind", kind, "name", value, "did_you_mean", strings.Join(suggestions, ", "))
+		}
+		return value, nil
+	}
+	reason := fmt.Sprintf("no file or %s/ entry named %q", dir, value)
+	if len(suggestions) > 0 {
+		reason += fmt.Sprintf(" (did you mean %s?)", strings.Join(suggestions, ", "))
+	}
+	return "", errors.ErrInvalidInput(kind, reason)
+}
+
+// assetSearchDirs lists the folders holding a component's references
+func assetSearchDirs(dir string) []string {
+	if ActiveProject() == nil {
+		return []string{Path(dir)}
+	}
+	return []string{ProjectPath(dir), Path(dir)}
+}
+
+// Assets returns the reference images of a component kind, the active
+// project's first, skipping cache folders
+func Assets(kind string) []string {
+	dir, ok := AssetDirs[kind]
+	if !ok {
+		return nil
+	}
+	var assets []string
+	for _, base := range assetSearchDirs(dir) {
+		assets = append(assets, listAssets(base)...)
+	}
+	return assets
+}
+
+// ListImages returns the images under dir and its subfolders, skipping
+// cache folders, sorted by path
+func ListImages(dir string) []string {
+	return listAssets(dir)
+}
+
+// listAssets returns the reference images under dir, skipping cache folders
+func listAssets(dir string) []string {
+	var assets []string
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if d.Name() == "cache" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if assetExtensions[strings.ToLower(filepath.Ext(path))] {
+			assets = append(assets, path)
+		}
+		return nil
+	})
+	sort.Strings(assets)
+	return assets
+}
+
+// suggestAssets returns up to three asset names close to a mistyped name
+func suggestAssets(name string, assets []string) []string {
+	want := normalizeName(name)
+	limit := len(want)/3 + 1
+	if limit < 2 {
+		limit = 2
+	}
+
+	type candidate struct {
+		name     string
+		distance int
+	}
+	var candidates []candidate
+	seen := make(map[string]bool)
+	for _, asset := range assets {
+		s := stem(asset)
+		if seen[s] {
+			continue
+		}
+		seen[s] = true
+		have := normalizeName(s)
+		d := editDistance(want, have)
+		if d <= limit || strings.Contains(have, want) {
+			candidates = append(candidates, candidate{s, d})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].distance < candidates[j].distance })
+
+	var names []string
+	for i := 0; i < len(candidates) && i < 3; i++ {
+		names = append(names, candidates[i].name)
+	}
+	return names
+}
+
+func stem(path string) string {
+	base := filepath.Base(path)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// normalizeName makes "Shearling_Black" and "shearling-black" compare equal
+func normalizeName(name string) string {
+	return strings.NewReplacer("_", "-", " ", "-").Replace(strings.ToLower(name))
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// editDistance is the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,198 @@
+package workspace
+
+import (
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/logger"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Client projects live under ProjectsDir, each with its own outputs, caches
+// and reference folders; their spend is totaled from the usage ledger. Without
+// an active project everything stays in the shared tree at the root.
+
+const (
+	// ProjectsDir holds one folder per project
+	ProjectsDir = "projects"
+
+	projectFile = "project.json"
+	currentFile = "project" // In Dir: the project selected with `project switch`
+)
+
+var projectName = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
+// Project is the metadata of a client project
+type Project struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Created     time.Time `json:"created"`
+	BudgetUSD   float64   `json:"budget_usd,omitempty"` // Total spend allowed (0 = no cap)
+}
+
+var (
+	active   *Project
+	activeMu sync.RWMutex
+)
+
+// CreateProject creates a project folder and its metadata
+func CreateProject(name, description string, budget float64) (*Project, error) {
+	if !projectName.MatchString(name) {
+		return nil, errors.ErrInvalidInput("project", fmt.Sprintf("%q: use lowercase letters, digits, '.', '_' and '-'", name))
+	}
+	if budget < 0 {
+		return nil, errors.ErrInvalidInput("budget", "must be 0 (no cap) or a positive amount")
+	}
+	dir := filepath.Join(Root(), ProjectsDir, name)
+	if _, err := os.Stat(filepath.Join(dir, projectFile)); err == nil {
+		return nil, errors.ErrInvalidInput("project", fmt.Sprintf("%q already exists", name))
+	}
+
+	project := &Project{Name: name, Description: description, Created: time.Now(), BudgetUSD: budget}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.Wrap(err, errors.FileError, "failed to create project directory")
+	}
+	data, err := json.MarshalIndent(project, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, projectFile), data, 0644); err != nil {
+		return nil, errors.Wrap(err, errors.FileError, "failed to write project metadata")
+	}
+	return project, nil
+}
+
+// LoadProject reads a project's metadata
+func LoadProject(name string) (*Project, error) {
+	data, err := os.ReadFile(filepath.Join(Root(), ProjectsDir, name, projectFile))
+	if os.IsNotExist(err) {
+		return nil, errors.ErrInvalidInput("project", fmt.Sprintf("no project named %q (create it with: img-cli project create %s)", name, name))
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, errors.FileError, "failed to read project metadata")
+	}
+	var project Project
+	if err := json.Unmarshal(data, &project); err != nil {
+		return nil, errors.Wrapf(err, errors.FileError, "invalid metadata for project %s", name)
+	}
+	project.Name = name
+	return &project, nil
+}
+
+// Projects lists the projects under the root, sorted by name
+func Projects() ([]Project, error) {
+	entries, err := os.ReadDir(filepath.Join(Root(), ProjectsDir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, errors.FileError, "failed to read projects directory")
+	}
+
+	var projects []Project
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		project, err := LoadProject(entry.Name())
+		if err != nil {
+			continue
+		}
+		projects = append(projects, *project)
+	}
+	sort.Slice(projects, func(i, j int) bool { return projects[i].Name < projects[j].Name })
+	return projects, nil
+}
+
+// SwitchProject makes a project the default for later invocations; "" clears it
+func SwitchProject(name string) error {
+	path := filepath.Join(Root(), Dir, currentFile)
+	if name == "" {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return errors.Wrap(err, errors.FileError, "failed to clear the current project")
+		}
+		return nil
+	}
+	if _, err := LoadProject(name); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrap(err, errors.FileError, "failed to create workspace directory")
+	}
+	return os.WriteFile(path, []byte(name+"\n"), 0644)
+}
+
+// DefaultProject returns the project selected with SwitchProject, if any
+func DefaultProject() string {
+	data, err := os.ReadFile(filepath.Join(Root(), Dir, currentFile))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// UseProject activates a project for this process; "" uses the shared tree
+func UseProject(name string) error {
+	var project *Project
+	if name != "" {
+		var err error
+		if project, err = LoadProject(name); err != nil {
+			return err
+		}
+		logger.Debug("Using project", "project", name)
+	}
+	activeMu.Lock()
+	active = project
+	activeMu.Unlock()
+	return nil
+}
+
+// ActiveProject returns the project in use, or nil for the shared tree
+func ActiveProject() *Project {
+	activeMu.RLock()
+	defer activeMu.RUnlock()
+	return active
+}
+
+// ProjectPath is Path inside the active project's folder, or Path itself
+// without a project. Outputs and caches live here.
+func ProjectPath(elem ...string) string {
+	if project := ActiveProject(); project != nil {
+		return Path(append([]string{ProjectsDir, project.Name}, elem...)...)
+	}
+	return Path(elem...)
+}
+
+// OutputPath is where generated images go: IMG_CLI_OUTPUT_DIR (output_dir in
+// the config file) when set, with a folder per client project, otherwise
+// ProjectPath("output")
+func OutputPath(elem ...string) string {
+	dir := os.Getenv("IMG_CLI_OUTPUT_DIR")
+	if dir == "" {
+		return ProjectPath(append([]string{"output"}, elem...)...)
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(Root(), dir)
+	}
+	if project := ActiveProject(); project != nil {
+		dir = filepath.Join(dir, project.Name)
+	}
+	return relative(filepath.Join(append([]string{dir}, elem...)...))
+}
+
+// AssetDir returns the reference folder for a component: the active project's
+// copy when it has one, otherwise the shared folder at the root
+func AssetDir(dir string) string {
+	if ActiveProject() != nil {
+		if path := ProjectPath(dir); exists(path) {
+			return path
+		}
+	}
+	return Path(dir)
+}
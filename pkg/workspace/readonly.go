@@ -0,0 +1,39 @@
+package workspace
+
+import (
+	"img-cli/pkg/config"
+	"sync"
+)
+
+var (
+	readOnlyOnce   sync.Once
+	readOnlyAssets bool
+)
+
+// SetReadOnlyAssets marks the asset directories read-only for this invocation,
+// on top of IMG_CLI_READONLY_ASSETS
+func SetReadOnlyAssets(readOnly bool) {
+	ReadOnlyAssets() // Read the environment first so it can't override the flag
+	if readOnly {
+		readOnlyAssets = true
+	}
+}
+
+// ReadOnlyAssets reports whether the asset directories (outfits/, styles/, ...)
+// must never be written to, e.g. because they live on a shared network mount
+func ReadOnlyAssets() bool {
+	readOnlyOnce.Do(func() {
+		readOnlyAssets = config.DefaultAssetsConfig().ReadOnly
+	})
+	return readOnlyAssets
+}
+
+// AssetCacheDir returns where analyses of the references in an asset directory
+// are cached: its cache/ subfolder, or cache/<dir> in the project when the
+// assets are read-only
+func AssetCacheDir(dir string) string {
+	if ReadOnlyAssets() {
+		return ProjectPath("cache", dir)
+	}
+	return ProjectPath(dir, "cache")
+}
@@ -0,0 +1,41 @@
+package workspace
+
+import (
+	"img-cli/pkg/config"
+	"img-cli/pkg/remote"
+	"sync"
+)
+
+// blobsDir holds downloaded URL and S3 inputs and the upload records, shared
+// by all runs and projects
+const blobsDir = "blobs"
+
+var (
+	blobsOnce sync.Once
+	blobs     *remote.Store
+)
+
+// FetchRemote downloads a URL or S3 input into the blob store, reusing the
+// stored copy when the remote hasn't changed, and returns its local path
+func FetchRemote(location string) (string, error) {
+	return blobStore().Fetch(location)
+}
+
+// UploadRemote copies a local file to a URL or S3 location and reports
+// whether it was sent; files the location already holds are skipped
+func UploadRemote(localPath, location string) (bool, error) {
+	return blobStore().Upload(localPath, location)
+}
+
+// blobStore opens the shared blob store on first use
+func blobStore() *remote.Store {
+	blobsOnce.Do(func() {
+		cfg := config.DefaultRemoteConfig()
+		dir := cfg.BlobDir
+		if dir == "" {
+			dir = Path(Dir, blobsDir)
+		}
+		blobs = remote.NewStore(dir, cfg)
+	})
+	return blobs
+}
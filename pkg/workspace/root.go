@@ -0,0 +1,103 @@
+package workspace
+
+import (
+	"img-cli/pkg/logger"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ConfigFile marks the root of an img-cli project. Conventional directories
+// (subjects/, outfits/, styles/, output/, ...) live next to it.
+const ConfigFile = ".img-cli.yaml"
+
+var (
+	rootOnce sync.Once
+	root     string
+)
+
+// FindRoot walks up from dir to the nearest directory containing ConfigFile
+func FindRoot(dir string) (string, bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+	for {
+		if info, err := os.Stat(filepath.Join(dir, ConfigFile)); err == nil && !info.IsDir() {
+			return dir, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// Root returns the project root: the nearest ConfigFile above the working
+// directory, then above the executable (for scripts that call the binary by
+// absolute path), falling back to the working directory itself.
+func Root() string {
+	rootOnce.Do(func() {
+		cwd, err := os.Getwd()
+		if err != nil {
+			cwd = "."
+		}
+		if dir, ok := FindRoot(cwd); ok {
+			root = dir
+		} else if exe, err := os.Executable(); err == nil {
+			if dir, ok := FindRoot(filepath.Dir(exe)); ok {
+				root = dir
+			}
+		}
+		if root == "" {
+			root = cwd
+			return
+		}
+		logger.Debug("Using project root", "root", root)
+	})
+	return root
+}
+
+// Path joins elements onto the project root, e.g. Path("subjects"). The result
+// is relative to the working directory when possible so paths in logs and
+// metadata stay short.
+func Path(elem ...string) string {
+	return relative(filepath.Join(append([]string{Root()}, elem...)...))
+}
+
+// Resolve finds a user-supplied path: as given when it exists relative to the
+// working directory (or is absolute), otherwise relative to the active client
+// project's folder and then the project root. Paths that exist in none of
+// these places, and text descriptions, are returned unchanged.
+func Resolve(path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+	if project := ActiveProject(); project != nil {
+		candidate := filepath.Join(Root(), ProjectsDir, project.Name, path)
+		if _, err := os.Stat(candidate); err == nil {
+			return relative(candidate)
+		}
+	}
+	candidate := filepath.Join(Root(), path)
+	if _, err := os.Stat(candidate); err == nil {
+		return relative(candidate)
+	}
+	return path
+}
+
+// relative expresses an absolute path relative to the working directory
+func relative(path string) string {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	if rel, err := filepath.Rel(cwd, path); err == nil {
+		return rel
+	}
+	return path
+}
@@ -0,0 +1,232 @@
+// Package workspace isolates concurrent img-cli invocations that share a project
+// directory (for example teammates on a shared drive). Each invocation gets a run ID
+// and a private temp directory, and holds a project lock while it writes outputs
+// and cache entries.
+package workspace
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"img-cli/pkg/errors"
+	"img-cli/pkg/logger"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// Dir is the per-project state directory
+	Dir = ".img-cli"
+
+	lockFile = "run.lock"
+	runsDir  = "runs"
+
+	// heartbeatInterval is how often a live run refreshes its lock
+	heartbeatInterval = 30 * time.Second
+
+	// staleAfter is how long a lock can go without a heartbeat before it's considered abandoned
+	staleAfter = 2 * time.Minute
+)
+
+// LockInfo is the content of the project lock file
+type LockInfo struct {
+	RunID   string    `json:"run_id"`
+	PID     int       `json:"pid"`
+	Host    string    `json:"host"`
+	Command string    `json:"command"`
+	Started time.Time `json:"started"`
+}
+
+// Run is a single img-cli invocation holding the project lock
+type Run struct {
+	ID      string
+	Command string // Command path the run was started for, e.g. "img-cli outfit-swap"
+	TempDir string
+
+	root     string
+	lockPath string
+	stop     chan struct{}
+	done     sync.WaitGroup
+}
+
+var (
+	current   *Run
+	currentMu sync.Mutex
+)
+
+// Start acquires the project lock under root and creates the run's temp directory.
+// It fails if another live run holds the lock; locks whose owner stopped
+// refreshing them (crash, Ctrl-C, lost drive) are taken over.
+func Start(root, command string) (*Run, error) {
+	stateDir := filepath.Join(root, Dir)
+	if err := os.MkdirAll(stateDir, 0755); err != nil {
+		return nil, errors.Wrap(err, errors.FileError, "failed to create workspace directory")
+	}
+
+	run := &Run{
+		ID:       newRunID(),
+		Command:  command,
+		root:     root,
+		lockPath: filepath.Join(stateDir, lockFile),
+		stop:     make(chan struct{}),
+	}
+
+	host, _ := os.Hostname()
+	info := LockInfo{
+		RunID:   run.ID,
+		PID:     os.Getpid(),
+		Host:    host,
+		Command: command,
+		Started: time.Now(),
+	}
+	if err := acquireLock(run.lockPath, info); err != nil {
+		return nil, err
+	}
+
+	run.TempDir = filepath.Join(stateDir, runsDir, run.ID)
+	if err := os.MkdirAll(run.TempDir, 0755); err != nil {
+		os.Remove(run.lockPath)
+		return nil, errors.Wrap(err, errors.FileError, "failed to create run temp directory")
+	}
+
+	run.done.Add(1)
+	go run.heartbeat()
+
+	currentMu.Lock()
+	current = run
+	currentMu.Unlock()
+
+	logger.Debug("Run started", "run_id", run.ID, "temp_dir", run.TempDir)
+	return run, nil
+}
+
+// Finish releases the project lock. The temp directory is removed on success
+// and kept on failure so partial results can be inspected.
+func (r *Run) Finish(success bool) {
+	close(r.stop)
+	r.done.Wait()
+
+	if success {
+		os.RemoveAll(r.TempDir)
+	} else {
+		logger.Info("Run failed, keeping temp directory", "run_id", r.ID, "temp_dir", r.TempDir)
+	}
+
+	// Only remove the lock if it's still ours
+	if info, err := readLock(r.lockPath); err == nil && info.RunID == r.ID {
+		os.Remove(r.lockPath)
+	}
+
+	currentMu.Lock()
+	if current == r {
+		current = nil
+	}
+	currentMu.Unlock()
+}
+
+// Current returns the active run, or nil outside of a run
+func Current() *Run {
+	currentMu.Lock()
+	defer currentMu.Unlock()
+	return current
+}
+
+// TempDir returns the active run's temp directory, falling back to the system temp dir
+func TempDir() string {
+	if run := Current(); run != nil {
+		return run.TempDir
+	}
+	return os.TempDir()
+}
+
+// heartbeat refreshes the lock's modification time so other runs know we're alive
+func (r *Run) heartbeat() {
+	defer r.done.Done()
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			now := time.Now()
+			if err := os.Chtimes(r.lockPath, now, now); err != nil {
+				logger.Warn("Failed to refresh run lock", "error", err)
+			}
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// acquireLock creates the lock file exclusively, taking over stale locks
+func acquireLock(path string, info LockInfo) error {
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, werr := file.Write(data)
+			cerr := file.Close()
+			if werr != nil {
+				return werr
+			}
+			return cerr
+		}
+		if !os.IsExist(err) {
+			return errors.Wrap(err, errors.FileError, "failed to create run lock")
+		}
+
+		stat, statErr := os.Stat(path)
+		if statErr != nil {
+			continue // Lock disappeared in the meantime, retry
+		}
+		if time.Since(stat.ModTime()) < staleAfter {
+			holder, _ := readLock(path)
+			return lockHeldError(path, holder)
+		}
+
+		logger.Warn("Taking over stale run lock", "path", path, "last_heartbeat", stat.ModTime())
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return errors.Wrap(err, errors.FileError, "failed to remove stale run lock")
+		}
+	}
+
+	return errors.New(errors.FileError, "failed to acquire run lock")
+}
+
+func readLock(path string) (*LockInfo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var info LockInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+func lockHeldError(path string, holder *LockInfo) error {
+	if holder == nil {
+		return errors.Newf(errors.ValidationError,
+			"another img-cli run is active in this project (lock: %s)", path)
+	}
+	return errors.Newf(errors.ValidationError,
+		"another img-cli run is active in this project: '%s' (pid %d on %s, started %s). Wait for it to finish or remove %s if it is no longer running",
+		holder.Command, holder.PID, holder.Host, holder.Started.Format("15:04:05"), path)
+}
+
+// newRunID returns a sortable, collision-resistant run identifier
+func newRunID() string {
+	suffix := make([]byte, 3)
+	if _, err := rand.Read(suffix); err != nil {
+		return time.Now().Format("20060102-150405.000000")
+	}
+	return fmt.Sprintf("%s-%s", time.Now().Format("20060102-150405"), hex.EncodeToString(suffix))
+}
@@ -0,0 +1,50 @@
+package workspace
+
+import (
+	"img-cli/pkg/logger"
+	"img-cli/pkg/subjects"
+)
+
+// SubjectRegistry returns the registry new subjects are added to: the one in
+// the active project's subjects folder, or the shared one without a project
+func SubjectRegistry() (*subjects.Registry, error) {
+	return subjects.Load(ProjectPath(AssetDirs["subject"]))
+}
+
+// SubjectRegistries returns the registries searched for subject names, the
+// active project's first. Unreadable registries are skipped with a warning.
+func SubjectRegistries() []*subjects.Registry {
+	var registries []*subjects.Registry
+	for _, dir := range assetSearchDirs(AssetDirs["subject"]) {
+		registry, err := subjects.Load(dir)
+		if err != nil {
+			logger.Warn("Skipping subject registry", "error", err)
+			continue
+		}
+		registries = append(registries, registry)
+	}
+	return registries
+}
+
+// FindSubject looks a subject name or alias up in the registries and returns
+// the subject with the path of its reference image, or nil when no registry
+// knows the name
+func FindSubject(name string) (*subjects.Subject, string) {
+	for _, registry := range SubjectRegistries() {
+		if subject := registry.Find(name); subject != nil {
+			return subject, registry.ImagePath(subject)
+		}
+	}
+	return nil, ""
+}
+
+// SubjectProfile returns the registered subject whose reference image is
+// path, or nil for unregistered images
+func SubjectProfile(path string) *subjects.Subject {
+	for _, registry := range SubjectRegistries() {
+		if subject := registry.ForImage(path); subject != nil {
+			return subject
+		}
+	}
+	return nil
+}